@@ -403,6 +403,9 @@ func (a *API) RegisterQueryAPI(handler http.Handler, buildInfoHandler http.Handl
 // with the Querier.
 func (a *API) RegisterQueryFrontendHandler(h http.Handler, buildInfoHandler http.Handler) {
 	a.RegisterQueryAPI(h, buildInfoHandler)
+
+	// Query explain is only supported by the query-frontend.
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/query_explain"), h, true, true, "GET", "POST")
 }
 
 func (a *API) RegisterQueryFrontend1(f *frontendv1.Frontend) {
@@ -416,8 +419,12 @@ func (a *API) RegisterQueryFrontend2(f *frontendv2.Frontend) {
 func (a *API) RegisterQueryScheduler(f *scheduler.Scheduler) {
 	a.indexPage.AddLinks(defaultWeight, "Query-scheduler", []IndexPageLink{
 		{Desc: "Ring status", Path: "/query-scheduler/ring"},
+		{Desc: "Queue status", Path: "/scheduler/queue"},
+		{Desc: "Autoscaling signals", Path: "/scheduler/autoscaling"},
 	})
 	a.RegisterRoute("/query-scheduler/ring", http.HandlerFunc(f.RingHandler), false, true, "GET", "POST")
+	a.RegisterRoute("/scheduler/queue", http.HandlerFunc(f.QueueHandler), false, true, "GET", "POST")
+	a.RegisterRoute("/scheduler/autoscaling", http.HandlerFunc(f.AutoscalingHandler), false, true, "GET")
 
 	schedulerpb.RegisterSchedulerForFrontendServer(a.server.GRPC, f)
 	schedulerpb.RegisterSchedulerForQuerierServer(a.server.GRPC, f)
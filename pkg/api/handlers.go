@@ -27,8 +27,8 @@ import (
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/route"
 	"github.com/prometheus/prometheus/config"
-	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/storage"
+	promql_stats "github.com/prometheus/prometheus/util/stats"
 	v1 "github.com/prometheus/prometheus/web/api/v1"
 
 	"github.com/grafana/mimir/pkg/querier"
@@ -199,8 +199,9 @@ func NewQuerierHandler(
 	queryable storage.SampleAndChunkQueryable,
 	exemplarQueryable storage.ExemplarQueryable,
 	metadataSupplier querier.MetadataSupplier,
-	engine *promql.Engine,
+	engine v1.QueryEngine,
 	distributor Distributor,
+	blocksCardinalityAnalyzer querier.BlocksCardinalityAnalyzer,
 	reg prometheus.Registerer,
 	logger log.Logger,
 	limits *validation.Overrides,
@@ -263,7 +264,7 @@ func NewQuerierHandler(
 		// This is used for the stats API which we should not support. Or find other ways to.
 		prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) { return nil, nil }),
 		reg,
-		nil,
+		queryStatsRenderer,
 		remoteWriteEnabled,
 		oltpEnabled,
 	)
@@ -310,14 +311,38 @@ func NewQuerierHandler(
 	router.Path(path.Join(prefix, "/api/v1/label/{name}/values")).Methods("GET").Handler(labelsQueryStats.Wrap(promRouter))
 	router.Path(path.Join(prefix, "/api/v1/series")).Methods("GET", "POST", "DELETE").Handler(seriesQueryStats.Wrap(promRouter))
 	router.Path(path.Join(prefix, "/api/v1/metadata")).Methods("GET").Handler(metadataQueryStats.Wrap(querier.NewMetadataHandler(metadataSupplier)))
-	router.Path(path.Join(prefix, "/api/v1/cardinality/label_names")).Methods("GET", "POST").Handler(cardinalityQueryStats.Wrap(querier.LabelNamesCardinalityHandler(distributor, limits)))
-	router.Path(path.Join(prefix, "/api/v1/cardinality/label_values")).Methods("GET", "POST").Handler(cardinalityQueryStats.Wrap(querier.LabelValuesCardinalityHandler(distributor, limits)))
+	router.Path(path.Join(prefix, "/api/v1/cardinality/label_names")).Methods("GET", "POST").Handler(cardinalityQueryStats.Wrap(querier.LabelNamesCardinalityHandler(distributor, blocksCardinalityAnalyzer, limits)))
+	router.Path(path.Join(prefix, "/api/v1/cardinality/label_values")).Methods("GET", "POST").Handler(cardinalityQueryStats.Wrap(querier.LabelValuesCardinalityHandler(distributor, blocksCardinalityAnalyzer, limits)))
 	router.Path(path.Join(prefix, "/api/v1/format_query")).Methods("GET", "POST").Handler(formattingQueryStats.Wrap(promRouter))
 
 	// Track execution time.
 	return stats.NewWallTimeMiddleware().Wrap(router)
 }
 
+// queryStatsRenderer tracks the number of samples processed by the PromQL engine in the query stats, in total
+// and per step if the per-step stats have been requested, and renders the Prometheus query stats in the response
+// if requested by the client.
+func queryStatsRenderer(ctx context.Context, s *promql_stats.Statistics, param string) promql_stats.QueryStats {
+	if s != nil && s.Samples != nil {
+		queryStats := stats.FromContext(ctx)
+		queryStats.AddSamplesProcessed(uint64(s.Samples.TotalSamples))
+
+		// The samples per step are only tracked by the PromQL engine when requested with stats=all.
+		if perStep := s.Samples.TotalSamplesPerStepMap(); perStep != nil {
+			steps := make([]stats.StepStat, 0, len(*perStep))
+			for ts, samples := range *perStep {
+				steps = append(steps, stats.StepStat{TimestampMs: ts, Value: int64(samples)})
+			}
+			queryStats.AddSamplesProcessedPerStep(steps)
+		}
+	}
+
+	if param != "" {
+		return promql_stats.NewQueryStats(s)
+	}
+	return nil
+}
+
 //go:embed memberlist_status.gohtml
 var memberlistStatusPageHTML string
 
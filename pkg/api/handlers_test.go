@@ -6,14 +6,18 @@
 package api
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	promql_stats "github.com/prometheus/prometheus/util/stats"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/querier/stats"
 )
 
 func TestIndexHandlerPrefix(t *testing.T) {
@@ -197,3 +201,45 @@ func TestConfigOverrideHandler(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, []byte("config"), body)
 }
+
+func TestQueryStatsRenderer(t *testing.T) {
+	samples := promql_stats.NewQuerySamples(false)
+	samples.IncrementSamplesAtTimestamp(0, 42)
+	promStats := &promql_stats.Statistics{Timers: promql_stats.NewQueryTimers(), Samples: samples}
+
+	t.Run("stats not requested by the client", func(t *testing.T) {
+		queryStats, ctx := stats.ContextWithEmptyStats(context.Background())
+
+		assert.Nil(t, queryStatsRenderer(ctx, promStats, ""))
+		assert.Equal(t, uint64(42), queryStats.LoadSamplesProcessed())
+	})
+
+	t.Run("stats requested by the client", func(t *testing.T) {
+		queryStats, ctx := stats.ContextWithEmptyStats(context.Background())
+
+		assert.NotNil(t, queryStatsRenderer(ctx, promStats, "all"))
+		assert.Equal(t, uint64(42), queryStats.LoadSamplesProcessed())
+	})
+
+	t.Run("stats not enabled in the context", func(t *testing.T) {
+		assert.Nil(t, queryStatsRenderer(context.Background(), promStats, ""))
+	})
+
+	t.Run("per-step stats enabled", func(t *testing.T) {
+		perStepSamples := promql_stats.NewQuerySamples(true)
+		perStepSamples.InitStepTracking(0, 20, 10)
+		perStepSamples.IncrementSamplesAtTimestamp(0, 2)
+		perStepSamples.IncrementSamplesAtTimestamp(20, 3)
+		perStepStats := &promql_stats.Statistics{Timers: promql_stats.NewQueryTimers(), Samples: perStepSamples}
+
+		queryStats, ctx := stats.ContextWithEmptyStats(context.Background())
+
+		assert.NotNil(t, queryStatsRenderer(ctx, perStepStats, "all"))
+		assert.Equal(t, uint64(5), queryStats.LoadSamplesProcessed())
+		assert.Equal(t, []stats.StepStat{
+			{TimestampMs: 0, Value: 2},
+			{TimestampMs: 10, Value: 0},
+			{TimestampMs: 20, Value: 3},
+		}, queryStats.LoadSamplesProcessedPerStep())
+	})
+}
@@ -4,6 +4,7 @@ package cardinality
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -14,6 +15,8 @@ import (
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql/parser"
 	"golang.org/x/exp/slices"
+
+	"github.com/grafana/mimir/pkg/util"
 )
 
 type CountMethod string
@@ -23,11 +26,28 @@ const (
 	ActiveMethod   CountMethod = "active"
 )
 
+// Source is the source of the series the cardinality analysis is run on.
+type Source string
+
+const (
+	// IngestersSource runs the cardinality analysis on the in-memory series of the ingesters.
+	IngestersSource Source = "ingesters"
+	// BlocksSource runs the cardinality analysis on the blocks of the long-term storage, served by the store-gateways.
+	BlocksSource Source = "blocks"
+	// AllSource runs the cardinality analysis on both ingesters and blocks, and merges the results.
+	AllSource Source = "all"
+)
+
 const (
 	minLimit           = 0
 	maxLimit           = 500
 	defaultLimit       = 20
 	defaultCountMethod = InMemoryMethod
+	defaultSource      = IngestersSource
+
+	// The default time range queries all the blocks.
+	defaultStart = int64(0)
+	defaultEnd   = int64(math.MaxInt64)
 
 	stringParamSeparator = rune(0)
 	stringValueSeparator = rune(1)
@@ -36,6 +56,12 @@ const (
 type LabelNamesRequest struct {
 	Matchers []*labels.Matcher
 	Limit    int
+	Source   Source
+
+	// Start and End are the time range, in milliseconds, of the blocks to analyze.
+	// They don't apply to the ingesters.
+	Start int64
+	End   int64
 }
 
 // Strings returns a full representation of the request. The returned string can be
@@ -55,6 +81,8 @@ func (r *LabelNamesRequest) String() string {
 	b.WriteRune(stringParamSeparator)
 	b.WriteString(strconv.Itoa(r.Limit))
 
+	writeSourceAndTimeRange(&b, r.Source, r.Start, r.End)
+
 	return b.String()
 }
 
@@ -85,6 +113,11 @@ func DecodeLabelNamesRequestFromValues(values url.Values) (*LabelNamesRequest, e
 		return nil, err
 	}
 
+	parsed.Source, parsed.Start, parsed.End, err = extractSourceAndTimeRange(values)
+	if err != nil {
+		return nil, err
+	}
+
 	return parsed, nil
 }
 
@@ -93,6 +126,12 @@ type LabelValuesRequest struct {
 	Matchers    []*labels.Matcher
 	CountMethod CountMethod
 	Limit       int
+	Source      Source
+
+	// Start and End are the time range, in milliseconds, of the blocks to analyze.
+	// They don't apply to the ingesters.
+	Start int64
+	End   int64
 }
 
 // Strings returns a full representation of the request. The returned string can be
@@ -125,6 +164,8 @@ func (r *LabelValuesRequest) String() string {
 	b.WriteRune(stringParamSeparator)
 	b.WriteString(strconv.Itoa(r.Limit))
 
+	writeSourceAndTimeRange(&b, r.Source, r.Start, r.End)
+
 	return b.String()
 }
 
@@ -165,6 +206,15 @@ func DecodeLabelValuesRequestFromValues(values url.Values) (*LabelValuesRequest,
 		return nil, err
 	}
 
+	parsed.Source, parsed.Start, parsed.End, err = extractSourceAndTimeRange(values)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsed.CountMethod == ActiveMethod && parsed.Source != IngestersSource {
+		return nil, fmt.Errorf("'count_method' param '%v' is only supported with 'source' param '%v'", ActiveMethod, IngestersSource)
+	}
+
 	return parsed, nil
 }
 
@@ -264,3 +314,49 @@ func extractCountMethod(values url.Values) (countMethod CountMethod, err error)
 		return "", fmt.Errorf("invalid 'count_method' param '%v'. valid options are: [%s]", countMethodParams[0], strings.Join([]string{string(ActiveMethod), string(InMemoryMethod)}, ","))
 	}
 }
+
+// extractSourceAndTimeRange parses and validates request params `source`, `start` and `end` if they're defined,
+// otherwise returns default values.
+func extractSourceAndTimeRange(values url.Values) (source Source, start, end int64, err error) {
+	source = defaultSource
+	if sourceParams := values["source"]; len(sourceParams) > 0 {
+		switch Source(sourceParams[0]) {
+		case IngestersSource, BlocksSource, AllSource:
+			source = Source(sourceParams[0])
+		default:
+			return "", 0, 0, fmt.Errorf("invalid 'source' param '%v'. valid options are: [%s]", sourceParams[0], strings.Join([]string{string(BlocksSource), string(IngestersSource), string(AllSource)}, ","))
+		}
+	}
+
+	start, end = defaultStart, defaultEnd
+	if startParams := values["start"]; len(startParams) > 0 {
+		if start, err = util.ParseTime(startParams[0]); err != nil {
+			return "", 0, 0, fmt.Errorf("invalid 'start' param '%v'", startParams[0])
+		}
+	}
+	if endParams := values["end"]; len(endParams) > 0 {
+		if end, err = util.ParseTime(endParams[0]); err != nil {
+			return "", 0, 0, fmt.Errorf("invalid 'end' param '%v'", endParams[0])
+		}
+	}
+	if end < start {
+		return "", 0, 0, fmt.Errorf("'end' param cannot be before 'start' param")
+	}
+
+	return source, start, end, nil
+}
+
+// writeSourceAndTimeRange adds the source and time range to the string representation of a request.
+// They're omitted for the ingesters source, which doesn't honor the time range.
+func writeSourceAndTimeRange(b *strings.Builder, source Source, start, end int64) {
+	if source == "" || source == IngestersSource {
+		return
+	}
+
+	b.WriteRune(stringParamSeparator)
+	b.WriteString(string(source))
+	b.WriteRune(stringParamSeparator)
+	b.WriteString(strconv.FormatInt(start, 10))
+	b.WriteRune(stringParamSeparator)
+	b.WriteString(strconv.FormatInt(end, 10))
+}
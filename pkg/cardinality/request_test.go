@@ -3,6 +3,7 @@
 package cardinality
 
 import (
+	"math"
 	"net/http"
 	"net/url"
 	"strings"
@@ -26,7 +27,10 @@ func TestDecodeLabelNamesRequest(t *testing.T) {
 				labels.MustNewMatcher(labels.MatchEqual, "first", "1"),
 				labels.MustNewMatcher(labels.MatchNotEqual, "second", "2"),
 			},
-			Limit: 100,
+			Limit:  100,
+			Source: IngestersSource,
+			Start:  0,
+			End:    math.MaxInt64,
 		}
 	)
 
@@ -69,6 +73,67 @@ func TestLabelNamesRequest_String(t *testing.T) {
 	}
 
 	assert.Equal(t, "first=\"1\"\x01second!=\"2\"\x00100", req.String())
+
+	req.Source = BlocksSource
+	req.Start = 1000
+	req.End = 2000
+	assert.Equal(t, "first=\"1\"\x01second!=\"2\"\x00100\x00blocks\x001000\x002000", req.String())
+}
+
+func TestDecodeLabelNamesRequest_SourceAndTimeRange(t *testing.T) {
+	tests := map[string]struct {
+		params        url.Values
+		expectedSrc   Source
+		expectedStart int64
+		expectedEnd   int64
+		expectedErr   string
+	}{
+		"defaults": {
+			params:        url.Values{},
+			expectedSrc:   IngestersSource,
+			expectedStart: 0,
+			expectedEnd:   math.MaxInt64,
+		},
+		"blocks with time range": {
+			params:        url.Values{"source": []string{"blocks"}, "start": []string{"1700000000"}, "end": []string{"2023-11-15T00:00:00Z"}},
+			expectedSrc:   BlocksSource,
+			expectedStart: 1700000000000,
+			expectedEnd:   1700006400000,
+		},
+		"all": {
+			params:        url.Values{"source": []string{"all"}},
+			expectedSrc:   AllSource,
+			expectedStart: 0,
+			expectedEnd:   math.MaxInt64,
+		},
+		"invalid source": {
+			params:      url.Values{"source": []string{"unknown"}},
+			expectedErr: "invalid 'source' param 'unknown'",
+		},
+		"invalid start": {
+			params:      url.Values{"start": []string{"yesterday"}},
+			expectedErr: "invalid 'start' param 'yesterday'",
+		},
+		"end before start": {
+			params:      url.Values{"start": []string{"2000"}, "end": []string{"1000"}},
+			expectedErr: "'end' param cannot be before 'start' param",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := DecodeLabelNamesRequestFromValues(tc.params)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedSrc, actual.Source)
+			assert.Equal(t, tc.expectedStart, actual.Start)
+			assert.Equal(t, tc.expectedEnd, actual.End)
+		})
+	}
 }
 
 func TestDecodeLabelValuesRequest(t *testing.T) {
@@ -88,6 +153,9 @@ func TestDecodeLabelValuesRequest(t *testing.T) {
 			},
 			CountMethod: ActiveMethod,
 			Limit:       100,
+			Source:      IngestersSource,
+			Start:       0,
+			End:         math.MaxInt64,
 		}
 	)
 
@@ -133,3 +201,14 @@ func TestLabelValuesRequest_String(t *testing.T) {
 
 	assert.Equal(t, "foo\x01bar\x00first=\"1\"\x01second!=\"2\"\x00active\x00100", req.String())
 }
+
+func TestDecodeLabelValuesRequest_ActiveCountMethodOnlyWithIngesters(t *testing.T) {
+	params := url.Values{
+		"label_names[]": []string{"metric_1"},
+		"count_method":  []string{"active"},
+		"source":        []string{"blocks"},
+	}
+
+	_, err := DecodeLabelValuesRequestFromValues(params)
+	require.EqualError(t, err, "'count_method' param 'active' is only supported with 'source' param 'ingesters'")
+}
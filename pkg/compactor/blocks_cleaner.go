@@ -21,12 +21,14 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
 	"github.com/thanos-io/objstore"
 
 	"github.com/grafana/mimir/pkg/storage/bucket"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
 	"github.com/grafana/mimir/pkg/util"
 	util_log "github.com/grafana/mimir/pkg/util/log"
 	"github.com/grafana/mimir/pkg/util/validation"
@@ -412,8 +414,10 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string, userLogger
 	if idx != nil {
 		// We do not want to stop the remaining work in the cleaner if an
 		// error occurs here. Errors are logged in the function.
-		retention := c.cfgProvider.CompactorBlocksRetentionPeriod(userID)
-		c.applyUserRetentionPeriod(ctx, idx, retention, userBucket, userLogger)
+		for _, resolution := range downsample.Resolutions {
+			retention := c.blocksRetentionPeriod(userID, resolution)
+			c.applyUserRetentionPeriod(ctx, idx, resolution, retention, userBucket, userLogger)
+		}
 	}
 
 	// Generate an updated in-memory version of the bucket index.
@@ -566,14 +570,39 @@ func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, partials map
 	}
 }
 
-// applyUserRetentionPeriod marks blocks for deletion which have aged past the retention period.
-func (c *BlocksCleaner) applyUserRetentionPeriod(ctx context.Context, idx *bucketindex.Index, retention time.Duration, userBucket objstore.Bucket, userLogger log.Logger) {
+// blocksRetentionPeriod returns the retention period of the blocks with the given resolution for a given user.
+// The blocks are retained as long as any retention rule retains their series: the compactor drops the expired
+// series from the blocks meanwhile.
+func (c *BlocksCleaner) blocksRetentionPeriod(userID string, resolution int64) time.Duration {
+	periods := []model.Duration{model.Duration(resolutionRetentionPeriod(c.cfgProvider, userID, resolution))}
+	for _, r := range c.cfgProvider.CompactorRetentionRules(userID) {
+		periods = append(periods, r.Period)
+	}
+	return validation.MaxRetentionPeriod(periods...)
+}
+
+// resolutionRetentionPeriod returns the retention period of the blocks with the given resolution for a given user.
+// The retention period of the raw blocks applies to the downsampled blocks too if downsampling is disabled.
+func resolutionRetentionPeriod(cfgProvider ConfigProvider, userID string, resolution int64) time.Duration {
+	if cfgProvider.CompactorDownsamplingEnabled(userID) {
+		switch resolution {
+		case downsample.ResLevel1:
+			return cfgProvider.CompactorBlocksRetentionPeriod5m(userID)
+		case downsample.ResLevel2:
+			return cfgProvider.CompactorBlocksRetentionPeriod1h(userID)
+		}
+	}
+	return cfgProvider.CompactorBlocksRetentionPeriod(userID)
+}
+
+// applyUserRetentionPeriod marks blocks with the given resolution for deletion which have aged past the retention period.
+func (c *BlocksCleaner) applyUserRetentionPeriod(ctx context.Context, idx *bucketindex.Index, resolution int64, retention time.Duration, userBucket objstore.Bucket, userLogger log.Logger) {
 	// The retention period of zero is a special value indicating to never delete.
 	if retention <= 0 {
 		return
 	}
 
-	blocks := listBlocksOutsideRetentionPeriod(idx, time.Now().Add(-retention))
+	blocks := listBlocksOutsideRetentionPeriod(idx, resolution, time.Now().Add(-retention))
 
 	// Attempt to mark all blocks. It is not critical if a marking fails, as
 	// the cleaner will retry applying the retention in its next cycle.
@@ -583,12 +612,12 @@ func (c *BlocksCleaner) applyUserRetentionPeriod(ctx context.Context, idx *bucke
 			level.Warn(userLogger).Log("msg", "failed to mark block for deletion", "block", b.ID, "err", err)
 		}
 	}
-	level.Info(userLogger).Log("msg", "marked blocks for deletion", "num_blocks", len(blocks), "retention", retention.String())
+	level.Info(userLogger).Log("msg", "marked blocks for deletion", "num_blocks", len(blocks), "retention", retention.String(), "resolution", resolution)
 }
 
-// listBlocksOutsideRetentionPeriod determines the blocks which have aged past
+// listBlocksOutsideRetentionPeriod determines the blocks with the given resolution which have aged past
 // the specified retention period, and are not already marked for deletion.
-func listBlocksOutsideRetentionPeriod(idx *bucketindex.Index, threshold time.Time) (result bucketindex.Blocks) {
+func listBlocksOutsideRetentionPeriod(idx *bucketindex.Index, resolution int64, threshold time.Time) (result bucketindex.Blocks) {
 	// Whilst re-marking a block is not harmful, it is wasteful and generates
 	// a warning log message. Use the block deletion marks already in-memory
 	// to prevent marking blocks already marked for deletion.
@@ -598,6 +627,10 @@ func listBlocksOutsideRetentionPeriod(idx *bucketindex.Index, threshold time.Tim
 	}
 
 	for _, b := range idx.Blocks {
+		if b.Resolution != resolution {
+			continue
+		}
+
 		maxTime := time.Unix(b.MaxTime/1000, 0)
 		if maxTime.Before(threshold) {
 			if _, isMarked := marked[b.ID]; !isMarked {
@@ -31,9 +31,11 @@ import (
 	"github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
 	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/test"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 type testBlocksCleanerOptions struct {
@@ -471,20 +473,20 @@ func TestBlocksCleaner_ListBlocksOutsideRetentionPeriod(t *testing.T) {
 	assert.ElementsMatch(t, []ulid.ULID{id1, id2, id3}, idx.Blocks.GetULIDs())
 
 	// Excessive retention period (wrapping epoch)
-	result := listBlocksOutsideRetentionPeriod(idx, time.Unix(10, 0).Add(-time.Hour))
+	result := listBlocksOutsideRetentionPeriod(idx, 0, time.Unix(10, 0).Add(-time.Hour))
 	assert.ElementsMatch(t, []ulid.ULID{}, result.GetULIDs())
 
 	// Normal operation - varying retention period.
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(6, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, 0, time.Unix(6, 0))
 	assert.ElementsMatch(t, []ulid.ULID{}, result.GetULIDs())
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(7, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, 0, time.Unix(7, 0))
 	assert.ElementsMatch(t, []ulid.ULID{id1}, result.GetULIDs())
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(8, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, 0, time.Unix(8, 0))
 	assert.ElementsMatch(t, []ulid.ULID{id1, id2}, result.GetULIDs())
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(9, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, 0, time.Unix(9, 0))
 	assert.ElementsMatch(t, []ulid.ULID{id1, id2, id3}, result.GetULIDs())
 
 	// Avoiding redundant marking - blocks already marked for deletion.
@@ -494,24 +496,128 @@ func TestBlocksCleaner_ListBlocksOutsideRetentionPeriod(t *testing.T) {
 
 	idx.BlockDeletionMarks = bucketindex.BlockDeletionMarks{mark1}
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(7, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, 0, time.Unix(7, 0))
 	assert.ElementsMatch(t, []ulid.ULID{}, result.GetULIDs())
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(8, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, 0, time.Unix(8, 0))
 	assert.ElementsMatch(t, []ulid.ULID{id2}, result.GetULIDs())
 
 	idx.BlockDeletionMarks = bucketindex.BlockDeletionMarks{mark1, mark2}
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(7, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, 0, time.Unix(7, 0))
 	assert.ElementsMatch(t, []ulid.ULID{}, result.GetULIDs())
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(8, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, 0, time.Unix(8, 0))
 	assert.ElementsMatch(t, []ulid.ULID{}, result.GetULIDs())
 
-	result = listBlocksOutsideRetentionPeriod(idx, time.Unix(9, 0))
+	result = listBlocksOutsideRetentionPeriod(idx, 0, time.Unix(9, 0))
 	assert.ElementsMatch(t, []ulid.ULID{id3}, result.GetULIDs())
 }
 
+func TestBlocksCleaner_ShouldApplyRetentionPeriodByResolution(t *testing.T) {
+	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	bucketClient = block.BucketWithGlobalMarkers(bucketClient)
+
+	ts := func(hours int) int64 {
+		return time.Now().Add(time.Duration(hours)*time.Hour).Unix() * 1000
+	}
+
+	rawBlock := createTSDBBlock(t, bucketClient, "user-1", ts(-10), ts(-8), 2, nil)
+	block5m := createTSDBBlock(t, bucketClient, "user-1", ts(-10), ts(-8), 2, nil)
+	setBlockResolution(t, bucketClient, "user-1", block5m, downsample.ResLevel1)
+	block1h := createTSDBBlock(t, bucketClient, "user-1", ts(-10), ts(-8), 2, nil)
+	setBlockResolution(t, bucketClient, "user-1", block1h, downsample.ResLevel2)
+
+	cfg := BlocksCleanerConfig{
+		DeletionDelay:           time.Hour,
+		CleanupInterval:         time.Minute,
+		CleanupConcurrency:      1,
+		DeleteBlocksConcurrency: 1,
+	}
+
+	ctx := context.Background()
+	cfgProvider := newMockConfigProvider()
+	cleaner := NewBlocksCleaner(cfg, bucketClient, tsdb.AllUsers, cfgProvider, test.NewTestingLogger(t), prometheus.NewPedanticRegistry())
+
+	assertBlockMarked := func(blockID ulid.ULID, expectMarked bool) {
+		exists, err := bucketClient.Exists(ctx, path.Join("user-1", blockID.String(), block.DeletionMarkFilename))
+		require.NoError(t, err)
+		assert.Equal(t, expectMarked, exists, blockID.String())
+	}
+
+	// The retention is only applied once the bucket index exists.
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+
+	// With downsampling enabled, each resolution has its own retention period.
+	cfgProvider.downsamplingEnabled["user-1"] = true
+	cfgProvider.userRetentionPeriods["user-1"] = 7 * time.Hour
+	cfgProvider.userRetentionPeriods5m["user-1"] = 9 * time.Hour
+	cfgProvider.userRetentionPeriods1h["user-1"] = 0
+
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assertBlockMarked(rawBlock, true)
+	assertBlockMarked(block5m, false)
+	assertBlockMarked(block1h, false)
+
+	// With downsampling disabled, the retention period of the raw blocks applies to all blocks.
+	cfgProvider.downsamplingEnabled["user-1"] = false
+
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assertBlockMarked(block5m, true)
+	assertBlockMarked(block1h, true)
+}
+
+func TestBlocksCleaner_ShouldRetainBlocksWithinRetentionRulesPeriod(t *testing.T) {
+	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	bucketClient = block.BucketWithGlobalMarkers(bucketClient)
+
+	ts := func(hours int) int64 {
+		return time.Now().Add(time.Duration(hours)*time.Hour).Unix() * 1000
+	}
+
+	block1 := createTSDBBlock(t, bucketClient, "user-1", ts(-20), ts(-18), 2, nil)
+	block2 := createTSDBBlock(t, bucketClient, "user-1", ts(-10), ts(-8), 2, nil)
+
+	cfg := BlocksCleanerConfig{
+		DeletionDelay:           time.Hour,
+		CleanupInterval:         time.Minute,
+		CleanupConcurrency:      1,
+		DeleteBlocksConcurrency: 1,
+	}
+
+	ctx := context.Background()
+	cfgProvider := newMockConfigProvider()
+	cleaner := NewBlocksCleaner(cfg, bucketClient, tsdb.AllUsers, cfgProvider, test.NewTestingLogger(t), prometheus.NewPedanticRegistry())
+
+	assertBlockMarked := func(blockID ulid.ULID, expectMarked bool) {
+		exists, err := bucketClient.Exists(ctx, path.Join("user-1", blockID.String(), block.DeletionMarkFilename))
+		require.NoError(t, err)
+		assert.Equal(t, expectMarked, exists, blockID.String())
+	}
+
+	rule, err := validation.NewRetentionRule(`{__name__="slo"}`, 12*time.Hour)
+	require.NoError(t, err)
+	unlimitedRule, err := validation.NewRetentionRule(`{__name__="audit"}`, 0)
+	require.NoError(t, err)
+
+	// The retention is only applied once the bucket index exists.
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+
+	// The blocks are retained until no retention rule retains their series.
+	cfgProvider.userRetentionPeriods["user-1"] = 5 * time.Hour
+	cfgProvider.retentionRules["user-1"] = []*validation.RetentionRule{rule, unlimitedRule}
+
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assertBlockMarked(block1, false)
+	assertBlockMarked(block2, false)
+
+	cfgProvider.retentionRules["user-1"] = []*validation.RetentionRule{rule}
+
+	require.NoError(t, cleaner.runCleanupWithErr(ctx))
+	assertBlockMarked(block1, true)
+	assertBlockMarked(block2, false)
+}
+
 func TestBlocksCleaner_ShouldRemoveBlocksOutsideRetentionPeriod(t *testing.T) {
 	bucketClient, _ := mimir_testutil.PrepareFilesystemBucket(t)
 	bucketClient = block.BucketWithGlobalMarkers(bucketClient)
@@ -1035,6 +1141,11 @@ type mockConfigProvider struct {
 	userPartialBlockDelay        map[string]time.Duration
 	userPartialBlockDelayInvalid map[string]bool
 	verifyChunks                 map[string]bool
+	bloomFilterLabelNames        map[string][]string
+	downsamplingEnabled          map[string]bool
+	userRetentionPeriods5m       map[string]time.Duration
+	userRetentionPeriods1h       map[string]time.Duration
+	retentionRules               map[string][]*validation.RetentionRule
 }
 
 func newMockConfigProvider() *mockConfigProvider {
@@ -1048,6 +1159,11 @@ func newMockConfigProvider() *mockConfigProvider {
 		userPartialBlockDelay:        make(map[string]time.Duration),
 		userPartialBlockDelayInvalid: make(map[string]bool),
 		verifyChunks:                 make(map[string]bool),
+		bloomFilterLabelNames:        make(map[string][]string),
+		downsamplingEnabled:          make(map[string]bool),
+		userRetentionPeriods5m:       make(map[string]time.Duration),
+		userRetentionPeriods1h:       make(map[string]time.Duration),
+		retentionRules:               make(map[string][]*validation.RetentionRule),
 	}
 }
 
@@ -1099,6 +1215,26 @@ func (m *mockConfigProvider) CompactorBlockUploadMaxBlockSizeBytes(user string)
 	return m.blockUploadMaxBlockSizeBytes[user]
 }
 
+func (m *mockConfigProvider) CompactorBloomFilterLabelNames(user string) []string {
+	return m.bloomFilterLabelNames[user]
+}
+
+func (m *mockConfigProvider) CompactorDownsamplingEnabled(user string) bool {
+	return m.downsamplingEnabled[user]
+}
+
+func (m *mockConfigProvider) CompactorBlocksRetentionPeriod5m(user string) time.Duration {
+	return m.userRetentionPeriods5m[user]
+}
+
+func (m *mockConfigProvider) CompactorBlocksRetentionPeriod1h(user string) time.Duration {
+	return m.userRetentionPeriods1h[user]
+}
+
+func (m *mockConfigProvider) CompactorRetentionRules(user string) []*validation.RetentionRule {
+	return m.retentionRules[user]
+}
+
 func (m *mockConfigProvider) S3SSEType(string) string {
 	return ""
 }
@@ -401,6 +401,12 @@ func (c *BucketCompactor) runCompactionJob(ctx context.Context, job *Job) (shoul
 			return errors.Wrapf(err, "invalid result block %s", bdir)
 		}
 
+		if len(c.bloomFilterLabelNames) > 0 {
+			if err := block.WriteBloomFilters(ctx, bdir, c.bloomFilterLabelNames, c.bloomFilterFalsePositiveRate); err != nil {
+				return errors.Wrapf(err, "failed to write bloom filters of block %s", bdir)
+			}
+		}
+
 		begin := time.Now()
 		if err := block.Upload(ctx, jobLogger, c.bkt, bdir, nil); err != nil {
 			return errors.Wrapf(err, "upload of %s failed", blockToUpload.ulid)
@@ -685,6 +691,8 @@ type BucketCompactor struct {
 	sortJobs                       JobsOrderFunc
 	waitPeriod                     time.Duration
 	blockSyncConcurrency           int
+	bloomFilterLabelNames          []string
+	bloomFilterFalsePositiveRate   float64
 	metrics                        *BucketCompactorMetrics
 }
 
@@ -703,6 +711,8 @@ func NewBucketCompactor(
 	sortJobs JobsOrderFunc,
 	waitPeriod time.Duration,
 	blockSyncConcurrency int,
+	bloomFilterLabelNames []string,
+	bloomFilterFalsePositiveRate float64,
 	metrics *BucketCompactorMetrics,
 ) (*BucketCompactor, error) {
 	if concurrency <= 0 {
@@ -722,6 +732,8 @@ func NewBucketCompactor(
 		sortJobs:                       sortJobs,
 		waitPeriod:                     waitPeriod,
 		blockSyncConcurrency:           blockSyncConcurrency,
+		bloomFilterLabelNames:          bloomFilterLabelNames,
+		bloomFilterFalsePositiveRate:   bloomFilterFalsePositiveRate,
 		metrics:                        metrics,
 	}, nil
 }
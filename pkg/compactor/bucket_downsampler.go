@@ -0,0 +1,301 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/multierror"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/objstore"
+
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+)
+
+// BucketDownsamplerMetrics holds the metrics tracked by BucketDownsampler.
+type BucketDownsamplerMetrics struct {
+	blocksDownsampled    *prometheus.CounterVec
+	downsamplingFailures *prometheus.CounterVec
+}
+
+// NewBucketDownsamplerMetrics makes a new BucketDownsamplerMetrics.
+func NewBucketDownsamplerMetrics(reg prometheus.Registerer) *BucketDownsamplerMetrics {
+	return &BucketDownsamplerMetrics{
+		blocksDownsampled: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_compactor_blocks_downsampled_total",
+			Help: "Total number of blocks downsampled, by target resolution.",
+		}, []string{"resolution"}),
+		downsamplingFailures: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_compactor_downsampling_failures_total",
+			Help: "Total number of blocks which failed to be downsampled, by target resolution.",
+		}, []string{"resolution"}),
+	}
+}
+
+// BucketDownsampler downsamples the blocks of a tenant in the bucket: the raw blocks compacted to
+// the largest block range are downsampled to downsample.ResLevel1, and these to downsample.ResLevel2.
+type BucketDownsampler struct {
+	logger        log.Logger
+	userID        string
+	sy            *Syncer
+	bkt           objstore.Bucket
+	downsampleDir string
+	largestRange  int64
+	ownJob        ownCompactionJobFunc
+	metrics       *BucketDownsamplerMetrics
+
+	// Allows to mock the current time in tests.
+	now func() time.Time
+}
+
+// NewBucketDownsampler creates a new BucketDownsampler. The blocks are downsampled
+// in downsampleDir, which is removed once done.
+func NewBucketDownsampler(
+	logger log.Logger,
+	userID string,
+	sy *Syncer,
+	bkt objstore.Bucket,
+	downsampleDir string,
+	blockRanges []int64,
+	ownJob ownCompactionJobFunc,
+	metrics *BucketDownsamplerMetrics,
+) *BucketDownsampler {
+	return &BucketDownsampler{
+		logger:        logger,
+		userID:        userID,
+		sy:            sy,
+		bkt:           bkt,
+		downsampleDir: downsampleDir,
+		largestRange:  blockRanges[len(blockRanges)-1],
+		ownJob:        ownJob,
+		metrics:       metrics,
+		now:           time.Now,
+	}
+}
+
+// Downsample downsamples the blocks which are ready to be downsampled, first to downsample.ResLevel1
+// and then to downsample.ResLevel2. Failures to downsample a block don't stop the downsampling of the
+// other blocks, and are returned once done.
+func (d *BucketDownsampler) Downsample(ctx context.Context) (rerr error) {
+	defer func() {
+		// Do not remove the downsampling work directory if an error has occurred, like the bucket compactor.
+		if rerr != nil {
+			return
+		}
+		if err := os.RemoveAll(d.downsampleDir); err != nil {
+			level.Error(d.logger).Log("msg", "failed to remove downsampling work directory", "path", d.downsampleDir, "err", err)
+		}
+	}()
+
+	errs := multierror.New()
+	for i := 1; i < len(downsample.Resolutions); i++ {
+		// Sync the metas at each pass, so that the blocks downsampled by the previous pass are found.
+		if err := d.sy.SyncMetas(ctx); err != nil {
+			return errors.Wrap(err, "sync metas")
+		}
+		if err := d.downsamplePass(ctx, downsample.Resolutions[i-1], downsample.Resolutions[i]); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			errs.Add(err)
+		}
+	}
+
+	// Mark for deletion the downsampled blocks superseded by the ones of this run.
+	if err := d.sy.SyncMetas(ctx); err != nil {
+		return errors.Wrap(err, "sync metas")
+	}
+	if err := d.sy.GarbageCollect(ctx); err != nil {
+		return errors.Wrap(err, "garbage collect")
+	}
+
+	return errs.Err()
+}
+
+func (d *BucketDownsampler) downsamplePass(ctx context.Context, fromResolution, toResolution int64) error {
+	resolutionLabel := strconv.FormatInt(toResolution, 10)
+	errs := multierror.New()
+
+	for _, meta := range d.blocksToDownsample(d.sy.Metas(), fromResolution, toResolution) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Each block is downsampled by a single compactor, like each compaction job is run by a single compactor.
+		job := NewJob(d.userID, "downsample-"+meta.ULID.String(), labels.FromMap(meta.Thanos.Labels), fromResolution, false, 0, fmt.Sprintf("downsample-%s-%d", meta.ULID, toResolution))
+		if ok, err := d.ownJob(job); err != nil {
+			level.Info(d.logger).Log("msg", "skipped downsampling because unable to check whether the block is owned by the compactor instance", "block", meta.ULID, "err", err)
+			continue
+		} else if !ok {
+			continue
+		}
+
+		if err := d.downsampleBlock(ctx, meta, toResolution); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			d.metrics.downsamplingFailures.WithLabelValues(resolutionLabel).Inc()
+			level.Error(d.logger).Log("msg", "failed to downsample block", "block", meta.ULID, "resolution", toResolution, "err", err)
+			errs.Add(errors.Wrapf(err, "downsample block %s to resolution %d", meta.ULID, toResolution))
+			continue
+		}
+		d.metrics.blocksDownsampled.WithLabelValues(resolutionLabel).Inc()
+	}
+
+	return errs.Err()
+}
+
+func (d *BucketDownsampler) downsampleBlock(ctx context.Context, meta *block.Meta, resolution int64) (rerr error) {
+	begin := time.Now()
+	blockDir := filepath.Join(d.downsampleDir, meta.ULID.String())
+	if err := block.Download(ctx, d.logger, d.bkt, meta.ULID, blockDir); err != nil {
+		return errors.Wrap(err, "download block")
+	}
+	defer func() {
+		if err := os.RemoveAll(blockDir); err != nil && rerr == nil {
+			rerr = errors.Wrap(err, "remove downloaded block")
+		}
+	}()
+
+	b, err := tsdb.OpenBlock(d.logger, blockDir, downsample.NewPool())
+	if err != nil {
+		return errors.Wrap(err, "open block")
+	}
+	id, err := downsample.Downsample(ctx, d.logger, meta, b, d.downsampleDir, resolution)
+	if closeErr := b.Close(); closeErr != nil && err == nil {
+		err = errors.Wrap(closeErr, "close block")
+	}
+	if err != nil {
+		return err
+	}
+
+	resultDir := filepath.Join(d.downsampleDir, id.String())
+	defer func() {
+		if err := os.RemoveAll(resultDir); err != nil && rerr == nil {
+			rerr = errors.Wrap(err, "remove downsampled block")
+		}
+	}()
+
+	if err := block.Upload(ctx, d.logger, d.bkt, resultDir, nil); err != nil {
+		return errors.Wrapf(err, "upload downsampled block %s", id)
+	}
+
+	level.Info(d.logger).Log("msg", "downsampled block", "block", meta.ULID, "result_block", id, "resolution", resolution, "duration", time.Since(begin))
+	return nil
+}
+
+// blocksToDownsample returns the blocks with resolution fromResolution which are ready to be downsampled to
+// toResolution, sorted by min time. A block is ready to be downsampled once it has been compacted to the largest
+// block range: it's the only block of its time range, it covers a time range which has ended, and all the blocks of
+// the same time range and external labels (except the shard ID) have a different shard ID. A block isn't downsampled
+// if a block with resolution toResolution and the same external labels already includes all its sources.
+func (d *BucketDownsampler) blocksToDownsample(metas map[ulid.ULID]*block.Meta, fromResolution, toResolution int64) []*block.Meta {
+	type rangeKey struct {
+		labels    string
+		rangeEnd  int64
+		rangeFull bool
+	}
+
+	var (
+		now              = d.now().UnixMilli()
+		candidatesByKey  = map[rangeKey][]*block.Meta{}
+		downsampledByKey = map[string][]*block.Meta{}
+	)
+	for _, meta := range metas {
+		switch meta.Thanos.Downsample.Resolution {
+		case fromResolution:
+			rangeStart := meta.MinTime - mod(meta.MinTime, d.largestRange)
+			rangeEnd := rangeStart + d.largestRange
+			key := rangeKey{labels: labelsWithoutShard(meta.Thanos.Labels).String(), rangeEnd: rangeEnd, rangeFull: meta.MaxTime <= rangeEnd}
+			candidatesByKey[key] = append(candidatesByKey[key], meta)
+		case toResolution:
+			key := labels.FromMap(meta.Thanos.Labels).String()
+			downsampledByKey[key] = append(downsampledByKey[key], meta)
+		}
+	}
+
+	var res []*block.Meta
+	for key, candidates := range candidatesByKey {
+		// Blocks spanning multiple ranges, or whose range hasn't ended yet, haven't been compacted to the largest range.
+		if !key.rangeFull || key.rangeEnd > now {
+			continue
+		}
+		if !haveDistinctShardIDs(candidates) {
+			continue
+		}
+
+		for _, meta := range candidates {
+			if !sourcesIncludedInAny(meta, downsampledByKey[labels.FromMap(meta.Thanos.Labels).String()]) {
+				res = append(res, meta)
+			}
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].MinTime != res[j].MinTime {
+			return res[i].MinTime < res[j].MinTime
+		}
+		return res[i].ULID.Compare(res[j].ULID) < 0
+	})
+	return res
+}
+
+// haveDistinctShardIDs returns whether each block has a shard ID different from the other blocks. Blocks without
+// a shard ID are only allowed when alone.
+func haveDistinctShardIDs(metas []*block.Meta) bool {
+	seen := make(map[string]struct{}, len(metas))
+	for _, meta := range metas {
+		shardID := meta.Thanos.Labels[mimir_tsdb.CompactorShardIDExternalLabel]
+		if _, ok := seen[shardID]; ok || (shardID == "" && len(metas) > 1) {
+			return false
+		}
+		seen[shardID] = struct{}{}
+	}
+	return true
+}
+
+// sourcesIncludedInAny returns whether all the sources of the block are included in the sources of any of the others.
+func sourcesIncludedInAny(meta *block.Meta, others []*block.Meta) bool {
+	for _, other := range others {
+		sources := make(map[ulid.ULID]struct{}, len(other.Compaction.Sources))
+		for _, id := range other.Compaction.Sources {
+			sources[id] = struct{}{}
+		}
+
+		included := true
+		for _, id := range meta.Compaction.Sources {
+			if _, ok := sources[id]; !ok {
+				included = false
+				break
+			}
+		}
+		if included {
+			return true
+		}
+	}
+	return false
+}
+
+// mod returns the non-negative remainder of t divided by r.
+func mod(t, r int64) int64 {
+	m := t % r
+	if m < 0 {
+		m += r
+	}
+	return m
+}
@@ -0,0 +1,228 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+)
+
+func TestBucketDownsampler_BlocksToDownsample(t *testing.T) {
+	const (
+		day  = int64(24 * time.Hour / time.Millisecond)
+		hour = int64(time.Hour / time.Millisecond)
+	)
+
+	newMeta := func(id uint64, minT, maxT, resolution int64, lbls map[string]string, sources ...uint64) *block.Meta {
+		m := &block.Meta{}
+		m.ULID = ulid.MustNew(id, nil)
+		m.MinTime = minT
+		m.MaxTime = maxT
+		m.Thanos.Labels = lbls
+		m.Thanos.Downsample.Resolution = resolution
+		for _, s := range sources {
+			m.Compaction.Sources = append(m.Compaction.Sources, ulid.MustNew(s, nil))
+		}
+		return m
+	}
+	shard := func(id string) map[string]string {
+		return map[string]string{mimir_tsdb.CompactorShardIDExternalLabel: id}
+	}
+
+	tests := map[string]struct {
+		metas          []*block.Meta
+		fromResolution int64
+		toResolution   int64
+		expected       []ulid.ULID
+	}{
+		"block compacted to the largest range": {
+			metas:          []*block.Meta{newMeta(1, 0, day, 0, nil, 1)},
+			fromResolution: downsample.ResLevel0,
+			toResolution:   downsample.ResLevel1,
+			expected:       []ulid.ULID{ulid.MustNew(1, nil)},
+		},
+		"block not compacted to the largest range yet": {
+			metas: []*block.Meta{
+				newMeta(1, 0, 2*hour, 0, nil, 1),
+				newMeta(2, 2*hour, 4*hour, 0, nil, 2),
+			},
+			fromResolution: downsample.ResLevel0,
+			toResolution:   downsample.ResLevel1,
+		},
+		"block of a range which hasn't ended yet": {
+			metas:          []*block.Meta{newMeta(1, 10*day, 11*day, 0, nil, 1)},
+			fromResolution: downsample.ResLevel0,
+			toResolution:   downsample.ResLevel1,
+		},
+		"block spanning multiple ranges": {
+			metas:          []*block.Meta{newMeta(1, day-hour, day+hour, 0, nil, 1)},
+			fromResolution: downsample.ResLevel0,
+			toResolution:   downsample.ResLevel1,
+		},
+		"split blocks compacted to the largest range": {
+			metas: []*block.Meta{
+				newMeta(1, 0, day, 0, shard("1_of_2"), 1, 2),
+				newMeta(2, 0, day, 0, shard("2_of_2"), 1, 2),
+			},
+			fromResolution: downsample.ResLevel0,
+			toResolution:   downsample.ResLevel1,
+			expected:       []ulid.ULID{ulid.MustNew(1, nil), ulid.MustNew(2, nil)},
+		},
+		"split blocks with a block not split yet": {
+			metas: []*block.Meta{
+				newMeta(1, 0, day, 0, shard("1_of_2"), 1, 2),
+				newMeta(2, 0, day, 0, shard("2_of_2"), 1, 2),
+				newMeta(3, 0, 2*hour, 0, nil, 3),
+			},
+			fromResolution: downsample.ResLevel0,
+			toResolution:   downsample.ResLevel1,
+		},
+		"block already downsampled": {
+			metas: []*block.Meta{
+				newMeta(1, 0, day, 0, shard("1_of_2"), 1, 2),
+				newMeta(2, 0, day, 0, shard("2_of_2"), 1, 2),
+				newMeta(3, 0, day, downsample.ResLevel1, shard("1_of_2"), 1, 2),
+			},
+			fromResolution: downsample.ResLevel0,
+			toResolution:   downsample.ResLevel1,
+			expected:       []ulid.ULID{ulid.MustNew(2, nil)},
+		},
+		"block downsampled before more sources were compacted into it": {
+			metas: []*block.Meta{
+				newMeta(1, 0, day, 0, nil, 1, 2, 3),
+				newMeta(2, 0, day, downsample.ResLevel1, nil, 1, 2),
+			},
+			fromResolution: downsample.ResLevel0,
+			toResolution:   downsample.ResLevel1,
+			expected:       []ulid.ULID{ulid.MustNew(1, nil)},
+		},
+		"downsampled blocks are downsampled to the next resolution": {
+			metas: []*block.Meta{
+				newMeta(1, 0, day, 0, nil, 1),
+				newMeta(2, 0, day, downsample.ResLevel1, nil, 1),
+				newMeta(3, day, 2*day, downsample.ResLevel1, nil, 3),
+				newMeta(4, day, 2*day, downsample.ResLevel2, nil, 3),
+			},
+			fromResolution: downsample.ResLevel1,
+			toResolution:   downsample.ResLevel2,
+			expected:       []ulid.ULID{ulid.MustNew(2, nil)},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			metas := map[ulid.ULID]*block.Meta{}
+			for _, m := range testData.metas {
+				metas[m.ULID] = m
+			}
+
+			d := NewBucketDownsampler(log.NewNopLogger(), "user-1", nil, nil, "", []int64{2 * hour, day}, ownAllJobs, nil)
+			d.now = func() time.Time { return time.UnixMilli(10*day + hour) }
+
+			var actual []ulid.ULID
+			for _, m := range d.blocksToDownsample(metas, testData.fromResolution, testData.toResolution) {
+				actual = append(actual, m.ULID)
+			}
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
+func TestBucketDownsampler_Downsample(t *testing.T) {
+	const blockRange = int64(2 * time.Hour / time.Millisecond)
+
+	ctx := context.Background()
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	rawID := createTSDBBlock(t, bkt, "user-1", 0, blockRange, 10, nil)
+	userBkt := bucket.NewUserBucketClient("user-1", bkt, nil)
+
+	reg := prometheus.NewPedanticRegistry()
+	d := NewBucketDownsampler(log.NewNopLogger(), "user-1", newTestSyncer(t, userBkt), userBkt, filepath.Join(t.TempDir(), "downsample"), []int64{blockRange}, ownAllJobs, NewBucketDownsamplerMetrics(reg))
+	require.NoError(t, d.Downsample(ctx))
+
+	metasByResolution := func() map[int64][]*block.Meta {
+		res := map[int64][]*block.Meta{}
+		require.NoError(t, userBkt.Iter(ctx, "", func(name string) error {
+			id, ok := block.IsBlockDir(name)
+			if !ok {
+				return nil
+			}
+			if marked, err := userBkt.Exists(ctx, path.Join(id.String(), block.DeletionMarkFilename)); err != nil || marked {
+				return err
+			}
+			meta, err := block.DownloadMeta(ctx, log.NewNopLogger(), userBkt, id)
+			if err != nil {
+				return err
+			}
+			res[meta.Thanos.Downsample.Resolution] = append(res[meta.Thanos.Downsample.Resolution], &meta)
+			return nil
+		}))
+		return res
+	}
+
+	metas := metasByResolution()
+	require.Len(t, metas[downsample.ResLevel0], 1)
+	require.Len(t, metas[downsample.ResLevel1], 1)
+	require.Len(t, metas[downsample.ResLevel2], 1)
+	for _, res := range []int64{downsample.ResLevel1, downsample.ResLevel2} {
+		assert.Equal(t, []ulid.ULID{rawID}, metas[res][0].Compaction.Sources)
+		assert.Equal(t, int64(0), metas[res][0].MinTime)
+		assert.Equal(t, blockRange, metas[res][0].MaxTime)
+		assert.Equal(t, uint64(10), metas[res][0].Stats.NumSeries)
+	}
+
+	// The blocks already downsampled are not downsampled again.
+	require.NoError(t, d.Downsample(ctx))
+	assert.Equal(t, metas, metasByResolution())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_blocks_downsampled_total Total number of blocks downsampled, by target resolution.
+		# TYPE cortex_compactor_blocks_downsampled_total counter
+		cortex_compactor_blocks_downsampled_total{resolution="300000"} 1
+		cortex_compactor_blocks_downsampled_total{resolution="3600000"} 1
+	`), "cortex_compactor_blocks_downsampled_total", "cortex_compactor_downsampling_failures_total"))
+}
+
+func newTestSyncer(t *testing.T, bkt objstore.InstrumentedBucket) *Syncer {
+	duplicateBlocksFilter := NewShardAwareDeduplicateFilter()
+	metaFetcher, err := block.NewMetaFetcher(nil, 32, bkt, "", nil, []block.MetadataFilter{duplicateBlocksFilter})
+	require.NoError(t, err)
+
+	sy, err := NewMetaSyncer(nil, nil, bkt, metaFetcher, duplicateBlocksFilter, promauto.With(nil).NewCounter(prometheus.CounterOpts{}))
+	require.NoError(t, err)
+	return sy
+}
+
+// setBlockResolution rewrites the meta.json of the block in the bucket with the given downsampling resolution.
+func setBlockResolution(t *testing.T, bkt objstore.Bucket, userID string, blockID ulid.ULID, resolution int64) {
+	userBkt := bucket.NewUserBucketClient(userID, bkt, nil)
+	meta, err := block.DownloadMeta(context.Background(), log.NewNopLogger(), userBkt, blockID)
+	require.NoError(t, err)
+
+	meta.Thanos.Downsample.Resolution = resolution
+
+	var buf bytes.Buffer
+	require.NoError(t, json.NewEncoder(&buf).Encode(&meta))
+	require.NoError(t, userBkt.Upload(context.Background(), path.Join(blockID.String(), block.MetaFilename), &buf))
+}
@@ -0,0 +1,390 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/multierror"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	// retentionRuleHintPrefix is the prefix of the compaction hint recording that a retention rule
+	// has been applied to a block. It's followed by the hash of the rule selector.
+	retentionRuleHintPrefix = "retention-rule-"
+
+	// defaultRetentionHint is the compaction hint recording that the retention period of the block
+	// resolution has been applied to the series of a block not matching any retention rule.
+	defaultRetentionHint = "retention-default"
+)
+
+// BucketRetentionRulesRewriterMetrics holds the metrics tracked by BucketRetentionRulesRewriter.
+type BucketRetentionRulesRewriterMetrics struct {
+	blocksRewritten         prometheus.Counter
+	rewriteFailures         prometheus.Counter
+	seriesDropped           prometheus.Counter
+	blocksMarkedForDeletion prometheus.Counter
+}
+
+// NewBucketRetentionRulesRewriterMetrics makes a new BucketRetentionRulesRewriterMetrics.
+func NewBucketRetentionRulesRewriterMetrics(blocksMarkedForDeletion prometheus.Counter, reg prometheus.Registerer) *BucketRetentionRulesRewriterMetrics {
+	return &BucketRetentionRulesRewriterMetrics{
+		blocksRewritten: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_retention_rules_blocks_rewritten_total",
+			Help: "Total number of blocks rewritten to drop the series whose retention rule period has expired.",
+		}),
+		rewriteFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_retention_rules_rewrite_failures_total",
+			Help: "Total number of blocks which failed to be rewritten to drop the series whose retention rule period has expired.",
+		}),
+		seriesDropped: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_retention_rules_series_dropped_total",
+			Help: "Total number of series dropped from the blocks because their retention rule period has expired.",
+		}),
+		blocksMarkedForDeletion: blocksMarkedForDeletion,
+	}
+}
+
+// BucketRetentionRulesRewriter applies the retention rules of a tenant to the blocks in the bucket: once the
+// period of a rule has expired for all the samples of a block, the block is rewritten without the series whose
+// first matching rule is the expired one. The series not matching any rule are dropped once the retention
+// period of the block resolution has expired, while the block is retained for the longest rule period.
+type BucketRetentionRulesRewriter struct {
+	logger                       log.Logger
+	userID                       string
+	sy                           *Syncer
+	bkt                          objstore.Bucket
+	comp                         Compactor
+	rewriteDir                   string
+	rules                        []*validation.RetentionRule
+	defaultRetention             func(resolution int64) time.Duration
+	bloomFilterLabelNames        []string
+	bloomFilterFalsePositiveRate float64
+	ownJob                       ownCompactionJobFunc
+	metrics                      *BucketRetentionRulesRewriterMetrics
+
+	// Allows to mock the current time in tests.
+	now func() time.Time
+}
+
+// NewBucketRetentionRulesRewriter creates a new BucketRetentionRulesRewriter. The blocks are rewritten
+// in rewriteDir, which is removed once done. The defaultRetention returns the retention period of the
+// series not matching any rule for the blocks of the given resolution.
+func NewBucketRetentionRulesRewriter(
+	logger log.Logger,
+	userID string,
+	sy *Syncer,
+	bkt objstore.Bucket,
+	comp Compactor,
+	rewriteDir string,
+	rules []*validation.RetentionRule,
+	defaultRetention func(resolution int64) time.Duration,
+	bloomFilterLabelNames []string,
+	bloomFilterFalsePositiveRate float64,
+	ownJob ownCompactionJobFunc,
+	metrics *BucketRetentionRulesRewriterMetrics,
+) *BucketRetentionRulesRewriter {
+	return &BucketRetentionRulesRewriter{
+		logger:                       logger,
+		userID:                       userID,
+		sy:                           sy,
+		bkt:                          bkt,
+		comp:                         comp,
+		rewriteDir:                   rewriteDir,
+		rules:                        rules,
+		defaultRetention:             defaultRetention,
+		bloomFilterLabelNames:        bloomFilterLabelNames,
+		bloomFilterFalsePositiveRate: bloomFilterFalsePositiveRate,
+		ownJob:                       ownJob,
+		metrics:                      metrics,
+		now:                          time.Now,
+	}
+}
+
+// Rewrite rewrites the blocks with retention rules not applied yet whose period has expired. Failures to
+// rewrite a block don't stop the rewriting of the other blocks, and are returned once done.
+func (r *BucketRetentionRulesRewriter) Rewrite(ctx context.Context) (rerr error) {
+	defer func() {
+		// Do not remove the rewriting work directory if an error has occurred, like the bucket compactor.
+		if rerr != nil {
+			return
+		}
+		if err := os.RemoveAll(r.rewriteDir); err != nil {
+			level.Error(r.logger).Log("msg", "failed to remove retention rules work directory", "path", r.rewriteDir, "err", err)
+		}
+	}()
+
+	if err := r.sy.SyncMetas(ctx); err != nil {
+		return errors.Wrap(err, "sync metas")
+	}
+
+	errs := multierror.New()
+	for _, meta := range r.blocksToRewrite(r.sy.Metas()) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Each block is rewritten by a single compactor, like each compaction job is run by a single compactor.
+		job := NewJob(r.userID, "retention-"+meta.ULID.String(), labels.FromMap(meta.Thanos.Labels), meta.Thanos.Downsample.Resolution, false, 0, "retention-"+meta.ULID.String())
+		if ok, err := r.ownJob(job); err != nil {
+			level.Info(r.logger).Log("msg", "skipped applying retention rules because unable to check whether the block is owned by the compactor instance", "block", meta.ULID, "err", err)
+			continue
+		} else if !ok {
+			continue
+		}
+
+		if err := r.rewriteBlock(ctx, meta, r.blockRetention(meta)); err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			r.metrics.rewriteFailures.Inc()
+			level.Error(r.logger).Log("msg", "failed to apply retention rules to block", "block", meta.ULID, "err", err)
+			errs.Add(errors.Wrapf(err, "apply retention rules to block %s", meta.ULID))
+			continue
+		}
+		r.metrics.blocksRewritten.Inc()
+	}
+
+	return errs.Err()
+}
+
+// blocksToRewrite returns the blocks which have expired retention rules not applied yet, sorted by min time.
+func (r *BucketRetentionRulesRewriter) blocksToRewrite(metas map[ulid.ULID]*block.Meta) []*block.Meta {
+	var res []*block.Meta
+	for _, meta := range metas {
+		applied := make(map[string]struct{}, len(meta.Compaction.Hints))
+		for _, h := range meta.Compaction.Hints {
+			applied[h] = struct{}{}
+		}
+
+		for _, h := range r.blockRetention(meta).hints() {
+			if _, ok := applied[h]; !ok {
+				res = append(res, meta)
+				break
+			}
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].MinTime != res[j].MinTime {
+			return res[i].MinTime < res[j].MinTime
+		}
+		return res[i].ULID.Compare(res[j].ULID) < 0
+	})
+	return res
+}
+
+// blockRetention returns the retention rules whose period has expired for all the samples of the block.
+func (r *BucketRetentionRulesRewriter) blockRetention(meta *block.Meta) blockRetention {
+	now := r.now()
+	expired := func(period time.Duration) bool {
+		// The block max time is exclusive.
+		return period > 0 && meta.MaxTime <= now.Add(-period).UnixMilli()
+	}
+
+	res := blockRetention{
+		rules:          r.rules,
+		expiredRules:   map[*validation.RetentionRule]struct{}{},
+		defaultExpired: expired(r.defaultRetention(meta.Thanos.Downsample.Resolution)),
+	}
+	for _, rule := range r.rules {
+		if expired(time.Duration(rule.Period)) {
+			res.expiredRules[rule] = struct{}{}
+		}
+	}
+	return res
+}
+
+func (r *BucketRetentionRulesRewriter) rewriteBlock(ctx context.Context, meta *block.Meta, retention blockRetention) (rerr error) {
+	begin := time.Now()
+	blockDir := filepath.Join(r.rewriteDir, meta.ULID.String())
+	if err := block.Download(ctx, r.logger, r.bkt, meta.ULID, blockDir); err != nil {
+		return errors.Wrap(err, "download block")
+	}
+	defer func() {
+		if err := os.RemoveAll(blockDir); err != nil && rerr == nil {
+			rerr = errors.Wrap(err, "remove downloaded block")
+		}
+	}()
+
+	// The expired series are deleted through tombstones, which the compactor honours when writing the new block.
+	b, err := tsdb.OpenBlock(r.logger, blockDir, downsample.NewPool())
+	if err != nil {
+		return errors.Wrap(err, "open block")
+	}
+	stones, dropped, err := expiredSeriesTombstones(ctx, b, retention)
+	if closeErr := b.Close(); closeErr != nil && err == nil {
+		err = errors.Wrap(closeErr, "close block")
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := tombstones.WriteFile(r.logger, blockDir, stones); err != nil {
+		return errors.Wrap(err, "write tombstones")
+	}
+
+	id, err := r.comp.Compact(r.rewriteDir, []string{blockDir}, nil)
+	if err != nil {
+		return errors.Wrap(err, "rewrite block")
+	}
+	r.metrics.seriesDropped.Add(float64(dropped))
+
+	if id == (ulid.ULID{}) {
+		level.Info(r.logger).Log("msg", "all the series of the block have expired, deleting the block", "block", meta.ULID, "dropped_series", dropped)
+		return deleteBlock(r.bkt, meta.ULID, blockDir, r.logger, r.metrics.blocksMarkedForDeletion)
+	}
+
+	resultDir := filepath.Join(r.rewriteDir, id.String())
+	defer func() {
+		if err := os.RemoveAll(resultDir); err != nil && rerr == nil {
+			rerr = errors.Wrap(err, "remove rewritten block")
+		}
+	}()
+
+	newMeta, err := block.InjectThanosMeta(r.logger, resultDir, block.ThanosMeta{
+		Labels:       meta.Thanos.Labels,
+		Downsample:   meta.Thanos.Downsample,
+		Source:       block.CompactorSource,
+		SegmentFiles: block.GetSegmentFiles(resultDir),
+	}, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to finalize the block %s", resultDir)
+	}
+
+	// Record the applied retention rules, so that the block isn't rewritten again.
+	newMeta.Compaction.Hints = mergeHints(meta.Compaction.Hints, retention.hints())
+	if err := newMeta.WriteToDir(r.logger, resultDir); err != nil {
+		return errors.Wrapf(err, "failed to write the meta of block %s", resultDir)
+	}
+
+	if err = os.Remove(filepath.Join(resultDir, "tombstones")); err != nil {
+		return errors.Wrap(err, "remove tombstones")
+	}
+
+	if err := block.VerifyBlock(ctx, r.logger, resultDir, newMeta.MinTime, newMeta.MaxTime, false); err != nil {
+		return errors.Wrapf(err, "invalid result block %s", resultDir)
+	}
+
+	if len(r.bloomFilterLabelNames) > 0 {
+		if err := block.WriteBloomFilters(ctx, resultDir, r.bloomFilterLabelNames, r.bloomFilterFalsePositiveRate); err != nil {
+			return errors.Wrapf(err, "failed to write bloom filters of block %s", resultDir)
+		}
+	}
+
+	if err := block.Upload(ctx, r.logger, r.bkt, resultDir, nil); err != nil {
+		return errors.Wrapf(err, "upload rewritten block %s", id)
+	}
+
+	if err := deleteBlock(r.bkt, meta.ULID, blockDir, r.logger, r.metrics.blocksMarkedForDeletion); err != nil {
+		return errors.Wrap(err, "mark old block for deletion from bucket")
+	}
+
+	level.Info(r.logger).Log("msg", "applied retention rules to block", "block", meta.ULID, "result_block", id, "dropped_series", dropped, "duration", time.Since(begin))
+	return nil
+}
+
+// expiredSeriesTombstones returns the tombstones deleting the expired series of the block, and their number.
+func expiredSeriesTombstones(ctx context.Context, b *tsdb.Block, retention blockRetention) (*tombstones.MemTombstones, int, error) {
+	ir, err := b.Index()
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "open index")
+	}
+	defer ir.Close()
+
+	name, value := index.AllPostingsKey()
+	postings, err := ir.Postings(ctx, name, value)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "read postings")
+	}
+
+	var (
+		stones  = tombstones.NewMemTombstones()
+		dropped = 0
+		builder labels.ScratchBuilder
+	)
+	for postings.Next() {
+		ref := postings.At()
+		if err := ir.Series(ref, &builder, nil); err != nil {
+			return nil, 0, errors.Wrapf(err, "read series %d", ref)
+		}
+		if retention.seriesExpired(builder.Labels()) {
+			stones.AddInterval(storage.SeriesRef(ref), tombstones.Interval{Mint: math.MinInt64, Maxt: math.MaxInt64})
+			dropped++
+		}
+	}
+	if err := postings.Err(); err != nil {
+		return nil, 0, errors.Wrap(err, "iterate postings")
+	}
+	return stones, dropped, nil
+}
+
+// blockRetention holds the retention rules whose period has expired for all the samples of a block.
+type blockRetention struct {
+	rules          []*validation.RetentionRule
+	expiredRules   map[*validation.RetentionRule]struct{}
+	defaultExpired bool
+}
+
+// seriesExpired returns whether the retention period of the series with the given labels has expired.
+func (b blockRetention) seriesExpired(series labels.Labels) bool {
+	if rule := validation.MatchingRetentionRule(b.rules, series); rule != nil {
+		_, ok := b.expiredRules[rule]
+		return ok
+	}
+	return b.defaultExpired
+}
+
+// hints returns the compaction hints recording the expired retention rules.
+func (b blockRetention) hints() []string {
+	var hints []string
+	for _, rule := range b.rules {
+		if _, ok := b.expiredRules[rule]; ok {
+			hints = append(hints, retentionRuleHint(rule))
+		}
+	}
+	if b.defaultExpired {
+		hints = append(hints, defaultRetentionHint)
+	}
+	return hints
+}
+
+func retentionRuleHint(rule *validation.RetentionRule) string {
+	return retentionRuleHintPrefix + strconv.FormatUint(xxhash.Sum64String(rule.Selector), 16)
+}
+
+// mergeHints returns the sorted union of the given compaction hints.
+func mergeHints(a, b []string) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	res := make([]string, 0, len(a)+len(b))
+	for _, h := range append(append([]string{}, a...), b...) {
+		if _, ok := seen[h]; !ok {
+			seen[h] = struct{}{}
+			res = append(res, h)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package compactor
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	mimir_testutil "github.com/grafana/mimir/pkg/storage/tsdb/testutil"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestBucketRetentionRulesRewriter_Rewrite(t *testing.T) {
+	const blockRange = int64(2 * time.Hour / time.Millisecond)
+
+	ctx := context.Background()
+	bkt, _ := mimir_testutil.PrepareFilesystemBucket(t)
+	createTSDBBlock(t, bkt, "user-1", 0, blockRange, 10, nil)
+	userBkt := bucket.NewUserBucketClient("user-1", bkt, nil)
+
+	// Downsample the block, so that the retention rules are applied to the blocks of all resolutions.
+	d := NewBucketDownsampler(log.NewNopLogger(), "user-1", newTestSyncer(t, userBkt), userBkt, filepath.Join(t.TempDir(), "downsample"), []int64{blockRange}, ownAllJobs, NewBucketDownsamplerMetrics(nil))
+	require.NoError(t, d.Downsample(ctx))
+
+	comp, err := tsdb.NewLeveledCompactor(ctx, nil, log.NewNopLogger(), []int64{blockRange}, downsample.NewPool(), nil, true)
+	require.NoError(t, err)
+
+	shortRule, err := validation.NewRetentionRule(`{series_id=~"[0-2]"}`, time.Hour)
+	require.NoError(t, err)
+	longRule, err := validation.NewRetentionRule(`{series_id=~"[2-5]"}`, 100*time.Hour)
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	r := NewBucketRetentionRulesRewriter(
+		log.NewNopLogger(),
+		"user-1",
+		newTestSyncer(t, userBkt),
+		userBkt,
+		comp,
+		filepath.Join(t.TempDir(), "retention"),
+		[]*validation.RetentionRule{shortRule, longRule},
+		func(int64) time.Duration { return 5 * time.Hour },
+		nil,
+		0,
+		ownAllJobs,
+		NewBucketRetentionRulesRewriterMetrics(promauto.With(nil).NewCounter(prometheus.CounterOpts{}), reg),
+	)
+
+	metasByResolution := func() map[int64]*block.Meta {
+		res := map[int64]*block.Meta{}
+		require.NoError(t, userBkt.Iter(ctx, "", func(name string) error {
+			id, ok := block.IsBlockDir(name)
+			if !ok {
+				return nil
+			}
+			if marked, err := userBkt.Exists(ctx, path.Join(id.String(), block.DeletionMarkFilename)); err != nil || marked {
+				return err
+			}
+			meta, err := block.DownloadMeta(ctx, log.NewNopLogger(), userBkt, id)
+			if err != nil {
+				return err
+			}
+			require.NotContains(t, res, meta.Thanos.Downsample.Resolution)
+			res[meta.Thanos.Downsample.Resolution] = &meta
+			return nil
+		}))
+		return res
+	}
+	assertMetas := func(expectedSeries uint64, expectedHints []string) {
+		metas := metasByResolution()
+		require.Len(t, metas, len(downsample.Resolutions))
+		for _, meta := range metas {
+			assert.Equal(t, expectedSeries, meta.Stats.NumSeries)
+			assert.Equal(t, expectedHints, meta.Compaction.Hints)
+			assert.Equal(t, int64(0), meta.MinTime)
+			assert.Equal(t, blockRange, meta.MaxTime)
+		}
+	}
+
+	// Nothing has expired yet.
+	r.now = func() time.Time { return time.UnixMilli(blockRange).Add(30 * time.Minute) }
+	require.NoError(t, r.Rewrite(ctx))
+	assertMetas(10, nil)
+
+	// The series whose first matching rule is the short one have expired.
+	r.now = func() time.Time { return time.UnixMilli(blockRange).Add(2 * time.Hour) }
+	require.NoError(t, r.Rewrite(ctx))
+	assertMetas(7, []string{retentionRuleHint(shortRule)})
+
+	// The blocks aren't rewritten again for the same rules.
+	before := metasByResolution()
+	require.NoError(t, r.Rewrite(ctx))
+	assert.Equal(t, before, metasByResolution())
+
+	// The series not matching any rule have expired too.
+	r.now = func() time.Time { return time.UnixMilli(blockRange).Add(6 * time.Hour) }
+	require.NoError(t, r.Rewrite(ctx))
+	assertMetas(3, []string{defaultRetentionHint, retentionRuleHint(shortRule)})
+
+	// All the series have expired: the blocks are deleted.
+	r.now = func() time.Time { return time.UnixMilli(blockRange).Add(101 * time.Hour) }
+	require.NoError(t, r.Rewrite(ctx))
+	assert.Empty(t, metasByResolution())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_retention_rules_blocks_rewritten_total Total number of blocks rewritten to drop the series whose retention rule period has expired.
+		# TYPE cortex_compactor_retention_rules_blocks_rewritten_total counter
+		cortex_compactor_retention_rules_blocks_rewritten_total 9
+		# HELP cortex_compactor_retention_rules_rewrite_failures_total Total number of blocks which failed to be rewritten to drop the series whose retention rule period has expired.
+		# TYPE cortex_compactor_retention_rules_rewrite_failures_total counter
+		cortex_compactor_retention_rules_rewrite_failures_total 0
+		# HELP cortex_compactor_retention_rules_series_dropped_total Total number of series dropped from the blocks because their retention rule period has expired.
+		# TYPE cortex_compactor_retention_rules_series_dropped_total counter
+		cortex_compactor_retention_rules_series_dropped_total 30
+	`), "cortex_compactor_retention_rules_blocks_rewritten_total", "cortex_compactor_retention_rules_rewrite_failures_total", "cortex_compactor_retention_rules_series_dropped_total"))
+}
+
+func TestBlockRetention(t *testing.T) {
+	rule1, err := validation.NewRetentionRule(`{__name__="debug"}`, time.Hour)
+	require.NoError(t, err)
+	rule2, err := validation.NewRetentionRule(`{__name__=~"debug|slo"}`, 0)
+	require.NoError(t, err)
+
+	r := NewBucketRetentionRulesRewriter(log.NewNopLogger(), "user-1", nil, nil, nil, "", []*validation.RetentionRule{rule1, rule2}, func(int64) time.Duration { return 3 * time.Hour }, nil, 0, ownAllJobs, nil)
+	r.now = func() time.Time { return time.UnixMilli(4 * time.Hour.Milliseconds()) }
+
+	newMeta := func(maxT int64) *block.Meta {
+		m := &block.Meta{}
+		m.MaxTime = maxT
+		return m
+	}
+
+	retention := r.blockRetention(newMeta(2 * time.Hour.Milliseconds()))
+	assert.Equal(t, []string{retentionRuleHint(rule1)}, retention.hints())
+	assert.True(t, retention.seriesExpired(labels.FromStrings("__name__", "debug")))
+	assert.False(t, retention.seriesExpired(labels.FromStrings("__name__", "slo")))
+	assert.False(t, retention.seriesExpired(labels.FromStrings("__name__", "other")))
+
+	retention = r.blockRetention(newMeta(time.Hour.Milliseconds()))
+	assert.Equal(t, []string{retentionRuleHint(rule1), defaultRetentionHint}, retention.hints())
+	assert.True(t, retention.seriesExpired(labels.FromStrings("__name__", "debug")))
+	assert.False(t, retention.seriesExpired(labels.FromStrings("__name__", "slo")))
+	assert.True(t, retention.seriesExpired(labels.FromStrings("__name__", "other")))
+
+	retention = r.blockRetention(newMeta(4 * time.Hour.Milliseconds()))
+	assert.Empty(t, retention.hints())
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/util"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 const (
@@ -58,6 +59,7 @@ var (
 	errInvalidMaxClosingBlocksConcurrency         = fmt.Errorf("invalid max-closing-blocks-concurrency value, must be positive")
 	errInvalidSymbolFlushersConcurrency           = fmt.Errorf("invalid symbols-flushers-concurrency value, must be positive")
 	errInvalidMaxBlockUploadValidationConcurrency = fmt.Errorf("invalid max-block-upload-validation-concurrency value, can't be negative")
+	errInvalidBloomFilterFalsePositiveRate        = fmt.Errorf("invalid bloom-filter-false-positive-rate value, must be greater than 0 and less than 1")
 	RingOp                                        = ring.NewOp([]ring.InstanceState{ring.ACTIVE}, nil)
 )
 
@@ -96,6 +98,8 @@ type Config struct {
 	MaxCompactionTime          time.Duration           `yaml:"max_compaction_time" category:"advanced"`
 	NoBlocksFileCleanupEnabled bool                    `yaml:"no_blocks_file_cleanup_enabled" category:"experimental"`
 
+	BloomFilterFalsePositiveRate float64 `yaml:"bloom_filter_false_positive_rate" category:"experimental"`
+
 	// Compactor concurrency options
 	MaxOpeningBlocksConcurrency         int `yaml:"max_opening_blocks_concurrency" category:"advanced"`          // Number of goroutines opening blocks before compaction.
 	MaxClosingBlocksConcurrency         int `yaml:"max_closing_blocks_concurrency" category:"advanced"`          // Max number of blocks that can be closed concurrently during split compaction. Note that closing of newly compacted block uses a lot of memory for writing index.
@@ -146,6 +150,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 		"If 0, blocks will be deleted straight away. Note that deleting blocks immediately can cause query failures.")
 	f.DurationVar(&cfg.TenantCleanupDelay, "compactor.tenant-cleanup-delay", 6*time.Hour, "For tenants marked for deletion, this is time between deleting of last block, and doing final cleanup (marker files, debug files) of the tenant.")
 	f.BoolVar(&cfg.NoBlocksFileCleanupEnabled, "compactor.no-blocks-file-cleanup-enabled", false, "If enabled, will delete the bucket-index, markers and debug files in the tenant bucket when there are no blocks left in the index.")
+	f.Float64Var(&cfg.BloomFilterFalsePositiveRate, "compactor.bloom-filter-false-positive-rate", 0.01, "Target false positive rate of the label values bloom filters written alongside compacted blocks for the label names configured via -compactor.bloom-filter-label-names. A lower rate allows store-gateways to skip more blocks, at the cost of larger bloom filters.")
 	// compactor concurrency options
 	f.IntVar(&cfg.MaxOpeningBlocksConcurrency, "compactor.max-opening-blocks-concurrency", 1, "Number of goroutines opening blocks before compaction.")
 	f.IntVar(&cfg.MaxClosingBlocksConcurrency, "compactor.max-closing-blocks-concurrency", 1, "Max number of blocks that can be closed concurrently during split compaction. Note that closing of newly compacted block uses a lot of memory for writing index.")
@@ -179,6 +184,9 @@ func (cfg *Config) Validate() error {
 	if !util.StringsContain(CompactionOrders, cfg.CompactionJobsOrder) {
 		return errInvalidCompactionOrder
 	}
+	if cfg.BloomFilterFalsePositiveRate <= 0 || cfg.BloomFilterFalsePositiveRate >= 1 {
+		return errInvalidBloomFilterFalsePositiveRate
+	}
 
 	return nil
 }
@@ -216,6 +224,21 @@ type ConfigProvider interface {
 
 	// CompactorBlockUploadMaxBlockSizeBytes returns the maximum size in bytes of a block that is allowed to be uploaded or validated for a given user.
 	CompactorBlockUploadMaxBlockSizeBytes(userID string) int64
+
+	// CompactorBloomFilterLabelNames returns the label names for which a bloom filter of the label values is written alongside each compacted block.
+	CompactorBloomFilterLabelNames(userID string) []string
+
+	// CompactorDownsamplingEnabled returns whether the blocks of a given user are downsampled.
+	CompactorDownsamplingEnabled(userID string) bool
+
+	// CompactorBlocksRetentionPeriod5m returns the retention period of the 5m resolution blocks for a given user.
+	CompactorBlocksRetentionPeriod5m(userID string) time.Duration
+
+	// CompactorBlocksRetentionPeriod1h returns the retention period of the 1h resolution blocks for a given user.
+	CompactorBlocksRetentionPeriod1h(userID string) time.Duration
+
+	// CompactorRetentionRules returns the retention rules applying to the series matching their selectors for a given user.
+	CompactorRetentionRules(userID string) []*validation.RetentionRule
 }
 
 // MultitenantCompactor is a multi-tenant TSDB blocks compactor based on Thanos.
@@ -270,6 +293,12 @@ type MultitenantCompactor struct {
 	// Metrics shared across all BucketCompactor instances.
 	bucketCompactorMetrics *BucketCompactorMetrics
 
+	// Metrics shared across all BucketDownsampler instances.
+	bucketDownsamplerMetrics *BucketDownsamplerMetrics
+
+	// Metrics shared across all BucketRetentionRulesRewriter instances.
+	bucketRetentionRulesRewriterMetrics *BucketRetentionRulesRewriterMetrics
+
 	// TSDB syncer metrics
 	syncerMetrics *aggregatedSyncerMetrics
 
@@ -393,6 +422,8 @@ func newMultitenantCompactor(
 	})
 
 	c.bucketCompactorMetrics = NewBucketCompactorMetrics(c.blocksMarkedForDeletion, registerer)
+	c.bucketDownsamplerMetrics = NewBucketDownsamplerMetrics(registerer)
+	c.bucketRetentionRulesRewriterMetrics = NewBucketRetentionRulesRewriterMetrics(c.blocksMarkedForDeletion, registerer)
 
 	if len(compactorCfg.EnabledTenants) > 0 {
 		level.Info(c.logger).Log("msg", "compactor using enabled users", "enabled", strings.Join(compactorCfg.EnabledTenants, ", "))
@@ -773,6 +804,8 @@ func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) e
 		c.jobsOrder,
 		c.compactorCfg.CompactionWaitPeriod,
 		c.compactorCfg.BlockSyncConcurrency,
+		c.cfgProvider.CompactorBloomFilterLabelNames(userID),
+		c.compactorCfg.BloomFilterFalsePositiveRate,
 		c.bucketCompactorMetrics,
 	)
 	if err != nil {
@@ -783,6 +816,44 @@ func (c *MultitenantCompactor) compactUser(ctx context.Context, userID string) e
 		return errors.Wrap(err, "compaction")
 	}
 
+	if c.cfgProvider.CompactorDownsamplingEnabled(userID) {
+		downsampler := NewBucketDownsampler(
+			userLogger,
+			userID,
+			syncer,
+			userBucket,
+			path.Join(c.compactorCfg.DataDir, "downsample"),
+			c.compactorCfg.BlockRanges.ToMilliseconds(),
+			c.shardingStrategy.ownJob,
+			c.bucketDownsamplerMetrics,
+		)
+		if err := downsampler.Downsample(ctx); err != nil {
+			return errors.Wrap(err, "downsampling")
+		}
+	}
+
+	if rules := c.cfgProvider.CompactorRetentionRules(userID); len(rules) > 0 {
+		rewriter := NewBucketRetentionRulesRewriter(
+			userLogger,
+			userID,
+			syncer,
+			userBucket,
+			c.blocksCompactor,
+			path.Join(c.compactorCfg.DataDir, "retention"),
+			rules,
+			func(resolution int64) time.Duration {
+				return resolutionRetentionPeriod(c.cfgProvider, userID, resolution)
+			},
+			c.cfgProvider.CompactorBloomFilterLabelNames(userID),
+			c.compactorCfg.BloomFilterFalsePositiveRate,
+			c.shardingStrategy.ownJob,
+			c.bucketRetentionRulesRewriterMetrics,
+		)
+		if err := rewriter.Rewrite(ctx); err != nil {
+			return errors.Wrap(err, "applying retention rules")
+		}
+	}
+
 	return nil
 }
 
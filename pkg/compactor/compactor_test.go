@@ -127,6 +127,10 @@ func TestConfig_Validate(t *testing.T) {
 			setup:    func(cfg *Config) { cfg.SymbolsFlushersConcurrency = 0 },
 			expected: errInvalidSymbolFlushersConcurrency.Error(),
 		},
+		"should fail on invalid value of bloom-filter-false-positive-rate": {
+			setup:    func(cfg *Config) { cfg.BloomFilterFalsePositiveRate = 1 },
+			expected: errInvalidBloomFilterFalsePositiveRate.Error(),
+		},
 	}
 
 	for testName, testData := range tests {
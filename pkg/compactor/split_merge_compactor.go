@@ -8,6 +8,8 @@ import (
 	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/tsdb"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
 )
 
 func splitAndMergeGrouperFactory(_ context.Context, cfg Config, cfgProvider ConfigProvider, userID string, logger log.Logger, _ prometheus.Registerer) Grouper {
@@ -20,8 +22,9 @@ func splitAndMergeGrouperFactory(_ context.Context, cfg Config, cfgProvider Conf
 }
 
 func splitAndMergeCompactorFactory(ctx context.Context, cfg Config, logger log.Logger, reg prometheus.Registerer) (Compactor, Planner, error) {
-	// We don't need to customise the TSDB compactor so we're just using the Prometheus one.
-	compactor, err := tsdb.NewLeveledCompactor(ctx, reg, logger, cfg.BlockRanges.ToMilliseconds(), nil, nil, true)
+	// We don't need to customise the TSDB compactor so we're just using the Prometheus one,
+	// with a chunk pool supporting the chunks of the downsampled blocks.
+	compactor, err := tsdb.NewLeveledCompactor(ctx, reg, logger, cfg.BlockRanges.ToMilliseconds(), downsample.NewPool(), nil, true)
 	if err != nil {
 		return nil, nil, err
 	}
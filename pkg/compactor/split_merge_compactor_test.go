@@ -28,6 +28,7 @@ import (
 	"github.com/grafana/mimir/pkg/storage/sharding"
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/util"
 	util_test "github.com/grafana/mimir/pkg/util/test"
 )
 
@@ -862,6 +863,102 @@ func TestMultitenantCompactor_ShouldGuaranteeSeriesShardingConsistencyOverTheTim
 	}
 }
 
+func TestMultitenantCompactor_ShouldWriteBloomFiltersOfCompactedBlocks(t *testing.T) {
+	const (
+		userID     = "user-1"
+		numSeries  = 100
+		blockRange = 2 * time.Hour
+		numShards  = 2
+	)
+
+	blockRangeMillis := blockRange.Milliseconds()
+
+	workDir := t.TempDir()
+	storageDir := t.TempDir()
+	fetcherDir := t.TempDir()
+
+	storageCfg := mimir_tsdb.BlocksStorageConfig{}
+	flagext.DefaultValues(&storageCfg)
+	storageCfg.Bucket.Backend = bucket.Filesystem
+	storageCfg.Bucket.Filesystem.Directory = storageDir
+
+	compactorCfg := prepareConfig(t)
+	compactorCfg.DataDir = workDir
+	compactorCfg.BlockRanges = mimir_tsdb.DurationList{blockRange}
+
+	cfgProvider := newMockConfigProvider()
+	cfgProvider.splitAndMergeShards[userID] = numShards
+	cfgProvider.bloomFilterLabelNames[userID] = []string{"series_id", "missing"}
+
+	logger := log.NewLogfmtLogger(os.Stdout)
+	reg := prometheus.NewPedanticRegistry()
+	ctx := context.Background()
+
+	bucketClient, err := bucket.NewClient(ctx, storageCfg.Bucket, "test", logger, nil)
+	require.NoError(t, err)
+
+	// Create a TSDB block in the storage.
+	createTSDBBlock(t, bucketClient, userID, blockRangeMillis, 2*blockRangeMillis, numSeries, nil)
+
+	c, err := NewMultitenantCompactor(compactorCfg, storageCfg, cfgProvider, logger, reg)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(context.Background(), c))
+	})
+
+	// Wait until the first compaction run completed.
+	test.Poll(t, 15*time.Second, nil, func() interface{} {
+		return testutil.GatherAndCompare(reg, strings.NewReader(`
+					# HELP cortex_compactor_runs_completed_total Total number of compaction runs successfully completed.
+					# TYPE cortex_compactor_runs_completed_total counter
+					cortex_compactor_runs_completed_total 1
+				`), "cortex_compactor_runs_completed_total")
+	})
+
+	// List back any (non deleted) block from the storage.
+	userBucket := bucket.NewUserBucketClient(userID, bucketClient, nil)
+	fetcher, err := block.NewMetaFetcher(logger, 1, userBucket, fetcherDir, reg, nil)
+	require.NoError(t, err)
+	metas, partials, err := fetcher.FetchWithoutMarkedForDeletion(ctx)
+	require.NoError(t, err)
+	require.Empty(t, partials)
+	require.Len(t, metas, numShards)
+
+	for _, meta := range metas {
+		require.True(t, block.HasBloomFilters(meta))
+
+		filters, err := block.ReadBloomFilters(ctx, userBucket, meta.ULID)
+		require.NoError(t, err)
+
+		b, err := tsdb.OpenBlock(logger, filepath.Join(storageDir, userID, meta.ULID.String()), nil)
+		require.NoError(t, err)
+		indexReader, err := b.Index()
+		require.NoError(t, err)
+		seriesIDs, err := indexReader.SortedLabelValues(ctx, "series_id")
+		require.NoError(t, err)
+
+		// The bloom filters must not rule out the series in the block, but should rule out most of the others.
+		ruledOut := 0
+		for seriesID := 0; seriesID < numSeries; seriesID++ {
+			value := strconv.Itoa(seriesID)
+			mayMatch, _ := filters.MayMatch([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "series_id", value)})
+			if util.StringsContain(seriesIDs, value) {
+				assert.True(t, mayMatch, "series_id", value)
+			} else if !mayMatch {
+				ruledOut++
+			}
+		}
+		assert.Greater(t, ruledOut, (numSeries-len(seriesIDs))/2)
+
+		mayMatch, _ := filters.MayMatch([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "missing", "value")})
+		assert.False(t, mayMatch)
+
+		require.NoError(t, indexReader.Close())
+		require.NoError(t, b.Close())
+	}
+}
+
 func convertMetasMapToSlice(metas map[ulid.ULID]*block.Meta) []*block.Meta {
 	var out []*block.Meta
 	for _, m := range metas {
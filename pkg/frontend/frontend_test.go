@@ -250,11 +250,14 @@ func testFrontend(t *testing.T, config CombinedFrontendConfig, handler http.Hand
 		frontendv1pb.RegisterFrontendServer(grpcServer, v1)
 	}
 
+	frontendHandler, err := transport.NewHandler(config.Handler, rt, nil, logger, nil, nil)
+	require.NoError(t, err)
+
 	r := mux.NewRouter()
 	r.PathPrefix("/").Handler(middleware.Merge(
 		middleware.AuthenticateUser,
 		middleware.Tracer{},
-	).Wrap(transport.NewHandler(config.Handler, rt, logger, nil, nil)))
+	).Wrap(frontendHandler))
 
 	httpServer := http.Server{
 		Handler: r,
@@ -295,3 +298,15 @@ type limits struct {
 func (l limits) MaxQueriersPerUser(_ string) int {
 	return l.queriers
 }
+
+func (l limits) QueryIngestersWithin(_ string) time.Duration {
+	return 0
+}
+
+func (l limits) AllowedQueryPriorityClasses(_ string) []string {
+	return nil
+}
+
+func (l limits) MaxHedgedRequestsPerQuery(_ string) int {
+	return 0
+}
@@ -94,7 +94,8 @@ func (c *cardinalityEstimation) Do(ctx context.Context, request Request) (Respon
 	actualCardinality := statistics.GetFetchedSeriesCount()
 	spanLog.LogFields(otlog.Uint64("actual cardinality", actualCardinality))
 
-	if !estimateAvailable || !isCardinalitySimilar(actualCardinality, estimatedCardinality) {
+	// The actual cardinality is unknown if the query is only explained, so the estimate isn't updated.
+	if (!estimateAvailable || !isCardinalitySimilar(actualCardinality, estimatedCardinality)) && !isQueryExplained(ctx) {
 		c.storeCardinalityForKey(k, actualCardinality)
 		spanLog.LogFields(otlog.Bool("cache updated", true))
 	}
@@ -0,0 +1,232 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+)
+
+const (
+	requestTypeRangeQuery   = "query_range"
+	requestTypeInstantQuery = "query"
+)
+
+type requestCoalescerMetrics struct {
+	coalescedRequests   *prometheus.CounterVec
+	cancelledExecutions *prometheus.CounterVec
+}
+
+func newRequestCoalescerMetrics(reg prometheus.Registerer) *requestCoalescerMetrics {
+	return &requestCoalescerMetrics{
+		coalescedRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_frontend_query_coalesced_requests_total",
+			Help: "Total number of queries which have been served by an identical query already in-flight, instead of being executed.",
+		}, []string{"request_type"}),
+		cancelledExecutions: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_frontend_query_coalesced_executions_cancelled_total",
+			Help: "Total number of coalesced query executions cancelled because all the callers waiting for them have gone away.",
+		}, []string{"request_type"}),
+	}
+}
+
+// requestCoalescer tracks the in-flight queries, so that identical queries received while a query is running wait for
+// and share its result, instead of being executed again.
+type requestCoalescer struct {
+	logger  log.Logger
+	metrics *requestCoalescerMetrics
+
+	mtx      sync.Mutex
+	inflight map[string]*coalescedCall
+}
+
+// coalescedCall is a query execution shared by one or more callers.
+type coalescedCall struct {
+	requestType string
+
+	// done is closed once resp and err are set.
+	done chan struct{}
+	resp Response
+	err  error
+
+	// stats are the query statistics tracked by the execution, merged into the statistics of each caller.
+	stats *stats.Stats
+
+	// callers is the number of callers waiting for the execution. It's guarded by requestCoalescer.mtx.
+	callers int
+	cancel  context.CancelFunc
+}
+
+func newRequestCoalescer(logger log.Logger, reg prometheus.Registerer) *requestCoalescer {
+	return &requestCoalescer{
+		logger:   logger,
+		metrics:  newRequestCoalescerMetrics(reg),
+		inflight: map[string]*coalescedCall{},
+	}
+}
+
+// newCoalescingMiddleware returns a middleware that deduplicates identical in-flight queries: a query received while
+// an identical one (same tenants, normalized query, time range, step and options) is running waits for the running
+// one to complete and gets a copy of its response.
+//
+// The shared execution isn't bound to the context of the caller which started it, so it keeps running as long as at
+// least one caller is waiting for it, and it's cancelled once all the callers have gone away. The execution tracks
+// its own query statistics, which are merged into the statistics of each caller getting its response.
+func newCoalescingMiddleware(coalescer *requestCoalescer) Middleware {
+	return MiddlewareFunc(func(next Handler) Handler {
+		return &coalescingMiddleware{
+			next:      next,
+			coalescer: coalescer,
+		}
+	})
+}
+
+type coalescingMiddleware struct {
+	next      Handler
+	coalescer *requestCoalescer
+}
+
+func (c *coalescingMiddleware) Do(ctx context.Context, req Request) (Response, error) {
+	// Explained queries are never executed.
+	if isQueryExplained(ctx) {
+		return c.next.Do(ctx, req)
+	}
+
+	requestType, key, ok := coalescingKey(ctx, req)
+	if !ok {
+		return c.next.Do(ctx, req)
+	}
+
+	return c.coalescer.do(ctx, requestType, key, req, c.next)
+}
+
+func (r *requestCoalescer) do(ctx context.Context, requestType, key string, req Request, next Handler) (Response, error) {
+	r.mtx.Lock()
+	call, found := r.inflight[key]
+	if !found {
+		// The execution keeps the values (eg. the tracing span) of the context of the caller starting it, but not
+		// its cancellation, which is handled below, nor its query statistics, which are shared by all the callers.
+		execCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		execStats, execCtx := stats.ContextWithEmptyStats(execCtx)
+		call = &coalescedCall{
+			requestType: requestType,
+			done:        make(chan struct{}),
+			stats:       execStats,
+			cancel:      cancel,
+		}
+		r.inflight[key] = call
+		go r.execute(execCtx, key, call, req, next)
+	}
+	call.callers++
+	r.mtx.Unlock()
+
+	if found {
+		r.metrics.coalescedRequests.WithLabelValues(requestType).Inc()
+
+		spanLog := spanlogger.FromContext(ctx, r.logger)
+		level.Debug(spanLog).Log("msg", "query coalesced with an identical in-flight query", "query", req.GetQuery())
+	}
+
+	select {
+	case <-call.done:
+		stats.FromContext(ctx).Merge(call.stats)
+		if call.err != nil {
+			return nil, call.err
+		}
+		return shallowCloneResponse(call.resp), nil
+
+	case <-ctx.Done():
+		r.leave(key, call)
+		return nil, ctx.Err()
+	}
+}
+
+func (r *requestCoalescer) execute(ctx context.Context, key string, call *coalescedCall, req Request, next Handler) {
+	defer call.cancel()
+
+	resp, err := next.Do(ctx, req)
+
+	r.mtx.Lock()
+	// The call may have already been removed if all its callers have gone away.
+	if r.inflight[key] == call {
+		delete(r.inflight, key)
+	}
+	r.mtx.Unlock()
+
+	call.resp, call.err = resp, err
+	close(call.done)
+}
+
+// leave removes a caller which stopped waiting for the call, cancelling the call if no caller is left.
+func (r *requestCoalescer) leave(key string, call *coalescedCall) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	call.callers--
+	if call.callers > 0 {
+		return
+	}
+
+	// Remove the call, so that an identical query received from now on is executed again instead of getting
+	// the cancellation error.
+	if r.inflight[key] == call {
+		delete(r.inflight, key)
+	}
+	call.cancel()
+	r.metrics.cancelledExecutions.WithLabelValues(call.requestType).Inc()
+}
+
+// coalescingKey returns the key identifying the queries that can be coalesced with req, and whether req can be
+// coalesced at all.
+func coalescingKey(ctx context.Context, req Request) (requestType, key string, ok bool) {
+	switch req.(type) {
+	case *PrometheusRangeQueryRequest:
+		requestType = requestTypeRangeQuery
+	case *PrometheusInstantQueryRequest:
+		requestType = requestTypeInstantQuery
+	default:
+		return "", "", false
+	}
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return "", "", false
+	}
+
+	// Normalize the query, so that queries only differing in formatting are coalesced too.
+	expr, err := parser.ParseExpr(req.GetQuery())
+	if err != nil {
+		return "", "", false
+	}
+
+	options := req.GetOptions()
+	key = fmt.Sprintf("%s:%s:%d:%d:%d:%s:%s", requestType, tenant.JoinTenantIDs(tenantIDs), req.GetStart(), req.GetEnd(), req.GetStep(), expr.String(), options.String())
+	return requestType, key, true
+}
+
+// shallowCloneResponse returns a copy of resp which can be modified by the upstream middlewares (eg. to add the
+// query statistics) without affecting the other callers sharing the same response. The series are not copied.
+func shallowCloneResponse(resp Response) Response {
+	promResp, ok := resp.(*PrometheusResponse)
+	if !ok {
+		return resp
+	}
+
+	clone := *promResp
+	if promResp.Data != nil {
+		data := *promResp.Data
+		clone.Data = &data
+	}
+	return &clone
+}
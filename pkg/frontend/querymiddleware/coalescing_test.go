@@ -0,0 +1,281 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/querier/stats"
+)
+
+func TestCoalescingMiddleware_IdenticalQueries(t *testing.T) {
+	var (
+		reg       = prometheus.NewPedanticRegistry()
+		coalescer = newRequestCoalescer(log.NewNopLogger(), reg)
+		calls     = atomic.NewInt64(0)
+		release   = make(chan struct{})
+	)
+
+	next := HandlerFunc(func(context.Context, Request) (Response, error) {
+		calls.Inc()
+		<-release
+		return &PrometheusResponse{Status: statusSuccess, Data: &PrometheusData{ResultType: "matrix"}}, nil
+	})
+	mw := newCoalescingMiddleware(coalescer).Wrap(next)
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	reqs := []Request{
+		&PrometheusRangeQueryRequest{Start: 0, End: 3600000, Step: 60000, Query: `sum(rate(metric[5m]))`},
+		&PrometheusRangeQueryRequest{Start: 0, End: 3600000, Step: 60000, Query: `sum (rate(metric[5m] ))`},
+		&PrometheusRangeQueryRequest{Start: 0, End: 3600000, Step: 60000, Query: `sum(rate(metric[5m]))`},
+	}
+
+	var (
+		wg        sync.WaitGroup
+		responses = make([]Response, len(reqs))
+	)
+	for i, req := range reqs {
+		wg.Add(1)
+		go func(i int, req Request) {
+			defer wg.Done()
+			resp, err := mw.Do(ctx, req)
+			require.NoError(t, err)
+			responses[i] = resp
+		}(i, req)
+	}
+
+	waitCoalescedCallers(t, coalescer, len(reqs))
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int64(1), calls.Load())
+	for _, resp := range responses {
+		require.Equal(t, statusSuccess, resp.(*PrometheusResponse).Status)
+	}
+
+	// Each caller gets its own copy of the response, so that the upstream middlewares can modify it.
+	responses[0].(*PrometheusResponse).Data.ResultType = "vector"
+	require.Equal(t, "matrix", responses[1].(*PrometheusResponse).Data.ResultType)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_frontend_query_coalesced_requests_total Total number of queries which have been served by an identical query already in-flight, instead of being executed.
+		# TYPE cortex_frontend_query_coalesced_requests_total counter
+		cortex_frontend_query_coalesced_requests_total{request_type="query_range"} 2
+	`), "cortex_frontend_query_coalesced_requests_total"))
+
+	// Once the query has completed, an identical query is executed again.
+	_, err := mw.Do(ctx, reqs[0])
+	require.NoError(t, err)
+	require.Equal(t, int64(2), calls.Load())
+}
+
+func TestCoalescingMiddleware_ShouldMergeTheExecutionStatsIntoEachCaller(t *testing.T) {
+	var (
+		coalescer = newRequestCoalescer(log.NewNopLogger(), nil)
+		release   = make(chan struct{})
+	)
+
+	next := HandlerFunc(func(ctx context.Context, _ Request) (Response, error) {
+		<-release
+		stats.FromContext(ctx).AddFetchedSeries(10)
+		return &PrometheusResponse{Status: statusSuccess}, nil
+	})
+	mw := newCoalescingMiddleware(coalescer).Wrap(next)
+	req := &PrometheusRangeQueryRequest{Start: 0, End: 3600000, Step: 60000, Query: `up`}
+
+	var (
+		wg          sync.WaitGroup
+		callerStats = make([]*stats.Stats, 2)
+	)
+	for i := range callerStats {
+		queryStats, ctx := stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), "user-1"))
+		queryStats.AddFetchedSeries(uint64(i))
+		callerStats[i] = queryStats
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := mw.Do(ctx, req)
+			require.NoError(t, err)
+		}()
+		waitCoalescedCallers(t, coalescer, i+1)
+	}
+
+	close(release)
+	wg.Wait()
+
+	// The execution doesn't update the stats of the caller which started it, but each caller gets the execution stats.
+	assert.Equal(t, uint64(10), callerStats[0].LoadFetchedSeries())
+	assert.Equal(t, uint64(11), callerStats[1].LoadFetchedSeries())
+}
+
+func TestCoalescingMiddleware_DifferentQueriesAreNotCoalesced(t *testing.T) {
+	base := &PrometheusRangeQueryRequest{Start: 0, End: 3600000, Step: 60000, Query: `up`}
+
+	tests := map[string]struct {
+		orgID string
+		req   Request
+	}{
+		"different tenant": {
+			orgID: "user-2",
+			req:   base,
+		},
+		"different query": {
+			orgID: "user-1",
+			req:   base.WithQuery(`down`),
+		},
+		"different time range": {
+			orgID: "user-1",
+			req:   base.WithStartEnd(60000, 3660000),
+		},
+		"different step": {
+			orgID: "user-1",
+			req:   &PrometheusRangeQueryRequest{Start: 0, End: 3600000, Step: 30000, Query: `up`},
+		},
+		"different options": {
+			orgID: "user-1",
+			req:   &PrometheusRangeQueryRequest{Start: 0, End: 3600000, Step: 60000, Query: `up`, Options: Options{CacheDisabled: true}},
+		},
+		"instant query": {
+			orgID: "user-1",
+			req:   &PrometheusInstantQueryRequest{Time: 3600000, Query: `up`},
+		},
+		"query requesting statistics": {
+			orgID: "user-1",
+			req:   &PrometheusRangeQueryRequest{Start: 0, End: 3600000, Step: 60000, Query: `up`, Options: Options{Stats: "all"}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var (
+				coalescer = newRequestCoalescer(log.NewNopLogger(), nil)
+				calls     = atomic.NewInt64(0)
+				release   = make(chan struct{})
+			)
+
+			next := HandlerFunc(func(context.Context, Request) (Response, error) {
+				calls.Inc()
+				<-release
+				return &PrometheusResponse{Status: statusSuccess}, nil
+			})
+			mw := newCoalescingMiddleware(coalescer).Wrap(next)
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := mw.Do(user.InjectOrgID(context.Background(), "user-1"), base)
+				require.NoError(t, err)
+			}()
+			waitCoalescedCallers(t, coalescer, 1)
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := mw.Do(user.InjectOrgID(context.Background(), tc.orgID), tc.req)
+				require.NoError(t, err)
+			}()
+
+			require.Eventually(t, func() bool { return calls.Load() == 2 }, time.Second, 10*time.Millisecond)
+			close(release)
+			wg.Wait()
+		})
+	}
+}
+
+func TestCoalescingMiddleware_Cancellation(t *testing.T) {
+	var (
+		reg        = prometheus.NewPedanticRegistry()
+		coalescer  = newRequestCoalescer(log.NewNopLogger(), reg)
+		calls      = atomic.NewInt64(0)
+		release    = make(chan struct{})
+		downstream = make(chan context.Context, 2)
+	)
+
+	next := HandlerFunc(func(ctx context.Context, _ Request) (Response, error) {
+		calls.Inc()
+		downstream <- ctx
+		select {
+		case <-release:
+			return &PrometheusResponse{Status: statusSuccess}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+	mw := newCoalescingMiddleware(coalescer).Wrap(next)
+	req := &PrometheusRangeQueryRequest{Start: 0, End: 3600000, Step: 60000, Query: `up`}
+
+	// Start the query with two callers, then cancel the one which started the execution.
+	firstCtx, cancelFirst := context.WithCancel(user.InjectOrgID(context.Background(), "user-1"))
+	secondCtx, cancelSecond := context.WithCancel(user.InjectOrgID(context.Background(), "user-1"))
+	t.Cleanup(cancelSecond)
+
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := mw.Do(firstCtx, req)
+		firstErr <- err
+	}()
+	waitCoalescedCallers(t, coalescer, 1)
+
+	secondErr := make(chan error, 1)
+	go func() {
+		_, err := mw.Do(secondCtx, req)
+		secondErr <- err
+	}()
+	waitCoalescedCallers(t, coalescer, 2)
+
+	cancelFirst()
+	require.ErrorIs(t, <-firstErr, context.Canceled)
+
+	// The execution is still running for the other caller.
+	execCtx := <-downstream
+	require.NoError(t, execCtx.Err())
+
+	// Once all the callers are gone, the execution is cancelled.
+	cancelSecond()
+	require.ErrorIs(t, <-secondErr, context.Canceled)
+	require.Eventually(t, func() bool { return execCtx.Err() != nil }, time.Second, 10*time.Millisecond)
+
+	// A new identical query is executed again, instead of getting the cancellation error.
+	thirdErr := make(chan error, 1)
+	go func() {
+		_, err := mw.Do(user.InjectOrgID(context.Background(), "user-1"), req)
+		thirdErr <- err
+	}()
+	<-downstream
+	close(release)
+	require.NoError(t, <-thirdErr)
+	require.Equal(t, int64(2), calls.Load())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_frontend_query_coalesced_executions_cancelled_total Total number of coalesced query executions cancelled because all the callers waiting for them have gone away.
+		# TYPE cortex_frontend_query_coalesced_executions_cancelled_total counter
+		cortex_frontend_query_coalesced_executions_cancelled_total{request_type="query_range"} 1
+	`), "cortex_frontend_query_coalesced_executions_cancelled_total"))
+}
+
+// waitCoalescedCallers waits until the only in-flight call of the coalescer has the expected number of callers.
+func waitCoalescedCallers(t *testing.T, coalescer *requestCoalescer, expected int) {
+	require.Eventually(t, func() bool {
+		coalescer.mtx.Lock()
+		defer coalescer.mtx.Unlock()
+
+		for _, call := range coalescer.inflight {
+			return call.callers == expected
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+}
@@ -8,6 +8,7 @@ package querymiddleware
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -50,10 +51,21 @@ const (
 	// statusSuccess Prometheus error result.
 	statusError = "error"
 
-	totalShardsControlHeader = "Sharding-Control"
+	// TotalShardsControlHeader, SplitControlHeader and InstantSplitControlHeader are the request headers used
+	// to override the query sharding and splitting of a single request. The query-frontend handler only forwards
+	// SplitControlHeader for the tenants allowed to use query hints.
+	TotalShardsControlHeader = "Sharding-Control"
+
+	// Range query specific options
+	SplitControlHeader = "Split-Control"
 
 	// Instant query specific options
-	instantSplitControlHeader = "Instant-Split-Control"
+	InstantSplitControlHeader = "Instant-Split-Control"
+
+	// StatsControlHeader is the request header used to enable the per-step stats of a single request, when set to
+	// PerStepStatsValue. The query-frontend handler only forwards it for the tenants allowed to use query hints.
+	StatsControlHeader = "Stats-Control"
+	PerStepStatsValue  = "per-step"
 
 	operationEncode = "encode"
 	operationDecode = "decode"
@@ -154,7 +166,9 @@ type prometheusCodec struct {
 }
 
 type formatter interface {
-	EncodeResponse(resp *PrometheusResponse) ([]byte, error)
+	// EncodeResponse encodes the response, failing with errResponseSizeLimitExceeded if the encoded response
+	// exceeds maxSizeBytes. 0 means unlimited.
+	EncodeResponse(resp *PrometheusResponse, maxSizeBytes int) (encodedResponse, error)
 	DecodeResponse([]byte) (*PrometheusResponse, error)
 	Name() string
 	ContentType() v1.MIMEType
@@ -273,7 +287,7 @@ func (c prometheusCodec) decodeInstantQueryRequest(r *http.Request) (Request, er
 func decodeOptions(r *http.Request, opts *Options) {
 	opts.CacheDisabled = decodeCacheDisabledOption(r)
 
-	for _, value := range r.Header.Values(totalShardsControlHeader) {
+	for _, value := range r.Header.Values(TotalShardsControlHeader) {
 		shards, err := strconv.ParseInt(value, 10, 32)
 		if err != nil {
 			continue
@@ -284,7 +298,16 @@ func decodeOptions(r *http.Request, opts *Options) {
 		}
 	}
 
-	for _, value := range r.Header.Values(instantSplitControlHeader) {
+	for _, value := range r.Header.Values(SplitControlHeader) {
+		splitInterval, err := time.ParseDuration(value)
+		if err != nil {
+			continue
+		}
+		// Only disabling the split by interval is supported, the interval itself can't be overridden.
+		opts.SplitDisabled = splitInterval < 1
+	}
+
+	for _, value := range r.Header.Values(InstantSplitControlHeader) {
 		splitInterval, err := time.ParseDuration(value)
 		if err != nil {
 			continue
@@ -295,11 +318,22 @@ func decodeOptions(r *http.Request, opts *Options) {
 			opts.InstantSplitDisabled = true
 		}
 	}
+	opts.Stats = r.FormValue("stats")
+
+	for _, value := range r.Header.Values(StatsControlHeader) {
+		if value == PerStepStatsValue {
+			opts.PerStepStatsEnabled = true
+		}
+	}
+	if opts.PerStepStatsEnabled && opts.Stats == "" {
+		// The per-step stats are returned with the other query statistics.
+		opts.Stats = "all"
+	}
 }
 
 func decodeCacheDisabledOption(r *http.Request) bool {
-	for _, value := range r.Header.Values(cacheControlHeader) {
-		if strings.Contains(value, noStoreValue) {
+	for _, value := range r.Header.Values(CacheControlHeader) {
+		if strings.Contains(value, NoStoreValue) {
 			return true
 		}
 	}
@@ -313,20 +347,20 @@ func (c prometheusCodec) EncodeRequest(ctx context.Context, r Request) (*http.Re
 	case *PrometheusRangeQueryRequest:
 		u = &url.URL{
 			Path: r.Path,
-			RawQuery: url.Values{
+			RawQuery: encodeQueryParams(url.Values{
 				"start": []string{encodeTime(r.Start)},
 				"end":   []string{encodeTime(r.End)},
 				"step":  []string{encodeDurationMs(r.Step)},
 				"query": []string{r.Query},
-			}.Encode(),
+			}, r.Options),
 		}
 	case *PrometheusInstantQueryRequest:
 		u = &url.URL{
 			Path: r.Path,
-			RawQuery: url.Values{
+			RawQuery: encodeQueryParams(url.Values{
 				"time":  []string{encodeTime(r.Time)},
 				"query": []string{r.Query},
-			}.Encode(),
+			}, r.Options),
 		}
 	default:
 		return nil, fmt.Errorf("unsupported request type %T", r)
@@ -352,6 +386,15 @@ func (c prometheusCodec) EncodeRequest(ctx context.Context, r Request) (*http.Re
 	return req.WithContext(ctx), nil
 }
 
+// encodeQueryParams encodes the query params, adding the ones required by the options to the querier.
+func encodeQueryParams(params url.Values, opts Options) string {
+	if opts.PerStepStatsEnabled {
+		// The querier computes the per-step stats only when all the stats are requested.
+		params.Set("stats", "all")
+	}
+	return params.Encode()
+}
+
 func (c prometheusCodec) DecodeResponse(ctx context.Context, r *http.Response, _ Request, logger log.Logger) (Response, error) {
 	switch r.StatusCode {
 	case http.StatusServiceUnavailable:
@@ -423,8 +466,13 @@ func (c prometheusCodec) EncodeResponse(ctx context.Context, req *http.Request,
 	if !ok {
 		return nil, apierror.Newf(apierror.TypeInternal, "invalid response format")
 	}
+	limits := queryResponseLimitsFromContext(ctx)
 	if a.Data != nil {
 		sp.LogFields(otlog.Int("series", len(a.Data.Result)))
+
+		if err := limits.checkSeries(len(a.Data.Result)); err != nil {
+			return nil, err
+		}
 	}
 
 	selectedContentType, formatter := c.negotiateContentType(req.Header.Get("Accept"))
@@ -433,22 +481,31 @@ func (c prometheusCodec) EncodeResponse(ctx context.Context, req *http.Request,
 	}
 
 	start := time.Now()
-	b, err := formatter.EncodeResponse(a)
+	encoded, err := formatter.EncodeResponse(a, limits.maxSizeBytes)
+	if errors.Is(err, errResponseSizeLimitExceeded) {
+		return nil, limits.sizeLimitExceededError()
+	}
 	if err != nil {
 		return nil, apierror.Newf(apierror.TypeInternal, "error encoding response: %v", err)
 	}
+	if encoded.size >= 0 {
+		sp.LogFields(otlog.Int("bytes", encoded.size))
+	}
 
-	c.metrics.duration.WithLabelValues(operationEncode, formatter.Name()).Observe(time.Since(start).Seconds())
-	c.metrics.size.WithLabelValues(operationEncode, formatter.Name()).Observe(float64(len(b)))
-	sp.LogFields(otlog.Int("bytes", len(b)))
+	// The encoding metrics are tracked once the response has been written, because the response may be encoded
+	// while it's written.
+	body := newEncodedResponseBody(encoded.writeTo, func(size int64) {
+		c.metrics.duration.WithLabelValues(operationEncode, formatter.Name()).Observe(time.Since(start).Seconds())
+		c.metrics.size.WithLabelValues(operationEncode, formatter.Name()).Observe(float64(size))
+	})
 
 	resp := http.Response{
 		Header: http.Header{
 			"Content-Type": []string{selectedContentType},
 		},
-		Body:          io.NopCloser(bytes.NewBuffer(b)),
+		Body:          body,
 		StatusCode:    http.StatusOK,
-		ContentLength: int64(len(b)),
+		ContentLength: int64(encoded.size),
 	}
 	return &resp, nil
 }
@@ -590,6 +647,101 @@ func readResponseBody(res *http.Response) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// encodedResponse is a response encoded by a formatter, ready to be written.
+type encodedResponse struct {
+	// size is the size of the encoded response, or -1 if it's only known once the response is written.
+	size int
+
+	// writeTo writes the encoded response to w.
+	writeTo func(w io.Writer) error
+}
+
+func newBufferedEncodedResponse(b []byte) encodedResponse {
+	return encodedResponse{
+		size: len(b),
+		writeTo: func(w io.Writer) error {
+			_, err := w.Write(b)
+			return err
+		},
+	}
+}
+
+// encodedResponseBody is the body of an encoded response, which writes the response straight to the writer the
+// body is copied to with io.Copy(), so that the response is never buffered as a whole. If the body is read instead,
+// the response is written to a pipe by a goroutine.
+type encodedResponseBody struct {
+	writeTo   func(w io.Writer) error
+	onWritten func(size int64)
+
+	consumed bool
+	reader   *io.PipeReader
+}
+
+func newEncodedResponseBody(writeTo func(w io.Writer) error, onWritten func(size int64)) *encodedResponseBody {
+	return &encodedResponseBody{writeTo: writeTo, onWritten: onWritten}
+}
+
+// WriteTo implements io.WriterTo.
+func (b *encodedResponseBody) WriteTo(w io.Writer) (int64, error) {
+	if b.reader != nil {
+		return io.Copy(w, b.reader)
+	}
+	if b.consumed {
+		return 0, nil
+	}
+	b.consumed = true
+
+	cw := &countingWriter{w: w}
+	err := b.write(cw)
+	return cw.size, err
+}
+
+func (b *encodedResponseBody) Read(p []byte) (int, error) {
+	if b.reader == nil {
+		if b.consumed {
+			return 0, io.EOF
+		}
+		b.consumed = true
+
+		var pw *io.PipeWriter
+		b.reader, pw = io.Pipe()
+		go func() {
+			_ = pw.CloseWithError(b.write(pw))
+		}()
+	}
+	return b.reader.Read(p)
+}
+
+// Close implements io.Closer. Closing the body before it's been fully read stops writing the response.
+func (b *encodedResponseBody) Close() error {
+	b.consumed = true
+	if b.reader != nil {
+		return b.reader.Close()
+	}
+	return nil
+}
+
+func (b *encodedResponseBody) write(w io.Writer) error {
+	cw := &countingWriter{w: w}
+	if err := b.writeTo(cw); err != nil {
+		return err
+	}
+	b.onWritten(cw.size)
+	return nil
+}
+
+// countingWriter counts the bytes written to the wrapped writer.
+type countingWriter struct {
+	w    io.Writer
+	size int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
 func mustReadResponseBody(r *http.Response) []byte {
 	body, _ := readResponseBody(r)
 	return body
@@ -5,14 +5,115 @@
 
 package querymiddleware
 
-import v1 "github.com/prometheus/prometheus/web/api/v1"
+import (
+	"io"
+
+	"github.com/prometheus/common/model"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+)
 
 const jsonMimeType = "application/json"
 
 type jsonFormatter struct{}
 
-func (j jsonFormatter) EncodeResponse(resp *PrometheusResponse) ([]byte, error) {
-	return json.Marshal(resp)
+func (j jsonFormatter) EncodeResponse(resp *PrometheusResponse, maxSizeBytes int) (encodedResponse, error) {
+	if !isStreamedJSONResponse(resp) {
+		b, err := json.Marshal(resp)
+		if err != nil {
+			return encodedResponse{}, err
+		}
+		if maxSizeBytes > 0 && len(b) > maxSizeBytes {
+			return encodedResponse{}, errResponseSizeLimitExceeded
+		}
+		return newBufferedEncodedResponse(b), nil
+	}
+
+	// To enforce the size limit before writing anything, the response is encoded into a buffer bounded by the limit,
+	// aborting the encoding as soon as the limit is exceeded. Without a limit, the response is encoded while it's
+	// written, so that it's never buffered.
+	if maxSizeBytes > 0 {
+		buf := &sizeLimitedBuffer{limit: maxSizeBytes}
+		if err := encodeStreamedJSONResponse(buf, resp); err != nil {
+			return encodedResponse{}, err
+		}
+		return newBufferedEncodedResponse(buf.Bytes()), nil
+	}
+
+	return encodedResponse{
+		size: -1,
+		writeTo: func(w io.Writer) error {
+			return encodeStreamedJSONResponse(w, resp)
+		},
+	}, nil
+}
+
+// isStreamedJSONResponse returns whether the response is a matrix or vector, which is encoded one series at a time.
+func isStreamedJSONResponse(resp *PrometheusResponse) bool {
+	return resp.Data != nil && (resp.Data.ResultType == model.ValMatrix.String() || resp.Data.ResultType == model.ValVector.String())
+}
+
+// encodeStreamedJSONResponse encodes the matrix or vector response to w. The series are encoded and written to w
+// one at a time, so that w can abort the encoding of a large response as soon as it exceeds a limit, without
+// encoding the whole response first.
+func encodeStreamedJSONResponse(w io.Writer, resp *PrometheusResponse) error {
+	isMatrix := resp.Data.ResultType == model.ValMatrix.String()
+	isVector := !isMatrix
+
+	stream := json.BorrowStream(w)
+	defer json.ReturnStream(stream)
+
+	stream.WriteObjectStart()
+	stream.WriteObjectField("status")
+	stream.WriteString(resp.Status)
+	stream.WriteMore()
+	stream.WriteObjectField("data")
+	stream.WriteObjectStart()
+	stream.WriteObjectField("resultType")
+	stream.WriteString(resp.Data.ResultType)
+	stream.WriteMore()
+	stream.WriteObjectField("result")
+
+	if resp.Data.Result == nil {
+		stream.WriteNil()
+	} else {
+		stream.WriteArrayStart()
+		for i := range resp.Data.Result {
+			if i > 0 {
+				stream.WriteMore()
+			}
+			if isVector {
+				stream.WriteVal(vectorSampleStream(resp.Data.Result[i]))
+			} else {
+				stream.WriteVal(&resp.Data.Result[i])
+			}
+			if err := stream.Flush(); err != nil {
+				return err
+			}
+		}
+		stream.WriteArrayEnd()
+	}
+
+	// Stats are only encoded for matrix results, consistently with PrometheusData.MarshalJSON().
+	if isMatrix && resp.Data.Stats != nil {
+		stream.WriteMore()
+		stream.WriteObjectField("stats")
+		stream.WriteVal(resp.Data.Stats)
+	}
+	stream.WriteObjectEnd()
+
+	if resp.ErrorType != "" {
+		stream.WriteMore()
+		stream.WriteObjectField("errorType")
+		stream.WriteString(resp.ErrorType)
+	}
+	if resp.Error != "" {
+		stream.WriteMore()
+		stream.WriteObjectField("error")
+		stream.WriteString(resp.Error)
+	}
+	stream.WriteObjectEnd()
+
+	return stream.Flush()
 }
 
 func (j jsonFormatter) DecodeResponse(buf []byte) (*PrometheusResponse, error) {
@@ -214,7 +214,8 @@ func TestPrometheusCodec_JSONResponse(t *testing.T) {
 			require.NoError(t, err)
 
 			require.JSONEq(t, string(expectedJSON), string(encodedJSON))
-			require.Equal(t, httpResponse, encoded)
+			require.Equal(t, httpResponse.StatusCode, encoded.StatusCode)
+			require.Equal(t, httpResponse.Header, encoded.Header)
 
 			metrics, err = dskit_metrics.NewMetricFamilyMapFromGatherer(reg)
 			require.NoError(t, err)
@@ -365,7 +366,9 @@ func TestPrometheusCodec_JSONEncoding(t *testing.T) {
 			encodedJSON, err := readResponseBody(encoded)
 			require.NoError(t, err)
 			require.JSONEq(t, tc.expectedJSON, string(encodedJSON))
-			require.Equal(t, len(encodedJSON), int(encoded.ContentLength))
+			if encoded.ContentLength >= 0 {
+				require.Equal(t, len(encodedJSON), int(encoded.ContentLength))
+			}
 
 			metrics, err := dskit_metrics.NewMetricFamilyMapFromGatherer(reg)
 			require.NoError(t, err)
@@ -376,7 +379,49 @@ func TestPrometheusCodec_JSONEncoding(t *testing.T) {
 			payloadSizeHistogram, err := dskit_metrics.FindHistogramWithNameAndLabels(metrics, "cortex_frontend_query_response_codec_payload_bytes", "format", "json", "operation", "encode")
 			require.NoError(t, err)
 			require.Equal(t, uint64(1), *payloadSizeHistogram.SampleCount)
-			require.Equal(t, float64(encoded.ContentLength), *payloadSizeHistogram.SampleSum)
+			require.Equal(t, float64(len(encodedJSON)), *payloadSizeHistogram.SampleSum)
+		})
+	}
+}
+
+func TestJSONFormatter_EncodeResponse(t *testing.T) {
+	labels := []mimirpb.LabelAdapter{{Name: "__name__", Value: "up"}, {Name: "job", Value: "test"}}
+
+	for name, resp := range map[string]*PrometheusResponse{
+		"matrix":                mockPrometheusResponse(3, 5),
+		"matrix with stats":     {Status: statusSuccess, Data: &PrometheusData{ResultType: model.ValMatrix.String(), Result: mockPrometheusResponse(1, 2).Data.Result, Stats: &mimirpb.QueryStats{SamplesProcessed: 10}}},
+		"matrix without result": {Status: statusSuccess, Data: &PrometheusData{ResultType: model.ValMatrix.String()}},
+		"empty matrix":          newEmptyPrometheusResponse(),
+		"vector": {Status: statusSuccess, Data: &PrometheusData{ResultType: model.ValVector.String(), Result: []SampleStream{
+			{Labels: labels, Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}}},
+			{Labels: labels, Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 2}}},
+		}, Stats: &mimirpb.QueryStats{SamplesProcessed: 10}}},
+		"scalar": {Status: statusSuccess, Data: &PrometheusData{ResultType: model.ValScalar.String(), Result: []SampleStream{
+			{Samples: []mimirpb.Sample{{TimestampMs: 1000, Value: 1}}},
+		}}},
+		"error": {Status: statusError, ErrorType: "execution", Error: `something "went" wrong`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// The response must be encoded exactly as if it was marshalled at once.
+			expected, err := json.Marshal(resp)
+			require.NoError(t, err)
+
+			actual, err := encodeFormatterResponse(jsonFormatter{}, resp, 0)
+			require.NoError(t, err)
+			require.Equal(t, string(expected), string(actual))
+
+			// The encoding fails once the response exceeds the size limit.
+			actual, err = encodeFormatterResponse(jsonFormatter{}, resp, len(expected))
+			require.NoError(t, err)
+			require.Equal(t, string(expected), string(actual))
+
+			// The size of the response is known before writing it when the size limit is enforced.
+			encoded, err := jsonFormatter{}.EncodeResponse(resp, len(expected))
+			require.NoError(t, err)
+			require.Equal(t, len(expected), encoded.size)
+
+			_, err = encodeFormatterResponse(jsonFormatter{}, resp, len(expected)-1)
+			require.ErrorIs(t, err, errResponseSizeLimitExceeded)
 		})
 	}
 }
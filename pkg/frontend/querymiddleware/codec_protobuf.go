@@ -22,15 +22,15 @@ func (f protobufFormatter) ContentType() v1.MIMEType {
 	return v1.MIMEType{Type: mimirpb.QueryResponseMimeTypeType, SubType: mimirpb.QueryResponseMimeTypeSubType}
 }
 
-func (f protobufFormatter) EncodeResponse(resp *PrometheusResponse) ([]byte, error) {
+func (f protobufFormatter) EncodeResponse(resp *PrometheusResponse, maxSizeBytes int) (encodedResponse, error) {
 	status, err := mimirpb.StatusFromPrometheusString(resp.Status)
 	if err != nil {
-		return nil, err
+		return encodedResponse{}, err
 	}
 
 	errorType, err := mimirpb.ErrorTypeFromPrometheusString(resp.ErrorType)
 	if err != nil {
-		return nil, err
+		return encodedResponse{}, err
 	}
 
 	payload := mimirpb.QueryResponse{
@@ -40,11 +40,13 @@ func (f protobufFormatter) EncodeResponse(resp *PrometheusResponse) ([]byte, err
 	}
 
 	if resp.Data != nil {
+		payload.Stats = resp.Data.Stats
+
 		switch resp.Data.ResultType {
 		case model.ValString.String():
 			data, err := f.encodeStringData(resp.Data.Result)
 			if err != nil {
-				return nil, err
+				return encodedResponse{}, err
 			}
 
 			payload.Data = &mimirpb.QueryResponse_String_{String_: &data}
@@ -52,7 +54,7 @@ func (f protobufFormatter) EncodeResponse(resp *PrometheusResponse) ([]byte, err
 		case model.ValScalar.String():
 			data, err := f.encodeScalarData(resp.Data.Result)
 			if err != nil {
-				return nil, err
+				return encodedResponse{}, err
 			}
 
 			payload.Data = &mimirpb.QueryResponse_Scalar{Scalar: &data}
@@ -60,7 +62,7 @@ func (f protobufFormatter) EncodeResponse(resp *PrometheusResponse) ([]byte, err
 		case model.ValVector.String():
 			data, err := f.encodeVectorData(resp.Data.Result)
 			if err != nil {
-				return nil, err
+				return encodedResponse{}, err
 			}
 
 			payload.Data = &mimirpb.QueryResponse_Vector{Vector: &data}
@@ -70,11 +72,21 @@ func (f protobufFormatter) EncodeResponse(resp *PrometheusResponse) ([]byte, err
 			payload.Data = &mimirpb.QueryResponse_Matrix{Matrix: &data}
 
 		default:
-			return nil, fmt.Errorf("unknown result type '%s'", resp.Data.ResultType)
+			return encodedResponse{}, fmt.Errorf("unknown result type '%s'", resp.Data.ResultType)
 		}
 	}
 
-	return payload.Marshal()
+	// The size of the payload is computed without marshalling it, so that a response exceeding the limit is not
+	// allocated at all.
+	if maxSizeBytes > 0 && payload.Size() > maxSizeBytes {
+		return encodedResponse{}, errResponseSizeLimitExceeded
+	}
+
+	b, err := payload.Marshal()
+	if err != nil {
+		return encodedResponse{}, err
+	}
+	return newBufferedEncodedResponse(b), nil
 }
 
 func (protobufFormatter) encodeStringData(data []SampleStream) (mimirpb.StringData, error) {
@@ -205,6 +217,9 @@ func (f protobufFormatter) DecodeResponse(buf []byte) (*PrometheusResponse, erro
 	if err != nil {
 		return nil, err
 	}
+	if data != nil {
+		data.Stats = resp.Stats
+	}
 
 	return &PrometheusResponse{
 		Status:    status,
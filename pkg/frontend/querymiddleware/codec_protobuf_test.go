@@ -115,6 +115,44 @@ var protobufCodecScenarios = []struct {
 			Headers: expectedProtobufResponseHeaders,
 		},
 	},
+	{
+		name: "successful scalar response with query stats",
+		payload: mimirpb.QueryResponse{
+			Status: mimirpb.QueryResponse_SUCCESS,
+			Data: &mimirpb.QueryResponse_Scalar{
+				Scalar: &mimirpb.ScalarData{
+					Value:       200,
+					TimestampMs: 1000,
+				},
+			},
+			Stats: &mimirpb.QueryStats{
+				WallTimeSeconds:      1.5,
+				QueueTimeSeconds:     0.5,
+				FetchedSeriesCount:   10,
+				SamplesProcessed:     100,
+				SplitQueries:         2,
+				ResultsCacheHitRatio: 0.5,
+			},
+		},
+		response: &PrometheusResponse{
+			Status: statusSuccess,
+			Data: &PrometheusData{
+				ResultType: model.ValScalar.String(),
+				Result: []SampleStream{
+					{Samples: []mimirpb.Sample{{TimestampMs: 1_000, Value: 200}}},
+				},
+				Stats: &mimirpb.QueryStats{
+					WallTimeSeconds:      1.5,
+					QueueTimeSeconds:     0.5,
+					FetchedSeriesCount:   10,
+					SamplesProcessed:     100,
+					SplitQueries:         2,
+					ResultsCacheHitRatio: 0.5,
+				},
+			},
+			Headers: expectedProtobufResponseHeaders,
+		},
+	},
 	{
 		name: "successful empty vector response",
 		payload: mimirpb.QueryResponse{
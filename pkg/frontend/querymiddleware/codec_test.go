@@ -13,6 +13,7 @@ import (
 	"io"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"testing"
@@ -41,6 +42,7 @@ func TestRequest(t *testing.T) {
 
 	for i, tc := range []struct {
 		url         string
+		header      http.Header
 		expected    Request
 		expectedErr error
 	}{
@@ -62,6 +64,19 @@ func TestRequest(t *testing.T) {
 				Query: "sum(container_memory_rss) by (namespace)",
 			},
 		},
+		{
+			url: "/api/v1/query?query=up&stats=all&time=1536716880",
+			expected: &PrometheusInstantQueryRequest{
+				Path:  "/api/v1/query",
+				Time:  1536716880 * 1e3,
+				Query: "up",
+				Options: Options{
+					Stats:               "all",
+					PerStepStatsEnabled: true,
+				},
+			},
+			header: http.Header{StatsControlHeader: []string{PerStepStatsValue}},
+		},
 		{
 			url:         "/api/v1/query_range?start=foo",
 			expectedErr: apierror.New(apierror.TypeBadData, "invalid parameter \"start\": cannot parse \"foo\" to a valid timestamp"),
@@ -90,6 +105,9 @@ func TestRequest(t *testing.T) {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
 			r, err := http.NewRequest("GET", tc.url, nil)
 			require.NoError(t, err)
+			for name, values := range tc.header {
+				r.Header[name] = values
+			}
 
 			ctx := user.InjectOrgID(context.Background(), "1")
 			r = r.WithContext(ctx)
@@ -135,10 +153,10 @@ func TestPrometheusCodec_EncodeResponse_ContentNegotiation(t *testing.T) {
 		Error:     "something went wrong",
 	}
 
-	jsonBody, err := jsonFormatter{}.EncodeResponse(testResponse)
+	jsonBody, err := encodeFormatterResponse(jsonFormatter{}, testResponse, 0)
 	require.NoError(t, err)
 
-	protobufBody, err := protobufFormatter{}.EncodeResponse(testResponse)
+	protobufBody, err := encodeFormatterResponse(protobufFormatter{}, testResponse, 0)
 	require.NoError(t, err)
 
 	scenarios := map[string]struct {
@@ -205,6 +223,62 @@ func TestPrometheusCodec_EncodeResponse_ContentNegotiation(t *testing.T) {
 	}
 }
 
+func TestEncodedResponseBody(t *testing.T) {
+	resp := mockPrometheusResponse(3, 5)
+	expected, err := json.Marshal(resp)
+	require.NoError(t, err)
+
+	newBody := func(written *int64) *encodedResponseBody {
+		encoded, err := jsonFormatter{}.EncodeResponse(resp, 0)
+		require.NoError(t, err)
+		return newEncodedResponseBody(encoded.writeTo, func(size int64) { *written = size })
+	}
+
+	t.Run("copied to a writer", func(t *testing.T) {
+		var written int64
+		body := newBody(&written)
+
+		// The response is written straight to the destination, without being read through a pipe.
+		var buf bytes.Buffer
+		n, err := io.Copy(onlyWriter{&buf}, body)
+		require.NoError(t, err)
+		require.NoError(t, body.Close())
+		require.Nil(t, body.reader)
+		require.Equal(t, string(expected), buf.String())
+		require.Equal(t, int64(len(expected)), n)
+		require.Equal(t, int64(len(expected)), written)
+	})
+
+	t.Run("read", func(t *testing.T) {
+		var written int64
+		body := newBody(&written)
+
+		actual, err := io.ReadAll(body)
+		require.NoError(t, err)
+		require.NoError(t, body.Close())
+		require.Equal(t, string(expected), string(actual))
+		require.Equal(t, int64(len(expected)), written)
+	})
+
+	t.Run("closed before being fully read", func(t *testing.T) {
+		var written int64
+		body := newBody(&written)
+
+		_, err := body.Read(make([]byte, 10))
+		require.NoError(t, err)
+		require.NoError(t, body.Close())
+
+		_, err = body.Read(make([]byte, 10))
+		require.ErrorIs(t, err, io.ErrClosedPipe)
+		require.Zero(t, written)
+	})
+}
+
+// onlyWriter hides the io.ReaderFrom implementation of the wrapped writer.
+type onlyWriter struct {
+	io.Writer
+}
+
 type prometheusAPIResponse struct {
 	Status    string       `json:"status"`
 	Data      interface{}  `json:"data,omitempty"`
@@ -841,7 +915,7 @@ func Test_DecodeOptions(t *testing.T) {
 			name: "disable cache",
 			input: &http.Request{
 				Header: http.Header{
-					cacheControlHeader: []string{noStoreValue},
+					CacheControlHeader: []string{NoStoreValue},
 				},
 			},
 			expected: &Options{
@@ -852,7 +926,7 @@ func Test_DecodeOptions(t *testing.T) {
 			name: "custom sharding",
 			input: &http.Request{
 				Header: http.Header{
-					totalShardsControlHeader: []string{"64"},
+					TotalShardsControlHeader: []string{"64"},
 				},
 			},
 			expected: &Options{
@@ -863,18 +937,29 @@ func Test_DecodeOptions(t *testing.T) {
 			name: "disable sharding",
 			input: &http.Request{
 				Header: http.Header{
-					totalShardsControlHeader: []string{"0"},
+					TotalShardsControlHeader: []string{"0"},
 				},
 			},
 			expected: &Options{
 				ShardingDisabled: true,
 			},
 		},
+		{
+			name: "disable range query splitting",
+			input: &http.Request{
+				Header: http.Header{
+					SplitControlHeader: []string{"0"},
+				},
+			},
+			expected: &Options{
+				SplitDisabled: true,
+			},
+		},
 		{
 			name: "custom instant query splitting",
 			input: &http.Request{
 				Header: http.Header{
-					instantSplitControlHeader: []string{"1h"},
+					InstantSplitControlHeader: []string{"1h"},
 				},
 			},
 			expected: &Options{
@@ -885,13 +970,35 @@ func Test_DecodeOptions(t *testing.T) {
 			name: "disable instant query splitting",
 			input: &http.Request{
 				Header: http.Header{
-					instantSplitControlHeader: []string{"0"},
+					InstantSplitControlHeader: []string{"0"},
 				},
 			},
 			expected: &Options{
 				InstantSplitDisabled: true,
 			},
 		},
+		{
+			name: "query stats requested",
+			input: &http.Request{
+				URL: &url.URL{RawQuery: "stats=all"},
+			},
+			expected: &Options{
+				Stats: "all",
+			},
+		},
+		{
+			name: "per-step stats enabled",
+			input: &http.Request{
+				Header: http.Header{
+					StatsControlHeader: []string{PerStepStatsValue},
+				},
+				URL: &url.URL{},
+			},
+			expected: &Options{
+				Stats:               "all",
+				PerStepStatsEnabled: true,
+			},
+		},
 	} {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
@@ -906,3 +1013,17 @@ func Test_DecodeOptions(t *testing.T) {
 func newTestPrometheusCodec() Codec {
 	return NewPrometheusCodec(prometheus.NewPedanticRegistry(), formatJSON)
 }
+
+// encodeFormatterResponse returns the response encoded by the formatter.
+func encodeFormatterResponse(f formatter, resp *PrometheusResponse, maxSizeBytes int) ([]byte, error) {
+	encoded, err := f.EncodeResponse(resp, maxSizeBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := encoded.writeTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
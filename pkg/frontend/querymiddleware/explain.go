@@ -0,0 +1,284 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/tenant"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	queryExplainPathSuffix = "/api/v1/query_explain"
+
+	// Values of the cache field of an explained split query.
+	explainCacheDisabled    = "disabled"
+	explainCacheNotCachable = "not-cachable"
+	explainCacheMiss        = "miss"
+	explainCachePartialHit  = "partial-hit"
+	explainCacheHit         = "hit"
+)
+
+type explanationContextKey int
+
+const queryExplanationKey explanationContextKey = 0
+
+// queryExplanation collects how the query-frontend middlewares would execute a query. It's populated
+// by the middlewares when the query is explained instead of being executed. All methods are safe to
+// be called on a nil explanation, so that middlewares don't need to check whether the query is explained.
+type queryExplanation struct {
+	mtx sync.Mutex
+
+	Query             explainedQuery        `json:"query"`
+	Limits            []explainedLimit      `json:"limits"`
+	Rewrites          []explainedRewrite    `json:"rewrites"`
+	SplitQueries      []explainedSplitQuery `json:"splitQueries"`
+	DownstreamQueries []explainedQuery      `json:"downstreamQueries"`
+}
+
+type explainedQuery struct {
+	Query string    `json:"query"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Step  string    `json:"step,omitempty"`
+}
+
+type explainedLimit struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type explainedRewrite struct {
+	Middleware  string `json:"middleware"`
+	Description string `json:"description"`
+	Query       string `json:"query,omitempty"`
+}
+
+type explainedSplitQuery struct {
+	explainedQuery
+	CacheKey      string          `json:"cacheKey,omitempty"`
+	Cache         string          `json:"cache"`
+	CachedExtents []explainedTime `json:"cachedExtents,omitempty"`
+}
+
+type explainedTime struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+func newQueryExplanation(req Request) *queryExplanation {
+	return &queryExplanation{
+		Query:             newExplainedQuery(req),
+		Limits:            []explainedLimit{},
+		Rewrites:          []explainedRewrite{},
+		SplitQueries:      []explainedSplitQuery{},
+		DownstreamQueries: []explainedQuery{},
+	}
+}
+
+func newExplainedQuery(req Request) explainedQuery {
+	q := explainedQuery{
+		Query: req.GetQuery(),
+		Start: util.TimeFromMillis(req.GetStart()).UTC(),
+		End:   util.TimeFromMillis(req.GetEnd()).UTC(),
+	}
+	if req.GetStep() > 0 {
+		q.Step = (time.Duration(req.GetStep()) * time.Millisecond).String()
+	}
+	return q
+}
+
+// contextWithQueryExplanation returns a new context carrying the input explanation.
+func contextWithQueryExplanation(ctx context.Context, explanation *queryExplanation) context.Context {
+	return context.WithValue(ctx, queryExplanationKey, explanation)
+}
+
+// queryExplanationFromContext returns the explanation from the context, or nil if the query is
+// executed and not explained.
+func queryExplanationFromContext(ctx context.Context) *queryExplanation {
+	explanation, _ := ctx.Value(queryExplanationKey).(*queryExplanation)
+	return explanation
+}
+
+// isQueryExplained returns whether the query is explained, in which case middlewares must not have
+// side effects (eg. storing results in the cache).
+func isQueryExplained(ctx context.Context) bool {
+	return queryExplanationFromContext(ctx) != nil
+}
+
+func (e *queryExplanation) addLimit(name string, value interface{}) {
+	if e == nil {
+		return
+	}
+
+	var formatted string
+	switch v := value.(type) {
+	case time.Duration:
+		formatted = v.String()
+	case int:
+		formatted = strconv.Itoa(v)
+	case string:
+		formatted = v
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.Limits = append(e.Limits, explainedLimit{Name: name, Value: formatted})
+}
+
+func (e *queryExplanation) addRewrite(middleware, description, query string) {
+	if e == nil {
+		return
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.Rewrites = append(e.Rewrites, explainedRewrite{Middleware: middleware, Description: description, Query: query})
+}
+
+func (e *queryExplanation) addSplitQueries(splitReqs splitRequests, isCacheEnabled bool) {
+	if e == nil {
+		return
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	for _, splitReq := range splitReqs {
+		explained := explainedSplitQuery{
+			explainedQuery: newExplainedQuery(splitReq.orig),
+			CacheKey:       splitReq.cacheKey,
+		}
+
+		switch {
+		case !isCacheEnabled:
+			explained.Cache = explainCacheDisabled
+		case splitReq.cacheKey == "":
+			explained.Cache = explainCacheNotCachable
+		case len(splitReq.fetchedExtents) == 0:
+			explained.Cache = explainCacheMiss
+		case len(splitReq.downstreamRequests) > 0:
+			explained.Cache = explainCachePartialHit
+		default:
+			explained.Cache = explainCacheHit
+		}
+
+		for _, extent := range splitReq.fetchedExtents {
+			explained.CachedExtents = append(explained.CachedExtents, explainedTime{
+				Start: util.TimeFromMillis(extent.Start).UTC(),
+				End:   util.TimeFromMillis(extent.End).UTC(),
+			})
+		}
+
+		e.SplitQueries = append(e.SplitQueries, explained)
+	}
+}
+
+func (e *queryExplanation) addDownstreamQuery(req Request) {
+	if e == nil {
+		return
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.DownstreamQueries = append(e.DownstreamQueries, newExplainedQuery(req))
+}
+
+type explainResponse struct {
+	Status string            `json:"status"`
+	Data   *queryExplanation `json:"data"`
+}
+
+// explainRoundTripper runs the query-frontend middlewares in dry-run mode and returns how
+// a range or instant query would be executed, without executing it against queriers.
+type explainRoundTripper struct {
+	codec             Codec
+	limits            Limits
+	rangeMiddleware   Middleware
+	instantMiddleware Middleware
+}
+
+func newExplainRoundTripper(codec Codec, limits Limits, rangeMiddlewares, instantMiddlewares []Middleware) http.RoundTripper {
+	return explainRoundTripper{
+		codec:             codec,
+		limits:            limits,
+		rangeMiddleware:   MergeMiddlewares(rangeMiddlewares...),
+		instantMiddleware: MergeMiddlewares(instantMiddlewares...),
+	}
+}
+
+func (rt explainRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	// The query is explained as a range query if the step is set, otherwise as an instant query.
+	prefix := strings.TrimSuffix(r.URL.Path, queryExplainPathSuffix)
+	r = r.Clone(r.Context())
+
+	if r.FormValue("step") != "" {
+		r.URL.Path = prefix + queryRangePathSuffix
+		return rt.explain(r, rt.rangeMiddleware)
+	}
+
+	r.URL.Path = prefix + instantQueryPathSuffix
+	return defaultInstantQueryParamsRoundTripper(RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return rt.explain(r, rt.instantMiddleware)
+	})).RoundTrip(r)
+}
+
+func (rt explainRoundTripper) explain(r *http.Request, middleware Middleware) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	req, err := rt.codec.DecodeRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	explanation := newQueryExplanation(req)
+	explanation.addLimit("max_query_parallelism", validation.SmallestPositiveIntPerTenant(tenantIDs, rt.limits.MaxQueryParallelism))
+	ctx = contextWithQueryExplanation(ctx, explanation)
+
+	// The middlewares update the query stats as if the query was executed, so they're tracked apart
+	// to not be reported for the explain request.
+	_, ctx = stats.ContextWithEmptyStats(ctx)
+
+	// The downstream queries are recorded instead of being executed, and an empty result is returned.
+	_, err = middleware.Wrap(HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+		explanation.addDownstreamQuery(req)
+		return newEmptyPrometheusResponse(), nil
+	})).Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(explainResponse{Status: statusSuccess, Data: explanation})
+	if err != nil {
+		return nil, apierror.New(apierror.TypeInternal, err.Error())
+	}
+
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": []string{jsonMimeType}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}, nil
+}
+
+func isExplainQuery(path string) bool {
+	return strings.HasSuffix(path, queryExplainPathSuffix)
+}
@@ -0,0 +1,251 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/cache"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+func TestExplainRoundTripper(t *testing.T) {
+	const totalShards = 4
+
+	tw, err := NewTripperware(
+		Config{
+			SplitQueriesByInterval: 24 * time.Hour,
+			ShardedQueries:         true,
+		},
+		log.NewNopLogger(),
+		mockLimits{totalShards: totalShards, maxQueryParallelism: 16},
+		newTestPrometheusCodec(),
+		nil,
+		promql.EngineOpts{
+			Logger:     log.NewNopLogger(),
+			Reg:        nil,
+			MaxSamples: 1000,
+			Timeout:    time.Minute,
+		},
+		nil,
+	)
+	require.NoError(t, err)
+
+	// Explained queries must never be executed against queriers.
+	rt := tw(RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		t.Fatalf("unexpected downstream request to %s", r.URL.String())
+		return nil, nil
+	}))
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		params                    url.Values
+		expectedStep              string
+		expectedSplitQueries      int
+		expectedDownstreamQueries int
+		expectedShardingRewrites  int
+	}{
+		"range query": {
+			params: url.Values{
+				"query": []string{"sum(rate(metric[1m]))"},
+				"start": []string{start.Format(time.RFC3339)},
+				"end":   []string{start.Add(36 * time.Hour).Format(time.RFC3339)},
+				"step":  []string{"60"},
+			},
+			expectedStep:              "1m0s",
+			expectedSplitQueries:      2,
+			expectedDownstreamQueries: 2 * totalShards,
+			expectedShardingRewrites:  2,
+		},
+		"instant query": {
+			params: url.Values{
+				"query": []string{"sum(metric)"},
+				"time":  []string{start.Format(time.RFC3339)},
+			},
+			expectedSplitQueries:      0,
+			expectedDownstreamQueries: totalShards,
+			expectedShardingRewrites:  1,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/prometheus"+queryExplainPathSuffix+"?"+tc.params.Encode(), http.NoBody)
+			require.NoError(t, err)
+
+			queryStats, ctx := stats.ContextWithEmptyStats(context.Background())
+			ctx = user.InjectOrgID(ctx, "user-1")
+			req = req.WithContext(ctx)
+			require.NoError(t, user.InjectOrgIDIntoHTTPRequest(ctx, req))
+
+			resp, err := rt.RoundTrip(req)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, jsonMimeType, resp.Header.Get("Content-Type"))
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			actual := struct {
+				Status string           `json:"status"`
+				Data   queryExplanation `json:"data"`
+			}{}
+			require.NoError(t, json.Unmarshal(body, &actual))
+
+			assert.Equal(t, statusSuccess, actual.Status)
+			assert.Equal(t, tc.params.Get("query"), actual.Data.Query.Query)
+			assert.Equal(t, tc.expectedStep, actual.Data.Query.Step)
+			assert.Len(t, actual.Data.SplitQueries, tc.expectedSplitQueries)
+			assert.Len(t, actual.Data.DownstreamQueries, tc.expectedDownstreamQueries)
+			assert.Contains(t, actual.Data.Limits, explainedLimit{Name: "max_query_parallelism", Value: "16"})
+
+			shardingRewrites := 0
+			for _, rewrite := range actual.Data.Rewrites {
+				if rewrite.Middleware == "querysharding" {
+					shardingRewrites++
+					assert.Contains(t, rewrite.Query, "__embedded_queries__")
+				}
+			}
+			assert.Equal(t, tc.expectedShardingRewrites, shardingRewrites)
+
+			for _, splitQuery := range actual.Data.SplitQueries {
+				assert.Equal(t, explainCacheDisabled, splitQuery.Cache)
+			}
+
+			// The query stats of the explain request should not be updated as if the query was executed.
+			assert.Zero(t, queryStats.LoadShardedQueries())
+			assert.Zero(t, queryStats.LoadSplitQueries())
+		})
+	}
+}
+
+func TestSplitAndCacheMiddleware_ExplainShouldNotStoreInCache(t *testing.T) {
+	cacheBackend := cache.NewInstrumentedMockCache()
+	reg := prometheus.NewPedanticRegistry()
+
+	mw := newSplitAndCacheMiddleware(
+		true,
+		true,
+		24*time.Hour,
+		mockLimits{maxCacheFreshness: 10 * time.Minute, resultsCacheTTL: resultsCacheTTL, resultsCacheOutOfOrderWindowTTL: resultsCacheLowerTTL},
+		newTestPrometheusCodec(),
+		cacheBackend,
+		ConstSplitter(day),
+		PrometheusResponseExtractor{},
+		resultsCacheAlwaysEnabled,
+		log.NewNopLogger(),
+		reg,
+	)
+
+	rc := mw.Wrap(HandlerFunc(func(_ context.Context, _ Request) (Response, error) {
+		return &PrometheusResponse{
+			Status: statusSuccess,
+			Data: &PrometheusData{
+				ResultType: model.ValMatrix.String(),
+				Result: []SampleStream{{
+					Labels:  []mimirpb.LabelAdapter{{Name: "foo", Value: "bar"}},
+					Samples: []mimirpb.Sample{{Value: 1, TimestampMs: 1634292000000}},
+				}},
+			},
+		}, nil
+	}))
+
+	step := int64(120 * 1000)
+	ctx := user.InjectOrgID(context.Background(), "1")
+
+	// Execute a query to populate the cache for the first day.
+	_, err := rc.Do(ctx, &PrometheusRangeQueryRequest{
+		Path:  "/api/v1/query_range",
+		Start: parseTimeRFC3339(t, "2021-10-15T10:00:00Z").Unix() * 1000,
+		End:   parseTimeRFC3339(t, "2021-10-15T12:00:00Z").Unix() * 1000,
+		Step:  step,
+		Query: `{__name__=~".+"}`,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, cacheBackend.CountStoreCalls())
+
+	// Explain a query spanning over two days.
+	req := &PrometheusRangeQueryRequest{
+		Path:  "/api/v1/query_range",
+		Start: parseTimeRFC3339(t, "2021-10-15T10:00:00Z").Unix() * 1000,
+		End:   parseTimeRFC3339(t, "2021-10-16T12:00:00Z").Unix() * 1000,
+		Step:  step,
+		Query: `{__name__=~".+"}`,
+	}
+	explanation := newQueryExplanation(req)
+	_, err = rc.Do(contextWithQueryExplanation(ctx, explanation), req)
+	require.NoError(t, err)
+
+	require.Len(t, explanation.SplitQueries, 2)
+	assert.Equal(t, explainCachePartialHit, explanation.SplitQueries[0].Cache)
+	assert.Equal(t, []explainedTime{{
+		Start: parseTimeRFC3339(t, "2021-10-15T10:00:00Z"),
+		End:   parseTimeRFC3339(t, "2021-10-15T12:00:00Z"),
+	}}, explanation.SplitQueries[0].CachedExtents)
+	assert.Equal(t, explainCacheMiss, explanation.SplitQueries[1].Cache)
+	assert.Empty(t, explanation.SplitQueries[1].CachedExtents)
+
+	// The explained query should not have stored anything in the cache.
+	assert.Equal(t, 1, cacheBackend.CountStoreCalls())
+
+	// The results cache metrics should only track the executed query.
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_frontend_query_result_cache_attempted_total Total number of queries that were attempted to be fetched from cache.
+		# TYPE cortex_frontend_query_result_cache_attempted_total counter
+		cortex_frontend_query_result_cache_attempted_total 1
+
+		# HELP cortex_frontend_query_result_cache_requests_total Total number of requests (or partial requests) looked up in the results cache.
+		# TYPE cortex_frontend_query_result_cache_requests_total counter
+		cortex_frontend_query_result_cache_requests_total{request_type="query_range"} 1
+
+		# HELP cortex_frontend_query_result_cache_hits_total Total number of requests (or partial requests) fetched from the results cache.
+		# TYPE cortex_frontend_query_result_cache_hits_total counter
+		cortex_frontend_query_result_cache_hits_total{request_type="query_range"} 0
+	`), "cortex_frontend_query_result_cache_attempted_total", "cortex_frontend_query_result_cache_requests_total", "cortex_frontend_query_result_cache_hits_total"))
+}
+
+func TestQueryStatsMiddleware_ExplainShouldNotUpdateMetrics(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	mw := newQueryStatsMiddleware(reg)
+
+	req := &PrometheusRangeQueryRequest{
+		Path:  "/query_range",
+		Start: util.TimeToMillis(start),
+		End:   util.TimeToMillis(end),
+		Step:  step.Milliseconds(),
+		Query: `sum(sum_over_time(metric{app="test",namespace=~"short"}[5m]))`,
+	}
+	ctx := contextWithQueryExplanation(user.InjectOrgID(context.Background(), "test"), newQueryExplanation(req))
+	_, err := mw.Wrap(mockHandlerWith(nil, nil)).Do(ctx, req)
+	require.NoError(t, err)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_frontend_non_step_aligned_queries_total Total queries sent that are not step aligned.
+		# TYPE cortex_query_frontend_non_step_aligned_queries_total counter
+		cortex_query_frontend_non_step_aligned_queries_total 0
+		# HELP cortex_query_frontend_regexp_matcher_count Total number of regexp matchers
+		# TYPE cortex_query_frontend_regexp_matcher_count counter
+		cortex_query_frontend_regexp_matcher_count 0
+		# HELP cortex_query_frontend_regexp_matcher_optimized_count Total number of optimized regexp matchers
+		# TYPE cortex_query_frontend_regexp_matcher_optimized_count counter
+		cortex_query_frontend_regexp_matcher_optimized_count 0
+	`)))
+}
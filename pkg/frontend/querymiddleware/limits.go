@@ -66,8 +66,8 @@ type Limits interface {
 	// This method is copied from compactor.ConfigProvider.
 	CompactorSplitAndMergeShards(userID string) int
 
-	// CompactorBlocksRetentionPeriod returns the retention period for a given user.
-	CompactorBlocksRetentionPeriod(userID string) time.Duration
+	// CompactorBlocksMaxRetentionPeriod returns the retention period of the blocks of any resolution for a given user.
+	CompactorBlocksMaxRetentionPeriod(userID string) time.Duration
 
 	// OutOfOrderTimeWindow returns the out-of-order time window for the user.
 	OutOfOrderTimeWindow(userID string) time.Duration
@@ -97,6 +97,18 @@ type Limits interface {
 
 	// BlockedQueries returns the blocked queries.
 	BlockedQueries(userID string) []*validation.BlockedQuery
+
+	// MaxFetchedSeriesPerQuery returns the maximum number of unique series allowed to be fetched by a query.
+	// 0 means "unlimited".
+	MaxFetchedSeriesPerQuery(userID string) int
+
+	// MaxQueryResponseSizeBytes returns the limit of the size of the response returned by the query-frontend,
+	// in bytes. 0 means "unlimited".
+	MaxQueryResponseSizeBytes(userID string) int
+
+	// MaxQueryResponseSeries returns the limit of the number of series in the response returned by the
+	// query-frontend. 0 means "unlimited".
+	MaxQueryResponseSeries(userID string) int
 }
 
 type limitsMiddleware struct {
@@ -125,12 +137,20 @@ func (l limitsMiddleware) Do(ctx context.Context, r Request) (Response, error) {
 		return nil, apierror.New(apierror.TypeBadData, err.Error())
 	}
 
+	explanation := queryExplanationFromContext(ctx)
+
 	// Clamp the time range based on the max query lookback and block retention period.
-	blocksRetentionPeriod := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, l.CompactorBlocksRetentionPeriod)
+	blocksRetentionPeriod := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, l.CompactorBlocksMaxRetentionPeriod)
 	maxQueryLookback := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, l.MaxQueryLookback)
 	maxLookback := util_math.Min(blocksRetentionPeriod, maxQueryLookback)
 	if maxLookback > 0 {
 		minStartTime := util.TimeToMillis(time.Now().Add(-maxLookback))
+		if blocksRetentionPeriod > 0 {
+			explanation.addLimit("compactor_blocks_retention_period", blocksRetentionPeriod)
+		}
+		if maxQueryLookback > 0 {
+			explanation.addLimit("max_query_lookback", maxQueryLookback)
+		}
 
 		if r.GetEnd() < minStartTime {
 			// The request is fully outside the allowed range, so we can return an
@@ -142,6 +162,7 @@ func (l limitsMiddleware) Do(ctx context.Context, r Request) (Response, error) {
 				"maxQueryLookback", maxQueryLookback,
 				"blocksRetentionPeriod", blocksRetentionPeriod)
 
+			explanation.addRewrite("limits", "query skipped because its time range is before the 'max query lookback' or 'blocks retention period' setting", "")
 			return newEmptyPrometheusResponse(), nil
 		}
 
@@ -154,6 +175,7 @@ func (l limitsMiddleware) Do(ctx context.Context, r Request) (Response, error) {
 				"maxQueryLookback", maxQueryLookback,
 				"blocksRetentionPeriod", blocksRetentionPeriod)
 
+			explanation.addRewrite("limits", fmt.Sprintf("start time changed to %s because of the 'max query lookback' or 'blocks retention period' setting", util.FormatTimeMillis(minStartTime)), "")
 			r = r.WithStartEnd(minStartTime, r.GetEnd())
 		}
 	}
@@ -161,6 +183,7 @@ func (l limitsMiddleware) Do(ctx context.Context, r Request) (Response, error) {
 	// Enforce the max end time.
 	creationGracePeriod := validation.LargestPositiveNonZeroDurationPerTenant(tenantIDs, l.CreationGracePeriod)
 	maxEndTime := util.TimeToMillis(time.Now().Add(creationGracePeriod))
+	explanation.addLimit("creation_grace_period", creationGracePeriod)
 	if r.GetEnd() > maxEndTime {
 		// Replace the end time in the request.
 		level.Debug(log).Log(
@@ -169,11 +192,13 @@ func (l limitsMiddleware) Do(ctx context.Context, r Request) (Response, error) {
 			"updated", util.FormatTimeMillis(maxEndTime),
 			"creationGracePeriod", creationGracePeriod)
 
+		explanation.addRewrite("limits", fmt.Sprintf("end time changed to %s because of the 'creation grace period' setting", util.FormatTimeMillis(maxEndTime)), "")
 		r = r.WithStartEnd(r.GetStart(), maxEndTime)
 	}
 
 	// Enforce max query size, in bytes.
 	if maxQuerySize := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, l.MaxQueryExpressionSizeBytes); maxQuerySize > 0 {
+		explanation.addLimit("max_query_expression_size_bytes", maxQuerySize)
 		querySize := len(r.GetQuery())
 		if querySize > maxQuerySize {
 			return nil, apierror.New(apierror.TypeBadData, validation.NewMaxQueryExpressionSizeBytesError(querySize, maxQuerySize).Error())
@@ -182,6 +207,7 @@ func (l limitsMiddleware) Do(ctx context.Context, r Request) (Response, error) {
 
 	// Enforce the max query length.
 	if maxQueryLength := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, l.MaxTotalQueryLength); maxQueryLength > 0 {
+		explanation.addLimit("max_total_query_length", maxQueryLength)
 		queryLen := timestamp.Time(r.GetEnd()).Sub(timestamp.Time(r.GetStart()))
 		if queryLen > maxQueryLength {
 			return nil, apierror.New(apierror.TypeBadData, validation.NewMaxTotalQueryLengthError(queryLen, maxQueryLength).Error())
@@ -249,7 +275,7 @@ func (rt limitedParallelismRoundTripper) RoundTrip(r *http.Request) (*http.Respo
 		return nil, err
 	}
 
-	return rt.codec.EncodeResponse(ctx, r, response)
+	return rt.codec.EncodeResponse(contextWithQueryResponseLimits(ctx, newQueryResponseLimits(tenantIDs, rt.limits)), r, response)
 }
 
 // roundTripperHandler is an adapter that implements the Handler interface using a http.RoundTripper to perform
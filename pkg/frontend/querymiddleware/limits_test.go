@@ -400,7 +400,7 @@ func (m multiTenantMockLimits) CompactorSplitAndMergeShards(userID string) int {
 	return m.byTenant[userID].compactorShards
 }
 
-func (m multiTenantMockLimits) CompactorBlocksRetentionPeriod(userID string) time.Duration {
+func (m multiTenantMockLimits) CompactorBlocksMaxRetentionPeriod(userID string) time.Duration {
 	return m.byTenant[userID].compactorBlocksRetentionPeriod
 }
 
@@ -432,6 +432,18 @@ func (m multiTenantMockLimits) BlockedQueries(userID string) []*validation.Block
 	return m.byTenant[userID].blockedQueries
 }
 
+func (m multiTenantMockLimits) MaxFetchedSeriesPerQuery(userID string) int {
+	return m.byTenant[userID].maxFetchedSeriesPerQuery
+}
+
+func (m multiTenantMockLimits) MaxQueryResponseSizeBytes(userID string) int {
+	return m.byTenant[userID].maxQueryResponseSizeBytes
+}
+
+func (m multiTenantMockLimits) MaxQueryResponseSeries(userID string) int {
+	return m.byTenant[userID].maxQueryResponseSeries
+}
+
 func (m multiTenantMockLimits) CreationGracePeriod(userID string) time.Duration {
 	return m.byTenant[userID].creationGracePeriod
 }
@@ -462,6 +474,9 @@ type mockLimits struct {
 	resultsCacheTTLForLabelsQuery        time.Duration
 	resultsCacheForUnalignedQueryEnabled bool
 	blockedQueries                       []*validation.BlockedQuery
+	maxFetchedSeriesPerQuery             int
+	maxQueryResponseSizeBytes            int
+	maxQueryResponseSeries               int
 }
 
 func (m mockLimits) MaxQueryLookback(string) time.Duration {
@@ -510,7 +525,7 @@ func (m mockLimits) CompactorSplitAndMergeShards(string) int {
 	return m.compactorShards
 }
 
-func (m mockLimits) CompactorBlocksRetentionPeriod(string) time.Duration {
+func (m mockLimits) CompactorBlocksMaxRetentionPeriod(string) time.Duration {
 	return m.compactorBlocksRetentionPeriod
 }
 
@@ -542,6 +557,18 @@ func (m mockLimits) ResultsCacheForUnalignedQueryEnabled(string) bool {
 	return m.resultsCacheForUnalignedQueryEnabled
 }
 
+func (m mockLimits) MaxFetchedSeriesPerQuery(string) int {
+	return m.maxFetchedSeriesPerQuery
+}
+
+func (m mockLimits) MaxQueryResponseSizeBytes(string) int {
+	return m.maxQueryResponseSizeBytes
+}
+
+func (m mockLimits) MaxQueryResponseSeries(string) int {
+	return m.maxQueryResponseSeries
+}
+
 func (m mockLimits) CreationGracePeriod(string) time.Duration {
 	return m.creationGracePeriod
 }
@@ -358,8 +358,9 @@ func (m *PrometheusResponse) GetHeaders() []*PrometheusResponseHeader {
 }
 
 type PrometheusData struct {
-	ResultType string         `protobuf:"bytes,1,opt,name=ResultType,proto3" json:"resultType"`
-	Result     []SampleStream `protobuf:"bytes,2,rep,name=Result,proto3" json:"result"`
+	ResultType string              `protobuf:"bytes,1,opt,name=ResultType,proto3" json:"resultType"`
+	Result     []SampleStream      `protobuf:"bytes,2,rep,name=Result,proto3" json:"result"`
+	Stats      *mimirpb.QueryStats `protobuf:"bytes,3,opt,name=Stats,proto3" json:"stats,omitempty"`
 }
 
 func (m *PrometheusData) Reset()      { *m = PrometheusData{} }
@@ -408,6 +409,13 @@ func (m *PrometheusData) GetResult() []SampleStream {
 	return nil
 }
 
+func (m *PrometheusData) GetStats() *mimirpb.QueryStats {
+	if m != nil {
+		return m.Stats
+	}
+	return nil
+}
+
 type SampleStream struct {
 	Labels     []github_com_grafana_mimir_pkg_mimirpb.LabelAdapter `protobuf:"bytes,1,rep,name=labels,proto3,customtype=github.com/grafana/mimir/pkg/mimirpb.LabelAdapter" json:"metric"`
 	Samples    []mimirpb.Sample                                    `protobuf:"bytes,2,rep,name=samples,proto3" json:"values"`
@@ -597,6 +605,12 @@ type Options struct {
 	InstantSplitDisabled bool  `protobuf:"varint,4,opt,name=InstantSplitDisabled,proto3" json:"InstantSplitDisabled,omitempty"`
 	// Instant split by time interval unit stored in nanoseconds (time.Duration unit in int64)
 	InstantSplitInterval int64 `protobuf:"varint,5,opt,name=InstantSplitInterval,proto3" json:"InstantSplitInterval,omitempty"`
+	// Disables splitting range queries by time interval.
+	SplitDisabled bool `protobuf:"varint,6,opt,name=SplitDisabled,proto3" json:"SplitDisabled,omitempty"`
+	// The value of the "stats" request param. If not empty, query statistics are returned in the response.
+	Stats string `protobuf:"bytes,7,opt,name=Stats,proto3" json:"Stats,omitempty"`
+	// Enables the per-step stats, computed by the queriers and returned in the response query statistics.
+	PerStepStatsEnabled bool `protobuf:"varint,8,opt,name=PerStepStatsEnabled,proto3" json:"PerStepStatsEnabled,omitempty"`
 }
 
 func (m *Options) Reset()      { *m = Options{} }
@@ -666,6 +680,27 @@ func (m *Options) GetInstantSplitInterval() int64 {
 	return 0
 }
 
+func (m *Options) GetSplitDisabled() bool {
+	if m != nil {
+		return m.SplitDisabled
+	}
+	return false
+}
+
+func (m *Options) GetStats() string {
+	if m != nil {
+		return m.Stats
+	}
+	return ""
+}
+
+func (m *Options) GetPerStepStatsEnabled() bool {
+	if m != nil {
+		return m.PerStepStatsEnabled
+	}
+	return false
+}
+
 type Hints struct {
 	// Total number of queries that are expected to to be executed to serve the original request.
 	TotalQueries int32 `protobuf:"varint,1,opt,name=TotalQueries,proto3" json:"TotalQueries,omitempty"`
@@ -938,83 +973,86 @@ func init() {
 func init() { proto.RegisterFile("model.proto", fileDescriptor_4c16552f9fdb66d8) }
 
 var fileDescriptor_4c16552f9fdb66d8 = []byte{
-	// 1202 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x55, 0x4d, 0x8f, 0x1b, 0x45,
-	0x13, 0xf6, 0xd8, 0xe3, 0xaf, 0xf2, 0xbe, 0xbb, 0xfb, 0xf6, 0x2e, 0x62, 0x36, 0x21, 0x33, 0xd6,
-	0x28, 0x87, 0x05, 0x25, 0x5e, 0xd8, 0x00, 0x07, 0x04, 0x88, 0xcc, 0x66, 0xd1, 0x86, 0xcf, 0xa5,
-	0x77, 0xc5, 0x81, 0xcb, 0xaa, 0xed, 0xe9, 0xd8, 0x43, 0xe6, 0x2b, 0xd3, 0xed, 0x10, 0xdf, 0x10,
-	0x3f, 0x00, 0x71, 0xe4, 0xc4, 0x0d, 0x89, 0x5f, 0xc0, 0x6f, 0xc8, 0x31, 0xdc, 0xa2, 0x1c, 0x0c,
-	0x71, 0x84, 0x84, 0x7c, 0xca, 0x4f, 0x40, 0x5d, 0x3d, 0x63, 0xcf, 0x7e, 0x20, 0xc2, 0xc5, 0xae,
-	0xae, 0x7a, 0xaa, 0xfa, 0xa9, 0x9a, 0xee, 0xa7, 0xa1, 0x13, 0x25, 0x3e, 0x0f, 0x7b, 0x69, 0x96,
-	0xc8, 0x84, 0xc0, 0xbd, 0x31, 0xcf, 0x26, 0x19, 0x8b, 0x87, 0xfc, 0xd2, 0xf5, 0x61, 0x20, 0x47,
-	0xe3, 0x7e, 0x6f, 0x90, 0x44, 0x3b, 0xc3, 0x64, 0x98, 0xec, 0x20, 0xa4, 0x3f, 0xbe, 0x83, 0x2b,
-	0x5c, 0xa0, 0xa5, 0x53, 0x2f, 0xd9, 0xc3, 0x24, 0x19, 0x86, 0x7c, 0x89, 0xf2, 0xc7, 0x19, 0x93,
-	0x41, 0x12, 0xe7, 0xf1, 0xd7, 0xcb, 0xe5, 0x32, 0x76, 0x87, 0xc5, 0x6c, 0x27, 0x0a, 0xa2, 0x20,
-	0xdb, 0x49, 0xef, 0x0e, 0xb5, 0x95, 0xf6, 0xf5, 0x7f, 0x9e, 0xb1, 0x75, 0xb6, 0x22, 0x8b, 0x27,
-	0x3a, 0xe4, 0xfe, 0x5a, 0x85, 0xcb, 0x87, 0x59, 0x12, 0x71, 0x39, 0xe2, 0x63, 0x41, 0x15, 0xdf,
-	0x2f, 0x14, 0x73, 0xca, 0xef, 0x8d, 0xb9, 0x90, 0x84, 0x80, 0x99, 0x32, 0x39, 0xb2, 0x8c, 0xae,
-	0xb1, 0xdd, 0xa6, 0x68, 0x93, 0x4d, 0xa8, 0x0b, 0xc9, 0x32, 0x69, 0x55, 0xbb, 0xc6, 0x76, 0x8d,
-	0xea, 0x05, 0x59, 0x87, 0x1a, 0x8f, 0x7d, 0xab, 0x86, 0x3e, 0x65, 0xaa, 0x5c, 0x21, 0x79, 0x6a,
-	0x99, 0xe8, 0x42, 0x9b, 0xbc, 0x07, 0x4d, 0x19, 0x44, 0x3c, 0x19, 0x4b, 0xab, 0xde, 0x35, 0xb6,
-	0x3b, 0xbb, 0x5b, 0x3d, 0x4d, 0xae, 0x57, 0x90, 0xeb, 0xdd, 0xca, 0xdb, 0xf5, 0x5a, 0x0f, 0xa7,
-	0x4e, 0xe5, 0xc7, 0xdf, 0x1d, 0x83, 0x16, 0x39, 0x6a, 0x6b, 0x1c, 0xac, 0xd5, 0x40, 0x3e, 0x7a,
-	0x41, 0x6e, 0x40, 0x33, 0x49, 0x55, 0x8a, 0xb0, 0x9a, 0x58, 0x74, 0xa3, 0xb7, 0x1c, 0x7f, 0xef,
-	0x73, 0x1d, 0xf2, 0x4c, 0x55, 0x8e, 0x16, 0x48, 0xb2, 0x0a, 0xd5, 0xc0, 0xb7, 0x5a, 0xc8, 0xad,
-	0x1a, 0xf8, 0xe4, 0x3a, 0xd4, 0x47, 0x41, 0x2c, 0x85, 0xd5, 0xc6, 0x12, 0xff, 0x2f, 0x97, 0x38,
-	0x50, 0x01, 0x2c, 0x60, 0x50, 0x8d, 0x72, 0x7f, 0x33, 0xe0, 0xca, 0x72, 0x70, 0xb7, 0x63, 0x21,
-	0x59, 0x2c, 0xff, 0x75, 0x74, 0x04, 0x4c, 0xd5, 0x4a, 0x3e, 0x39, 0xb4, 0x97, 0x3d, 0xd5, 0xfe,
-	0xa1, 0x27, 0xf3, 0x3f, 0xf6, 0x54, 0x3f, 0xdf, 0x53, 0xe3, 0x85, 0x7a, 0x3a, 0x06, 0xab, 0x74,
-	0x16, 0xb8, 0x48, 0x93, 0x58, 0xf0, 0x03, 0xce, 0x7c, 0x9e, 0x91, 0x2d, 0x30, 0x3f, 0x63, 0x11,
-	0xd7, 0xdd, 0x78, 0xf5, 0xf9, 0xd4, 0x31, 0xae, 0x53, 0x74, 0x91, 0x2b, 0xd0, 0xf8, 0x92, 0x85,
-	0x63, 0x2e, 0xac, 0x6a, 0xb7, 0xb6, 0x0c, 0xe6, 0x4e, 0xf7, 0xe7, 0x2a, 0x90, 0xf3, 0x65, 0x89,
-	0x0b, 0x8d, 0x23, 0xc9, 0xe4, 0x58, 0xe4, 0x25, 0x61, 0x3e, 0x75, 0x1a, 0x02, 0x3d, 0x34, 0x8f,
-	0x10, 0x0f, 0xcc, 0x5b, 0x4c, 0x32, 0x1c, 0x57, 0x67, 0xf7, 0x52, 0x99, 0xfe, 0xb2, 0xa2, 0x42,
-	0x78, 0x64, 0x3e, 0x75, 0x56, 0x7d, 0x26, 0xd9, 0xb5, 0x24, 0x0a, 0x24, 0x8f, 0x52, 0x39, 0xa1,
-	0x98, 0x4b, 0xde, 0x82, 0xf6, 0x7e, 0x96, 0x25, 0xd9, 0xf1, 0x24, 0xe5, 0x7a, 0xc4, 0xde, 0xcb,
-	0xf3, 0xa9, 0xb3, 0xc1, 0x0b, 0x67, 0x29, 0x63, 0x89, 0x24, 0xaf, 0x42, 0x1d, 0x17, 0x38, 0xfd,
-	0xb6, 0xb7, 0x31, 0x9f, 0x3a, 0x6b, 0x98, 0x52, 0x82, 0x6b, 0x04, 0xd9, 0x87, 0xa6, 0x1e, 0x92,
-	0xb0, 0xea, 0xdd, 0xda, 0x76, 0x67, 0xf7, 0xea, 0xc5, 0x44, 0x4f, 0x4f, 0xb4, 0x18, 0x53, 0x91,
-	0xeb, 0x7e, 0x67, 0xc0, 0xea, 0xe9, 0xae, 0x48, 0x0f, 0x80, 0x72, 0x31, 0x0e, 0x25, 0x92, 0xd7,
-	0x73, 0x5a, 0x9d, 0x4f, 0x1d, 0xc8, 0x16, 0x5e, 0x5a, 0x42, 0x90, 0x0f, 0xa0, 0xa1, 0x57, 0xf8,
-	0x25, 0x3a, 0xbb, 0x56, 0x99, 0xc8, 0x11, 0x8b, 0xd2, 0x90, 0x1f, 0xc9, 0x8c, 0xb3, 0xc8, 0x5b,
-	0x55, 0x07, 0x47, 0x4d, 0x5c, 0x57, 0xa2, 0x79, 0x9e, 0xfb, 0x7d, 0x15, 0x56, 0xca, 0x40, 0x92,
-	0x42, 0x23, 0x64, 0x7d, 0x1e, 0xaa, 0xcf, 0x54, 0xc3, 0x63, 0x38, 0x48, 0x32, 0xc9, 0x1f, 0xa4,
-	0xfd, 0xde, 0x27, 0xca, 0x7f, 0xc8, 0x82, 0xcc, 0xdb, 0x53, 0xd5, 0x9e, 0x4c, 0x9d, 0x37, 0x5e,
-	0x44, 0x9a, 0x74, 0xde, 0x4d, 0x9f, 0xa5, 0x92, 0x67, 0x8a, 0x42, 0xc4, 0x65, 0x16, 0x0c, 0x68,
-	0xbe, 0x0f, 0x79, 0x07, 0x9a, 0x02, 0x19, 0x88, 0xbc, 0x8b, 0xf5, 0xe5, 0x96, 0x9a, 0xda, 0x92,
-	0xfd, 0x7d, 0x3c, 0x62, 0xb4, 0x48, 0x20, 0x87, 0x00, 0xa3, 0x40, 0xc8, 0x64, 0x98, 0xb1, 0x48,
-	0x58, 0x35, 0x4c, 0x7f, 0x65, 0x99, 0xfe, 0x61, 0x98, 0x30, 0x79, 0x50, 0x00, 0x90, 0x3a, 0xc9,
-	0x4b, 0x95, 0xf2, 0x68, 0xc9, 0x76, 0xbf, 0x86, 0xd5, 0x3d, 0x36, 0x18, 0x71, 0x7f, 0x71, 0x70,
-	0xb7, 0xa0, 0x76, 0x97, 0x4f, 0xf2, 0xaf, 0xd1, 0x9c, 0x4f, 0x1d, 0xb5, 0xa4, 0xea, 0x47, 0xa9,
-	0x1b, 0x7f, 0x20, 0xb9, 0xba, 0x71, 0x9a, 0x3a, 0x29, 0x7f, 0x80, 0x7d, 0x0c, 0x79, 0x6b, 0xf9,
-	0x8e, 0x05, 0x94, 0x16, 0x86, 0xfb, 0xc4, 0x80, 0x86, 0x06, 0x11, 0xa7, 0xd0, 0x58, 0xb5, 0x4d,
-	0xcd, 0x6b, 0xcf, 0xa7, 0x8e, 0x76, 0x14, 0x72, 0xbb, 0xa5, 0xe5, 0x16, 0x85, 0x44, 0xb3, 0xe0,
-	0xb1, 0xaf, 0x75, 0xb7, 0x0b, 0x2d, 0x99, 0xb1, 0x01, 0x3f, 0x09, 0xfc, 0xfc, 0xf4, 0x16, 0x47,
-	0x0d, 0xdd, 0xb7, 0x7d, 0xf2, 0x3e, 0xb4, 0xb2, 0xbc, 0x9d, 0x5c, 0x86, 0x37, 0xcf, 0xc9, 0xf0,
-	0xcd, 0x78, 0xe2, 0xad, 0xcc, 0xa7, 0xce, 0x02, 0x49, 0x17, 0x16, 0xb9, 0x06, 0x04, 0xfb, 0x3a,
-	0x51, 0x02, 0x26, 0x24, 0x8b, 0xd2, 0x93, 0x48, 0x8b, 0x4c, 0x8d, 0xae, 0x63, 0xe4, 0xb8, 0x08,
-	0x7c, 0x2a, 0x3e, 0x32, 0x5b, 0xb5, 0x75, 0xd3, 0xfd, 0xd3, 0x80, 0x66, 0x2e, 0x5b, 0xe4, 0x2a,
-	0xfc, 0x0f, 0x87, 0x7a, 0x2b, 0x10, 0xac, 0x1f, 0x72, 0x1f, 0xbb, 0x6c, 0xd1, 0xd3, 0x4e, 0xf2,
-	0x1a, 0xac, 0x1f, 0x8d, 0x58, 0xe6, 0x07, 0xf1, 0x70, 0x01, 0xac, 0x22, 0xf0, 0x9c, 0x9f, 0x74,
-	0xa1, 0x73, 0x9c, 0x48, 0x16, 0x62, 0x40, 0xe0, 0x3d, 0xaf, 0xd3, 0xb2, 0x8b, 0xec, 0xc2, 0x66,
-	0xae, 0xd2, 0x47, 0x69, 0x18, 0xc8, 0x45, 0x45, 0x13, 0x2b, 0x5e, 0x18, 0x3b, 0x9b, 0x73, 0x3b,
-	0x96, 0x3c, 0xbb, 0xcf, 0xc2, 0x5c, 0x61, 0x2f, 0x8c, 0xb9, 0x0f, 0xa0, 0x8e, 0xd2, 0x4a, 0x5c,
-	0x58, 0xc1, 0xfd, 0xd5, 0xa3, 0x10, 0x70, 0x2d, 0x73, 0x75, 0x7a, 0xca, 0x47, 0xde, 0x84, 0xcd,
-	0x7d, 0x21, 0x83, 0x88, 0x49, 0xee, 0x1f, 0xa1, 0x6b, 0x2f, 0x19, 0xc7, 0xfa, 0x65, 0x35, 0x0f,
-	0x2a, 0xf4, 0xc2, 0xa8, 0xf7, 0x12, 0x6c, 0xec, 0x61, 0xff, 0x2c, 0x0c, 0xe4, 0xa4, 0x80, 0xb8,
-	0xfb, 0xb0, 0x86, 0x0f, 0x90, 0x12, 0xcf, 0x40, 0xc8, 0x60, 0x80, 0x4d, 0x5f, 0x58, 0x5f, 0x71,
-	0x31, 0x2f, 0xae, 0xee, 0xfe, 0x64, 0x00, 0xd1, 0x47, 0xfe, 0xe0, 0xf8, 0xf8, 0x70, 0x71, 0xec,
-	0x2f, 0x43, 0x7b, 0xa0, 0xbc, 0x27, 0x8b, 0xc3, 0x4f, 0x5b, 0xe8, 0xf8, 0x98, 0x4f, 0x88, 0x03,
-	0x1d, 0x2d, 0xdd, 0x27, 0x83, 0xc4, 0xd7, 0xcf, 0x5b, 0x9d, 0x82, 0x76, 0xed, 0x25, 0x3e, 0x27,
-	0x6f, 0x43, 0x73, 0x94, 0x6b, 0x64, 0x71, 0x2b, 0x4b, 0x37, 0x63, 0xb9, 0x9d, 0x16, 0x43, 0x5a,
-	0x80, 0xd5, 0x83, 0xd9, 0x4f, 0xfc, 0x09, 0x7e, 0xa5, 0x15, 0x8a, 0xb6, 0xfb, 0x2e, 0xac, 0x9f,
-	0x4d, 0x50, 0xb8, 0x78, 0xf1, 0x3c, 0x51, 0xb4, 0xd5, 0xc3, 0x8a, 0xfa, 0x80, 0x74, 0xda, 0x54,
-	0x2f, 0xbc, 0xfd, 0x47, 0x4f, 0xed, 0xca, 0xe3, 0xa7, 0x76, 0xe5, 0xf9, 0x53, 0xdb, 0xf8, 0x76,
-	0x66, 0x1b, 0xbf, 0xcc, 0x6c, 0xe3, 0xe1, 0xcc, 0x36, 0x1e, 0xcd, 0x6c, 0xe3, 0x8f, 0x99, 0x6d,
-	0xfc, 0x35, 0xb3, 0x2b, 0xcf, 0x67, 0xb6, 0xf1, 0xc3, 0x33, 0xbb, 0xf2, 0xe8, 0x99, 0x5d, 0x79,
-	0xfc, 0xcc, 0xae, 0x7c, 0xb5, 0x86, 0x6c, 0xa3, 0xc0, 0xf7, 0x43, 0xfe, 0x0d, 0xcb, 0x78, 0xbf,
-	0x81, 0x17, 0xe5, 0xc6, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0xf2, 0xd9, 0xcc, 0xb5, 0xf6, 0x09,
-	0x00, 0x00,
+	// 1256 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0xcb, 0x72, 0x1b, 0x45,
+	0x17, 0xd6, 0xe8, 0xee, 0x23, 0xff, 0xb6, 0xff, 0xb6, 0x29, 0xc6, 0x09, 0x99, 0x51, 0x4d, 0x65,
+	0x61, 0xa8, 0x44, 0x0e, 0x0e, 0xb0, 0xa0, 0xb8, 0x65, 0x1c, 0x51, 0x0e, 0x57, 0xd3, 0x72, 0xb1,
+	0x60, 0xe3, 0x6a, 0x69, 0x3a, 0xd2, 0x90, 0xb9, 0xa5, 0xa7, 0x15, 0xa2, 0x1d, 0x4f, 0x40, 0xb1,
+	0x64, 0xc5, 0x8e, 0x2a, 0x9e, 0x80, 0x17, 0x60, 0x93, 0x65, 0x28, 0x36, 0xa9, 0x2c, 0x04, 0x51,
+	0x36, 0x94, 0x56, 0x79, 0x04, 0xaa, 0x4f, 0xcf, 0x48, 0xe3, 0x58, 0x14, 0x61, 0x63, 0x9f, 0x3e,
+	0x97, 0xaf, 0xcf, 0xf9, 0xa6, 0xfb, 0x6b, 0x41, 0x2b, 0x8c, 0x3d, 0x1e, 0x74, 0x12, 0x11, 0xcb,
+	0x98, 0xc0, 0xdd, 0x31, 0x17, 0x13, 0xc1, 0xa2, 0x21, 0xbf, 0x70, 0x75, 0xe8, 0xcb, 0xd1, 0xb8,
+	0xdf, 0x19, 0xc4, 0xe1, 0xfe, 0x30, 0x1e, 0xc6, 0xfb, 0x98, 0xd2, 0x1f, 0xdf, 0xc6, 0x15, 0x2e,
+	0xd0, 0xd2, 0xa5, 0x17, 0xac, 0x61, 0x1c, 0x0f, 0x03, 0xbe, 0xcc, 0xf2, 0xc6, 0x82, 0x49, 0x3f,
+	0x8e, 0xb2, 0xf8, 0xb5, 0x22, 0x9c, 0x60, 0xb7, 0x59, 0xc4, 0xf6, 0x43, 0x3f, 0xf4, 0xc5, 0x7e,
+	0x72, 0x67, 0xa8, 0xad, 0xa4, 0xaf, 0xff, 0x67, 0x15, 0xbb, 0xcf, 0x23, 0xb2, 0x68, 0xa2, 0x43,
+	0xce, 0x2f, 0x65, 0xb8, 0x78, 0x2c, 0xe2, 0x90, 0xcb, 0x11, 0x1f, 0xa7, 0x54, 0xf5, 0xfb, 0x85,
+	0xea, 0x9c, 0xf2, 0xbb, 0x63, 0x9e, 0x4a, 0x42, 0xa0, 0x9a, 0x30, 0x39, 0x32, 0x8d, 0xb6, 0xb1,
+	0xb7, 0x46, 0xd1, 0x26, 0x3b, 0x50, 0x4b, 0x25, 0x13, 0xd2, 0x2c, 0xb7, 0x8d, 0xbd, 0x0a, 0xd5,
+	0x0b, 0xb2, 0x05, 0x15, 0x1e, 0x79, 0x66, 0x05, 0x7d, 0xca, 0x54, 0xb5, 0xa9, 0xe4, 0x89, 0x59,
+	0x45, 0x17, 0xda, 0xe4, 0x5d, 0x68, 0x48, 0x3f, 0xe4, 0xf1, 0x58, 0x9a, 0xb5, 0xb6, 0xb1, 0xd7,
+	0x3a, 0xd8, 0xed, 0xe8, 0xe6, 0x3a, 0x79, 0x73, 0x9d, 0x9b, 0xd9, 0xb8, 0x6e, 0xf3, 0xc1, 0xd4,
+	0x2e, 0xfd, 0xf0, 0x87, 0x6d, 0xd0, 0xbc, 0x46, 0x6d, 0x8d, 0xc4, 0x9a, 0x75, 0xec, 0x47, 0x2f,
+	0xc8, 0x75, 0x68, 0xc4, 0x89, 0x2a, 0x49, 0xcd, 0x06, 0x82, 0x6e, 0x77, 0x96, 0xf4, 0x77, 0x3e,
+	0xd7, 0x21, 0xb7, 0xaa, 0xe0, 0x68, 0x9e, 0x49, 0x36, 0xa0, 0xec, 0x7b, 0x66, 0x13, 0x7b, 0x2b,
+	0xfb, 0x1e, 0xb9, 0x0a, 0xb5, 0x91, 0x1f, 0xc9, 0xd4, 0x5c, 0x43, 0x88, 0xff, 0x17, 0x21, 0x8e,
+	0x54, 0x00, 0x01, 0x0c, 0xaa, 0xb3, 0x9c, 0xdf, 0x0c, 0xb8, 0xb4, 0x24, 0xee, 0x56, 0x94, 0x4a,
+	0x16, 0xc9, 0x7f, 0xa5, 0x8e, 0x40, 0x55, 0x8d, 0x92, 0x31, 0x87, 0xf6, 0x72, 0xa6, 0xca, 0x3f,
+	0xcc, 0x54, 0xfd, 0x8f, 0x33, 0xd5, 0xce, 0xcf, 0x54, 0x7f, 0xa1, 0x99, 0x4e, 0xc0, 0x2c, 0x9c,
+	0x05, 0x9e, 0x26, 0x71, 0x94, 0xf2, 0x23, 0xce, 0x3c, 0x2e, 0xc8, 0x2e, 0x54, 0x3f, 0x63, 0x21,
+	0xd7, 0xd3, 0xb8, 0xb5, 0xf9, 0xd4, 0x36, 0xae, 0x52, 0x74, 0x91, 0x4b, 0x50, 0xff, 0x92, 0x05,
+	0x63, 0x9e, 0x9a, 0xe5, 0x76, 0x65, 0x19, 0xcc, 0x9c, 0xce, 0x4f, 0x65, 0x20, 0xe7, 0x61, 0x89,
+	0x03, 0xf5, 0x9e, 0x64, 0x72, 0x9c, 0x66, 0x90, 0x30, 0x9f, 0xda, 0xf5, 0x14, 0x3d, 0x34, 0x8b,
+	0x10, 0x17, 0xaa, 0x37, 0x99, 0x64, 0x48, 0x57, 0xeb, 0xe0, 0x42, 0xb1, 0xfd, 0x25, 0xa2, 0xca,
+	0x70, 0xc9, 0x7c, 0x6a, 0x6f, 0x78, 0x4c, 0xb2, 0x2b, 0x71, 0xe8, 0x4b, 0x1e, 0x26, 0x72, 0x42,
+	0xb1, 0x96, 0xbc, 0x09, 0x6b, 0x5d, 0x21, 0x62, 0x71, 0x32, 0x49, 0xb8, 0xa6, 0xd8, 0x7d, 0x79,
+	0x3e, 0xb5, 0xb7, 0x79, 0xee, 0x2c, 0x54, 0x2c, 0x33, 0xc9, 0xab, 0x50, 0xc3, 0x05, 0xb2, 0xbf,
+	0xe6, 0x6e, 0xcf, 0xa7, 0xf6, 0x26, 0x96, 0x14, 0xd2, 0x75, 0x06, 0xe9, 0x42, 0x43, 0x93, 0x94,
+	0x9a, 0xb5, 0x76, 0x65, 0xaf, 0x75, 0x70, 0x79, 0x75, 0xa3, 0x67, 0x19, 0xcd, 0x69, 0xca, 0x6b,
+	0x9d, 0x5f, 0x0d, 0xd8, 0x38, 0x3b, 0x15, 0xe9, 0x00, 0x50, 0x9e, 0x8e, 0x03, 0x89, 0xcd, 0x6b,
+	0x9e, 0x36, 0xe6, 0x53, 0x1b, 0xc4, 0xc2, 0x4b, 0x0b, 0x19, 0xe4, 0x03, 0xa8, 0xeb, 0x15, 0x7e,
+	0x89, 0xd6, 0x81, 0x59, 0x6c, 0xa4, 0xc7, 0xc2, 0x24, 0xe0, 0x3d, 0x29, 0x38, 0x0b, 0xdd, 0x0d,
+	0x75, 0x70, 0x14, 0xe3, 0x1a, 0x89, 0x66, 0x75, 0xe4, 0x7d, 0xa8, 0x29, 0xee, 0x53, 0x64, 0xaa,
+	0x75, 0xb0, 0xd3, 0x19, 0xc4, 0x42, 0xf2, 0xfb, 0x49, 0xbf, 0x83, 0x67, 0x1b, 0x63, 0x9a, 0x0c,
+	0xf5, 0xa9, 0xd2, 0x22, 0x19, 0x18, 0x73, 0xbe, 0x2b, 0xc3, 0x7a, 0x71, 0x27, 0x92, 0x40, 0x3d,
+	0x60, 0x7d, 0x1e, 0xa8, 0xef, 0x5c, 0xc1, 0x73, 0xbc, 0x80, 0xfc, 0x44, 0xf9, 0x8f, 0x99, 0x2f,
+	0xdc, 0x43, 0xd5, 0xce, 0xe3, 0xa9, 0xfd, 0xfa, 0x8b, 0x68, 0x9b, 0xae, 0xbb, 0xe1, 0xb1, 0x44,
+	0x72, 0xa1, 0x66, 0x08, 0xb9, 0x14, 0xfe, 0x80, 0x66, 0xfb, 0x90, 0xb7, 0xa1, 0x91, 0x62, 0x07,
+	0x69, 0x46, 0xc3, 0xd6, 0x72, 0x4b, 0xdd, 0xda, 0x72, 0xfc, 0x7b, 0x78, 0x46, 0x69, 0x5e, 0x40,
+	0x8e, 0x01, 0x46, 0x7e, 0x2a, 0xe3, 0xa1, 0x60, 0xa1, 0x22, 0x41, 0x95, 0xbf, 0xb2, 0x2c, 0xff,
+	0x30, 0x88, 0x99, 0x3c, 0xca, 0x13, 0xb0, 0x75, 0x92, 0x41, 0x15, 0xea, 0x68, 0xc1, 0x76, 0xbe,
+	0x86, 0x8d, 0x43, 0x36, 0x18, 0x71, 0x6f, 0x71, 0xf2, 0x77, 0xa1, 0x72, 0x87, 0x4f, 0xb2, 0xcf,
+	0xd9, 0x98, 0x4f, 0x6d, 0xb5, 0xa4, 0xea, 0x8f, 0x92, 0x47, 0x7e, 0x5f, 0xf2, 0x48, 0xe6, 0xad,
+	0x93, 0xe2, 0x17, 0xec, 0x62, 0xc8, 0xdd, 0xcc, 0x76, 0xcc, 0x53, 0x69, 0x6e, 0x38, 0x8f, 0x0d,
+	0xa8, 0xeb, 0x24, 0x62, 0xe7, 0x22, 0xad, 0xb6, 0xa9, 0xb8, 0x6b, 0xf3, 0xa9, 0xad, 0x1d, 0xb9,
+	0x5e, 0xef, 0x6a, 0xbd, 0x46, 0x25, 0xd2, 0x5d, 0xf0, 0xc8, 0xd3, 0xc2, 0xdd, 0x86, 0xa6, 0x14,
+	0x6c, 0xc0, 0x4f, 0x7d, 0x2f, 0x3b, 0xfe, 0xf9, 0x59, 0x45, 0xf7, 0x2d, 0x8f, 0xbc, 0x07, 0x4d,
+	0x91, 0x8d, 0x93, 0xe9, 0xf8, 0xce, 0x39, 0x1d, 0xbf, 0x11, 0x4d, 0xdc, 0xf5, 0xf9, 0xd4, 0x5e,
+	0x64, 0xd2, 0x85, 0x45, 0xae, 0x00, 0xc1, 0xb9, 0x4e, 0x95, 0x02, 0xa6, 0x92, 0x85, 0xc9, 0x69,
+	0xa8, 0x55, 0xaa, 0x42, 0xb7, 0x30, 0x72, 0x92, 0x07, 0x3e, 0x4d, 0x3f, 0xaa, 0x36, 0x2b, 0x5b,
+	0x55, 0xe7, 0xf7, 0x32, 0x34, 0x32, 0xdd, 0x23, 0x97, 0xe1, 0x7f, 0x48, 0xea, 0x4d, 0x3f, 0x65,
+	0xfd, 0x80, 0x7b, 0x38, 0x65, 0x93, 0x9e, 0x75, 0x92, 0xd7, 0x60, 0xab, 0x37, 0x62, 0xc2, 0xf3,
+	0xa3, 0xe1, 0x22, 0xb1, 0x8c, 0x89, 0xe7, 0xfc, 0xa4, 0x0d, 0xad, 0x93, 0x58, 0xb2, 0x00, 0x03,
+	0xfa, 0xf8, 0xd7, 0x68, 0xd1, 0x45, 0x0e, 0x60, 0x27, 0x93, 0xf9, 0x5e, 0x12, 0xf8, 0x72, 0x81,
+	0x58, 0x45, 0xc4, 0x95, 0xb1, 0xe7, 0x6b, 0x6e, 0x45, 0x92, 0x8b, 0x7b, 0x2c, 0xc8, 0x24, 0x7a,
+	0x65, 0x4c, 0xcd, 0x76, 0x76, 0x83, 0xba, 0x9e, 0xed, 0x2c, 0xf2, 0x4e, 0x7e, 0x51, 0x1b, 0xfa,
+	0xd5, 0xc0, 0x05, 0xb9, 0x06, 0xdb, 0xc7, 0x5c, 0xf4, 0x24, 0x4f, 0x70, 0xdd, 0x8d, 0x34, 0x42,
+	0x13, 0x11, 0x56, 0x85, 0x9c, 0xfb, 0x50, 0xc3, 0x97, 0x80, 0x38, 0xb0, 0x8e, 0xd3, 0xaa, 0x7b,
+	0xee, 0x73, 0xad, 0xca, 0x35, 0x7a, 0xc6, 0x47, 0xde, 0x80, 0x9d, 0x6e, 0x2a, 0xfd, 0x90, 0x49,
+	0xee, 0xf5, 0xd0, 0x75, 0x18, 0x8f, 0x23, 0xfd, 0x43, 0xa0, 0x7a, 0x54, 0xa2, 0x2b, 0xa3, 0xee,
+	0x4b, 0xb0, 0x7d, 0x88, 0x6c, 0xb3, 0xc0, 0x97, 0x93, 0x3c, 0xc5, 0xe9, 0xc2, 0xe6, 0x42, 0x53,
+	0xfc, 0x54, 0xfa, 0x03, 0xa4, 0x78, 0x25, 0xbe, 0xea, 0xa5, 0xba, 0x1a, 0xdd, 0xf9, 0xd1, 0x00,
+	0xa2, 0x2f, 0xd8, 0xd1, 0xc9, 0xc9, 0xf1, 0xe2, 0x92, 0x5d, 0x84, 0xb5, 0x81, 0xf2, 0x9e, 0x2e,
+	0xae, 0x1a, 0x6d, 0xa2, 0xe3, 0x63, 0x3e, 0x21, 0x36, 0xb4, 0xf4, 0x4b, 0x73, 0x3a, 0x88, 0x3d,
+	0xfd, 0x1a, 0xd7, 0x28, 0x68, 0xd7, 0x61, 0xec, 0x71, 0xf2, 0x16, 0x34, 0x46, 0x99, 0xa4, 0xe7,
+	0x1a, 0x50, 0xb8, 0x87, 0xcb, 0xed, 0xb4, 0x76, 0xd3, 0x3c, 0x59, 0xbd, 0xef, 0xfd, 0xd8, 0x9b,
+	0xe0, 0x99, 0x58, 0xa7, 0x68, 0x3b, 0xef, 0xc0, 0xd6, 0xf3, 0x05, 0x2a, 0x2f, 0x5a, 0xbc, 0xa6,
+	0x14, 0x6d, 0xf5, 0x45, 0x51, 0x8d, 0xb0, 0x9d, 0x35, 0xaa, 0x17, 0x6e, 0xf7, 0xe1, 0x13, 0xab,
+	0xf4, 0xe8, 0x89, 0x55, 0x7a, 0xf6, 0xc4, 0x32, 0xbe, 0x9d, 0x59, 0xc6, 0xcf, 0x33, 0xcb, 0x78,
+	0x30, 0xb3, 0x8c, 0x87, 0x33, 0xcb, 0xf8, 0x73, 0x66, 0x19, 0x7f, 0xcd, 0xac, 0xd2, 0xb3, 0x99,
+	0x65, 0x7c, 0xff, 0xd4, 0x2a, 0x3d, 0x7c, 0x6a, 0x95, 0x1e, 0x3d, 0xb5, 0x4a, 0x5f, 0x6d, 0x62,
+	0xb7, 0xa1, 0xef, 0x79, 0x01, 0xff, 0x86, 0x09, 0xde, 0xaf, 0xe3, 0xb5, 0xbc, 0xfe, 0xf7, 0x00,
+	0xf0, 0x39, 0xd5, 0xc8, 0xa5, 0x0a, 0x00, 0x00,
 }
 
 func (this *PrometheusRangeQueryRequest) Equal(that interface{}) bool {
@@ -1207,6 +1245,9 @@ func (this *PrometheusData) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if !this.Stats.Equal(that1.Stats) {
+		return false
+	}
 	return true
 }
 func (this *SampleStream) Equal(that interface{}) bool {
@@ -1356,6 +1397,15 @@ func (this *Options) Equal(that interface{}) bool {
 	if this.InstantSplitInterval != that1.InstantSplitInterval {
 		return false
 	}
+	if this.SplitDisabled != that1.SplitDisabled {
+		return false
+	}
+	if this.Stats != that1.Stats {
+		return false
+	}
+	if this.PerStepStatsEnabled != that1.PerStepStatsEnabled {
+		return false
+	}
 	return true
 }
 func (this *Hints) Equal(that interface{}) bool {
@@ -1574,7 +1624,7 @@ func (this *PrometheusData) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 6)
+	s := make([]string, 0, 7)
 	s = append(s, "&querymiddleware.PrometheusData{")
 	s = append(s, "ResultType: "+fmt.Sprintf("%#v", this.ResultType)+",\n")
 	if this.Result != nil {
@@ -1584,6 +1634,9 @@ func (this *PrometheusData) GoString() string {
 		}
 		s = append(s, "Result: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
+	if this.Stats != nil {
+		s = append(s, "Stats: "+fmt.Sprintf("%#v", this.Stats)+",\n")
+	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1648,13 +1701,16 @@ func (this *Options) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 12)
 	s = append(s, "&querymiddleware.Options{")
 	s = append(s, "CacheDisabled: "+fmt.Sprintf("%#v", this.CacheDisabled)+",\n")
 	s = append(s, "ShardingDisabled: "+fmt.Sprintf("%#v", this.ShardingDisabled)+",\n")
 	s = append(s, "TotalShards: "+fmt.Sprintf("%#v", this.TotalShards)+",\n")
 	s = append(s, "InstantSplitDisabled: "+fmt.Sprintf("%#v", this.InstantSplitDisabled)+",\n")
 	s = append(s, "InstantSplitInterval: "+fmt.Sprintf("%#v", this.InstantSplitInterval)+",\n")
+	s = append(s, "SplitDisabled: "+fmt.Sprintf("%#v", this.SplitDisabled)+",\n")
+	s = append(s, "Stats: "+fmt.Sprintf("%#v", this.Stats)+",\n")
+	s = append(s, "PerStepStatsEnabled: "+fmt.Sprintf("%#v", this.PerStepStatsEnabled)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -2008,6 +2064,18 @@ func (m *PrometheusData) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Stats != nil {
+		{
+			size, err := m.Stats.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintModel(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
 	if len(m.Result) > 0 {
 		for iNdEx := len(m.Result) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -2218,6 +2286,33 @@ func (m *Options) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.PerStepStatsEnabled {
+		i--
+		if m.PerStepStatsEnabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x40
+	}
+	if len(m.Stats) > 0 {
+		i -= len(m.Stats)
+		copy(dAtA[i:], m.Stats)
+		i = encodeVarintModel(dAtA, i, uint64(len(m.Stats)))
+		i--
+		dAtA[i] = 0x3a
+	}
+	if m.SplitDisabled {
+		i--
+		if m.SplitDisabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x30
+	}
 	if m.InstantSplitInterval != 0 {
 		i = encodeVarintModel(dAtA, i, uint64(m.InstantSplitInterval))
 		i--
@@ -2573,6 +2668,10 @@ func (m *PrometheusData) Size() (n int) {
 			n += 1 + l + sovModel(uint64(l))
 		}
 	}
+	if m.Stats != nil {
+		l = m.Stats.Size()
+		n += 1 + l + sovModel(uint64(l))
+	}
 	return n
 }
 
@@ -2669,6 +2768,16 @@ func (m *Options) Size() (n int) {
 	if m.InstantSplitInterval != 0 {
 		n += 1 + sovModel(uint64(m.InstantSplitInterval))
 	}
+	if m.SplitDisabled {
+		n += 2
+	}
+	l = len(m.Stats)
+	if l > 0 {
+		n += 1 + l + sovModel(uint64(l))
+	}
+	if m.PerStepStatsEnabled {
+		n += 2
+	}
 	return n
 }
 
@@ -2832,6 +2941,7 @@ func (this *PrometheusData) String() string {
 	s := strings.Join([]string{`&PrometheusData{`,
 		`ResultType:` + fmt.Sprintf("%v", this.ResultType) + `,`,
 		`Result:` + repeatedStringForResult + `,`,
+		`Stats:` + strings.Replace(fmt.Sprintf("%v", this.Stats), "QueryStats", "mimirpb.QueryStats", 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -2898,6 +3008,9 @@ func (this *Options) String() string {
 		`TotalShards:` + fmt.Sprintf("%v", this.TotalShards) + `,`,
 		`InstantSplitDisabled:` + fmt.Sprintf("%v", this.InstantSplitDisabled) + `,`,
 		`InstantSplitInterval:` + fmt.Sprintf("%v", this.InstantSplitInterval) + `,`,
+		`SplitDisabled:` + fmt.Sprintf("%v", this.SplitDisabled) + `,`,
+		`Stats:` + fmt.Sprintf("%v", this.Stats) + `,`,
+		`PerStepStatsEnabled:` + fmt.Sprintf("%v", this.PerStepStatsEnabled) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -3920,6 +4033,42 @@ func (m *PrometheusData) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Stats", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowModel
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthModel
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthModel
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Stats == nil {
+				m.Stats = &mimirpb.QueryStats{}
+			}
+			if err := m.Stats.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipModel(dAtA[iNdEx:])
@@ -4523,6 +4672,78 @@ func (m *Options) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SplitDisabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowModel
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.SplitDisabled = bool(v != 0)
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Stats", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowModel
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthModel
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthModel
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Stats = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PerStepStatsEnabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowModel
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.PerStepStatsEnabled = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipModel(dAtA[iNdEx:])
@@ -114,6 +114,7 @@ func (s *querySharding) Do(ctx context.Context, r Request) (Response, error) {
 	totalShards := s.getShardsForQuery(ctx, tenantIDs, r, queryExpr, log)
 	if totalShards <= 1 {
 		level.Debug(log).Log("msg", "query sharding is disabled for this query or tenant")
+		queryExplanationFromContext(ctx).addRewrite("querysharding", "query not sharded because sharding is disabled for this query or tenant", "")
 		return s.next.Do(ctx, r)
 	}
 
@@ -131,6 +132,7 @@ func (s *querySharding) Do(ctx context.Context, r Request) (Response, error) {
 			level.Debug(log).Log("msg", "query is not supported for being rewritten into a shardable query", "query", r.GetQuery())
 		}
 
+		queryExplanationFromContext(ctx).addRewrite("querysharding", "query not sharded because it can't be rewritten into a shardable query", "")
 		return s.next.Do(ctx, r)
 	}
 
@@ -144,6 +146,7 @@ func (s *querySharding) Do(ctx context.Context, r Request) (Response, error) {
 	// Update query stats.
 	queryStats := stats.FromContext(ctx)
 	queryStats.AddShardedQueries(uint32(shardingStats.GetShardedQueries()))
+	queryExplanationFromContext(ctx).addRewrite("querysharding", fmt.Sprintf("query rewritten into %d sharded queries using %d shards", shardingStats.GetShardedQueries(), totalShards), shardedQuery)
 
 	r = r.WithQuery(shardedQuery)
 	shardedQueryable := newShardedQueryable(r, s.next)
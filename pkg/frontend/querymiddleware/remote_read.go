@@ -0,0 +1,810 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/grafana/dskit/tenant"
+	"github.com/grafana/dskit/user"
+	"github.com/opentracing/opentracing-go"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/timestamp"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage/remote"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/limiter"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	remoteReadPathSuffix = "/api/v1/read"
+
+	// Remote read queries are a set of matchers with time ranges - should not get into megabytes.
+	maxRemoteReadQuerySize = 1024 * 1024
+
+	// Maximum number of bytes in a frame written to the client when using streaming remote read.
+	// It's the same limit used by the querier.
+	maxRemoteReadFrameBytes = 1024 * 1024
+
+	// Maximum size of a frame read from the querier when using streaming remote read. It's the
+	// default limit used by Prometheus remote read clients.
+	maxRemoteReadChunkedReadFrameBytes = 50 * 1024 * 1024
+
+	// Maximum size of the error message read from a failed split query response.
+	maxRemoteReadErrorBytes = 64 * 1024
+
+	remoteReadStreamedContentType = "application/x-streamed-protobuf; proto=prometheus.ChunkedReadResponse"
+)
+
+// remoteReadRoundTripper parses the remote read requests received by the query-frontend, runs each query
+// through the middlewares enforcing limits and blocked queries, splits each query by time interval and
+// executes the split queries in parallel. The responses of the split queries are merged back and, when
+// the STREAMED_XOR_CHUNKS response type is requested, streamed to the client query by query.
+type remoteReadRoundTripper struct {
+	next          http.RoundTripper
+	limits        Limits
+	splitInterval time.Duration
+	middleware    Middleware
+	logger        log.Logger
+}
+
+// newRemoteReadRoundTripper makes a new remoteReadRoundTripper. The input middlewares are used only to
+// validate the remote read queries: they're not expected to execute the request.
+func newRemoteReadRoundTripper(next http.RoundTripper, limits Limits, splitInterval time.Duration, logger log.Logger, middlewares ...Middleware) http.RoundTripper {
+	return &remoteReadRoundTripper{
+		next:          next,
+		limits:        limits,
+		splitInterval: splitInterval,
+		middleware:    MergeMiddlewares(middlewares...),
+		logger:        logger,
+	}
+}
+
+func (rt *remoteReadRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	spanLog, ctx := spanlogger.NewWithLogger(r.Context(), rt.logger, "remoteReadRoundTripper.RoundTrip")
+	defer spanLog.Finish()
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	readReq := &prompb.ReadRequest{}
+	if _, err := util.ParseProtoReader(ctx, r.Body, int(r.ContentLength), maxRemoteReadQuerySize, nil, readReq, util.RawSnappy); err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	respType, err := remote.NegotiateResponseType(readReq.AcceptedResponseTypes)
+	if err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	// Validate each query and split it by interval.
+	splitQueries := make([][]*prompb.Query, len(readReq.Queries))
+	for i, query := range readReq.Queries {
+		validated, err := rt.validateQuery(ctx, r.URL.Path, query)
+		if err != nil {
+			return nil, err
+		}
+		if validated == nil {
+			// The query is fully outside the allowed time range, so it will get an empty result.
+			continue
+		}
+
+		splitQueries[i] = splitRemoteReadQueryByInterval(validated, rt.splitInterval)
+		stats.FromContext(ctx).AddSplitQueries(uint32(len(splitQueries[i])))
+	}
+
+	exec := &remoteReadExecutor{
+		next:             rt.next,
+		orig:             r,
+		respType:         respType,
+		parallelism:      semaphore.NewWeighted(int64(validation.SmallestPositiveIntPerTenant(tenantIDs, rt.limits.MaxQueryParallelism))),
+		maxFetchedSeries: validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, rt.limits.MaxFetchedSeriesPerQuery),
+	}
+
+	switch respType {
+	case prompb.ReadRequest_STREAMED_XOR_CHUNKS:
+		return exec.streamChunks(ctx, splitQueries, rt.logger)
+	default:
+		return exec.mergeSamples(ctx, splitQueries)
+	}
+}
+
+// validateQuery runs the input query through the validation middlewares and returns the query
+// with the time range eventually manipulated by the middlewares, or nil if the query should not
+// be executed at all.
+func (rt *remoteReadRoundTripper) validateQuery(ctx context.Context, path string, query *prompb.Query) (*prompb.Query, error) {
+	req, err := newRemoteReadQueryRequest(path, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var validated Request
+	_, err = rt.middleware.Wrap(HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+		// We do not need to do anything here, because the middlewares are used only for validation.
+		validated = req
+		return newEmptyPrometheusResponse(), nil
+	})).Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if validated == nil {
+		return nil, nil
+	}
+
+	out := *query
+	out.StartTimestampMs = validated.GetStart()
+	out.EndTimestampMs = validated.GetEnd()
+	if query.Hints != nil {
+		hints := *query.Hints
+		hints.StartMs = out.StartTimestampMs
+		hints.EndMs = out.EndTimestampMs
+		out.Hints = &hints
+	}
+	return &out, nil
+}
+
+// splitRemoteReadQueryByInterval splits the input query by the configured interval. The time range of a
+// remote read query is inclusive on both ends, so the split queries are aligned to the interval and don't
+// overlap. Returns the input query if splitting is disabled.
+func splitRemoteReadQueryByInterval(query *prompb.Query, interval time.Duration) []*prompb.Query {
+	if interval <= 0 {
+		return []*prompb.Query{query}
+	}
+
+	intervalMillis := interval.Milliseconds()
+
+	var out []*prompb.Query
+	for start := query.StartTimestampMs; start <= query.EndTimestampMs; {
+		end := ((start/intervalMillis)+1)*intervalMillis - 1
+		if end > query.EndTimestampMs {
+			end = query.EndTimestampMs
+		}
+
+		split := *query
+		split.StartTimestampMs = start
+		split.EndTimestampMs = end
+		if query.Hints != nil {
+			hints := *query.Hints
+			hints.StartMs = start
+			hints.EndMs = end
+			split.Hints = &hints
+		}
+		out = append(out, &split)
+
+		start = end + 1
+	}
+	return out
+}
+
+// remoteReadExecutor executes the split remote read queries downstream and merges their responses.
+type remoteReadExecutor struct {
+	next             http.RoundTripper
+	orig             *http.Request
+	respType         prompb.ReadRequest_ResponseType
+	parallelism      *semaphore.Weighted
+	maxFetchedSeries int
+}
+
+// mergeSamples executes all the split queries and merges the responses into a single SAMPLES response.
+func (e *remoteReadExecutor) mergeSamples(ctx context.Context, splitQueries [][]*prompb.Query) (*http.Response, error) {
+	resp := &prompb.ReadResponse{
+		Results: make([]*prompb.QueryResult, len(splitQueries)),
+	}
+
+	g, gCtx := errgroup.WithContext(ctx)
+	for i := range splitQueries {
+		i := i
+		g.Go(func() error {
+			bodies, err := e.openQuery(gCtx, splitQueries[i])
+			if err != nil {
+				return err
+			}
+			defer closeAll(bodies)
+
+			splitResults := make([]*prompb.QueryResult, 0, len(bodies))
+			for _, body := range bodies {
+				// SAMPLES responses are snappy block encoded, so they can only be decoded once fully read.
+				splitResp, err := decodeRemoteReadResponse(body)
+				if err != nil {
+					return err
+				}
+				if len(splitResp.Results) != 1 {
+					return fmt.Errorf("unexpected number of results in remote read response (expected: 1, got: %d)", len(splitResp.Results))
+				}
+				splitResults = append(splitResults, splitResp.Results[0])
+			}
+
+			merged := mergeRemoteReadQueryResults(splitResults)
+			if e.maxFetchedSeries > 0 && len(merged.Timeseries) > e.maxFetchedSeries {
+				return apierror.New(apierror.TypeExec, validation.LimitError(fmt.Sprintf(limiter.MaxSeriesHitMsgFormat, e.maxFetchedSeries)).Error())
+			}
+
+			resp.Results[i] = merged
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	data, err := proto.Marshal(resp)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode remote read response")
+	}
+	body := snappy.Encode(nil, data)
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"Content-Type":     []string{"application/x-protobuf"},
+			"Content-Encoding": []string{"snappy"},
+		},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+	}, nil
+}
+
+// streamChunks executes the split queries and streams the merged STREAMED_XOR_CHUNKS response to the client,
+// one query at a time. The frames are merged from the split query responses as they're received, without
+// buffering them. The split queries of the first query are sent before returning, so that their errors can be
+// returned to the client with the proper status code. Errors occurring after the response has started to be
+// written, like hitting the max fetched series limit, fail the response body: the HTTP handler aborts the
+// response, so that the client gets an error instead of a truncated response.
+func (e *remoteReadExecutor) streamChunks(ctx context.Context, splitQueries [][]*prompb.Query, logger log.Logger) (*http.Response, error) {
+	var first []io.ReadCloser
+	if len(splitQueries) > 0 {
+		var err error
+		if first, err = e.openQuery(ctx, splitQueries[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	pr, pw := io.Pipe()
+
+	go func() {
+		// Stop writing if the client goes away or the response body is closed.
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				_ = pr.CloseWithError(ctx.Err())
+			case <-done:
+			}
+		}()
+
+		cw := remote.NewChunkedWriter(pw, noopFlusher{})
+		for i := range splitQueries {
+			bodies := first
+			if i > 0 {
+				var err error
+				if bodies, err = e.openQuery(ctx, splitQueries[i]); err != nil {
+					level.Error(logger).Log("msg", "error executing remote read query", "err", err)
+					_ = pw.CloseWithError(err)
+					return
+				}
+			}
+
+			err := e.writeMergedChunkedSeries(cw, int64(i), bodies)
+			closeAll(bodies)
+			if err != nil {
+				level.Error(logger).Log("msg", "error streaming remote read response", "err", err)
+				_ = pw.CloseWithError(err)
+				return
+			}
+		}
+
+		_ = pw.Close()
+	}()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"Content-Type": []string{remoteReadStreamedContentType},
+		},
+		Body:          pr,
+		ContentLength: -1,
+	}, nil
+}
+
+// writeMergedChunkedSeries merges the series from the input split query responses, sorted by labels,
+// and writes them to w as frames of the query at the input index.
+func (e *remoteReadExecutor) writeMergedChunkedSeries(w io.Writer, queryIndex int64, bodies []io.ReadCloser) error {
+	iterators := make([]*chunkedSeriesIterator, 0, len(bodies))
+	for _, body := range bodies {
+		iterators = append(iterators, newChunkedSeriesIterator(remote.NewChunkedReader(body, maxRemoteReadChunkedReadFrameBytes, nil)))
+	}
+
+	seriesCount := 0
+	for {
+		// Find the lowest series across all the split responses. The split responses are few (one
+		// per split interval) so it's not worth to use a heap.
+		var lowest []prompb.Label
+		for _, it := range iterators {
+			if !it.Next() {
+				if err := it.Err(); err != nil {
+					return err
+				}
+				continue
+			}
+			if lowest == nil || compareLabels(it.Labels(), lowest) < 0 {
+				lowest = it.Labels()
+			}
+		}
+		if lowest == nil {
+			return nil
+		}
+
+		seriesCount++
+		if e.maxFetchedSeries > 0 && seriesCount > e.maxFetchedSeries {
+			return validation.LimitError(fmt.Sprintf(limiter.MaxSeriesHitMsgFormat, e.maxFetchedSeries))
+		}
+
+		// Iterators are ordered by split time range, so the chunks are appended in time order.
+		var chks []prompb.Chunk
+		for _, it := range iterators {
+			if it.Done() || compareLabels(it.Labels(), lowest) != 0 {
+				continue
+			}
+			chks = appendNonOverlappingChunks(chks, it.Chunks())
+			it.Consume()
+		}
+
+		if err := writeChunkedSeries(w, queryIndex, lowest, chks, maxRemoteReadFrameBytes); err != nil {
+			return err
+		}
+	}
+}
+
+// openQuery sends the input split queries in parallel and returns the response bodies, in the same order
+// of the split queries. The caller is responsible to close the returned bodies. The parallelism is limited
+// while sending the split queries only, because the split responses are consumed together.
+func (e *remoteReadExecutor) openQuery(ctx context.Context, splits []*prompb.Query) ([]io.ReadCloser, error) {
+	bodies := make([]io.ReadCloser, len(splits))
+
+	g, gCtx := errgroup.WithContext(ctx)
+	for i, split := range splits {
+		i, split := i, split
+		g.Go(func() error {
+			if err := e.parallelism.Acquire(gCtx, 1); err != nil {
+				return fmt.Errorf("could not acquire work: %w", err)
+			}
+			defer e.parallelism.Release(1)
+
+			// The split queries must not be canceled once all sent, so they're sent with the parent context.
+			body, err := e.openSplitQuery(ctx, split)
+			if err != nil {
+				return err
+			}
+			bodies[i] = body
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		closeAll(bodies)
+		return nil, err
+	}
+	return bodies, nil
+}
+
+// openSplitQuery sends the input split query downstream and returns the response body.
+func (e *remoteReadExecutor) openSplitQuery(ctx context.Context, query *prompb.Query) (io.ReadCloser, error) {
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		span.LogFields(
+			otlog.String("start", timestamp.Time(query.StartTimestampMs).String()),
+			otlog.String("end", timestamp.Time(query.EndTimestampMs).String()),
+		)
+	}
+
+	data, err := proto.Marshal(&prompb.ReadRequest{
+		Queries:               []*prompb.Query{query},
+		AcceptedResponseTypes: []prompb.ReadRequest_ResponseType{e.respType},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "encode remote read request")
+	}
+	body := snappy.Encode(nil, data)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.orig.URL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = e.orig.Header.Clone()
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Del("Content-Length")
+	req.RequestURI = e.orig.RequestURI
+	if err := user.InjectOrgIDIntoHTTPRequest(ctx, req); err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	resp, err := e.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer func() { _ = resp.Body.Close() }()
+
+		errBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxRemoteReadErrorBytes))
+		return nil, httpgrpc.Errorf(resp.StatusCode, "%s", strings.TrimSpace(string(errBody)))
+	}
+
+	return resp.Body, nil
+}
+
+func closeAll(bodies []io.ReadCloser) {
+	for _, body := range bodies {
+		if body != nil {
+			_ = body.Close()
+		}
+	}
+}
+
+func decodeRemoteReadResponse(body io.Reader) (*prompb.ReadResponse, error) {
+	compressed, err := io.ReadAll(body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read remote read response")
+	}
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode remote read response")
+	}
+
+	resp := &prompb.ReadResponse{}
+	if err := proto.Unmarshal(data, resp); err != nil {
+		return nil, errors.Wrap(err, "decode remote read response")
+	}
+	return resp, nil
+}
+
+// mergeRemoteReadQueryResults merges the results of the split queries, which are expected to be ordered
+// by time range. The returned series are sorted by labels.
+func mergeRemoteReadQueryResults(results []*prompb.QueryResult) *prompb.QueryResult {
+	if len(results) == 1 {
+		return results[0]
+	}
+
+	byLabels := map[string]*prompb.TimeSeries{}
+	for _, result := range results {
+		for _, series := range result.Timeseries {
+			key := labelsKey(series.Labels)
+			existing, ok := byLabels[key]
+			if !ok {
+				byLabels[key] = series
+				continue
+			}
+
+			// Split queries don't overlap, so we just need to skip samples which may have been
+			// returned by a previous split query.
+			for _, s := range series.Samples {
+				if n := len(existing.Samples); n == 0 || s.Timestamp > existing.Samples[n-1].Timestamp {
+					existing.Samples = append(existing.Samples, s)
+				}
+			}
+			for _, h := range series.Histograms {
+				if n := len(existing.Histograms); n == 0 || h.Timestamp > existing.Histograms[n-1].Timestamp {
+					existing.Histograms = append(existing.Histograms, h)
+				}
+			}
+		}
+	}
+
+	merged := &prompb.QueryResult{Timeseries: make([]*prompb.TimeSeries, 0, len(byLabels))}
+	for _, series := range byLabels {
+		merged.Timeseries = append(merged.Timeseries, series)
+	}
+	sort.Slice(merged.Timeseries, func(i, j int) bool {
+		return compareLabels(merged.Timeseries[i].Labels, merged.Timeseries[j].Labels) < 0
+	})
+	return merged
+}
+
+// appendNonOverlappingChunks appends the input chunks to dst, skipping the ones fully covered by the
+// chunks already in dst. Chunks overlapping the boundary between two split queries are returned by both.
+func appendNonOverlappingChunks(dst, chks []prompb.Chunk) []prompb.Chunk {
+	for _, chk := range chks {
+		if n := len(dst); n > 0 && chk.MaxTimeMs <= dst[n-1].MaxTimeMs {
+			continue
+		}
+		dst = append(dst, chk)
+	}
+	return dst
+}
+
+// writeChunkedSeries writes the input series chunks to w, splitting them in multiple frames
+// if they exceed the max frame size.
+func writeChunkedSeries(w io.Writer, queryIndex int64, lbls []prompb.Label, chks []prompb.Chunk, maxBytesInFrame int) error {
+	labelsSize := 0
+	for _, l := range lbls {
+		labelsSize += l.Size()
+	}
+
+	for len(chks) > 0 {
+		// We are fine with minor inaccuracy of max bytes per frame. The inaccuracy will be max of full chunk size.
+		frameBytesRemaining := maxBytesInFrame - labelsSize
+		n := 0
+		for n < len(chks) && (n == 0 || frameBytesRemaining > 0) {
+			frameBytesRemaining -= chks[n].Size()
+			n++
+		}
+
+		b, err := proto.Marshal(&prompb.ChunkedReadResponse{
+			ChunkedSeries: []*prompb.ChunkedSeries{{Labels: lbls, Chunks: chks[:n]}},
+			QueryIndex:    queryIndex,
+		})
+		if err != nil {
+			return errors.Wrap(err, "marshal remote read chunked response")
+		}
+		if _, err := w.Write(b); err != nil {
+			return errors.Wrap(err, "write to stream")
+		}
+
+		chks = chks[n:]
+	}
+	return nil
+}
+
+// chunkedSeriesIterator iterates over the series of a STREAMED_XOR_CHUNKS response. A series may be
+// split across multiple consecutive frames, so the iterator merges them back.
+type chunkedSeriesIterator struct {
+	reader *remote.ChunkedReader
+
+	// The current series. It's valid until consumed.
+	curr *prompb.ChunkedSeries
+	// The next series read from the stream, which may be a continuation of the current one.
+	pending []*prompb.ChunkedSeries
+
+	done bool
+	err  error
+}
+
+func newChunkedSeriesIterator(reader *remote.ChunkedReader) *chunkedSeriesIterator {
+	return &chunkedSeriesIterator{reader: reader}
+}
+
+// Next advances the iterator to the next series, unless the current one has not been consumed yet.
+func (it *chunkedSeriesIterator) Next() bool {
+	if it.curr != nil {
+		return true
+	}
+	if it.done {
+		return false
+	}
+
+	for {
+		next, ok := it.readSeries()
+		if !ok {
+			it.done = it.curr == nil
+			return it.curr != nil
+		}
+		if it.curr == nil {
+			it.curr = next
+			continue
+		}
+		if compareLabels(it.curr.Labels, next.Labels) == 0 {
+			it.curr.Chunks = append(it.curr.Chunks, next.Chunks...)
+			continue
+		}
+
+		// The next series is a different one, so keep it for later.
+		it.pending = append([]*prompb.ChunkedSeries{next}, it.pending...)
+		return true
+	}
+}
+
+func (it *chunkedSeriesIterator) readSeries() (*prompb.ChunkedSeries, bool) {
+	if len(it.pending) > 0 {
+		next := it.pending[0]
+		it.pending = it.pending[1:]
+		return next, true
+	}
+	if it.err != nil {
+		return nil, false
+	}
+
+	for {
+		frame := &prompb.ChunkedReadResponse{}
+		if err := it.reader.NextProto(frame); err != nil {
+			if !errors.Is(err, io.EOF) {
+				it.err = err
+			}
+			return nil, false
+		}
+		if len(frame.ChunkedSeries) == 0 {
+			continue
+		}
+
+		it.pending = append(it.pending, frame.ChunkedSeries[1:]...)
+		return frame.ChunkedSeries[0], true
+	}
+}
+
+// Done returns true if the iterator has no current series.
+func (it *chunkedSeriesIterator) Done() bool {
+	return it.curr == nil
+}
+
+// Labels returns the labels of the current series.
+func (it *chunkedSeriesIterator) Labels() []prompb.Label {
+	return it.curr.Labels
+}
+
+// Chunks returns the chunks of the current series.
+func (it *chunkedSeriesIterator) Chunks() []prompb.Chunk {
+	return it.curr.Chunks
+}
+
+// Consume marks the current series as consumed, so that the next call to Next() advances the iterator.
+func (it *chunkedSeriesIterator) Consume() {
+	it.curr = nil
+}
+
+func (it *chunkedSeriesIterator) Err() error {
+	return it.err
+}
+
+// compareLabels compares two sets of labels, with the same semantic of labels.Compare().
+func compareLabels(a, b []prompb.Label) int {
+	l := len(a)
+	if len(b) < l {
+		l = len(b)
+	}
+
+	for i := 0; i < l; i++ {
+		if a[i].Name != b[i].Name {
+			if a[i].Name < b[i].Name {
+				return -1
+			}
+			return 1
+		}
+		if a[i].Value != b[i].Value {
+			if a[i].Value < b[i].Value {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+func labelsKey(lbls []prompb.Label) string {
+	b := strings.Builder{}
+	for _, l := range lbls {
+		b.WriteString(l.Name)
+		b.WriteByte(0)
+		b.WriteString(l.Value)
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+type noopFlusher struct{}
+
+func (noopFlusher) Flush() {}
+
+// remoteReadQueryRequest is a Request wrapping a single query of a remote read request. It's used to run
+// remote read queries through the middlewares validating the requests, like the limits and the query blocker.
+type remoteReadQueryRequest struct {
+	path  string
+	query *prompb.Query
+
+	// The selector of the query, formatted as PromQL.
+	promQuery string
+}
+
+func newRemoteReadQueryRequest(path string, query *prompb.Query) (*remoteReadQueryRequest, error) {
+	matchers, err := remote.FromLabelMatchers(query.Matchers)
+	if err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	return &remoteReadQueryRequest{
+		path:      path,
+		query:     query,
+		promQuery: remoteReadMatchersToPromQL(matchers),
+	}, nil
+}
+
+// remoteReadMatchersToPromQL formats the input matchers as a PromQL vector selector.
+func remoteReadMatchersToPromQL(matchers []*labels.Matcher) string {
+	return (&parser.VectorSelector{LabelMatchers: matchers}).String()
+}
+
+func (r *remoteReadQueryRequest) GetId() int64 {
+	return 0
+}
+
+func (r *remoteReadQueryRequest) GetStart() int64 {
+	return r.query.GetStartTimestampMs()
+}
+
+func (r *remoteReadQueryRequest) GetEnd() int64 {
+	return r.query.GetEndTimestampMs()
+}
+
+func (r *remoteReadQueryRequest) GetStep() int64 {
+	return 0
+}
+
+func (r *remoteReadQueryRequest) GetQuery() string {
+	return r.promQuery
+}
+
+func (r *remoteReadQueryRequest) GetOptions() Options {
+	return Options{}
+}
+
+func (r *remoteReadQueryRequest) GetHints() *Hints {
+	return nil
+}
+
+func (r *remoteReadQueryRequest) WithID(_ int64) Request {
+	return r
+}
+
+func (r *remoteReadQueryRequest) WithStartEnd(startTime int64, endTime int64) Request {
+	query := *r.query
+	query.StartTimestampMs = startTime
+	query.EndTimestampMs = endTime
+
+	newRequest := *r
+	newRequest.query = &query
+	return &newRequest
+}
+
+func (r *remoteReadQueryRequest) WithQuery(_ string) Request {
+	return r
+}
+
+func (r *remoteReadQueryRequest) WithTotalQueriesHint(_ int32) Request {
+	return r
+}
+
+func (r *remoteReadQueryRequest) WithEstimatedSeriesCountHint(_ uint64) Request {
+	return r
+}
+
+func (r *remoteReadQueryRequest) LogToSpan(sp opentracing.Span) {
+	sp.LogFields(
+		otlog.String("query", r.GetQuery()),
+		otlog.String("start", timestamp.Time(r.GetStart()).String()),
+		otlog.String("end", timestamp.Time(r.GetEnd()).String()),
+	)
+}
+
+func (r *remoteReadQueryRequest) Reset() {}
+
+func (r *remoteReadQueryRequest) String() string {
+	return fmt.Sprintf("%s %s", r.path, r.promQuery)
+}
+
+func (r *remoteReadQueryRequest) ProtoMessage() {}
+
+func isRemoteReadQuery(path string) bool {
+	return strings.HasSuffix(path, remoteReadPathSuffix)
+}
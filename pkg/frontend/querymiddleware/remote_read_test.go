@@ -0,0 +1,428 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/util/limiter"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestSplitRemoteReadQueryByInterval(t *testing.T) {
+	tests := map[string]struct {
+		start, end int64
+		interval   time.Duration
+		expected   [][2]int64
+	}{
+		"splitting disabled": {
+			start:    10,
+			end:      250,
+			interval: 0,
+			expected: [][2]int64{{10, 250}},
+		},
+		"query within a single interval": {
+			start:    10,
+			end:      90,
+			interval: 100 * time.Millisecond,
+			expected: [][2]int64{{10, 90}},
+		},
+		"query spanning multiple intervals": {
+			start:    10,
+			end:      250,
+			interval: 100 * time.Millisecond,
+			expected: [][2]int64{{10, 99}, {100, 199}, {200, 250}},
+		},
+		"query ending on the interval boundary": {
+			start:    0,
+			end:      200,
+			interval: 100 * time.Millisecond,
+			expected: [][2]int64{{0, 99}, {100, 199}, {200, 200}},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			query := &prompb.Query{
+				StartTimestampMs: testData.start,
+				EndTimestampMs:   testData.end,
+				Hints:            &prompb.ReadHints{StartMs: testData.start, EndMs: testData.end},
+			}
+
+			var actual [][2]int64
+			for _, split := range splitRemoteReadQueryByInterval(query, testData.interval) {
+				actual = append(actual, [2]int64{split.StartTimestampMs, split.EndTimestampMs})
+				assert.Equal(t, split.StartTimestampMs, split.Hints.StartMs)
+				assert.Equal(t, split.EndTimestampMs, split.Hints.EndMs)
+			}
+			assert.Equal(t, testData.expected, actual)
+
+			// The input query should not be modified.
+			assert.Equal(t, testData.start, query.Hints.StartMs)
+			assert.Equal(t, testData.end, query.Hints.EndMs)
+		})
+	}
+}
+
+func TestRemoteReadRoundTripper_Samples(t *testing.T) {
+	now := time.Now().Truncate(time.Hour)
+	start := now.Add(-3 * time.Hour).UnixMilli()
+	end := now.Add(-time.Minute).UnixMilli()
+
+	downstream := newMockRemoteReadDownstream(t, func(query *prompb.Query) []*prompb.TimeSeries {
+		// Return a sample for each split query start and end, for two series, in reverse order.
+		return []*prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "metric"}, {Name: "series", Value: "2"}},
+				Samples: []prompb.Sample{{Timestamp: query.StartTimestampMs, Value: 2}, {Timestamp: query.EndTimestampMs, Value: 2}},
+			},
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "metric"}, {Name: "series", Value: "1"}},
+				Samples: []prompb.Sample{{Timestamp: query.StartTimestampMs, Value: 1}, {Timestamp: query.EndTimestampMs, Value: 1}},
+			},
+		}
+	})
+
+	rt := newRemoteReadRoundTripper(downstream, mockLimits{}, time.Hour, log.NewNopLogger(), newLimitsMiddleware(mockLimits{}, log.NewNopLogger()))
+
+	queryStats, ctx := stats.ContextWithEmptyStats(context.Background())
+	resp, err := rt.RoundTrip(newRemoteReadRequest(t, ctx, []prompb.ReadRequest_ResponseType{prompb.ReadRequest_SAMPLES}, &prompb.Query{
+		StartTimestampMs: start,
+		EndTimestampMs:   end,
+		Matchers:         []*prompb.LabelMatcher{{Type: prompb.LabelMatcher_EQ, Name: "__name__", Value: "metric"}},
+	}))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The query should have been split in 3 queries.
+	assert.Equal(t, 3, downstream.requestsCount())
+	assert.Equal(t, uint32(3), queryStats.LoadSplitQueries())
+
+	readResp, err := decodeRemoteReadResponse(resp.Body)
+	require.NoError(t, err)
+	require.Len(t, readResp.Results, 1)
+	require.Len(t, readResp.Results[0].Timeseries, 2)
+
+	for i, series := range readResp.Results[0].Timeseries {
+		// Series are expected to be sorted by labels.
+		assert.Equal(t, fmt.Sprintf("%d", i+1), series.Labels[1].Value)
+
+		// Samples are expected to be sorted by timestamp.
+		require.Len(t, series.Samples, 6)
+		assert.True(t, sort.SliceIsSorted(series.Samples, func(i, j int) bool {
+			return series.Samples[i].Timestamp < series.Samples[j].Timestamp
+		}))
+		assert.Equal(t, start, series.Samples[0].Timestamp)
+		assert.Equal(t, end, series.Samples[5].Timestamp)
+	}
+}
+
+func TestRemoteReadRoundTripper_StreamedChunks(t *testing.T) {
+	now := time.Now().Truncate(time.Hour)
+	start := now.Add(-3 * time.Hour).UnixMilli()
+	end := now.Add(-time.Minute).UnixMilli()
+
+	// The boundary chunk overlaps two split queries, so it's returned by both of them.
+	boundaryChunk := prompb.Chunk{MinTimeMs: now.Add(-2*time.Hour - time.Minute).UnixMilli(), MaxTimeMs: now.Add(-2*time.Hour + time.Minute).UnixMilli(), Type: prompb.Chunk_XOR, Data: []byte("boundary")}
+
+	downstream := newMockRemoteReadDownstream(t, func(query *prompb.Query) []*prompb.TimeSeries {
+		return nil
+	})
+	downstream.chunksFn = func(query *prompb.Query) []*prompb.ChunkedSeries {
+		chk := prompb.Chunk{MinTimeMs: query.StartTimestampMs, MaxTimeMs: query.EndTimestampMs, Type: prompb.Chunk_XOR, Data: []byte(fmt.Sprintf("%d", query.StartTimestampMs))}
+		chks := []prompb.Chunk{chk}
+		if query.StartTimestampMs <= boundaryChunk.MaxTimeMs && query.EndTimestampMs >= boundaryChunk.MinTimeMs {
+			chks = []prompb.Chunk{boundaryChunk}
+		}
+
+		return []*prompb.ChunkedSeries{
+			{Labels: []prompb.Label{{Name: "__name__", Value: "metric"}, {Name: "series", Value: "1"}}, Chunks: chks},
+			{Labels: []prompb.Label{{Name: "__name__", Value: "metric"}, {Name: "series", Value: "2"}}, Chunks: []prompb.Chunk{chk}},
+		}
+	}
+
+	rt := newRemoteReadRoundTripper(downstream, mockLimits{}, time.Hour, log.NewNopLogger(), newLimitsMiddleware(mockLimits{}, log.NewNopLogger()))
+
+	ctx := context.Background()
+	query := &prompb.Query{
+		StartTimestampMs: start,
+		EndTimestampMs:   end,
+		Matchers:         []*prompb.LabelMatcher{{Type: prompb.LabelMatcher_EQ, Name: "__name__", Value: "metric"}},
+	}
+	resp, err := rt.RoundTrip(newRemoteReadRequest(t, ctx, []prompb.ReadRequest_ResponseType{prompb.ReadRequest_STREAMED_XOR_CHUNKS}, query, query))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, remoteReadStreamedContentType, resp.Header.Get("Content-Type"))
+
+	// Read all frames from the stream.
+	var frames []*prompb.ChunkedReadResponse
+	reader := remote.NewChunkedReader(resp.Body, maxRemoteReadChunkedReadFrameBytes, nil)
+	for {
+		frame := &prompb.ChunkedReadResponse{}
+		if err := reader.NextProto(frame); err != nil {
+			require.ErrorIs(t, err, io.EOF)
+			break
+		}
+		frames = append(frames, frame)
+	}
+
+	// Each query should have been split in 3 queries.
+	assert.Equal(t, 6, downstream.requestsCount())
+
+	// Two series per query, each one in a single frame.
+	require.Len(t, frames, 4)
+	for i, frame := range frames {
+		assert.Equal(t, int64(i/2), frame.QueryIndex)
+		require.Len(t, frame.ChunkedSeries, 1)
+		assert.Equal(t, fmt.Sprintf("%d", i%2+1), frame.ChunkedSeries[0].Labels[1].Value)
+	}
+
+	// The boundary chunk should be streamed only once.
+	assert.Len(t, frames[0].ChunkedSeries[0].Chunks, 2)
+	assert.Equal(t, boundaryChunk, frames[0].ChunkedSeries[0].Chunks[0])
+	assert.Len(t, frames[1].ChunkedSeries[0].Chunks, 3)
+}
+
+func TestRemoteReadRoundTripper_StreamedChunks_ShouldStreamFramesAsReceived(t *testing.T) {
+	downstreamBody, downstreamWriter := io.Pipe()
+	downstreamDone := make(chan struct{})
+	t.Cleanup(func() { close(downstreamDone) })
+
+	downstream := RoundTripFunc(func(*http.Request) (*http.Response, error) {
+		go func() {
+			w := remote.NewChunkedWriter(downstreamWriter, noopFlusher{})
+			for _, value := range []string{"1", "2"} {
+				b, err := proto.Marshal(&prompb.ChunkedReadResponse{
+					ChunkedSeries: []*prompb.ChunkedSeries{{Labels: []prompb.Label{{Name: "series", Value: value}}, Chunks: []prompb.Chunk{{MaxTimeMs: 1}}}},
+				})
+				if err != nil {
+					_ = downstreamWriter.CloseWithError(err)
+					return
+				}
+				if _, err := w.Write(b); err != nil {
+					return
+				}
+			}
+
+			// Keep the downstream response open until the test ends.
+			<-downstreamDone
+			_ = downstreamWriter.Close()
+		}()
+		return &http.Response{StatusCode: http.StatusOK, Body: downstreamBody}, nil
+	})
+
+	rt := newRemoteReadRoundTripper(downstream, mockLimits{}, 0, log.NewNopLogger())
+	resp, err := rt.RoundTrip(newRemoteReadRequest(t, context.Background(), []prompb.ReadRequest_ResponseType{prompb.ReadRequest_STREAMED_XOR_CHUNKS}, &prompb.Query{
+		StartTimestampMs: 0,
+		EndTimestampMs:   10,
+		Matchers:         []*prompb.LabelMatcher{{Type: prompb.LabelMatcher_EQ, Name: "__name__", Value: "metric"}},
+	}))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	// The first series is streamed to the client while the downstream response is still being received.
+	frames := make(chan *prompb.ChunkedReadResponse)
+	go func() {
+		frame := &prompb.ChunkedReadResponse{}
+		if err := remote.NewChunkedReader(resp.Body, maxRemoteReadChunkedReadFrameBytes, nil).NextProto(frame); err == nil {
+			frames <- frame
+		}
+	}()
+
+	select {
+	case frame := <-frames:
+		require.Len(t, frame.ChunkedSeries, 1)
+		assert.Equal(t, []prompb.Label{{Name: "series", Value: "1"}}, frame.ChunkedSeries[0].Labels)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the first series has not been streamed before the downstream response completed")
+	}
+}
+
+func TestRemoteReadRoundTripper_Limits(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		limits                   mockLimits
+		respType                 prompb.ReadRequest_ResponseType
+		start, end               time.Time
+		expectedErr              error
+		expectedStreamErr        bool
+		expectedDownstreamCalled bool
+	}{
+		"query within the limits": {
+			limits:                   mockLimits{maxTotalQueryLength: 2 * time.Hour},
+			start:                    now.Add(-time.Hour),
+			end:                      now,
+			expectedDownstreamCalled: true,
+		},
+		"query exceeding max total query length": {
+			limits:      mockLimits{maxTotalQueryLength: time.Hour},
+			start:       now.Add(-2 * time.Hour),
+			end:         now,
+			expectedErr: apierror.New(apierror.TypeBadData, validation.NewMaxTotalQueryLengthError(2*time.Hour, time.Hour).Error()),
+		},
+		"blocked query": {
+			limits:      mockLimits{blockedQueries: []*validation.BlockedQuery{{Pattern: `.*metric.*`, Regex: true}}},
+			start:       now.Add(-time.Hour),
+			end:         now,
+			expectedErr: apierror.New(apierror.TypeBadData, validation.NewQueryBlockedError().Error()),
+		},
+		"query outside the max lookback": {
+			limits:                   mockLimits{maxQueryLookback: time.Hour, compactorBlocksRetentionPeriod: time.Hour},
+			start:                    now.Add(-3 * time.Hour),
+			end:                      now.Add(-2 * time.Hour),
+			expectedDownstreamCalled: false,
+		},
+		"query exceeding max fetched series with samples response": {
+			limits:                   mockLimits{maxFetchedSeriesPerQuery: 1},
+			start:                    now.Add(-time.Hour),
+			end:                      now,
+			expectedErr:              apierror.New(apierror.TypeExec, validation.LimitError(fmt.Sprintf(limiter.MaxSeriesHitMsgFormat, 1)).Error()),
+			expectedDownstreamCalled: true,
+		},
+		"query exceeding max fetched series with streamed chunks response": {
+			limits:                   mockLimits{maxFetchedSeriesPerQuery: 1},
+			respType:                 prompb.ReadRequest_STREAMED_XOR_CHUNKS,
+			start:                    now.Add(-time.Hour),
+			end:                      now,
+			expectedStreamErr:        true,
+			expectedDownstreamCalled: true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			downstream := newMockRemoteReadDownstream(t, func(query *prompb.Query) []*prompb.TimeSeries {
+				return []*prompb.TimeSeries{
+					{Labels: []prompb.Label{{Name: "series", Value: "1"}}},
+					{Labels: []prompb.Label{{Name: "series", Value: "2"}}},
+				}
+			})
+			downstream.chunksFn = func(query *prompb.Query) []*prompb.ChunkedSeries {
+				return []*prompb.ChunkedSeries{
+					{Labels: []prompb.Label{{Name: "series", Value: "1"}}, Chunks: []prompb.Chunk{{MinTimeMs: query.StartTimestampMs, MaxTimeMs: query.EndTimestampMs}}},
+					{Labels: []prompb.Label{{Name: "series", Value: "2"}}, Chunks: []prompb.Chunk{{MinTimeMs: query.StartTimestampMs, MaxTimeMs: query.EndTimestampMs}}},
+				}
+			}
+
+			logger := log.NewNopLogger()
+			rt := newRemoteReadRoundTripper(downstream, testData.limits, 0, logger,
+				newLimitsMiddleware(testData.limits, logger),
+				newQueryBlockerMiddleware(testData.limits, logger, nil),
+			)
+
+			respType := testData.respType
+			resp, err := rt.RoundTrip(newRemoteReadRequest(t, context.Background(), []prompb.ReadRequest_ResponseType{respType}, &prompb.Query{
+				StartTimestampMs: testData.start.UnixMilli(),
+				EndTimestampMs:   testData.end.UnixMilli(),
+				Matchers:         []*prompb.LabelMatcher{{Type: prompb.LabelMatcher_EQ, Name: "__name__", Value: "metric"}},
+			}))
+
+			if testData.expectedErr != nil {
+				require.Equal(t, testData.expectedErr, err)
+			} else {
+				require.NoError(t, err)
+				_, err = io.ReadAll(resp.Body)
+				if testData.expectedStreamErr {
+					require.Error(t, err)
+				} else {
+					require.NoError(t, err)
+				}
+			}
+
+			assert.Equal(t, testData.expectedDownstreamCalled, downstream.requestsCount() > 0)
+		})
+	}
+}
+
+// newRemoteReadRequest builds a remote read HTTP request for tenant "test".
+func newRemoteReadRequest(t *testing.T, ctx context.Context, respTypes []prompb.ReadRequest_ResponseType, queries ...*prompb.Query) *http.Request {
+	data, err := proto.Marshal(&prompb.ReadRequest{Queries: queries, AcceptedResponseTypes: respTypes})
+	require.NoError(t, err)
+
+	body := snappy.Encode(nil, data)
+	req, err := http.NewRequestWithContext(user.InjectOrgID(ctx, "test"), http.MethodPost, "/api/v1/read", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.ContentLength = int64(len(body))
+	return req
+}
+
+// mockRemoteReadDownstream is a http.RoundTripper simulating the querier remote read endpoint.
+type mockRemoteReadDownstream struct {
+	t         *testing.T
+	samplesFn func(query *prompb.Query) []*prompb.TimeSeries
+	chunksFn  func(query *prompb.Query) []*prompb.ChunkedSeries
+
+	mx       sync.Mutex
+	requests []*prompb.ReadRequest
+}
+
+func newMockRemoteReadDownstream(t *testing.T, samplesFn func(query *prompb.Query) []*prompb.TimeSeries) *mockRemoteReadDownstream {
+	return &mockRemoteReadDownstream{t: t, samplesFn: samplesFn}
+}
+
+func (m *mockRemoteReadDownstream) requestsCount() int {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return len(m.requests)
+}
+
+func (m *mockRemoteReadDownstream) RoundTrip(r *http.Request) (*http.Response, error) {
+	assert.Equal(m.t, "test", r.Header.Get(user.OrgIDHeaderName))
+
+	compressed, err := io.ReadAll(r.Body)
+	require.NoError(m.t, err)
+	data, err := snappy.Decode(nil, compressed)
+	require.NoError(m.t, err)
+	req := &prompb.ReadRequest{}
+	require.NoError(m.t, proto.Unmarshal(data, req))
+	require.Len(m.t, req.Queries, 1)
+	require.Len(m.t, req.AcceptedResponseTypes, 1)
+
+	m.mx.Lock()
+	m.requests = append(m.requests, req)
+	m.mx.Unlock()
+
+	query := req.Queries[0]
+
+	if req.AcceptedResponseTypes[0] == prompb.ReadRequest_STREAMED_XOR_CHUNKS {
+		buf := &bytes.Buffer{}
+		w := remote.NewChunkedWriter(buf, noopFlusher{})
+		for _, series := range m.chunksFn(query) {
+			// Write each chunk in a different frame, to simulate series split across frames.
+			for _, chk := range series.Chunks {
+				b, err := proto.Marshal(&prompb.ChunkedReadResponse{
+					ChunkedSeries: []*prompb.ChunkedSeries{{Labels: series.Labels, Chunks: []prompb.Chunk{chk}}},
+				})
+				require.NoError(m.t, err)
+				_, err = w.Write(b)
+				require.NoError(m.t, err)
+			}
+		}
+
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(buf)}, nil
+	}
+
+	data, err = proto.Marshal(&prompb.ReadResponse{Results: []*prompb.QueryResult{{Timeseries: m.samplesFn(query)}}})
+	require.NoError(m.t, err)
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(snappy.Encode(nil, data)))}, nil
+}
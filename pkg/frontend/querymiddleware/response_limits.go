@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/grafana/dskit/tenant"
+	jsoniter "github.com/json-iterator/go"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// errResponseSizeLimitExceeded is returned by sizeLimitedBuffer when a write would exceed its limit.
+var errResponseSizeLimitExceeded = errors.New("response size limit exceeded")
+
+// maxSeriesResponseBufferSizeBytes bounds the buffering of the series responses to enforce the series limit when
+// the size limit isn't set. Larger responses fail as if they exceeded a size limit set to this value.
+const maxSeriesResponseBufferSizeBytes = 256 * 1024 * 1024
+
+// queryResponseLimits are the limits enforced on the responses returned by the query-frontend.
+type queryResponseLimits struct {
+	maxSizeBytes int
+	maxSeries    int
+}
+
+func newQueryResponseLimits(tenantIDs []string, limits Limits) queryResponseLimits {
+	return queryResponseLimits{
+		maxSizeBytes: validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, limits.MaxQueryResponseSizeBytes),
+		maxSeries:    validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, limits.MaxQueryResponseSeries),
+	}
+}
+
+// checkSeries returns an error if the number of series exceeds the limit.
+func (l queryResponseLimits) checkSeries(series int) error {
+	if l.maxSeries > 0 && series > l.maxSeries {
+		return apierror.New(apierror.TypeExec, validation.NewMaxQueryResponseSeriesError(series, l.maxSeries).Error())
+	}
+	return nil
+}
+
+// checkSizeBytes returns an error if the size of the response exceeds the limit.
+func (l queryResponseLimits) checkSizeBytes(sizeBytes int) error {
+	if l.maxSizeBytes > 0 && sizeBytes > l.maxSizeBytes {
+		return l.sizeLimitExceededError()
+	}
+	return nil
+}
+
+func (l queryResponseLimits) sizeLimitExceededError() error {
+	return apierror.New(apierror.TypeExec, validation.NewMaxQueryResponseSizeBytesError(l.maxSizeBytes).Error())
+}
+
+type queryResponseLimitsContextKey int
+
+const queryResponseLimitsKey queryResponseLimitsContextKey = 0
+
+// contextWithQueryResponseLimits returns a context carrying the limits to enforce when encoding the response.
+func contextWithQueryResponseLimits(ctx context.Context, limits queryResponseLimits) context.Context {
+	return context.WithValue(ctx, queryResponseLimitsKey, limits)
+}
+
+// queryResponseLimitsFromContext returns the limits to enforce when encoding the response. Without limits in the
+// context, no limit is enforced.
+func queryResponseLimitsFromContext(ctx context.Context) queryResponseLimits {
+	limits, _ := ctx.Value(queryResponseLimitsKey).(queryResponseLimits)
+	return limits
+}
+
+// sizeLimitedBuffer buffers the writes, failing the writes which would make it exceed its limit.
+type sizeLimitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *sizeLimitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, errResponseSizeLimitExceeded
+	}
+	return b.Buffer.Write(p)
+}
+
+// queryResponseLimitsRoundTripper enforces the query response limits on the responses of the requests which are
+// not decoded by the query-frontend, like series, label names and label values requests.
+type queryResponseLimitsRoundTripper struct {
+	next   http.RoundTripper
+	limits Limits
+}
+
+func newQueryResponseLimitsRoundTripper(next http.RoundTripper, limits Limits) http.RoundTripper {
+	return queryResponseLimitsRoundTripper{
+		next:   next,
+		limits: limits,
+	}
+}
+
+func (rt queryResponseLimitsRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	tenantIDs, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+
+	limits := newQueryResponseLimits(tenantIDs, rt.limits)
+	if !isSeriesQuery(r.URL.Path) {
+		// Only the series requests return series.
+		limits.maxSeries = 0
+	}
+	if limits.maxSizeBytes == 0 && limits.maxSeries == 0 {
+		return rt.next.RoundTrip(r)
+	}
+
+	resp, err := rt.next.RoundTrip(r)
+	if err != nil || resp.StatusCode/100 != 2 {
+		return resp, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// The body is buffered to reject it before returning anything if it exceeds the limits. The buffering is bounded
+	// by the size limit, reading at most one byte more than the limit to detect if it's exceeded, and the series are
+	// counted while the body is read, so that the reading stops as soon as the series limit is exceeded. Without a
+	// size limit, the buffering is bounded by maxSeriesResponseBufferSizeBytes.
+	if limits.maxSizeBytes == 0 {
+		limits.maxSizeBytes = maxSeriesResponseBufferSizeBytes
+	}
+	if resp.ContentLength > int64(limits.maxSizeBytes) {
+		return nil, limits.sizeLimitExceededError()
+	}
+	buf := &bytes.Buffer{}
+	body := io.TeeReader(io.LimitReader(resp.Body, int64(limits.maxSizeBytes)+1), buf)
+
+	// The series of responses with an unknown content encoding can't be counted, so they're passed through.
+	contentEncoding := resp.Header.Get("Content-Encoding")
+	var countErr error
+	if limits.maxSeries > 0 && (contentEncoding == "" || contentEncoding == "gzip") {
+		var series int
+		series, countErr = countSeriesResponseItems(body, contentEncoding, limits.maxSeries)
+		if countErr == nil {
+			if err := limits.checkSeries(series); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Read the rest of the body not read while counting the series.
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		return nil, apierror.Newf(apierror.TypeInternal, "error reading response: %v", err)
+	}
+	if err := limits.checkSizeBytes(buf.Len()); err != nil {
+		return nil, err
+	}
+	if countErr != nil {
+		return nil, apierror.Newf(apierror.TypeInternal, "error decoding response: %v", countErr)
+	}
+
+	resp.Body = io.NopCloser(buf)
+	return resp, nil
+}
+
+// countSeriesResponseItems returns the number of series in the JSON body of a series response, without decoding
+// them. The body is decoded while it's read, and the counting stops once the number of series exceeds maxSeries.
+func countSeriesResponseItems(body io.Reader, contentEncoding string, maxSeries int) (int, error) {
+	if contentEncoding == "gzip" {
+		reader, err := gzip.NewReader(body)
+		if err != nil {
+			return 0, err
+		}
+		defer func() { _ = reader.Close() }()
+		body = reader
+	}
+
+	iter := jsoniter.Parse(json, body, 4096)
+
+	series := 0
+	iter.ReadObjectCB(func(iter *jsoniter.Iterator, field string) bool {
+		if field != "data" {
+			iter.Skip()
+			return true
+		}
+		return iter.ReadArrayCB(func(iter *jsoniter.Iterator) bool {
+			series++
+			if series > maxSeries {
+				return false
+			}
+			iter.Skip()
+			return true
+		})
+	})
+	if series > maxSeries {
+		return series, nil
+	}
+	if iter.Error != nil && iter.Error != io.EOF {
+		return 0, iter.Error
+	}
+	return series, nil
+}
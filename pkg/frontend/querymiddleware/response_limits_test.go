@@ -0,0 +1,236 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/dskit/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apierror "github.com/grafana/mimir/pkg/api/error"
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestPrometheusCodec_EncodeResponse_QueryResponseLimits(t *testing.T) {
+	resp := mockPrometheusResponse(10, 10)
+
+	jsonSize, err := encodeFormatterResponse(jsonFormatter{}, resp, 0)
+	require.NoError(t, err)
+	protobufSize, err := encodeFormatterResponse(protobufFormatter{}, resp, 0)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		format        string
+		limits        queryResponseLimits
+		expectedError error
+	}{
+		"json: no limits": {
+			format: formatJSON,
+		},
+		"json: within limits": {
+			format: formatJSON,
+			limits: queryResponseLimits{maxSizeBytes: len(jsonSize), maxSeries: 10},
+		},
+		"json: series limit exceeded": {
+			format:        formatJSON,
+			limits:        queryResponseLimits{maxSeries: 9},
+			expectedError: apierror.New(apierror.TypeExec, validation.NewMaxQueryResponseSeriesError(10, 9).Error()),
+		},
+		"json: size limit exceeded": {
+			format:        formatJSON,
+			limits:        queryResponseLimits{maxSizeBytes: len(jsonSize) - 1},
+			expectedError: apierror.New(apierror.TypeExec, validation.NewMaxQueryResponseSizeBytesError(len(jsonSize)-1).Error()),
+		},
+		"protobuf: within limits": {
+			format: formatProtobuf,
+			limits: queryResponseLimits{maxSizeBytes: len(protobufSize), maxSeries: 10},
+		},
+		"protobuf: size limit exceeded": {
+			format:        formatProtobuf,
+			limits:        queryResponseLimits{maxSizeBytes: len(protobufSize) - 1},
+			expectedError: apierror.New(apierror.TypeExec, validation.NewMaxQueryResponseSizeBytesError(len(protobufSize)-1).Error()),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			codec := NewPrometheusCodec(nil, tc.format)
+			contentType := jsonMimeType
+			if tc.format == formatProtobuf {
+				contentType = mimirpb.QueryResponseMimeType
+			}
+			req := &http.Request{Header: http.Header{"Accept": []string{contentType}}}
+
+			ctx := contextWithQueryResponseLimits(context.Background(), tc.limits)
+			encoded, err := codec.EncodeResponse(ctx, req, resp)
+			if tc.expectedError != nil {
+				require.Equal(t, tc.expectedError, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, encoded.StatusCode)
+		})
+	}
+}
+
+func TestQueryResponseLimitsRoundTripper(t *testing.T) {
+	const seriesBody = `{"status":"success","data":[{"__name__":"up","job":"a"},{"__name__":"up","job":"b"},{"__name__":"up","job":"c"}]}`
+	const labelsBody = `{"status":"success","data":["__name__","job"]}`
+
+	tests := map[string]struct {
+		path            string
+		body            string
+		contentEncoding string
+		contentLength   int64
+		limits          mockLimits
+		expectedError   error
+	}{
+		"series: no limits": {
+			path: seriesPathSuffix,
+			body: seriesBody,
+		},
+		"series: within limits": {
+			path:   seriesPathSuffix,
+			body:   seriesBody,
+			limits: mockLimits{maxQueryResponseSizeBytes: len(seriesBody), maxQueryResponseSeries: 3},
+		},
+		"series: series limit exceeded": {
+			path:          seriesPathSuffix,
+			body:          seriesBody,
+			limits:        mockLimits{maxQueryResponseSeries: 2},
+			expectedError: apierror.New(apierror.TypeExec, validation.NewMaxQueryResponseSeriesError(3, 2).Error()),
+		},
+		"series: series limit exceeded with gzipped response": {
+			path:            seriesPathSuffix,
+			body:            seriesBody,
+			contentEncoding: "gzip",
+			limits:          mockLimits{maxQueryResponseSeries: 2},
+			expectedError:   apierror.New(apierror.TypeExec, validation.NewMaxQueryResponseSeriesError(3, 2).Error()),
+		},
+		"series: within limits with gzipped response": {
+			path:            seriesPathSuffix,
+			body:            seriesBody,
+			contentEncoding: "gzip",
+			limits:          mockLimits{maxQueryResponseSeries: 3},
+		},
+		"series: series limit is not enforced with unknown content encoding": {
+			path:            seriesPathSuffix,
+			body:            seriesBody,
+			contentEncoding: "br",
+			limits:          mockLimits{maxQueryResponseSeries: 2},
+		},
+		"series: size limit exceeded": {
+			path:          seriesPathSuffix,
+			body:          seriesBody,
+			limits:        mockLimits{maxQueryResponseSizeBytes: len(seriesBody) - 1},
+			expectedError: apierror.New(apierror.TypeExec, validation.NewMaxQueryResponseSizeBytesError(len(seriesBody)-1).Error()),
+		},
+		"series: response exceeding the buffer size with only the series limit set": {
+			path:          seriesPathSuffix,
+			body:          seriesBody,
+			contentLength: maxSeriesResponseBufferSizeBytes + 1,
+			limits:        mockLimits{maxQueryResponseSeries: 3},
+			expectedError: apierror.New(apierror.TypeExec, validation.NewMaxQueryResponseSizeBytesError(maxSeriesResponseBufferSizeBytes).Error()),
+		},
+		"label names: series limit is not enforced": {
+			path:   labelNamesPathSuffix,
+			body:   labelsBody,
+			limits: mockLimits{maxQueryResponseSeries: 1},
+		},
+		"label names: size limit exceeded": {
+			path:          labelNamesPathSuffix,
+			body:          labelsBody,
+			limits:        mockLimits{maxQueryResponseSizeBytes: 10},
+			expectedError: apierror.New(apierror.TypeExec, validation.NewMaxQueryResponseSizeBytesError(10).Error()),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			body := []byte(tc.body)
+			if tc.contentEncoding == "gzip" {
+				var buf bytes.Buffer
+				gz := gzip.NewWriter(&buf)
+				_, err := gz.Write(body)
+				require.NoError(t, err)
+				require.NoError(t, gz.Close())
+				body = buf.Bytes()
+			}
+
+			next := RoundTripFunc(func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode:    http.StatusOK,
+					Header:        http.Header{"Content-Encoding": []string{tc.contentEncoding}},
+					Body:          io.NopCloser(bytes.NewReader(body)),
+					ContentLength: tc.contentLength,
+				}, nil
+			})
+			rt := newQueryResponseLimitsRoundTripper(next, tc.limits)
+
+			req := httptest.NewRequest(http.MethodGet, "/prometheus"+tc.path, nil)
+			req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+
+			resp, err := rt.RoundTrip(req)
+			if tc.expectedError != nil {
+				require.Equal(t, tc.expectedError, err)
+				return
+			}
+
+			require.NoError(t, err)
+			actual, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, body, actual)
+		})
+	}
+}
+
+func TestQueryResponseLimits_MultipleTenants(t *testing.T) {
+	limits := multiTenantMockLimits{byTenant: map[string]mockLimits{
+		"tenant-1": {maxQueryResponseSizeBytes: 100, maxQueryResponseSeries: 0},
+		"tenant-2": {maxQueryResponseSizeBytes: 0, maxQueryResponseSeries: 10},
+	}}
+
+	// The most restrictive non-zero limit of the tenants is enforced.
+	assert.Equal(t, queryResponseLimits{maxSizeBytes: 100, maxSeries: 10}, newQueryResponseLimits([]string{"tenant-1", "tenant-2"}, limits))
+}
+
+func TestLimitedRoundTripper_QueryResponseLimits(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	codec := newTestPrometheusCodec()
+	r, err := codec.EncodeRequest(ctx, &PrometheusRangeQueryRequest{
+		Path:  "/api/v1/query_range",
+		Start: 0,
+		End:   60000,
+		Step:  1000,
+		Query: `foo`,
+	})
+	require.NoError(t, err)
+
+	downstream := RoundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("unexpected request to downstream")
+	})
+	handler := MiddlewareFunc(func(Handler) Handler {
+		return HandlerFunc(func(context.Context, Request) (Response, error) {
+			return mockPrometheusResponse(3, 1), nil
+		})
+	})
+
+	_, err = newLimitedParallelismRoundTripper(downstream, codec, mockLimits{maxQueryResponseSeries: 2}, handler).RoundTrip(r)
+	require.Equal(t, apierror.New(apierror.TypeExec, validation.NewMaxQueryResponseSeriesError(3, 2).Error()), err)
+
+	resp, err := newLimitedParallelismRoundTripper(downstream, codec, mockLimits{maxQueryResponseSeries: 3}, handler).RoundTrip(r)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
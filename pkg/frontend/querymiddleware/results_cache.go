@@ -39,11 +39,11 @@ const (
 	// resultsCacheVersion should be increased every time cache should be invalidated (after a bugfix or cache format change).
 	resultsCacheVersion = 1
 
-	// cacheControlHeader is the name of the cache control header.
-	cacheControlHeader = "Cache-Control"
+	// CacheControlHeader is the name of the cache control header.
+	CacheControlHeader = "Cache-Control"
 
-	// noStoreValue is the value that cacheControlHeader has if the response indicates that the results should not be cached.
-	noStoreValue = "no-store"
+	// NoStoreValue is the value that CacheControlHeader has if the response indicates that the results should not be cached.
+	NoStoreValue = "no-store"
 )
 
 var (
@@ -228,10 +228,10 @@ func isRequestCachable(req Request, maxCacheTime int64, cacheUnalignedRequests b
 
 // isResponseCachable says whether the response should be cached or not.
 func isResponseCachable(r Response, logger log.Logger) bool {
-	headerValues := getHeaderValuesWithName(r, cacheControlHeader)
+	headerValues := getHeaderValuesWithName(r, CacheControlHeader)
 	for _, v := range headerValues {
-		if v == noStoreValue {
-			level.Debug(logger).Log("msg", fmt.Sprintf("%s header in response is equal to %s, not caching the response", cacheControlHeader, noStoreValue))
+		if v == NoStoreValue {
+			level.Debug(logger).Log("msg", fmt.Sprintf("%s header in response is equal to %s, not caching the response", CacheControlHeader, NoStoreValue))
 			return false
 		}
 	}
@@ -240,19 +240,21 @@ func isResponseCachable(r Response, logger log.Logger) bool {
 }
 
 var (
-	errAtModifierAfterEnd = errors.New("at modifier after end")
-	errNegativeOffset     = errors.New("negative offset")
+	errAtModifierAfterMaxCacheTime = errors.New("at modifier after max cache time")
+	errNegativeOffset              = errors.New("negative offset after max cache time")
 )
 
 // areEvaluationTimeModifiersCachable returns true if the @ modifier and the offset modifier results are safe to cache.
 func areEvaluationTimeModifiersCachable(r Request, maxCacheTime int64, logger log.Logger) bool {
-	// There are 3 cases when evaluation time modifiers are not safe to cache:
-	//   1. When @ modifier points to time beyond the maxCacheTime.
-	//   2. If the @ modifier time is > the query range end while being
-	//      below maxCacheTime. In such cases if any tenant is intentionally
-	//      playing with old data, we could cache empty result if we look
-	//      beyond query end.
-	//   3. When query contains a negative offset.
+	// The @ and offset modifiers change the time at which a selector reads the data, so the
+	// data read by the query may be more recent than the query range end. Such results are safe
+	// to cache only if the most recent data read by each selector is older than maxCacheTime:
+	//   1. When the @ modifier is used, the selector is evaluated at the fixed timestamp (minus the
+	//      offset, if any), regardless of the query range. Start() and end() are resolved against
+	//      the request range first.
+	//   2. When the @ modifier is not used, the selector is evaluated up to the query range end
+	//      minus the offset, which is after the query range end when the offset is negative.
+	// The offset and @ modifiers of the enclosing subqueries apply to the selectors they contain too.
 	query := r.GetQuery()
 	if !strings.Contains(query, "@") && !strings.Contains(query, "offset") {
 		return true
@@ -269,24 +271,47 @@ func areEvaluationTimeModifiersCachable(r Request, maxCacheTime int64, logger lo
 
 	end := r.GetEnd()
 	cachable := true
-	check := func(ts *int64, offset time.Duration) error {
-		if offset < 0 {
+	check := func(n parser.Node, path []parser.Node) error {
+		// The offsets of the enclosing subqueries add up, so the most recent data read by the selector
+		// is computed walking the path from the root down to the selector.
+		readEnd, pinned := end, false
+		for _, p := range append(path[:len(path):len(path)], n) {
+			var (
+				ts     *int64
+				offset time.Duration
+			)
+			switch e := p.(type) {
+			case *parser.VectorSelector:
+				ts, offset = e.Timestamp, e.OriginalOffset
+			case *parser.SubqueryExpr:
+				ts, offset = e.Timestamp, e.OriginalOffset
+			default:
+				continue
+			}
+			if ts != nil {
+				readEnd, pinned = *ts, true
+			}
+			readEnd -= offset.Milliseconds()
+		}
+
+		if readEnd <= maxCacheTime {
+			return nil
+		}
+		if pinned {
 			cachable = false
-			return errNegativeOffset
+			return errAtModifierAfterMaxCacheTime
 		}
-		if ts != nil && (*ts > end || *ts > maxCacheTime) {
+		if readEnd > end {
 			cachable = false
-			return errAtModifierAfterEnd
+			return errNegativeOffset
 		}
 		return nil
 	}
 
-	parser.Inspect(expr, func(n parser.Node, _ []parser.Node) error {
-		switch e := n.(type) {
-		case *parser.VectorSelector:
-			return check(e.Timestamp, e.OriginalOffset)
-		case *parser.SubqueryExpr:
-			return check(e.Timestamp, e.OriginalOffset)
+	parser.Inspect(expr, func(n parser.Node, path []parser.Node) error {
+		switch n.(type) {
+		case *parser.VectorSelector, *parser.SubqueryExpr:
+			return check(n, path)
 		}
 		return nil
 	})
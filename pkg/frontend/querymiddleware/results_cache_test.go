@@ -137,10 +137,9 @@ func TestIsRequestCachable(t *testing.T) {
 			expected: true,
 		},
 		{
-			name:                      "@ modifier on vector selector, after end, before maxCacheTime",
-			request:                   &PrometheusRangeQueryRequest{Query: "metric @ 127", End: 125000, Step: 5},
-			expected:                  false,
-			expectedNotCachableReason: notCachableReasonModifiersNotCachable,
+			name:     "@ modifier on vector selector, after end, before maxCacheTime",
+			request:  &PrometheusRangeQueryRequest{Query: "metric @ 127", End: 125000, Step: 5},
+			expected: true,
 		},
 		{
 			name:                      "@ modifier on vector selector, before end, after maxCacheTime",
@@ -172,10 +171,9 @@ func TestIsRequestCachable(t *testing.T) {
 			expected: true,
 		},
 		{
-			name:                      "@ modifier on matrix selector, after end, before maxCacheTime",
-			request:                   &PrometheusRangeQueryRequest{Query: "rate(metric[5m] @ 127)", End: 125000, Step: 5},
-			expected:                  false,
-			expectedNotCachableReason: notCachableReasonModifiersNotCachable,
+			name:     "@ modifier on matrix selector, after end, before maxCacheTime",
+			request:  &PrometheusRangeQueryRequest{Query: "rate(metric[5m] @ 127)", End: 125000, Step: 5},
+			expected: true,
 		},
 		{
 			name:                      "@ modifier on matrix selector, before end, after maxCacheTime",
@@ -207,10 +205,9 @@ func TestIsRequestCachable(t *testing.T) {
 			expected: true,
 		},
 		{
-			name:                      "@ modifier on subqueries, after end, before maxCacheTime",
-			request:                   &PrometheusRangeQueryRequest{Query: "sum_over_time(rate(metric[1m])[10m:1m] @ 127)", End: 125000, Step: 5},
-			expected:                  false,
-			expectedNotCachableReason: notCachableReasonModifiersNotCachable,
+			name:     "@ modifier on subqueries, after end, before maxCacheTime",
+			request:  &PrometheusRangeQueryRequest{Query: "sum_over_time(rate(metric[1m])[10m:1m] @ 127)", End: 125000, Step: 5},
+			expected: true,
 		},
 		{
 			name:                      "@ modifier on subqueries, before end, after maxCacheTime",
@@ -242,11 +239,43 @@ func TestIsRequestCachable(t *testing.T) {
 			expected: true,
 		},
 		{
-			name:                      "negative offset on vector selector",
-			request:                   &PrometheusRangeQueryRequest{Query: "metric offset -1ms", End: 125000, Step: 5},
+			name:     "negative offset on vector selector, before maxCacheTime",
+			request:  &PrometheusRangeQueryRequest{Query: "metric offset -1ms", End: 125000, Step: 5},
+			expected: true,
+		},
+		{
+			name:     "negative offset on vector selector, end minus offset equal to maxCacheTime",
+			request:  &PrometheusRangeQueryRequest{Query: "metric offset -25s", End: 125000, Step: 5},
+			expected: true,
+		},
+		{
+			name:                      "negative offset on vector selector, end minus offset after maxCacheTime",
+			request:                   &PrometheusRangeQueryRequest{Query: "metric offset -26s", End: 125000, Step: 5},
 			expected:                  false,
 			expectedNotCachableReason: notCachableReasonModifiersNotCachable,
 		},
+		// @ and offset modifiers combined on vector selectors.
+		{
+			name:     "@ modifier with negative offset on vector selector, before maxCacheTime",
+			request:  &PrometheusRangeQueryRequest{Query: "metric @ 100 offset -50s", End: 200000, Step: 5},
+			expected: true,
+		},
+		{
+			name:                      "@ modifier with negative offset on vector selector, after maxCacheTime",
+			request:                   &PrometheusRangeQueryRequest{Query: "metric @ 100 offset -51s", End: 125000, Step: 5},
+			expected:                  false,
+			expectedNotCachableReason: notCachableReasonModifiersNotCachable,
+		},
+		{
+			name:     "@ modifier with positive offset on vector selector, @ after maxCacheTime but offset before",
+			request:  &PrometheusRangeQueryRequest{Query: "metric @ 160 offset 10s", End: 200000, Step: 5},
+			expected: true,
+		},
+		{
+			name:     "@ modifier on vector selector equal to maxCacheTime",
+			request:  &PrometheusRangeQueryRequest{Query: "metric @ 150", End: 125000, Step: 5},
+			expected: true,
+		},
 		// offset modifier on subqueries.
 		{
 			name:     "positive offset on subqueries",
@@ -259,6 +288,33 @@ func TestIsRequestCachable(t *testing.T) {
 			expected:                  false,
 			expectedNotCachableReason: notCachableReasonModifiersNotCachable,
 		},
+		{
+			name:     "negative offset on subqueries, before maxCacheTime",
+			request:  &PrometheusRangeQueryRequest{Query: "sum_over_time(rate(metric[1m])[10m:1m] offset -1ms)", Start: 100000, End: 120000, Step: 5},
+			expected: true,
+		},
+		{
+			name:                      "negative offsets on subquery and inner selector, adding up after maxCacheTime",
+			request:                   &PrometheusRangeQueryRequest{Query: "sum_over_time(rate(metric[1m] offset -15s)[10m:1m] offset -15s)", Start: 100000, End: 125000, Step: 5},
+			expected:                  false,
+			expectedNotCachableReason: notCachableReasonModifiersNotCachable,
+		},
+		{
+			name:     "negative offsets on subquery and inner selector, adding up before maxCacheTime",
+			request:  &PrometheusRangeQueryRequest{Query: "sum_over_time(rate(metric[1m] offset -10s)[10m:1m] offset -15s)", Start: 100000, End: 125000, Step: 5},
+			expected: true,
+		},
+		{
+			name:     "negative offset on inner selector compensated by the positive offset of the subquery",
+			request:  &PrometheusRangeQueryRequest{Query: "sum_over_time(rate(metric[1m] offset -1m)[10m:1m] offset 1m)", Start: 100000, End: 200000, Step: 5},
+			expected: true,
+		},
+		{
+			name:                      "negative offset on inner selector of a subquery with @ modifier, adding up after maxCacheTime",
+			request:                   &PrometheusRangeQueryRequest{Query: "sum_over_time(rate(metric[1m] offset -10s)[10m:1m] @ 145)", End: 125000, Step: 5},
+			expected:                  false,
+			expectedNotCachableReason: notCachableReasonModifiersNotCachable,
+		},
 		// On step aligned and non-aligned requests
 		{
 			name:     "request that is step aligned",
@@ -299,7 +355,7 @@ func TestIsResponseCachable(t *testing.T) {
 		response Response
 		expected bool
 	}{
-		// Tests only for cacheControlHeader
+		// Tests only for CacheControlHeader
 		{
 			name: "does not contain the cacheControl header",
 			response: Response(&PrometheusResponse{
@@ -317,8 +373,8 @@ func TestIsResponseCachable(t *testing.T) {
 			response: Response(&PrometheusResponse{
 				Headers: []*PrometheusResponseHeader{
 					{
-						Name:   cacheControlHeader,
-						Values: []string{noStoreValue},
+						Name:   CacheControlHeader,
+						Values: []string{NoStoreValue},
 					},
 				},
 			}),
@@ -329,8 +385,8 @@ func TestIsResponseCachable(t *testing.T) {
 			response: Response(&PrometheusResponse{
 				Headers: []*PrometheusResponseHeader{
 					{
-						Name:   cacheControlHeader,
-						Values: []string{"foo", noStoreValue},
+						Name:   CacheControlHeader,
+						Values: []string{"foo", NoStoreValue},
 					},
 				},
 			}),
@@ -351,7 +407,7 @@ func TestIsResponseCachable(t *testing.T) {
 		{
 			name: "had cacheControl header but no values",
 			response: Response(&PrometheusResponse{
-				Headers: []*PrometheusResponseHeader{{Name: cacheControlHeader}},
+				Headers: []*PrometheusResponseHeader{{Name: CacheControlHeader}},
 			}),
 			expected: true,
 		},
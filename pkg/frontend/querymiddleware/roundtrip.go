@@ -34,6 +34,7 @@ const (
 	cardinalityLabelNamesPathSuffix  = "/api/v1/cardinality/label_names"
 	cardinalityLabelValuesPathSuffix = "/api/v1/cardinality/label_values"
 	labelNamesPathSuffix             = "/api/v1/labels"
+	seriesPathSuffix                 = "/api/v1/series"
 
 	// DefaultDeprecatedCacheUnalignedRequests is the default value for the deprecated querier frontend config DeprecatedCacheUnalignedRequests
 	// which has been moved to a per-tenant limit; TODO remove in Mimir 2.12
@@ -54,6 +55,7 @@ type Config struct {
 	ShardedQueries                   bool   `yaml:"parallelize_shardable_queries"`
 	DeprecatedCacheUnalignedRequests bool   `yaml:"cache_unaligned_requests" category:"advanced" doc:"hidden"` // Deprecated: Deprecated in Mimir 2.10.0, remove in Mimir 2.12.0 (https://github.com/grafana/mimir/issues/5253)
 	TargetSeriesPerShard             uint64 `yaml:"query_sharding_target_series_per_shard" category:"advanced"`
+	CoalesceIdenticalQueries         bool   `yaml:"coalesce_identical_queries" category:"experimental"`
 
 	// CacheSplitter allows to inject a CacheSplitter to use for generating cache keys.
 	// If nil, the querymiddleware package uses a ConstSplitter with SplitQueriesByInterval.
@@ -70,6 +72,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.CacheResults, "query-frontend.cache-results", false, "Cache query results.")
 	f.BoolVar(&cfg.ShardedQueries, "query-frontend.parallelize-shardable-queries", false, "True to enable query sharding.")
 	f.Uint64Var(&cfg.TargetSeriesPerShard, "query-frontend.query-sharding-target-series-per-shard", 0, "How many series a single sharded partial query should load at most. This is not a strict requirement guaranteed to be honoured by query sharding, but a hint given to the query sharding when the query execution is initially planned. 0 to disable cardinality-based hints.")
+	f.BoolVar(&cfg.CoalesceIdenticalQueries, "query-frontend.coalesce-identical-queries", false, "True to deduplicate identical in-flight queries: a range or instant query received while an identical query of the same tenant is running waits for the running query and shares its result and query statistics.")
 	f.StringVar(&cfg.QueryResultResponseFormat, "query-frontend.query-result-response-format", formatProtobuf, fmt.Sprintf("Format to use when retrieving query results from queriers. Supported values: %s", strings.Join(allFormats, ", ")))
 	cfg.ResultsCacheConfig.RegisterFlags(f)
 
@@ -200,10 +203,16 @@ func newQueryTripperware(
 	// Metric used to keep track of each middleware execution duration.
 	metrics := newInstrumentMiddlewareMetrics(registerer)
 	queryBlockerMiddleware := newQueryBlockerMiddleware(limits, log, registerer)
+	queryStatsMiddleware := newQueryStatsMiddleware(registerer)
+
+	responseStatsMiddleware := newResponseStatsMiddleware()
+	tenantFederationPushdownMiddleware := newTenantFederationPushdownMiddleware(log)
 
 	queryRangeMiddleware := []Middleware{
 		// Track query range statistics. Added first before any subsequent middleware modifies the request.
-		newQueryStatsMiddleware(registerer),
+		queryStatsMiddleware,
+		responseStatsMiddleware,
+		tenantFederationPushdownMiddleware,
 		newLimitsMiddleware(limits, log),
 		queryBlockerMiddleware,
 	}
@@ -211,6 +220,13 @@ func newQueryTripperware(
 		queryRangeMiddleware = append(queryRangeMiddleware, newInstrumentMiddleware("step_align", metrics), newStepAlignMiddleware())
 	}
 
+	// Coalesce identical queries after they've been aligned to the step, but before they get split and sharded.
+	var coalescingMiddleware Middleware
+	if cfg.CoalesceIdenticalQueries {
+		coalescingMiddleware = newCoalescingMiddleware(newRequestCoalescer(log, registerer))
+		queryRangeMiddleware = append(queryRangeMiddleware, newInstrumentMiddleware("coalescing", metrics), coalescingMiddleware)
+	}
+
 	var c cache.Cache
 	if cfg.CacheResults || cfg.cardinalityBasedShardingEnabled() {
 		var err error
@@ -248,7 +264,10 @@ func newQueryTripperware(
 		))
 	}
 
-	queryInstantMiddleware := []Middleware{newLimitsMiddleware(limits, log)}
+	queryInstantMiddleware := []Middleware{responseStatsMiddleware, tenantFederationPushdownMiddleware, newLimitsMiddleware(limits, log)}
+	if coalescingMiddleware != nil {
+		queryInstantMiddleware = append(queryInstantMiddleware, coalescingMiddleware)
+	}
 
 	queryInstantMiddleware = append(
 		queryInstantMiddleware,
@@ -305,6 +324,16 @@ func newQueryTripperware(
 			newLimitedParallelismRoundTripper(next, codec, limits, queryInstantMiddleware...),
 		)
 
+		// Explained queries run through the same middlewares, but are never executed against queriers.
+		explain := newExplainRoundTripper(codec, limits, queryRangeMiddleware, queryInstantMiddleware)
+
+		// Remote read queries are only validated by the middlewares, and then split and executed by the roundtripper.
+		remoteRead := newRemoteReadRoundTripper(next, limits, cfg.SplitQueriesByInterval, log,
+			queryStatsMiddleware,
+			newLimitsMiddleware(limits, log),
+			queryBlockerMiddleware,
+		)
+
 		// Inject the cardinality and labels query cache roundtripper only if the query results cache is enabled.
 		cardinality := next
 		labels := next
@@ -314,16 +343,28 @@ func newQueryTripperware(
 			labels = newLabelsQueryCacheRoundTripper(c, limits, next, log, registerer)
 		}
 
+		// The responses of these requests are not decoded by the query-frontend, so the response limits are enforced
+		// on their body.
+		cardinality = newQueryResponseLimitsRoundTripper(cardinality, limits)
+		labels = newQueryResponseLimitsRoundTripper(labels, limits)
+		series := newQueryResponseLimitsRoundTripper(next, limits)
+
 		return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
 			switch {
 			case isRangeQuery(r.URL.Path):
 				return queryrange.RoundTrip(r)
 			case isInstantQuery(r.URL.Path):
 				return instant.RoundTrip(r)
+			case isExplainQuery(r.URL.Path):
+				return explain.RoundTrip(r)
 			case isCardinalityQuery(r.URL.Path):
 				return cardinality.RoundTrip(r)
 			case isLabelsQuery(r.URL.Path):
 				return labels.RoundTrip(r)
+			case isSeriesQuery(r.URL.Path):
+				return series.RoundTrip(r)
+			case isRemoteReadQuery(r.URL.Path):
+				return remoteRead.RoundTrip(r)
 			default:
 				return next.RoundTrip(r)
 			}
@@ -349,6 +390,8 @@ func newActiveUsersTripperware(registerer prometheus.Registerer) Tripperware {
 			op := "query"
 			if isRangeQuery(r.URL.Path) {
 				op = "query_range"
+			} else if isRemoteReadQuery(r.URL.Path) {
+				op = "remote_read"
 			}
 
 			tenantIDs, err := tenant.TenantIDs(r.Context())
@@ -381,6 +424,10 @@ func isLabelsQuery(path string) bool {
 	return strings.HasSuffix(path, labelNamesPathSuffix) || labelValuesPathSuffix.MatchString(path)
 }
 
+func isSeriesQuery(path string) bool {
+	return strings.HasSuffix(path, seriesPathSuffix)
+}
+
 func defaultInstantQueryParamsRoundTripper(next http.RoundTripper) http.RoundTripper {
 	return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
 		if isInstantQuery(r.URL.Path) && !r.Form.Has("time") && !r.URL.Query().Has("time") {
@@ -8,6 +8,7 @@ package querymiddleware
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -152,9 +153,13 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 	maxCacheTime := int64(model.Now().Add(-maxCacheFreshness))
 	cacheUnalignedRequests := validation.AllTrueBooleansPerTenant(tenantIDs, s.limits.ResultsCacheForUnalignedQueryEnabled)
 
-	// Lookup the results cache.
+	// Lookup the results cache. The results cache metrics are not updated if the query is only explained.
+	isExplained := isQueryExplained(ctx)
 	if isCacheEnabled {
-		s.metrics.queryResultCacheAttemptedCount.Add(float64(len(splitReqs)))
+		if !isExplained {
+			s.metrics.queryResultCacheAttemptedCount.Add(float64(len(splitReqs)))
+		}
+		queryExplanationFromContext(ctx).addLimit("max_cache_freshness", maxCacheFreshness)
 
 		// Build the cache keys for all requests to try to fetch from cache.
 		lookupReqs := make([]*splitRequest, 0, len(splitReqs))
@@ -164,7 +169,9 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 			// Do not try to pick response from cache at all if the request is not cachable.
 			if cachable, reason := isRequestCachable(splitReq.orig, maxCacheTime, cacheUnalignedRequests, s.logger); !cachable {
 				splitReq.downstreamRequests = []Request{splitReq.orig}
-				s.metrics.queryResultCacheSkippedCount.WithLabelValues(reason).Inc()
+				if !isExplained {
+					s.metrics.queryResultCacheSkippedCount.WithLabelValues(reason).Inc()
+				}
 				continue
 			}
 
@@ -175,6 +182,7 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 
 		// Lookup all keys from cache.
 		fetchedExtents := s.fetchCacheExtents(ctx, s.currentTime(), tenantIDs, lookupKeys)
+		cacheHits := 0
 
 		for lookupIdx, extents := range fetchedExtents {
 			if len(extents) == 0 {
@@ -183,6 +191,9 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 				continue
 			}
 
+			cacheHits++
+			lookupReqs[lookupIdx].fetchedExtents = extents
+
 			// We have some extents. This means some parts of the response has been cached and we need
 			// to generate the queries for the missing parts.
 			requests, responses, err := partitionCacheExtents(lookupReqs[lookupIdx].orig, extents, defaultMinCacheExtent, s.extractor)
@@ -205,6 +216,11 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 			lookupReqs[lookupIdx].cachedResponses = responses
 			lookupReqs[lookupIdx].cachedExtents = extents
 		}
+
+		// Update query stats. A lookup is a hit if the response has been fully or partially picked up from the cache.
+		queryStats := stats.FromContext(ctx)
+		queryStats.AddResultsCacheLookups(uint32(len(lookupReqs)))
+		queryStats.AddResultsCacheHits(uint32(cacheHits))
 	} else {
 		// Cache is disabled. We've just to execute the original request.
 		for _, splitReq := range splitReqs {
@@ -212,6 +228,8 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 		}
 	}
 
+	queryExplanationFromContext(ctx).addSplitQueries(splitReqs, isCacheEnabled)
+
 	// Prepare and execute the downstream requests.
 	execReqs := splitReqs.prepareDownstreamRequests()
 
@@ -234,8 +252,8 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 		}
 	}
 
-	// Store the updated response in the results cache.
-	if isCacheEnabled && len(execReqs) > 0 {
+	// Store the updated response in the results cache. Nothing is stored if the query is only explained.
+	if isCacheEnabled && len(execReqs) > 0 && !isExplained {
 		for _, splitReq := range splitReqs {
 			// If there are no downstream requests it means the response was entirely picked up from the cache
 			// so there's no need to store it again in the cache (because nothing has changed).
@@ -298,9 +316,21 @@ func (s *splitAndCacheMiddleware) Do(ctx context.Context, req Request) (Response
 	return s.merger.MergeResponse(responses...)
 }
 
-// splitRequestByInterval splits the given Request by configured interval. Returns the input request if splitting is disabled.
+// splitRequestByInterval splits the given Request by configured interval. Returns the input request if splitting is disabled
+// either in the config or for the specific request.
 func (s *splitAndCacheMiddleware) splitRequestByInterval(req Request) (splitRequests, error) {
-	if !s.splitEnabled {
+	if !s.splitEnabled || req.GetOptions().SplitDisabled {
+		// The @ start() and @ end() modifiers are resolved against the request time range, so they
+		// must be replaced with their constant values to get a cache key which doesn't collide with
+		// requests having the same query but a different time range.
+		if strings.Contains(req.GetQuery(), "@") {
+			query, err := evaluateAtModifierFunction(req.GetQuery(), req.GetStart(), req.GetEnd())
+			if err != nil {
+				return nil, err
+			}
+			req = req.WithQuery(query)
+		}
+
 		return splitRequests{{orig: req}}, nil
 	}
 
@@ -345,9 +375,11 @@ func (s *splitAndCacheMiddleware) fetchCacheExtents(ctx context.Context, now tim
 	}
 
 	// Lookup the cache.
-	s.metrics.cacheRequests.Add(float64(len(keys)))
 	founds := s.cache.Fetch(ctx, hashedKeys)
-	s.metrics.cacheHits.Add(float64(len(founds)))
+	if !isQueryExplained(ctx) {
+		s.metrics.cacheRequests.Add(float64(len(keys)))
+		s.metrics.cacheHits.Add(float64(len(founds)))
+	}
 
 	// Decode all cached responses.
 	extents := make([][]Extent, len(keys))
@@ -454,6 +486,10 @@ type splitRequest struct {
 	// The extents picked up from the cache.
 	cachedExtents []Extent
 
+	// All the extents fetched from the cache, including the ones of a response fully picked up from the cache.
+	// Only used to explain the query execution.
+	fetchedExtents []Extent
+
 	// The responses picked up from the cache.
 	cachedResponses []Response
 
@@ -622,7 +658,8 @@ func splitQueryByInterval(r Request, interval time.Duration) ([]Request, error)
 	return reqs, nil
 }
 
-// evaluateAtModifierFunction parse the query and evaluates the `start()` and `end()` at modifier functions into actual constant timestamps.
+// evaluateAtModifierFunction parse the query and evaluates the `start()` and `end()` at modifier functions into actual constant timestamps,
+// both on selectors and subqueries.
 // For example given the start of the query is 10.00, `http_requests_total[1h] @ start()` query will be replaced with `http_requests_total[1h] @ 10.00`
 // If the modifier is already a constant, it will be returned as is.
 func evaluateAtModifierFunction(query string, start, end int64) (string, error) {
@@ -630,15 +667,21 @@ func evaluateAtModifierFunction(query string, start, end int64) (string, error)
 	if err != nil {
 		return "", apierror.New(apierror.TypeBadData, decorateWithParamName(err, "query").Error())
 	}
+	resolve := func(ts **int64, startOrEnd *parser.ItemType) {
+		switch *startOrEnd {
+		case parser.START:
+			*ts = &start
+		case parser.END:
+			*ts = &end
+		}
+		*startOrEnd = 0
+	}
 	parser.Inspect(expr, func(n parser.Node, _ []parser.Node) error {
-		if selector, ok := n.(*parser.VectorSelector); ok {
-			switch selector.StartOrEnd {
-			case parser.START:
-				selector.Timestamp = &start
-			case parser.END:
-				selector.Timestamp = &end
-			}
-			selector.StartOrEnd = 0
+		switch e := n.(type) {
+		case *parser.VectorSelector:
+			resolve(&e.Timestamp, &e.StartOrEnd)
+		case *parser.SubqueryExpr:
+			resolve(&e.Timestamp, &e.StartOrEnd)
 		}
 		return nil
 	})
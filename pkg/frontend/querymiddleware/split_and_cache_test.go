@@ -338,6 +338,8 @@ func TestSplitAndCacheMiddleware_ResultsCache(t *testing.T) {
 	// Assert query stats from context
 	queryStats = stats.FromContext(ctx)
 	assert.Equal(t, uint32(2), queryStats.LoadSplitQueries())
+	assert.Equal(t, uint32(3), queryStats.LoadResultsCacheLookups())
+	assert.Equal(t, uint32(2), queryStats.LoadResultsCacheHits())
 
 	// Assert metrics
 	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
@@ -365,6 +367,161 @@ func TestSplitAndCacheMiddleware_ResultsCache(t *testing.T) {
 	`)))
 }
 
+func TestSplitAndCacheMiddleware_ResultsCache_EvaluationTimeModifiers(t *testing.T) {
+	const step = int64(120 * 1000)
+
+	var (
+		start = parseTimeRFC3339(t, "2021-10-15T10:00:00Z").Unix() * 1000
+		end   = parseTimeRFC3339(t, "2021-10-15T12:00:00Z").Unix() * 1000
+	)
+
+	tests := map[string]struct {
+		splitEnabled bool
+		query        string
+		// The second request is the first request shifted by the given amount of steps.
+		shiftSteps                   int64
+		expectedDownstreamQueries    []string
+		expectedCacheStoreCalls      int
+		expectedSkippedWithModifiers int
+	}{
+		"@ start() with splitting disabled should be resolved before computing the cache key": {
+			splitEnabled: false,
+			query:        "metric @ start()",
+			shiftSteps:   1,
+			expectedDownstreamQueries: []string{
+				fmt.Sprintf("metric @ %.3f", float64(start)/1000),
+				fmt.Sprintf("metric @ %.3f", float64(start+step)/1000),
+			},
+			expectedCacheStoreCalls: 2,
+		},
+		"@ end() with splitting enabled should be resolved before computing the cache key": {
+			splitEnabled: true,
+			query:        "metric @ end()",
+			shiftSteps:   1,
+			expectedDownstreamQueries: []string{
+				fmt.Sprintf("metric @ %.3f", float64(end)/1000),
+				fmt.Sprintf("metric @ %.3f", float64(end+step)/1000),
+			},
+			expectedCacheStoreCalls: 2,
+		},
+		"@ start() on subquery with splitting disabled should be resolved before computing the cache key": {
+			splitEnabled: false,
+			query:        "sum_over_time(metric[1m:1m] @ start())",
+			shiftSteps:   1,
+			expectedDownstreamQueries: []string{
+				fmt.Sprintf("sum_over_time(metric[1m:1m] @ %.3f)", float64(start)/1000),
+				fmt.Sprintf("sum_over_time(metric[1m:1m] @ %.3f)", float64(start+step)/1000),
+			},
+			expectedCacheStoreCalls: 2,
+		},
+		"same request with @ start() should be served from the cache": {
+			splitEnabled: false,
+			query:        "metric @ start()",
+			shiftSteps:   0,
+			expectedDownstreamQueries: []string{
+				fmt.Sprintf("metric @ %.3f", float64(start)/1000),
+			},
+			expectedCacheStoreCalls: 1,
+		},
+		"absolute @ timestamp after the query end but before max cache freshness should be cached": {
+			splitEnabled: false,
+			query:        fmt.Sprintf("metric @ %d", (end/1000)+3600),
+			shiftSteps:   0,
+			expectedDownstreamQueries: []string{
+				fmt.Sprintf("metric @ %d", (end/1000)+3600),
+			},
+			expectedCacheStoreCalls: 1,
+		},
+		"absolute @ timestamp after max cache freshness should not be cached": {
+			splitEnabled: false,
+			query:        fmt.Sprintf("metric @ %d", time.Now().Add(time.Hour).Unix()),
+			shiftSteps:   0,
+			expectedDownstreamQueries: []string{
+				fmt.Sprintf("metric @ %d", time.Now().Add(time.Hour).Unix()),
+				fmt.Sprintf("metric @ %d", time.Now().Add(time.Hour).Unix()),
+			},
+			expectedCacheStoreCalls:      0,
+			expectedSkippedWithModifiers: 2,
+		},
+		"negative offset ending before max cache freshness should be cached": {
+			splitEnabled: false,
+			query:        "metric offset -1h",
+			shiftSteps:   0,
+			expectedDownstreamQueries: []string{
+				"metric offset -1h",
+			},
+			expectedCacheStoreCalls: 1,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cacheBackend := cache.NewInstrumentedMockCache()
+			reg := prometheus.NewPedanticRegistry()
+			mw := newSplitAndCacheMiddleware(
+				testData.splitEnabled,
+				true,
+				24*time.Hour,
+				mockLimits{maxCacheFreshness: 10 * time.Minute, resultsCacheTTL: resultsCacheTTL, resultsCacheOutOfOrderWindowTTL: resultsCacheLowerTTL},
+				newTestPrometheusCodec(),
+				cacheBackend,
+				ConstSplitter(day),
+				PrometheusResponseExtractor{},
+				resultsCacheAlwaysEnabled,
+				log.NewNopLogger(),
+				reg,
+			)
+
+			var downstreamQueries []string
+			rc := mw.Wrap(HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+				// Normalise the query to make the assertion independent of formatting.
+				expr, err := parser.ParseExpr(req.GetQuery())
+				require.NoError(t, err)
+				downstreamQueries = append(downstreamQueries, expr.String())
+
+				return &PrometheusResponse{
+					Status: "success",
+					Data: &PrometheusData{
+						ResultType: model.ValMatrix.String(),
+						Result: []SampleStream{{
+							Labels:  []mimirpb.LabelAdapter{{Name: "foo", Value: "bar"}},
+							Samples: []mimirpb.Sample{{Value: 1, TimestampMs: req.GetStart()}},
+						}},
+					},
+				}, nil
+			}))
+
+			ctx := user.InjectOrgID(context.Background(), "1")
+			firstReq := &PrometheusRangeQueryRequest{Path: "/api/v1/query_range", Start: start, End: end, Step: step, Query: testData.query}
+			_, err := rc.Do(ctx, firstReq)
+			require.NoError(t, err)
+
+			secondReq := firstReq.WithStartEnd(start+testData.shiftSteps*step, end+testData.shiftSteps*step)
+			_, err = rc.Do(ctx, secondReq)
+			require.NoError(t, err)
+
+			expectedQueries := make([]string, 0, len(testData.expectedDownstreamQueries))
+			for _, q := range testData.expectedDownstreamQueries {
+				expr, err := parser.ParseExpr(q)
+				require.NoError(t, err)
+				expectedQueries = append(expectedQueries, expr.String())
+			}
+
+			// When the second request is shifted, the resolved query changes and so does the cache key,
+			// so the response cached for the first request must not be reused.
+			assert.Equal(t, expectedQueries, downstreamQueries)
+			assert.Equal(t, testData.expectedCacheStoreCalls, cacheBackend.CountStoreCalls())
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(fmt.Sprintf(`
+				# HELP cortex_frontend_query_result_cache_skipped_total Total number of times a query was not cacheable because of a reason. This metric is tracked for each partial query when time-splitting is enabled.
+				# TYPE cortex_frontend_query_result_cache_skipped_total counter
+				cortex_frontend_query_result_cache_skipped_total{reason="has-modifiers"} %d
+				cortex_frontend_query_result_cache_skipped_total{reason="too-new"} 0
+				cortex_frontend_query_result_cache_skipped_total{reason="unaligned-time-range"} 0
+			`, testData.expectedSkippedWithModifiers)), "cortex_frontend_query_result_cache_skipped_total"))
+		})
+	}
+}
+
 func TestSplitAndCacheMiddleware_ResultsCache_ShouldNotLookupCacheIfStepIsNotAligned(t *testing.T) {
 	cacheBackend := cache.NewInstrumentedMockCache()
 	reg := prometheus.NewPedanticRegistry()
@@ -1265,6 +1422,56 @@ func TestSplitAndCacheMiddleware_StoreAndFetchCacheExtents(t *testing.T) {
 	})
 }
 
+func TestSplitAndCacheMiddleware_SplitDisabledByRequestOptions(t *testing.T) {
+	mw := newSplitAndCacheMiddleware(
+		true,
+		false,
+		24*time.Hour,
+		mockLimits{},
+		newTestPrometheusCodec(),
+		cache.NewMockCache(),
+		ConstSplitter(day),
+		PrometheusResponseExtractor{},
+		resultsCacheAlwaysEnabled,
+		log.NewNopLogger(),
+		prometheus.NewPedanticRegistry(),
+	)
+
+	for _, splitDisabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("split disabled: %t", splitDisabled), func(t *testing.T) {
+			var (
+				downstreamReqsMx sync.Mutex
+				downstreamReqs   []Request
+			)
+			handler := mw.Wrap(HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+				downstreamReqsMx.Lock()
+				defer downstreamReqsMx.Unlock()
+				downstreamReqs = append(downstreamReqs, req)
+				return newEmptyPrometheusResponse(), nil
+			}))
+
+			req := &PrometheusRangeQueryRequest{
+				Start:   0,
+				End:     (3 * day).Milliseconds(),
+				Step:    time.Minute.Milliseconds(),
+				Query:   "up",
+				Options: Options{SplitDisabled: splitDisabled},
+			}
+
+			_, err := handler.Do(user.InjectOrgID(context.Background(), "user-1"), req)
+			require.NoError(t, err)
+
+			if splitDisabled {
+				require.Len(t, downstreamReqs, 1)
+				require.Equal(t, req.GetStart(), downstreamReqs[0].GetStart())
+				require.Equal(t, req.GetEnd(), downstreamReqs[0].GetEnd())
+			} else {
+				require.Len(t, downstreamReqs, 3)
+			}
+		})
+	}
+}
+
 func TestSplitAndCacheMiddleware_WrapMultipleTimes(t *testing.T) {
 	m := newSplitAndCacheMiddleware(
 		false,
@@ -1777,6 +1984,8 @@ func Test_evaluateAtModifier(t *testing.T) {
 		{"topk(5, rate(http_requests_total[1h] @ start()))", "topk(5, rate(http_requests_total[1h] @ 1546300.800))", nil},
 		{"topk(5, rate(http_requests_total[1h] @ 0))", "topk(5, rate(http_requests_total[1h] @ 0.000))", nil},
 		{"http_requests_total[1h] @ 10.001", "http_requests_total[1h] @ 10.001", nil},
+		{"sum_over_time(rate(http_requests_total[1m])[10m:1m] @ start())", "sum_over_time(rate(http_requests_total[1m])[10m:1m] @ 1546300.800)", nil},
+		{"sum_over_time(rate(http_requests_total[1m] @ end())[10m:1m] @ start())", "sum_over_time(rate(http_requests_total[1m] @ 1646300.800)[10m:1m] @ 1546300.800)", nil},
 		{
 			`min_over_time(
 				sum by(cluster) (
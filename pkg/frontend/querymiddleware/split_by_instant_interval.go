@@ -4,6 +4,7 @@ package querymiddleware
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/go-kit/log"
@@ -115,6 +116,7 @@ func (s *splitInstantQueryByIntervalMiddleware) Do(ctx context.Context, req Requ
 	splitInterval := s.getSplitIntervalForQuery(tenantsIds, req, logger)
 	if splitInterval <= 0 {
 		level.Debug(logger).Log("msg", "query splitting is disabled for this query or tenant")
+		queryExplanationFromContext(ctx).addRewrite("split_instant_query_by_interval", "query not split because splitting is disabled for this query or tenant", "")
 		return s.next.Do(ctx, req)
 	}
 
@@ -156,6 +158,7 @@ func (s *splitInstantQueryByIntervalMiddleware) Do(ctx context.Context, req Requ
 			// If there are no split queries, the default skipped reason case is a non-splittable query
 			s.metrics.splittingSkipped.WithLabelValues(string(astmapper.SkippedReasonNonSplittable)).Inc()
 		}
+		queryExplanationFromContext(ctx).addRewrite("split_instant_query_by_interval", "query not split because it can't be split by interval", "")
 		return s.next.Do(ctx, req)
 	}
 
@@ -164,6 +167,7 @@ func (s *splitInstantQueryByIntervalMiddleware) Do(ctx context.Context, req Requ
 	// Update query stats.
 	queryStats := stats.FromContext(ctx)
 	queryStats.AddSplitQueries(uint32(mapperStats.GetSplitQueries()))
+	queryExplanationFromContext(ctx).addRewrite("split_instant_query_by_interval", fmt.Sprintf("query rewritten into %d split queries using a %s interval", mapperStats.GetSplitQueries(), splitInterval), instantSplitQuery.String())
 
 	// Update metrics.
 	s.metrics.splittingSuccesses.Inc()
@@ -9,6 +9,9 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
 )
 
 type queryStatsMiddleware struct {
@@ -43,6 +46,11 @@ func newQueryStatsMiddleware(reg prometheus.Registerer) Middleware {
 }
 
 func (s queryStatsMiddleware) Do(ctx context.Context, req Request) (Response, error) {
+	// The query stats metrics only track the executed queries.
+	if isQueryExplained(ctx) {
+		return s.next.Do(ctx, req)
+	}
+
 	if !isRequestStepAligned(req) {
 		s.nonAlignedQueries.Inc()
 	}
@@ -64,3 +72,61 @@ func (s queryStatsMiddleware) Do(ctx context.Context, req Request) (Response, er
 
 	return s.next.Do(ctx, req)
 }
+
+// responseStatsMiddleware attaches the query execution statistics to the response
+// when they've been requested by the client through the "stats" request param.
+type responseStatsMiddleware struct {
+	next Handler
+}
+
+func newResponseStatsMiddleware() Middleware {
+	return MiddlewareFunc(func(next Handler) Handler {
+		return &responseStatsMiddleware{
+			next: next,
+		}
+	})
+}
+
+func (s responseStatsMiddleware) Do(ctx context.Context, req Request) (Response, error) {
+	if req.GetOptions().Stats == "" {
+		return s.next.Do(ctx, req)
+	}
+
+	// Ensure statistics are tracked for this query, even if they're not enabled for all queries.
+	queryStats := querier_stats.FromContext(ctx)
+	if queryStats == nil {
+		queryStats, ctx = querier_stats.ContextWithEmptyStats(ctx)
+	}
+
+	resp, err := s.next.Do(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	if promResp, ok := resp.(*PrometheusResponse); ok && promResp.Data != nil {
+		promResp.Data.Stats = &mimirpb.QueryStats{
+			WallTimeSeconds:      queryStats.LoadWallTime().Seconds(),
+			QueueTimeSeconds:     queryStats.LoadQueueTime().Seconds(),
+			FetchedSeriesCount:   queryStats.LoadFetchedSeries(),
+			FetchedChunksCount:   queryStats.LoadFetchedChunks(),
+			FetchedChunkBytes:    queryStats.LoadFetchedChunkBytes(),
+			FetchedIndexBytes:    queryStats.LoadFetchedIndexBytes(),
+			SamplesProcessed:     queryStats.LoadSamplesProcessed(),
+			ShardedQueries:       queryStats.LoadShardedQueries(),
+			SplitQueries:         queryStats.LoadSplitQueries(),
+			ResultsCacheHitRatio: queryStats.LoadResultsCacheHitRatio(),
+		}
+
+		if req.GetOptions().PerStepStatsEnabled {
+			// The steps served from the results cache haven't been processed by the queriers, so they're missing.
+			for _, step := range queryStats.LoadSamplesProcessedPerStep() {
+				promResp.Data.Stats.SamplesProcessedPerStep = append(promResp.Data.Stats.SamplesProcessedPerStep, mimirpb.QueryStepStat{
+					TimestampMs: step.TimestampMs,
+					Value:       step.Value,
+				})
+			}
+		}
+	}
+
+	return resp, nil
+}
@@ -6,13 +6,17 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/grafana/dskit/user"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/mimir/pkg/mimirpb"
+	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
 	"github.com/grafana/mimir/pkg/util"
 )
 
@@ -82,3 +86,68 @@ func Test_queryStatsMiddleware_Do(t *testing.T) {
 		})
 	}
 }
+
+func Test_responseStatsMiddleware_Do(t *testing.T) {
+	downstream := HandlerFunc(func(ctx context.Context, _ Request) (Response, error) {
+		queryStats := querier_stats.FromContext(ctx)
+		queryStats.AddWallTime(2 * time.Second)
+		queryStats.AddQueueTime(time.Second)
+		queryStats.AddFetchedSeries(10)
+		queryStats.AddSamplesProcessed(100)
+		queryStats.AddSplitQueries(3)
+		queryStats.AddResultsCacheLookups(4)
+		queryStats.AddResultsCacheHits(1)
+		queryStats.AddSamplesProcessedPerStep([]querier_stats.StepStat{{TimestampMs: 1000, Value: 40}, {TimestampMs: 2000, Value: 60}})
+
+		return &PrometheusResponse{Status: statusSuccess, Data: &PrometheusData{ResultType: model.ValMatrix.String()}}, nil
+	})
+
+	tests := map[string]struct {
+		stats         string
+		perStepStats  bool
+		expectedStats *mimirpb.QueryStats
+	}{
+		"should not attach stats to the response if not requested": {
+			stats:         "",
+			expectedStats: nil,
+		},
+		"should attach stats to the response if requested": {
+			stats: "all",
+			expectedStats: &mimirpb.QueryStats{
+				WallTimeSeconds:      2,
+				QueueTimeSeconds:     1,
+				FetchedSeriesCount:   10,
+				SamplesProcessed:     100,
+				SplitQueries:         3,
+				ResultsCacheHitRatio: 0.25,
+			},
+		},
+		"should attach per-step stats to the response if requested": {
+			stats:        "all",
+			perStepStats: true,
+			expectedStats: &mimirpb.QueryStats{
+				WallTimeSeconds:         2,
+				QueueTimeSeconds:        1,
+				FetchedSeriesCount:      10,
+				SamplesProcessed:        100,
+				SplitQueries:            3,
+				ResultsCacheHitRatio:    0.25,
+				SamplesProcessedPerStep: []mimirpb.QueryStepStat{{TimestampMs: 1000, Value: 40}, {TimestampMs: 2000, Value: 60}},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := &PrometheusRangeQueryRequest{
+				Path:    "/query_range",
+				Query:   "up",
+				Options: Options{Stats: tc.stats, PerStepStatsEnabled: tc.perStepStats},
+			}
+
+			resp, err := newResponseStatsMiddleware().Wrap(downstream).Do(user.InjectOrgID(context.Background(), "test"), req)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStats, resp.(*PrometheusResponse).Data.Stats)
+		})
+	}
+}
@@ -7,6 +7,9 @@ package querymiddleware
 
 import (
 	"context"
+	"fmt"
+
+	"github.com/grafana/mimir/pkg/util"
 )
 
 // newStepAlignMiddleware creates a middleware that aligns the start and end of request to the step to
@@ -16,6 +19,9 @@ func newStepAlignMiddleware() Middleware {
 		return HandlerFunc(func(ctx context.Context, r Request) (Response, error) {
 			start := (r.GetStart() / r.GetStep()) * r.GetStep()
 			end := (r.GetEnd() / r.GetStep()) * r.GetStep()
+			if start != r.GetStart() || end != r.GetEnd() {
+				queryExplanationFromContext(ctx).addRewrite("step_align", fmt.Sprintf("start and end aligned to the step, the time range is now %s to %s", util.FormatTimeMillis(start), util.FormatTimeMillis(end)), "")
+			}
 			return next.Do(ctx, r.WithStartEnd(start, end))
 		})
 	})
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+)
+
+// tenantFederationLabel is the label added by the queriers to the series of a query federated across multiple tenants,
+// to identify the tenant each series belongs to.
+const tenantFederationLabel = "__tenant_id__"
+
+// tenantFederationPushdownMiddleware narrows down the tenants of a query federated across multiple tenants to the
+// ones that can match the query, based on its matchers on the tenantFederationLabel. Downstream middlewares then
+// apply the limits of the selected tenants only, and the queriers don't query the tenants that can't match.
+type tenantFederationPushdownMiddleware struct {
+	next   Handler
+	logger log.Logger
+}
+
+func newTenantFederationPushdownMiddleware(logger log.Logger) Middleware {
+	return MiddlewareFunc(func(next Handler) Handler {
+		return &tenantFederationPushdownMiddleware{
+			next:   next,
+			logger: logger,
+		}
+	})
+}
+
+func (t *tenantFederationPushdownMiddleware) Do(ctx context.Context, r Request) (Response, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil || len(tenantIDs) <= 2 {
+		// The tenants are only narrowed down if at least two of them are left, because the queriers don't add the
+		// tenantFederationLabel to the results of a query for a single tenant. So there's nothing to do here.
+		return t.next.Do(ctx, r)
+	}
+
+	expr, err := parser.ParseExpr(r.GetQuery())
+	if err != nil {
+		// Let the downstream middlewares handle the error.
+		return t.next.Do(ctx, r)
+	}
+
+	selected := selectedTenantIDs(expr, tenantIDs)
+	if len(selected) < 2 || len(selected) == len(tenantIDs) {
+		return t.next.Do(ctx, r)
+	}
+
+	spanLog := spanlogger.FromContext(ctx, t.logger)
+	level.Debug(spanLog).Log("msg", "narrowed down the tenants of the federated query", "original", tenant.JoinTenantIDs(tenantIDs), "selected", tenant.JoinTenantIDs(selected))
+	queryExplanationFromContext(ctx).addRewrite("tenant_federation", fmt.Sprintf("tenants narrowed down to %s because of the %s matchers", tenant.JoinTenantIDs(selected), tenantFederationLabel), "")
+
+	return t.next.Do(user.InjectOrgID(ctx, tenant.JoinTenantIDs(selected)), r)
+}
+
+// selectedTenantIDs returns the tenants, out of the input ones, which can match any of the vector selectors of expr.
+// The returned tenants keep the input order.
+func selectedTenantIDs(expr parser.Expr, tenantIDs []string) []string {
+	selected := make(map[string]struct{}, len(tenantIDs))
+	hasSelectors := false
+
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		vs, ok := node.(*parser.VectorSelector)
+		if !ok {
+			return nil
+		}
+		hasSelectors = true
+
+	tenants:
+		for _, id := range tenantIDs {
+			for _, m := range vs.LabelMatchers {
+				if m.Name == tenantFederationLabel && !m.Matches(id) {
+					continue tenants
+				}
+			}
+			selected[id] = struct{}{}
+		}
+		return nil
+	})
+
+	// A query without vector selectors doesn't select any data, so we keep all the tenants.
+	if !hasSelectors {
+		return tenantIDs
+	}
+
+	out := make([]string, 0, len(selected))
+	for _, id := range tenantIDs {
+		if _, ok := selected[id]; ok {
+			out = append(out, id)
+		}
+	}
+	return out
+}
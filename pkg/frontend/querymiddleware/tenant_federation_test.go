@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querymiddleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/tenant"
+	"github.com/grafana/dskit/user"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantFederationPushdownMiddleware(t *testing.T) {
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	t.Cleanup(func() { tenant.WithDefaultResolver(tenant.NewSingleResolver()) })
+
+	tests := map[string]struct {
+		orgID         string
+		query         string
+		expectedOrgID string
+	}{
+		"single tenant": {
+			orgID:         "team-a",
+			query:         `up{__tenant_id__="team-a"}`,
+			expectedOrgID: "team-a",
+		},
+		"query without tenant matchers": {
+			orgID:         "team-a|team-b|team-c",
+			query:         `sum(rate(http_requests_total[5m]))`,
+			expectedOrgID: "team-a|team-b|team-c",
+		},
+		"query without vector selectors": {
+			orgID:         "team-a|team-b|team-c",
+			query:         `vector(1)`,
+			expectedOrgID: "team-a|team-b|team-c",
+		},
+		"query selecting a subset of the tenants": {
+			orgID:         "team-a|team-b|team-c",
+			query:         `sum by (__tenant_id__) (rate(http_requests_total{__tenant_id__=~"team-(a|c)"}[5m]))`,
+			expectedOrgID: "team-a|team-c",
+		},
+		"query excluding a tenant": {
+			orgID:         "team-a|team-b|team-c",
+			query:         `up{__tenant_id__!="team-b"}`,
+			expectedOrgID: "team-a|team-c",
+		},
+		"query selecting different tenants in each selector": {
+			orgID:         "team-a|team-b|team-c|team-d",
+			query:         `up{__tenant_id__="team-a"} or up{__tenant_id__="team-d"}`,
+			expectedOrgID: "team-a|team-d",
+		},
+		"query with a selector not matching on tenants": {
+			orgID:         "team-a|team-b|team-c",
+			query:         `up{__tenant_id__="team-a"} or up`,
+			expectedOrgID: "team-a|team-b|team-c",
+		},
+		"query selecting a single tenant": {
+			orgID:         "team-a|team-b|team-c",
+			query:         `up{__tenant_id__="team-b"}`,
+			expectedOrgID: "team-a|team-b|team-c",
+		},
+		"invalid query": {
+			orgID:         "team-a|team-b|team-c",
+			query:         `up{__tenant_id__="team-b"`,
+			expectedOrgID: "team-a|team-b|team-c",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var actualOrgID string
+			next := HandlerFunc(func(ctx context.Context, _ Request) (Response, error) {
+				tenantIDs, err := tenant.TenantIDs(ctx)
+				require.NoError(t, err)
+				actualOrgID = tenant.JoinTenantIDs(tenantIDs)
+				return &PrometheusResponse{Status: statusSuccess}, nil
+			})
+
+			mw := newTenantFederationPushdownMiddleware(log.NewNopLogger()).Wrap(next)
+			_, err := mw.Do(user.InjectOrgID(context.Background(), tc.orgID), &PrometheusInstantQueryRequest{Query: tc.query})
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedOrgID, actualOrgID)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package siblings tracks the execution of sibling requests, which are expected to take a similar time to execute
+// (eg. the sharded queries of the same query), so that the query-frontend can hedge the stragglers.
+package siblings
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type contextKey int
+
+const requestsKey contextKey = 0
+
+// ContextWithRequests returns a new context tracking the requests run through the frontend with it, or any
+// context derived from it, as siblings. Siblings are expected to take a similar time to execute (eg. the sharded
+// queries of the same query), so that a sibling taking much longer than the others can be hedged.
+func ContextWithRequests(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestsKey, &Requests{changed: make(chan struct{})})
+}
+
+// FromContext returns the sibling requests tracked by the context, or nil if the context doesn't track any.
+func FromContext(ctx context.Context) *Requests {
+	s, _ := ctx.Value(requestsKey).(*Requests)
+	return s
+}
+
+// Requests keeps track of the execution time of sibling requests, to detect straggling ones.
+type Requests struct {
+	mu sync.Mutex
+
+	started int
+	hedged  int
+
+	// Durations of completed requests.
+	durations []time.Duration
+
+	// Closed and replaced each time a request completes.
+	changed chan struct{}
+}
+
+// Start records a sibling request has started.
+func (s *Requests) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.started++
+}
+
+// Complete records the duration of a completed request, and notifies the requests waiting for a straggler deadline.
+func (s *Requests) Complete(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.durations = append(s.durations, d)
+
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// StragglerDeadline returns the time after which a request started at the input time is considered straggling:
+// multiplier times the median duration of completed siblings, but not earlier than minDelay. The returned bool is
+// false if the deadline can't be computed yet, because less than half of the siblings have completed. The returned
+// channel is closed when a sibling completes, and so the deadline should be computed again.
+func (s *Requests) StragglerDeadline(start time.Time, multiplier float64, minDelay time.Duration) (<-chan struct{}, time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.durations) == 0 || len(s.durations)*2 < s.started {
+		return s.changed, time.Time{}, false
+	}
+
+	sorted := make([]time.Duration, len(s.durations))
+	copy(sorted, s.durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	median := sorted[len(sorted)/2]
+
+	delay := time.Duration(float64(median) * multiplier)
+	if delay < minDelay {
+		delay = minDelay
+	}
+
+	return s.changed, start.Add(delay), true
+}
+
+// TryHedge returns true, and takes a slot from the hedge budget, if less than maxHedged siblings have been hedged.
+func (s *Requests) TryHedge(maxHedged int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hedged >= maxHedged {
+		return false
+	}
+	s.hedged++
+	return true
+}
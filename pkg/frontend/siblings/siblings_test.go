@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package siblings
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequests_StragglerDeadline(t *testing.T) {
+	siblings := FromContext(ContextWithRequests(context.Background()))
+	require.NotNil(t, siblings)
+
+	for i := 0; i < 4; i++ {
+		siblings.Start()
+	}
+	start := time.Now()
+
+	// The deadline is unknown until at least half of the siblings have completed.
+	changed, _, ok := siblings.StragglerDeadline(start, 3, 0)
+	assert.False(t, ok)
+
+	siblings.Complete(time.Second)
+	select {
+	case <-changed:
+	default:
+		require.Fail(t, "expected the channel to be closed when a sibling completes")
+	}
+
+	_, _, ok = siblings.StragglerDeadline(start, 3, 0)
+	assert.False(t, ok)
+
+	siblings.Complete(3 * time.Second)
+	_, deadline, ok := siblings.StragglerDeadline(start, 3, 0)
+	require.True(t, ok)
+	assert.Equal(t, start.Add(9*time.Second), deadline)
+
+	// The deadline is never earlier than the min delay.
+	_, deadline, ok = siblings.StragglerDeadline(start, 3, time.Minute)
+	require.True(t, ok)
+	assert.Equal(t, start.Add(time.Minute), deadline)
+}
+
+func TestRequests_TryHedge(t *testing.T) {
+	siblings := FromContext(ContextWithRequests(context.Background()))
+
+	assert.True(t, siblings.TryHedge(2))
+	assert.True(t, siblings.TryHedge(2))
+	assert.False(t, siblings.TryHedge(2))
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Nil(t, FromContext(context.Background()))
+}
@@ -10,6 +10,8 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -32,6 +34,7 @@ import (
 	"github.com/grafana/mimir/pkg/util"
 	"github.com/grafana/mimir/pkg/util/activitytracker"
 	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
 const (
@@ -52,6 +55,7 @@ type HandlerConfig struct {
 	LogQueryRequestHeaders flagext.StringSliceCSV `yaml:"log_query_request_headers" category:"advanced"`
 	MaxBodySize            int64                  `yaml:"max_body_size" category:"advanced"`
 	QueryStatsEnabled      bool                   `yaml:"query_stats_enabled" category:"advanced"`
+	QueryLog               QueryLogConfig         `yaml:"query_log"`
 }
 
 func (cfg *HandlerConfig) RegisterFlags(f *flag.FlagSet) {
@@ -59,6 +63,21 @@ func (cfg *HandlerConfig) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&cfg.LogQueryRequestHeaders, "query-frontend.log-query-request-headers", "Comma-separated list of request header names to include in query logs. Applies to both query stats and slow queries logs.")
 	f.Int64Var(&cfg.MaxBodySize, "query-frontend.max-body-size", 10*1024*1024, "Max body size for downstream prometheus.")
 	f.BoolVar(&cfg.QueryStatsEnabled, "query-frontend.query-stats-enabled", true, "False to disable query statistics tracking. When enabled, a message with some statistics is logged for every query.")
+	cfg.QueryLog.RegisterFlags(f)
+}
+
+func (cfg *HandlerConfig) Validate() error {
+	return errors.Wrap(cfg.QueryLog.Validate(), "invalid query-frontend query log config")
+}
+
+// Limits allows us to specify per-tenant runtime limits on the behavior of the Handler.
+type Limits interface {
+	// QueryHintsEnabled returns whether the tenant is allowed to override the query-frontend behaviour
+	// on a per-request basis via query hints.
+	QueryHintsEnabled(userID string) bool
+
+	// QueryLogSampleRate returns the fraction of the tenant's queries written to the query log.
+	QueryLogSampleRate(userID string) float64
 }
 
 // Handler accepts queries and forwards them to RoundTripper. It can wait on in-flight requests and log slow queries,
@@ -67,8 +86,12 @@ type Handler struct {
 	cfg          HandlerConfig
 	log          log.Logger
 	roundTripper http.RoundTripper
+	limits       Limits
 	at           *activitytracker.ActivityTracker
 
+	// queryLog is nil if the query log is disabled.
+	queryLog *queryLogWriter
+
 	// Metrics.
 	querySeconds    *prometheus.CounterVec
 	querySeries     *prometheus.CounterVec
@@ -84,15 +107,24 @@ type Handler struct {
 }
 
 // NewHandler creates a new frontend handler.
-func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logger, reg prometheus.Registerer, at *activitytracker.ActivityTracker) *Handler {
+func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, limits Limits, log log.Logger, reg prometheus.Registerer, at *activitytracker.ActivityTracker) (*Handler, error) {
 	h := &Handler{
 		cfg:          cfg,
 		log:          log,
 		roundTripper: roundTripper,
+		limits:       limits,
 		at:           at,
 	}
 	h.cond = sync.NewCond(&h.mtx)
 
+	if cfg.QueryLog.FilePath != "" {
+		bkt, err := newQueryLogBucketClient(cfg.QueryLog, log, reg)
+		if err != nil {
+			return nil, errors.Wrap(err, "create query log bucket client")
+		}
+		h.queryLog = newQueryLogWriter(cfg.QueryLog, bkt, log, reg)
+	}
+
 	if cfg.QueryStatsEnabled {
 		h.querySeconds = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_query_seconds_total",
@@ -131,7 +163,7 @@ func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logge
 		_ = h.activeUsers.StartAsync(context.Background())
 	}
 
-	return h
+	return h, nil
 }
 
 // Stop makes f enter stopped mode and wait on in-flight requests.
@@ -145,6 +177,12 @@ func (f *Handler) Stop() {
 	}
 	f.mtx.Unlock()
 	level.Info(f.log).Log("msg", "done waiting on in-flight requests")
+
+	if f.queryLog != nil {
+		if err := f.queryLog.close(); err != nil {
+			level.Warn(f.log).Log("msg", "failed to close the query log", "err", err)
+		}
+	}
 }
 
 func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -164,16 +202,6 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		f.mtx.Unlock()
 	}()
 
-	var stats *querier_stats.Stats
-
-	// Initialise the stats in the context and make sure it's propagated
-	// down the request chain.
-	if f.cfg.QueryStatsEnabled {
-		var ctx context.Context
-		stats, ctx = querier_stats.ContextWithEmptyStats(r.Context())
-		r = r.WithContext(ctx)
-	}
-
 	// Ensure to close the request body reader.
 	defer func() { _ = r.Body.Close() }()
 
@@ -186,6 +214,23 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	hints, err := f.applyQueryHints(r, params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var stats *querier_stats.Stats
+
+	// Initialise the stats in the context and make sure it's propagated
+	// down the request chain. Stats are also tracked when the query log is enabled.
+	statsEnabled := f.cfg.QueryStatsEnabled
+	if statsEnabled || f.queryLog != nil {
+		var ctx context.Context
+		stats, ctx = querier_stats.ContextWithEmptyStats(r.Context())
+		r = r.WithContext(ctx)
+	}
+
 	activityIndex := f.at.Insert(func() string { return httpRequestActivity(r, params) })
 	defer f.at.Delete(activityIndex)
 
@@ -194,30 +239,79 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	queryResponseTime := time.Since(startTime)
 
 	if err != nil {
-		writeError(w, err)
-		f.reportQueryStats(r, params, queryResponseTime, 0, stats, err)
+		statusCode := writeError(w, err)
+		f.reportQueryStats(r, params, hints, queryResponseTime, 0, stats, err)
+		f.writeQueryLog(r, params, statusCode, queryResponseTime, 0, stats, err)
 		return
 	}
 
+	defer func() { _ = resp.Body.Close() }()
+
 	hs := w.Header()
 	for h, vs := range resp.Header {
 		hs[h] = vs
 	}
 
-	if f.cfg.QueryStatsEnabled {
+	if statsEnabled {
 		writeServiceTimingHeader(queryResponseTime, hs, stats)
 	}
 
 	w.WriteHeader(resp.StatusCode)
-	// we don't check for copy error as there is no much we can do at this point
-	queryResponseSize, _ := io.Copy(w, resp.Body)
+	queryResponseSize, copyErr := io.Copy(newResponseWriter(w, resp), resp.Body)
 
 	if f.cfg.LogQueriesLongerThan > 0 && queryResponseTime > f.cfg.LogQueriesLongerThan {
 		f.reportSlowQuery(r, params, queryResponseTime)
 	}
-	if f.cfg.QueryStatsEnabled {
-		f.reportQueryStats(r, params, queryResponseTime, queryResponseSize, stats, nil)
+	if statsEnabled {
+		f.reportQueryStats(r, params, hints, queryResponseTime, queryResponseSize, stats, nil)
+	}
+	f.writeQueryLog(r, params, resp.StatusCode, queryResponseTime, queryResponseSize, stats, copyErr)
+
+	if copyErr != nil {
+		// The status code has already been sent, so the only way to let the client know the response
+		// is incomplete (eg. a streamed response failed midway) is aborting it, instead of ending it cleanly.
+		level.Warn(util_log.WithContext(r.Context(), f.log)).Log("msg", "failed to write the response body, aborting the response", "path", r.URL.Path, "err", copyErr)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// applyQueryHints parses the query hints from the request, if any, and translates them into the control headers
+// read by the query-frontend middlewares. This is the only place where the tenant is checked to be allowed to use
+// query hints: query hints are rejected, and the gated control headers set directly by the client are removed, if
+// the tenant isn't allowed to. Returns nil if the request has no query hints.
+func (f *Handler) applyQueryHints(r *http.Request, params url.Values) (*queryHints, error) {
+	hints, err := parseQueryHints(r, params)
+	if err != nil {
+		return nil, apierror.New(apierror.TypeBadData, err.Error())
+	}
+	if hints == nil && !hasGatedControlHeaders(r) {
+		return nil, nil
+	}
+
+	if !f.queryHintsEnabled(r) {
+		if hints != nil {
+			return nil, apierror.New(apierror.TypeBadData, "query hints are not enabled for the tenant")
+		}
+		removeGatedControlHeaders(r)
+		return nil, nil
+	}
+
+	if hints != nil {
+		hints.apply(r)
 	}
+	return hints, nil
+}
+
+// queryHintsEnabled returns whether all the tenants of the request are allowed to use query hints.
+func (f *Handler) queryHintsEnabled(r *http.Request) bool {
+	if f.limits == nil {
+		return false
+	}
+	tenantIDs, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		return false
+	}
+	return validation.AllTrueBooleansPerTenant(tenantIDs, f.limits.QueryHintsEnabled)
 }
 
 // reportSlowQuery reports slow queries.
@@ -237,7 +331,7 @@ func (f *Handler) reportSlowQuery(r *http.Request, queryString url.Values, query
 	level.Info(util_log.WithContext(r.Context(), f.log)).Log(logMessage...)
 }
 
-func (f *Handler) reportQueryStats(r *http.Request, queryString url.Values, queryResponseTime time.Duration, queryResponseSizeBytes int64, stats *querier_stats.Stats, queryErr error) {
+func (f *Handler) reportQueryStats(r *http.Request, queryString url.Values, hints *queryHints, queryResponseTime time.Duration, queryResponseSizeBytes int64, stats *querier_stats.Stats, queryErr error) {
 	tenantIDs, err := tenant.TenantIDs(r.Context())
 	if err != nil {
 		return
@@ -250,7 +344,9 @@ func (f *Handler) reportQueryStats(r *http.Request, queryString url.Values, quer
 	numIndexBytes := stats.LoadFetchedIndexBytes()
 	sharded := strconv.FormatBool(stats.GetShardedQueries() > 0)
 
-	if stats != nil {
+	// Metrics are only registered when query stats are enabled, while stats could have been
+	// tracked for the query log only.
+	if stats != nil && f.cfg.QueryStatsEnabled {
 		// Track stats.
 		f.querySeconds.WithLabelValues(userID, sharded).Add(wallTime.Seconds())
 		f.querySeries.WithLabelValues(userID).Add(float64(numSeries))
@@ -277,31 +373,74 @@ func (f *Handler) reportQueryStats(r *http.Request, queryString url.Values, quer
 		"sharded_queries", stats.LoadShardedQueries(),
 		"split_queries", stats.LoadSplitQueries(),
 		"estimated_series_count", stats.GetEstimatedSeriesCount(),
+		"samples_processed", stats.LoadSamplesProcessed(),
+		"queue_time_seconds", stats.LoadQueueTime().Seconds(),
+		"results_cache_hit_ratio", stats.LoadResultsCacheHitRatio(),
 	}, formatQueryString(queryString)...)
 
 	if len(f.cfg.LogQueryRequestHeaders) != 0 {
 		logMessage = append(logMessage, formatRequestHeaders(&r.Header, f.cfg.LogQueryRequestHeaders)...)
 	}
 
-	if queryErr != nil {
-		logStatus := "failed"
-		if errors.Is(queryErr, context.Canceled) {
-			logStatus = "canceled"
-		} else if errors.Is(queryErr, context.DeadlineExceeded) {
-			logStatus = "timeout"
+	if hints != nil {
+		logMessage = append(logMessage, "query_hints", hints.raw)
+		if hints.perStepStats {
+			logMessage = append(logMessage, "per_step_stats_enabled", true)
 		}
+	}
 
+	if queryErr != nil {
 		logMessage = append(logMessage,
-			"status", logStatus,
+			"status", queryStatus(queryErr),
 			"err", queryErr)
 	} else {
 		logMessage = append(logMessage,
-			"status", "success")
+			"status", queryStatus(nil))
 	}
 
 	level.Info(util_log.WithContext(r.Context(), f.log)).Log(logMessage...)
 }
 
+// writeQueryLog writes the query to the query log, if enabled and if the query is sampled according to the
+// sample rate of its tenants.
+func (f *Handler) writeQueryLog(r *http.Request, params url.Values, statusCode int, queryResponseTime time.Duration, queryResponseSizeBytes int64, stats *querier_stats.Stats, queryErr error) {
+	if f.queryLog == nil {
+		return
+	}
+
+	tenantIDs, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		return
+	}
+
+	// A query federated across multiple tenants is logged with the highest sample rate of its tenants.
+	sampleRate := 1.0
+	if f.limits != nil {
+		sampleRate = 0
+		for _, tenantID := range tenantIDs {
+			sampleRate = math.Max(sampleRate, f.limits.QueryLogSampleRate(tenantID))
+		}
+	}
+	if sampleRate <= 0 || (sampleRate < 1 && rand.Float64() >= sampleRate) {
+		return
+	}
+
+	f.queryLog.write(newQueryLogEntry(r, params, tenant.JoinTenantIDs(tenantIDs), queryStatus(queryErr), statusCode, queryErr, queryResponseTime, queryResponseSizeBytes, stats, sampleRate))
+}
+
+func queryStatus(queryErr error) string {
+	switch {
+	case queryErr == nil:
+		return "success"
+	case errors.Is(queryErr, context.Canceled):
+		return "canceled"
+	case errors.Is(queryErr, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "failed"
+	}
+}
+
 func formatQueryString(queryString url.Values) (fields []interface{}) {
 	for k, v := range queryString {
 		fields = append(fields, fmt.Sprintf("param_%s", k), strings.Join(v, ","))
@@ -318,7 +457,8 @@ func formatRequestHeaders(h *http.Header, headersToLog []string) (fields []inter
 	return fields
 }
 
-func writeError(w http.ResponseWriter, err error) {
+// writeError writes the error to the response, and returns the status code of the response.
+func writeError(w http.ResponseWriter, err error) int {
 	switch {
 	case errors.Is(err, context.Canceled):
 		err = errCanceled
@@ -333,10 +473,37 @@ func writeError(w http.ResponseWriter, err error) {
 	// if the error is an APIError, ensure it gets written as a JSON response
 	if resp, ok := apierror.HTTPResponseFromError(err); ok {
 		_ = server.WriteResponse(w, resp)
-		return
+		return int(resp.Code)
+	}
+
+	if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+		_ = server.WriteResponse(w, resp)
+		return int(resp.Code)
 	}
 
-	server.WriteError(w, err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+	return http.StatusInternalServerError
+}
+
+// newResponseWriter returns the writer to copy the response body to. Streamed responses are flushed
+// to the client after each write, so that they're not buffered.
+func newResponseWriter(w http.ResponseWriter, resp *http.Response) io.Writer {
+	f, ok := w.(http.Flusher)
+	if !ok || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/x-streamed-protobuf") {
+		return w
+	}
+	return flushWriter{w: w, f: f}
+}
+
+type flushWriter struct {
+	w io.Writer
+	f http.Flusher
+}
+
+func (fw flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	fw.f.Flush()
+	return n, err
 }
 
 func writeServiceTimingHeader(queryResponseTime time.Duration, headers http.Header, stats *querier_stats.Stats) {
@@ -31,6 +31,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
 
+	"github.com/grafana/mimir/pkg/frontend/querymiddleware"
 	"github.com/grafana/mimir/pkg/util/activitytracker"
 )
 
@@ -52,8 +53,9 @@ func TestWriteError(t *testing.T) {
 	} {
 		t.Run(test.err.Error(), func(t *testing.T) {
 			w := httptest.NewRecorder()
-			writeError(w, test.err)
+			statusCode := writeError(w, test.err)
 			require.Equal(t, test.status, w.Result().StatusCode)
+			require.Equal(t, test.status, statusCode)
 		})
 	}
 }
@@ -149,7 +151,8 @@ func TestHandler_ServeHTTP(t *testing.T) {
 			t.Cleanup(func() { require.NoError(t, at.Close()) })
 
 			logger := &testLogger{}
-			handler := NewHandler(tt.cfg, roundTripper, logger, reg, at)
+			handler, err := NewHandler(tt.cfg, roundTripper, nil, logger, reg, at)
+			require.NoError(t, err)
 
 			req := tt.request().WithContext(user.InjectOrgID(context.Background(), "12345"))
 			resp := httptest.NewRecorder()
@@ -178,7 +181,7 @@ func TestHandler_ServeHTTP(t *testing.T) {
 				require.Len(t, logger.logMessages, 1)
 
 				msg := logger.logMessages[0]
-				require.Len(t, msg, 18+len(tt.expectedParams))
+				require.Len(t, msg, 21+len(tt.expectedParams))
 				require.Equal(t, level.InfoValue(), msg["level"])
 				require.Equal(t, "query stats", msg["msg"])
 				require.Equal(t, "query-frontend", msg["component"])
@@ -197,6 +200,9 @@ func TestHandler_ServeHTTP(t *testing.T) {
 				require.EqualValues(t, 0, msg["sharded_queries"])
 				require.EqualValues(t, 0, msg["split_queries"])
 				require.EqualValues(t, 0, msg["estimated_series_count"])
+				require.EqualValues(t, 0, msg["samples_processed"])
+				require.EqualValues(t, 0, msg["queue_time_seconds"])
+				require.EqualValues(t, 0, msg["results_cache_hit_ratio"])
 
 				for name, values := range tt.expectedParams {
 					logMessageKey := fmt.Sprintf("param_%v", name)
@@ -244,7 +250,8 @@ func TestHandler_FailedRoundTrip(t *testing.T) {
 			reg := prometheus.NewPedanticRegistry()
 			logs := &concurrency.SyncBuffer{}
 			logger := log.NewLogfmtLogger(logs)
-			handler := NewHandler(test.cfg, roundTripper, logger, reg, nil)
+			handler, err := NewHandler(test.cfg, roundTripper, nil, logger, reg, nil)
+			require.NoError(t, err)
 
 			ctx := user.InjectOrgID(context.Background(), "12345")
 			req := httptest.NewRequest("GET", test.path, nil)
@@ -275,6 +282,291 @@ func TestHandler_FailedRoundTrip(t *testing.T) {
 	}
 }
 
+func TestHandler_ShouldAbortTheResponseIfTheBodyFailsAfterTheStatusCodeIsSent(t *testing.T) {
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		pr, pw := io.Pipe()
+		go func() {
+			_, _ = pw.Write([]byte("partial"))
+			_ = pw.CloseWithError(errors.New("series limit reached"))
+		}()
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/x-streamed-protobuf; proto=prometheus.ChunkedReadResponse"}},
+			Body:       pr,
+		}, nil
+	})
+
+	handler, err := NewHandler(HandlerConfig{}, roundTripper, nil, log.NewNopLogger(), nil, nil)
+	require.NoError(t, err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(user.InjectOrgID(r.Context(), "12345")))
+	}))
+	t.Cleanup(server.Close)
+
+	resp, err := http.Get(server.URL + "/api/v1/read")
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The client gets an error instead of a truncated response.
+	_, err = io.ReadAll(resp.Body)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestHandler_QueryHints(t *testing.T) {
+	for _, tt := range []struct {
+		name               string
+		cfg                HandlerConfig
+		limits             Limits
+		request            func() *http.Request
+		expectedStatusCode int
+		expectedHeaders    http.Header
+		expectedStatsLog   bool
+		expectedMetrics    int
+	}{
+		{
+			name:   "no query hints",
+			cfg:    HandlerConfig{QueryStatsEnabled: true},
+			limits: mockLimits{queryHintsEnabled: false},
+			request: func() *http.Request {
+				return httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders:    http.Header{},
+			expectedStatsLog:   true,
+			expectedMetrics:    5,
+		},
+		{
+			name:   "query hints not enabled for the tenant",
+			cfg:    HandlerConfig{QueryStatsEnabled: true},
+			limits: mockLimits{queryHintsEnabled: false},
+			request: func() *http.Request {
+				r := httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+				r.Header.Set(queryHintsHeader, "no-cache")
+				return r
+			},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "query hints without limits",
+			cfg:  HandlerConfig{QueryStatsEnabled: true},
+			request: func() *http.Request {
+				return httptest.NewRequest("GET", "/api/v1/query?query=up&query_hints=no-cache", nil)
+			},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid query hint",
+			cfg:    HandlerConfig{QueryStatsEnabled: true},
+			limits: mockLimits{queryHintsEnabled: true},
+			request: func() *http.Request {
+				r := httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+				r.Header.Set(queryHintsHeader, "no-cache, unknown")
+				return r
+			},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid number of shards",
+			cfg:    HandlerConfig{QueryStatsEnabled: true},
+			limits: mockLimits{queryHintsEnabled: true},
+			request: func() *http.Request {
+				r := httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+				r.Header.Set(queryHintsHeader, "shards=-1")
+				return r
+			},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:   "query hints via header",
+			cfg:    HandlerConfig{QueryStatsEnabled: true},
+			limits: mockLimits{queryHintsEnabled: true},
+			request: func() *http.Request {
+				r := httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+				r.Header.Set(queryHintsHeader, "no-cache, no-split, shards=8")
+				return r
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: http.Header{
+				querymiddleware.CacheControlHeader:        []string{querymiddleware.NoStoreValue},
+				querymiddleware.SplitControlHeader:        []string{"0"},
+				querymiddleware.InstantSplitControlHeader: []string{"0"},
+				querymiddleware.TotalShardsControlHeader:  []string{"8"},
+			},
+			expectedStatsLog: true,
+			expectedMetrics:  5,
+		},
+		{
+			name:   "query hints via request param disabling sharding",
+			cfg:    HandlerConfig{QueryStatsEnabled: true},
+			limits: mockLimits{queryHintsEnabled: true},
+			request: func() *http.Request {
+				return httptest.NewRequest("GET", "/api/v1/query?query=up&query_hints=shards%3D0", nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: http.Header{
+				querymiddleware.TotalShardsControlHeader: []string{"0"},
+			},
+			expectedStatsLog: true,
+			expectedMetrics:  5,
+		},
+		{
+			name:   "control headers set directly while query hints are not enabled for the tenant",
+			cfg:    HandlerConfig{QueryStatsEnabled: true},
+			limits: mockLimits{queryHintsEnabled: false},
+			request: func() *http.Request {
+				r := httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+				r.Header.Set(querymiddleware.CacheControlHeader, querymiddleware.NoStoreValue)
+				r.Header.Set(querymiddleware.SplitControlHeader, "0")
+				r.Header.Set(querymiddleware.InstantSplitControlHeader, "0")
+				r.Header.Set(querymiddleware.TotalShardsControlHeader, "8")
+				r.Header.Set(querymiddleware.StatsControlHeader, querymiddleware.PerStepStatsValue)
+				return r
+			},
+			expectedStatusCode: http.StatusOK,
+			// The control headers predating query hints are honoured for all tenants.
+			expectedHeaders: http.Header{
+				querymiddleware.CacheControlHeader:        []string{querymiddleware.NoStoreValue},
+				querymiddleware.InstantSplitControlHeader: []string{"0"},
+				querymiddleware.TotalShardsControlHeader:  []string{"8"},
+			},
+			expectedStatsLog: true,
+			expectedMetrics:  5,
+		},
+		{
+			name: "control headers set directly without limits",
+			cfg:  HandlerConfig{QueryStatsEnabled: true},
+			request: func() *http.Request {
+				r := httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+				r.Header.Set(querymiddleware.SplitControlHeader, "0")
+				return r
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders:    http.Header{},
+			expectedStatsLog:   true,
+			expectedMetrics:    5,
+		},
+		{
+			name:   "control headers set directly while query hints are enabled for the tenant",
+			cfg:    HandlerConfig{QueryStatsEnabled: true},
+			limits: mockLimits{queryHintsEnabled: true},
+			request: func() *http.Request {
+				r := httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+				r.Header.Set(querymiddleware.CacheControlHeader, querymiddleware.NoStoreValue)
+				r.Header.Set(querymiddleware.SplitControlHeader, "0")
+				return r
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: http.Header{
+				querymiddleware.CacheControlHeader: []string{querymiddleware.NoStoreValue},
+				querymiddleware.SplitControlHeader: []string{"0"},
+			},
+			expectedStatsLog: true,
+			expectedMetrics:  5,
+		},
+		{
+			name:   "stats query hint enabling the per-step stats",
+			cfg:    HandlerConfig{QueryStatsEnabled: true},
+			limits: mockLimits{queryHintsEnabled: true},
+			request: func() *http.Request {
+				r := httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+				r.Header.Set(queryHintsHeader, "stats=all")
+				return r
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders: http.Header{
+				querymiddleware.StatsControlHeader: []string{querymiddleware.PerStepStatsValue},
+			},
+			expectedStatsLog: true,
+			expectedMetrics:  5,
+		},
+		{
+			name:   "invalid stats query hint",
+			cfg:    HandlerConfig{QueryStatsEnabled: true},
+			limits: mockLimits{queryHintsEnabled: true},
+			request: func() *http.Request {
+				r := httptest.NewRequest("GET", "/api/v1/query?query=up", nil)
+				r.Header.Set(queryHintsHeader, "stats=none")
+				return r
+			},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				for name := range tt.expectedHeaders {
+					assert.Equal(t, tt.expectedHeaders.Values(name), req.Header.Values(name))
+				}
+				for _, name := range []string{querymiddleware.CacheControlHeader, querymiddleware.SplitControlHeader, querymiddleware.InstantSplitControlHeader, querymiddleware.TotalShardsControlHeader, querymiddleware.StatsControlHeader} {
+					if _, ok := tt.expectedHeaders[name]; !ok {
+						assert.Empty(t, req.Header.Values(name))
+					}
+				}
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("{}")),
+				}, nil
+			})
+
+			reg := prometheus.NewPedanticRegistry()
+			logger := &testLogger{}
+			handler, err := NewHandler(tt.cfg, roundTripper, tt.limits, logger, reg, nil)
+			require.NoError(t, err)
+
+			req := tt.request().WithContext(user.InjectOrgID(context.Background(), "12345"))
+			resp := httptest.NewRecorder()
+
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, tt.expectedStatusCode, resp.Code)
+
+			count, err := promtest.GatherAndCount(
+				reg,
+				"cortex_query_seconds_total",
+				"cortex_query_fetched_series_total",
+				"cortex_query_fetched_chunk_bytes_total",
+				"cortex_query_fetched_chunks_total",
+				"cortex_query_fetched_index_bytes_total",
+			)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedMetrics, count)
+
+			if !tt.expectedStatsLog {
+				require.Empty(t, logger.logMessages)
+				return
+			}
+
+			require.Len(t, logger.logMessages, 1)
+			msg := logger.logMessages[0]
+			require.Equal(t, "query stats", msg["msg"])
+			if hints := req.Header.Get(queryHintsHeader) + req.URL.Query().Get(queryHintsParam); hints != "" {
+				require.Equal(t, hints, msg["query_hints"])
+			} else {
+				require.NotContains(t, msg, "query_hints")
+			}
+			if _, ok := tt.expectedHeaders[querymiddleware.StatsControlHeader]; ok {
+				require.Equal(t, true, msg["per_step_stats_enabled"])
+			} else {
+				require.NotContains(t, msg, "per_step_stats_enabled")
+			}
+		})
+	}
+}
+
+type mockLimits struct {
+	queryHintsEnabled  bool
+	queryLogSampleRate float64
+}
+
+func (m mockLimits) QueryHintsEnabled(string) bool {
+	return m.queryHintsEnabled
+}
+
+func (m mockLimits) QueryLogSampleRate(string) float64 {
+	return m.queryLogSampleRate
+}
+
 // Test Handler.Stop.
 func TestHandler_Stop(t *testing.T) {
 	const (
@@ -296,7 +588,8 @@ func TestHandler_Stop(t *testing.T) {
 	reg := prometheus.NewPedanticRegistry()
 	cfg := HandlerConfig{MaxBodySize: 1024}
 	logger := &testLogger{}
-	handler := NewHandler(cfg, roundTripper, logger, reg, nil)
+	handler, err := NewHandler(cfg, roundTripper, nil, logger, reg, nil)
+	require.NoError(t, err)
 
 	var wg sync.WaitGroup
 	for i := 0; i < requests; i++ {
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/mimir/pkg/frontend/querymiddleware"
+)
+
+const (
+	// queryHintsHeader is the name of the request header used to pass query hints to the query-frontend.
+	queryHintsHeader = "Query-Hints"
+
+	// queryHintsParam is the name of the request param used to pass query hints to the query-frontend.
+	queryHintsParam = "query_hints"
+
+	hintNoCache  = "no-cache"
+	hintNoSplit  = "no-split"
+	hintShards   = "shards"
+	hintStats    = "stats"
+	hintStatsAll = "all"
+)
+
+// gatedControlHeaders are the request headers read by the query-frontend middlewares to override the behaviour
+// of a single request which are only honoured for the tenants allowed to use query hints. The Cache-Control,
+// Sharding-Control and Instant-Split-Control headers, which predate query hints, are honoured for all tenants.
+var gatedControlHeaders = []string{
+	querymiddleware.SplitControlHeader,
+	querymiddleware.StatsControlHeader,
+}
+
+// queryHints holds the per-request overrides a caller can ask for via the Query-Hints header
+// or the query_hints request param.
+type queryHints struct {
+	// raw holds the hints as received, used for logging.
+	raw string
+
+	cacheDisabled bool
+	splitDisabled bool
+	// totalShards is the number of shards to force. 0 means no override, while a negative value disables sharding.
+	totalShards int
+	// perStepStats enables the per-step stats, returned in the response along with the other query statistics.
+	perStepStats bool
+}
+
+// parseQueryHints parses the query hints from the request header and params. The hints are a comma-separated list
+// of directives, for example "no-cache, no-split, shards=8, stats=all". Returns nil if no hint has been provided.
+func parseQueryHints(r *http.Request, params map[string][]string) (*queryHints, error) {
+	var values []string
+	values = append(values, r.Header.Values(queryHintsHeader)...)
+	values = append(values, params[queryHintsParam]...)
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	hints := &queryHints{raw: strings.Join(values, ",")}
+
+	for _, value := range values {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+
+			name, arg, hasArg := strings.Cut(directive, "=")
+			switch {
+			case name == hintNoCache && !hasArg:
+				hints.cacheDisabled = true
+			case name == hintNoSplit && !hasArg:
+				hints.splitDisabled = true
+			case name == hintShards && hasArg:
+				shards, err := strconv.Atoi(arg)
+				if err != nil || shards < 0 {
+					return nil, fmt.Errorf("invalid query hint %q: the number of shards must be a non-negative integer", directive)
+				}
+				// Forcing 0 shards means disabling query sharding.
+				hints.totalShards = shards
+				if shards == 0 {
+					hints.totalShards = -1
+				}
+			case name == hintStats && arg == hintStatsAll:
+				hints.perStepStats = true
+			default:
+				return nil, fmt.Errorf("invalid query hint %q", directive)
+			}
+		}
+	}
+
+	return hints, nil
+}
+
+// apply translates the hints into the control headers understood by the query-frontend middlewares.
+func (h *queryHints) apply(r *http.Request) {
+	if h.cacheDisabled {
+		r.Header.Add(querymiddleware.CacheControlHeader, querymiddleware.NoStoreValue)
+	}
+	if h.splitDisabled {
+		r.Header.Set(querymiddleware.SplitControlHeader, "0")
+		r.Header.Set(querymiddleware.InstantSplitControlHeader, "0")
+	}
+	if h.totalShards > 0 {
+		r.Header.Set(querymiddleware.TotalShardsControlHeader, strconv.Itoa(h.totalShards))
+	} else if h.totalShards < 0 {
+		r.Header.Set(querymiddleware.TotalShardsControlHeader, "0")
+	}
+	if h.perStepStats {
+		r.Header.Set(querymiddleware.StatsControlHeader, querymiddleware.PerStepStatsValue)
+	}
+}
+
+// hasGatedControlHeaders returns whether the request sets any of the gated control headers directly.
+func hasGatedControlHeaders(r *http.Request) bool {
+	for _, name := range gatedControlHeaders {
+		if len(r.Header.Values(name)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// removeGatedControlHeaders removes the gated control headers set directly in the request.
+func removeGatedControlHeaders(r *http.Request) {
+	for _, name := range gatedControlHeaders {
+		r.Header.Del(name)
+	}
+}
@@ -0,0 +1,391 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/objstore"
+
+	querier_stats "github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/storage/bucket"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+const (
+	// queryLogBufferSize is the max number of entries waiting to be written to the query log. Entries
+	// are dropped when the buffer is full, so that a slow disk never slows down the queries.
+	queryLogBufferSize = 1024
+
+	queryLogUploadTimeout = time.Minute
+)
+
+var (
+	errInvalidQueryLogMaxFileSize = errors.New("the query log max file size must be greater than 0")
+	errInvalidQueryLogMaxFiles    = errors.New("the query log max number of rotated files must not be negative")
+	errInvalidQueryLogMaxFileAge  = errors.New("the query log max file age must not be negative")
+)
+
+// QueryLogConfig configures the structured query log.
+type QueryLogConfig struct {
+	FilePath         string        `yaml:"file_path" category:"experimental"`
+	MaxFileSizeBytes int64         `yaml:"max_file_size_bytes" category:"experimental"`
+	MaxFiles         int           `yaml:"max_files" category:"experimental"`
+	MaxFileAge       time.Duration `yaml:"max_file_age" category:"experimental"`
+
+	UploadEnabled bool          `yaml:"upload_enabled" category:"experimental"`
+	Storage       bucket.Config `yaml:"storage"`
+}
+
+func (cfg *QueryLogConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.FilePath, "query-frontend.query-log.file-path", "", "Path of the file where the query-frontend writes a structured query log, as JSON lines, with the tenant, query, time range, status, duration and statistics of each query. The fraction of logged queries is configured per tenant with -query-frontend.query-log-sample-rate. Empty to disable the query log.")
+	f.Int64Var(&cfg.MaxFileSizeBytes, "query-frontend.query-log.max-file-size-bytes", 100*1024*1024, "Maximum size of the query log file. When exceeded, the file is rotated.")
+	f.IntVar(&cfg.MaxFiles, "query-frontend.query-log.max-files", 5, "Maximum number of rotated query log files to keep, in addition to the file currently written. The rotated files are suffixed with a sequence number, the lower the more recent. 0 to not keep rotated files.")
+	f.DurationVar(&cfg.MaxFileAge, "query-frontend.query-log.max-file-age", 0, "Maximum age of the query log file. When exceeded, the file is rotated even if it doesn't exceed the max size. 0 to disable.")
+	f.BoolVar(&cfg.UploadEnabled, "query-frontend.query-log.upload-enabled", false, "Upload the rotated query log files to the object storage configured with -query-frontend.query-log.storage.*, instead of keeping them locally. The files which fail to be uploaded are kept locally, up to -query-frontend.query-log.max-files.")
+	cfg.Storage.RegisterFlagsWithPrefixAndDefaultDirectory("query-frontend.query-log.storage.", "query-log", f)
+}
+
+func (cfg *QueryLogConfig) Validate() error {
+	if cfg.FilePath == "" {
+		return nil
+	}
+	if cfg.MaxFileSizeBytes <= 0 {
+		return errInvalidQueryLogMaxFileSize
+	}
+	if cfg.MaxFiles < 0 {
+		return errInvalidQueryLogMaxFiles
+	}
+	if cfg.MaxFileAge < 0 {
+		return errInvalidQueryLogMaxFileAge
+	}
+	if cfg.UploadEnabled {
+		return errors.Wrap(cfg.Storage.Validate(), "invalid query log storage config")
+	}
+	return nil
+}
+
+// queryLogEntry is a line of the query log.
+type queryLogEntry struct {
+	Timestamp  time.Time  `json:"ts"`
+	Tenant     string     `json:"tenant"`
+	Method     string     `json:"method"`
+	Path       string     `json:"path"`
+	Query      string     `json:"query,omitempty"`
+	Start      *time.Time `json:"start,omitempty"`
+	End        *time.Time `json:"end,omitempty"`
+	Time       *time.Time `json:"time,omitempty"`
+	Step       string     `json:"step,omitempty"`
+	Status     string     `json:"status"`
+	StatusCode int        `json:"status_code,omitempty"`
+	Error      string     `json:"error,omitempty"`
+
+	DurationSeconds   float64 `json:"duration_seconds"`
+	ResponseSizeBytes int64   `json:"response_size_bytes"`
+
+	QueueTimeSeconds     float64 `json:"queue_time_seconds"`
+	WallTimeSeconds      float64 `json:"wall_time_seconds"`
+	FetchedSeriesCount   uint64  `json:"fetched_series_count"`
+	FetchedChunksCount   uint64  `json:"fetched_chunks_count"`
+	FetchedChunkBytes    uint64  `json:"fetched_chunk_bytes"`
+	FetchedIndexBytes    uint64  `json:"fetched_index_bytes"`
+	SamplesProcessed     uint64  `json:"samples_processed"`
+	ShardedQueries       uint32  `json:"sharded_queries"`
+	SplitQueries         uint32  `json:"split_queries"`
+	ResultsCacheHitRatio float64 `json:"results_cache_hit_ratio"`
+
+	// SampleRate is the fraction of the queries of the tenant written to the query log, which allows to estimate
+	// the total number of queries when analysing the log.
+	SampleRate float64 `json:"sample_rate"`
+}
+
+func newQueryLogEntry(r *http.Request, params url.Values, tenantID string, status string, statusCode int, queryErr error, queryResponseTime time.Duration, queryResponseSizeBytes int64, stats *querier_stats.Stats, sampleRate float64) queryLogEntry {
+	entry := queryLogEntry{
+		Timestamp:         time.Now().UTC(),
+		Tenant:            tenantID,
+		Method:            r.Method,
+		Path:              r.URL.Path,
+		Query:             params.Get("query"),
+		Start:             parseQueryLogTime(params.Get("start")),
+		End:               parseQueryLogTime(params.Get("end")),
+		Time:              parseQueryLogTime(params.Get("time")),
+		Step:              params.Get("step"),
+		Status:            status,
+		StatusCode:        statusCode,
+		DurationSeconds:   queryResponseTime.Seconds(),
+		ResponseSizeBytes: queryResponseSizeBytes,
+
+		QueueTimeSeconds:     stats.LoadQueueTime().Seconds(),
+		WallTimeSeconds:      stats.LoadWallTime().Seconds(),
+		FetchedSeriesCount:   stats.LoadFetchedSeries(),
+		FetchedChunksCount:   stats.LoadFetchedChunks(),
+		FetchedChunkBytes:    stats.LoadFetchedChunkBytes(),
+		FetchedIndexBytes:    stats.LoadFetchedIndexBytes(),
+		SamplesProcessed:     stats.LoadSamplesProcessed(),
+		ShardedQueries:       stats.LoadShardedQueries(),
+		SplitQueries:         stats.LoadSplitQueries(),
+		ResultsCacheHitRatio: stats.LoadResultsCacheHitRatio(),
+
+		SampleRate: sampleRate,
+	}
+	if queryErr != nil {
+		entry.Error = queryErr.Error()
+	}
+	return entry
+}
+
+func parseQueryLogTime(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	ms, err := util.ParseTime(value)
+	if err != nil {
+		return nil
+	}
+	t := util.TimeFromMillis(ms).UTC()
+	return &t
+}
+
+// queryLogWriter writes the query log entries as JSON lines to a file, rotating it when it exceeds the max size
+// or age. The entries are buffered and written by a background goroutine, so that the queries are never slowed
+// down by the query log: entries are dropped if the buffer is full.
+type queryLogWriter struct {
+	cfg    QueryLogConfig
+	logger log.Logger
+
+	// bucket is nil if the rotated files are kept locally.
+	bucket   objstore.Bucket
+	hostname string
+
+	buffer chan queryLogEntry
+	done   chan struct{}
+
+	entries        prometheus.Counter
+	writeFailures  prometheus.Counter
+	droppedEntries prometheus.Counter
+	uploadFailures prometheus.Counter
+
+	// The following fields are only accessed by the background goroutine.
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	closeErr error
+}
+
+func newQueryLogWriter(cfg QueryLogConfig, bkt objstore.Bucket, logger log.Logger, reg prometheus.Registerer) *queryLogWriter {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	w := &queryLogWriter{
+		cfg:      cfg,
+		logger:   logger,
+		bucket:   bkt,
+		hostname: hostname,
+		buffer:   make(chan queryLogEntry, queryLogBufferSize),
+		done:     make(chan struct{}),
+		entries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_query_log_entries_total",
+			Help: "Total number of entries written to the query log.",
+		}),
+		writeFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_query_log_write_failures_total",
+			Help: "Total number of entries which failed to be written to the query log.",
+		}),
+		droppedEntries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_query_log_dropped_entries_total",
+			Help: "Total number of entries dropped because the query log buffer was full.",
+		}),
+		uploadFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_query_log_upload_failures_total",
+			Help: "Total number of rotated query log files which failed to be uploaded to object storage.",
+		}),
+	}
+
+	go w.run()
+	return w
+}
+
+// newQueryLogBucketClient returns the client of the object storage the rotated query log files are uploaded to,
+// or nil if the upload is disabled.
+func newQueryLogBucketClient(cfg QueryLogConfig, logger log.Logger, reg prometheus.Registerer) (objstore.Bucket, error) {
+	if !cfg.UploadEnabled {
+		return nil, nil
+	}
+	return bucket.NewClient(context.Background(), cfg.Storage, "query-log", logger, reg)
+}
+
+// write enqueues the entry to be written to the query log, or drops it if the buffer is full.
+// It must not be called after close.
+func (w *queryLogWriter) write(entry queryLogEntry) {
+	select {
+	case w.buffer <- entry:
+	default:
+		w.droppedEntries.Inc()
+	}
+}
+
+// close writes the buffered entries, and closes the query log file. If the upload is enabled,
+// the file is rotated so that it's uploaded to object storage.
+func (w *queryLogWriter) close() error {
+	close(w.buffer)
+	<-w.done
+	return w.closeErr
+}
+
+func (w *queryLogWriter) run() {
+	defer close(w.done)
+
+	var rotateTicker <-chan time.Time
+	if w.cfg.MaxFileAge > 0 {
+		// Check the file age more frequently than the max age, so that it's rotated shortly after exceeding it.
+		ticker := time.NewTicker(w.cfg.MaxFileAge / 10)
+		defer ticker.Stop()
+		rotateTicker = ticker.C
+	}
+
+	for {
+		select {
+		case entry, ok := <-w.buffer:
+			if !ok {
+				w.closeErr = w.closeFile()
+				return
+			}
+			if err := w.writeEntry(entry); err != nil {
+				w.writeFailures.Inc()
+				level.Warn(w.logger).Log("msg", "failed to write the query log", "err", err)
+				continue
+			}
+			w.entries.Inc()
+
+		case <-rotateTicker:
+			if w.file == nil || w.size == 0 || time.Since(w.openedAt) < w.cfg.MaxFileAge {
+				continue
+			}
+			if err := w.rotate(); err != nil {
+				level.Warn(w.logger).Log("msg", "failed to rotate the query log", "err", err)
+			}
+		}
+	}
+}
+
+// writeEntry writes the entry to the query log file, opening or rotating the file if needed.
+func (w *queryLogWriter) writeEntry(entry queryLogEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if w.file != nil && w.size > 0 && w.size+int64(len(line)) > w.cfg.MaxFileSizeBytes {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	return err
+}
+
+// open opens the query log file, appending to it if it already exists.
+func (w *queryLogWriter) open() error {
+	file, err := os.OpenFile(w.cfg.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "open query log file")
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return errors.Wrap(err, "stat query log file")
+	}
+
+	w.file, w.size, w.openedAt = file, info.Size(), time.Now()
+	return nil
+}
+
+// rotate closes the query log file, and uploads it to object storage if enabled. Otherwise, or if the upload
+// fails, it shifts the rotated files by one, removing the oldest one.
+func (w *queryLogWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return errors.Wrap(err, "close query log file")
+	}
+	w.file, w.size = nil, 0
+
+	if w.bucket != nil {
+		err := w.upload()
+		if err == nil {
+			return errors.Wrap(os.Remove(w.cfg.FilePath), "remove uploaded query log file")
+		}
+		w.uploadFailures.Inc()
+		level.Warn(w.logger).Log("msg", "failed to upload the query log file to object storage, keeping it locally", "err", err)
+	}
+
+	if w.cfg.MaxFiles == 0 {
+		return errors.Wrap(os.Remove(w.cfg.FilePath), "remove query log file")
+	}
+
+	if err := os.Remove(rotatedQueryLogPath(w.cfg.FilePath, w.cfg.MaxFiles)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove oldest rotated query log file")
+	}
+	for i := w.cfg.MaxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(rotatedQueryLogPath(w.cfg.FilePath, i), rotatedQueryLogPath(w.cfg.FilePath, i+1)); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "rotate query log file")
+		}
+	}
+	return errors.Wrap(os.Rename(w.cfg.FilePath, rotatedQueryLogPath(w.cfg.FilePath, 1)), "rotate query log file")
+}
+
+// upload uploads the query log file to object storage, as an object named after the hostname, so that the
+// query-frontend replicas don't overwrite each other's files, and the upload time.
+func (w *queryLogWriter) upload() error {
+	file, err := os.Open(w.cfg.FilePath)
+	if err != nil {
+		return errors.Wrap(err, "open query log file")
+	}
+	defer func() { _ = file.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), queryLogUploadTimeout)
+	defer cancel()
+
+	name := path.Join(w.hostname, time.Now().UTC().Format("20060102T150405.000000000Z")+".jsonl")
+	return errors.Wrap(w.bucket.Upload(ctx, name, file), "upload query log file")
+}
+
+// closeFile closes the query log file, rotating it first if it has to be uploaded to object storage.
+func (w *queryLogWriter) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	if w.bucket != nil && w.size > 0 {
+		return w.rotate()
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func rotatedQueryLogPath(filePath string, index int) string {
+	return fmt.Sprintf("%s.%d", filePath, index)
+}
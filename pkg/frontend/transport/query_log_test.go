@@ -0,0 +1,315 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/objstore/providers/filesystem"
+
+	"github.com/grafana/mimir/pkg/storage/bucket"
+)
+
+func TestQueryLogConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cfg         QueryLogConfig
+		expectedErr error
+	}{
+		"disabled": {
+			cfg: QueryLogConfig{},
+		},
+		"valid": {
+			cfg: QueryLogConfig{FilePath: "query.log", MaxFileSizeBytes: 1024, MaxFiles: 2},
+		},
+		"invalid max file size": {
+			cfg:         QueryLogConfig{FilePath: "query.log", MaxFileSizeBytes: 0, MaxFiles: 2},
+			expectedErr: errInvalidQueryLogMaxFileSize,
+		},
+		"invalid max files": {
+			cfg:         QueryLogConfig{FilePath: "query.log", MaxFileSizeBytes: 1024, MaxFiles: -1},
+			expectedErr: errInvalidQueryLogMaxFiles,
+		},
+		"invalid max file age": {
+			cfg:         QueryLogConfig{FilePath: "query.log", MaxFileSizeBytes: 1024, MaxFileAge: -time.Second},
+			expectedErr: errInvalidQueryLogMaxFileAge,
+		},
+		"invalid storage config": {
+			cfg:         QueryLogConfig{FilePath: "query.log", MaxFileSizeBytes: 1024, UploadEnabled: true, Storage: bucket.Config{StorageBackendConfig: bucket.StorageBackendConfig{Backend: "unknown"}}},
+			expectedErr: bucket.ErrUnsupportedStorageBackend,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.ErrorIs(t, tc.cfg.Validate(), tc.expectedErr)
+		})
+	}
+}
+
+func TestHandler_QueryLog(t *testing.T) {
+	tests := map[string]struct {
+		sampleRate      float64
+		roundTripErr    error
+		expectedEntries int
+		expectedStatus  string
+		expectedCode    int
+	}{
+		"successful query": {
+			sampleRate:      1,
+			expectedEntries: 1,
+			expectedStatus:  "success",
+			expectedCode:    http.StatusOK,
+		},
+		"failed query": {
+			sampleRate:      1,
+			roundTripErr:    context.DeadlineExceeded,
+			expectedEntries: 1,
+			expectedStatus:  "timeout",
+			expectedCode:    http.StatusGatewayTimeout,
+		},
+		"query not sampled": {
+			sampleRate:      0,
+			expectedEntries: 0,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "query.log")
+			cfg := HandlerConfig{
+				MaxBodySize: 1024,
+				QueryLog:    QueryLogConfig{FilePath: path, MaxFileSizeBytes: 1024 * 1024},
+			}
+
+			roundTripper := roundTripperFunc(func(*http.Request) (*http.Response, error) {
+				if tc.roundTripErr != nil {
+					return nil, tc.roundTripErr
+				}
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+			})
+
+			reg := prometheus.NewPedanticRegistry()
+			handler, err := NewHandler(cfg, roundTripper, mockLimits{queryLogSampleRate: tc.sampleRate}, log.NewNopLogger(), reg, nil)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/query_range?query=sum(up)&start=1700000000&end=1700003600&step=60", nil)
+			req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			handler.Stop()
+
+			entries := readQueryLog(t, path)
+			require.Len(t, entries, tc.expectedEntries)
+			assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+				# HELP cortex_query_frontend_query_log_entries_total Total number of entries written to the query log.
+				# TYPE cortex_query_frontend_query_log_entries_total counter
+				cortex_query_frontend_query_log_entries_total `+strings.Repeat("1", tc.expectedEntries)+strings.Repeat("0", 1-tc.expectedEntries)+`
+				# HELP cortex_query_frontend_query_log_dropped_entries_total Total number of entries dropped because the query log buffer was full.
+				# TYPE cortex_query_frontend_query_log_dropped_entries_total counter
+				cortex_query_frontend_query_log_dropped_entries_total 0
+			`), "cortex_query_frontend_query_log_entries_total", "cortex_query_frontend_query_log_dropped_entries_total"))
+
+			if tc.expectedEntries == 0 {
+				return
+			}
+
+			entry := entries[0]
+			assert.Equal(t, "user-1", entry.Tenant)
+			assert.Equal(t, "/api/v1/query_range", entry.Path)
+			assert.Equal(t, "sum(up)", entry.Query)
+			require.NotNil(t, entry.Start)
+			require.NotNil(t, entry.End)
+			assert.Equal(t, time.Unix(1700000000, 0).UTC(), *entry.Start)
+			assert.Equal(t, time.Unix(1700003600, 0).UTC(), *entry.End)
+			assert.Nil(t, entry.Time)
+			assert.Equal(t, "60", entry.Step)
+			assert.Equal(t, tc.expectedStatus, entry.Status)
+			assert.Equal(t, tc.sampleRate, entry.SampleRate)
+			assert.Equal(t, tc.expectedCode, entry.StatusCode)
+			if tc.roundTripErr != nil {
+				assert.Equal(t, tc.roundTripErr.Error(), entry.Error)
+			} else {
+				assert.Equal(t, int64(2), entry.ResponseSizeBytes)
+				assert.Empty(t, entry.Error)
+			}
+		})
+	}
+}
+
+func TestQueryLogWriter_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "query.log")
+
+	entry := queryLogEntry{Tenant: "user-1", Query: "up"}
+	line, err := json.Marshal(entry)
+	require.NoError(t, err)
+
+	// Each file fits 2 entries.
+	w := newQueryLogWriter(QueryLogConfig{FilePath: path, MaxFileSizeBytes: int64(2 * (len(line) + 1)), MaxFiles: 2}, nil, log.NewNopLogger(), nil)
+	for i := 0; i < 7; i++ {
+		w.write(entry)
+	}
+	require.NoError(t, w.close())
+
+	// The oldest entries have been removed with the oldest rotated file.
+	assert.Len(t, readQueryLog(t, path), 1)
+	assert.Len(t, readQueryLog(t, path+".1"), 2)
+	assert.Len(t, readQueryLog(t, path+".2"), 2)
+	assert.NoFileExists(t, path+".3")
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+}
+
+func TestQueryLogWriter_RotationWithoutRotatedFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.log")
+
+	entry := queryLogEntry{Tenant: "user-1", Query: "up"}
+	line, err := json.Marshal(entry)
+	require.NoError(t, err)
+
+	w := newQueryLogWriter(QueryLogConfig{FilePath: path, MaxFileSizeBytes: int64(len(line) + 1), MaxFiles: 0}, nil, log.NewNopLogger(), nil)
+	for i := 0; i < 3; i++ {
+		w.write(entry)
+	}
+	require.NoError(t, w.close())
+
+	assert.Len(t, readQueryLog(t, path), 1)
+	assert.NoFileExists(t, path+".1")
+}
+
+func TestQueryLogWriter_RotationByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.log")
+
+	w := newQueryLogWriter(QueryLogConfig{FilePath: path, MaxFileSizeBytes: 1024 * 1024, MaxFiles: 1, MaxFileAge: 100 * time.Millisecond}, nil, log.NewNopLogger(), nil)
+	t.Cleanup(func() { require.NoError(t, w.close()) })
+
+	w.write(queryLogEntry{Tenant: "user-1", Query: "up"})
+
+	require.Eventually(t, func() bool {
+		return len(readQueryLog(t, path+".1")) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestQueryLogWriter_Upload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.log")
+	bkt, err := filesystem.NewBucket(t.TempDir())
+	require.NoError(t, err)
+
+	entry := queryLogEntry{Tenant: "user-1", Query: "up"}
+	line, err := json.Marshal(entry)
+	require.NoError(t, err)
+
+	// Each file fits 2 entries, and the file still open on close is uploaded too.
+	w := newQueryLogWriter(QueryLogConfig{FilePath: path, MaxFileSizeBytes: int64(2 * (len(line) + 1)), MaxFiles: 2, UploadEnabled: true}, bkt, log.NewNopLogger(), nil)
+	for i := 0; i < 5; i++ {
+		w.write(entry)
+	}
+	require.NoError(t, w.close())
+
+	var uploaded int
+	require.NoError(t, bkt.Iter(context.Background(), "", func(name string) error {
+		return bkt.Iter(context.Background(), name, func(name string) error {
+			reader, err := bkt.Get(context.Background(), name)
+			require.NoError(t, err)
+			defer func() { _ = reader.Close() }()
+
+			data, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			uploaded += strings.Count(string(data), "\n")
+			return nil
+		})
+	}))
+	assert.Equal(t, 5, uploaded)
+
+	// The uploaded files are not kept locally.
+	assert.NoFileExists(t, path)
+	assert.NoFileExists(t, path+".1")
+}
+
+func TestQueryLogWriter_ShouldDropEntriesWhenTheBufferIsFull(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "query.log")
+	bkt, err := filesystem.NewBucket(t.TempDir())
+	require.NoError(t, err)
+
+	// Block the background goroutine while uploading the first rotated file.
+	uploading := make(chan struct{})
+	release := make(chan struct{})
+	blockingBkt := &blockingUploadBucket{Bucket: bkt, uploading: uploading, release: release}
+
+	entry := queryLogEntry{Tenant: "user-1", Query: "up"}
+	line, err := json.Marshal(entry)
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	w := newQueryLogWriter(QueryLogConfig{FilePath: path, MaxFileSizeBytes: int64(len(line) + 1), UploadEnabled: true}, blockingBkt, log.NewNopLogger(), reg)
+
+	w.write(entry)
+	w.write(entry)
+	<-uploading
+
+	for i := 0; i < queryLogBufferSize+5; i++ {
+		w.write(entry)
+	}
+	close(release)
+	require.NoError(t, w.close())
+
+	assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_frontend_query_log_dropped_entries_total Total number of entries dropped because the query log buffer was full.
+		# TYPE cortex_query_frontend_query_log_dropped_entries_total counter
+		cortex_query_frontend_query_log_dropped_entries_total 5
+	`), "cortex_query_frontend_query_log_dropped_entries_total"))
+}
+
+type blockingUploadBucket struct {
+	objstore.Bucket
+
+	once      sync.Once
+	uploading chan struct{}
+	release   chan struct{}
+}
+
+func (b *blockingUploadBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	b.once.Do(func() {
+		close(b.uploading)
+		<-b.release
+	})
+	return b.Bucket.Upload(ctx, name, r)
+}
+
+func readQueryLog(t *testing.T, path string) []queryLogEntry {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	var entries []queryLogEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry queryLogEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+	return entries
+}
@@ -38,6 +38,9 @@ var (
 type Config struct {
 	MaxOutstandingPerTenant int           `yaml:"max_outstanding_per_tenant" category:"advanced"`
 	QuerierForgetDelay      time.Duration `yaml:"querier_forget_delay" category:"experimental"`
+
+	// This configuration is injected internally.
+	QueryStoreAfter time.Duration `yaml:"-"`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet.
@@ -49,6 +52,9 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 type Limits interface {
 	// Returns max queriers to use per tenant, or 0 if shuffle sharding is disabled.
 	MaxQueriersPerUser(user string) int
+
+	// QueryIngestersWithin returns the maximum lookback beyond which queries are not sent to ingester.
+	QueryIngestersWithin(user string) time.Duration
 }
 
 // Frontend queues HTTP requests, dispatches them to backends, and handles retries
@@ -68,10 +74,11 @@ type Frontend struct {
 	subservicesWatcher *services.FailureWatcher
 
 	// Metrics.
-	queueLength       *prometheus.GaugeVec
-	discardedRequests *prometheus.CounterVec
-	numClients        prometheus.GaugeFunc
-	queueDuration     prometheus.Histogram
+	queueLength            *prometheus.GaugeVec
+	queueLengthByComponent *prometheus.GaugeVec
+	discardedRequests      *prometheus.CounterVec
+	numClients             prometheus.GaugeFunc
+	queueDuration          prometheus.Histogram
 }
 
 type request struct {
@@ -95,6 +102,10 @@ func New(cfg Config, limits Limits, log log.Logger, registerer prometheus.Regist
 			Name: "cortex_query_frontend_queue_length",
 			Help: "Number of queries in the queue.",
 		}, []string{"user"}),
+		queueLengthByComponent: promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_query_frontend_queue_length_by_query_component",
+			Help: "Number of queries in the queue, by the component they're expected to query.",
+		}, []string{"user", "query_component"}),
 		discardedRequests: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_query_frontend_discarded_requests_total",
 			Help: "Total number of query requests discarded.",
@@ -111,7 +122,7 @@ func New(cfg Config, limits Limits, log log.Logger, registerer prometheus.Regist
 		Help: "Time spent by requests waiting to join the queue or be rejected.",
 	})
 
-	f.requestQueue = queue.NewRequestQueue(cfg.MaxOutstandingPerTenant, cfg.QuerierForgetDelay, f.queueLength, f.discardedRequests, enqueueDuration)
+	f.requestQueue = queue.NewRequestQueue(cfg.MaxOutstandingPerTenant, cfg.QuerierForgetDelay, 0, 0, f.queueLength, f.queueLengthByComponent, f.discardedRequests, enqueueDuration)
 	f.activeUsers = util.NewActiveUsersCleanupWithDefaultValues(f.cleanupInactiveUserMetrics)
 
 	var err error
@@ -157,6 +168,7 @@ func (f *Frontend) stopping(_ error) error {
 
 func (f *Frontend) cleanupInactiveUserMetrics(user string) {
 	f.queueLength.DeleteLabelValues(user)
+	f.queueLengthByComponent.DeletePartialMatch(prometheus.Labels{"user": user})
 	f.discardedRequests.DeleteLabelValues(user)
 }
 
@@ -207,20 +219,23 @@ func (f *Frontend) Process(server frontendv1pb.Frontend_ProcessServer) error {
 	}
 
 	f.requestQueue.RegisterQuerierConnection(querierID)
-	defer f.requestQueue.UnregisterQuerierConnection(querierID)
-
 	lastUserIndex := queue.FirstUser()
+	defer func() {
+		f.requestQueue.UnregisterQuerierConnection(querierID, lastUserIndex)
+	}()
 
 	for {
 		reqWrapper, idx, err := f.requestQueue.GetNextRequestForQuerier(server.Context(), lastUserIndex, querierID)
+		lastUserIndex = idx
 		if err != nil {
 			return err
 		}
-		lastUserIndex = idx
 
 		req := reqWrapper.(*request)
 
-		f.queueDuration.Observe(time.Since(req.enqueueTime).Seconds())
+		queueTime := time.Since(req.enqueueTime)
+		f.queueDuration.Observe(queueTime.Seconds())
+		stats.FromContext(req.originalCtx).AddQueueTime(queueTime) // Safe if stats is nil.
 		req.queueSpan.Finish()
 
 		/*
@@ -334,7 +349,10 @@ func (f *Frontend) queueRequest(ctx context.Context, req *request) error {
 	joinedTenantID := tenant.JoinTenantIDs(tenantIDs)
 	f.activeUsers.UpdateUserTimestamp(joinedTenantID, now)
 
-	err = f.requestQueue.EnqueueRequest(joinedTenantID, req, maxQueriers, nil)
+	queryIngestersWithin := validation.LargestPositiveNonZeroDurationPerTenant(tenantIDs, f.limits.QueryIngestersWithin)
+	queryComponent := queue.QueryComponentForRequest(req.request, now, f.cfg.QueryStoreAfter, queryIngestersWithin)
+
+	err = f.requestQueue.EnqueueRequest(joinedTenantID, req, queryComponent, 0, maxQueriers, nil)
 	if errors.Is(err, queue.ErrTooManyRequests) {
 		return errTooManyRequest
 	}
@@ -265,11 +265,14 @@ func testFrontend(t *testing.T, config Config, handler http.Handler, test func(a
 	flagext.DefaultValues(&handlerCfg)
 
 	rt := transport.AdaptGrpcRoundTripperToHTTPRoundTripper(v1)
+	frontendHandler, err := transport.NewHandler(handlerCfg, rt, nil, logger, nil, nil)
+	require.NoError(t, err)
+
 	r := mux.NewRouter()
 	r.PathPrefix("/").Handler(middleware.Merge(
 		middleware.AuthenticateUser,
 		middleware.Tracer{},
-	).Wrap(transport.NewHandler(handlerCfg, rt, logger, nil, nil)))
+	).Wrap(frontendHandler))
 
 	httpServer := http.Server{
 		Handler: r,
@@ -302,3 +305,7 @@ type limits struct {
 func (l limits) MaxQueriersPerUser(_ string) int {
 	return l.queriers
 }
+
+func (l limits) QueryIngestersWithin(_ string) time.Duration {
+	return 0
+}
@@ -30,13 +30,18 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"go.uber.org/atomic"
 
+	"github.com/grafana/mimir/pkg/frontend/siblings"
 	"github.com/grafana/mimir/pkg/frontend/v2/frontendv2pb"
 	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/scheduler/queue"
 	"github.com/grafana/mimir/pkg/scheduler/schedulerdiscovery"
 	"github.com/grafana/mimir/pkg/util/httpgrpcutil"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
+	"github.com/grafana/mimir/pkg/util/validation"
 )
 
+var errInvalidHedgingStragglerMultiplier = errors.New("the hedging straggler multiplier must be greater than or equal to 1")
+
 // Config for a Frontend.
 type Config struct {
 	SchedulerAddress  string            `yaml:"scheduler_address"`
@@ -52,8 +57,14 @@ type Config struct {
 	Addr string `yaml:"address" category:"advanced"`
 	Port int    `category:"advanced"`
 
+	HedgingStragglerMultiplier float64       `yaml:"hedging_straggler_multiplier" category:"experimental"`
+	HedgingMinDelay            time.Duration `yaml:"hedging_min_delay" category:"experimental"`
+
+	QueryPriorityClasses flagext.StringSliceCSV `yaml:"query_priority_classes" category:"experimental"`
+
 	// This configuration is injected internally.
 	QuerySchedulerDiscovery schedulerdiscovery.Config `yaml:"-"`
+	QueryStoreAfter         time.Duration             `yaml:"-"`
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
@@ -67,6 +78,11 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.StringVar(&cfg.Addr, "query-frontend.instance-addr", "", "IP address to advertise to the querier (via scheduler) (default is auto-detected from network interfaces).")
 	f.IntVar(&cfg.Port, "query-frontend.instance-port", 0, "Port to advertise to querier (via scheduler) (defaults to server.grpc-listen-port).")
 
+	f.Float64Var(&cfg.HedgingStragglerMultiplier, "query-frontend.hedging-straggler-multiplier", 3, "A sharded query is considered straggling, and can be hedged, once at least half of the sharded queries of the same query have completed and it's been running for longer than this multiplier times their median duration. The number of sharded queries which can be hedged is limited by -query-frontend.max-hedged-requests-per-query.")
+	f.DurationVar(&cfg.HedgingMinDelay, "query-frontend.hedging-min-delay", time.Second, "Minimum time a sharded query must run before it can be hedged.")
+
+	f.Var(&cfg.QueryPriorityClasses, "query-frontend.query-priority-classes", "Comma-separated list of query priority classes clients can request via the X-Query-Priority-Class header, from the highest to the lowest priority. Queries of a higher priority class are dequeued first by the query-scheduler. A class is applied only to the queries of the tenants allowed to request it with -query-frontend.allowed-query-priority-classes, while queries without an allowed priority class get the lowest priority.")
+
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("query-frontend.grpc-client-config", f)
 }
 
@@ -75,16 +91,32 @@ func (cfg *Config) Validate() error {
 		return fmt.Errorf("scheduler address cannot be specified when query-scheduler service discovery mode is set to '%s'", cfg.QuerySchedulerDiscovery.Mode)
 	}
 
+	if cfg.HedgingStragglerMultiplier < 1 {
+		return errInvalidHedgingStragglerMultiplier
+	}
+
 	return cfg.GRPCClientConfig.Validate()
 }
 
+type Limits interface {
+	// QueryIngestersWithin returns the maximum lookback beyond which queries are not sent to ingester.
+	QueryIngestersWithin(user string) time.Duration
+
+	// AllowedQueryPriorityClasses returns the query priority classes the tenant's clients are allowed to request.
+	AllowedQueryPriorityClasses(user string) []string
+
+	// MaxHedgedRequestsPerQuery returns the maximum number of sharded queries of a single query which can be hedged.
+	MaxHedgedRequestsPerQuery(user string) int
+}
+
 // Frontend implements GrpcRoundTripper. It queues HTTP requests,
 // dispatches them to backends via gRPC, and handles retries for requests which failed.
 type Frontend struct {
 	services.Service
 
-	cfg Config
-	log log.Logger
+	cfg    Config
+	log    log.Logger
+	limits Limits
 
 	lastQueryID atomic.Uint64
 
@@ -94,6 +126,9 @@ type Frontend struct {
 	schedulerWorkers        *frontendSchedulerWorkers
 	schedulerWorkersWatcher *services.FailureWatcher
 	requests                *requestsInProgress
+
+	hedgedRequests    prometheus.Counter
+	hedgedRequestsWon prometheus.Counter
 }
 
 type frontendRequest struct {
@@ -102,6 +137,13 @@ type frontendRequest struct {
 	userID       string
 	statsEnabled bool
 
+	// The component the request is expected to query, used by the query-scheduler to fairly
+	// dequeue requests of the same tenant. Empty if it can't be inferred.
+	queryComponent string
+
+	// The priority of the request, from the priority class requested by the client. 0 if unprioritized.
+	priority int
+
 	ctx    context.Context
 	cancel context.CancelFunc
 
@@ -126,7 +168,7 @@ type enqueueResult struct {
 }
 
 // NewFrontend creates a new frontend.
-func NewFrontend(cfg Config, log log.Logger, reg prometheus.Registerer) (*Frontend, error) {
+func NewFrontend(cfg Config, limits Limits, log log.Logger, reg prometheus.Registerer) (*Frontend, error) {
 	requestsCh := make(chan *frontendRequest)
 
 	schedulerWorkers, err := newFrontendSchedulerWorkers(cfg, net.JoinHostPort(cfg.Addr, strconv.Itoa(cfg.Port)), requestsCh, log, reg)
@@ -137,10 +179,20 @@ func NewFrontend(cfg Config, log log.Logger, reg prometheus.Registerer) (*Fronte
 	f := &Frontend{
 		cfg:                     cfg,
 		log:                     log,
+		limits:                  limits,
 		requestsCh:              requestsCh,
 		schedulerWorkers:        schedulerWorkers,
 		schedulerWorkersWatcher: services.NewFailureWatcher(),
 		requests:                newRequestsInProgress(),
+
+		hedgedRequests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_hedged_requests_total",
+			Help: "Total number of hedged requests enqueued because the original request was straggling compared to its sibling requests.",
+		}),
+		hedgedRequestsWon: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_hedged_requests_won_total",
+			Help: "Total number of hedged requests which completed successfully before the original request.",
+		}),
 	}
 	// Randomize to avoid getting responses from queries sent before restart, which could lead to mixing results
 	// between different queries. Note that frontend verifies the user, so it cannot leak results between tenants.
@@ -209,11 +261,15 @@ func (f *Frontend) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest)
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	queryIngestersWithin := validation.LargestPositiveNonZeroDurationPerTenant(tenantIDs, f.limits.QueryIngestersWithin)
+
 	freq := &frontendRequest{
-		queryID:      f.lastQueryID.Inc(),
-		request:      req,
-		userID:       userID,
-		statsEnabled: stats.IsEnabled(ctx),
+		queryID:        f.lastQueryID.Inc(),
+		request:        req,
+		userID:         userID,
+		statsEnabled:   stats.IsEnabled(ctx),
+		queryComponent: queue.QueryComponentForRequest(req, time.Now(), f.cfg.QueryStoreAfter, queryIngestersWithin),
+		priority:       queue.PriorityForRequest(req, f.cfg.QueryPriorityClasses, tenantIDs, f.limits.AllowedQueryPriorityClasses),
 
 		ctx:    ctx,
 		cancel: cancel,
@@ -227,62 +283,234 @@ func (f *Frontend) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest)
 	f.requests.put(freq)
 	defer f.requests.delete(freq.queryID)
 
-	retries := f.cfg.WorkerConcurrency + 1 // To make sure we hit at least two different schedulers.
+	// Requests can be hedged only if they have siblings to compare their execution time with.
+	siblingReqs := siblings.FromContext(ctx)
+	maxHedged := validation.SmallestPositiveIntPerTenant(tenantIDs, f.limits.MaxHedgedRequestsPerQuery)
+	if siblingReqs != nil && maxHedged > 0 {
+		siblingReqs.Start()
+	}
+	start := time.Now()
 
-enqueueAgain:
-	level.Debug(spanLogger).Log("msg", "enqueuing request")
+	cancelCh, err := f.enqueueRequest(ctx, freq, spanLogger)
+	if err != nil {
+		return nil, err
+	}
+
+	level.Debug(spanLogger).Log("msg", "request enqueued successfully, waiting for response")
+
+	if siblingReqs != nil && maxHedged > 0 {
+		return f.waitForResponseWithHedging(ctx, freq, cancelCh, siblingReqs, maxHedged, start, spanLogger)
+	}
 
-	var cancelCh chan<- uint64
 	select {
 	case <-ctx.Done():
-		level.Debug(spanLogger).Log("msg", "request context cancelled while enqueuing request, aborting", "err", ctx.Err())
+		level.Debug(spanLogger).Log("msg", "request context cancelled after enqueuing request, aborting", "err", ctx.Err())
+		f.cancelEnqueuedRequest(freq, cancelCh, spanLogger)
 		return nil, ctx.Err()
 
-	case f.requestsCh <- freq:
-		// Enqueued, let's wait for response.
-		enqRes := <-freq.enqueue
-		if enqRes.status == waitForResponse {
-			cancelCh = enqRes.cancelCh
-			break // go wait for response.
-		} else if enqRes.status == failed {
-			retries--
-			if retries > 0 {
-				level.Debug(spanLogger).Log("msg", "enqueuing request failed, will retry")
-				goto enqueueAgain
+	case resp := <-freq.response:
+		level.Debug(spanLogger).Log("msg", "received response")
+		return f.handleResponse(ctx, resp), nil
+	}
+}
+
+// enqueueRequest forwards the request to a query-scheduler, via the scheduler workers. Returns the channel to use
+// to cancel the request, which may be nil if cancellation is not possible.
+func (f *Frontend) enqueueRequest(ctx context.Context, freq *frontendRequest, spanLogger *spanlogger.SpanLogger) (chan<- uint64, error) {
+	retries := f.cfg.WorkerConcurrency + 1 // To make sure we hit at least two different schedulers.
+
+	for {
+		level.Debug(spanLogger).Log("msg", "enqueuing request")
+
+		select {
+		case <-ctx.Done():
+			level.Debug(spanLogger).Log("msg", "request context cancelled while enqueuing request, aborting", "err", ctx.Err())
+			return nil, ctx.Err()
+
+		case f.requestsCh <- freq:
+			// Enqueued, let's wait for response.
+			enqRes := <-freq.enqueue
+			if enqRes.status == waitForResponse {
+				return enqRes.cancelCh, nil
+			} else if enqRes.status == failed {
+				retries--
+				if retries > 0 {
+					level.Debug(spanLogger).Log("msg", "enqueuing request failed, will retry")
+					continue
+				}
 			}
-		}
 
-		level.Debug(spanLogger).Log("msg", "enqueuing request failed, retries are exhausted, aborting")
+			level.Debug(spanLogger).Log("msg", "enqueuing request failed, retries are exhausted, aborting")
 
-		return nil, httpgrpc.Errorf(http.StatusInternalServerError, "failed to enqueue request")
+			return nil, httpgrpc.Errorf(http.StatusInternalServerError, "failed to enqueue request")
+		}
 	}
+}
 
-	level.Debug(spanLogger).Log("msg", "request enqueued successfully, waiting for response")
+// cancelEnqueuedRequest asks the query-scheduler to cancel the request, if cancellation is possible.
+func (f *Frontend) cancelEnqueuedRequest(freq *frontendRequest, cancelCh chan<- uint64, spanLogger *spanlogger.SpanLogger) {
+	if cancelCh == nil {
+		return
+	}
 
 	select {
-	case <-ctx.Done():
-		level.Debug(spanLogger).Log("msg", "request context cancelled after enqueuing request, aborting", "err", ctx.Err())
+	case cancelCh <- freq.queryID:
+		// cancellation sent.
+	default:
+		// failed to cancel, ignore.
+		level.Warn(spanLogger).Log("msg", "failed to send cancellation request to scheduler, queue full")
+	}
+}
+
+func (f *Frontend) handleResponse(ctx context.Context, resp *frontendv2pb.QueryResultRequest) *httpgrpc.HTTPResponse {
+	if stats.ShouldTrackHTTPGRPCResponse(resp.HttpResponse) {
+		stats := stats.FromContext(ctx)
+		stats.Merge(resp.Stats) // Safe if stats is nil.
+	}
+
+	return resp.HttpResponse
+}
 
-		if cancelCh != nil {
-			select {
-			case cancelCh <- freq.queryID:
-				// cancellation sent.
-			default:
-				// failed to cancel, ignore.
-				level.Warn(spanLogger).Log("msg", "failed to send cancellation request to scheduler, queue full")
+// waitForResponseWithHedging waits for the response of the request. If the request is straggling compared to its
+// siblings, and the hedge budget allows it, a hedged duplicate of the request is enqueued: the first successful
+// response is returned, and the other request is cancelled.
+func (f *Frontend) waitForResponseWithHedging(ctx context.Context, freq *frontendRequest, cancelCh chan<- uint64, siblingReqs *siblings.Requests, maxHedged int, start time.Time, spanLogger *spanlogger.SpanLogger) (*httpgrpc.HTTPResponse, error) {
+	var (
+		hedge         *frontendRequest
+		hedgeCancelCh chan<- uint64
+
+		// Set to nil once the response has been received, so that they're not selected anymore.
+		originalResponse = freq.response
+		hedgeResponse    chan *frontendv2pb.QueryResultRequest
+
+		// Whether we're still waiting to find out if the request is straggling.
+		watchStraggling = true
+	)
+
+	defer func() {
+		if hedge != nil {
+			hedge.cancel()
+			f.requests.delete(hedge.queryID)
+		}
+	}()
+
+	for {
+		var (
+			siblingsChanged <-chan struct{}
+			stragglerTimer  *time.Timer
+			stragglerC      <-chan time.Time
+		)
+		if watchStraggling {
+			var deadline time.Time
+			var ok bool
+			siblingsChanged, deadline, ok = siblingReqs.StragglerDeadline(start, f.cfg.HedgingStragglerMultiplier, f.cfg.HedgingMinDelay)
+			if ok {
+				stragglerTimer = time.NewTimer(time.Until(deadline))
+				stragglerC = stragglerTimer.C
 			}
 		}
-		return nil, ctx.Err()
 
-	case resp := <-freq.response:
-		level.Debug(spanLogger).Log("msg", "received response")
+		var (
+			resp    *frontendv2pb.QueryResultRequest
+			isHedge bool
+		)
+
+		select {
+		case <-ctx.Done():
+			level.Debug(spanLogger).Log("msg", "request context cancelled after enqueuing request, aborting", "err", ctx.Err())
+			if originalResponse != nil {
+				f.cancelEnqueuedRequest(freq, cancelCh, spanLogger)
+			}
+			if hedgeResponse != nil {
+				f.cancelEnqueuedRequest(hedge, hedgeCancelCh, spanLogger)
+			}
+			return nil, ctx.Err()
+
+		case <-siblingsChanged:
+			// The straggler deadline has to be computed again.
+
+		case <-stragglerC:
+			watchStraggling = false
+			if !siblingReqs.TryHedge(maxHedged) {
+				level.Debug(spanLogger).Log("msg", "request is straggling, but the hedge budget has been exhausted")
+				break
+			}
+
+			hedge = f.newHedgedRequest(ctx, freq)
+			f.requests.put(hedge)
+
+			level.Debug(spanLogger).Log("msg", "request is straggling, enqueuing hedged request", "hedged_query_id", hedge.queryID)
+			var err error
+			if hedgeCancelCh, err = f.enqueueRequest(hedge.ctx, hedge, spanLogger); err != nil {
+				level.Warn(spanLogger).Log("msg", "failed to enqueue hedged request", "err", err)
+				break
+			}
+
+			f.hedgedRequests.Inc()
+			hedgeResponse = hedge.response
+
+		case resp = <-originalResponse:
+			originalResponse = nil
+
+		case resp = <-hedgeResponse:
+			hedgeResponse = nil
+			isHedge = true
+		}
+
+		if stragglerTimer != nil {
+			stragglerTimer.Stop()
+		}
 
-		if stats.ShouldTrackHTTPGRPCResponse(resp.HttpResponse) {
-			stats := stats.FromContext(ctx)
-			stats.Merge(resp.Stats) // Safe if stats is nil.
+		if resp == nil {
+			continue
 		}
 
-		return resp.HttpResponse, nil
+		successful := resp.HttpResponse != nil && resp.HttpResponse.Code/100 == 2
+
+		// If the request failed, give the other one a chance to succeed.
+		if !successful && (originalResponse != nil || hedgeResponse != nil) {
+			level.Debug(spanLogger).Log("msg", "received failed response, waiting for the other request", "hedged", isHedge)
+			continue
+		}
+
+		level.Debug(spanLogger).Log("msg", "received response", "hedged", isHedge)
+
+		if originalResponse != nil {
+			f.cancelEnqueuedRequest(freq, cancelCh, spanLogger)
+		}
+		if hedgeResponse != nil {
+			f.cancelEnqueuedRequest(hedge, hedgeCancelCh, spanLogger)
+		}
+
+		if successful {
+			siblingReqs.Complete(time.Since(start))
+
+			if isHedge {
+				f.hedgedRequestsWon.Inc()
+			}
+		}
+
+		return f.handleResponse(ctx, resp), nil
+	}
+}
+
+// newHedgedRequest returns a duplicate of the input request, with a different query ID.
+func (f *Frontend) newHedgedRequest(ctx context.Context, freq *frontendRequest) *frontendRequest {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &frontendRequest{
+		queryID:        f.lastQueryID.Inc(),
+		request:        freq.request,
+		userID:         freq.userID,
+		statsEnabled:   freq.statsEnabled,
+		queryComponent: freq.queryComponent,
+		priority:       freq.priority,
+
+		ctx:    ctx,
+		cancel: cancel,
+
+		enqueue:  make(chan enqueueResult, 1),
+		response: make(chan *frontendv2pb.QueryResultRequest, 1),
 	}
 }
 
@@ -380,6 +380,8 @@ func (w *frontendSchedulerWorker) enqueueRequest(loop schedulerpb.SchedulerForFr
 		HttpRequest:     req.request,
 		FrontendAddress: w.frontendAddr,
 		StatsEnabled:    req.statsEnabled,
+		QueryComponent:  req.queryComponent,
+		Priority:        int32(req.priority),
 	})
 	if err != nil {
 		level.Warn(spanLogger).Log("msg", "received error while sending request to scheduler", "err", err)
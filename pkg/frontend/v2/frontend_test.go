@@ -7,6 +7,7 @@ package v2
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
@@ -25,6 +26,7 @@ import (
 	"github.com/grafana/dskit/test"
 	"github.com/grafana/dskit/user"
 	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,8 +35,10 @@ import (
 	"google.golang.org/grpc/keepalive"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/grafana/mimir/pkg/frontend/siblings"
 	"github.com/grafana/mimir/pkg/frontend/v2/frontendv2pb"
 	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/scheduler/queue"
 	"github.com/grafana/mimir/pkg/scheduler/schedulerdiscovery"
 	"github.com/grafana/mimir/pkg/scheduler/schedulerpb"
 	"github.com/grafana/mimir/pkg/util/servicediscovery"
@@ -66,7 +70,7 @@ func setupFrontendWithConcurrencyAndServerOptions(t *testing.T, reg prometheus.R
 	cfg.Port = grpcPort
 
 	logger := log.NewLogfmtLogger(os.Stdout)
-	f, err := NewFrontend(cfg, logger, reg)
+	f, err := NewFrontend(cfg, limits{}, logger, reg)
 	require.NoError(t, err)
 
 	frontendv2pb.RegisterFrontendForQuerierServer(server, f)
@@ -135,6 +139,141 @@ func TestFrontendBasicWorkflow(t *testing.T) {
 	require.Equal(t, []byte(body), resp.Body)
 }
 
+func TestFrontend_ShouldEnqueueRequestWithQueryComponent(t *testing.T) {
+	const userID = "test"
+
+	f, ms := setupFrontend(t, nil, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+		go sendResponseWithDelay(f, 100*time.Millisecond, userID, msg.QueryID, &httpgrpc.HTTPResponse{Code: 200})
+
+		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+	})
+
+	now := time.Now()
+	req := &httpgrpc.HTTPRequest{
+		Method: http.MethodGet,
+		Url:    fmt.Sprintf("/prometheus/api/v1/query_range?query=up&start=%d&end=%d&step=60", now.Add(-time.Hour).Unix(), now.Unix()),
+	}
+
+	_, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), userID), req)
+	require.NoError(t, err)
+
+	ms.checkWithLock(func() {
+		require.Len(t, ms.msgs, 1)
+		require.Equal(t, schedulerpb.ENQUEUE, ms.msgs[0].Type)
+		require.Equal(t, queue.QueryComponentIngesterAndStoreGateway, ms.msgs[0].QueryComponent)
+	})
+}
+
+func TestFrontend_ShouldHedgeStragglingSiblingRequests(t *testing.T) {
+	const (
+		userID          = "test"
+		fastRequests    = 4
+		slowRequests    = 2
+		slowRequestTime = time.Second
+	)
+
+	var (
+		enqueuedMx sync.Mutex
+		enqueued   = map[string]int{}
+	)
+
+	reg := prometheus.NewPedanticRegistry()
+	f, ms := setupFrontend(t, reg, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+		if msg.Type != schedulerpb.ENQUEUE {
+			return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+		}
+
+		enqueuedMx.Lock()
+		enqueued[msg.HttpRequest.Url]++
+		attempt := enqueued[msg.HttpRequest.Url]
+		enqueuedMx.Unlock()
+
+		// The first attempt of slow requests is straggling, while hedged requests are fast.
+		delay := 10 * time.Millisecond
+		if strings.HasPrefix(msg.HttpRequest.Url, "/slow") && attempt == 1 {
+			delay = slowRequestTime
+		}
+		go sendResponseWithDelay(f, delay, userID, msg.QueryID, &httpgrpc.HTTPResponse{Code: 200, Body: []byte(msg.HttpRequest.Url)})
+
+		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+	})
+	f.limits = limits{maxHedgedRequestsPerQuery: 1}
+	f.cfg.HedgingMinDelay = 50 * time.Millisecond
+
+	ctx := siblings.ContextWithRequests(user.InjectOrgID(context.Background(), userID))
+
+	var urls []string
+	for i := 0; i < fastRequests; i++ {
+		urls = append(urls, fmt.Sprintf("/fast-%d", i))
+	}
+	for i := 0; i < slowRequests; i++ {
+		urls = append(urls, fmt.Sprintf("/slow-%d", i))
+	}
+
+	wg := sync.WaitGroup{}
+	for _, url := range urls {
+		url := url
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			resp, err := f.RoundTripGRPC(ctx, &httpgrpc.HTTPRequest{Method: http.MethodGet, Url: url})
+			require.NoError(t, err)
+			require.Equal(t, int32(200), resp.Code)
+			require.Equal(t, url, string(resp.Body))
+		}()
+	}
+	wg.Wait()
+
+	// Only one of the slow requests has been hedged, because of the hedge budget.
+	enqueuedMx.Lock()
+	assert.Equal(t, 1, enqueued["/slow-0"]+enqueued["/slow-1"]-slowRequests)
+	enqueuedMx.Unlock()
+
+	// The straggling original request has been cancelled.
+	test.Poll(t, time.Second, 1, func() interface{} {
+		cancelled := 0
+		ms.checkWithLock(func() {
+			for _, msg := range ms.msgs {
+				if msg.Type == schedulerpb.CANCEL {
+					cancelled++
+				}
+			}
+		})
+		return cancelled
+	})
+
+	require.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_frontend_hedged_requests_total Total number of hedged requests enqueued because the original request was straggling compared to its sibling requests.
+		# TYPE cortex_query_frontend_hedged_requests_total counter
+		cortex_query_frontend_hedged_requests_total 1
+
+		# HELP cortex_query_frontend_hedged_requests_won_total Total number of hedged requests which completed successfully before the original request.
+		# TYPE cortex_query_frontend_hedged_requests_won_total counter
+		cortex_query_frontend_hedged_requests_won_total 1
+	`), "cortex_query_frontend_hedged_requests_total", "cortex_query_frontend_hedged_requests_won_total"))
+}
+
+func TestFrontend_ShouldNotHedgeRequestsWithoutSiblings(t *testing.T) {
+	const userID = "test"
+
+	f, ms := setupFrontend(t, nil, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+		go sendResponseWithDelay(f, 200*time.Millisecond, userID, msg.QueryID, &httpgrpc.HTTPResponse{Code: 200})
+
+		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+	})
+	f.limits = limits{maxHedgedRequestsPerQuery: 1}
+	f.cfg.HedgingMinDelay = 0
+
+	_, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), userID), &httpgrpc.HTTPRequest{})
+	require.NoError(t, err)
+
+	ms.checkWithLock(func() {
+		require.Len(t, ms.msgs, 1)
+	})
+}
+
 func TestFrontend_ShouldTrackPerRequestMetrics(t *testing.T) {
 	const (
 		body   = "all fine here"
@@ -419,6 +558,12 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectedErr: `scheduler address cannot be specified when query-scheduler service discovery mode is set to 'ring'`,
 		},
+		"should fail if the hedging straggler multiplier is lower than 1": {
+			setup: func(cfg *Config) {
+				cfg.HedgingStragglerMultiplier = 0.5
+			},
+			expectedErr: errInvalidHedgingStragglerMultiplier.Error(),
+		},
 	}
 
 	for testName, testData := range tests {
@@ -487,6 +632,23 @@ func checkStreamGoroutines() int {
 	return strings.Count(goroutineStacks, streamGoroutineStackFrameTrailer)
 }
 
+type limits struct {
+	queryIngestersWithin      time.Duration
+	maxHedgedRequestsPerQuery int
+}
+
+func (l limits) QueryIngestersWithin(_ string) time.Duration {
+	return l.queryIngestersWithin
+}
+
+func (l limits) AllowedQueryPriorityClasses(_ string) []string {
+	return nil
+}
+
+func (l limits) MaxHedgedRequestsPerQuery(_ string) int {
+	return l.maxHedgedRequestsPerQuery
+}
+
 func makeLabels(namesAndValues ...string) []*dto.LabelPair {
 	out := []*dto.LabelPair(nil)
 
@@ -58,6 +58,7 @@ import (
 	"github.com/grafana/mimir/pkg/ingester/client"
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/querier"
+	"github.com/grafana/mimir/pkg/querier/clusterfederation"
 	"github.com/grafana/mimir/pkg/querier/tenantfederation"
 	querier_worker "github.com/grafana/mimir/pkg/querier/worker"
 	"github.com/grafana/mimir/pkg/ruler"
@@ -109,22 +110,23 @@ type Config struct {
 	PrintConfig                     bool                   `yaml:"-"`
 	ApplicationName                 string                 `yaml:"-"`
 
-	API              api.Config                      `yaml:"api"`
-	Server           server.Config                   `yaml:"server"`
-	Distributor      distributor.Config              `yaml:"distributor"`
-	Querier          querier.Config                  `yaml:"querier"`
-	IngesterClient   client.Config                   `yaml:"ingester_client"`
-	Ingester         ingester.Config                 `yaml:"ingester"`
-	Flusher          flusher.Config                  `yaml:"flusher"`
-	LimitsConfig     validation.Limits               `yaml:"limits"`
-	Worker           querier_worker.Config           `yaml:"frontend_worker"`
-	Frontend         frontend.CombinedFrontendConfig `yaml:"frontend"`
-	BlocksStorage    tsdb.BlocksStorageConfig        `yaml:"blocks_storage"`
-	Compactor        compactor.Config                `yaml:"compactor"`
-	StoreGateway     storegateway.Config             `yaml:"store_gateway"`
-	TenantFederation tenantfederation.Config         `yaml:"tenant_federation"`
-	ActivityTracker  activitytracker.Config          `yaml:"activity_tracker"`
-	Vault            vault.Config                    `yaml:"vault"`
+	API               api.Config                      `yaml:"api"`
+	Server            server.Config                   `yaml:"server"`
+	Distributor       distributor.Config              `yaml:"distributor"`
+	Querier           querier.Config                  `yaml:"querier"`
+	IngesterClient    client.Config                   `yaml:"ingester_client"`
+	Ingester          ingester.Config                 `yaml:"ingester"`
+	Flusher           flusher.Config                  `yaml:"flusher"`
+	LimitsConfig      validation.Limits               `yaml:"limits"`
+	Worker            querier_worker.Config           `yaml:"frontend_worker"`
+	Frontend          frontend.CombinedFrontendConfig `yaml:"frontend"`
+	BlocksStorage     tsdb.BlocksStorageConfig        `yaml:"blocks_storage"`
+	Compactor         compactor.Config                `yaml:"compactor"`
+	StoreGateway      storegateway.Config             `yaml:"store_gateway"`
+	TenantFederation  tenantfederation.Config         `yaml:"tenant_federation"`
+	ClusterFederation clusterfederation.Config        `yaml:"cluster_federation"`
+	ActivityTracker   activitytracker.Config          `yaml:"activity_tracker"`
+	Vault             vault.Config                    `yaml:"vault"`
 
 	Ruler               ruler.Config                               `yaml:"ruler"`
 	RulerStorage        rulestore.Config                           `yaml:"ruler_storage"`
@@ -179,6 +181,7 @@ func (c *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	c.Compactor.RegisterFlags(f, logger)
 	c.StoreGateway.RegisterFlags(f, logger)
 	c.TenantFederation.RegisterFlags(f)
+	c.ClusterFederation.RegisterFlags(f)
 
 	c.Ruler.RegisterFlags(f, logger)
 	c.RulerStorage.RegisterFlags(f)
@@ -244,6 +247,9 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.Querier.Validate(); err != nil {
 		return errors.Wrap(err, "invalid querier config")
 	}
+	if err := c.ClusterFederation.Validate(); err != nil {
+		return errors.Wrap(err, "invalid cluster federation config")
+	}
 	if c.Querier.EngineConfig.Timeout > c.Server.HTTPServerWriteTimeout {
 		return fmt.Errorf("querier timeout (%s) must be lower than or equal to HTTP server write timeout (%s)",
 			c.Querier.EngineConfig.Timeout, c.Server.HTTPServerWriteTimeout)
@@ -329,6 +335,11 @@ func (c *Config) validateBucketConfigs() error {
 		errs.Add(errors.Wrap(validateBucketConfig(c.RulerStorage.Config, c.BlocksStorage.Bucket), "ruler storage"))
 	}
 
+	// Validate query-frontend query log bucket config.
+	if c.isAnyModuleEnabled(All, QueryFrontend, Read) && c.Frontend.Handler.QueryLog.FilePath != "" && c.Frontend.Handler.QueryLog.UploadEnabled {
+		errs.Add(errors.Wrap(validateBucketConfig(c.Frontend.Handler.QueryLog.Storage, c.BlocksStorage.Bucket), "query-frontend query log storage"))
+	}
+
 	return errs.Err()
 }
 
@@ -726,6 +737,10 @@ func New(cfg Config, reg prometheus.Registerer) (*Mimir, error) {
 		}
 	}
 
+	if cfg.ClusterFederation.Enabled {
+		util_log.WarnExperimentalUse("cluster-federation")
+	}
+
 	cfg.API.HTTPAuthMiddleware = noauth.SetupAuthMiddleware(&cfg.Server, cfg.MultitenancyEnabled,
 		// Also don't check auth for these gRPC methods, since single call is used for multiple users (or no user like health check).
 		[]string{
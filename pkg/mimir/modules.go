@@ -33,6 +33,7 @@ import (
 	"github.com/prometheus/prometheus/rules"
 	prom_storage "github.com/prometheus/prometheus/storage"
 	prom_remote "github.com/prometheus/prometheus/storage/remote"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
 
 	"github.com/grafana/mimir/pkg/alertmanager"
 	"github.com/grafana/mimir/pkg/alertmanager/alertstore"
@@ -45,6 +46,7 @@ import (
 	"github.com/grafana/mimir/pkg/frontend/transport"
 	"github.com/grafana/mimir/pkg/ingester"
 	"github.com/grafana/mimir/pkg/querier"
+	"github.com/grafana/mimir/pkg/querier/clusterfederation"
 	"github.com/grafana/mimir/pkg/querier/engine"
 	"github.com/grafana/mimir/pkg/querier/tenantfederation"
 	querier_worker "github.com/grafana/mimir/pkg/querier/worker"
@@ -93,6 +95,7 @@ const (
 	QueryScheduler             string = "query-scheduler"
 	Vault                      string = "vault"
 	TenantFederation           string = "tenant-federation"
+	ClusterFederation          string = "cluster-federation"
 	UsageStats                 string = "usage-stats"
 	All                        string = "all"
 
@@ -471,13 +474,22 @@ func (t *Mimir) initTenantFederation() (serv services.Service, err error) {
 		// single tenant. This allows for a less impactful enabling of tenant
 		// federation.
 		const bypassForSingleQuerier = true
-		t.QuerierQueryable = querier.NewSampleAndChunkQueryable(tenantfederation.NewQueryable(t.QuerierQueryable, bypassForSingleQuerier, t.Cfg.TenantFederation.MaxConcurrent, util_log.Logger))
+		t.QuerierQueryable = querier.NewSampleAndChunkQueryable(tenantfederation.NewQueryable(t.QuerierQueryable, bypassForSingleQuerier, t.Cfg.TenantFederation.AllowPartialResults, t.Cfg.TenantFederation.MaxConcurrent, util_log.Logger))
 		t.ExemplarQueryable = tenantfederation.NewExemplarQueryable(t.ExemplarQueryable, bypassForSingleQuerier, t.Cfg.TenantFederation.MaxConcurrent, util_log.Logger)
 		t.MetadataSupplier = tenantfederation.NewMetadataSupplier(t.MetadataSupplier, t.Cfg.TenantFederation.MaxConcurrent, util_log.Logger)
 	}
 	return nil, nil
 }
 
+// Enable the cluster federation querier if querying remote Mimir clusters is enabled.
+// Only series and label queries are federated across clusters.
+func (t *Mimir) initClusterFederation() (serv services.Service, err error) {
+	if t.Cfg.ClusterFederation.Enabled {
+		t.QuerierQueryable = querier.NewSampleAndChunkQueryable(clusterfederation.NewQueryable(t.Cfg.ClusterFederation, t.QuerierQueryable, t.Overrides, t.Registerer, util_log.Logger))
+	}
+	return nil, nil
+}
+
 // initQuerier registers an internal HTTP router with a Prometheus API backed by the
 // Mimir Queryable. Then it does one of the following:
 //
@@ -533,18 +545,33 @@ func (t *Mimir) initQuerier() (serv services.Service, err error) {
 
 	// Create an internal HTTP handler that is configured with the Prometheus API routes and points
 	// to a Prometheus API struct instantiated with the Mimir Queryable.
+	// The cardinality analysis on blocks is served by the store-gateways through the blocks store queryable.
+	blocksCardinalityAnalyzer, _ := t.StoreQueryable.(querier.BlocksCardinalityAnalyzer)
+
+	// The aggregation pushdown wraps the PromQL engine to evaluate the supported queries on the store-gateways.
+	engine := v1.QueryEngine(t.QuerierEngine)
+	if blocksStoreQueryable, ok := t.StoreQueryable.(*querier.BlocksStoreQueryable); ok && t.Cfg.Querier.AggregationPushdownEnabled {
+		engine = querier.NewAggregationPushdownEngine(t.QuerierEngine, blocksStoreQueryable, t.Cfg.Querier, t.Overrides, util_log.Logger, t.Registerer)
+	}
+
 	internalQuerierRouter := api.NewQuerierHandler(
 		t.Cfg.API,
 		t.QuerierQueryable,
 		t.ExemplarQueryable,
 		t.MetadataSupplier,
-		t.QuerierEngine,
+		engine,
 		t.Distributor,
+		blocksCardinalityAnalyzer,
 		t.Registerer,
 		util_log.Logger,
 		t.Overrides,
 	)
 
+	// The requests sent by remote clusters are refused when cluster federation is enabled, to not loop between clusters.
+	if t.Cfg.ClusterFederation.Enabled {
+		internalQuerierRouter = clusterfederation.RejectFederatedRequests(internalQuerierRouter)
+	}
+
 	// If the querier is running standalone without the query-frontend or query-scheduler, we must register it's internal
 	// HTTP handler externally and provide the external Mimir Server HTTP handler to the frontend worker
 	// to ensure requests it processes use the default middleware instrumentation.
@@ -675,6 +702,8 @@ func (t *Mimir) initQueryFrontendTripperware() (serv services.Service, err error
 
 func (t *Mimir) initQueryFrontend() (serv services.Service, err error) {
 	t.Cfg.Frontend.FrontendV2.QuerySchedulerDiscovery = t.Cfg.QueryScheduler.ServiceDiscovery
+	t.Cfg.Frontend.FrontendV1.QueryStoreAfter = t.Cfg.Querier.QueryStoreAfter
+	t.Cfg.Frontend.FrontendV2.QueryStoreAfter = t.Cfg.Querier.QueryStoreAfter
 
 	roundTripper, frontendV1, frontendV2, err := frontend.InitFrontend(t.Cfg.Frontend, t.Overrides, t.Cfg.Server.GRPCListenPort, util_log.Logger, t.Registerer)
 	if err != nil {
@@ -684,7 +713,10 @@ func (t *Mimir) initQueryFrontend() (serv services.Service, err error) {
 	// Wrap roundtripper into Tripperware.
 	roundTripper = t.QueryFrontendTripperware(roundTripper)
 
-	handler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, util_log.Logger, t.Registerer, t.ActivityTracker)
+	handler, err := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, t.Overrides, util_log.Logger, t.Registerer, t.ActivityTracker)
+	if err != nil {
+		return nil, err
+	}
 	t.API.RegisterQueryFrontendHandler(handler, t.BuildInfoHandler)
 
 	var frontendSvc services.Service
@@ -755,7 +787,11 @@ func (t *Mimir) initRuler() (serv services.Service, err error) {
 		if err != nil {
 			return nil, err
 		}
-		remoteQuerier := ruler.NewRemoteQuerier(queryFrontendClient, t.Cfg.Querier.EngineConfig.Timeout, t.Cfg.Ruler.QueryFrontend.QueryResultResponseFormat, t.Cfg.API.PrometheusHTTPPrefix, util_log.Logger, ruler.WithOrgIDMiddleware)
+		middlewares := []ruler.Middleware{ruler.WithOrgIDMiddleware}
+		if t.Cfg.Ruler.QueryFrontend.QueryPriorityClass != "" {
+			middlewares = append(middlewares, ruler.WithQueryPriorityClassMiddleware(t.Cfg.Ruler.QueryFrontend.QueryPriorityClass))
+		}
+		remoteQuerier := ruler.NewRemoteQuerier(queryFrontendClient, t.Cfg.Querier.EngineConfig.Timeout, t.Cfg.Ruler.QueryFrontend.QueryResultResponseFormat, t.Cfg.API.PrometheusHTTPPrefix, util_log.Logger, middlewares...)
 
 		embeddedQueryable = prom_remote.NewSampleAndChunkQueryableClient(
 			remoteQuerier,
@@ -782,8 +818,10 @@ func (t *Mimir) initRuler() (serv services.Service, err error) {
 			// the `__tenant_id__` label on all metrics regardless if they're for a single tenant or multiple tenants.
 			// This makes this label more consistent and hopefully less confusing to users.
 			const bypassForSingleQuerier = false
+			// Rules are never evaluated on partial results, because missing series could make alerts resolve or fire.
+			const allowPartialResults = false
 
-			federatedQueryable = tenantfederation.NewQueryable(queryable, bypassForSingleQuerier, t.Cfg.TenantFederation.MaxConcurrent, util_log.Logger)
+			federatedQueryable = tenantfederation.NewQueryable(queryable, bypassForSingleQuerier, allowPartialResults, t.Cfg.TenantFederation.MaxConcurrent, util_log.Logger)
 
 			regularQueryFunc := rules.EngineQueryFunc(eng, queryable)
 			federatedQueryFunc := rules.EngineQueryFunc(eng, federatedQueryable)
@@ -993,6 +1031,7 @@ func (t *Mimir) setupModuleManager() error {
 	mm.RegisterModule(StoreGateway, t.initStoreGateway)
 	mm.RegisterModule(QueryScheduler, t.initQueryScheduler)
 	mm.RegisterModule(TenantFederation, t.initTenantFederation, modules.UserInvisibleModule)
+	mm.RegisterModule(ClusterFederation, t.initClusterFederation, modules.UserInvisibleModule)
 	mm.RegisterModule(UsageStats, t.initUsageStats, modules.UserInvisibleModule)
 	mm.RegisterModule(Vault, t.initVault, modules.UserInvisibleModule)
 	mm.RegisterModule(Write, nil)
@@ -1015,7 +1054,7 @@ func (t *Mimir) setupModuleManager() error {
 		IngesterService:          {Overrides, RuntimeConfig, MemberlistKV},
 		Flusher:                  {Overrides, API},
 		Queryable:                {Overrides, DistributorService, Ring, API, StoreQueryable, MemberlistKV},
-		Querier:                  {TenantFederation, Vault},
+		Querier:                  {TenantFederation, ClusterFederation, Vault},
 		StoreQueryable:           {Overrides, MemberlistKV},
 		QueryFrontendTripperware: {API, Overrides},
 		QueryFrontend:            {QueryFrontendTripperware, MemberlistKV, Vault},
@@ -1026,6 +1065,7 @@ func (t *Mimir) setupModuleManager() error {
 		Compactor:                {API, MemberlistKV, Overrides, Vault},
 		StoreGateway:             {API, Overrides, MemberlistKV, Vault},
 		TenantFederation:         {Queryable},
+		ClusterFederation:        {TenantFederation},
 		Write:                    {Distributor, Ingester},
 		Read:                     {QueryFrontend, Querier},
 		Backend:                  {QueryScheduler, Ruler, StoreGateway, Compactor, AlertManager, OverridesExporter},
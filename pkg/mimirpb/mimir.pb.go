@@ -1252,6 +1252,8 @@ type QueryResponse struct {
 	//	*QueryResponse_Scalar
 	//	*QueryResponse_Matrix
 	Data isQueryResponse_Data `protobuf_oneof:"data"`
+	// Query execution statistics, only set when requested by the client.
+	Stats *QueryStats `protobuf:"bytes,8,opt,name=stats,proto3" json:"stats,omitempty"`
 }
 
 func (m *QueryResponse) Reset()      { *m = QueryResponse{} }
@@ -1367,6 +1369,13 @@ func (m *QueryResponse) GetMatrix() *MatrixData {
 	return nil
 }
 
+func (m *QueryResponse) GetStats() *QueryStats {
+	if m != nil {
+		return m.Stats
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*QueryResponse) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
@@ -1753,6 +1762,188 @@ func (m *MatrixSeries) GetHistograms() []FloatHistogramPair {
 	return nil
 }
 
+// QueryStats holds the statistics about the execution of a query, similar to the ones returned by Prometheus.
+type QueryStats struct {
+	// The sum of all wall time spent in the queriers to execute the query.
+	WallTimeSeconds float64 `protobuf:"fixed64,1,opt,name=wall_time_seconds,json=wallTimeSeconds,proto3" json:"wallTimeSeconds"`
+	// The sum of all time spent by the query requests in the queue.
+	QueueTimeSeconds   float64 `protobuf:"fixed64,2,opt,name=queue_time_seconds,json=queueTimeSeconds,proto3" json:"queueTimeSeconds"`
+	FetchedSeriesCount uint64  `protobuf:"varint,3,opt,name=fetched_series_count,json=fetchedSeriesCount,proto3" json:"fetchedSeriesCount"`
+	FetchedChunksCount uint64  `protobuf:"varint,4,opt,name=fetched_chunks_count,json=fetchedChunksCount,proto3" json:"fetchedChunksCount"`
+	FetchedChunkBytes  uint64  `protobuf:"varint,5,opt,name=fetched_chunk_bytes,json=fetchedChunkBytes,proto3" json:"fetchedChunkBytes"`
+	FetchedIndexBytes  uint64  `protobuf:"varint,6,opt,name=fetched_index_bytes,json=fetchedIndexBytes,proto3" json:"fetchedIndexBytes"`
+	// The number of samples processed by the PromQL engine in the queriers.
+	SamplesProcessed uint64 `protobuf:"varint,7,opt,name=samples_processed,json=samplesProcessed,proto3" json:"samplesProcessed"`
+	ShardedQueries   uint32 `protobuf:"varint,8,opt,name=sharded_queries,json=shardedQueries,proto3" json:"shardedQueries"`
+	SplitQueries     uint32 `protobuf:"varint,9,opt,name=split_queries,json=splitQueries,proto3" json:"splitQueries"`
+	// The ratio of split queries fully or partially served from the results cache.
+	ResultsCacheHitRatio float64 `protobuf:"fixed64,10,opt,name=results_cache_hit_ratio,json=resultsCacheHitRatio,proto3" json:"resultsCacheHitRatio"`
+	// The number of samples processed by the PromQL engine in the queriers at each step, only set when per-step
+	// stats have been requested.
+	SamplesProcessedPerStep []QueryStepStat `protobuf:"bytes,11,rep,name=samples_processed_per_step,json=samplesProcessedPerStep,proto3" json:"samplesProcessedPerStep,omitempty"`
+}
+
+func (m *QueryStats) Reset()      { *m = QueryStats{} }
+func (*QueryStats) ProtoMessage() {}
+func (*QueryStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_86d4d7485f544059, []int{23}
+}
+func (m *QueryStats) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QueryStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QueryStats.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *QueryStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryStats.Merge(m, src)
+}
+func (m *QueryStats) XXX_Size() int {
+	return m.Size()
+}
+func (m *QueryStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryStats.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryStats proto.InternalMessageInfo
+
+func (m *QueryStats) GetWallTimeSeconds() float64 {
+	if m != nil {
+		return m.WallTimeSeconds
+	}
+	return 0
+}
+
+func (m *QueryStats) GetQueueTimeSeconds() float64 {
+	if m != nil {
+		return m.QueueTimeSeconds
+	}
+	return 0
+}
+
+func (m *QueryStats) GetFetchedSeriesCount() uint64 {
+	if m != nil {
+		return m.FetchedSeriesCount
+	}
+	return 0
+}
+
+func (m *QueryStats) GetFetchedChunksCount() uint64 {
+	if m != nil {
+		return m.FetchedChunksCount
+	}
+	return 0
+}
+
+func (m *QueryStats) GetFetchedChunkBytes() uint64 {
+	if m != nil {
+		return m.FetchedChunkBytes
+	}
+	return 0
+}
+
+func (m *QueryStats) GetFetchedIndexBytes() uint64 {
+	if m != nil {
+		return m.FetchedIndexBytes
+	}
+	return 0
+}
+
+func (m *QueryStats) GetSamplesProcessed() uint64 {
+	if m != nil {
+		return m.SamplesProcessed
+	}
+	return 0
+}
+
+func (m *QueryStats) GetShardedQueries() uint32 {
+	if m != nil {
+		return m.ShardedQueries
+	}
+	return 0
+}
+
+func (m *QueryStats) GetSplitQueries() uint32 {
+	if m != nil {
+		return m.SplitQueries
+	}
+	return 0
+}
+
+func (m *QueryStats) GetResultsCacheHitRatio() float64 {
+	if m != nil {
+		return m.ResultsCacheHitRatio
+	}
+	return 0
+}
+
+func (m *QueryStats) GetSamplesProcessedPerStep() []QueryStepStat {
+	if m != nil {
+		return m.SamplesProcessedPerStep
+	}
+	return nil
+}
+
+type QueryStepStat struct {
+	// The timestamp of the step, in milliseconds.
+	TimestampMs int64 `protobuf:"varint,1,opt,name=timestamp_ms,json=timestampMs,proto3" json:"timestampMs"`
+	Value       int64 `protobuf:"varint,2,opt,name=value,proto3" json:"value"`
+}
+
+func (m *QueryStepStat) Reset()      { *m = QueryStepStat{} }
+func (*QueryStepStat) ProtoMessage() {}
+func (*QueryStepStat) Descriptor() ([]byte, []int) {
+	return fileDescriptor_86d4d7485f544059, []int{24}
+}
+func (m *QueryStepStat) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *QueryStepStat) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_QueryStepStat.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *QueryStepStat) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryStepStat.Merge(m, src)
+}
+func (m *QueryStepStat) XXX_Size() int {
+	return m.Size()
+}
+func (m *QueryStepStat) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryStepStat.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryStepStat proto.InternalMessageInfo
+
+func (m *QueryStepStat) GetTimestampMs() int64 {
+	if m != nil {
+		return m.TimestampMs
+	}
+	return 0
+}
+
+func (m *QueryStepStat) GetValue() int64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterEnum("cortexpb.WriteRequest_SourceEnum", WriteRequest_SourceEnum_name, WriteRequest_SourceEnum_value)
 	proto.RegisterEnum("cortexpb.MetricMetadata_MetricType", MetricMetadata_MetricType_name, MetricMetadata_MetricType_value)
@@ -1782,122 +1973,147 @@ func init() {
 	proto.RegisterType((*ScalarData)(nil), "cortexpb.ScalarData")
 	proto.RegisterType((*MatrixData)(nil), "cortexpb.MatrixData")
 	proto.RegisterType((*MatrixSeries)(nil), "cortexpb.MatrixSeries")
+	proto.RegisterType((*QueryStats)(nil), "cortexpb.QueryStats")
+	proto.RegisterType((*QueryStepStat)(nil), "cortexpb.QueryStepStat")
 }
 
 func init() { proto.RegisterFile("mimir.proto", fileDescriptor_86d4d7485f544059) }
 
 var fileDescriptor_86d4d7485f544059 = []byte{
-	// 1758 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0xcf, 0x73, 0x1b, 0x49,
-	0x15, 0x56, 0x4b, 0x63, 0x49, 0xf3, 0x2c, 0xc9, 0xb3, 0xbd, 0xa9, 0xa0, 0x4d, 0x6d, 0x64, 0x67,
-	0x28, 0x16, 0x43, 0x81, 0x42, 0x65, 0x21, 0x5b, 0xbb, 0x15, 0x0a, 0x46, 0xf2, 0x24, 0xb6, 0xd7,
-	0x96, 0x4c, 0x4b, 0xca, 0xb2, 0x5c, 0x54, 0x23, 0xb9, 0x6d, 0x4d, 0xed, 0x8c, 0x66, 0x98, 0x19,
-	0x85, 0x98, 0x13, 0x17, 0x28, 0x8a, 0x13, 0x17, 0x2e, 0x14, 0x37, 0x0e, 0xf0, 0x17, 0xf0, 0x37,
-	0xa4, 0x8a, 0xa2, 0x2a, 0xc7, 0x2d, 0x0e, 0x29, 0xe2, 0x5c, 0xf6, 0x98, 0x03, 0x27, 0x4e, 0x54,
-	0xbf, 0x9e, 0x1f, 0x1a, 0xd9, 0x86, 0xc0, 0xfa, 0x36, 0xfd, 0xfa, 0x7b, 0xaf, 0xbf, 0x7e, 0xfd,
-	0xf5, 0xd3, 0x6b, 0xc1, 0xba, 0x6b, 0xbb, 0x76, 0xd0, 0xf6, 0x03, 0x2f, 0xf2, 0x68, 0x75, 0xea,
-	0x05, 0x11, 0x7f, 0xea, 0x4f, 0x6e, 0x7d, 0xfb, 0xd4, 0x8e, 0x66, 0x8b, 0x49, 0x7b, 0xea, 0xb9,
-	0x77, 0x4f, 0xbd, 0x53, 0xef, 0x2e, 0x02, 0x26, 0x8b, 0x13, 0x1c, 0xe1, 0x00, 0xbf, 0xa4, 0xa3,
-	0xfe, 0x97, 0x22, 0xd4, 0x3e, 0x09, 0xec, 0x88, 0x33, 0xfe, 0xd3, 0x05, 0x0f, 0x23, 0x7a, 0x04,
-	0x10, 0xd9, 0x2e, 0x0f, 0x79, 0x60, 0xf3, 0xb0, 0x49, 0xb6, 0x4a, 0xdb, 0xeb, 0xf7, 0x6e, 0xb4,
-	0x93, 0xf0, 0xed, 0xa1, 0xed, 0xf2, 0x01, 0xce, 0x75, 0x6e, 0x3d, 0x7b, 0xb1, 0x59, 0xf8, 0xfb,
-	0x8b, 0x4d, 0x7a, 0x14, 0x70, 0xcb, 0x71, 0xbc, 0xe9, 0x30, 0xf5, 0x63, 0x4b, 0x31, 0xe8, 0x87,
-	0x50, 0x1e, 0x78, 0x8b, 0x60, 0xca, 0x9b, 0xc5, 0x2d, 0xb2, 0xdd, 0xb8, 0x77, 0x27, 0x8b, 0xb6,
-	0xbc, 0x72, 0x5b, 0x82, 0xcc, 0xf9, 0xc2, 0x65, 0xb1, 0x03, 0xfd, 0x08, 0xaa, 0x2e, 0x8f, 0xac,
-	0x63, 0x2b, 0xb2, 0x9a, 0x25, 0xa4, 0xd2, 0xcc, 0x9c, 0x0f, 0x79, 0x14, 0xd8, 0xd3, 0xc3, 0x78,
-	0xbe, 0xa3, 0x3c, 0x7b, 0xb1, 0x49, 0x58, 0x8a, 0xa7, 0x0f, 0xe0, 0x56, 0xf8, 0x99, 0xed, 0x8f,
-	0x1d, 0x6b, 0xc2, 0x9d, 0xf1, 0xdc, 0x72, 0xf9, 0xf8, 0x89, 0xe5, 0xd8, 0xc7, 0x56, 0x64, 0x7b,
-	0xf3, 0xe6, 0x17, 0x95, 0x2d, 0xb2, 0x5d, 0x65, 0x5f, 0x11, 0x90, 0x03, 0x81, 0xe8, 0x59, 0x2e,
-	0x7f, 0x9c, 0xce, 0xeb, 0x9b, 0x00, 0x19, 0x1f, 0x5a, 0x81, 0x92, 0x71, 0xb4, 0xa7, 0x15, 0x68,
-	0x15, 0x14, 0x36, 0x3a, 0x30, 0x35, 0xa2, 0x6f, 0x40, 0x3d, 0x66, 0x1f, 0xfa, 0xde, 0x3c, 0xe4,
-	0xfa, 0x3f, 0x09, 0x40, 0x96, 0x1d, 0x6a, 0x40, 0x19, 0x57, 0x4e, 0x72, 0xf8, 0x76, 0x46, 0x1c,
-	0xd7, 0x3b, 0xb2, 0xec, 0xa0, 0x73, 0x23, 0x4e, 0x61, 0x0d, 0x4d, 0xc6, 0xb1, 0xe5, 0x47, 0x3c,
-	0x60, 0xb1, 0x23, 0xfd, 0x0e, 0x54, 0x42, 0xcb, 0xf5, 0x1d, 0x1e, 0x36, 0x8b, 0x18, 0x43, 0xcb,
-	0x62, 0x0c, 0x70, 0x02, 0x37, 0x5d, 0x60, 0x09, 0x8c, 0xde, 0x07, 0x95, 0x3f, 0xe5, 0xae, 0xef,
-	0x58, 0x41, 0x18, 0x27, 0x8c, 0x66, 0x3e, 0x66, 0x3c, 0x15, 0x7b, 0x65, 0x50, 0xfa, 0x21, 0xc0,
-	0xcc, 0x0e, 0x23, 0xef, 0x34, 0xb0, 0xdc, 0xb0, 0xa9, 0xac, 0x12, 0xde, 0x4d, 0xe6, 0x62, 0xcf,
-	0x25, 0xb0, 0xfe, 0x3d, 0x50, 0xd3, 0xfd, 0x50, 0x0a, 0x8a, 0x48, 0x74, 0x93, 0x6c, 0x91, 0xed,
-	0x1a, 0xc3, 0x6f, 0x7a, 0x03, 0xd6, 0x9e, 0x58, 0xce, 0x42, 0x9e, 0x7e, 0x8d, 0xc9, 0x81, 0x6e,
-	0x40, 0x59, 0x6e, 0x81, 0xde, 0x81, 0x1a, 0x8a, 0x25, 0xb2, 0x5c, 0x7f, 0xec, 0x86, 0x08, 0x2b,
-	0xb1, 0xf5, 0xd4, 0x76, 0x18, 0x66, 0x21, 0x44, 0x5c, 0x92, 0x84, 0xf8, 0x7d, 0x11, 0x1a, 0x79,
-	0x0d, 0xd0, 0x0f, 0x40, 0x89, 0xce, 0x7c, 0x89, 0x6b, 0xdc, 0xfb, 0xea, 0x55, 0x5a, 0x89, 0x87,
-	0xc3, 0x33, 0x9f, 0x33, 0x74, 0xa0, 0xdf, 0x02, 0xea, 0xa2, 0x6d, 0x7c, 0x62, 0xb9, 0xb6, 0x73,
-	0x86, 0x7a, 0x41, 0x2a, 0x2a, 0xd3, 0xe4, 0xcc, 0x43, 0x9c, 0x10, 0x32, 0x11, 0xdb, 0x9c, 0x71,
-	0xc7, 0x6f, 0x2a, 0x38, 0x8f, 0xdf, 0xc2, 0xb6, 0x98, 0xdb, 0x51, 0x73, 0x4d, 0xda, 0xc4, 0xb7,
-	0x7e, 0x06, 0x90, 0xad, 0x44, 0xd7, 0xa1, 0x32, 0xea, 0x7d, 0xdc, 0xeb, 0x7f, 0xd2, 0xd3, 0x0a,
-	0x62, 0xd0, 0xed, 0x8f, 0x7a, 0x43, 0x93, 0x69, 0x84, 0xaa, 0xb0, 0xf6, 0xc8, 0x18, 0x3d, 0x32,
-	0xb5, 0x22, 0xad, 0x83, 0xba, 0xbb, 0x37, 0x18, 0xf6, 0x1f, 0x31, 0xe3, 0x50, 0x2b, 0x51, 0x0a,
-	0x0d, 0x9c, 0xc9, 0x6c, 0x8a, 0x70, 0x1d, 0x8c, 0x0e, 0x0f, 0x0d, 0xf6, 0xa9, 0xb6, 0x26, 0x04,
-	0xb9, 0xd7, 0x7b, 0xd8, 0xd7, 0xca, 0xb4, 0x06, 0xd5, 0xc1, 0xd0, 0x18, 0x9a, 0x03, 0x73, 0xa8,
-	0x55, 0xf4, 0x8f, 0xa1, 0x2c, 0x97, 0xbe, 0x06, 0x21, 0xea, 0xbf, 0x22, 0x50, 0x4d, 0xc4, 0x73,
-	0x1d, 0xc2, 0xce, 0x49, 0x22, 0x39, 0xcf, 0x0b, 0x42, 0x28, 0x5d, 0x10, 0x82, 0xfe, 0xd7, 0x35,
-	0x50, 0x53, 0x31, 0xd2, 0xdb, 0xa0, 0x4e, 0xbd, 0xc5, 0x3c, 0x1a, 0xdb, 0xf3, 0x08, 0x8f, 0x5c,
-	0xd9, 0x2d, 0xb0, 0x2a, 0x9a, 0xf6, 0xe6, 0x11, 0xbd, 0x03, 0xeb, 0x72, 0xfa, 0xc4, 0xf1, 0xac,
-	0x48, 0xae, 0xb5, 0x5b, 0x60, 0x80, 0xc6, 0x87, 0xc2, 0x46, 0x35, 0x28, 0x85, 0x0b, 0x17, 0x57,
-	0x22, 0x4c, 0x7c, 0xd2, 0x9b, 0x50, 0x0e, 0xa7, 0x33, 0xee, 0x5a, 0x78, 0xb8, 0x6f, 0xb1, 0x78,
-	0x44, 0xbf, 0x06, 0x8d, 0x9f, 0xf3, 0xc0, 0x1b, 0x47, 0xb3, 0x80, 0x87, 0x33, 0xcf, 0x39, 0xc6,
-	0x83, 0x26, 0xac, 0x2e, 0xac, 0xc3, 0xc4, 0x48, 0xdf, 0x8b, 0x61, 0x19, 0xaf, 0x32, 0xf2, 0x22,
-	0xac, 0x26, 0xec, 0xdd, 0x84, 0xdb, 0x37, 0x41, 0x5b, 0xc2, 0x49, 0x82, 0x15, 0x24, 0x48, 0x58,
-	0x23, 0x45, 0x4a, 0x92, 0x06, 0x34, 0xe6, 0xfc, 0xd4, 0x8a, 0xec, 0x27, 0x7c, 0x1c, 0xfa, 0xd6,
-	0x3c, 0x6c, 0x56, 0x57, 0xab, 0x72, 0x67, 0x31, 0xfd, 0x8c, 0x47, 0x03, 0xdf, 0x9a, 0xc7, 0x37,
-	0xb4, 0x9e, 0x78, 0x08, 0x5b, 0x48, 0xbf, 0x0e, 0x1b, 0x69, 0x88, 0x63, 0xee, 0x44, 0x56, 0xd8,
-	0x54, 0xb7, 0x4a, 0xdb, 0x94, 0xa5, 0x91, 0x77, 0xd0, 0x9a, 0x03, 0x22, 0xb7, 0xb0, 0x09, 0x5b,
-	0xa5, 0x6d, 0x92, 0x01, 0x91, 0x98, 0x28, 0x6f, 0x0d, 0xdf, 0x0b, 0xed, 0x25, 0x52, 0xeb, 0xff,
-	0x9d, 0x54, 0xe2, 0x91, 0x92, 0x4a, 0x43, 0xc4, 0xa4, 0x6a, 0x92, 0x54, 0x62, 0xce, 0x48, 0xa5,
-	0xc0, 0x98, 0x54, 0x5d, 0x92, 0x4a, 0xcc, 0x31, 0xa9, 0x07, 0x00, 0x01, 0x0f, 0x79, 0x34, 0x9e,
-	0x89, 0xcc, 0x37, 0xb0, 0x08, 0xdc, 0xbe, 0xa4, 0x8c, 0xb5, 0x99, 0x40, 0xed, 0xda, 0xf3, 0x88,
-	0xa9, 0x41, 0xf2, 0x49, 0xdf, 0x05, 0x35, 0xd5, 0x5a, 0x73, 0x03, 0xc5, 0x97, 0x19, 0xf4, 0x8f,
-	0x40, 0x4d, 0xbd, 0xf2, 0x57, 0xb9, 0x02, 0xa5, 0x4f, 0xcd, 0x81, 0x46, 0x68, 0x19, 0x8a, 0xbd,
-	0xbe, 0x56, 0xcc, 0xae, 0x73, 0xe9, 0x96, 0xf2, 0xeb, 0x3f, 0xb6, 0x48, 0xa7, 0x02, 0x6b, 0xc8,
-	0xbb, 0x53, 0x03, 0xc8, 0x8e, 0x5d, 0xff, 0x9b, 0x02, 0x0d, 0x3c, 0xe2, 0x4c, 0xd2, 0x21, 0x50,
-	0x9c, 0xe3, 0xc1, 0x78, 0x65, 0x27, 0xf5, 0x8e, 0xf9, 0xaf, 0x17, 0x9b, 0xc6, 0xd2, 0xaf, 0xbb,
-	0x1f, 0x78, 0x2e, 0x8f, 0x66, 0x7c, 0x11, 0x2e, 0x7f, 0xba, 0xde, 0x31, 0x77, 0xee, 0xa6, 0x05,
-	0xba, 0xdd, 0x95, 0xe1, 0xb2, 0x1d, 0x6b, 0xd3, 0x15, 0xcb, 0x97, 0xd5, 0xfc, 0xed, 0xe5, 0x4d,
-	0x49, 0x15, 0x33, 0x35, 0xd5, 0xb0, 0xb8, 0xec, 0x72, 0x26, 0xbe, 0xec, 0x38, 0xb8, 0xe4, 0xe6,
-	0x5d, 0x83, 0xa2, 0xae, 0xe1, 0xa6, 0x7c, 0x03, 0xb4, 0x94, 0xc5, 0x04, 0xb1, 0x89, 0xd8, 0x52,
-	0x0d, 0xca, 0x10, 0x08, 0x4d, 0x57, 0x4b, 0xa0, 0xf2, 0xb2, 0xa4, 0x77, 0x28, 0x86, 0xee, 0x2b,
-	0x55, 0xa2, 0x15, 0xf7, 0x95, 0x6a, 0x59, 0xab, 0xec, 0x2b, 0x55, 0x55, 0x83, 0x7d, 0xa5, 0x5a,
-	0xd3, 0xea, 0xfb, 0x4a, 0x75, 0x43, 0xd3, 0x58, 0x56, 0xc5, 0xd8, 0x4a, 0xf5, 0x60, 0xab, 0xd7,
-	0x96, 0xad, 0x5e, 0x99, 0x65, 0x89, 0x3e, 0x00, 0xc8, 0xb6, 0x27, 0x4e, 0xd5, 0x3b, 0x39, 0x09,
-	0xb9, 0x2c, 0x8d, 0x6f, 0xb1, 0x78, 0x24, 0xec, 0x0e, 0x9f, 0x9f, 0x46, 0x33, 0x3c, 0x90, 0x3a,
-	0x8b, 0x47, 0xfa, 0x02, 0x68, 0x5e, 0x8c, 0xf8, 0x8b, 0xfe, 0x06, 0xbf, 0xce, 0x0f, 0x40, 0x4d,
-	0xe5, 0x86, 0x6b, 0xe5, 0xba, 0xb4, 0x7c, 0xcc, 0xb8, 0x4b, 0xcb, 0x1c, 0xf4, 0x39, 0x6c, 0xc8,
-	0x46, 0x20, 0xbb, 0x04, 0xa9, 0x62, 0xc8, 0x25, 0x8a, 0x29, 0x66, 0x8a, 0x79, 0x1f, 0x2a, 0x49,
-	0xde, 0x65, 0xaf, 0xf3, 0xce, 0x65, 0x2d, 0x0b, 0x22, 0x58, 0x82, 0xd4, 0x43, 0xd8, 0x58, 0x99,
-	0xa3, 0x2d, 0x80, 0x89, 0xb7, 0x98, 0x1f, 0x5b, 0x71, 0xcb, 0x4b, 0xb6, 0xd7, 0xd8, 0x92, 0x45,
-	0xf0, 0x71, 0xbc, 0x9f, 0xf1, 0x20, 0x51, 0x30, 0x0e, 0x84, 0x75, 0xe1, 0xfb, 0x3c, 0x88, 0x35,
-	0x2c, 0x07, 0x19, 0x77, 0x65, 0x89, 0xbb, 0xee, 0xc0, 0xdb, 0x2b, 0x9b, 0xc4, 0xe4, 0xe6, 0x2a,
-	0x4e, 0x71, 0xa5, 0xe2, 0xd0, 0x0f, 0x2e, 0xe6, 0xf5, 0x9d, 0xd5, 0x06, 0x30, 0x8d, 0xb7, 0x9c,
-	0xd2, 0x3f, 0x29, 0x50, 0xff, 0xd1, 0x82, 0x07, 0x67, 0x49, 0x6f, 0x4a, 0xef, 0x43, 0x39, 0x8c,
-	0xac, 0x68, 0x11, 0xc6, 0x9d, 0x51, 0x2b, 0x8b, 0x93, 0x03, 0xb6, 0x07, 0x88, 0x62, 0x31, 0x9a,
-	0xfe, 0x10, 0x80, 0x07, 0x81, 0x17, 0x8c, 0xb1, 0xab, 0xba, 0xd0, 0xbe, 0xe7, 0x7d, 0x4d, 0x81,
-	0xc4, 0x9e, 0x4a, 0xe5, 0xc9, 0xa7, 0xc8, 0x07, 0x0e, 0x30, 0x4b, 0x2a, 0x93, 0x03, 0xda, 0x16,
-	0x7c, 0x02, 0x7b, 0x7e, 0x8a, 0x69, 0xca, 0x5d, 0xd0, 0x01, 0xda, 0x77, 0xac, 0xc8, 0xda, 0x2d,
-	0xb0, 0x18, 0x25, 0xf0, 0x4f, 0xf8, 0x34, 0xf2, 0x02, 0xac, 0x40, 0x39, 0xfc, 0x63, 0xb4, 0x27,
-	0x78, 0x89, 0xc2, 0xf8, 0x53, 0xcb, 0xb1, 0x02, 0xfc, 0xf9, 0xcd, 0xc7, 0x47, 0x7b, 0x1a, 0x1f,
-	0x47, 0x02, 0xef, 0x5a, 0x51, 0x60, 0x3f, 0xc5, 0xf2, 0x95, 0xc3, 0x1f, 0xa2, 0x3d, 0xc1, 0x4b,
-	0x94, 0xfe, 0x1e, 0x94, 0x65, 0xa6, 0x44, 0xad, 0x37, 0x19, 0xeb, 0x33, 0xd9, 0xd2, 0x0d, 0x46,
-	0xdd, 0xae, 0x39, 0x18, 0x68, 0x44, 0x16, 0x7e, 0xfd, 0x77, 0x04, 0xd4, 0x34, 0x2d, 0xa2, 0x57,
-	0xeb, 0xf5, 0x7b, 0xa6, 0x84, 0x0e, 0xf7, 0x0e, 0xcd, 0xfe, 0x68, 0xa8, 0x11, 0xd1, 0xb8, 0x75,
-	0x8d, 0x5e, 0xd7, 0x3c, 0x30, 0x77, 0x64, 0x03, 0x68, 0xfe, 0xd8, 0xec, 0x8e, 0x86, 0x7b, 0xfd,
-	0x9e, 0x56, 0x12, 0x93, 0x1d, 0x63, 0x67, 0xbc, 0x63, 0x0c, 0x0d, 0x4d, 0x11, 0xa3, 0x3d, 0xd1,
-	0x33, 0xf6, 0x8c, 0x03, 0x6d, 0x8d, 0x6e, 0xc0, 0xfa, 0xa8, 0x67, 0x3c, 0x36, 0xf6, 0x0e, 0x8c,
-	0xce, 0x81, 0xa9, 0x95, 0x85, 0x6f, 0xaf, 0x3f, 0x1c, 0x3f, 0xec, 0x8f, 0x7a, 0x3b, 0x5a, 0x45,
-	0x34, 0x8f, 0x62, 0x68, 0x74, 0xbb, 0xe6, 0xd1, 0x10, 0x21, 0xd5, 0xf8, 0x07, 0xa9, 0x0c, 0x8a,
-	0xe8, 0x83, 0x75, 0x13, 0x20, 0xcb, 0x77, 0xbe, 0xcd, 0x56, 0xaf, 0x6a, 0xcb, 0x2e, 0x56, 0x00,
-	0xfd, 0x97, 0x04, 0x20, 0x3b, 0x07, 0x7a, 0x3f, 0x7b, 0xb7, 0xc8, 0x16, 0xf1, 0xe6, 0xea, 0x71,
-	0x5d, 0xfe, 0x7a, 0xf9, 0x41, 0xee, 0x15, 0x52, 0x5c, 0xbd, 0xd2, 0xd2, 0xf5, 0x3f, 0xbd, 0x45,
-	0xc6, 0x50, 0x5b, 0x8e, 0x2f, 0x4a, 0x9d, 0xec, 0xdd, 0x91, 0x87, 0xca, 0xe2, 0xd1, 0xff, 0xdf,
-	0x7f, 0xfe, 0x86, 0xc0, 0xc6, 0x0a, 0x8d, 0x2b, 0x17, 0xc9, 0x95, 0xc5, 0xe2, 0x1b, 0x94, 0xc5,
-	0xc2, 0xd2, 0x1d, 0x7e, 0x13, 0x32, 0xe2, 0xf0, 0x52, 0x31, 0x5f, 0xfe, 0x46, 0x7a, 0x93, 0xc3,
-	0xeb, 0x00, 0x64, 0x1a, 0xa7, 0xdf, 0x85, 0x72, 0xee, 0xe9, 0x7f, 0x73, 0xf5, 0x26, 0xc4, 0x8f,
-	0x7f, 0x49, 0x38, 0xc6, 0xea, 0x7f, 0x20, 0x50, 0x5b, 0x9e, 0xbe, 0x32, 0x29, 0xff, 0xfb, 0x93,
-	0xb6, 0x93, 0x13, 0x85, 0xac, 0xf3, 0xef, 0x5e, 0x95, 0x47, 0x7c, 0x7b, 0x5c, 0xd0, 0x45, 0xe7,
-	0xfb, 0xcf, 0x5f, 0xb6, 0x0a, 0x9f, 0xbf, 0x6c, 0x15, 0x5e, 0xbf, 0x6c, 0x91, 0x5f, 0x9c, 0xb7,
-	0xc8, 0x9f, 0xcf, 0x5b, 0xe4, 0xd9, 0x79, 0x8b, 0x3c, 0x3f, 0x6f, 0x91, 0x7f, 0x9c, 0xb7, 0xc8,
-	0x17, 0xe7, 0xad, 0xc2, 0xeb, 0xf3, 0x16, 0xf9, 0xed, 0xab, 0x56, 0xe1, 0xf9, 0xab, 0x56, 0xe1,
-	0xf3, 0x57, 0xad, 0xc2, 0x4f, 0x2a, 0xf8, 0x07, 0x8b, 0x3f, 0x99, 0x94, 0xf1, 0xaf, 0x92, 0xf7,
-	0xff, 0x1d, 0x00, 0x00, 0xff, 0xff, 0x65, 0xcf, 0xac, 0x82, 0x72, 0x11, 0x00, 0x00,
+	// 2127 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0x4f, 0x73, 0xdb, 0xd6,
+	0x11, 0x27, 0x48, 0x88, 0x7f, 0x56, 0x14, 0x09, 0x3f, 0xab, 0x36, 0xe3, 0x49, 0x48, 0x19, 0x9d,
+	0xa6, 0x4a, 0x26, 0x55, 0x3a, 0x4e, 0xe3, 0x4c, 0x52, 0x77, 0x52, 0x92, 0x82, 0x2d, 0x39, 0x12,
+	0xa9, 0x3c, 0x52, 0x4e, 0xd3, 0x0b, 0x06, 0x22, 0x9f, 0x44, 0x8c, 0x01, 0x02, 0x06, 0x1e, 0x1c,
+	0xab, 0x97, 0xf6, 0xd2, 0x4e, 0xa7, 0xa7, 0x5e, 0x7a, 0xe9, 0xf4, 0xd6, 0x4b, 0x3f, 0x41, 0x4f,
+	0xfd, 0x00, 0x9e, 0xe9, 0x74, 0xc6, 0x33, 0xbd, 0x64, 0x7a, 0xe0, 0xd4, 0xf2, 0x25, 0xa3, 0x53,
+	0x0e, 0x3d, 0xf5, 0xd4, 0x79, 0xef, 0xe1, 0x3f, 0xa5, 0xd6, 0x6d, 0x7d, 0xc3, 0xee, 0xfe, 0x76,
+	0xb1, 0xd8, 0xf7, 0xdb, 0xc5, 0x02, 0xb0, 0x6a, 0x9b, 0xb6, 0xe9, 0x6d, 0xb9, 0x9e, 0x43, 0x1d,
+	0x54, 0x9d, 0x38, 0x1e, 0x25, 0x4f, 0xdc, 0xa3, 0x1b, 0xdf, 0x39, 0x31, 0xe9, 0x2c, 0x38, 0xda,
+	0x9a, 0x38, 0xf6, 0xbb, 0x27, 0xce, 0x89, 0xf3, 0x2e, 0x07, 0x1c, 0x05, 0xc7, 0x5c, 0xe2, 0x02,
+	0xbf, 0x12, 0x8e, 0xea, 0x1f, 0x8b, 0x50, 0xff, 0xcc, 0x33, 0x29, 0xc1, 0xe4, 0x51, 0x40, 0x7c,
+	0x8a, 0x0e, 0x00, 0xa8, 0x69, 0x13, 0x9f, 0x78, 0x26, 0xf1, 0x5b, 0xd2, 0x46, 0x69, 0x73, 0xf5,
+	0xd6, 0xfa, 0x56, 0x14, 0x7e, 0x6b, 0x6c, 0xda, 0x64, 0xc4, 0x6d, 0xbd, 0x1b, 0x4f, 0x17, 0x9d,
+	0xc2, 0xdf, 0x16, 0x1d, 0x74, 0xe0, 0x11, 0xc3, 0xb2, 0x9c, 0xc9, 0x38, 0xf6, 0xc3, 0xa9, 0x18,
+	0xe8, 0x43, 0x28, 0x8f, 0x9c, 0xc0, 0x9b, 0x90, 0x56, 0x71, 0x43, 0xda, 0x6c, 0xdc, 0xba, 0x99,
+	0x44, 0x4b, 0xdf, 0x79, 0x4b, 0x80, 0xb4, 0x79, 0x60, 0xe3, 0xd0, 0x01, 0x7d, 0x04, 0x55, 0x9b,
+	0x50, 0x63, 0x6a, 0x50, 0xa3, 0x55, 0xe2, 0xa9, 0xb4, 0x12, 0xe7, 0x7d, 0x42, 0x3d, 0x73, 0xb2,
+	0x1f, 0xda, 0x7b, 0xf2, 0xd3, 0x45, 0x47, 0xc2, 0x31, 0x1e, 0xdd, 0x81, 0x1b, 0xfe, 0x43, 0xd3,
+	0xd5, 0x2d, 0xe3, 0x88, 0x58, 0xfa, 0xdc, 0xb0, 0x89, 0xfe, 0xd8, 0xb0, 0xcc, 0xa9, 0x41, 0x4d,
+	0x67, 0xde, 0xfa, 0xaa, 0xb2, 0x21, 0x6d, 0x56, 0xf1, 0x75, 0x06, 0xd9, 0x63, 0x88, 0x81, 0x61,
+	0x93, 0x07, 0xb1, 0x5d, 0xed, 0x00, 0x24, 0xf9, 0xa0, 0x0a, 0x94, 0xba, 0x07, 0xbb, 0x4a, 0x01,
+	0x55, 0x41, 0xc6, 0x87, 0x7b, 0x9a, 0x22, 0xa9, 0x4d, 0x58, 0x0b, 0xb3, 0xf7, 0x5d, 0x67, 0xee,
+	0x13, 0xf5, 0x1f, 0x12, 0x40, 0x52, 0x1d, 0xd4, 0x85, 0x32, 0xbf, 0x73, 0x54, 0xc3, 0xab, 0x49,
+	0xe2, 0xfc, 0x7e, 0x07, 0x86, 0xe9, 0xf5, 0xd6, 0xc3, 0x12, 0xd6, 0xb9, 0xaa, 0x3b, 0x35, 0x5c,
+	0x4a, 0x3c, 0x1c, 0x3a, 0xa2, 0xef, 0x42, 0xc5, 0x37, 0x6c, 0xd7, 0x22, 0x7e, 0xab, 0xc8, 0x63,
+	0x28, 0x49, 0x8c, 0x11, 0x37, 0xf0, 0x87, 0x2e, 0xe0, 0x08, 0x86, 0x6e, 0x43, 0x8d, 0x3c, 0x21,
+	0xb6, 0x6b, 0x19, 0x9e, 0x1f, 0x16, 0x0c, 0x25, 0x3e, 0x5a, 0x68, 0x0a, 0xbd, 0x12, 0x28, 0xfa,
+	0x10, 0x60, 0x66, 0xfa, 0xd4, 0x39, 0xf1, 0x0c, 0xdb, 0x6f, 0xc9, 0xf9, 0x84, 0x77, 0x22, 0x5b,
+	0xe8, 0x99, 0x02, 0xab, 0xef, 0x43, 0x2d, 0x7e, 0x1e, 0x84, 0x40, 0x66, 0x85, 0x6e, 0x49, 0x1b,
+	0xd2, 0x66, 0x1d, 0xf3, 0x6b, 0xb4, 0x0e, 0x2b, 0x8f, 0x0d, 0x2b, 0x10, 0xa7, 0x5f, 0xc7, 0x42,
+	0x50, 0xbb, 0x50, 0x16, 0x8f, 0x80, 0x6e, 0x42, 0x9d, 0x93, 0x85, 0x1a, 0xb6, 0xab, 0xdb, 0x3e,
+	0x87, 0x95, 0xf0, 0x6a, 0xac, 0xdb, 0xf7, 0x93, 0x10, 0x2c, 0xae, 0x14, 0x85, 0xf8, 0x6d, 0x11,
+	0x1a, 0x59, 0x0e, 0xa0, 0x0f, 0x40, 0xa6, 0xa7, 0xae, 0xc0, 0x35, 0x6e, 0x7d, 0xf3, 0x32, 0xae,
+	0x84, 0xe2, 0xf8, 0xd4, 0x25, 0x98, 0x3b, 0xa0, 0x77, 0x00, 0xd9, 0x5c, 0xa7, 0x1f, 0x1b, 0xb6,
+	0x69, 0x9d, 0x72, 0xbe, 0xf0, 0x54, 0x6a, 0x58, 0x11, 0x96, 0xbb, 0xdc, 0xc0, 0x68, 0xc2, 0x1e,
+	0x73, 0x46, 0x2c, 0xb7, 0x25, 0x73, 0x3b, 0xbf, 0x66, 0xba, 0x60, 0x6e, 0xd2, 0xd6, 0x8a, 0xd0,
+	0xb1, 0x6b, 0xf5, 0x14, 0x20, 0xb9, 0x13, 0x5a, 0x85, 0xca, 0xe1, 0xe0, 0x93, 0xc1, 0xf0, 0xb3,
+	0x81, 0x52, 0x60, 0x42, 0x7f, 0x78, 0x38, 0x18, 0x6b, 0x58, 0x91, 0x50, 0x0d, 0x56, 0xee, 0x75,
+	0x0f, 0xef, 0x69, 0x4a, 0x11, 0xad, 0x41, 0x6d, 0x67, 0x77, 0x34, 0x1e, 0xde, 0xc3, 0xdd, 0x7d,
+	0xa5, 0x84, 0x10, 0x34, 0xb8, 0x25, 0xd1, 0xc9, 0xcc, 0x75, 0x74, 0xb8, 0xbf, 0xdf, 0xc5, 0x9f,
+	0x2b, 0x2b, 0x8c, 0x90, 0xbb, 0x83, 0xbb, 0x43, 0xa5, 0x8c, 0xea, 0x50, 0x1d, 0x8d, 0xbb, 0x63,
+	0x6d, 0xa4, 0x8d, 0x95, 0x8a, 0xfa, 0x09, 0x94, 0xc5, 0xad, 0x5f, 0x01, 0x11, 0xd5, 0x5f, 0x48,
+	0x50, 0x8d, 0xc8, 0xf3, 0x2a, 0x88, 0x9d, 0xa1, 0x44, 0x74, 0x9e, 0x4b, 0x44, 0x28, 0x2d, 0x11,
+	0x41, 0xfd, 0xf3, 0x0a, 0xd4, 0x62, 0x32, 0xa2, 0x37, 0xa0, 0x36, 0x71, 0x82, 0x39, 0xd5, 0xcd,
+	0x39, 0xe5, 0x47, 0x2e, 0xef, 0x14, 0x70, 0x95, 0xab, 0x76, 0xe7, 0x14, 0xdd, 0x84, 0x55, 0x61,
+	0x3e, 0xb6, 0x1c, 0x83, 0x8a, 0x7b, 0xed, 0x14, 0x30, 0x70, 0xe5, 0x5d, 0xa6, 0x43, 0x0a, 0x94,
+	0xfc, 0xc0, 0xe6, 0x77, 0x92, 0x30, 0xbb, 0x44, 0xd7, 0xa0, 0xec, 0x4f, 0x66, 0xc4, 0x36, 0xf8,
+	0xe1, 0x5e, 0xc1, 0xa1, 0x84, 0xbe, 0x05, 0x8d, 0x9f, 0x10, 0xcf, 0xd1, 0xe9, 0xcc, 0x23, 0xfe,
+	0xcc, 0xb1, 0xa6, 0xfc, 0xa0, 0x25, 0xbc, 0xc6, 0xb4, 0xe3, 0x48, 0x89, 0xde, 0x0c, 0x61, 0x49,
+	0x5e, 0x65, 0x9e, 0x97, 0x84, 0xeb, 0x4c, 0xdf, 0x8f, 0x72, 0x7b, 0x1b, 0x94, 0x14, 0x4e, 0x24,
+	0x58, 0xe1, 0x09, 0x4a, 0xb8, 0x11, 0x23, 0x45, 0x92, 0x5d, 0x68, 0xcc, 0xc9, 0x89, 0x41, 0xcd,
+	0xc7, 0x44, 0xf7, 0x5d, 0x63, 0xee, 0xb7, 0xaa, 0xf9, 0xa9, 0xdc, 0x0b, 0x26, 0x0f, 0x09, 0x1d,
+	0xb9, 0xc6, 0x3c, 0xec, 0xd0, 0xb5, 0xc8, 0x83, 0xe9, 0x7c, 0xf4, 0x6d, 0x68, 0xc6, 0x21, 0xa6,
+	0xc4, 0xa2, 0x86, 0xdf, 0xaa, 0x6d, 0x94, 0x36, 0x11, 0x8e, 0x23, 0x6f, 0x73, 0x6d, 0x06, 0xc8,
+	0x73, 0xf3, 0x5b, 0xb0, 0x51, 0xda, 0x94, 0x12, 0x20, 0x4f, 0x8c, 0x8d, 0xb7, 0x86, 0xeb, 0xf8,
+	0x66, 0x2a, 0xa9, 0xd5, 0xff, 0x9c, 0x54, 0xe4, 0x11, 0x27, 0x15, 0x87, 0x08, 0x93, 0xaa, 0x8b,
+	0xa4, 0x22, 0x75, 0x92, 0x54, 0x0c, 0x0c, 0x93, 0x5a, 0x13, 0x49, 0x45, 0xea, 0x30, 0xa9, 0x3b,
+	0x00, 0x1e, 0xf1, 0x09, 0xd5, 0x67, 0xac, 0xf2, 0x0d, 0x3e, 0x04, 0xde, 0xb8, 0x60, 0x8c, 0x6d,
+	0x61, 0x86, 0xda, 0x31, 0xe7, 0x14, 0xd7, 0xbc, 0xe8, 0x12, 0xbd, 0x0e, 0xb5, 0x98, 0x6b, 0xad,
+	0x26, 0x27, 0x5f, 0xa2, 0x50, 0x3f, 0x82, 0x5a, 0xec, 0x95, 0x6d, 0xe5, 0x0a, 0x94, 0x3e, 0xd7,
+	0x46, 0x8a, 0x84, 0xca, 0x50, 0x1c, 0x0c, 0x95, 0x62, 0xd2, 0xce, 0xa5, 0x1b, 0xf2, 0x2f, 0x7f,
+	0xdf, 0x96, 0x7a, 0x15, 0x58, 0xe1, 0x79, 0xf7, 0xea, 0x00, 0xc9, 0xb1, 0xab, 0x7f, 0x91, 0xa1,
+	0xc1, 0x8f, 0x38, 0xa1, 0xb4, 0x0f, 0x88, 0xdb, 0x88, 0xa7, 0xe7, 0x9e, 0x64, 0xad, 0xa7, 0xfd,
+	0x73, 0xd1, 0xe9, 0xa6, 0xde, 0xee, 0xae, 0xe7, 0xd8, 0x84, 0xce, 0x48, 0xe0, 0xa7, 0x2f, 0x6d,
+	0x67, 0x4a, 0xac, 0x77, 0xe3, 0x01, 0xbd, 0xd5, 0x17, 0xe1, 0x92, 0x27, 0x56, 0x26, 0x39, 0xcd,
+	0xff, 0xcb, 0xf9, 0x37, 0xd2, 0x0f, 0x25, 0x58, 0x8c, 0x6b, 0x31, 0x87, 0x59, 0xb3, 0x0b, 0x4b,
+	0xd8, 0xec, 0x5c, 0xb8, 0xa0, 0xf3, 0x5e, 0x01, 0xa3, 0x5e, 0x41, 0xa7, 0xbc, 0x05, 0x4a, 0x9c,
+	0xc5, 0x11, 0xc7, 0x46, 0x64, 0x8b, 0x39, 0x28, 0x42, 0x70, 0x68, 0x7c, 0xb7, 0x08, 0x2a, 0x9a,
+	0x25, 0xee, 0xa1, 0x10, 0x7a, 0x5f, 0xae, 0x4a, 0x4a, 0xf1, 0xbe, 0x5c, 0x2d, 0x2b, 0x95, 0xfb,
+	0x72, 0xb5, 0xa6, 0xc0, 0x7d, 0xb9, 0x5a, 0x57, 0xd6, 0xee, 0xcb, 0xd5, 0xa6, 0xa2, 0xe0, 0x64,
+	0x8a, 0xe1, 0xdc, 0xf4, 0xc0, 0xf9, 0xb6, 0xc5, 0xf9, 0x96, 0x49, 0x53, 0xf4, 0x0e, 0x40, 0xf2,
+	0x78, 0xec, 0x54, 0x9d, 0xe3, 0x63, 0x9f, 0x88, 0xd1, 0x78, 0x05, 0x87, 0x12, 0xd3, 0x5b, 0x64,
+	0x7e, 0x42, 0x67, 0xfc, 0x40, 0xd6, 0x70, 0x28, 0xa9, 0x01, 0xa0, 0x2c, 0x19, 0xf9, 0x1b, 0xfd,
+	0x25, 0xde, 0xce, 0x77, 0xa0, 0x16, 0xd3, 0x8d, 0xdf, 0x2b, 0xb3, 0xa5, 0x65, 0x63, 0x86, 0x5b,
+	0x5a, 0xe2, 0xa0, 0xce, 0xa1, 0x29, 0x16, 0x81, 0xa4, 0x09, 0x62, 0xc6, 0x48, 0x17, 0x30, 0xa6,
+	0x98, 0x30, 0xe6, 0x3d, 0xa8, 0x44, 0x75, 0x17, 0xbb, 0xce, 0x6b, 0x17, 0xad, 0x2c, 0x1c, 0x81,
+	0x23, 0xa4, 0xea, 0x43, 0x33, 0x67, 0x43, 0x6d, 0x80, 0x23, 0x27, 0x98, 0x4f, 0x8d, 0x70, 0xe5,
+	0x95, 0x36, 0x57, 0x70, 0x4a, 0xc3, 0xf2, 0xb1, 0x9c, 0x2f, 0x88, 0x17, 0x31, 0x98, 0x0b, 0x4c,
+	0x1b, 0xb8, 0x2e, 0xf1, 0x42, 0x0e, 0x0b, 0x21, 0xc9, 0x5d, 0x4e, 0xe5, 0xae, 0x5a, 0x70, 0x35,
+	0xf7, 0x90, 0xbc, 0xb8, 0x99, 0x89, 0x53, 0xcc, 0x4d, 0x1c, 0xf4, 0xc1, 0x72, 0x5d, 0x5f, 0xcb,
+	0x2f, 0x80, 0x71, 0xbc, 0x74, 0x49, 0xff, 0x2a, 0xc3, 0xda, 0xa7, 0x01, 0xf1, 0x4e, 0xa3, 0xdd,
+	0x14, 0xdd, 0x86, 0xb2, 0x4f, 0x0d, 0x1a, 0xf8, 0xe1, 0x66, 0xd4, 0x4e, 0xe2, 0x64, 0x80, 0x5b,
+	0x23, 0x8e, 0xc2, 0x21, 0x1a, 0xfd, 0x10, 0x80, 0x78, 0x9e, 0xe3, 0xe9, 0x7c, 0xab, 0x5a, 0x5a,
+	0xdf, 0xb3, 0xbe, 0x1a, 0x43, 0xf2, 0x9d, 0xaa, 0x46, 0xa2, 0x4b, 0x56, 0x0f, 0x2e, 0xf0, 0x2a,
+	0xd5, 0xb0, 0x10, 0xd0, 0x16, 0xcb, 0xc7, 0x33, 0xe7, 0x27, 0xbc, 0x4c, 0x99, 0x06, 0x1d, 0x71,
+	0xfd, 0xb6, 0x41, 0x8d, 0x9d, 0x02, 0x0e, 0x51, 0x0c, 0xff, 0x98, 0x4c, 0xa8, 0xe3, 0xb5, 0x56,
+	0xf2, 0xf8, 0x07, 0x5c, 0x1f, 0xe1, 0x05, 0x8a, 0xc7, 0x9f, 0x18, 0x96, 0xe1, 0xb5, 0xca, 0x79,
+	0xfc, 0x88, 0xeb, 0xe3, 0xf8, 0x5c, 0x62, 0x78, 0xdb, 0xa0, 0x9e, 0xf9, 0xa4, 0x55, 0xc9, 0xe3,
+	0xf7, 0xb9, 0x3e, 0xc2, 0x0b, 0x14, 0x7a, 0x1b, 0x56, 0x58, 0x85, 0xd8, 0x7c, 0xc9, 0xc1, 0x79,
+	0x49, 0x58, 0x15, 0x7d, 0x2c, 0x20, 0xea, 0x9b, 0x50, 0x16, 0x55, 0x65, 0xef, 0x05, 0x0d, 0xe3,
+	0x21, 0x16, 0xeb, 0xdf, 0xe8, 0xb0, 0xdf, 0xd7, 0x46, 0x23, 0x45, 0x12, 0x2f, 0x09, 0xf5, 0x37,
+	0x12, 0xd4, 0xe2, 0x12, 0xb2, 0xbd, 0x6e, 0x30, 0x1c, 0x68, 0x02, 0x3a, 0xde, 0xdd, 0xd7, 0x86,
+	0x87, 0x63, 0x45, 0x62, 0x4b, 0x5e, 0xbf, 0x3b, 0xe8, 0x6b, 0x7b, 0xda, 0xb6, 0x58, 0x16, 0xb5,
+	0x1f, 0x69, 0xfd, 0xc3, 0xf1, 0xee, 0x70, 0xa0, 0x94, 0x98, 0xb1, 0xd7, 0xdd, 0xd6, 0xb7, 0xbb,
+	0xe3, 0xae, 0x22, 0x33, 0x69, 0x97, 0xed, 0x97, 0x83, 0xee, 0x9e, 0xb2, 0x82, 0x9a, 0xb0, 0x7a,
+	0x38, 0xe8, 0x3e, 0xe8, 0xee, 0xee, 0x75, 0x7b, 0x7b, 0x9a, 0x52, 0x66, 0xbe, 0x83, 0xe1, 0x58,
+	0xbf, 0x3b, 0x3c, 0x1c, 0x6c, 0x2b, 0x15, 0xb6, 0x68, 0x32, 0xb1, 0xdb, 0xef, 0x6b, 0x07, 0x63,
+	0x0e, 0xa9, 0x86, 0x2f, 0xaf, 0x32, 0xc8, 0x6c, 0x67, 0x56, 0x35, 0x80, 0xe4, 0x6c, 0xb2, 0x2b,
+	0x79, 0xed, 0xb2, 0x15, 0x6e, 0x79, 0x5a, 0xa8, 0x3f, 0x97, 0x00, 0x92, 0x33, 0x43, 0xb7, 0x93,
+	0x6f, 0x1c, 0xb1, 0x4e, 0x5e, 0xcb, 0x1f, 0xed, 0xc5, 0x5f, 0x3a, 0x1f, 0x67, 0xbe, 0x58, 0x8a,
+	0xf9, 0xf6, 0x17, 0xae, 0xff, 0xee, 0xbb, 0x45, 0x87, 0x7a, 0x3a, 0x3e, 0x1b, 0x8b, 0x62, 0xcf,
+	0xe7, 0x79, 0xd4, 0x70, 0x28, 0xfd, 0xef, 0xbb, 0xea, 0xaf, 0x24, 0x68, 0xe6, 0xd2, 0xb8, 0xf4,
+	0x26, 0x99, 0x11, 0x5a, 0x7c, 0x89, 0x11, 0x5a, 0x48, 0xf5, 0xfb, 0xcb, 0x24, 0xc3, 0x0e, 0x2f,
+	0x26, 0xfe, 0xc5, 0xdf, 0x53, 0x2f, 0x73, 0x78, 0x3d, 0x80, 0xa4, 0x1f, 0xd0, 0xf7, 0xa0, 0x9c,
+	0xf9, 0x4d, 0x70, 0x2d, 0xdf, 0x35, 0xe1, 0x8f, 0x02, 0x91, 0x70, 0x88, 0x55, 0x7f, 0x27, 0x41,
+	0x3d, 0x6d, 0xbe, 0xb4, 0x28, 0xff, 0xfd, 0xe7, 0x6f, 0x2f, 0x43, 0x0a, 0xf1, 0x4e, 0x78, 0xfd,
+	0xb2, 0x3a, 0xf2, 0xef, 0x94, 0x65, 0x5e, 0xfc, 0xa9, 0x0c, 0x90, 0x34, 0x31, 0xfa, 0x18, 0xae,
+	0x7c, 0x61, 0x58, 0x96, 0xce, 0xaa, 0xa0, 0xfb, 0x64, 0xe2, 0xcc, 0xa7, 0x62, 0x88, 0x4a, 0xbd,
+	0xab, 0xe7, 0x8b, 0x4e, 0x93, 0x19, 0xc5, 0x57, 0x3f, 0x37, 0xe1, 0xbc, 0x02, 0xf5, 0x00, 0x3d,
+	0x0a, 0x48, 0x40, 0xb2, 0x11, 0x38, 0x99, 0x7a, 0xeb, 0xe7, 0x8b, 0x8e, 0xc2, 0xad, 0xe9, 0x10,
+	0x4b, 0x1a, 0xb4, 0x03, 0xeb, 0xc7, 0x84, 0x4e, 0x66, 0x64, 0xaa, 0x8b, 0x22, 0x86, 0xbb, 0x16,
+	0x3b, 0x68, 0xb9, 0x77, 0xed, 0x7c, 0xd1, 0x41, 0xa1, 0x5d, 0x94, 0x94, 0x2f, 0x5e, 0xf8, 0x02,
+	0x5d, 0x3a, 0xd2, 0x64, 0x16, 0xcc, 0x1f, 0x46, 0x91, 0xe4, 0xa5, 0x48, 0x7d, 0x6e, 0xce, 0x46,
+	0x4a, 0xe9, 0x90, 0x06, 0x57, 0x33, 0x91, 0xf4, 0xa3, 0x53, 0x4a, 0x7c, 0x3e, 0x9f, 0xe5, 0xde,
+	0x37, 0xce, 0x17, 0x9d, 0x2b, 0x69, 0xa7, 0x1e, 0x33, 0xe2, 0x65, 0x55, 0x3a, 0x8c, 0x39, 0x9f,
+	0x92, 0x27, 0x61, 0x98, 0xf2, 0x52, 0x98, 0x5d, 0x66, 0xcd, 0x86, 0x49, 0x54, 0xa8, 0x0b, 0x57,
+	0x42, 0x12, 0xe8, 0xae, 0xe7, 0x4c, 0x88, 0xef, 0x93, 0x29, 0x9f, 0xe5, 0xb2, 0x28, 0x72, 0x68,
+	0x3c, 0x88, 0x6c, 0x78, 0x49, 0x83, 0xbe, 0x0f, 0x4d, 0x7f, 0x66, 0x78, 0x53, 0x32, 0xd5, 0x1f,
+	0x05, 0x82, 0xd6, 0x55, 0xbe, 0x77, 0xa3, 0xf3, 0x45, 0xa7, 0x11, 0x9a, 0x3e, 0x15, 0x16, 0x9c,
+	0x93, 0xd1, 0xfb, 0xb0, 0xe6, 0xbb, 0x96, 0x49, 0x63, 0xd7, 0x1a, 0x77, 0x55, 0xce, 0x17, 0x9d,
+	0x3a, 0x37, 0x44, 0x8e, 0x19, 0x09, 0x0d, 0xe1, 0xba, 0x47, 0xfc, 0xc0, 0xa2, 0xbe, 0x3e, 0x31,
+	0x26, 0x33, 0xa2, 0xcf, 0x4c, 0xaa, 0x7b, 0x06, 0x35, 0x9d, 0x16, 0x70, 0x86, 0xb4, 0xce, 0x17,
+	0x9d, 0xf5, 0x10, 0xd2, 0x67, 0x88, 0x1d, 0x93, 0x62, 0x66, 0xc7, 0x17, 0x6a, 0xd1, 0x4f, 0xe1,
+	0xc6, 0x52, 0x1d, 0x74, 0x97, 0x78, 0xba, 0x4f, 0x89, 0x1b, 0x2e, 0xd4, 0xd7, 0x97, 0xde, 0x56,
+	0xc4, 0x65, 0x64, 0xef, 0xbd, 0xc5, 0x9a, 0xe1, 0x7c, 0xd1, 0xb9, 0x99, 0xaf, 0xcd, 0x01, 0xf1,
+	0x18, 0xe8, 0x1d, 0xc7, 0x36, 0x29, 0xb1, 0x5d, 0x7a, 0x8a, 0xaf, 0x5f, 0x02, 0x51, 0xa7, 0xe1,
+	0xea, 0x11, 0x05, 0x45, 0xb7, 0x72, 0x53, 0x85, 0xf5, 0x4e, 0xa9, 0xd7, 0x3c, 0x5f, 0x74, 0xd2,
+	0x93, 0x25, 0xbb, 0x51, 0x76, 0xd2, 0x33, 0xb7, 0xd4, 0xab, 0x9d, 0x2f, 0x3a, 0x42, 0x11, 0x8e,
+	0xaa, 0xde, 0x0f, 0x9e, 0x3d, 0x6f, 0x17, 0xbe, 0x7c, 0xde, 0x2e, 0x7c, 0xfd, 0xbc, 0x2d, 0xfd,
+	0xec, 0xac, 0x2d, 0xfd, 0xe1, 0xac, 0x2d, 0x3d, 0x3d, 0x6b, 0x4b, 0xcf, 0xce, 0xda, 0xd2, 0xdf,
+	0xcf, 0xda, 0xd2, 0x57, 0x67, 0xed, 0xc2, 0xd7, 0x67, 0x6d, 0xe9, 0xd7, 0x2f, 0xda, 0x85, 0x67,
+	0x2f, 0xda, 0x85, 0x2f, 0x5f, 0xb4, 0x0b, 0x3f, 0xae, 0xf0, 0x3f, 0xa6, 0xee, 0xd1, 0x51, 0x99,
+	0xff, 0xfb, 0x7c, 0xef, 0x5f, 0x03, 0x00, 0xff, 0x6e, 0x7c, 0xd0, 0x43, 0x15, 0x00, 0x00,
 }
 
 func (x WriteRequest_SourceEnum) String() string {
@@ -2658,6 +2874,9 @@ func (this *QueryResponse) Equal(that interface{}) bool {
 	} else if !this.Data.Equal(that1.Data) {
 		return false
 	}
+	if !this.Stats.Equal(that1.Stats) {
+		return false
+	}
 	return true
 }
 func (this *QueryResponse_String_) Equal(that interface{}) bool {
@@ -2991,6 +3210,92 @@ func (this *MatrixSeries) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *QueryStats) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*QueryStats)
+	if !ok {
+		that2, ok := that.(QueryStats)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.WallTimeSeconds != that1.WallTimeSeconds {
+		return false
+	}
+	if this.QueueTimeSeconds != that1.QueueTimeSeconds {
+		return false
+	}
+	if this.FetchedSeriesCount != that1.FetchedSeriesCount {
+		return false
+	}
+	if this.FetchedChunksCount != that1.FetchedChunksCount {
+		return false
+	}
+	if this.FetchedChunkBytes != that1.FetchedChunkBytes {
+		return false
+	}
+	if this.FetchedIndexBytes != that1.FetchedIndexBytes {
+		return false
+	}
+	if this.SamplesProcessed != that1.SamplesProcessed {
+		return false
+	}
+	if this.ShardedQueries != that1.ShardedQueries {
+		return false
+	}
+	if this.SplitQueries != that1.SplitQueries {
+		return false
+	}
+	if this.ResultsCacheHitRatio != that1.ResultsCacheHitRatio {
+		return false
+	}
+	if len(this.SamplesProcessedPerStep) != len(that1.SamplesProcessedPerStep) {
+		return false
+	}
+	for i := range this.SamplesProcessedPerStep {
+		if !this.SamplesProcessedPerStep[i].Equal(&that1.SamplesProcessedPerStep[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *QueryStepStat) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*QueryStepStat)
+	if !ok {
+		that2, ok := that.(QueryStepStat)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.TimestampMs != that1.TimestampMs {
+		return false
+	}
+	if this.Value != that1.Value {
+		return false
+	}
+	return true
+}
 func (this *WriteRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -3280,6 +3585,9 @@ func (this *QueryResponse) GoString() string {
 	if this.Data != nil {
 		s = append(s, "Data: "+fmt.Sprintf("%#v", this.Data)+",\n")
 	}
+	if this.Stats != nil {
+		s = append(s, "Stats: "+fmt.Sprintf("%#v", this.Stats)+",\n")
+	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -3424,6 +3732,43 @@ func (this *MatrixSeries) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *QueryStats) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 15)
+	s = append(s, "&mimirpb.QueryStats{")
+	s = append(s, "WallTimeSeconds: "+fmt.Sprintf("%#v", this.WallTimeSeconds)+",\n")
+	s = append(s, "QueueTimeSeconds: "+fmt.Sprintf("%#v", this.QueueTimeSeconds)+",\n")
+	s = append(s, "FetchedSeriesCount: "+fmt.Sprintf("%#v", this.FetchedSeriesCount)+",\n")
+	s = append(s, "FetchedChunksCount: "+fmt.Sprintf("%#v", this.FetchedChunksCount)+",\n")
+	s = append(s, "FetchedChunkBytes: "+fmt.Sprintf("%#v", this.FetchedChunkBytes)+",\n")
+	s = append(s, "FetchedIndexBytes: "+fmt.Sprintf("%#v", this.FetchedIndexBytes)+",\n")
+	s = append(s, "SamplesProcessed: "+fmt.Sprintf("%#v", this.SamplesProcessed)+",\n")
+	s = append(s, "ShardedQueries: "+fmt.Sprintf("%#v", this.ShardedQueries)+",\n")
+	s = append(s, "SplitQueries: "+fmt.Sprintf("%#v", this.SplitQueries)+",\n")
+	s = append(s, "ResultsCacheHitRatio: "+fmt.Sprintf("%#v", this.ResultsCacheHitRatio)+",\n")
+	if this.SamplesProcessedPerStep != nil {
+		vs := make([]QueryStepStat, len(this.SamplesProcessedPerStep))
+		for i := range vs {
+			vs[i] = this.SamplesProcessedPerStep[i]
+		}
+		s = append(s, "SamplesProcessedPerStep: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *QueryStepStat) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&mimirpb.QueryStepStat{")
+	s = append(s, "TimestampMs: "+fmt.Sprintf("%#v", this.TimestampMs)+",\n")
+	s = append(s, "Value: "+fmt.Sprintf("%#v", this.Value)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringMimir(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -4340,6 +4685,18 @@ func (m *QueryResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Stats != nil {
+		{
+			size, err := m.Stats.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintMimir(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x42
+	}
 	if m.Data != nil {
 		{
 			size := m.Data.Size()
@@ -4756,37 +5113,160 @@ func (m *MatrixSeries) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintMimir(dAtA []byte, offset int, v uint64) int {
-	offset -= sovMimir(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *QueryStats) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return dAtA[:n], nil
 }
-func (m *WriteRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+
+func (m *QueryStats) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QueryStats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if len(m.Timeseries) > 0 {
-		for _, e := range m.Timeseries {
-			l = e.Size()
-			n += 1 + l + sovMimir(uint64(l))
+	if len(m.SamplesProcessedPerStep) > 0 {
+		for iNdEx := len(m.SamplesProcessedPerStep) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.SamplesProcessedPerStep[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintMimir(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x5a
 		}
 	}
-	if m.Source != 0 {
-		n += 1 + sovMimir(uint64(m.Source))
+	if m.ResultsCacheHitRatio != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.ResultsCacheHitRatio))))
+		i--
+		dAtA[i] = 0x51
 	}
-	if len(m.Metadata) > 0 {
-		for _, e := range m.Metadata {
-			l = e.Size()
-			n += 1 + l + sovMimir(uint64(l))
-		}
+	if m.SplitQueries != 0 {
+		i = encodeVarintMimir(dAtA, i, uint64(m.SplitQueries))
+		i--
+		dAtA[i] = 0x48
+	}
+	if m.ShardedQueries != 0 {
+		i = encodeVarintMimir(dAtA, i, uint64(m.ShardedQueries))
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.SamplesProcessed != 0 {
+		i = encodeVarintMimir(dAtA, i, uint64(m.SamplesProcessed))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.FetchedIndexBytes != 0 {
+		i = encodeVarintMimir(dAtA, i, uint64(m.FetchedIndexBytes))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.FetchedChunkBytes != 0 {
+		i = encodeVarintMimir(dAtA, i, uint64(m.FetchedChunkBytes))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.FetchedChunksCount != 0 {
+		i = encodeVarintMimir(dAtA, i, uint64(m.FetchedChunksCount))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.FetchedSeriesCount != 0 {
+		i = encodeVarintMimir(dAtA, i, uint64(m.FetchedSeriesCount))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.QueueTimeSeconds != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.QueueTimeSeconds))))
+		i--
+		dAtA[i] = 0x11
+	}
+	if m.WallTimeSeconds != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.WallTimeSeconds))))
+		i--
+		dAtA[i] = 0x9
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *QueryStepStat) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QueryStepStat) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *QueryStepStat) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Value != 0 {
+		i = encodeVarintMimir(dAtA, i, uint64(m.Value))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.TimestampMs != 0 {
+		i = encodeVarintMimir(dAtA, i, uint64(m.TimestampMs))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintMimir(dAtA []byte, offset int, v uint64) int {
+	offset -= sovMimir(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *WriteRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Timeseries) > 0 {
+		for _, e := range m.Timeseries {
+			l = e.Size()
+			n += 1 + l + sovMimir(uint64(l))
+		}
+	}
+	if m.Source != 0 {
+		n += 1 + sovMimir(uint64(m.Source))
+	}
+	if len(m.Metadata) > 0 {
+		for _, e := range m.Metadata {
+			l = e.Size()
+			n += 1 + l + sovMimir(uint64(l))
+		}
 	}
 	if m.SkipLabelNameValidation {
 		n += 3
@@ -5179,6 +5659,10 @@ func (m *QueryResponse) Size() (n int) {
 	if m.Data != nil {
 		n += m.Data.Size()
 	}
+	if m.Stats != nil {
+		l = m.Stats.Size()
+		n += 1 + l + sovMimir(uint64(l))
+	}
 	return n
 }
 
@@ -5365,6 +5849,66 @@ func (m *MatrixSeries) Size() (n int) {
 	return n
 }
 
+func (m *QueryStats) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.WallTimeSeconds != 0 {
+		n += 9
+	}
+	if m.QueueTimeSeconds != 0 {
+		n += 9
+	}
+	if m.FetchedSeriesCount != 0 {
+		n += 1 + sovMimir(uint64(m.FetchedSeriesCount))
+	}
+	if m.FetchedChunksCount != 0 {
+		n += 1 + sovMimir(uint64(m.FetchedChunksCount))
+	}
+	if m.FetchedChunkBytes != 0 {
+		n += 1 + sovMimir(uint64(m.FetchedChunkBytes))
+	}
+	if m.FetchedIndexBytes != 0 {
+		n += 1 + sovMimir(uint64(m.FetchedIndexBytes))
+	}
+	if m.SamplesProcessed != 0 {
+		n += 1 + sovMimir(uint64(m.SamplesProcessed))
+	}
+	if m.ShardedQueries != 0 {
+		n += 1 + sovMimir(uint64(m.ShardedQueries))
+	}
+	if m.SplitQueries != 0 {
+		n += 1 + sovMimir(uint64(m.SplitQueries))
+	}
+	if m.ResultsCacheHitRatio != 0 {
+		n += 9
+	}
+	if len(m.SamplesProcessedPerStep) > 0 {
+		for _, e := range m.SamplesProcessedPerStep {
+			l = e.Size()
+			n += 1 + l + sovMimir(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *QueryStepStat) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.TimestampMs != 0 {
+		n += 1 + sovMimir(uint64(m.TimestampMs))
+	}
+	if m.Value != 0 {
+		n += 1 + sovMimir(uint64(m.Value))
+	}
+	return n
+}
+
 func sovMimir(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -5656,6 +6200,7 @@ func (this *QueryResponse) String() string {
 		`ErrorType:` + fmt.Sprintf("%v", this.ErrorType) + `,`,
 		`Error:` + fmt.Sprintf("%v", this.Error) + `,`,
 		`Data:` + fmt.Sprintf("%v", this.Data) + `,`,
+		`Stats:` + strings.Replace(this.Stats.String(), "QueryStats", "QueryStats", 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -5804,6 +6349,42 @@ func (this *MatrixSeries) String() string {
 	}, "")
 	return s
 }
+func (this *QueryStats) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForSamplesProcessedPerStep := "[]QueryStepStat{"
+	for _, f := range this.SamplesProcessedPerStep {
+		repeatedStringForSamplesProcessedPerStep += strings.Replace(strings.Replace(f.String(), "QueryStepStat", "QueryStepStat", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForSamplesProcessedPerStep += "}"
+	s := strings.Join([]string{`&QueryStats{`,
+		`WallTimeSeconds:` + fmt.Sprintf("%v", this.WallTimeSeconds) + `,`,
+		`QueueTimeSeconds:` + fmt.Sprintf("%v", this.QueueTimeSeconds) + `,`,
+		`FetchedSeriesCount:` + fmt.Sprintf("%v", this.FetchedSeriesCount) + `,`,
+		`FetchedChunksCount:` + fmt.Sprintf("%v", this.FetchedChunksCount) + `,`,
+		`FetchedChunkBytes:` + fmt.Sprintf("%v", this.FetchedChunkBytes) + `,`,
+		`FetchedIndexBytes:` + fmt.Sprintf("%v", this.FetchedIndexBytes) + `,`,
+		`SamplesProcessed:` + fmt.Sprintf("%v", this.SamplesProcessed) + `,`,
+		`ShardedQueries:` + fmt.Sprintf("%v", this.ShardedQueries) + `,`,
+		`SplitQueries:` + fmt.Sprintf("%v", this.SplitQueries) + `,`,
+		`ResultsCacheHitRatio:` + fmt.Sprintf("%v", this.ResultsCacheHitRatio) + `,`,
+		`SamplesProcessedPerStep:` + repeatedStringForSamplesProcessedPerStep + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *QueryStepStat) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&QueryStepStat{`,
+		`TimestampMs:` + fmt.Sprintf("%v", this.TimestampMs) + `,`,
+		`Value:` + fmt.Sprintf("%v", this.Value) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func valueToStringMimir(v interface{}) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -8393,6 +8974,42 @@ func (m *QueryResponse) Unmarshal(dAtA []byte) error {
 			}
 			m.Data = &QueryResponse_Matrix{v}
 			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Stats", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMimir
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Stats == nil {
+				m.Stats = &QueryStats{}
+			}
+			if err := m.Stats.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipMimir(dAtA[iNdEx:])
@@ -9217,6 +9834,350 @@ func (m *MatrixSeries) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *QueryStats) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMimir
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QueryStats: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QueryStats: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WallTimeSeconds", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.WallTimeSeconds = float64(math.Float64frombits(v))
+		case 2:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueueTimeSeconds", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.QueueTimeSeconds = float64(math.Float64frombits(v))
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FetchedSeriesCount", wireType)
+			}
+			m.FetchedSeriesCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FetchedSeriesCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FetchedChunksCount", wireType)
+			}
+			m.FetchedChunksCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FetchedChunksCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FetchedChunkBytes", wireType)
+			}
+			m.FetchedChunkBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FetchedChunkBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FetchedIndexBytes", wireType)
+			}
+			m.FetchedIndexBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FetchedIndexBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SamplesProcessed", wireType)
+			}
+			m.SamplesProcessed = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SamplesProcessed |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardedQueries", wireType)
+			}
+			m.ShardedQueries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ShardedQueries |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SplitQueries", wireType)
+			}
+			m.SplitQueries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SplitQueries |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ResultsCacheHitRatio", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.ResultsCacheHitRatio = float64(math.Float64frombits(v))
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SamplesProcessedPerStep", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthMimir
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SamplesProcessedPerStep = append(m.SamplesProcessedPerStep, QueryStepStat{})
+			if err := m.SamplesProcessedPerStep[len(m.SamplesProcessedPerStep)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMimir(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QueryStepStat) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowMimir
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QueryStepStat: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QueryStepStat: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TimestampMs", wireType)
+			}
+			m.TimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			m.Value = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowMimir
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Value |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipMimir(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthMimir
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipMimir(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
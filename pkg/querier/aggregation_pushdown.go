@@ -0,0 +1,299 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/util/annotations"
+	"github.com/prometheus/prometheus/util/stats"
+	v1 "github.com/prometheus/prometheus/web/api/v1"
+
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
+	"github.com/grafana/mimir/pkg/storegateway/pushdown"
+	util_log "github.com/grafana/mimir/pkg/util/log"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	aggregationPushdownResultPushedDown = "pushed_down"
+	aggregationPushdownResultFallback   = "fallback"
+)
+
+// AggregationPushdownEngine wraps a PromQL engine to evaluate the supported range queries by pushing down the
+// aggregation to the store-gateways. The steps which can't be pushed down, and the queries which aren't
+// supported, are evaluated by the wrapped engine.
+type AggregationPushdownEngine struct {
+	v1.QueryEngine
+
+	blocks             *BlocksStoreQueryable
+	limits             *validation.Overrides
+	maxQueryIntoFuture time.Duration
+	logger             log.Logger
+
+	queries *prometheus.CounterVec
+}
+
+// NewAggregationPushdownEngine returns an AggregationPushdownEngine wrapping the input engine.
+func NewAggregationPushdownEngine(engine v1.QueryEngine, blocks *BlocksStoreQueryable, cfg Config, limits *validation.Overrides, logger log.Logger, reg prometheus.Registerer) *AggregationPushdownEngine {
+	return &AggregationPushdownEngine{
+		QueryEngine:        engine,
+		blocks:             blocks,
+		limits:             limits,
+		maxQueryIntoFuture: cfg.MaxQueryIntoFuture,
+		logger:             logger,
+		queries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_querier_aggregation_pushdown_queries_total",
+			Help: "Total number of range queries supporting the aggregation pushdown, by whether the aggregation has been pushed down to the store-gateways or the query has been evaluated by the PromQL engine.",
+		}, []string{"result"}),
+	}
+}
+
+// NewRangeQuery implements v1.QueryEngine.
+func (e *AggregationPushdownEngine) NewRangeQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, start, end time.Time, interval time.Duration) (promql.Query, error) {
+	expr, err := parser.ParseExpr(qs)
+	if err != nil || interval <= 0 || end.Before(start) {
+		// Let the wrapped engine return the error.
+		return e.QueryEngine.NewRangeQuery(ctx, q, opts, qs, start, end, interval)
+	}
+
+	p, matchers, ok := pushdown.FromExpr(expr)
+	if !ok {
+		return e.QueryEngine.NewRangeQuery(ctx, q, opts, qs, start, end, interval)
+	}
+	p.Start = start.UnixMilli()
+	p.End = end.UnixMilli()
+	p.Step = interval.Milliseconds()
+
+	return &aggregationPushdownQuery{
+		engine:    e,
+		queryable: q,
+		opts:      opts,
+		qs:        qs,
+		expr:      expr,
+		pushdown:  p,
+		matchers:  matchers,
+		start:     start,
+		end:       end,
+		interval:  interval,
+		stats:     &stats.Statistics{Timers: stats.NewQueryTimers(), Samples: stats.NewQuerySamples(false)},
+	}, nil
+}
+
+// selectAggregationPushdown pushes down the aggregation to the store-gateways, if the whole query is served by
+// the store-gateways. It returns, for each step of the query, whether the step has been evaluated by pushing down
+// the aggregation, and the result of the query at those steps.
+func (e *AggregationPushdownEngine) selectAggregationPushdown(ctx context.Context, p *hintspb.AggregationPushdown, matchers []*labels.Matcher) ([]bool, promql.Matrix, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tenantIDs) != 1 {
+		return nil, nil, nil
+	}
+	tenantID := tenantIDs[0]
+
+	var (
+		now  = time.Now()
+		minT = p.Start - p.Range
+		maxT = p.End
+	)
+
+	if ShouldQueryIngesters(e.limits.QueryIngestersWithin(tenantID), now, maxT) {
+		return nil, nil, nil
+	}
+	if validMinT, validMaxT, err := validateQueryTimeRange(tenantID, minT, maxT, now.UnixMilli(), e.limits, e.maxQueryIntoFuture, e.logger); err != nil || validMinT != minT || validMaxT != maxT {
+		return nil, nil, nil
+	}
+	// The store-gateways don't apply the retention rules, so the aggregation isn't pushed down if they mask any sample.
+	if retentionRulesApply(e.limits.CompactorRetentionRules(tenantID), now, minT) {
+		return nil, nil, nil
+	}
+
+	querier, err := e.blocks.Querier(minT, maxT)
+	if err != nil {
+		return nil, nil, err
+	}
+	return querier.(*blocksStoreQuerier).selectAggregationPushdown(ctx, tenantID, p, matchers)
+}
+
+// aggregationPushdownQuery is a range query supporting the aggregation pushdown.
+type aggregationPushdownQuery struct {
+	engine     *AggregationPushdownEngine
+	queryable  storage.Queryable
+	opts       promql.QueryOpts
+	qs         string
+	expr       parser.Expr
+	pushdown   *hintspb.AggregationPushdown
+	matchers   []*labels.Matcher
+	start, end time.Time
+	interval   time.Duration
+	stats      *stats.Statistics
+
+	mtx       sync.Mutex
+	cancel    context.CancelFunc
+	evaluated []promql.Query
+}
+
+// Exec implements promql.Query.
+func (q *aggregationPushdownQuery) Exec(ctx context.Context) *promql.Result {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	q.mtx.Lock()
+	q.cancel = cancel
+	q.mtx.Unlock()
+
+	covered, pushedDown, err := q.engine.selectAggregationPushdown(ctx, q.pushdown, q.matchers)
+	if err != nil {
+		level.Warn(util_log.WithContext(ctx, q.engine.logger)).Log("msg", "failed to push down the aggregation to the store-gateways, falling back to the PromQL engine", "query", q.qs, "err", err)
+	}
+
+	anyCovered := false
+	for _, ok := range covered {
+		anyCovered = anyCovered || ok
+	}
+	if err != nil || !anyCovered {
+		q.engine.queries.WithLabelValues(aggregationPushdownResultFallback).Inc()
+		return q.evaluate(ctx, q.start, q.end)
+	}
+	q.engine.queries.WithLabelValues(aggregationPushdownResultPushedDown).Inc()
+
+	// Evaluate the steps which haven't been pushed down with the wrapped engine,
+	// running a range query for each sequence of consecutive steps.
+	var (
+		matrices = []promql.Matrix{pushedDown}
+		warnings annotations.Annotations
+	)
+	for first := 0; first < len(covered); first++ {
+		if covered[first] {
+			continue
+		}
+
+		last := first
+		for last+1 < len(covered) && !covered[last+1] {
+			last++
+		}
+
+		res := q.evaluate(ctx, q.stepTime(first), q.stepTime(last))
+		if res.Err != nil {
+			return res
+		}
+		matrix, err := res.Matrix()
+		if err != nil {
+			return &promql.Result{Err: err}
+		}
+		matrices = append(matrices, matrix)
+		warnings.Merge(res.Warnings)
+
+		first = last
+	}
+
+	return &promql.Result{Value: mergeMatrices(matrices), Warnings: warnings}
+}
+
+// evaluate runs the query on the wrapped engine between start and end.
+func (q *aggregationPushdownQuery) evaluate(ctx context.Context, start, end time.Time) *promql.Result {
+	query, err := q.engine.QueryEngine.NewRangeQuery(ctx, q.queryable, q.opts, q.qs, start, end, q.interval)
+	if err != nil {
+		return &promql.Result{Err: err}
+	}
+
+	// The query result is released when the query is closed, so we close it together with this query.
+	q.mtx.Lock()
+	q.evaluated = append(q.evaluated, query)
+	q.mtx.Unlock()
+
+	res := query.Exec(ctx)
+	if s := query.Stats(); s != nil && s.Samples != nil {
+		q.stats.Samples.TotalSamples += s.Samples.TotalSamples
+		q.stats.Samples.UpdatePeak(s.Samples.PeakSamples)
+	}
+	return res
+}
+
+func (q *aggregationPushdownQuery) stepTime(i int) time.Time {
+	return time.UnixMilli(q.pushdown.Start + int64(i)*q.pushdown.Step)
+}
+
+// Close implements promql.Query.
+func (q *aggregationPushdownQuery) Close() {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	for _, query := range q.evaluated {
+		query.Close()
+	}
+	q.evaluated = nil
+}
+
+// Statement implements promql.Query.
+func (q *aggregationPushdownQuery) Statement() parser.Statement {
+	return &parser.EvalStmt{Expr: q.expr, Start: q.start, End: q.end, Interval: q.interval}
+}
+
+// Stats implements promql.Query.
+func (q *aggregationPushdownQuery) Stats() *stats.Statistics {
+	return q.stats
+}
+
+// Cancel implements promql.Query.
+func (q *aggregationPushdownQuery) Cancel() {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	if q.cancel != nil {
+		q.cancel()
+	}
+}
+
+// String implements promql.Query.
+func (q *aggregationPushdownQuery) String() string {
+	return q.qs
+}
+
+// mergeMatrices merges matrices holding the values of different steps.
+func mergeMatrices(matrices []promql.Matrix) promql.Matrix {
+	var (
+		merged = promql.Matrix{}
+		index  = map[string]int{}
+	)
+	for _, matrix := range matrices {
+		for _, series := range matrix {
+			key := series.Metric.String()
+			idx, ok := index[key]
+			if !ok {
+				index[key] = len(merged)
+				merged = append(merged, promql.Series{
+					Metric:     series.Metric,
+					Floats:     append([]promql.FPoint(nil), series.Floats...),
+					Histograms: append([]promql.HPoint(nil), series.Histograms...),
+				})
+				continue
+			}
+			merged[idx].Floats = append(merged[idx].Floats, series.Floats...)
+			merged[idx].Histograms = append(merged[idx].Histograms, series.Histograms...)
+		}
+	}
+
+	for _, series := range merged {
+		floats, histograms := series.Floats, series.Histograms
+		sort.Slice(floats, func(i, j int) bool { return floats[i].T < floats[j].T })
+		sort.Slice(histograms, func(i, j int) bool { return histograms[i].T < histograms[j].T })
+	}
+	sort.Sort(merged)
+
+	return merged
+}
@@ -0,0 +1,228 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/user"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestAggregationPushdownEngine(t *testing.T) {
+	const (
+		scrapeInterval = 15 * time.Second
+		numPods        = 6
+	)
+
+	var (
+		ctx    = user.InjectOrgID(context.Background(), "user-1")
+		logger = log.NewNopLogger()
+		random = rand.New(rand.NewSource(1))
+		tmpDir = t.TempDir()
+
+		// The reference head holds all the samples. It's the queryable used by the PromQL engine.
+		referenceHead = newAggregationPushdownTestHead(t, filepath.Join(tmpDir, "reference"))
+
+		// The first block covers the first 2h, the following 2h are split in two shards.
+		firstBlock  = aggregationPushdownTestBlock{meta: bucketindex.Block{ID: ulid.MustNew(1, nil)}, head: newAggregationPushdownTestHead(t, filepath.Join(tmpDir, "first"))}
+		secondBlock = []aggregationPushdownTestBlock{
+			{meta: bucketindex.Block{ID: ulid.MustNew(2, nil), CompactorShardID: "1_of_2"}, head: newAggregationPushdownTestHead(t, filepath.Join(tmpDir, "second-1"))},
+			{meta: bucketindex.Block{ID: ulid.MustNew(3, nil), CompactorShardID: "2_of_2"}, head: newAggregationPushdownTestHead(t, filepath.Join(tmpDir, "second-2"))},
+		}
+	)
+
+	appendSample := func(head *tsdb.Head, lset labels.Labels, ts int64, value float64) {
+		app := head.Appender(ctx)
+		_, err := app.Append(0, lset, ts, value)
+		require.NoError(t, err)
+		require.NoError(t, app.Commit())
+	}
+
+	for pod := 0; pod < numPods; pod++ {
+		lset := labels.FromStrings(labels.MetricName, "metric", "job", fmt.Sprintf("job-%d", pod%2), "pod", fmt.Sprintf("pod-%d", pod))
+
+		value := 0.0
+		for ts := int64(0); ts < (4 * time.Hour).Milliseconds(); ts += scrapeInterval.Milliseconds() {
+			// Simulate a counter, with some resets.
+			if random.Intn(100) == 0 {
+				value = 0
+			}
+			value += float64(random.Intn(10))
+
+			appendSample(referenceHead, lset, ts, value)
+			if ts < (2 * time.Hour).Milliseconds() {
+				appendSample(firstBlock.head, lset, ts, value)
+			} else {
+				appendSample(secondBlock[pod%2].head, lset, ts, value)
+			}
+		}
+	}
+
+	var (
+		blocks     = map[ulid.ULID]aggregationPushdownTestBlock{}
+		blockMetas bucketindex.Blocks
+	)
+	for _, b := range append([]aggregationPushdownTestBlock{firstBlock}, secondBlock...) {
+		b := b
+
+		// Block intervals are half-open: [MinTime, MaxTime).
+		b.meta.MinTime = b.head.MinTime()
+		b.meta.MaxTime = b.head.MaxTime() + 1
+		blocks[b.meta.ID] = b
+		blockMetas = append(blockMetas, &b.meta)
+	}
+
+	engine := promql.NewEngine(promql.EngineOpts{Logger: logger, MaxSamples: math.MaxInt, Timeout: time.Minute})
+	referenceQueryable := storage.QueryableFunc(func(mint, maxt int64) (storage.Querier, error) {
+		return tsdb.NewBlockQuerier(referenceHead, mint, maxt)
+	})
+
+	var (
+		start = time.UnixMilli(0).Add(10 * time.Minute)
+		end   = time.UnixMilli(0).Add(4 * time.Hour)
+		step  = time.Minute
+	)
+
+	tests := map[string]struct {
+		query              string
+		rawSeries          bool
+		retentionRules     []*validation.RetentionRule
+		expectedPushedDown float64
+		expectedFallback   float64
+	}{
+		"sum of rate": {
+			query:              `sum(rate(metric[5m]))`,
+			expectedPushedDown: 1,
+		},
+		"sum by of increase": {
+			query:              `sum by (job) (increase(metric[5m]))`,
+			expectedPushedDown: 1,
+		},
+		"min of idelta": {
+			query:              `min(idelta(metric[5m]))`,
+			expectedPushedDown: 1,
+		},
+		"max without of max_over_time": {
+			query:              `max without (pod) (max_over_time(metric[10m]))`,
+			expectedPushedDown: 1,
+		},
+		"count by of count_over_time": {
+			query:              `count by (job) (count_over_time(metric{pod=~"pod-[0-3]"}[5m]))`,
+			expectedPushedDown: 1,
+		},
+		"unsupported query": {
+			query: `avg(rate(metric[5m]))`,
+		},
+		"store-gateways not supporting the aggregation pushdown": {
+			query:            `sum(rate(metric[5m]))`,
+			rawSeries:        true,
+			expectedFallback: 1,
+		},
+		"retention rules masking the queried samples": {
+			query:            `sum(rate(metric[5m]))`,
+			retentionRules:   []*validation.RetentionRule{mustNewRetentionRule(t, `{job="job-0"}`, time.Hour)},
+			expectedFallback: 1,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			gateway1 := &aggregationPushdownStoreGatewayMock{storeGatewayClientMock: storeGatewayClientMock{remoteAddr: "1.1.1.1"}, engine: engine, query: testData.query, blocks: blocks, rawSeries: testData.rawSeries}
+			gateway2 := &aggregationPushdownStoreGatewayMock{storeGatewayClientMock: storeGatewayClientMock{remoteAddr: "2.2.2.2"}, engine: engine, query: testData.query, blocks: blocks, rawSeries: testData.rawSeries}
+
+			finder := &blocksFinderMock{Service: services.NewIdleService(nil, nil)}
+			finder.On("GetBlocks", mock.Anything, "user-1", mock.Anything, mock.Anything).Return(blockMetas, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), error(nil))
+
+			stores := &blocksStoreSetMock{
+				Service: services.NewIdleService(nil, nil),
+				mockedResponses: []interface{}{
+					map[BlocksStoreClient][]ulid.ULID{
+						gateway1: {firstBlock.meta.ID},
+						gateway2: {secondBlock[0].meta.ID, secondBlock[1].meta.ID},
+					},
+				},
+			}
+
+			blocksQueryable, err := NewBlocksStoreQueryable(stores, finder, NewBlocksConsistencyChecker(0, 0, logger, nil), &blocksStoreLimitsMock{}, 0, 0, 0, 0, logger, nil)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), blocksQueryable))
+			t.Cleanup(func() {
+				require.NoError(t, services.StopAndAwaitTerminated(context.Background(), blocksQueryable))
+			})
+
+			limits := defaultLimitsConfig()
+			limits.QueryIngestersWithin = model.Duration(13 * time.Hour)
+			limits.CompactorRetentionRules = testData.retentionRules
+			overrides, err := validation.NewOverrides(limits, nil)
+			require.NoError(t, err)
+
+			reg := prometheus.NewPedanticRegistry()
+			pushdownEngine := NewAggregationPushdownEngine(engine, blocksQueryable, Config{}, overrides, logger, reg)
+
+			// Run the query with and without pushing down the aggregation.
+			expectedQuery, err := engine.NewRangeQuery(ctx, referenceQueryable, nil, testData.query, start, end, step)
+			require.NoError(t, err)
+			t.Cleanup(expectedQuery.Close)
+			expected, err := expectedQuery.Exec(ctx).Matrix()
+			require.NoError(t, err)
+
+			actualQuery, err := pushdownEngine.NewRangeQuery(ctx, referenceQueryable, nil, testData.query, start, end, step)
+			require.NoError(t, err)
+			t.Cleanup(actualQuery.Close)
+			actual, err := actualQuery.Exec(ctx).Matrix()
+			require.NoError(t, err)
+
+			require.Len(t, actual, len(expected))
+			for i := range expected {
+				require.Equal(t, expected[i].Metric, actual[i].Metric)
+				require.Len(t, actual[i].Floats, len(expected[i].Floats))
+				require.Empty(t, actual[i].Histograms)
+
+				for j, point := range expected[i].Floats {
+					require.Equal(t, point.T, actual[i].Floats[j].T)
+
+					// The partial sums are added up in a different order, so the result may differ by rounding errors.
+					assert.InDelta(t, point.F, actual[i].Floats[j].F, 1e-9*math.Max(1, math.Abs(point.F)), "series: %s step: %d", expected[i].Metric, point.T)
+				}
+			}
+
+			assert.Equal(t, testData.expectedPushedDown, testutil.ToFloat64(pushdownEngine.queries.WithLabelValues(aggregationPushdownResultPushedDown)))
+			assert.Equal(t, testData.expectedFallback, testutil.ToFloat64(pushdownEngine.queries.WithLabelValues(aggregationPushdownResultFallback)))
+		})
+	}
+}
+
+func newAggregationPushdownTestHead(t *testing.T, dir string) *tsdb.Head {
+	headOpts := tsdb.DefaultHeadOptions()
+	headOpts.ChunkDirRoot = dir
+	headOpts.ChunkRange = (24 * time.Hour).Milliseconds()
+
+	head, err := tsdb.NewHead(nil, nil, nil, nil, headOpts, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, head.Close())
+	})
+	return head
+}
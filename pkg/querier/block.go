@@ -18,6 +18,7 @@ import (
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/series"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 )
 
@@ -49,6 +50,9 @@ func convertMatchersToLabelMatcher(matchers []*labels.Matcher) []storepb.LabelMa
 type blockQuerierSeriesSet struct {
 	series []*storepb.Series
 
+	// aggr is the aggregate to read from the chunks of downsampled blocks.
+	aggr downsample.AggrType
+
 	// next response to process
 	next int
 
@@ -75,7 +79,7 @@ func (bqss *blockQuerierSeriesSet) Next() bool {
 		bqss.next++
 	}
 
-	bqss.currSeries = newBlockQuerierSeries(mimirpb.FromLabelAdaptersToLabels(currLabels), currChunks)
+	bqss.currSeries = newBlockQuerierSeries(mimirpb.FromLabelAdaptersToLabels(currLabels), currChunks, bqss.aggr)
 	return true
 }
 
@@ -92,17 +96,18 @@ func (bqss *blockQuerierSeriesSet) Warnings() annotations.Annotations {
 }
 
 // newBlockQuerierSeries makes a new blockQuerierSeries. Input labels must be already sorted by name.
-func newBlockQuerierSeries(lbls labels.Labels, chunks []storepb.AggrChunk) *blockQuerierSeries {
+func newBlockQuerierSeries(lbls labels.Labels, chunks []storepb.AggrChunk, aggr downsample.AggrType) *blockQuerierSeries {
 	sort.Slice(chunks, func(i, j int) bool {
 		return chunks[i].MinTime < chunks[j].MinTime
 	})
 
-	return &blockQuerierSeries{labels: lbls, chunks: chunks}
+	return &blockQuerierSeries{labels: lbls, chunks: chunks, aggr: aggr}
 }
 
 type blockQuerierSeries struct {
 	labels labels.Labels
 	chunks []storepb.AggrChunk
+	aggr   downsample.AggrType
 }
 
 func (bqs *blockQuerierSeries) Labels() labels.Labels {
@@ -115,7 +120,7 @@ func (bqs *blockQuerierSeries) Iterator(reuse chunkenc.Iterator) chunkenc.Iterat
 		return series.NewErrIterator(errors.New("no chunks"))
 	}
 
-	it, err := newBlockQuerierSeriesIterator(reuse, bqs.Labels(), bqs.chunks)
+	it, err := newBlockQuerierSeriesIterator(reuse, bqs.Labels(), bqs.chunks, bqs.aggr)
 	if err != nil {
 		return series.NewErrIterator(err)
 	}
@@ -123,7 +128,9 @@ func (bqs *blockQuerierSeries) Iterator(reuse chunkenc.Iterator) chunkenc.Iterat
 	return it
 }
 
-func newBlockQuerierSeriesIterator(reuse chunkenc.Iterator, lbls labels.Labels, chunks []storepb.AggrChunk) (*blockQuerierSeriesIterator, error) {
+// newBlockQuerierSeriesIterator returns an iterator over the given chunks. The chunks of downsampled blocks
+// are iterated over the samples of the given aggregate.
+func newBlockQuerierSeriesIterator(reuse chunkenc.Iterator, lbls labels.Labels, chunks []storepb.AggrChunk, aggr downsample.AggrType) (*blockQuerierSeriesIterator, error) {
 	var it *blockQuerierSeriesIterator
 	r, ok := reuse.(*blockQuerierSeriesIterator)
 	if ok {
@@ -151,6 +158,12 @@ func newBlockQuerierSeriesIterator(reuse chunkenc.Iterator, lbls labels.Labels,
 			ch, err = chunkenc.FromData(chunkenc.EncHistogram, c.Raw.Data)
 		case storepb.Chunk_FloatHistogram:
 			ch, err = chunkenc.FromData(chunkenc.EncFloatHistogram, c.Raw.Data)
+		case storepb.Chunk_Downsampled:
+			ch, err = downsample.AggrChunk(c.Raw.Data).Get(aggr)
+			if err == nil && ch == nil {
+				// The aggregate is not set if there are no samples for it, like the counter of a series of NaN values.
+				ch = chunkenc.NewXORChunk()
+			}
 		default:
 			return nil, errors.Wrapf(err, "failed to initialize chunk from unknown type (%v) encoded raw data (series: %v min time: %d max time: %d)", c.Raw.Type, lbls, c.MinTime, c.MaxTime)
 		}
@@ -0,0 +1,387 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/types"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	grpc_metadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/sharding"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	"github.com/grafana/mimir/pkg/storegateway"
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
+	"github.com/grafana/mimir/pkg/storegateway/pushdown"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+)
+
+// aggregationPushdownPartial is a partial aggregate of a group of series, with its value at each step.
+type aggregationPushdownPartial struct {
+	lset   labels.Labels
+	values map[int64]float64
+}
+
+// selectAggregationPushdown pushes down the aggregation to the store-gateways. It returns, for each step of the query,
+// whether the step has been evaluated by pushing down the aggregation, and the result of the query at those steps.
+func (q *blocksStoreQuerier) selectAggregationPushdown(ctx context.Context, tenantID string, p *hintspb.AggregationPushdown, matchers []*labels.Matcher) ([]bool, promql.Matrix, error) {
+	spanLog, ctx := spanlogger.NewWithLogger(ctx, q.logger, "blocksStoreQuerier.selectAggregationPushdown")
+	defer spanLog.Span.Finish()
+
+	// Steps without any block overlapping their window are considered covered, because there's no data
+	// to evaluate. It's not the case if the query time range is within the query-store-after period,
+	// where the data may have not been synced by the store-gateways yet.
+	if q.queryStoreAfter > 0 && q.maxT > time.Now().Add(-q.queryStoreAfter).UnixMilli() {
+		return nil, nil, nil
+	}
+
+	shard, _, err := sharding.ShardFromMatchers(matchers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	knownBlocks, knownDeletionMarks, maxT, err := q.findBlocksToQuery(ctx, spanLog, q.minT, q.maxT, tenantID, shard, downsample.ResLevel0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	covered, pushdownBlocks := aggregationPushdownCoveredSteps(p, knownBlocks)
+	if len(pushdownBlocks) == 0 {
+		return covered, nil, nil
+	}
+
+	var (
+		convertedMatchers = convertMatchersToLabelMatcher(matchers)
+		merge             = pushdown.MergeFunc(p.Aggregation)
+		groups            = map[string]*aggregationPushdownPartial{}
+	)
+
+	queryF := func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error) {
+		partials, queriedBlocks, err := q.fetchAggregationPushdownFromStores(ctx, clients, minT, maxT, tenantID, convertedMatchers, p)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, partial := range partials {
+			key := partial.lset.String()
+			group, ok := groups[key]
+			if !ok {
+				groups[key] = partial
+				continue
+			}
+			for t, v := range partial.values {
+				if prev, ok := group.values[t]; ok {
+					group.values[t] = merge(prev, v)
+				} else {
+					group.values[t] = v
+				}
+			}
+		}
+
+		return queriedBlocks, nil
+	}
+
+	if err := q.queryBlocksWithConsistencyCheck(ctx, spanLog, q.minT, maxT, tenantID, pushdownBlocks, knownDeletionMarks, queryF); err != nil {
+		return nil, nil, err
+	}
+
+	// The store-gateways evaluate each block at all the steps covered by the block, so we only keep
+	// the steps which don't need the data of other blocks.
+	matrix := make(promql.Matrix, 0, len(groups))
+	for _, group := range groups {
+		series := promql.Series{Metric: group.lset}
+		for i, ok := range covered {
+			t := p.Start + int64(i)*p.Step
+			if v, found := group.values[t]; ok && found {
+				series.Floats = append(series.Floats, promql.FPoint{T: t, F: v})
+			}
+		}
+		if len(series.Floats) > 0 {
+			matrix = append(matrix, series)
+		}
+	}
+	sort.Sort(matrix)
+
+	return covered, matrix, nil
+}
+
+// aggregationPushdownCoveredSteps returns, for each step of the query, whether the step can be evaluated by
+// pushing down the aggregation to the store-gateways, and the blocks required to evaluate the covered steps.
+func aggregationPushdownCoveredSteps(p *hintspb.AggregationPushdown, blocks bucketindex.Blocks) ([]bool, bucketindex.Blocks) {
+	var (
+		covered  = make([]bool, (p.End-p.Start)/p.Step+1)
+		required = map[ulid.ULID]struct{}{}
+		overlap  bucketindex.Blocks
+	)
+
+	for i := range covered {
+		t := p.Start + int64(i)*p.Step
+
+		// Find the blocks overlapping the range function window of the step.
+		overlap = overlap[:0]
+		for _, b := range blocks {
+			if b.Within(t-p.Range, t) {
+				overlap = append(overlap, b)
+			}
+		}
+
+		if !canPushdownAggregationStep(p.Range, t, overlap) {
+			continue
+		}
+
+		covered[i] = true
+		for _, b := range overlap {
+			required[b.ID] = struct{}{}
+		}
+	}
+
+	var res bucketindex.Blocks
+	for _, b := range blocks {
+		if _, ok := required[b.ID]; ok {
+			res = append(res, b)
+		}
+	}
+	return covered, res
+}
+
+// canPushdownAggregationStep returns whether the step at time t can be evaluated by pushing down the aggregation,
+// given the blocks overlapping its range function window. It's the case if the window is fully contained in the
+// time range of the blocks, and the blocks don't share any series: either there's a single block, or the blocks
+// are the shards of the same split compaction. The store-gateways only evaluate raw blocks.
+func canPushdownAggregationStep(rangeMillis, t int64, blocks bucketindex.Blocks) bool {
+	if len(blocks) == 0 {
+		// There's no data to evaluate.
+		return true
+	}
+
+	for _, b := range blocks {
+		if b.Resolution != downsample.ResLevel0 {
+			return false
+		}
+	}
+
+	first := blocks[0]
+	if !pushdown.IsStepCovered(rangeMillis, t, first.MinTime, first.MaxTime) {
+		return false
+	}
+	if len(blocks) == 1 {
+		return true
+	}
+
+	var (
+		shardCount uint64
+		shards     = map[uint64]struct{}{}
+	)
+	for _, b := range blocks {
+		if b.MinTime != first.MinTime || b.MaxTime != first.MaxTime {
+			return false
+		}
+
+		index, count, err := sharding.ParseShardIDLabelValue(b.CompactorShardID)
+		if err != nil {
+			return false
+		}
+		if shardCount == 0 {
+			shardCount = count
+		}
+		if _, ok := shards[index]; ok || count != shardCount {
+			return false
+		}
+		shards[index] = struct{}{}
+	}
+	return true
+}
+
+// fetchAggregationPushdownFromStores fetches the partial aggregates of the blocks from all store-gateways in clients.
+// Errors caused by the aggregation pushdown not being supported by a store-gateway are returned, while other errors
+// are only logged and give rise to fetch retries.
+func (q *blocksStoreQuerier) fetchAggregationPushdownFromStores(
+	ctx context.Context,
+	clients map[BlocksStoreClient][]ulid.ULID,
+	minT int64,
+	maxT int64,
+	tenantID string,
+	matchers []storepb.LabelMatcher,
+	p *hintspb.AggregationPushdown,
+) ([]*aggregationPushdownPartial, []ulid.ULID, error) {
+	var (
+		reqCtx        = grpc_metadata.AppendToOutgoingContext(ctx, storegateway.GrpcContextMetadataTenantID, tenantID)
+		g, gCtx       = errgroup.WithContext(reqCtx)
+		mtx           = sync.Mutex{}
+		partials      []*aggregationPushdownPartial
+		queriedBlocks = []ulid.ULID(nil)
+		spanLog       = spanlogger.FromContext(ctx, q.logger)
+	)
+
+	// Concurrently fetch the partial aggregates from all clients.
+	for c, blockIDs := range clients {
+		// Change variables scope since it will be used in a goroutine.
+		c := c
+		blockIDs := blockIDs
+
+		g.Go(func() error {
+			req, err := createAggregationPushdownSeriesRequest(minT, maxT, matchers, blockIDs, p)
+			if err != nil {
+				return errors.Wrapf(err, "failed to create aggregation pushdown series request")
+			}
+
+			myPartials, myQueriedBlocks, err := fetchAggregationPushdownFromStore(gCtx, c, req)
+			if err != nil {
+				if shouldStopQueryFunc(err) || isAggregationPushdownUnsupportedErr(err) {
+					return err
+				}
+
+				level.Warn(spanLog).Log("msg", "failed to fetch aggregation pushdown series", "remote", c.RemoteAddress(), "err", err)
+				return nil
+			}
+
+			level.Debug(spanLog).Log("msg", "received aggregation pushdown series from store-gateway",
+				"instance", c.RemoteAddress(),
+				"num series", len(myPartials),
+				"requested blocks", strings.Join(convertULIDsToString(blockIDs), " "),
+				"queried blocks", strings.Join(convertULIDsToString(myQueriedBlocks), " "))
+
+			// Store the result.
+			mtx.Lock()
+			partials = append(partials, myPartials...)
+			queriedBlocks = append(queriedBlocks, myQueriedBlocks...)
+			mtx.Unlock()
+
+			return nil
+		})
+	}
+
+	// Wait until all client requests complete.
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	return partials, queriedBlocks, nil
+}
+
+// fetchAggregationPushdownFromStore fetches the partial aggregates from a single store-gateway. Partial aggregates
+// are only returned if the whole response has been successfully received.
+func fetchAggregationPushdownFromStore(ctx context.Context, c BlocksStoreClient, req *storepb.SeriesRequest) ([]*aggregationPushdownPartial, []ulid.ULID, error) {
+	stream, err := c.Series(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		partials      []*aggregationPushdownPartial
+		queriedBlocks []ulid.ULID
+	)
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return partials, queriedBlocks, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if s := resp.GetSeries(); s != nil {
+			partial, err := decodeAggregationPushdownPartial(s)
+			if err != nil {
+				return nil, nil, err
+			}
+			partials = append(partials, partial)
+		}
+
+		if w := resp.GetWarning(); w != "" {
+			return nil, nil, errors.New(w)
+		}
+
+		if h := resp.GetHints(); h != nil {
+			hints := hintspb.SeriesResponseHints{}
+			if err := types.UnmarshalAny(h, &hints); err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to unmarshal series hints from %s", c.RemoteAddress())
+			}
+
+			ids, err := convertBlockHintsToULIDs(hints.QueriedBlocks)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to parse queried block IDs from received hints")
+			}
+			queriedBlocks = append(queriedBlocks, ids...)
+		}
+	}
+}
+
+var errAggregationPushdownNotSupported = status.Error(codes.FailedPrecondition, "the store-gateway returned raw series instead of partial aggregates")
+
+// decodeAggregationPushdownPartial decodes a partial aggregate received from a store-gateway.
+func decodeAggregationPushdownPartial(s *storepb.Series) (*aggregationPushdownPartial, error) {
+	lset := mimirpb.FromLabelAdaptersToLabels(s.Labels)
+
+	// The aggregation always drops the metric name, while the raw series always have it because we only
+	// push down queries with a metric name. A store-gateway not supporting the aggregation pushdown
+	// ignores the request hint and returns the raw series.
+	if lset.Has(model.MetricNameLabel) {
+		return nil, errAggregationPushdownNotSupported
+	}
+
+	partial := &aggregationPushdownPartial{lset: lset, values: map[int64]float64{}}
+	for _, chk := range s.Chunks {
+		if chk.Raw == nil || chk.Raw.Type != storepb.Chunk_XOR {
+			return nil, errors.New("unexpected chunk encoding of partial aggregate")
+		}
+		c, err := chunkenc.FromData(chunkenc.EncXOR, chk.Raw.Data)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode partial aggregate chunk")
+		}
+
+		it := c.Iterator(nil)
+		for it.Next() == chunkenc.ValFloat {
+			t, v := it.At()
+			partial.values[t] = v
+		}
+		if it.Err() != nil {
+			return nil, errors.Wrap(it.Err(), "iterate partial aggregate chunk")
+		}
+	}
+	return partial, nil
+}
+
+// isAggregationPushdownUnsupportedErr returns whether the error has been caused by the aggregation pushdown
+// not being supported for the request, in which case there's no point to retry on other store-gateways.
+func isAggregationPushdownUnsupportedErr(err error) bool {
+	if st, ok := status.FromError(errors.Cause(err)); ok {
+		return st.Code() == codes.FailedPrecondition || st.Code() == codes.InvalidArgument
+	}
+	return false
+}
+
+func createAggregationPushdownSeriesRequest(minT, maxT int64, matchers []storepb.LabelMatcher, blockIDs []ulid.ULID, p *hintspb.AggregationPushdown) (*storepb.SeriesRequest, error) {
+	anyHints, err := types.MarshalAny(&hintspb.SeriesRequestHints{
+		BlockMatchers:       blockIDsMatchers(blockIDs),
+		AggregationPushdown: p,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal series request hints")
+	}
+
+	return &storepb.SeriesRequest{
+		MinTime:  minT,
+		MaxTime:  maxT,
+		Matchers: matchers,
+		Hints:    anyHints,
+	}, nil
+}
@@ -0,0 +1,295 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gogo/protobuf/types"
+	"github.com/grafana/dskit/services"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
+	"github.com/grafana/mimir/pkg/storegateway/pushdown"
+	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+func TestAggregationPushdownCoveredSteps(t *testing.T) {
+	var (
+		block1 = ulid.MustNew(1, nil)
+		block2 = ulid.MustNew(2, nil)
+		block3 = ulid.MustNew(3, nil)
+	)
+
+	// Steps at 100, 110, ..., 200 with a range of 20.
+	p := &hintspb.AggregationPushdown{Start: 100, End: 200, Step: 10, Range: 20}
+
+	tests := map[string]struct {
+		blocks           bucketindex.Blocks
+		expectedCovered  []bool
+		expectedRequired []ulid.ULID
+	}{
+		"no blocks": {
+			expectedCovered: []bool{true, true, true, true, true, true, true, true, true, true, true},
+		},
+		"single block covering all the steps": {
+			blocks:           bucketindex.Blocks{{ID: block1, MinTime: 0, MaxTime: 1000}},
+			expectedCovered:  []bool{true, true, true, true, true, true, true, true, true, true, true},
+			expectedRequired: []ulid.ULID{block1},
+		},
+		"consecutive blocks": {
+			blocks: bucketindex.Blocks{
+				{ID: block1, MinTime: 0, MaxTime: 150},
+				{ID: block2, MinTime: 150, MaxTime: 1000},
+			},
+			// The windows of the steps at 150, 160 span both blocks.
+			expectedCovered:  []bool{true, true, true, true, true, false, false, true, true, true, true},
+			expectedRequired: []ulid.ULID{block1, block2},
+		},
+		"block partially overlapping the query": {
+			blocks: bucketindex.Blocks{
+				{ID: block1, MinTime: 150, MaxTime: 1000},
+			},
+			// The windows of the steps at 150, 160 start before the block, while the windows of the previous
+			// steps don't overlap any block so there's nothing to evaluate.
+			expectedCovered:  []bool{true, true, true, true, true, false, false, true, true, true, true},
+			expectedRequired: []ulid.ULID{block1},
+		},
+		"shards of the same split compaction": {
+			blocks: bucketindex.Blocks{
+				{ID: block1, MinTime: 0, MaxTime: 1000, CompactorShardID: "1_of_2"},
+				{ID: block2, MinTime: 0, MaxTime: 1000, CompactorShardID: "2_of_2"},
+			},
+			expectedCovered:  []bool{true, true, true, true, true, true, true, true, true, true, true},
+			expectedRequired: []ulid.ULID{block1, block2},
+		},
+		"shards with the same index": {
+			blocks: bucketindex.Blocks{
+				{ID: block1, MinTime: 0, MaxTime: 1000, CompactorShardID: "1_of_2"},
+				{ID: block2, MinTime: 0, MaxTime: 1000, CompactorShardID: "1_of_2"},
+			},
+			expectedCovered: []bool{false, false, false, false, false, false, false, false, false, false, false},
+		},
+		"shards with a different count": {
+			blocks: bucketindex.Blocks{
+				{ID: block1, MinTime: 0, MaxTime: 1000, CompactorShardID: "1_of_2"},
+				{ID: block2, MinTime: 0, MaxTime: 1000, CompactorShardID: "2_of_4"},
+			},
+			expectedCovered: []bool{false, false, false, false, false, false, false, false, false, false, false},
+		},
+		"overlapping blocks which are not shards": {
+			blocks: bucketindex.Blocks{
+				{ID: block1, MinTime: 0, MaxTime: 1000},
+				{ID: block2, MinTime: 0, MaxTime: 1000},
+			},
+			expectedCovered: []bool{false, false, false, false, false, false, false, false, false, false, false},
+		},
+		"overlapping blocks with different time ranges": {
+			blocks: bucketindex.Blocks{
+				{ID: block1, MinTime: 0, MaxTime: 1000},
+				{ID: block2, MinTime: 130, MaxTime: 170},
+				{ID: block3, MinTime: 1000, MaxTime: 2000},
+			},
+			// The windows of the steps between 130 and 180 overlap the second block.
+			expectedCovered:  []bool{true, true, true, false, false, false, false, false, false, true, true},
+			expectedRequired: []ulid.ULID{block1},
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			covered, required := aggregationPushdownCoveredSteps(p, testData.blocks)
+			assert.Equal(t, testData.expectedCovered, covered)
+			assert.ElementsMatch(t, testData.expectedRequired, required.GetULIDs())
+		})
+	}
+}
+
+func TestBlocksStoreQuerier_SelectAggregationPushdown(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		metric  = labels.FromStrings(labels.MetricName, "metric", "job", "a")
+		block1  = ulid.MustNew(1, nil)
+		block2  = ulid.MustNew(2, nil)
+		blocks  = bucketindex.Blocks{{ID: block1, MinTime: 0, MaxTime: 100}, {ID: block2, MinTime: 100, MaxTime: 200}}
+		p       = &hintspb.AggregationPushdown{Aggregation: "sum", Function: "rate", Range: 20, Start: 20, End: 200, Step: 10}
+		matcher = labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "metric")
+	)
+
+	tests := map[string]struct {
+		storeGateway1Responses []*storepb.SeriesResponse
+		storeGateway2Responses []*storepb.SeriesResponse
+		expectedCovered        []bool
+		expectedMatrix         promql.Matrix
+		expectedErr            error
+	}{
+		"should merge the partial aggregates and only keep the covered steps": {
+			storeGateway1Responses: []*storepb.SeriesResponse{
+				mockSeriesResponseWithSamples(labels.EmptyLabels(), promql.FPoint{T: 20, F: 1}, promql.FPoint{T: 30, F: 2}, promql.FPoint{T: 90, F: 3}),
+				mockHintsResponse(block1),
+			},
+			storeGateway2Responses: []*storepb.SeriesResponse{
+				// The store-gateway may return steps which are not covered because of other blocks.
+				mockSeriesResponseWithSamples(labels.EmptyLabels(), promql.FPoint{T: 120, F: 4}, promql.FPoint{T: 190, F: 5}, promql.FPoint{T: 200, F: 6}),
+				mockHintsResponse(block2),
+			},
+			expectedCovered: []bool{true, true, true, true, true, true, true, true, false, false, true, true, true, true, true, true, true, true, false},
+			expectedMatrix: promql.Matrix{
+				{Metric: labels.EmptyLabels(), Floats: []promql.FPoint{{T: 20, F: 1}, {T: 30, F: 2}, {T: 90, F: 3}, {T: 120, F: 4}, {T: 190, F: 5}}},
+			},
+		},
+		"should fail if a store-gateway returns raw series": {
+			storeGateway1Responses: []*storepb.SeriesResponse{
+				mockSeriesResponse(metric, 20, 1),
+				mockHintsResponse(block1),
+			},
+			storeGateway2Responses: []*storepb.SeriesResponse{
+				mockSeriesResponseWithSamples(labels.EmptyLabels(), promql.FPoint{T: 120, F: 4}),
+				mockHintsResponse(block2),
+			},
+			expectedErr: errAggregationPushdownNotSupported,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			finder := &blocksFinderMock{Service: services.NewIdleService(nil, nil)}
+			finder.On("GetBlocks", mock.Anything, "user-1", mock.Anything, mock.Anything).Return(blocks, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), error(nil))
+
+			gateway1 := &storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: testData.storeGateway1Responses}
+			gateway2 := &storeGatewayClientMock{remoteAddr: "2.2.2.2", mockedSeriesResponses: testData.storeGateway2Responses}
+			stores := &blocksStoreSetMock{
+				Service: services.NewIdleService(nil, nil),
+				mockedResponses: []interface{}{
+					map[BlocksStoreClient][]ulid.ULID{gateway1: {block1}, gateway2: {block2}},
+				},
+			}
+
+			q := &blocksStoreQuerier{
+				minT:        p.Start - p.Range,
+				maxT:        p.End,
+				finder:      finder,
+				stores:      stores,
+				consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+				logger:      log.NewNopLogger(),
+				metrics:     newBlocksStoreQueryableMetrics(nil),
+				limits:      &blocksStoreLimitsMock{},
+			}
+
+			covered, matrix, err := q.selectAggregationPushdown(ctx, "user-1", p, []*labels.Matcher{matcher})
+			if testData.expectedErr != nil {
+				require.ErrorIs(t, err, testData.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedCovered, covered)
+			assert.Equal(t, testData.expectedMatrix, matrix)
+		})
+	}
+}
+
+// aggregationPushdownStoreGatewayMock is a store-gateway client mock evaluating the aggregation pushdown of the
+// query on the requested blocks, each one backed by a TSDB head.
+type aggregationPushdownStoreGatewayMock struct {
+	storeGatewayClientMock
+
+	engine *promql.Engine
+	query  string
+	blocks map[ulid.ULID]aggregationPushdownTestBlock
+
+	// rawSeries simulates a store-gateway not supporting the aggregation pushdown.
+	rawSeries bool
+}
+
+type aggregationPushdownTestBlock struct {
+	meta bucketindex.Block
+	head *tsdb.Head
+}
+
+func (m *aggregationPushdownStoreGatewayMock) Series(ctx context.Context, req *storepb.SeriesRequest, _ ...grpc.CallOption) (storegatewaypb.StoreGateway_SeriesClient, error) {
+	reqHints := hintspb.SeriesRequestHints{}
+	if err := types.UnmarshalAny(req.Hints, &reqHints); err != nil {
+		return nil, err
+	}
+
+	var (
+		p          = reqHints.AggregationPushdown
+		merge      = pushdown.MergeFunc(p.Aggregation)
+		partials   = map[string]*aggregationPushdownPartial{}
+		blockIDs   []ulid.ULID
+		respSeries []*storepb.SeriesResponse
+	)
+
+	for _, id := range strings.Split(reqHints.BlockMatchers[0].Value, "|") {
+		b := m.blocks[ulid.MustParse(id)]
+		blockIDs = append(blockIDs, b.meta.ID)
+
+		queryable := storage.QueryableFunc(func(mint, maxt int64) (storage.Querier, error) {
+			return tsdb.NewBlockQuerier(b.head, mint, maxt)
+		})
+
+		if m.rawSeries {
+			respSeries = append(respSeries, mockSeriesResponse(labels.FromStrings(labels.MetricName, "metric"), b.meta.MinTime, 1))
+			continue
+		}
+
+		first, last, ok := pushdown.CoveredSteps(p, b.meta.MinTime, b.meta.MaxTime)
+		if !ok {
+			continue
+		}
+
+		query, err := m.engine.NewRangeQuery(ctx, queryable, nil, m.query, time.UnixMilli(first), time.UnixMilli(last), time.Duration(p.Step)*time.Millisecond)
+		if err != nil {
+			return nil, err
+		}
+		matrix, err := query.Exec(ctx).Matrix()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, series := range matrix {
+			partial, ok := partials[series.Metric.String()]
+			if !ok {
+				partial = &aggregationPushdownPartial{lset: series.Metric, values: map[int64]float64{}}
+				partials[series.Metric.String()] = partial
+			}
+			for _, point := range series.Floats {
+				if prev, ok := partial.values[point.T]; ok {
+					partial.values[point.T] = merge(prev, point.F)
+				} else {
+					partial.values[point.T] = point.F
+				}
+			}
+		}
+		query.Close()
+	}
+
+	for _, partial := range partials {
+		points := make([]promql.FPoint, 0, len(partial.values))
+		for t, v := range partial.values {
+			points = append(points, promql.FPoint{T: t, F: v})
+		}
+		sort.Slice(points, func(i, j int) bool { return points[i].T < points[j].T })
+		respSeries = append(respSeries, mockSeriesResponseWithSamples(partial.lset, points...))
+	}
+
+	return &storeGatewaySeriesClientMock{
+		ClientStream:    grpcClientStreamMock{ctx: ctx},
+		mockedResponses: append(respSeries, mockHintsResponse(blockIDs...)),
+	}, nil
+}
@@ -0,0 +1,371 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/types"
+	"github.com/grafana/dskit/tenant"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"golang.org/x/sync/errgroup"
+	grpc_metadata "google.golang.org/grpc/metadata"
+
+	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	"github.com/grafana/mimir/pkg/storegateway"
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	"github.com/grafana/mimir/pkg/util"
+	util_math "github.com/grafana/mimir/pkg/util/math"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+)
+
+// BlocksCardinalityAnalyzer runs the cardinality analysis on the blocks of the long-term storage.
+type BlocksCardinalityAnalyzer interface {
+	// LabelNamesAndValues returns the label names, with their values, of the series matching the matchers
+	// in the blocks overlapping the time range.
+	LabelNamesAndValues(ctx context.Context, minT, maxT int64, matchers []*labels.Matcher) (*ingester_client.LabelNamesAndValuesResponse, error)
+
+	// LabelValuesCardinality returns the total number of series and the series count of each value of the label names,
+	// for the series matching the matchers in the blocks overlapping the time range. Because a series is stored in every
+	// block covering its lifetime, the series counts of the blocks covering different time ranges are not summed up:
+	// the max series count across time ranges is returned instead.
+	LabelValuesCardinality(ctx context.Context, minT, maxT int64, labelNames []model.LabelName, matchers []*labels.Matcher) (uint64, *ingester_client.LabelValuesCardinalityResponse, error)
+}
+
+// LabelNamesAndValues implements BlocksCardinalityAnalyzer.
+func (q *BlocksStoreQueryable) LabelNamesAndValues(ctx context.Context, minT, maxT int64, matchers []*labels.Matcher) (*ingester_client.LabelNamesAndValuesResponse, error) {
+	querier, err := q.Querier(minT, maxT)
+	if err != nil {
+		return nil, err
+	}
+	return querier.(*blocksStoreQuerier).labelNamesAndValues(ctx, matchers)
+}
+
+// LabelValuesCardinality implements BlocksCardinalityAnalyzer.
+func (q *BlocksStoreQueryable) LabelValuesCardinality(ctx context.Context, minT, maxT int64, labelNames []model.LabelName, matchers []*labels.Matcher) (uint64, *ingester_client.LabelValuesCardinalityResponse, error) {
+	querier, err := q.Querier(minT, maxT)
+	if err != nil {
+		return 0, nil, err
+	}
+	return querier.(*blocksStoreQuerier).labelValuesCardinality(ctx, labelNames, matchers)
+}
+
+func (q *blocksStoreQuerier) labelNamesAndValues(ctx context.Context, matchers []*labels.Matcher) (*ingester_client.LabelNamesAndValuesResponse, error) {
+	spanLog, ctx := spanlogger.NewWithLogger(ctx, q.logger, "blocksStoreQuerier.labelNamesAndValues")
+	defer spanLog.Span.Finish()
+
+	tenantID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	minT, maxT := q.clampLabelsQueryMinTime(spanLog, tenantID, matchers)
+
+	var (
+		resValueSets      = map[string][][]string{}
+		convertedMatchers = convertMatchersToLabelMatcher(matchers)
+	)
+
+	queryF := func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error) {
+		items, queriedBlocks, err := q.fetchLabelNamesCardinalityFromStore(ctx, clients, minT, maxT, tenantID, convertedMatchers)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			resValueSets[item.LabelName] = append(resValueSets[item.LabelName], item.Values)
+		}
+
+		return queriedBlocks, nil
+	}
+
+	if err := q.queryWithConsistencyCheck(ctx, spanLog, minT, maxT, tenantID, nil, downsample.ResLevel0, queryF); err != nil {
+		return nil, err
+	}
+
+	resp := &ingester_client.LabelNamesAndValuesResponse{Items: make([]*ingester_client.LabelValues, 0, len(resValueSets))}
+	for name, valueSets := range resValueSets {
+		resp.Items = append(resp.Items, &ingester_client.LabelValues{LabelName: name, Values: util.MergeSlices(valueSets...)})
+	}
+	sort.Slice(resp.Items, func(i, j int) bool { return resp.Items[i].LabelName < resp.Items[j].LabelName })
+	return resp, nil
+}
+
+func (q *blocksStoreQuerier) labelValuesCardinality(ctx context.Context, labelNames []model.LabelName, matchers []*labels.Matcher) (uint64, *ingester_client.LabelValuesCardinalityResponse, error) {
+	spanLog, ctx := spanlogger.NewWithLogger(ctx, q.logger, "blocksStoreQuerier.labelValuesCardinality")
+	defer spanLog.Span.Finish()
+
+	tenantID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	minT, maxT := q.clampLabelsQueryMinTime(spanLog, tenantID, matchers)
+
+	var (
+		resGroups         []storepb.LabelValuesCardinalityGroup
+		convertedMatchers = convertMatchersToLabelMatcher(matchers)
+		names             = make([]string, 0, len(labelNames))
+	)
+	for _, name := range labelNames {
+		names = append(names, string(name))
+	}
+
+	queryF := func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error) {
+		groups, queriedBlocks, err := q.fetchLabelValuesCardinalityFromStore(ctx, clients, minT, maxT, tenantID, names, convertedMatchers)
+		if err != nil {
+			return nil, err
+		}
+
+		resGroups = append(resGroups, groups...)
+
+		return queriedBlocks, nil
+	}
+
+	if err := q.queryWithConsistencyCheck(ctx, spanLog, minT, maxT, tenantID, nil, downsample.ResLevel0, queryF); err != nil {
+		return 0, nil, err
+	}
+
+	seriesCountTotal, resp := labelValuesCardinalityFromGroups(storepb.MergeLabelValuesCardinalityGroups(resGroups))
+	return seriesCountTotal, resp, nil
+}
+
+// clampLabelsQueryMinTime returns the time range of the querier, with the min time clamped to honor
+// the max labels query length.
+func (q *blocksStoreQuerier) clampLabelsQueryMinTime(spanLog *spanlogger.SpanLogger, tenantID string, matchers []*labels.Matcher) (minT, maxT int64) {
+	minT, maxT = q.minT, q.maxT
+
+	level.Debug(spanLog).Log("start", util.TimeFromMillis(minT).UTC().String(), "end",
+		util.TimeFromMillis(maxT).UTC().String(), "matchers", util.MatchersStringer(matchers))
+
+	if maxQueryLength := q.limits.MaxLabelsQueryLength(tenantID); maxQueryLength != 0 {
+		minT = clampMinTime(spanLog, minT, maxT, -maxQueryLength, "max label query length")
+	}
+	return minT, maxT
+}
+
+// labelValuesCardinalityFromGroups merges the series counts of the groups covering different time ranges,
+// taking the max series count of each label value across time ranges.
+func labelValuesCardinalityFromGroups(groups []storepb.LabelValuesCardinalityGroup) (uint64, *ingester_client.LabelValuesCardinalityResponse) {
+	var (
+		seriesCountTotal uint64
+		resp             = &ingester_client.LabelValuesCardinalityResponse{}
+	)
+
+	for _, group := range groups {
+		seriesCountTotal = util_math.Max(seriesCountTotal, group.SeriesCount)
+
+		for _, item := range group.Items {
+			if len(item.LabelValueSeries) == 0 {
+				continue
+			}
+
+			var dst *ingester_client.LabelValueSeriesCount
+			for _, respItem := range resp.Items {
+				if respItem.LabelName == item.LabelName {
+					dst = respItem
+					break
+				}
+			}
+			if dst == nil {
+				dst = &ingester_client.LabelValueSeriesCount{LabelName: item.LabelName, LabelValueSeries: make(map[string]uint64, len(item.LabelValueSeries))}
+				resp.Items = append(resp.Items, dst)
+			}
+
+			for value, count := range item.LabelValueSeries {
+				dst.LabelValueSeries[value] = util_math.Max(dst.LabelValueSeries[value], count)
+			}
+		}
+	}
+
+	return seriesCountTotal, resp
+}
+
+func (q *blocksStoreQuerier) fetchLabelNamesCardinalityFromStore(
+	ctx context.Context,
+	clients map[BlocksStoreClient][]ulid.ULID,
+	minT int64,
+	maxT int64,
+	tenantID string,
+	matchers []storepb.LabelMatcher,
+) ([]*storepb.LabelNameValues, []ulid.ULID, error) {
+	var (
+		reqCtx        = grpc_metadata.AppendToOutgoingContext(ctx, storegateway.GrpcContextMetadataTenantID, tenantID)
+		g, gCtx       = errgroup.WithContext(reqCtx)
+		mtx           = sync.Mutex{}
+		items         []*storepb.LabelNameValues
+		queriedBlocks = []ulid.ULID(nil)
+		spanLog       = spanlogger.FromContext(ctx, q.logger)
+	)
+
+	// Concurrently fetch label names and values from all clients.
+	for c, blockIDs := range clients {
+		// Change variables scope since it will be used in a goroutine.
+		c := c
+		blockIDs := blockIDs
+
+		g.Go(func() error {
+			anyHints, err := types.MarshalAny(&hintspb.LabelNamesRequestHints{BlockMatchers: blockIDsMatchers(blockIDs)})
+			if err != nil {
+				return errors.Wrapf(err, "failed to marshal label names cardinality request hints")
+			}
+
+			resp, err := c.LabelNamesCardinality(gCtx, &storepb.LabelNamesCardinalityRequest{
+				Start:    minT,
+				End:      maxT,
+				Hints:    anyHints,
+				Matchers: matchers,
+			})
+			if err != nil {
+				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+					return err
+				}
+
+				level.Warn(spanLog).Log("msg", "failed to fetch label names cardinality", "remote", c.RemoteAddress(), "err", err)
+				return nil
+			}
+
+			myQueriedBlocks := []ulid.ULID(nil)
+			if resp.Hints != nil {
+				hints := hintspb.LabelNamesResponseHints{}
+				if err := types.UnmarshalAny(resp.Hints, &hints); err != nil {
+					return errors.Wrapf(err, "failed to unmarshal label names cardinality hints from %s", c.RemoteAddress())
+				}
+
+				ids, err := convertBlockHintsToULIDs(hints.QueriedBlocks)
+				if err != nil {
+					return errors.Wrapf(err, "failed to parse queried block IDs from received hints")
+				}
+
+				myQueriedBlocks = ids
+			}
+
+			level.Debug(spanLog).Log("msg", "received label names cardinality from store-gateway",
+				"instance", c.RemoteAddress(),
+				"num labels", len(resp.Items),
+				"requested blocks", strings.Join(convertULIDsToString(blockIDs), " "),
+				"queried blocks", strings.Join(convertULIDsToString(myQueriedBlocks), " "))
+
+			// Store the result.
+			mtx.Lock()
+			items = append(items, resp.Items...)
+			queriedBlocks = append(queriedBlocks, myQueriedBlocks...)
+			mtx.Unlock()
+
+			return nil
+		})
+	}
+
+	// Wait until all client requests complete.
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	return items, queriedBlocks, nil
+}
+
+func (q *blocksStoreQuerier) fetchLabelValuesCardinalityFromStore(
+	ctx context.Context,
+	clients map[BlocksStoreClient][]ulid.ULID,
+	minT int64,
+	maxT int64,
+	tenantID string,
+	labelNames []string,
+	matchers []storepb.LabelMatcher,
+) ([]storepb.LabelValuesCardinalityGroup, []ulid.ULID, error) {
+	var (
+		reqCtx        = grpc_metadata.AppendToOutgoingContext(ctx, storegateway.GrpcContextMetadataTenantID, tenantID)
+		g, gCtx       = errgroup.WithContext(reqCtx)
+		mtx           = sync.Mutex{}
+		groups        []storepb.LabelValuesCardinalityGroup
+		queriedBlocks = []ulid.ULID(nil)
+		spanLog       = spanlogger.FromContext(ctx, q.logger)
+	)
+
+	// Concurrently fetch label values cardinality from all clients.
+	for c, blockIDs := range clients {
+		// Change variables scope since it will be used in a goroutine.
+		c := c
+		blockIDs := blockIDs
+
+		g.Go(func() error {
+			anyHints, err := types.MarshalAny(&hintspb.LabelValuesRequestHints{BlockMatchers: blockIDsMatchers(blockIDs)})
+			if err != nil {
+				return errors.Wrapf(err, "failed to marshal label values cardinality request hints")
+			}
+
+			resp, err := c.LabelValuesCardinality(gCtx, &storepb.LabelValuesCardinalityRequest{
+				Start:      minT,
+				End:        maxT,
+				Hints:      anyHints,
+				Matchers:   matchers,
+				LabelNames: labelNames,
+			})
+			if err != nil {
+				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+					return err
+				}
+
+				level.Warn(spanLog).Log("msg", "failed to fetch label values cardinality", "remote", c.RemoteAddress(), "err", err)
+				return nil
+			}
+
+			myQueriedBlocks := []ulid.ULID(nil)
+			if resp.Hints != nil {
+				hints := hintspb.LabelValuesResponseHints{}
+				if err := types.UnmarshalAny(resp.Hints, &hints); err != nil {
+					return errors.Wrapf(err, "failed to unmarshal label values cardinality hints from %s", c.RemoteAddress())
+				}
+
+				ids, err := convertBlockHintsToULIDs(hints.QueriedBlocks)
+				if err != nil {
+					return errors.Wrapf(err, "failed to parse queried block IDs from received hints")
+				}
+
+				myQueriedBlocks = ids
+			}
+
+			level.Debug(spanLog).Log("msg", "received label values cardinality from store-gateway",
+				"instance", c.RemoteAddress(),
+				"num groups", len(resp.Groups),
+				"requested blocks", strings.Join(convertULIDsToString(blockIDs), " "),
+				"queried blocks", strings.Join(convertULIDsToString(myQueriedBlocks), " "))
+
+			// Store the result.
+			mtx.Lock()
+			groups = append(groups, resp.Groups...)
+			queriedBlocks = append(queriedBlocks, myQueriedBlocks...)
+			mtx.Unlock()
+
+			return nil
+		})
+	}
+
+	// Wait until all client requests complete.
+	if err := g.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	return groups, queriedBlocks, nil
+}
+
+// blockIDsMatchers returns the block matchers to selectively query only the given blocks.
+func blockIDsMatchers(blockIDs []ulid.ULID) []storepb.LabelMatcher {
+	return []storepb.LabelMatcher{
+		{
+			Type:  storepb.LabelMatcher_RE,
+			Name:  block.BlockIDLabel,
+			Value: strings.Join(convertULIDsToString(blockIDs), "|"),
+		},
+	}
+}
@@ -0,0 +1,231 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+func TestBlocksStoreQuerier_LabelNamesAndValues(t *testing.T) {
+	const (
+		minT = int64(10)
+		maxT = int64(20)
+	)
+
+	var (
+		block1 = ulid.MustNew(1, nil)
+		block2 = ulid.MustNew(2, nil)
+	)
+
+	tests := map[string]struct {
+		finderResult      bucketindex.Blocks
+		storeSetResponses []interface{}
+		expected          *ingester_client.LabelNamesAndValuesResponse
+		expectedErr       string
+	}{
+		"no block in the storage matching the query time range": {
+			expected: &ingester_client.LabelNamesAndValuesResponse{Items: []*ingester_client.LabelValues{}},
+		},
+		"multiple store-gateway instances holding the required blocks": {
+			finderResult: bucketindex.Blocks{{ID: block1}, {ID: block2}},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{
+						remoteAddr: "1.1.1.1",
+						mockedLabelNamesCardinalityResponse: &storepb.LabelNamesCardinalityResponse{
+							Items: []*storepb.LabelNameValues{
+								{LabelName: "job", Values: []string{"a", "c"}},
+								{LabelName: "zone", Values: []string{"z1"}},
+							},
+							Hints: mockNamesHints(block1),
+						},
+					}: {block1},
+					&storeGatewayClientMock{
+						remoteAddr: "2.2.2.2",
+						mockedLabelNamesCardinalityResponse: &storepb.LabelNamesCardinalityResponse{
+							Items: []*storepb.LabelNameValues{
+								{LabelName: "job", Values: []string{"b", "c"}},
+								{LabelName: "instance", Values: []string{"i1"}},
+							},
+							Hints: mockNamesHints(block2),
+						},
+					}: {block2},
+				},
+			},
+			expected: &ingester_client.LabelNamesAndValuesResponse{Items: []*ingester_client.LabelValues{
+				{LabelName: "instance", Values: []string{"i1"}},
+				{LabelName: "job", Values: []string{"a", "b", "c"}},
+				{LabelName: "zone", Values: []string{"z1"}},
+			}},
+		},
+		"a block is not queried": {
+			finderResult: bucketindex.Blocks{{ID: block1}, {ID: block2}},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{
+						remoteAddr: "1.1.1.1",
+						mockedLabelNamesCardinalityResponse: &storepb.LabelNamesCardinalityResponse{
+							Items: []*storepb.LabelNameValues{{LabelName: "job", Values: []string{"a"}}},
+							Hints: mockNamesHints(block1),
+						},
+					}: {block1, block2},
+				},
+				errors.New("no store-gateway remaining after exclude"),
+			},
+			expectedErr: "failed to fetch some blocks",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+			q := newBlocksStoreQuerierForCardinalityTest(minT, maxT, testData.finderResult, testData.storeSetResponses)
+
+			resp, err := q.labelNamesAndValues(ctx, nil)
+			if testData.expectedErr != "" {
+				require.ErrorContains(t, err, testData.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testData.expected, resp)
+		})
+	}
+}
+
+func TestBlocksStoreQuerier_LabelValuesCardinality(t *testing.T) {
+	const (
+		minT = int64(10)
+		maxT = int64(20)
+	)
+
+	var (
+		block1 = ulid.MustNew(1, nil)
+		block2 = ulid.MustNew(2, nil)
+		block3 = ulid.MustNew(3, nil)
+	)
+
+	tests := map[string]struct {
+		finderResult          bucketindex.Blocks
+		storeSetResponses     []interface{}
+		expectedSeriesCount   uint64
+		expectedSeriesCountBy map[string]map[string]uint64
+	}{
+		"no block in the storage matching the query time range": {
+			expectedSeriesCountBy: map[string]map[string]uint64{},
+		},
+		"blocks of different compactor shards covering the same time range are summed up": {
+			finderResult: bucketindex.Blocks{{ID: block1}, {ID: block2}},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{
+						remoteAddr: "1.1.1.1",
+						mockedLabelValuesCardinalityResponse: &storepb.LabelValuesCardinalityResponse{
+							Groups: []storepb.LabelValuesCardinalityGroup{{
+								MinTime: 0, MaxTime: 10, SeriesCount: 5,
+								Items: []storepb.LabelValueSeriesCount{{LabelName: "job", LabelValueSeries: map[string]uint64{"a": 3, "b": 2}}},
+							}},
+							Hints: mockValuesHints(block1),
+						},
+					}: {block1},
+					&storeGatewayClientMock{
+						remoteAddr: "2.2.2.2",
+						mockedLabelValuesCardinalityResponse: &storepb.LabelValuesCardinalityResponse{
+							Groups: []storepb.LabelValuesCardinalityGroup{{
+								MinTime: 0, MaxTime: 10, SeriesCount: 4,
+								Items: []storepb.LabelValueSeriesCount{{LabelName: "job", LabelValueSeries: map[string]uint64{"a": 1, "c": 3}}},
+							}},
+							Hints: mockValuesHints(block2),
+						},
+					}: {block2},
+				},
+			},
+			expectedSeriesCount:   9,
+			expectedSeriesCountBy: map[string]map[string]uint64{"job": {"a": 4, "b": 2, "c": 3}},
+		},
+		"replicated blocks covering the same time range and blocks covering different time ranges take the max": {
+			finderResult: bucketindex.Blocks{{ID: block1}, {ID: block2}, {ID: block3}},
+			storeSetResponses: []interface{}{
+				map[BlocksStoreClient][]ulid.ULID{
+					&storeGatewayClientMock{
+						remoteAddr: "1.1.1.1",
+						mockedLabelValuesCardinalityResponse: &storepb.LabelValuesCardinalityResponse{
+							Groups: []storepb.LabelValuesCardinalityGroup{{
+								MinTime: 0, MaxTime: 10, Replicated: true, SeriesCount: 5,
+								Items: []storepb.LabelValueSeriesCount{{LabelName: "job", LabelValueSeries: map[string]uint64{"a": 3, "b": 2}}},
+							}},
+							Hints: mockValuesHints(block1),
+						},
+					}: {block1},
+					&storeGatewayClientMock{
+						remoteAddr: "2.2.2.2",
+						mockedLabelValuesCardinalityResponse: &storepb.LabelValuesCardinalityResponse{
+							Groups: []storepb.LabelValuesCardinalityGroup{
+								{
+									MinTime: 0, MaxTime: 10, Replicated: true, SeriesCount: 6,
+									Items: []storepb.LabelValueSeriesCount{{LabelName: "job", LabelValueSeries: map[string]uint64{"a": 4, "b": 2}}},
+								},
+								{
+									MinTime: 10, MaxTime: 20, SeriesCount: 3,
+									Items: []storepb.LabelValueSeriesCount{{LabelName: "job", LabelValueSeries: map[string]uint64{"b": 3}}},
+								},
+							},
+							Hints: mockValuesHints(block2, block3),
+						},
+					}: {block2, block3},
+				},
+			},
+			expectedSeriesCount:   6,
+			expectedSeriesCountBy: map[string]map[string]uint64{"job": {"a": 4, "b": 3}},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+			q := newBlocksStoreQuerierForCardinalityTest(minT, maxT, testData.finderResult, testData.storeSetResponses)
+
+			seriesCount, resp, err := q.labelValuesCardinality(ctx, []model.LabelName{"job"}, []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "env", "prod")})
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedSeriesCount, seriesCount)
+
+			actual := map[string]map[string]uint64{}
+			for _, item := range resp.Items {
+				actual[item.LabelName] = item.LabelValueSeries
+			}
+			assert.Equal(t, testData.expectedSeriesCountBy, actual)
+		})
+	}
+}
+
+func newBlocksStoreQuerierForCardinalityTest(minT, maxT int64, finderResult bucketindex.Blocks, storeSetResponses []interface{}) *blocksStoreQuerier {
+	finder := &blocksFinderMock{}
+	finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(finderResult, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+	return &blocksStoreQuerier{
+		minT:        minT,
+		maxT:        maxT,
+		finder:      finder,
+		stores:      &blocksStoreSetMock{mockedResponses: storeSetResponses},
+		consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+		logger:      log.NewNopLogger(),
+		metrics:     newBlocksStoreQueryableMetrics(prometheus.NewPedanticRegistry()),
+		limits:      &blocksStoreLimitsMock{},
+	}
+}
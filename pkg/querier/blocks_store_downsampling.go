@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"sort"
+	"time"
+
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+)
+
+// minSamplesPerWindow is the min number of samples of a downsampled block that a range function window,
+// or a query step, must include for the downsampled block to be queried instead of the raw blocks.
+const minSamplesPerWindow = 5
+
+// maxResolutionForHints returns the coarsest resolution of the blocks which can be queried to evaluate
+// the query with the given hints. It's the raw resolution unless the query is a range query whose step
+// and range function windows are large enough to include several samples of the downsampled blocks.
+func maxResolutionForHints(sp *storage.SelectHints, lookbackDelta time.Duration) int64 {
+	if sp == nil || sp.Step <= 0 {
+		return downsample.ResLevel0
+	}
+
+	// The downsampled blocks don't preserve the number of samples in a window.
+	if sp.Func == "count_over_time" {
+		return downsample.ResLevel0
+	}
+
+	for i := len(downsample.Resolutions) - 1; i > 0; i-- {
+		res := downsample.Resolutions[i]
+		if res*minSamplesPerWindow > sp.Step {
+			continue
+		}
+		if sp.Range > 0 && res*minSamplesPerWindow > sp.Range {
+			continue
+		}
+		// Instant vector selectors need a sample of the downsampled block within the lookback delta.
+		if sp.Range == 0 && res > lookbackDelta.Milliseconds() {
+			continue
+		}
+		return res
+	}
+	return downsample.ResLevel0
+}
+
+// aggrTypeForHints returns the aggregate of the downsampled blocks to read to evaluate the query with the given hints.
+func aggrTypeForHints(sp *storage.SelectHints) downsample.AggrType {
+	if sp == nil {
+		return downsample.AggrAvg
+	}
+
+	switch sp.Func {
+	case "min", "min_over_time":
+		return downsample.AggrMin
+	case "max", "max_over_time":
+		return downsample.AggrMax
+	case "sum_over_time":
+		return downsample.AggrSum
+	case "rate", "increase", "irate", "resets":
+		return downsample.AggrCounter
+	default:
+		return downsample.AggrAvg
+	}
+}
+
+// selectBlocksByResolution returns the blocks to query to cover the time range between minT and maxT (both included)
+// with the coarsest resolution not greater than maxResolution. The time ranges which aren't covered by the blocks with
+// such resolution are covered by the blocks of the other resolutions: the finer compatible resolutions first, and then
+// the coarser ones.
+func selectBlocksByResolution(blocks bucketindex.Blocks, minT, maxT, maxResolution int64) bucketindex.Blocks {
+	// Nothing to select if all the blocks have the same resolution.
+	if !hasMultipleResolutions(blocks) {
+		return blocks
+	}
+
+	var preferred, others []int64
+	for _, res := range downsample.Resolutions {
+		if res <= maxResolution {
+			preferred = append([]int64{res}, preferred...)
+		} else {
+			others = append(others, res)
+		}
+	}
+
+	return selectBlocksByResolutionOrder(blocks, minT, maxT, append(preferred, others...))
+}
+
+func selectBlocksByResolutionOrder(blocks bucketindex.Blocks, minT, maxT int64, resolutions []int64) bucketindex.Blocks {
+	if len(resolutions) == 0 || minT > maxT {
+		return nil
+	}
+
+	var candidates bucketindex.Blocks
+	for _, b := range blocks {
+		if b.Resolution == resolutions[0] && b.Within(minT, maxT) {
+			candidates = append(candidates, b)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].MinTime < candidates[j].MinTime
+	})
+
+	var (
+		res  bucketindex.Blocks
+		next = minT
+	)
+	for _, b := range candidates {
+		// Fill the gap before the block with the blocks of the next resolutions.
+		if b.MinTime > next {
+			res = append(res, selectBlocksByResolutionOrder(blocks, next, b.MinTime-1, resolutions[1:])...)
+		}
+		res = append(res, b)
+
+		// The block max time is exclusive.
+		if b.MaxTime > next {
+			next = b.MaxTime
+		}
+	}
+	return append(res, selectBlocksByResolutionOrder(blocks, next, maxT, resolutions[1:])...)
+}
+
+func hasMultipleResolutions(blocks bucketindex.Blocks) bool {
+	for _, b := range blocks {
+		if b.Resolution != blocks[0].Resolution {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,407 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/services"
+	"github.com/grafana/dskit/user"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+func TestMaxResolutionForHints(t *testing.T) {
+	const lookbackDelta = 5 * time.Minute
+
+	tests := map[string]struct {
+		hints    *storage.SelectHints
+		expected int64
+	}{
+		"no hints": {
+			expected: downsample.ResLevel0,
+		},
+		"instant query": {
+			hints:    &storage.SelectHints{Range: time.Hour.Milliseconds()},
+			expected: downsample.ResLevel0,
+		},
+		"range query with a small step": {
+			hints:    &storage.SelectHints{Step: time.Minute.Milliseconds(), Range: time.Hour.Milliseconds(), Func: "rate"},
+			expected: downsample.ResLevel0,
+		},
+		"range query with a step large enough for the 5m resolution": {
+			hints:    &storage.SelectHints{Step: 30 * time.Minute.Milliseconds(), Range: time.Hour.Milliseconds(), Func: "rate"},
+			expected: downsample.ResLevel1,
+		},
+		"range query with a step large enough for the 1h resolution": {
+			hints:    &storage.SelectHints{Step: 6 * time.Hour.Milliseconds(), Range: 6 * time.Hour.Milliseconds(), Func: "rate"},
+			expected: downsample.ResLevel2,
+		},
+		"range query with a range function window too small for the step": {
+			hints:    &storage.SelectHints{Step: 6 * time.Hour.Milliseconds(), Range: 10 * time.Minute.Milliseconds(), Func: "rate"},
+			expected: downsample.ResLevel0,
+		},
+		"range query with a range function window large enough for the 5m resolution only": {
+			hints:    &storage.SelectHints{Step: 6 * time.Hour.Milliseconds(), Range: time.Hour.Milliseconds(), Func: "rate"},
+			expected: downsample.ResLevel1,
+		},
+		"range query with count_over_time": {
+			hints:    &storage.SelectHints{Step: 6 * time.Hour.Milliseconds(), Range: 6 * time.Hour.Milliseconds(), Func: "count_over_time"},
+			expected: downsample.ResLevel0,
+		},
+		"range query with instant vector selector and a step large enough for the 1h resolution": {
+			hints:    &storage.SelectHints{Step: 6 * time.Hour.Milliseconds()},
+			expected: downsample.ResLevel1,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, maxResolutionForHints(testData.hints, lookbackDelta))
+		})
+	}
+}
+
+func TestAggrTypeForHints(t *testing.T) {
+	tests := map[string]struct {
+		hints    *storage.SelectHints
+		expected downsample.AggrType
+	}{
+		"no hints":        {expected: downsample.AggrAvg},
+		"no function":     {hints: &storage.SelectHints{}, expected: downsample.AggrAvg},
+		"rate":            {hints: &storage.SelectHints{Func: "rate"}, expected: downsample.AggrCounter},
+		"increase":        {hints: &storage.SelectHints{Func: "increase"}, expected: downsample.AggrCounter},
+		"min_over_time":   {hints: &storage.SelectHints{Func: "min_over_time"}, expected: downsample.AggrMin},
+		"max":             {hints: &storage.SelectHints{Func: "max"}, expected: downsample.AggrMax},
+		"sum_over_time":   {hints: &storage.SelectHints{Func: "sum_over_time"}, expected: downsample.AggrSum},
+		"avg_over_time":   {hints: &storage.SelectHints{Func: "avg_over_time"}, expected: downsample.AggrAvg},
+		"other functions": {hints: &storage.SelectHints{Func: "sum"}, expected: downsample.AggrAvg},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, aggrTypeForHints(testData.hints))
+		})
+	}
+}
+
+func TestSelectBlocksByResolution(t *testing.T) {
+	newBlock := func(id uint64, minT, maxT, resolution int64) *bucketindex.Block {
+		return &bucketindex.Block{ID: ulid.MustNew(id, nil), MinTime: minT, MaxTime: maxT, Resolution: resolution}
+	}
+
+	var (
+		raw1 = newBlock(1, 0, 100, downsample.ResLevel0)
+		raw2 = newBlock(2, 100, 200, downsample.ResLevel0)
+		raw3 = newBlock(3, 200, 300, downsample.ResLevel0)
+		res1 = newBlock(4, 0, 100, downsample.ResLevel1)
+		res2 = newBlock(5, 100, 200, downsample.ResLevel1)
+		res3 = newBlock(6, 0, 100, downsample.ResLevel2)
+	)
+
+	tests := map[string]struct {
+		blocks        bucketindex.Blocks
+		minT, maxT    int64
+		maxResolution int64
+		expected      bucketindex.Blocks
+	}{
+		"only raw blocks": {
+			blocks:        bucketindex.Blocks{raw1, raw2},
+			minT:          0,
+			maxT:          199,
+			maxResolution: downsample.ResLevel2,
+			expected:      bucketindex.Blocks{raw1, raw2},
+		},
+		"raw resolution requested": {
+			blocks:        bucketindex.Blocks{raw1, raw2, raw3, res1, res2, res3},
+			minT:          0,
+			maxT:          299,
+			maxResolution: downsample.ResLevel0,
+			expected:      bucketindex.Blocks{raw1, raw2, raw3},
+		},
+		"5m resolution requested, and the time range not covered by 5m blocks is covered by the raw ones": {
+			blocks:        bucketindex.Blocks{raw1, raw2, raw3, res1, res2, res3},
+			minT:          0,
+			maxT:          299,
+			maxResolution: downsample.ResLevel1,
+			expected:      bucketindex.Blocks{res1, res2, raw3},
+		},
+		"1h resolution requested, and the gaps are covered by the finer resolutions": {
+			blocks:        bucketindex.Blocks{raw1, raw2, raw3, res1, res2, res3},
+			minT:          0,
+			maxT:          299,
+			maxResolution: downsample.ResLevel2,
+			expected:      bucketindex.Blocks{res3, res2, raw3},
+		},
+		"raw resolution requested, and the gaps are covered by the coarser resolutions": {
+			blocks:        bucketindex.Blocks{raw2, res1, res3},
+			minT:          0,
+			maxT:          199,
+			maxResolution: downsample.ResLevel0,
+			expected:      bucketindex.Blocks{res1, raw2},
+		},
+		"blocks outside the time range are not selected": {
+			blocks:        bucketindex.Blocks{raw1, raw2, raw3, res1, res2},
+			minT:          150,
+			maxT:          250,
+			maxResolution: downsample.ResLevel1,
+			expected:      bucketindex.Blocks{res2, raw3},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			assert.Equal(t, testData.expected, selectBlocksByResolution(testData.blocks, testData.minT, testData.maxT, testData.maxResolution))
+		})
+	}
+}
+
+func TestBlocksStoreQuerier_RateAcrossResolutions(t *testing.T) {
+	const (
+		day            = 24 * time.Hour
+		scrapeInterval = 15 * time.Second
+	)
+
+	var (
+		ctx    = context.Background()
+		logger = log.NewNopLogger()
+		dir    = t.TempDir()
+		series = labels.FromStrings("__name__", "counter")
+	)
+
+	// Generate two daily raw blocks of a counter increasing by 1 at each scrape and reset every 1000 scrapes,
+	// and downsample the first one to 5m and 1h, so that the second day is only covered by the raw block.
+	newRawBlock := func(minT, maxT time.Duration) *block.Meta {
+		chk := chunkenc.NewXORChunk()
+		app, err := chk.Appender()
+		require.NoError(t, err)
+
+		var chks []chunks.Meta
+		for ts := minT; ts < maxT; ts += scrapeInterval {
+			if chk.NumSamples() == 120 {
+				chks = append(chks, chunks.Meta{MinTime: (ts - 120*scrapeInterval).Milliseconds(), MaxTime: (ts - scrapeInterval).Milliseconds(), Chunk: chk})
+				chk = chunkenc.NewXORChunk()
+				app, err = chk.Appender()
+				require.NoError(t, err)
+			}
+			app.Append(ts.Milliseconds(), float64(int64(ts/scrapeInterval)%1000))
+		}
+		chks = append(chks, chunks.Meta{MinTime: (maxT - time.Duration(chk.NumSamples())*scrapeInterval).Milliseconds(), MaxTime: (maxT - scrapeInterval).Milliseconds(), Chunk: chk})
+
+		meta, err := block.GenerateBlockFromSpec("", dir, block.SeriesSpecs{{Labels: series, Chunks: chks}})
+		require.NoError(t, err)
+		return meta
+	}
+	downsampleBlock := func(meta *block.Meta, resolution int64) *block.Meta {
+		b, err := tsdb.OpenBlock(logger, filepath.Join(dir, meta.ULID.String()), downsample.NewPool())
+		require.NoError(t, err)
+		defer func() { require.NoError(t, b.Close()) }()
+
+		id, err := downsample.Downsample(ctx, logger, meta, b, dir, resolution)
+		require.NoError(t, err)
+
+		newMeta, err := block.ReadMetaFromDir(filepath.Join(dir, id.String()))
+		require.NoError(t, err)
+		return newMeta
+	}
+
+	raw1 := newRawBlock(0, day)
+	raw2 := newRawBlock(day, 2*day)
+	res5m := downsampleBlock(raw1, downsample.ResLevel1)
+	res1h := downsampleBlock(res5m, downsample.ResLevel2)
+
+	// Each block is held by a different store-gateway.
+	clients := map[ulid.ULID]*storeGatewayClientMock{}
+	for _, meta := range []*block.Meta{raw1, raw2, res5m, res1h} {
+		clients[meta.ULID] = &storeGatewayClientMock{
+			remoteAddr: meta.ULID.String(),
+			mockedSeriesResponses: []*storepb.SeriesResponse{
+				mockSeriesResponseWithChunks(series, readBlockAggrChunks(t, filepath.Join(dir, meta.ULID.String()))...),
+				mockHintsResponse(meta.ULID),
+			},
+		}
+	}
+
+	runQuery := func(t *testing.T, query string, start, end time.Time, step time.Duration, metas []*block.Meta, streaming bool) (promql.Matrix, []ulid.ULID) {
+		var knownBlocks bucketindex.Blocks
+		for _, meta := range metas {
+			knownBlocks = append(knownBlocks, bucketindex.BlockFromThanosMeta(*meta))
+		}
+
+		finder := &blocksFinderMock{Service: services.NewIdleService(nil, nil)}
+		finder.On("GetBlocks", mock.Anything, "user-1", mock.Anything, mock.Anything).Return(knownBlocks, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), error(nil))
+
+		stores := &blocksStoreSetByBlockMock{Service: services.NewIdleService(nil, nil), clients: map[ulid.ULID]BlocksStoreClient{}}
+		for id, client := range clients {
+			c := *client
+			if streaming {
+				c.mockedSeriesResponses = generateStreamingResponses(c.mockedSeriesResponses)
+			}
+			stores.clients[id] = &c
+		}
+
+		queryable, err := NewBlocksStoreQueryable(stores, finder, NewBlocksConsistencyChecker(0, 0, logger, nil), &blocksStoreLimitsMock{}, 0, 5*time.Minute, 0, 0, logger, nil)
+		require.NoError(t, err)
+		require.NoError(t, services.StartAndAwaitRunning(ctx, queryable))
+		defer services.StopAndAwaitTerminated(ctx, queryable) // nolint:errcheck
+
+		engine := promql.NewEngine(promql.EngineOpts{
+			Logger:     logger,
+			Timeout:    10 * time.Second,
+			MaxSamples: 1e6,
+		})
+
+		q, err := engine.NewRangeQuery(user.InjectOrgID(ctx, "user-1"), queryable, nil, query, start, end, step)
+		require.NoError(t, err)
+		res := q.Exec(user.InjectOrgID(ctx, "user-1"))
+		require.NoError(t, res.Err)
+
+		matrix, err := res.Matrix()
+		require.NoError(t, err)
+		return matrix, stores.queriedBlocks()
+	}
+
+	tests := map[string]struct {
+		query           string
+		start, end      time.Time
+		step            time.Duration
+		expectedQueried []ulid.ULID
+	}{
+		"5m resolution": {
+			query:           "rate(counter[1h])",
+			start:           time.UnixMilli(0).Add(2 * time.Hour),
+			end:             time.UnixMilli(0).Add(2*day - time.Hour),
+			step:            30 * time.Minute,
+			expectedQueried: []ulid.ULID{res5m.ULID, raw2.ULID},
+		},
+		"1h resolution": {
+			query:           "rate(counter[6h])",
+			start:           time.UnixMilli(0).Add(6 * time.Hour),
+			end:             time.UnixMilli(0).Add(2 * day),
+			step:            6 * time.Hour,
+			expectedQueried: []ulid.ULID{res1h.ULID, raw2.ULID},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			for _, streaming := range []bool{false, true} {
+				t.Run(fmt.Sprintf("streaming=%t", streaming), func(t *testing.T) {
+					expected, _ := runQuery(t, testData.query, testData.start, testData.end, testData.step, []*block.Meta{raw1, raw2}, streaming)
+					actual, queried := runQuery(t, testData.query, testData.start, testData.end, testData.step, []*block.Meta{raw1, raw2, res5m, res1h}, streaming)
+					assert.ElementsMatch(t, testData.expectedQueried, queried)
+
+					// The rate computed from the downsampled blocks, including the steps whose range function window spans
+					// both the downsampled and the raw blocks, must be close to the rate computed from the raw blocks. It's not
+					// the same because the extrapolation to the window boundaries depends on the average interval between the
+					// samples, which is irregular where the resolutions meet and around the counter resets.
+					require.Len(t, expected, 1)
+					require.Len(t, actual, 1)
+					require.Len(t, actual[0].Floats, len(expected[0].Floats))
+					for i, p := range expected[0].Floats {
+						assert.Equal(t, p.T, actual[0].Floats[i].T)
+						assert.InEpsilon(t, p.F, actual[0].Floats[i].F, 0.15, "timestamp: %d", p.T)
+					}
+				})
+			}
+		})
+	}
+}
+
+// readBlockAggrChunks returns the chunks of the only series of the block in blockDir.
+func readBlockAggrChunks(t *testing.T, blockDir string) []storepb.AggrChunk {
+	b, err := tsdb.OpenBlock(log.NewNopLogger(), blockDir, downsample.NewPool())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, b.Close()) }()
+
+	indexr, err := b.Index()
+	require.NoError(t, err)
+	defer func() { require.NoError(t, indexr.Close()) }()
+	chunkr, err := b.Chunks()
+	require.NoError(t, err)
+	defer func() { require.NoError(t, chunkr.Close()) }()
+
+	k, v := index.AllPostingsKey()
+	postings, err := indexr.Postings(context.Background(), k, v)
+	require.NoError(t, err)
+	require.True(t, postings.Next())
+
+	var (
+		builder labels.ScratchBuilder
+		metas   []chunks.Meta
+		res     []storepb.AggrChunk
+	)
+	require.NoError(t, indexr.Series(postings.At(), &builder, &metas))
+	for _, meta := range metas {
+		chk, err := chunkr.Chunk(meta)
+		require.NoError(t, err)
+
+		chkType := storepb.Chunk_XOR
+		if chk.Encoding() == downsample.ChunkEncAggr {
+			chkType = storepb.Chunk_Downsampled
+		}
+		// Copy the chunk data, because it's only valid until the block is closed.
+		data := append([]byte(nil), chk.Bytes()...)
+		res = append(res, storepb.AggrChunk{MinTime: meta.MinTime, MaxTime: meta.MaxTime, Raw: &storepb.Chunk{Type: chkType, Data: data}})
+	}
+	require.False(t, postings.Next())
+	return res
+}
+
+// blocksStoreSetByBlockMock returns the client holding each of the requested blocks,
+// and keeps track of the queried blocks.
+type blocksStoreSetByBlockMock struct {
+	services.Service
+
+	clients map[ulid.ULID]BlocksStoreClient
+
+	mtx     sync.Mutex
+	queried []ulid.ULID
+}
+
+func (m *blocksStoreSetByBlockMock) GetClientsFor(_ string, blockIDs []ulid.ULID, _ map[ulid.ULID][]string) (map[BlocksStoreClient][]ulid.ULID, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	res := map[BlocksStoreClient][]ulid.ULID{}
+	for _, id := range blockIDs {
+		res[m.clients[id]] = append(res[m.clients[id]], id)
+		m.queried = append(m.queried, id)
+	}
+	return res, nil
+}
+
+func (m *blocksStoreSetByBlockMock) RecordRejection(string, string) {}
+
+func (m *blocksStoreSetByBlockMock) GetHedgeClientFor(string, []ulid.ULID, []string) (BlocksStoreClient, error) {
+	return nil, nil
+}
+
+func (m *blocksStoreSetByBlockMock) RecordHedgeResult(string, bool) {}
+
+func (m *blocksStoreSetByBlockMock) queriedBlocks() []ulid.ULID {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	return append([]ulid.ULID(nil), m.queried...)
+}
@@ -0,0 +1,320 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+
+	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	"github.com/grafana/mimir/pkg/util/limiter"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+)
+
+const (
+	// hedgingLatencySamples is the number of latest series requests latencies of each tenant
+	// the hedging delay is computed from.
+	hedgingLatencySamples = 1000
+
+	// hedgingMinLatencySamples is the min number of series requests latencies observed for
+	// a tenant before its series requests are hedged.
+	hedgingMinLatencySamples = 100
+
+	// slowInstanceMinHedges is the min number of hedged requests a store-gateway has been
+	// involved in before it's considered slow.
+	slowInstanceMinHedges = 10
+
+	// slowInstanceLossRate is the rate of hedged requests lost by a store-gateway over which
+	// it's considered slow.
+	slowInstanceLossRate = 0.5
+
+	// slowInstanceLossRateWeight is the weight of the latest hedged request in the loss rate
+	// of a store-gateway, which decays the weight of the older ones.
+	slowInstanceLossRateWeight = 0.1
+
+	// slowInstancesAvoidPeriod is how long a slow store-gateway is avoided since the latest
+	// hedged request it has been involved in. Once the period is over, the store-gateway is
+	// queried again, and avoided again if it keeps losing the hedged requests.
+	slowInstancesAvoidPeriod = time.Minute
+)
+
+// storeGatewayHedging computes the delay after which the series requests of each tenant to the
+// store-gateways are hedged, from the latest latencies of the tenant series requests.
+type storeGatewayHedging struct {
+	minDelay time.Duration
+
+	mtx       sync.Mutex
+	latencies map[string]*latencyWindow
+}
+
+// latencyWindow holds the latest hedgingLatencySamples latencies of a tenant.
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+}
+
+func newStoreGatewayHedging(minDelay time.Duration) *storeGatewayHedging {
+	return &storeGatewayHedging{
+		minDelay:  minDelay,
+		latencies: map[string]*latencyWindow{},
+	}
+}
+
+// observe records the latency of a successful series request of the tenant.
+func (h *storeGatewayHedging) observe(userID string, latency time.Duration) {
+	if h == nil {
+		return
+	}
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	w := h.latencies[userID]
+	if w == nil {
+		w = &latencyWindow{}
+		h.latencies[userID] = w
+	}
+
+	if len(w.samples) < hedgingLatencySamples {
+		w.samples = append(w.samples, latency)
+		return
+	}
+	w.samples[w.next] = latency
+	w.next = (w.next + 1) % hedgingLatencySamples
+}
+
+// delay returns the percentile of the latest latencies of the tenant series requests, not lower than
+// the min delay. Returns false if hedging is disabled, or not enough latencies have been observed yet.
+func (h *storeGatewayHedging) delay(userID string, percentile float64) (time.Duration, bool) {
+	if h == nil || percentile <= 0 {
+		return 0, false
+	}
+
+	h.mtx.Lock()
+	w := h.latencies[userID]
+	if w == nil || len(w.samples) < hedgingMinLatencySamples {
+		h.mtx.Unlock()
+		return 0, false
+	}
+	samples := make([]time.Duration, len(w.samples))
+	copy(samples, w.samples)
+	h.mtx.Unlock()
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	// Nearest-rank percentile.
+	idx := int(math.Ceil(percentile/100*float64(len(samples)))) - 1
+	idx = max(0, min(idx, len(samples)-1))
+
+	return max(samples[idx], h.minDelay), true
+}
+
+// storeGatewayHedgeStats tracks the outcome of the hedged series requests by store-gateway,
+// to steer the requests away from the store-gateways consistently slower than their replicas.
+type storeGatewayHedgeStats struct {
+	mtx       sync.Mutex
+	instances map[string]*hedgeOutcomes
+}
+
+type hedgeOutcomes struct {
+	// lossRate is the exponentially weighted rate of the hedged requests lost by the store-gateway.
+	lossRate float64
+	count    int
+	last     time.Time
+}
+
+func newStoreGatewayHedgeStats() *storeGatewayHedgeStats {
+	return &storeGatewayHedgeStats{instances: map[string]*hedgeOutcomes{}}
+}
+
+// record records whether the store-gateway at the given address responded first to a hedged request.
+func (s *storeGatewayHedgeStats) record(addr string, won bool, now time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// Remove the store-gateways not involved in hedged requests for a while, so that the stats of
+	// the store-gateways which have left the ring are not kept indefinitely.
+	for a, o := range s.instances {
+		if now.Sub(o.last) >= slowInstancesAvoidPeriod {
+			delete(s.instances, a)
+		}
+	}
+
+	o := s.instances[addr]
+	if o == nil {
+		o = &hedgeOutcomes{}
+		s.instances[addr] = o
+	}
+
+	loss := 0.0
+	if !won {
+		loss = 1
+	}
+	if o.count == 0 {
+		o.lossRate = loss
+	} else {
+		o.lossRate = (1-slowInstanceLossRateWeight)*o.lossRate + slowInstanceLossRateWeight*loss
+	}
+	o.count++
+	o.last = now
+}
+
+// slowInstances returns the addresses of the store-gateways which have recently lost most of
+// the hedged requests they have been involved in.
+func (s *storeGatewayHedgeStats) slowInstances(now time.Time) []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var addrs []string
+	for addr, o := range s.instances {
+		if o.count >= slowInstanceMinHedges && o.lossRate > slowInstanceLossRate && now.Sub(o.last) < slowInstancesAvoidPeriod {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// fetchSeriesFromStoreWithHedging fetches the series from the store-gateway c like fetchSeriesFromStore. If hedging
+// is enabled for the tenant and the store-gateway hasn't responded within the tenant latency percentile, the same
+// request is sent to another store-gateway replica holding all the blocks, and the first successful response is
+// returned. The other request is canceled.
+//
+// When hedging is enabled, each request enforces the limits on its own response as it's received, with a limiter
+// of its own, and only the series of the returned response are accounted in the query limiter, so that the series
+// of the same blocks are not accounted twice.
+func (q *blocksStoreQuerier) fetchSeriesFromStoreWithHedging(
+	ctx, abortCtx context.Context,
+	log *spanlogger.SpanLogger,
+	tenantID string,
+	c BlocksStoreClient,
+	blockIDs []ulid.ULID,
+	req *storepb.SeriesRequest,
+	queryLimiter *limiter.QueryLimiter,
+	onStream func(storegatewaypb.StoreGateway_SeriesClient),
+) (*seriesFromStoreResponse, error) {
+	delay, ok := q.hedging.delay(tenantID, q.limits.StoreGatewayHedgingLatencyPercentile(tenantID))
+	if !ok {
+		start := time.Now()
+		res, err := q.fetchSeriesFromStore(ctx, abortCtx, log, tenantID, c, req, queryLimiter, onStream)
+		if err == nil {
+			q.hedging.observe(tenantID, time.Since(start))
+		}
+		return res, err
+	}
+
+	type attempt struct {
+		res     *seriesFromStoreResponse
+		err     error
+		client  BlocksStoreClient
+		latency time.Duration
+	}
+
+	var (
+		// Buffered to not block the attempts still running once a response has been returned.
+		attempts = make(chan attempt, 2)
+		cancels  = map[BlocksStoreClient]context.CancelFunc{}
+		running  = 0
+		hedge    BlocksStoreClient
+		winner   *seriesFromStoreResponse
+		lastErr  error
+	)
+
+	startAttempt := func(client BlocksStoreClient) {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels[client] = cancel
+		running++
+
+		go func() {
+			start := time.Now()
+			res, err := q.fetchSeriesFromStore(attemptCtx, abortCtx, log, tenantID, client, req, queryLimiter.WithSameLimits(), onStream)
+			attempts <- attempt{res: res, err: err, client: client, latency: time.Since(start)}
+		}()
+	}
+
+	defer func() {
+		// Cancel all the requests, except the winning one if its stream is used later to receive the chunks.
+		for client, cancel := range cancels {
+			if winner == nil || client != winner.client || len(winner.streamingSeries) == 0 {
+				cancel()
+			}
+		}
+	}()
+
+	startAttempt(c)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for running > 0 {
+		select {
+		case <-timer.C:
+			hedgeClient, err := q.stores.GetHedgeClientFor(tenantID, blockIDs, []string{c.RemoteAddress()})
+			if err != nil {
+				level.Warn(log).Log("msg", "failed to get store-gateway client for hedged series request", "remote", c.RemoteAddress(), "err", err)
+				continue
+			}
+			if hedgeClient == nil {
+				level.Debug(log).Log("msg", "no other store-gateway holding all the blocks to send the hedged series request to", "remote", c.RemoteAddress())
+				continue
+			}
+
+			level.Debug(log).Log("msg", "sending hedged series request", "remote", hedgeClient.RemoteAddress(), "original remote", c.RemoteAddress(), "delay", delay)
+			q.metrics.hedgedRequests.Inc()
+			hedge = hedgeClient
+			startAttempt(hedgeClient)
+
+		case a := <-attempts:
+			running--
+			if a.err != nil {
+				if shouldStopQueryFunc(a.err) {
+					return nil, a.err
+				}
+				lastErr = a.err
+				continue
+			}
+
+			if err := addSeriesFromStoreResponseToQueryLimiter(queryLimiter, a.res); err != nil {
+				return nil, err
+			}
+
+			winner = a.res
+			q.hedging.observe(tenantID, a.latency)
+
+			if hedge != nil {
+				loser := hedge
+				if a.client == hedge {
+					loser = c
+					q.metrics.hedgedRequestsWon.Inc()
+				}
+				q.stores.RecordHedgeResult(a.client.RemoteAddress(), true)
+				q.stores.RecordHedgeResult(loser.RemoteAddress(), false)
+			}
+			return winner, nil
+		}
+	}
+
+	return nil, lastErr
+}
+
+// addSeriesFromStoreResponseToQueryLimiter accounts the series of the response in the query limiter, and returns an
+// error if a limit is exceeded.
+func addSeriesFromStoreResponseToQueryLimiter(queryLimiter *limiter.QueryLimiter, res *seriesFromStoreResponse) error {
+	for _, s := range res.series {
+		if err := addSeriesToQueryLimiter(queryLimiter, s); err != nil {
+			return err
+		}
+	}
+	for _, s := range res.streamingSeries {
+		if err := addStreamingSeriesToQueryLimiter(queryLimiter, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
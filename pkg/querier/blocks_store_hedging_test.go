@@ -0,0 +1,262 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	"github.com/grafana/mimir/pkg/util/limiter"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestStoreGatewayHedging_Delay(t *testing.T) {
+	h := newStoreGatewayHedging(5 * time.Millisecond)
+
+	// Not enough latencies observed yet.
+	for i := 1; i < hedgingMinLatencySamples; i++ {
+		h.observe("user-1", time.Duration(i)*time.Millisecond)
+	}
+	_, ok := h.delay("user-1", 90)
+	assert.False(t, ok)
+
+	h.observe("user-1", hedgingMinLatencySamples*time.Millisecond)
+
+	delay, ok := h.delay("user-1", 90)
+	require.True(t, ok)
+	assert.Equal(t, 90*time.Millisecond, delay)
+
+	delay, ok = h.delay("user-1", 100)
+	require.True(t, ok)
+	assert.Equal(t, 100*time.Millisecond, delay)
+
+	// The delay is not lower than the min delay.
+	delay, ok = h.delay("user-1", 1)
+	require.True(t, ok)
+	assert.Equal(t, 5*time.Millisecond, delay)
+
+	// Hedging is disabled.
+	_, ok = h.delay("user-1", 0)
+	assert.False(t, ok)
+
+	// The latencies are tracked by tenant.
+	_, ok = h.delay("user-2", 90)
+	assert.False(t, ok)
+
+	// Only the latest latencies are kept.
+	for i := 0; i < hedgingLatencySamples; i++ {
+		h.observe("user-1", time.Second)
+	}
+	delay, ok = h.delay("user-1", 1)
+	require.True(t, ok)
+	assert.Equal(t, time.Second, delay)
+}
+
+func TestStoreGatewayHedgeStats_SlowInstances(t *testing.T) {
+	now := time.Now()
+	s := newStoreGatewayHedgeStats()
+
+	for i := 0; i < slowInstanceMinHedges-1; i++ {
+		s.record("1.1.1.1", false, now)
+		s.record("2.2.2.2", true, now)
+	}
+	assert.Empty(t, s.slowInstances(now))
+
+	s.record("1.1.1.1", false, now)
+	s.record("2.2.2.2", true, now)
+	assert.Equal(t, []string{"1.1.1.1"}, s.slowInstances(now))
+
+	// The store-gateway is no longer slow once it wins most of the hedged requests.
+	for i := 0; i < 10; i++ {
+		s.record("1.1.1.1", true, now)
+	}
+	assert.Empty(t, s.slowInstances(now))
+
+	for i := 0; i < 20; i++ {
+		s.record("1.1.1.1", false, now)
+	}
+	assert.Equal(t, []string{"1.1.1.1"}, s.slowInstances(now))
+
+	// The store-gateway is no longer avoided once the avoid period is over.
+	assert.Empty(t, s.slowInstances(now.Add(slowInstancesAvoidPeriod)))
+
+	// The expired stats are removed.
+	s.record("2.2.2.2", true, now.Add(slowInstancesAvoidPeriod))
+	assert.Len(t, s.instances, 1)
+}
+
+func TestBlocksStoreQuerier_Select_ShouldHedgeSeriesRequestsToAnotherStoreGateway(t *testing.T) {
+	const (
+		metricName = "test_metric"
+		minT       = int64(10)
+		maxT       = int64(20)
+	)
+
+	var (
+		block       = ulid.MustNew(1, nil)
+		seriesLabel = labels.FromStrings(labels.MetricName, metricName)
+	)
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	reg := prometheus.NewPedanticRegistry()
+
+	slowStoreGateway := &storeGatewayClientMock{
+		remoteAddr:            "1.1.1.1",
+		mockedSeriesDelay:     time.Minute,
+		mockedSeriesResponses: []*storepb.SeriesResponse{mockSeriesResponse(seriesLabel, minT, 1), mockHintsResponse(block)},
+	}
+	hedgeStoreGateway := &storeGatewayClientMock{
+		remoteAddr:            "2.2.2.2",
+		mockedSeriesResponses: []*storepb.SeriesResponse{mockSeriesResponse(seriesLabel, minT, 2), mockHintsResponse(block)},
+	}
+
+	stores := &blocksStoreSetMock{
+		mockedResponses: []interface{}{map[BlocksStoreClient][]ulid.ULID{slowStoreGateway: {block}}},
+		hedgeClient:     hedgeStoreGateway,
+	}
+
+	finder := &blocksFinderMock{}
+	finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(bucketindex.Blocks{
+		{ID: block},
+	}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+	// Observe enough latencies for the series requests of the tenant to be hedged.
+	hedging := newStoreGatewayHedging(0)
+	for i := 0; i < hedgingMinLatencySamples; i++ {
+		hedging.observe("user-1", 10*time.Millisecond)
+	}
+
+	q := &blocksStoreQuerier{
+		minT:        minT,
+		maxT:        maxT,
+		finder:      finder,
+		stores:      stores,
+		consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+		logger:      log.NewNopLogger(),
+		metrics:     newBlocksStoreQueryableMetrics(reg),
+		limits:      &blocksStoreLimitsMock{hedgingLatencyPercentile: 90},
+		hedging:     hedging,
+	}
+
+	matchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, metricName),
+	}
+
+	start := time.Now()
+	set := q.Select(ctx, true, &storage.SelectHints{Start: minT, End: maxT}, matchers...)
+	require.NoError(t, set.Err())
+	assert.Less(t, time.Since(start), time.Minute)
+
+	// The series are the ones received from the hedged request.
+	require.True(t, set.Next())
+	assert.Equal(t, seriesLabel, set.At().Labels())
+	it := set.At().Iterator(nil)
+	require.NotEqual(t, chunkenc.ValNone, it.Next())
+	_, v := it.At()
+	assert.Equal(t, 2.0, v)
+	require.False(t, set.Next())
+	require.NoError(t, set.Err())
+
+	stores.hedgeResultsMx.Lock()
+	assert.Equal(t, map[string][]bool{"1.1.1.1": {false}, "2.2.2.2": {true}}, stores.hedgeResults)
+	stores.hedgeResultsMx.Unlock()
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_querier_storegateway_hedged_requests_total Number of series requests sent to another store-gateway replica because the first store-gateway didn't respond within the hedging delay.
+		# TYPE cortex_querier_storegateway_hedged_requests_total counter
+		cortex_querier_storegateway_hedged_requests_total 1
+
+		# HELP cortex_querier_storegateway_hedged_requests_won_total Number of hedged series requests whose response has been received before the one of the first store-gateway.
+		# TYPE cortex_querier_storegateway_hedged_requests_won_total counter
+		cortex_querier_storegateway_hedged_requests_won_total 1
+	`), "cortex_querier_storegateway_hedged_requests_total", "cortex_querier_storegateway_hedged_requests_won_total"))
+}
+
+func TestBlocksStoreQuerier_Select_ShouldEnforceLimitsOnHedgedSeriesRequests(t *testing.T) {
+	const (
+		metricName = "test_metric"
+		minT       = int64(10)
+		maxT       = int64(20)
+	)
+
+	block := ulid.MustNew(1, nil)
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	ctx = limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(1, 0, 0, 0, stats.NewQueryMetrics(prometheus.NewPedanticRegistry())))
+
+	slowStoreGateway := &storeGatewayClientMock{
+		remoteAddr:        "1.1.1.1",
+		mockedSeriesDelay: time.Minute,
+		mockedSeriesResponses: []*storepb.SeriesResponse{
+			mockSeriesResponse(labels.FromStrings(labels.MetricName, metricName, "series", "1"), minT, 1),
+			mockHintsResponse(block),
+		},
+	}
+	hedgeStoreGateway := &storeGatewayClientMock{
+		remoteAddr: "2.2.2.2",
+		mockedSeriesResponses: []*storepb.SeriesResponse{
+			mockSeriesResponse(labels.FromStrings(labels.MetricName, metricName, "series", "1"), minT, 1),
+			mockSeriesResponse(labels.FromStrings(labels.MetricName, metricName, "series", "2"), minT, 2),
+			mockHintsResponse(block),
+		},
+	}
+
+	stores := &blocksStoreSetMock{
+		mockedResponses: []interface{}{map[BlocksStoreClient][]ulid.ULID{slowStoreGateway: {block}}},
+		hedgeClient:     hedgeStoreGateway,
+	}
+
+	finder := &blocksFinderMock{}
+	finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(bucketindex.Blocks{
+		{ID: block},
+	}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+	// Observe enough latencies for the series requests of the tenant to be hedged.
+	hedging := newStoreGatewayHedging(0)
+	for i := 0; i < hedgingMinLatencySamples; i++ {
+		hedging.observe("user-1", 10*time.Millisecond)
+	}
+
+	q := &blocksStoreQuerier{
+		minT:        minT,
+		maxT:        maxT,
+		finder:      finder,
+		stores:      stores,
+		consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+		logger:      log.NewNopLogger(),
+		metrics:     newBlocksStoreQueryableMetrics(prometheus.NewPedanticRegistry()),
+		limits:      &blocksStoreLimitsMock{hedgingLatencyPercentile: 90},
+		hedging:     hedging,
+	}
+
+	matchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, metricName),
+	}
+
+	// The hedged request exceeds the limit while its series are received, which fails the query without waiting
+	// for the slow store-gateway.
+	start := time.Now()
+	set := q.Select(ctx, true, &storage.SelectHints{Start: minT, End: maxT}, matchers...)
+	expectedErr := validation.LimitError(fmt.Sprintf(limiter.MaxSeriesHitMsgFormat, 1))
+	assert.ErrorContains(t, set.Err(), expectedErr.Error())
+	assert.IsType(t, expectedErr, set.Err())
+	assert.Less(t, time.Since(start), time.Minute)
+}
@@ -41,6 +41,7 @@ import (
 	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketindex"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
 	"github.com/grafana/mimir/pkg/storegateway"
 	"github.com/grafana/mimir/pkg/storegateway/hintspb"
 	"github.com/grafana/mimir/pkg/storegateway/storegatewaypb"
@@ -68,6 +69,19 @@ type BlocksStoreSet interface {
 	// query the set of blocks in input. The exclude parameter is the map of
 	// blocks -> store-gateway addresses that should be excluded.
 	GetClientsFor(userID string, blockIDs []ulid.ULID, exclude map[ulid.ULID][]string) (map[BlocksStoreClient][]ulid.ULID, error)
+
+	// RecordRejection records that the store-gateway at the given address rejected a request
+	// of the tenant because the tenant reached its max number of concurrent requests there.
+	RecordRejection(userID, addr string)
+
+	// GetHedgeClientFor returns the client of a store-gateway holding all the blocks in input to send
+	// a hedged series request to, excluding the store-gateways at the given addresses. Returns nil
+	// if there's no such store-gateway.
+	GetHedgeClientFor(userID string, blockIDs []ulid.ULID, exclude []string) (BlocksStoreClient, error)
+
+	// RecordHedgeResult records whether the store-gateway at the given address responded first to
+	// a hedged series request.
+	RecordHedgeResult(addr string, won bool)
 }
 
 // BlocksFinder is the interface used to find blocks for a given user and time range.
@@ -96,6 +110,7 @@ type BlocksStoreLimits interface {
 	MaxLabelsQueryLength(userID string) time.Duration
 	MaxChunksPerQuery(userID string) int
 	StoreGatewayTenantShardSize(userID string) int
+	StoreGatewayHedgingLatencyPercentile(userID string) float64
 }
 
 type blocksStoreQueryableMetrics struct {
@@ -105,6 +120,9 @@ type blocksStoreQueryableMetrics struct {
 	blocksFound                                       prometheus.Counter
 	blocksQueried                                     prometheus.Counter
 	blocksWithCompactorShardButIncompatibleQueryShard prometheus.Counter
+
+	hedgedRequests    prometheus.Counter
+	hedgedRequestsWon prometheus.Counter
 }
 
 func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQueryableMetrics {
@@ -134,6 +152,17 @@ func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQuery
 			Name: "cortex_querier_blocks_with_compactor_shard_but_incompatible_query_shard_total",
 			Help: "Blocks that couldn't be checked for query and compactor sharding optimization due to incompatible shard counts.",
 		}),
+
+		hedgedRequests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "querier_storegateway_hedged_requests_total",
+			Help:      "Number of series requests sent to another store-gateway replica because the first store-gateway didn't respond within the hedging delay.",
+		}),
+		hedgedRequestsWon: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "querier_storegateway_hedged_requests_won_total",
+			Help:      "Number of hedged series requests whose response has been received before the one of the first store-gateway.",
+		}),
 	}
 }
 
@@ -147,9 +176,11 @@ type BlocksStoreQueryable struct {
 	consistency              *BlocksConsistencyChecker
 	logger                   log.Logger
 	queryStoreAfter          time.Duration
+	lookbackDelta            time.Duration
 	metrics                  *blocksStoreQueryableMetrics
 	limits                   BlocksStoreLimits
 	streamingChunksBatchSize uint64
+	hedging                  *storeGatewayHedging
 
 	// Subservices manager.
 	subservices        *services.Manager
@@ -162,7 +193,9 @@ func NewBlocksStoreQueryable(
 	consistency *BlocksConsistencyChecker,
 	limits BlocksStoreLimits,
 	queryStoreAfter time.Duration,
+	lookbackDelta time.Duration,
 	streamingChunksBatchSize uint64,
+	hedgingMinDelay time.Duration,
 	logger log.Logger,
 	reg prometheus.Registerer,
 ) (*BlocksStoreQueryable, error) {
@@ -176,12 +209,14 @@ func NewBlocksStoreQueryable(
 		finder:                   finder,
 		consistency:              consistency,
 		queryStoreAfter:          queryStoreAfter,
+		lookbackDelta:            lookbackDelta,
 		logger:                   logger,
 		subservices:              manager,
 		subservicesWatcher:       services.NewFailureWatcher(),
 		metrics:                  newBlocksStoreQueryableMetrics(reg),
 		limits:                   limits,
 		streamingChunksBatchSize: streamingChunksBatchSize,
+		hedging:                  newStoreGatewayHedging(hedgingMinDelay),
 	}
 
 	q.Service = services.NewBasicService(q.starting, q.running, q.stopping)
@@ -268,7 +303,7 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 		streamingBufferSize = 0
 	}
 
-	return NewBlocksStoreQueryable(stores, finder, consistency, limits, querierCfg.QueryStoreAfter, streamingBufferSize, logger, reg)
+	return NewBlocksStoreQueryable(stores, finder, consistency, limits, querierCfg.QueryStoreAfter, querierCfg.EngineConfig.LookbackDelta, streamingBufferSize, querierCfg.StoreGatewayHedgingMinDelay, logger, reg)
 }
 
 func (q *BlocksStoreQueryable) starting(ctx context.Context) error {
@@ -310,9 +345,11 @@ func (q *BlocksStoreQueryable) Querier(mint, maxt int64) (storage.Querier, error
 		metrics:                  q.metrics,
 		limits:                   q.limits,
 		streamingChunksBatchSize: q.streamingChunksBatchSize,
+		hedging:                  q.hedging,
 		consistency:              q.consistency,
 		logger:                   q.logger,
 		queryStoreAfter:          q.queryStoreAfter,
+		lookbackDelta:            q.lookbackDelta,
 	}, nil
 }
 
@@ -326,9 +363,15 @@ type blocksStoreQuerier struct {
 	streamingChunksBatchSize uint64
 	logger                   log.Logger
 
+	// The hedging of the series requests to the store-gateways. Hedging is disabled if nil.
+	hedging *storeGatewayHedging
+
 	// If set, the querier manipulates the max time to not be greater than
 	// "now - queryStoreAfter" so that most recent blocks are not queried.
 	queryStoreAfter time.Duration
+
+	// The PromQL engine lookback delta, used to choose the resolution of the blocks to query.
+	lookbackDelta time.Duration
 }
 
 // Select implements storage.Querier interface.
@@ -380,7 +423,7 @@ func (q *blocksStoreQuerier) LabelNames(ctx context.Context, matchers ...*labels
 		return queriedBlocks, nil
 	}
 
-	if err := q.queryWithConsistencyCheck(ctx, spanLog, minT, maxT, tenantID, nil, queryF); err != nil {
+	if err := q.queryWithConsistencyCheck(ctx, spanLog, minT, maxT, tenantID, nil, downsample.ResLevel0, queryF); err != nil {
 		return nil, nil, err
 	}
 
@@ -424,7 +467,7 @@ func (q *blocksStoreQuerier) LabelValues(ctx context.Context, name string, match
 		return queriedBlocks, nil
 	}
 
-	if err := q.queryWithConsistencyCheck(ctx, spanLog, minT, maxT, tenantID, nil, queryF); err != nil {
+	if err := q.queryWithConsistencyCheck(ctx, spanLog, minT, maxT, tenantID, nil, downsample.ResLevel0, queryF); err != nil {
 		return nil, nil, err
 	}
 
@@ -469,7 +512,7 @@ func (q *blocksStoreQuerier) selectSorted(ctx context.Context, sp *storage.Selec
 		return queriedBlocks, nil
 	}
 
-	err = q.queryWithConsistencyCheck(ctx, spanLog, minT, maxT, tenantID, shard, queryF)
+	err = q.queryWithConsistencyCheck(ctx, spanLog, minT, maxT, tenantID, shard, maxResolutionForHints(sp, q.lookbackDelta), queryF)
 	if err != nil {
 		return storage.ErrSeriesSet(err)
 	}
@@ -508,14 +551,28 @@ func (q *blocksStoreQuerier) selectSorted(ctx context.Context, sp *storage.Selec
 type queryFunc func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error)
 
 func (q *blocksStoreQuerier) queryWithConsistencyCheck(
-	ctx context.Context, logger log.Logger, minT, maxT int64, tenantID string, shard *sharding.ShardSelector, queryF queryFunc,
+	ctx context.Context, logger log.Logger, minT, maxT int64, tenantID string, shard *sharding.ShardSelector, maxResolution int64, queryF queryFunc,
 ) error {
+	knownBlocks, knownDeletionMarks, maxT, err := q.findBlocksToQuery(ctx, logger, minT, maxT, tenantID, shard, maxResolution)
+	if err != nil || len(knownBlocks) == 0 {
+		return err
+	}
+
+	return q.queryBlocksWithConsistencyCheck(ctx, logger, minT, maxT, tenantID, knownBlocks, knownDeletionMarks, queryF)
+}
+
+// findBlocksToQuery returns the blocks to query for the given time range and query shard, preferring the coarsest
+// resolution not greater than maxResolution, along with their deletion marks and the max time clamped to honor the
+// query-store-after limit.
+func (q *blocksStoreQuerier) findBlocksToQuery(
+	ctx context.Context, logger log.Logger, minT, maxT int64, tenantID string, shard *sharding.ShardSelector, maxResolution int64,
+) (bucketindex.Blocks, map[ulid.ULID]*bucketindex.BlockDeletionMark, int64, error) {
 	now := time.Now()
 
 	if !ShouldQueryBlockStore(q.queryStoreAfter, now, minT) {
 		q.metrics.storesHit.Observe(0)
 		level.Debug(logger).Log("msg", "not querying block store; query time range begins after the query-store-after limit")
-		return nil
+		return nil, nil, maxT, nil
 	}
 
 	maxT = clampMaxTime(logger, maxT, now.UnixMilli(), -q.queryStoreAfter, "query store after")
@@ -523,17 +580,20 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(
 	// Find the list of blocks we need to query given the time range.
 	knownBlocks, knownDeletionMarks, err := q.finder.GetBlocks(ctx, tenantID, minT, maxT)
 	if err != nil {
-		return err
+		return nil, nil, maxT, err
 	}
 
 	if len(knownBlocks) == 0 {
 		q.metrics.storesHit.Observe(0)
 		level.Debug(logger).Log("msg", "no blocks found")
-		return nil
+		return nil, nil, maxT, nil
 	}
 
 	q.metrics.blocksFound.Add(float64(len(knownBlocks)))
 
+	// Each time range is covered by the blocks of a single resolution, where the blocks of multiple resolutions exist.
+	knownBlocks = selectBlocksByResolution(knownBlocks, minT, maxT, maxResolution)
+
 	if shard != nil && shard.ShardCount > 0 {
 		level.Debug(logger).Log("msg", "filtering blocks due to sharding", "blocksBeforeFiltering", knownBlocks.String(), "shardID", shard.LabelValue())
 
@@ -549,6 +609,15 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(
 
 	level.Debug(logger).Log("msg", "found blocks to query", "expected", knownBlocks.String())
 
+	return knownBlocks, knownDeletionMarks, maxT, nil
+}
+
+// queryBlocksWithConsistencyCheck runs queryF on the store-gateways holding the known blocks, retrying on other
+// store-gateways the blocks which haven't been queried, until all known blocks have been queried.
+func (q *blocksStoreQuerier) queryBlocksWithConsistencyCheck(
+	ctx context.Context, logger log.Logger, minT, maxT int64, tenantID string,
+	knownBlocks bucketindex.Blocks, knownDeletionMarks map[ulid.ULID]*bucketindex.BlockDeletionMark, queryF queryFunc,
+) error {
 	var (
 		// At the beginning the list of blocks to query are all known blocks.
 		remainingBlocks = knownBlocks.GetULIDs()
@@ -609,7 +678,7 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(
 	}
 
 	// We've not been able to query all expected blocks after all retries.
-	level.Warn(util_log.WithContext(ctx, logger)).Log("msg", "failed consistency check", "err", err)
+	level.Warn(util_log.WithContext(ctx, logger)).Log("msg", "failed consistency check")
 	return newStoreConsistencyCheckFailedError(remainingBlocks)
 }
 
@@ -748,107 +817,27 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *stor
 				return errors.Wrapf(err, "failed to create series request")
 			}
 
-			stream, err := c.Series(reqCtx, req)
-			if err == nil {
+			res, err := q.fetchSeriesFromStoreWithHedging(reqCtx, gCtx, log, tenantID, c, blockIDs, req, queryLimiter, func(stream storegatewaypb.StoreGateway_SeriesClient) {
 				mtx.Lock()
 				streams = append(streams, stream)
 				mtx.Unlock()
-				err = gCtx.Err()
-			}
+			})
 			if err != nil {
 				if shouldStopQueryFunc(err) {
 					return err
 				}
-
-				level.Warn(log).Log("msg", "failed to fetch series", "remote", c.RemoteAddress(), "err", err)
+				// The failure has already been logged.
 				return nil
 			}
 
-			// A storegateway client will only fill either of mySeries or myStreamingSeries, and not both.
-			mySeries := []*storepb.Series(nil)
-			myStreamingSeries := []*storepb.StreamingSeries(nil)
-			var myWarnings annotations.Annotations
-			myQueriedBlocks := []ulid.ULID(nil)
-			indexBytesFetched := uint64(0)
-
-			for {
-				// Ensure the context hasn't been canceled in the meanwhile (eg. an error occurred
-				// in another goroutine).
-				if gCtx.Err() != nil {
-					return gCtx.Err()
-				}
-
-				resp, err := stream.Recv()
-				if errors.Is(err, io.EOF) {
-					break
-				}
-				if err != nil {
-					if shouldStopQueryFunc(err) {
-						return err
-					}
-
-					level.Warn(log).Log("msg", "failed to receive series", "remote", c.RemoteAddress(), "err", err)
-					return nil
-				}
-
-				// Response may either contain series, streaming series, warning or hints.
-				if s := resp.GetSeries(); s != nil {
-					mySeries = append(mySeries, s)
-
-					// Add series fingerprint to query limiter; will return error if we are over the limit
-					if err := queryLimiter.AddSeries(s.Labels); err != nil {
-						return err
-					}
-
-					chunksCount, chunksSize := countChunksAndBytes(s)
-					if err := queryLimiter.AddChunkBytes(chunksSize); err != nil {
-						return err
-					}
-					if err := queryLimiter.AddChunks(chunksCount); err != nil {
-						return err
-					}
-					if err := queryLimiter.AddEstimatedChunks(chunksCount); err != nil {
-						return err
-					}
-				}
-
-				if w := resp.GetWarning(); w != "" {
-					myWarnings.Add(errors.New(w))
-				}
-
-				if h := resp.GetHints(); h != nil {
-					hints := hintspb.SeriesResponseHints{}
-					if err := types.UnmarshalAny(h, &hints); err != nil {
-						return errors.Wrapf(err, "failed to unmarshal series hints from %s", c.RemoteAddress())
-					}
-
-					ids, err := convertBlockHintsToULIDs(hints.QueriedBlocks)
-					if err != nil {
-						return errors.Wrapf(err, "failed to parse queried block IDs from received hints")
-					}
-
-					myQueriedBlocks = append(myQueriedBlocks, ids...)
-				}
-
-				if s := resp.GetStats(); s != nil {
-					indexBytesFetched += s.FetchedIndexBytes
-				}
-
-				if ss := resp.GetStreamingSeries(); ss != nil {
-					for _, s := range ss.Series {
-						// Add series fingerprint to query limiter; will return error if we are over the limit
-						limitErr := queryLimiter.AddSeries(s.Labels)
-						if limitErr != nil {
-							return validation.LimitError(limitErr.Error())
-						}
-					}
-					myStreamingSeries = append(myStreamingSeries, ss.Series...)
-					if ss.IsEndOfSeriesStream {
-						// We expect "end of stream" to be sent after the hints and the stats have been sent.
-						break
-					}
-				}
-			}
+			// The series have already been accounted in the query limiter.
+			c := res.client
+			mySeries := res.series
+			myStreamingSeries := res.streamingSeries
+			myWarnings := res.warnings
+			myQueriedBlocks := res.queriedBlocks
+			indexBytesFetched := res.indexBytesFetched
+			stream := res.stream
 
 			reqStats.AddFetchedIndexBytes(indexBytesFetched)
 			var streamReader *storeGatewayStreamReader
@@ -882,9 +871,9 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *stor
 			// Store the result.
 			mtx.Lock()
 			if len(mySeries) > 0 {
-				seriesSets = append(seriesSets, &blockQuerierSeriesSet{series: mySeries})
+				seriesSets = append(seriesSets, &blockQuerierSeriesSet{series: mySeries, aggr: aggrTypeForHints(sp)})
 			} else if len(myStreamingSeries) > 0 {
-				seriesSets = append(seriesSets, &blockStreamingQuerierSeriesSet{series: myStreamingSeries, streamReader: streamReader})
+				seriesSets = append(seriesSets, &blockStreamingQuerierSeriesSet{series: myStreamingSeries, streamReader: streamReader, aggr: aggrTypeForHints(sp)})
 				streamReaders = append(streamReaders, streamReader)
 			}
 			warnings.Merge(myWarnings)
@@ -924,11 +913,152 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(ctx context.Context, sp *stor
 	return seriesSets, queriedBlocks, warnings, startStreamingChunks, estimateChunks, nil
 }
 
+// seriesFromStoreResponse is the response of a series request to a store-gateway.
+type seriesFromStoreResponse struct {
+	client BlocksStoreClient
+	stream storegatewaypb.StoreGateway_SeriesClient
+
+	// A storegateway client will only fill either of series or streamingSeries, and not both.
+	series            []*storepb.Series
+	streamingSeries   []*storepb.StreamingSeries
+	warnings          annotations.Annotations
+	queriedBlocks     []ulid.ULID
+	indexBytesFetched uint64
+}
+
+// fetchSeriesFromStore sends the series request to the store-gateway c and receives the series, or the streaming
+// series only if the request is streaming the chunks, in which case the chunks are received later from the returned
+// stream. The series are accounted in the query limiter as they're received, so that the request is aborted as soon
+// as a limit is exceeded. The request is aborted once abortCtx is done, and onStream is called with the stream once
+// opened. Failures which shouldn't stop the query are logged here.
+func (q *blocksStoreQuerier) fetchSeriesFromStore(ctx, abortCtx context.Context, log *spanlogger.SpanLogger, tenantID string, c BlocksStoreClient, req *storepb.SeriesRequest, queryLimiter *limiter.QueryLimiter, onStream func(storegatewaypb.StoreGateway_SeriesClient)) (*seriesFromStoreResponse, error) {
+	res := &seriesFromStoreResponse{client: c}
+
+	stream, err := c.Series(ctx, req)
+	if err == nil {
+		onStream(stream)
+		res.stream = stream
+		err = abortCtx.Err()
+	}
+	if err != nil {
+		if !shouldStopQueryFunc(err) {
+			if isTooManyRequestsFunc(err) {
+				q.stores.RecordRejection(tenantID, c.RemoteAddress())
+			}
+			level.Warn(log).Log("msg", "failed to fetch series", "remote", c.RemoteAddress(), "err", err)
+		}
+		return nil, err
+	}
+
+	for {
+		// Ensure the context hasn't been canceled in the meanwhile (eg. an error occurred
+		// in another goroutine).
+		if abortCtx.Err() != nil {
+			return nil, abortCtx.Err()
+		}
+
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if !shouldStopQueryFunc(err) {
+				if isTooManyRequestsFunc(err) {
+					q.stores.RecordRejection(tenantID, c.RemoteAddress())
+				}
+				level.Warn(log).Log("msg", "failed to receive series", "remote", c.RemoteAddress(), "err", err)
+			}
+			return nil, err
+		}
+
+		// Response may either contain series, streaming series, warning or hints.
+		if s := resp.GetSeries(); s != nil {
+			res.series = append(res.series, s)
+
+			if err := addSeriesToQueryLimiter(queryLimiter, s); err != nil {
+				return nil, err
+			}
+		}
+
+		if w := resp.GetWarning(); w != "" {
+			res.warnings.Add(errors.New(w))
+		}
+
+		if h := resp.GetHints(); h != nil {
+			hints := hintspb.SeriesResponseHints{}
+			if err := types.UnmarshalAny(h, &hints); err != nil {
+				return nil, errors.Wrapf(err, "failed to unmarshal series hints from %s", c.RemoteAddress())
+			}
+
+			ids, err := convertBlockHintsToULIDs(hints.QueriedBlocks)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse queried block IDs from received hints")
+			}
+
+			res.queriedBlocks = append(res.queriedBlocks, ids...)
+		}
+
+		if s := resp.GetStats(); s != nil {
+			res.indexBytesFetched += s.FetchedIndexBytes
+		}
+
+		if ss := resp.GetStreamingSeries(); ss != nil {
+			for _, s := range ss.Series {
+				if err := addStreamingSeriesToQueryLimiter(queryLimiter, s); err != nil {
+					return nil, err
+				}
+			}
+
+			res.streamingSeries = append(res.streamingSeries, ss.Series...)
+			if ss.IsEndOfSeriesStream {
+				// We expect "end of stream" to be sent after the hints and the stats have been sent.
+				break
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// addSeriesToQueryLimiter accounts the series and its chunks in the query limiter, and returns an error if a limit
+// is exceeded.
+func addSeriesToQueryLimiter(queryLimiter *limiter.QueryLimiter, s *storepb.Series) error {
+	// Add series fingerprint to query limiter; will return error if we are over the limit
+	if err := queryLimiter.AddSeries(s.Labels); err != nil {
+		return err
+	}
+
+	chunksCount, chunksSize := countChunksAndBytes(s)
+	if err := queryLimiter.AddChunkBytes(chunksSize); err != nil {
+		return err
+	}
+	if err := queryLimiter.AddChunks(chunksCount); err != nil {
+		return err
+	}
+	return queryLimiter.AddEstimatedChunks(chunksCount)
+}
+
+// addStreamingSeriesToQueryLimiter accounts the streaming series in the query limiter, and returns an error if the
+// limit is exceeded. Its chunks are accounted once received.
+func addStreamingSeriesToQueryLimiter(queryLimiter *limiter.QueryLimiter, s *storepb.StreamingSeries) error {
+	// Add series fingerprint to query limiter; will return error if we are over the limit
+	if limitErr := queryLimiter.AddSeries(s.Labels); limitErr != nil {
+		return validation.LimitError(limitErr.Error())
+	}
+	return nil
+}
+
 func shouldStopQueryFunc(err error) bool {
 	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 		return true
 	}
 
+	// A query exceeding a limit fails, whichever store-gateway the series are received from.
+	var limitErr validation.LimitError
+	if errors.As(err, &limitErr) {
+		return true
+	}
+
 	if st, ok := status.FromError(errors.Cause(err)); ok {
 		if int(st.Code()) == http.StatusUnprocessableEntity {
 			return true
@@ -938,6 +1068,15 @@ func shouldStopQueryFunc(err error) bool {
 	return false
 }
 
+// isTooManyRequestsFunc returns whether the store-gateway rejected the request because the tenant
+// reached its max number of concurrent requests there. The request can be retried on another replica.
+func isTooManyRequestsFunc(err error) bool {
+	if st, ok := status.FromError(errors.Cause(err)); ok {
+		return int(st.Code()) == http.StatusTooManyRequests
+	}
+	return false
+}
+
 func (q *blocksStoreQuerier) fetchLabelNamesFromStore(
 	ctx context.Context,
 	clients map[BlocksStoreClient][]ulid.ULID,
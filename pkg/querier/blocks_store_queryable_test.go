@@ -12,6 +12,7 @@ import (
 	"io"
 	"math/rand"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -1865,7 +1866,7 @@ func TestBlocksStoreQuerier_PromQLExecution(t *testing.T) {
 
 					// Instantiate the querier that will be executed to run the query.
 					logger := log.NewNopLogger()
-					queryable, err := NewBlocksStoreQueryable(stores, finder, NewBlocksConsistencyChecker(0, 0, logger, nil), &blocksStoreLimitsMock{}, 0, 0, logger, nil)
+					queryable, err := NewBlocksStoreQueryable(stores, finder, NewBlocksConsistencyChecker(0, 0, logger, nil), &blocksStoreLimitsMock{}, 0, 0, 0, 0, logger, nil)
 					require.NoError(t, err)
 					require.NoError(t, services.StartAndAwaitRunning(context.Background(), queryable))
 					defer services.StopAndAwaitTerminated(context.Background(), queryable) // nolint:errcheck
@@ -1998,6 +1999,12 @@ type blocksStoreSetMock struct {
 
 	mockedResponses []interface{}
 	nextResult      int
+
+	// The client returned for the hedged requests, if any.
+	hedgeClient BlocksStoreClient
+
+	hedgeResultsMx sync.Mutex
+	hedgeResults   map[string][]bool
 }
 
 func (m *blocksStoreSetMock) GetClientsFor(_ string, _ []ulid.ULID, _ map[ulid.ULID][]string) (map[BlocksStoreClient][]ulid.ULID, error) {
@@ -2018,6 +2025,22 @@ func (m *blocksStoreSetMock) GetClientsFor(_ string, _ []ulid.ULID, _ map[ulid.U
 	return nil, errors.New("unknown data type in the mocked result")
 }
 
+func (m *blocksStoreSetMock) RecordRejection(string, string) {}
+
+func (m *blocksStoreSetMock) GetHedgeClientFor(string, []ulid.ULID, []string) (BlocksStoreClient, error) {
+	return m.hedgeClient, nil
+}
+
+func (m *blocksStoreSetMock) RecordHedgeResult(addr string, won bool) {
+	m.hedgeResultsMx.Lock()
+	defer m.hedgeResultsMx.Unlock()
+
+	if m.hedgeResults == nil {
+		m.hedgeResults = map[string][]bool{}
+	}
+	m.hedgeResults[addr] = append(m.hedgeResults[addr], won)
+}
+
 type blocksFinderMock struct {
 	services.Service
 	mock.Mock
@@ -2032,13 +2055,25 @@ type storeGatewayClientMock struct {
 	remoteAddr                string
 	mockedSeriesResponses     []*storepb.SeriesResponse
 	mockedSeriesErr           error
+	mockedSeriesDelay         time.Duration
 	mockedLabelNamesResponse  *storepb.LabelNamesResponse
 	mockedLabelNamesErr       error
 	mockedLabelValuesResponse *storepb.LabelValuesResponse
 	mockedLabelValuesErr      error
+
+	mockedLabelNamesCardinalityResponse  *storepb.LabelNamesCardinalityResponse
+	mockedLabelValuesCardinalityResponse *storepb.LabelValuesCardinalityResponse
 }
 
 func (m *storeGatewayClientMock) Series(ctx context.Context, _ *storepb.SeriesRequest, _ ...grpc.CallOption) (storegatewaypb.StoreGateway_SeriesClient, error) {
+	if m.mockedSeriesDelay > 0 {
+		select {
+		case <-time.After(m.mockedSeriesDelay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
 	seriesClient := &storeGatewaySeriesClientMock{
 		ClientStream:    grpcClientStreamMock{ctx: ctx}, // Required to not panic.
 		mockedResponses: m.mockedSeriesResponses,
@@ -2055,6 +2090,14 @@ func (m *storeGatewayClientMock) LabelValues(context.Context, *storepb.LabelValu
 	return m.mockedLabelValuesResponse, m.mockedLabelValuesErr
 }
 
+func (m *storeGatewayClientMock) LabelNamesCardinality(context.Context, *storepb.LabelNamesCardinalityRequest, ...grpc.CallOption) (*storepb.LabelNamesCardinalityResponse, error) {
+	return m.mockedLabelNamesCardinalityResponse, nil
+}
+
+func (m *storeGatewayClientMock) LabelValuesCardinality(context.Context, *storepb.LabelValuesCardinalityRequest, ...grpc.CallOption) (*storepb.LabelValuesCardinalityResponse, error) {
+	return m.mockedLabelValuesCardinalityResponse, nil
+}
+
 func (m *storeGatewayClientMock) RemoteAddress() string {
 	return m.remoteAddr
 }
@@ -2131,6 +2174,16 @@ func (m *cancelerStoreGatewayClientMock) LabelValues(ctx context.Context, _ *sto
 	return nil, ctx.Err()
 }
 
+func (m *cancelerStoreGatewayClientMock) LabelNamesCardinality(ctx context.Context, _ *storepb.LabelNamesCardinalityRequest, _ ...grpc.CallOption) (*storepb.LabelNamesCardinalityResponse, error) {
+	m.cancel()
+	return nil, ctx.Err()
+}
+
+func (m *cancelerStoreGatewayClientMock) LabelValuesCardinality(ctx context.Context, _ *storepb.LabelValuesCardinalityRequest, _ ...grpc.CallOption) (*storepb.LabelValuesCardinalityResponse, error) {
+	m.cancel()
+	return nil, ctx.Err()
+}
+
 func (m *cancelerStoreGatewayClientMock) RemoteAddress() string {
 	return m.remoteAddr
 }
@@ -2139,6 +2192,7 @@ type blocksStoreLimitsMock struct {
 	maxLabelsQueryLength        time.Duration
 	maxChunksPerQuery           int
 	storeGatewayTenantShardSize int
+	hedgingLatencyPercentile    float64
 }
 
 func (m *blocksStoreLimitsMock) MaxLabelsQueryLength(_ string) time.Duration {
@@ -2153,6 +2207,10 @@ func (m *blocksStoreLimitsMock) StoreGatewayTenantShardSize(_ string) int {
 	return m.storeGatewayTenantShardSize
 }
 
+func (m *blocksStoreLimitsMock) StoreGatewayHedgingLatencyPercentile(_ string) float64 {
+	return m.hedgingLatencyPercentile
+}
+
 func (m *blocksStoreLimitsMock) S3SSEType(_ string) string {
 	return ""
 }
@@ -9,6 +9,8 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sync"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/dskit/ring"
@@ -30,6 +32,10 @@ const (
 	randomLoadBalancing
 )
 
+// rejectedInstancesAvoidPeriod is how long a store-gateway which rejected a request of a tenant, because the
+// tenant reached its max number of concurrent requests there, is avoided for the following requests of the tenant.
+const rejectedInstancesAvoidPeriod = 10 * time.Second
+
 // BlocksStoreSet implementation used when the blocks are sharded and replicated across
 // a set of store-gateway instances.
 type blocksStoreReplicationSet struct {
@@ -40,6 +46,13 @@ type blocksStoreReplicationSet struct {
 	balancingStrategy loadBalancingStrategy
 	limits            BlocksStoreLimits
 
+	// The time of the latest rejection by tenant and store-gateway address.
+	rejectionsMx sync.Mutex
+	rejections   map[string]map[string]time.Time
+
+	// The outcome of the hedged requests by store-gateway address.
+	hedgeStats *storeGatewayHedgeStats
+
 	// Subservices manager.
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
@@ -58,6 +71,8 @@ func newBlocksStoreReplicationSet(
 		clientsPool:        newStoreGatewayClientPool(client.NewRingServiceDiscovery(storesRing), clientConfig, logger, reg),
 		balancingStrategy:  balancingStrategy,
 		limits:             limits,
+		rejections:         map[string]map[string]time.Time{},
+		hedgeStats:         newStoreGatewayHedgeStats(),
 		subservicesWatcher: services.NewFailureWatcher(),
 	}
 
@@ -102,6 +117,7 @@ func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid
 	instances := make(map[string]ring.InstanceDesc)
 
 	userRing := storegateway.GetShuffleShardingSubring(s.storesRing, userID, s.limits)
+	avoid := append(s.recentlyRejected(userID), s.hedgeStats.slowInstances(time.Now())...)
 
 	// Find the replication set of each block we need to query.
 	for _, blockID := range blockIDs {
@@ -114,8 +130,9 @@ func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid
 			return nil, errors.Wrapf(err, "failed to get store-gateway replication set owning the block %s", blockID.String())
 		}
 
-		// Pick a non excluded store-gateway instance.
-		inst := getNonExcludedInstance(set, exclude[blockID], s.balancingStrategy)
+		// Pick a non excluded store-gateway instance, preferring the ones which haven't recently rejected the tenant
+		// requests nor been consistently slower than their replicas.
+		inst := getNonExcludedInstance(set, exclude[blockID], avoid, s.balancingStrategy)
 		if inst == nil {
 			return nil, fmt.Errorf("no store-gateway instance left after checking exclude for block %s", blockID.String())
 		}
@@ -139,7 +156,98 @@ func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid
 	return clients, nil
 }
 
-func getNonExcludedInstance(set ring.ReplicationSet, exclude []string, balancingStrategy loadBalancingStrategy) *ring.InstanceDesc {
+// GetHedgeClientFor implements BlocksStoreSet.
+func (s *blocksStoreReplicationSet) GetHedgeClientFor(userID string, blockIDs []ulid.ULID, exclude []string) (BlocksStoreClient, error) {
+	userRing := storegateway.GetShuffleShardingSubring(s.storesRing, userID, s.limits)
+
+	// Find the store-gateway instances holding all the blocks.
+	var candidates []ring.InstanceDesc
+	for i, blockID := range blockIDs {
+		bufDescs, bufHosts, bufZones := ring.MakeBuffersForGet()
+
+		set, err := userRing.Get(mimir_tsdb.HashBlockID(blockID), storegateway.BlocksRead, bufDescs, bufHosts, bufZones)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get store-gateway replication set owning the block %s", blockID.String())
+		}
+
+		if i == 0 {
+			candidates = set.Instances
+			continue
+		}
+
+		filtered := candidates[:0]
+		for _, instance := range candidates {
+			if set.Includes(instance.Addr) {
+				filtered = append(filtered, instance)
+			}
+		}
+		candidates = filtered
+	}
+
+	avoid := append(s.recentlyRejected(userID), s.hedgeStats.slowInstances(time.Now())...)
+	inst := getNonExcludedInstance(ring.ReplicationSet{Instances: candidates}, exclude, avoid, s.balancingStrategy)
+	if inst == nil {
+		return nil, nil
+	}
+
+	c, err := s.clientsPool.GetClientForInstance(*inst)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get store-gateway client for %s %s", inst.Id, inst.Addr)
+	}
+
+	return c.(BlocksStoreClient), nil
+}
+
+// RecordHedgeResult implements BlocksStoreSet.
+func (s *blocksStoreReplicationSet) RecordHedgeResult(addr string, won bool) {
+	s.hedgeStats.record(addr, won, time.Now())
+}
+
+// RecordRejection implements BlocksStoreSet.
+func (s *blocksStoreReplicationSet) RecordRejection(userID, addr string) {
+	now := time.Now()
+
+	s.rejectionsMx.Lock()
+	defer s.rejectionsMx.Unlock()
+
+	// Remove the expired rejections, so that the map doesn't grow indefinitely.
+	for u, instances := range s.rejections {
+		for a, t := range instances {
+			if now.Sub(t) >= rejectedInstancesAvoidPeriod {
+				delete(instances, a)
+			}
+		}
+		if len(instances) == 0 {
+			delete(s.rejections, u)
+		}
+	}
+
+	if s.rejections[userID] == nil {
+		s.rejections[userID] = map[string]time.Time{}
+	}
+	s.rejections[userID][addr] = now
+}
+
+// recentlyRejected returns the addresses of the store-gateways which rejected a request of the tenant
+// within the last rejectedInstancesAvoidPeriod.
+func (s *blocksStoreReplicationSet) recentlyRejected(userID string) []string {
+	now := time.Now()
+
+	s.rejectionsMx.Lock()
+	defer s.rejectionsMx.Unlock()
+
+	var addrs []string
+	for addr, t := range s.rejections[userID] {
+		if now.Sub(t) < rejectedInstancesAvoidPeriod {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// getNonExcludedInstance returns a store-gateway instance of the replication set which isn't excluded,
+// preferring the ones which aren't in avoid. Returns nil if all the instances are excluded.
+func getNonExcludedInstance(set ring.ReplicationSet, exclude, avoid []string, balancingStrategy loadBalancingStrategy) *ring.InstanceDesc {
 	if balancingStrategy == randomLoadBalancing {
 		// Randomize the list of instances to not always query the same one.
 		rand.Shuffle(len(set.Instances), func(i, j int) {
@@ -147,6 +255,12 @@ func getNonExcludedInstance(set ring.ReplicationSet, exclude []string, balancing
 		})
 	}
 
+	for _, instance := range set.Instances {
+		if !util.StringsContain(exclude, instance.Addr) && !util.StringsContain(avoid, instance.Addr) {
+			return &instance
+		}
+	}
+
 	for _, instance := range set.Instances {
 		if !util.StringsContain(exclude, instance.Addr) {
 			return &instance
@@ -454,3 +454,169 @@ func getStoreGatewayClientAddrs(clients map[BlocksStoreClient][]ulid.ULID) map[s
 	}
 	return addrs
 }
+
+func TestBlocksStoreReplicationSet_GetClientsFor_ShouldAvoidStoreGatewaysWhichRecentlyRejectedTheTenant(t *testing.T) {
+	const (
+		numRuns      = 100
+		numInstances = 3
+	)
+
+	ctx := context.Background()
+	registeredAt := time.Now()
+	block1 := ulid.MustNew(1, nil)
+
+	// Create a ring.
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	require.NoError(t, ringStore.CAS(ctx, "test", func(in interface{}) (interface{}, bool, error) {
+		d := ring.NewDesc()
+		for n := 1; n <= numInstances; n++ {
+			d.AddIngester(fmt.Sprintf("instance-%d", n), fmt.Sprintf("127.0.0.%d", n), "", []uint32{uint32(n)}, ring.ACTIVE, registeredAt)
+		}
+		return d, true, nil
+	}))
+
+	// Configure a replication factor equal to the number of instances, so that every store-gateway gets all blocks.
+	ringCfg := ring.Config{}
+	flagext.DefaultValues(&ringCfg)
+	ringCfg.ReplicationFactor = numInstances
+
+	r, err := ring.NewWithStoreClientAndStrategy(ringCfg, "test", "test", ringStore, ring.NewIgnoreUnhealthyInstancesReplicationStrategy(), nil, log.NewNopLogger())
+	require.NoError(t, err)
+
+	limits := &blocksStoreLimitsMock{storeGatewayTenantShardSize: 0}
+	s, err := newBlocksStoreReplicationSet(r, randomLoadBalancing, limits, ClientConfig{}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, s))
+	defer services.StopAndAwaitTerminated(ctx, s) //nolint:errcheck
+
+	// Wait until the ring client has initialised the state.
+	test.Poll(t, time.Second, true, func() interface{} {
+		all, err := r.GetAllHealthy(storegateway.BlocksRead)
+		return err == nil && len(all.Instances) > 0
+	})
+
+	distribution := func(userID string, exclude map[ulid.ULID][]string) map[string]int {
+		res := map[string]int{}
+		for n := 0; n < numRuns; n++ {
+			clients, err := s.GetClientsFor(userID, []ulid.ULID{block1}, exclude)
+			require.NoError(t, err)
+			for addr := range getStoreGatewayClientAddrs(clients) {
+				res[addr]++
+			}
+			for c := range clients {
+				c.(io.Closer).Close() //nolint:errcheck
+			}
+		}
+		return res
+	}
+
+	s.RecordRejection("user-A", "127.0.0.1")
+	s.RecordRejection("user-A", "127.0.0.2")
+
+	// The store-gateways which rejected the tenant are avoided.
+	assert.Equal(t, map[string]int{"127.0.0.3": numRuns}, distribution("user-A", nil))
+
+	// The other tenants aren't affected.
+	assert.Len(t, distribution("user-B", nil), numInstances)
+
+	// The store-gateways which rejected the tenant are queried if no other one is left.
+	assert.NotContains(t, distribution("user-A", map[ulid.ULID][]string{block1: {"127.0.0.3"}}), "127.0.0.3")
+
+	// The rejections expire.
+	s.rejectionsMx.Lock()
+	for addr := range s.rejections["user-A"] {
+		s.rejections["user-A"][addr] = time.Now().Add(-rejectedInstancesAvoidPeriod)
+	}
+	s.rejectionsMx.Unlock()
+	assert.Len(t, distribution("user-A", nil), numInstances)
+}
+
+func TestBlocksStoreReplicationSet_GetHedgeClientFor(t *testing.T) {
+	const (
+		numRuns      = 100
+		numInstances = 3
+	)
+
+	ctx := context.Background()
+	registeredAt := time.Now()
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+
+	// Create a ring.
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	require.NoError(t, ringStore.CAS(ctx, "test", func(in interface{}) (interface{}, bool, error) {
+		d := ring.NewDesc()
+		for n := 1; n <= numInstances; n++ {
+			d.AddIngester(fmt.Sprintf("instance-%d", n), fmt.Sprintf("127.0.0.%d", n), "", []uint32{uint32(n)}, ring.ACTIVE, registeredAt)
+		}
+		return d, true, nil
+	}))
+
+	// Configure a replication factor equal to the number of instances, so that every store-gateway gets all blocks.
+	ringCfg := ring.Config{}
+	flagext.DefaultValues(&ringCfg)
+	ringCfg.ReplicationFactor = numInstances
+
+	r, err := ring.NewWithStoreClientAndStrategy(ringCfg, "test", "test", ringStore, ring.NewIgnoreUnhealthyInstancesReplicationStrategy(), nil, log.NewNopLogger())
+	require.NoError(t, err)
+
+	limits := &blocksStoreLimitsMock{storeGatewayTenantShardSize: 0}
+	s, err := newBlocksStoreReplicationSet(r, randomLoadBalancing, limits, ClientConfig{}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, s))
+	defer services.StopAndAwaitTerminated(ctx, s) //nolint:errcheck
+
+	// Wait until the ring client has initialised the state.
+	test.Poll(t, time.Second, true, func() interface{} {
+		all, err := r.GetAllHealthy(storegateway.BlocksRead)
+		return err == nil && len(all.Instances) > 0
+	})
+
+	hedgeDistribution := func(exclude []string) map[string]int {
+		res := map[string]int{}
+		for n := 0; n < numRuns; n++ {
+			c, err := s.GetHedgeClientFor("user-A", []ulid.ULID{block1, block2}, exclude)
+			require.NoError(t, err)
+			if c == nil {
+				res[""]++
+				continue
+			}
+			res[c.RemoteAddress()]++
+			c.(io.Closer).Close() //nolint:errcheck
+		}
+		return res
+	}
+
+	// The excluded store-gateways aren't returned.
+	assert.NotContains(t, hedgeDistribution([]string{"127.0.0.1"}), "127.0.0.1")
+
+	// No client is returned if all the store-gateways are excluded.
+	assert.Equal(t, map[string]int{"": numRuns}, hedgeDistribution([]string{"127.0.0.1", "127.0.0.2", "127.0.0.3"}))
+
+	// The store-gateways which consistently lost the hedged requests are avoided.
+	for n := 0; n < slowInstanceMinHedges; n++ {
+		s.RecordHedgeResult("127.0.0.2", false)
+		s.RecordHedgeResult("127.0.0.3", true)
+	}
+	assert.Equal(t, map[string]int{"127.0.0.3": numRuns}, hedgeDistribution([]string{"127.0.0.1"}))
+
+	res := map[string]int{}
+	for n := 0; n < numRuns; n++ {
+		clients, err := s.GetClientsFor("user-A", []ulid.ULID{block1}, nil)
+		require.NoError(t, err)
+		for addr := range getStoreGatewayClientAddrs(clients) {
+			res[addr]++
+		}
+		for c := range clients {
+			c.(io.Closer).Close() //nolint:errcheck
+		}
+	}
+	assert.NotContains(t, res, "127.0.0.2")
+
+	// The slow store-gateways are used if no other one is left.
+	assert.Equal(t, map[string]int{"127.0.0.2": numRuns}, hedgeDistribution([]string{"127.0.0.1", "127.0.0.3"}))
+}
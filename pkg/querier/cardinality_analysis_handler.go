@@ -3,6 +3,7 @@
 package querier
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sort"
@@ -10,6 +11,8 @@ import (
 	"github.com/grafana/dskit/httpgrpc"
 	"github.com/grafana/dskit/tenant"
 	"github.com/pkg/errors"
+	"golang.org/x/exp/slices"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/mimir/pkg/cardinality"
 	ingester_client "github.com/grafana/mimir/pkg/ingester/client"
@@ -19,7 +22,8 @@ import (
 )
 
 // LabelNamesCardinalityHandler creates handler for label names cardinality endpoint.
-func LabelNamesCardinalityHandler(d Distributor, limits *validation.Overrides) http.Handler {
+// The blocks analyzer is optional, and the requests for the blocks source fail if it's nil.
+func LabelNamesCardinalityHandler(d Distributor, blocks BlocksCardinalityAnalyzer, limits *validation.Overrides) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		tenantID, err := tenant.TenantID(ctx)
@@ -37,7 +41,7 @@ func LabelNamesCardinalityHandler(d Distributor, limits *validation.Overrides) h
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		response, err := d.LabelNamesAndValues(ctx, cardinalityRequest.Matchers)
+		response, err := fetchLabelNamesAndValues(ctx, d, blocks, cardinalityRequest)
 		if err != nil {
 			respondFromError(err, w)
 			return
@@ -48,7 +52,8 @@ func LabelNamesCardinalityHandler(d Distributor, limits *validation.Overrides) h
 }
 
 // LabelValuesCardinalityHandler creates handler for label values cardinality endpoint.
-func LabelValuesCardinalityHandler(distributor Distributor, limits *validation.Overrides) http.Handler {
+// The blocks analyzer is optional, and the requests for the blocks source fail if it's nil.
+func LabelValuesCardinalityHandler(distributor Distributor, blocks BlocksCardinalityAnalyzer, limits *validation.Overrides) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		// Guarantee request's context is for a single tenant id
@@ -68,7 +73,7 @@ func LabelValuesCardinalityHandler(distributor Distributor, limits *validation.O
 			return
 		}
 
-		seriesCountTotal, cardinalityResponse, err := distributor.LabelValuesCardinality(ctx, cardinalityRequest.LabelNames, cardinalityRequest.Matchers, cardinalityRequest.CountMethod)
+		seriesCountTotal, cardinalityResponse, err := fetchLabelValuesCardinality(ctx, distributor, blocks, limits, tenantID, cardinalityRequest)
 		if err != nil {
 			respondFromError(err, w)
 			return
@@ -78,6 +83,103 @@ func LabelValuesCardinalityHandler(distributor Distributor, limits *validation.O
 	})
 }
 
+// fetchLabelNamesAndValues returns the label names, with their values, from the source of the request. When both
+// ingesters and blocks are queried, the values of each label name are merged.
+func fetchLabelNamesAndValues(ctx context.Context, d Distributor, blocks BlocksCardinalityAnalyzer, req *cardinality.LabelNamesRequest) (*ingester_client.LabelNamesAndValuesResponse, error) {
+	if req.Source == cardinality.IngestersSource {
+		return d.LabelNamesAndValues(ctx, req.Matchers)
+	}
+	if blocks == nil {
+		return nil, errBlocksCardinalityAnalysisUnsupported
+	}
+	if req.Source == cardinality.BlocksSource {
+		return blocks.LabelNamesAndValues(ctx, req.Start, req.End, req.Matchers)
+	}
+
+	var ingestersResp, blocksResp *ingester_client.LabelNamesAndValuesResponse
+	g, gCtx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		ingestersResp, err = d.LabelNamesAndValues(gCtx, req.Matchers)
+		return err
+	})
+	g.Go(func() (err error) {
+		blocksResp, err = blocks.LabelNamesAndValues(gCtx, req.Start, req.End, req.Matchers)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	valueSets := map[string][][]string{}
+	for _, resp := range []*ingester_client.LabelNamesAndValuesResponse{ingestersResp, blocksResp} {
+		for _, item := range resp.Items {
+			// The order of the values is not guaranteed, but they must be sorted to be merged.
+			slices.Sort(item.Values)
+			valueSets[item.LabelName] = append(valueSets[item.LabelName], item.Values)
+		}
+	}
+
+	merged := &ingester_client.LabelNamesAndValuesResponse{Items: make([]*ingester_client.LabelValues, 0, len(valueSets))}
+	for name, sets := range valueSets {
+		merged.Items = append(merged.Items, &ingester_client.LabelValues{LabelName: name, Values: util.MergeSlices(sets...)})
+	}
+	return merged, nil
+}
+
+// fetchLabelValuesCardinality returns the total number of series and the series count of each label value from the source
+// of the request. When both ingesters and blocks are queried, the max series count of each label value is returned,
+// because the most recent series are both in the ingesters and in the blocks.
+func fetchLabelValuesCardinality(ctx context.Context, d Distributor, blocks BlocksCardinalityAnalyzer, limits *validation.Overrides, tenantID string, req *cardinality.LabelValuesRequest) (uint64, *ingester_client.LabelValuesCardinalityResponse, error) {
+	if req.Source == cardinality.IngestersSource {
+		return d.LabelValuesCardinality(ctx, req.LabelNames, req.Matchers, req.CountMethod)
+	}
+	if blocks == nil {
+		return 0, nil, errBlocksCardinalityAnalysisUnsupported
+	}
+	if limit := limits.LabelValuesMaxCardinalityLabelNamesPerRequest(tenantID); len(req.LabelNames) > limit {
+		return 0, nil, httpgrpc.Errorf(http.StatusBadRequest, "label values cardinality request label names limit (limit: %d actual: %d) exceeded", limit, len(req.LabelNames))
+	}
+	if req.Source == cardinality.BlocksSource {
+		return blocks.LabelValuesCardinality(ctx, req.Start, req.End, req.LabelNames, req.Matchers)
+	}
+
+	var (
+		ingestersSeriesCount, blocksSeriesCount uint64
+		ingestersResp, blocksResp               *ingester_client.LabelValuesCardinalityResponse
+	)
+	g, gCtx := errgroup.WithContext(ctx)
+	g.Go(func() (err error) {
+		ingestersSeriesCount, ingestersResp, err = d.LabelValuesCardinality(gCtx, req.LabelNames, req.Matchers, req.CountMethod)
+		return err
+	})
+	g.Go(func() (err error) {
+		blocksSeriesCount, blocksResp, err = blocks.LabelValuesCardinality(gCtx, req.Start, req.End, req.LabelNames, req.Matchers)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return 0, nil, err
+	}
+
+	merged := &ingester_client.LabelValuesCardinalityResponse{}
+	mergedByName := map[string]map[string]uint64{}
+	for _, resp := range []*ingester_client.LabelValuesCardinalityResponse{ingestersResp, blocksResp} {
+		for _, item := range resp.Items {
+			dst, ok := mergedByName[item.LabelName]
+			if !ok {
+				dst = make(map[string]uint64, len(item.LabelValueSeries))
+				mergedByName[item.LabelName] = dst
+				merged.Items = append(merged.Items, &ingester_client.LabelValueSeriesCount{LabelName: item.LabelName, LabelValueSeries: dst})
+			}
+			for value, count := range item.LabelValueSeries {
+				dst[value] = util_math.Max(dst[value], count)
+			}
+		}
+	}
+	return util_math.Max(ingestersSeriesCount, blocksSeriesCount), merged, nil
+}
+
+var errBlocksCardinalityAnalysisUnsupported = httpgrpc.Errorf(http.StatusBadRequest, "cardinality analysis on blocks is not supported")
+
 func respondFromError(err error, w http.ResponseWriter) {
 	httpResp, ok := httpgrpc.HTTPResponseFromError(errors.Cause(err))
 	if !ok {
@@ -210,7 +210,7 @@ func TestLabelNamesCardinalityHandler_DistributorError(t *testing.T) {
 			limits.CardinalityAnalysisEnabled = true
 			overrides, err := validation.NewOverrides(limits, nil)
 			require.NoError(t, err)
-			handler := LabelNamesCardinalityHandler(distributor, overrides)
+			handler := LabelNamesCardinalityHandler(distributor, nil, overrides)
 			ctx := user.InjectOrgID(context.Background(), "test")
 
 			request, err := http.NewRequestWithContext(ctx, "GET", labelNamesURL, http.NoBody)
@@ -275,7 +275,7 @@ func TestLabelNamesCardinalityHandler_NegativeTests(t *testing.T) {
 			}
 			overrides, err := validation.NewOverrides(limits, nil)
 			require.NoError(t, err)
-			handler := LabelNamesCardinalityHandler(mockDistributorLabelNamesAndValues([]*client.LabelValues{}, nil), overrides)
+			handler := LabelNamesCardinalityHandler(mockDistributorLabelNamesAndValues([]*client.LabelValues{}, nil), nil, overrides)
 
 			recorder := httptest.NewRecorder()
 
@@ -643,7 +643,7 @@ func TestLabelValuesCardinalityHandler_FeatureFlag(t *testing.T) {
 			limits := validation.Limits{CardinalityAnalysisEnabled: testData.cardinalityAnalysisEnabled}
 			overrides, err := validation.NewOverrides(limits, nil)
 			require.NoError(t, err)
-			handler := LabelValuesCardinalityHandler(distributor, overrides)
+			handler := LabelValuesCardinalityHandler(distributor, nil, overrides)
 
 			recorder := httptest.NewRecorder()
 			handler.ServeHTTP(recorder, testData.request)
@@ -786,13 +786,149 @@ func TestLabelValuesCardinalityHandler_DistributorError(t *testing.T) {
 	}
 }
 
+func TestCardinalityHandlers_Source(t *testing.T) {
+	const (
+		start = int64(1000)
+		end   = int64(2000)
+	)
+
+	var (
+		matchers   = []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "env", "prod")}
+		labelNames = []model.LabelName{"job"}
+	)
+
+	limits := validation.Limits{CardinalityAnalysisEnabled: true, LabelValuesMaxCardinalityLabelNamesPerRequest: 1}
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+
+	distributor := &mockDistributor{}
+	distributor.On("LabelNamesAndValues", mock.Anything, matchers).Return(&client.LabelNamesAndValuesResponse{Items: []*client.LabelValues{
+		{LabelName: "env", Values: []string{"prod"}},
+		{LabelName: "job", Values: []string{"a", "b"}},
+	}}, nil)
+	distributor.On("LabelValuesCardinality", mock.Anything, labelNames, matchers, cardinality.InMemoryMethod).Return(uint64(10), &client.LabelValuesCardinalityResponse{Items: []*client.LabelValueSeriesCount{
+		{LabelName: "job", LabelValueSeries: map[string]uint64{"a": 3, "b": 7}},
+	}}, nil)
+
+	blocks := &blocksCardinalityAnalyzerMock{}
+	blocks.On("LabelNamesAndValues", mock.Anything, start, end, matchers).Return(&client.LabelNamesAndValuesResponse{Items: []*client.LabelValues{
+		{LabelName: "env", Values: []string{"prod"}},
+		{LabelName: "job", Values: []string{"b", "c", "d"}},
+	}}, nil)
+	blocks.On("LabelValuesCardinality", mock.Anything, start, end, labelNames, matchers).Return(uint64(12), &client.LabelValuesCardinalityResponse{Items: []*client.LabelValueSeriesCount{
+		{LabelName: "job", LabelValueSeries: map[string]uint64{"b": 5, "c": 4, "d": 3}},
+	}}, nil)
+
+	t.Run("label names", func(t *testing.T) {
+		tests := map[string]struct {
+			source   string
+			expected LabelNamesCardinalityResponse
+		}{
+			"ingesters": {
+				source: "ingesters",
+				expected: LabelNamesCardinalityResponse{LabelValuesCountTotal: 3, LabelNamesCount: 2, Cardinality: []*LabelNamesCardinalityItem{
+					{LabelName: "job", LabelValuesCount: 2},
+					{LabelName: "env", LabelValuesCount: 1},
+				}},
+			},
+			"blocks": {
+				source: "blocks",
+				expected: LabelNamesCardinalityResponse{LabelValuesCountTotal: 4, LabelNamesCount: 2, Cardinality: []*LabelNamesCardinalityItem{
+					{LabelName: "job", LabelValuesCount: 3},
+					{LabelName: "env", LabelValuesCount: 1},
+				}},
+			},
+			"all": {
+				source: "all",
+				expected: LabelNamesCardinalityResponse{LabelValuesCountTotal: 5, LabelNamesCount: 2, Cardinality: []*LabelNamesCardinalityItem{
+					{LabelName: "job", LabelValuesCount: 4},
+					{LabelName: "env", LabelValuesCount: 1},
+				}},
+			},
+		}
+
+		for testName, testData := range tests {
+			t.Run(testName, func(t *testing.T) {
+				handler := LabelNamesCardinalityHandler(distributor, blocks, overrides)
+				recorder := httptest.NewRecorder()
+				handler.ServeHTTP(recorder, createRequest(fmt.Sprintf("/ignored-url?selector={env=\"prod\"}&source=%s&start=1&end=2", testData.source), "team-a"))
+				require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+
+				responseBody := LabelNamesCardinalityResponse{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &responseBody))
+				require.Equal(t, testData.expected, responseBody)
+			})
+		}
+	})
+
+	t.Run("label values", func(t *testing.T) {
+		tests := map[string]struct {
+			source   string
+			expected labelValuesCardinalityResponse
+		}{
+			"ingesters": {
+				source: "ingesters",
+				expected: labelValuesCardinalityResponse{SeriesCountTotal: 10, Labels: []labelNamesCardinality{{
+					LabelName: "job", LabelValuesCount: 2, SeriesCount: 10,
+					Cardinality: []labelValuesCardinality{{LabelValue: "b", SeriesCount: 7}, {LabelValue: "a", SeriesCount: 3}},
+				}}},
+			},
+			"blocks": {
+				source: "blocks",
+				expected: labelValuesCardinalityResponse{SeriesCountTotal: 12, Labels: []labelNamesCardinality{{
+					LabelName: "job", LabelValuesCount: 3, SeriesCount: 12,
+					Cardinality: []labelValuesCardinality{{LabelValue: "b", SeriesCount: 5}, {LabelValue: "c", SeriesCount: 4}, {LabelValue: "d", SeriesCount: 3}},
+				}}},
+			},
+			"all": {
+				source: "all",
+				expected: labelValuesCardinalityResponse{SeriesCountTotal: 12, Labels: []labelNamesCardinality{{
+					LabelName: "job", LabelValuesCount: 4, SeriesCount: 17,
+					Cardinality: []labelValuesCardinality{{LabelValue: "b", SeriesCount: 7}, {LabelValue: "c", SeriesCount: 4}, {LabelValue: "a", SeriesCount: 3}, {LabelValue: "d", SeriesCount: 3}},
+				}}},
+			},
+		}
+
+		for testName, testData := range tests {
+			t.Run(testName, func(t *testing.T) {
+				handler := LabelValuesCardinalityHandler(distributor, blocks, overrides)
+				recorder := httptest.NewRecorder()
+				handler.ServeHTTP(recorder, createRequest(fmt.Sprintf("/ignored-url?label_names[]=job&selector={env=\"prod\"}&source=%s&start=1&end=2", testData.source), "team-a"))
+				require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+
+				responseBody := labelValuesCardinalityResponse{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &responseBody))
+				require.Equal(t, testData.expected, responseBody)
+			})
+		}
+	})
+
+	t.Run("blocks source is not supported without the blocks analyzer", func(t *testing.T) {
+		for _, handler := range []http.Handler{
+			LabelNamesCardinalityHandler(distributor, nil, overrides),
+			LabelValuesCardinalityHandler(distributor, nil, overrides),
+		} {
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, createRequest("/ignored-url?label_names[]=job&source=blocks", "team-a"))
+			require.Equal(t, http.StatusBadRequest, recorder.Result().StatusCode)
+		}
+	})
+
+	t.Run("too many label names for the blocks source", func(t *testing.T) {
+		handler := LabelValuesCardinalityHandler(distributor, blocks, overrides)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, createRequest("/ignored-url?label_names[]=job&label_names[]=env&source=blocks", "team-a"))
+		require.Equal(t, http.StatusBadRequest, recorder.Result().StatusCode)
+	})
+}
+
 // createEnabledHandler creates a cardinalityHandler that can be either a LabelNamesCardinalityHandler or a LabelValuesCardinalityHandler
-func createEnabledHandler(t *testing.T, cardinalityHandler func(Distributor, *validation.Overrides) http.Handler, distributor *mockDistributor) http.Handler {
+func createEnabledHandler(t *testing.T, cardinalityHandler func(Distributor, BlocksCardinalityAnalyzer, *validation.Overrides) http.Handler, distributor *mockDistributor) http.Handler {
 	limits := validation.Limits{CardinalityAnalysisEnabled: true}
 	overrides, err := validation.NewOverrides(limits, nil)
 	require.NoError(t, err)
 
-	handler := cardinalityHandler(distributor, overrides)
+	handler := cardinalityHandler(distributor, nil, overrides)
 	return handler
 }
 
@@ -830,3 +966,17 @@ func mockDistributorLabelValuesCardinality(labelNames []model.LabelName, matcher
 	distributor.On("LabelValuesCardinality", mock.Anything, labelNames, matchers, countMethod).Return(seriesCount, cardinalityResponse, err)
 	return distributor
 }
+
+type blocksCardinalityAnalyzerMock struct {
+	mock.Mock
+}
+
+func (m *blocksCardinalityAnalyzerMock) LabelNamesAndValues(ctx context.Context, minT, maxT int64, matchers []*labels.Matcher) (*client.LabelNamesAndValuesResponse, error) {
+	args := m.Called(ctx, minT, maxT, matchers)
+	return args.Get(0).(*client.LabelNamesAndValuesResponse), args.Error(1)
+}
+
+func (m *blocksCardinalityAnalyzerMock) LabelValuesCardinality(ctx context.Context, minT, maxT int64, labelNames []model.LabelName, matchers []*labels.Matcher) (uint64, *client.LabelValuesCardinalityResponse, error) {
+	args := m.Called(ctx, minT, maxT, labelNames, matchers)
+	return args.Get(0).(uint64), args.Get(1).(*client.LabelValuesCardinalityResponse), args.Error(2)
+}
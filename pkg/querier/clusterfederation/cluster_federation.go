@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clusterfederation
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/tenant"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/util/annotations"
+
+	"github.com/grafana/mimir/pkg/querier/stats"
+	"github.com/grafana/mimir/pkg/querier/tenantfederation"
+	"github.com/grafana/mimir/pkg/util/limiter"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	defaultClusterLabel = "__cluster__"
+	defaultConcurrency  = 16
+)
+
+var (
+	errMissingLocalCluster      = errors.New("the local cluster name must be set when cluster federation is enabled")
+	errInvalidClusterLabel      = errors.New("the cluster label must be a valid label name")
+	errMissingRemoteClusterName = errors.New("the name of a remote cluster must be set")
+	errInvalidRemoteTimeout     = errors.New("the timeout of a remote cluster must not be negative")
+	errFederatedRequest         = errors.New("the request has been sent by a remote cluster, but cluster federation is enabled in this cluster too: clusters can't be configured as each other's remote clusters")
+)
+
+type Config struct {
+	Enabled        bool                  `yaml:"enabled" category:"experimental"`
+	LocalCluster   string                `yaml:"local_cluster" category:"experimental"`
+	ClusterLabel   string                `yaml:"cluster_label" category:"experimental"`
+	MaxConcurrent  int                   `yaml:"max_concurrent" category:"experimental"`
+	RemoteClusters []RemoteClusterConfig `yaml:"remote_clusters" doc:"nocli|description=List of remote Mimir clusters queried along with the local one. Each remote cluster is configured with a 'name', used as the value of the cluster label of its series, an 'address', the URL prefix of the Prometheus HTTP API of its query-frontends (for example http://mimir.example.com/prometheus), an optional 'timeout' for the requests to the remote cluster, and 'partial_response' which, if true, returns the errors querying the remote cluster as warnings instead of failing the query. The queriers with cluster federation enabled refuse the requests sent by a remote cluster, so remote clusters must not have cluster federation enabled towards this cluster." category:"experimental"`
+}
+
+// Limits are the per-tenant limits enforced on the series received from the remote clusters.
+type Limits interface {
+	MaxFetchedSeriesPerQuery(userID string) int
+	MaxFetchedChunkBytesPerQuery(userID string) int
+	MaxChunksPerQuery(userID string) int
+}
+
+// RemoteClusterConfig configures a remote Mimir cluster queried via its query-frontends.
+type RemoteClusterConfig struct {
+	Name            string        `yaml:"name"`
+	Address         string        `yaml:"address"`
+	Timeout         time.Duration `yaml:"timeout"`
+	PartialResponse bool          `yaml:"partial_response"`
+}
+
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "cluster-federation.enabled", false, "If enabled, queriers also query the remote Mimir clusters configured in the remote clusters list, via remote read, and merge their series with the local ones. Series are labelled with the name of the cluster they come from.")
+	f.StringVar(&cfg.LocalCluster, "cluster-federation.local-cluster", "", "Name of the local cluster, used as the value of the cluster label of the local series.")
+	f.StringVar(&cfg.ClusterLabel, "cluster-federation.cluster-label", defaultClusterLabel, "Name of the label added to the series to identify the cluster they come from. Matchers on this label only select the matching clusters, and are not forwarded to the clusters.")
+	f.IntVar(&cfg.MaxConcurrent, "cluster-federation.max-concurrent", defaultConcurrency, "The number of workers used for each cluster federated query. This setting limits the maximum number of per-cluster queries executed at a time for a cluster federated query.")
+}
+
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.LocalCluster == "" {
+		return errMissingLocalCluster
+	}
+	if !model.LabelName(cfg.ClusterLabel).IsValid() {
+		return errInvalidClusterLabel
+	}
+
+	names := map[string]struct{}{cfg.LocalCluster: {}}
+	for _, remote := range cfg.RemoteClusters {
+		if remote.Name == "" {
+			return errMissingRemoteClusterName
+		}
+		if _, ok := names[remote.Name]; ok {
+			return fmt.Errorf("the cluster name %q is used by more than one cluster", remote.Name)
+		}
+		names[remote.Name] = struct{}{}
+
+		if u, err := url.Parse(remote.Address); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid address %q for remote cluster %q", remote.Address, remote.Name)
+		}
+		if remote.Timeout < 0 {
+			return errInvalidRemoteTimeout
+		}
+	}
+	return nil
+}
+
+// NewQueryable returns a queryable that queries the local queryable and the remote clusters configured in cfg, and
+// merges their results. Each series has the cluster label set to the name of the cluster it comes from. If the
+// label already exists, its previous value is exposed through a new label prefixed with "original_". The series
+// received from the remote clusters are accounted in the query limits of the tenants.
+func NewQueryable(cfg Config, local storage.Queryable, limits Limits, reg prometheus.Registerer, logger log.Logger) storage.Queryable {
+	metrics := newRemoteClusterMetrics(reg)
+	queryMetrics := stats.NewQueryMetrics(prometheus.WrapRegistererWith(prometheus.Labels{"engine": "cluster-federation"}, reg))
+
+	clusters := make([]string, 0, len(cfg.RemoteClusters)+1)
+	clusters = append(clusters, cfg.LocalCluster)
+
+	remotes := make(map[string]*remoteCluster, len(cfg.RemoteClusters))
+	for _, remoteCfg := range cfg.RemoteClusters {
+		clusters = append(clusters, remoteCfg.Name)
+		remotes[remoteCfg.Name] = newRemoteCluster(remoteCfg, metrics, logger)
+	}
+
+	callbacks := tenantfederation.MergeQueryableCallbacks{
+		Querier: func(mint, maxt int64) (tenantfederation.MergeQuerierUpstream, error) {
+			q, err := local.Querier(mint, maxt)
+			if err != nil {
+				return nil, errors.Wrap(err, "construct querier")
+			}
+
+			return &clusterQuerier{
+				local:        q,
+				localCluster: cfg.LocalCluster,
+				remotes:      remotes,
+				limits:       limits,
+				queryMetrics: queryMetrics,
+				mint:         mint,
+				maxt:         maxt,
+			}, nil
+		},
+		IDs: func(context.Context) ([]string, error) {
+			return clusters, nil
+		},
+	}
+
+	// The cluster label is added to the series of the local cluster too, so bypassing the merge is not allowed.
+	// Partial responses are configured per remote cluster.
+	return tenantfederation.NewMergeQueryable(cfg.ClusterLabel, callbacks, false, false, cfg.MaxConcurrent, logger)
+}
+
+// RejectFederatedRequests wraps the handler of the queriers with cluster federation enabled, refusing the requests
+// sent by a remote cluster, so that the queries can't loop between clusters configured as each other's remote
+// clusters.
+func RejectFederatedRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(FederatedRequestHeader) != "" {
+			http.Error(w, errFederatedRequest.Error(), http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clusterQuerier implements tenantfederation.MergeQuerierUpstream, running each query against the local querier or
+// the remote cluster identified by the federation ID.
+type clusterQuerier struct {
+	local        storage.Querier
+	localCluster string
+	remotes      map[string]*remoteCluster
+	limits       Limits
+	queryMetrics *stats.QueryMetrics
+	mint, maxt   int64
+}
+
+func (q *clusterQuerier) Select(ctx context.Context, cluster string, sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	if cluster == q.localCluster {
+		return q.local.Select(ctx, sortSeries, hints, matchers...)
+	}
+
+	mint, maxt := q.mint, q.maxt
+	if hints != nil {
+		mint, maxt = hints.Start, hints.End
+	}
+
+	ctx, err := q.contextWithQueryLimiter(ctx)
+	if err != nil {
+		return storage.ErrSeriesSet(err)
+	}
+	return q.remotes[cluster].selectSeries(ctx, mint, maxt, matchers)
+}
+
+// contextWithQueryLimiter returns a context carrying a query limiter enforcing the smallest query limits of the
+// tenants on the series received from a remote cluster, like the local querier does for each Select().
+func (q *clusterQuerier) contextWithQueryLimiter(ctx context.Context) (context.Context, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return limiter.AddQueryLimiterToContext(ctx, limiter.NewQueryLimiter(
+		validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, q.limits.MaxFetchedSeriesPerQuery),
+		validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, q.limits.MaxFetchedChunkBytesPerQuery),
+		validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, q.limits.MaxChunksPerQuery),
+		0,
+		q.queryMetrics,
+	)), nil
+}
+
+func (q *clusterQuerier) LabelValues(ctx context.Context, cluster string, name string, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	if cluster == q.localCluster {
+		return q.local.LabelValues(ctx, name, matchers...)
+	}
+	return q.remotes[cluster].labelValues(ctx, q.mint, q.maxt, name, matchers)
+}
+
+func (q *clusterQuerier) LabelNames(ctx context.Context, cluster string, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	if cluster == q.localCluster {
+		return q.local.LabelNames(ctx, matchers...)
+	}
+	return q.remotes[cluster].labelNames(ctx, q.mint, q.maxt, matchers)
+}
+
+func (q *clusterQuerier) Close() error {
+	return q.local.Close()
+}
@@ -0,0 +1,489 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clusterfederation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/util/annotations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"golang.org/x/exp/slices"
+
+	"github.com/grafana/mimir/pkg/querier"
+	"github.com/grafana/mimir/pkg/storage/series"
+	"github.com/grafana/mimir/pkg/util/limiter"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := map[string]struct {
+		cfg         Config
+		expectedErr string
+	}{
+		"disabled": {
+			cfg: Config{},
+		},
+		"valid": {
+			cfg: Config{Enabled: true, LocalCluster: "eu", ClusterLabel: defaultClusterLabel, RemoteClusters: []RemoteClusterConfig{
+				{Name: "us", Address: "http://mimir-us/prometheus", Timeout: time.Second},
+			}},
+		},
+		"missing local cluster": {
+			cfg:         Config{Enabled: true, ClusterLabel: defaultClusterLabel},
+			expectedErr: errMissingLocalCluster.Error(),
+		},
+		"invalid cluster label": {
+			cfg:         Config{Enabled: true, LocalCluster: "eu", ClusterLabel: "cluster-name"},
+			expectedErr: errInvalidClusterLabel.Error(),
+		},
+		"missing remote cluster name": {
+			cfg: Config{Enabled: true, LocalCluster: "eu", ClusterLabel: defaultClusterLabel, RemoteClusters: []RemoteClusterConfig{
+				{Address: "http://mimir-us/prometheus"},
+			}},
+			expectedErr: errMissingRemoteClusterName.Error(),
+		},
+		"duplicated cluster name": {
+			cfg: Config{Enabled: true, LocalCluster: "eu", ClusterLabel: defaultClusterLabel, RemoteClusters: []RemoteClusterConfig{
+				{Name: "eu", Address: "http://mimir-us/prometheus"},
+			}},
+			expectedErr: `the cluster name "eu" is used by more than one cluster`,
+		},
+		"invalid remote cluster address": {
+			cfg: Config{Enabled: true, LocalCluster: "eu", ClusterLabel: defaultClusterLabel, RemoteClusters: []RemoteClusterConfig{
+				{Name: "us", Address: "mimir-us"},
+			}},
+			expectedErr: `invalid address "mimir-us" for remote cluster "us"`,
+		},
+		"negative remote cluster timeout": {
+			cfg: Config{Enabled: true, LocalCluster: "eu", ClusterLabel: defaultClusterLabel, RemoteClusters: []RemoteClusterConfig{
+				{Name: "us", Address: "http://mimir-us/prometheus", Timeout: -time.Second},
+			}},
+			expectedErr: errInvalidRemoteTimeout.Error(),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestQueryable_Select(t *testing.T) {
+	local := newMockQueryable(
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "up", "job", "local"), []model.SamplePair{{Timestamp: 10, Value: 1}}, nil),
+	)
+	remote := newMockRemoteCluster(t,
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "up", "job", "remote-1"), []model.SamplePair{{Timestamp: 10, Value: 2}, {Timestamp: 20, Value: 3}}, nil),
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "up", "job", "remote-2"), []model.SamplePair{{Timestamp: 10, Value: 4}}, nil),
+	)
+
+	queryable := NewQueryable(Config{
+		Enabled:        true,
+		LocalCluster:   "eu",
+		ClusterLabel:   defaultClusterLabel,
+		MaxConcurrent:  defaultConcurrency,
+		RemoteClusters: []RemoteClusterConfig{{Name: "us", Address: remote.url()}},
+	}, local, mockLimits{}, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+
+	ctx := user.InjectOrgID(context.Background(), "team-a")
+
+	t.Run("should merge the series of the local and remote clusters", func(t *testing.T) {
+		q, err := queryable.Querier(0, 100)
+		require.NoError(t, err)
+
+		set := q.Select(ctx, true, &storage.SelectHints{Start: 0, End: 100}, labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up"))
+		actual := readSeriesSet(t, set)
+		require.NoError(t, set.Err())
+
+		assert.Equal(t, map[string][]model.SamplePair{
+			`{__cluster__="eu", __name__="up", job="local"}`:    {{Timestamp: 10, Value: 1}},
+			`{__cluster__="us", __name__="up", job="remote-1"}`: {{Timestamp: 10, Value: 2}, {Timestamp: 20, Value: 3}},
+			`{__cluster__="us", __name__="up", job="remote-2"}`: {{Timestamp: 10, Value: 4}},
+		}, actual)
+		assert.Equal(t, "team-a", remote.lastOrgID.Load())
+		assert.Equal(t, "true", remote.lastFederatedHeader.Load())
+	})
+
+	t.Run("should forward the matchers to the remote cluster", func(t *testing.T) {
+		q, err := queryable.Querier(0, 100)
+		require.NoError(t, err)
+
+		set := q.Select(ctx, true, &storage.SelectHints{Start: 0, End: 100}, labels.MustNewMatcher(labels.MatchEqual, "job", "remote-2"))
+		actual := readSeriesSet(t, set)
+		require.NoError(t, set.Err())
+
+		assert.Equal(t, map[string][]model.SamplePair{
+			`{__cluster__="us", __name__="up", job="remote-2"}`: {{Timestamp: 10, Value: 4}},
+		}, actual)
+	})
+
+	t.Run("should not query the remote cluster if not selected by the cluster label matchers", func(t *testing.T) {
+		q, err := queryable.Querier(0, 100)
+		require.NoError(t, err)
+
+		requestsBefore := remote.requests.Load()
+		set := q.Select(ctx, true, &storage.SelectHints{Start: 0, End: 100}, labels.MustNewMatcher(labels.MatchEqual, defaultClusterLabel, "eu"))
+		actual := readSeriesSet(t, set)
+		require.NoError(t, set.Err())
+
+		assert.Equal(t, map[string][]model.SamplePair{
+			`{__cluster__="eu", __name__="up", job="local"}`: {{Timestamp: 10, Value: 1}},
+		}, actual)
+		assert.Equal(t, requestsBefore, remote.requests.Load())
+	})
+}
+
+func TestQueryable_RemoteClusterFailures(t *testing.T) {
+	local := newMockQueryable(
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "up", "job", "local"), []model.SamplePair{{Timestamp: 10, Value: 1}}, nil),
+	)
+
+	failing := newMockRemoteCluster(t)
+	failing.statusCode = http.StatusInternalServerError
+
+	slow := newMockRemoteCluster(t)
+	slow.delay = time.Second
+
+	tests := map[string]struct {
+		remote              RemoteClusterConfig
+		expectedErr         string
+		expectedWarningPart string
+	}{
+		"remote cluster failing without partial response": {
+			remote:      RemoteClusterConfig{Name: "us", Address: failing.url()},
+			expectedErr: "error querying cluster us: remote cluster us returned status code 500: failure",
+		},
+		"remote cluster failing with partial response": {
+			remote:              RemoteClusterConfig{Name: "us", Address: failing.url(), PartialResponse: true},
+			expectedWarningPart: "remote cluster us returned status code 500: failure",
+		},
+		"remote cluster timing out with partial response": {
+			remote:              RemoteClusterConfig{Name: "us", Address: slow.url(), Timeout: 50 * time.Millisecond, PartialResponse: true},
+			expectedWarningPart: context.DeadlineExceeded.Error(),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			queryable := NewQueryable(Config{
+				Enabled:        true,
+				LocalCluster:   "eu",
+				ClusterLabel:   defaultClusterLabel,
+				MaxConcurrent:  defaultConcurrency,
+				RemoteClusters: []RemoteClusterConfig{tc.remote},
+			}, local, mockLimits{}, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+
+			q, err := queryable.Querier(0, 100)
+			require.NoError(t, err)
+
+			ctx := user.InjectOrgID(context.Background(), "team-a")
+			set := q.Select(ctx, true, &storage.SelectHints{Start: 0, End: 100})
+			actual := readSeriesSet(t, set)
+
+			if tc.expectedErr != "" {
+				require.EqualError(t, set.Err(), tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, set.Err())
+			assert.Equal(t, map[string][]model.SamplePair{
+				`{__cluster__="eu", __name__="up", job="local"}`: {{Timestamp: 10, Value: 1}},
+			}, actual)
+
+			warnings := set.Warnings()
+			require.Len(t, warnings, 1)
+			for w := range warnings {
+				assert.Contains(t, w, tc.expectedWarningPart)
+			}
+		})
+	}
+}
+
+func TestQueryable_RemoteClusterQueryLimits(t *testing.T) {
+	local := newMockQueryable(
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "up", "job", "local"), []model.SamplePair{{Timestamp: 10, Value: 1}}, nil),
+	)
+	remote := newMockRemoteCluster(t,
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "up", "job", "remote-1"), []model.SamplePair{{Timestamp: 10, Value: 2}}, nil),
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "up", "job", "remote-2"), []model.SamplePair{{Timestamp: 10, Value: 3}}, nil),
+	)
+
+	tests := map[string]struct {
+		limits      mockLimits
+		expectedErr string
+	}{
+		"within the limits": {
+			limits: mockLimits{maxSeries: 2, maxChunks: 2},
+		},
+		"series limit exceeded": {
+			limits:      mockLimits{maxSeries: 1},
+			expectedErr: fmt.Sprintf(limiter.MaxSeriesHitMsgFormat, 1),
+		},
+		"chunks limit exceeded": {
+			limits:      mockLimits{maxChunks: 1},
+			expectedErr: fmt.Sprintf(limiter.MaxChunksPerQueryLimitMsgFormat, 1),
+		},
+		"chunk bytes limit exceeded": {
+			limits:      mockLimits{maxChunkBytes: 1},
+			expectedErr: fmt.Sprintf(limiter.MaxChunkBytesHitMsgFormat, 1),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// The queries exceeding a limit fail even with partial responses enabled.
+			queryable := NewQueryable(Config{
+				Enabled:        true,
+				LocalCluster:   "eu",
+				ClusterLabel:   defaultClusterLabel,
+				MaxConcurrent:  defaultConcurrency,
+				RemoteClusters: []RemoteClusterConfig{{Name: "us", Address: remote.url(), PartialResponse: true}},
+			}, local, tc.limits, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+
+			q, err := queryable.Querier(0, 100)
+			require.NoError(t, err)
+
+			ctx := user.InjectOrgID(context.Background(), "team-a")
+			set := q.Select(ctx, true, &storage.SelectHints{Start: 0, End: 100}, labels.MustNewMatcher(labels.MatchEqual, defaultClusterLabel, "us"))
+			actual := readSeriesSet(t, set)
+
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, set.Err(), tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, set.Err())
+			assert.Len(t, actual, 2)
+			assert.Empty(t, set.Warnings())
+		})
+	}
+}
+
+func TestRejectFederatedRequests(t *testing.T) {
+	handler := RejectFederatedRequests(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/prometheus/api/v1/read", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	req.Header.Set(FederatedRequestHeader, "true")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), errFederatedRequest.Error())
+}
+
+func TestQueryable_LabelNamesAndValues(t *testing.T) {
+	local := newMockQueryable(
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "up", "job", "local"), nil, nil),
+	)
+	remote := newMockRemoteCluster(t,
+		series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "up", "job", "remote", "region", "us-east"), nil, nil),
+	)
+
+	queryable := NewQueryable(Config{
+		Enabled:        true,
+		LocalCluster:   "eu",
+		ClusterLabel:   defaultClusterLabel,
+		MaxConcurrent:  defaultConcurrency,
+		RemoteClusters: []RemoteClusterConfig{{Name: "us", Address: remote.url()}},
+	}, local, mockLimits{}, prometheus.NewPedanticRegistry(), log.NewNopLogger())
+
+	ctx := user.InjectOrgID(context.Background(), "team-a")
+	q, err := queryable.Querier(0, 100)
+	require.NoError(t, err)
+
+	names, _, err := q.LabelNames(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{defaultClusterLabel, labels.MetricName, "job", "region"}, names)
+
+	values, _, err := q.LabelValues(ctx, "job")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"local", "remote"}, values)
+
+	values, _, err = q.LabelValues(ctx, defaultClusterLabel)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eu", "us"}, values)
+}
+
+func readSeriesSet(t *testing.T, set storage.SeriesSet) map[string][]model.SamplePair {
+	out := map[string][]model.SamplePair{}
+
+	var it chunkenc.Iterator
+	for set.Next() {
+		s := set.At()
+		it = s.Iterator(it)
+
+		var samples []model.SamplePair
+		for it.Next() == chunkenc.ValFloat {
+			ts, v := it.At()
+			samples = append(samples, model.SamplePair{Timestamp: model.Time(ts), Value: model.SampleValue(v)})
+		}
+		require.NoError(t, it.Err())
+		out[s.Labels().String()] = samples
+	}
+	return out
+}
+
+// mockRemoteCluster is a remote Mimir cluster serving the remote read, label names and label values endpoints.
+type mockRemoteCluster struct {
+	server     *httptest.Server
+	statusCode int
+	delay      time.Duration
+	requests   atomic.Int64
+	lastOrgID  atomic.String
+
+	lastFederatedHeader atomic.String
+}
+
+func newMockRemoteCluster(t *testing.T, series ...storage.Series) *mockRemoteCluster {
+	m := &mockRemoteCluster{}
+	queryable := newMockQueryable(series...)
+
+	mux := http.NewServeMux()
+	mux.Handle("/prometheus"+readEndpointPath, querier.RemoteReadHandler(querier.NewSampleAndChunkQueryable(queryable), log.NewNopLogger()))
+	mux.HandleFunc("/prometheus"+labelNamesEndpointPath, func(w http.ResponseWriter, r *http.Request) {
+		q, _ := queryable.Querier(0, 0)
+		names, _, _ := q.LabelNames(r.Context())
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": statusSuccess, "data": names})
+	})
+	mux.HandleFunc("/prometheus/api/v1/label/job/values", func(w http.ResponseWriter, r *http.Request) {
+		q, _ := queryable.Querier(0, 0)
+		values, _, _ := q.LabelValues(r.Context(), "job")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": statusSuccess, "data": values})
+	})
+
+	m.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.requests.Inc()
+		m.lastOrgID.Store(r.Header.Get(user.OrgIDHeaderName))
+		m.lastFederatedHeader.Store(r.Header.Get(FederatedRequestHeader))
+
+		if m.delay > 0 {
+			select {
+			case <-time.After(m.delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if m.statusCode != 0 {
+			http.Error(w, "failure", m.statusCode)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(m.server.Close)
+
+	return m
+}
+
+func (m *mockRemoteCluster) url() string {
+	return m.server.URL + "/prometheus"
+}
+
+type mockLimits struct {
+	maxSeries     int
+	maxChunkBytes int
+	maxChunks     int
+}
+
+func (m mockLimits) MaxFetchedSeriesPerQuery(string) int {
+	return m.maxSeries
+}
+
+func (m mockLimits) MaxFetchedChunkBytesPerQuery(string) int {
+	return m.maxChunkBytes
+}
+
+func (m mockLimits) MaxChunksPerQuery(string) int {
+	return m.maxChunks
+}
+
+// mockQueryable is a storage.Queryable over a fixed set of series.
+type mockQueryable struct {
+	series []storage.Series
+}
+
+func newMockQueryable(series ...storage.Series) *mockQueryable {
+	return &mockQueryable{series: series}
+}
+
+func (m *mockQueryable) Querier(_, _ int64) (storage.Querier, error) {
+	return &mockQuerier{series: m.series}, nil
+}
+
+type mockQuerier struct {
+	series []storage.Series
+}
+
+func (m *mockQuerier) Select(_ context.Context, _ bool, _ *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	var out []storage.Series
+	for _, s := range m.series {
+		if seriesMatches(s.Labels(), matchers) {
+			out = append(out, s)
+		}
+	}
+	return series.NewConcreteSeriesSetFromUnsortedSeries(out)
+}
+
+func (m *mockQuerier) LabelValues(_ context.Context, name string, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	var values []string
+	for _, s := range m.series {
+		if v := s.Labels().Get(name); v != "" && seriesMatches(s.Labels(), matchers) && !slices.Contains(values, v) {
+			values = append(values, v)
+		}
+	}
+	slices.Sort(values)
+	return values, nil, nil
+}
+
+func (m *mockQuerier) LabelNames(_ context.Context, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	var names []string
+	for _, s := range m.series {
+		if !seriesMatches(s.Labels(), matchers) {
+			continue
+		}
+		s.Labels().Range(func(l labels.Label) {
+			if !slices.Contains(names, l.Name) {
+				names = append(names, l.Name)
+			}
+		})
+	}
+	slices.Sort(names)
+	return names, nil, nil
+}
+
+func (m *mockQuerier) Close() error {
+	return nil
+}
+
+func seriesMatches(lbls labels.Labels, matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,337 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clusterfederation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/golang/snappy"
+	"github.com/grafana/dskit/user"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	prom_remote "github.com/prometheus/prometheus/storage/remote"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/util/annotations"
+	"golang.org/x/exp/slices"
+
+	"github.com/grafana/mimir/pkg/ingester/client"
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/series"
+	"github.com/grafana/mimir/pkg/util/limiter"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+const (
+	readEndpointPath        = "/api/v1/read"
+	labelNamesEndpointPath  = "/api/v1/labels"
+	labelValuesEndpointPath = "/api/v1/label/%s/values"
+
+	// Maximum number of bytes of an error response body returned in the error.
+	maxErrorBodyBytes = 1024
+
+	statusSuccess = "success"
+
+	// FederatedRequestHeader marks the requests sent to the remote clusters. The queriers with cluster federation
+	// enabled refuse them, so that the queries can't loop between clusters configured as each other's remote clusters.
+	FederatedRequestHeader = "X-Mimir-Cluster-Federated-Request"
+)
+
+type remoteClusterMetrics struct {
+	requestDuration *prometheus.HistogramVec
+}
+
+func newRemoteClusterMetrics(reg prometheus.Registerer) *remoteClusterMetrics {
+	return &remoteClusterMetrics{
+		requestDuration: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cortex_querier_cluster_federation_request_duration_seconds",
+			Help:    "Time spent doing requests to remote clusters.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"cluster", "operation", "status"}),
+	}
+}
+
+// remoteCluster queries a remote Mimir cluster via the Prometheus HTTP API exposed by its query-frontends.
+// Series are fetched via streamed remote read, while label names and values are fetched via the respective
+// Prometheus HTTP API endpoints, because remote read doesn't support them.
+type remoteCluster struct {
+	cfg     RemoteClusterConfig
+	client  *http.Client
+	metrics *remoteClusterMetrics
+	logger  log.Logger
+}
+
+func newRemoteCluster(cfg RemoteClusterConfig, metrics *remoteClusterMetrics, logger log.Logger) *remoteCluster {
+	return &remoteCluster{
+		cfg:     cfg,
+		client:  &http.Client{Transport: http.DefaultTransport},
+		metrics: metrics,
+		logger:  log.With(logger, "cluster", cfg.Name),
+	}
+}
+
+func (r *remoteCluster) selectSeries(ctx context.Context, mint, maxt int64, matchers []*labels.Matcher) storage.SeriesSet {
+	set, err := r.read(ctx, mint, maxt, matchers)
+	if err != nil {
+		// The queries exceeding a limit fail, even if partial responses are enabled for the remote cluster.
+		var limitErr validation.LimitError
+		if r.cfg.PartialResponse && !errors.Is(err, context.Canceled) && !errors.As(err, &limitErr) {
+			var warnings annotations.Annotations
+			warnings.Add(err)
+			return series.NewSeriesSetWithWarnings(storage.EmptySeriesSet(), warnings)
+		}
+		return storage.ErrSeriesSet(err)
+	}
+	return set
+}
+
+func (r *remoteCluster) read(ctx context.Context, mint, maxt int64, matchers []*labels.Matcher) (_ storage.SeriesSet, returnErr error) {
+	spanLog, ctx := spanlogger.NewWithLogger(ctx, r.logger, "remoteCluster.read")
+	defer spanLog.Finish()
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	defer r.observe("read", time.Now(), &returnErr)
+
+	query, err := client.ToQueryRequest(model.Time(mint), model.Time(maxt), matchers)
+	if err != nil {
+		return nil, err
+	}
+	data, err := (&client.ReadRequest{
+		Queries:               []*client.QueryRequest{query},
+		AcceptedResponseTypes: []client.ReadRequest_ResponseType{client.STREAMED_XOR_CHUNKS},
+	}).Marshal()
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal remote read request")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.Address+readEndpointPath, bytes.NewReader(snappy.Encode(nil, data)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Read-Version", "0.1.0")
+
+	resp, err := r.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/x-streamed-protobuf") {
+		return nil, fmt.Errorf("remote cluster %s returned unsupported remote read response content type %q", r.cfg.Name, contentType)
+	}
+
+	// The remote read handler streams the series sorted by labels, possibly splitting the chunks of a series in
+	// multiple consecutive frames. The series and chunks are accounted in the query limiter as they're received, so
+	// that the reading stops as soon as a limit is exceeded.
+	var (
+		queryLimiter = limiter.QueryLimiterFromContextWithFallback(ctx)
+		reader       = prom_remote.NewChunkedReader(resp.Body, prom_remote.DefaultChunkedReadLimit, nil)
+		result       []storage.ChunkSeries
+		last         *storage.ChunkSeriesEntry
+		metas        []chunks.Meta
+	)
+	flush := func() {
+		if last == nil {
+			return
+		}
+		chks := metas
+		last.ChunkIteratorFn = func(chunks.Iterator) chunks.Iterator {
+			return storage.NewListChunkSeriesIterator(chks...)
+		}
+		result = append(result, last)
+	}
+
+	for {
+		var frame client.StreamReadResponse
+		if err := reader.NextProto(&frame); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, errors.Wrapf(err, "read remote read response from remote cluster %s", r.cfg.Name)
+		}
+
+		for _, s := range frame.ChunkedSeries {
+			// Labels and chunks are unmarshalled pointing to the frame buffer, which is reused by the reader.
+			lbls := mimirpb.FromLabelAdaptersToLabels(s.Labels)
+			if last == nil || !labels.Equal(last.Lset, lbls) {
+				if err := queryLimiter.AddSeries(s.Labels); err != nil {
+					return nil, err
+				}
+
+				flush()
+				last = &storage.ChunkSeriesEntry{Lset: mimirpb.FromLabelAdaptersToLabelsWithCopy(s.Labels)}
+				metas = nil
+			}
+
+			chunksSize := 0
+			for _, c := range s.Chunks {
+				chunksSize += len(c.Data)
+			}
+			if err := queryLimiter.AddChunks(len(s.Chunks)); err != nil {
+				return nil, err
+			}
+			if err := queryLimiter.AddChunkBytes(chunksSize); err != nil {
+				return nil, err
+			}
+
+			for _, c := range s.Chunks {
+				chk, err := chunkenc.FromData(chunkenc.Encoding(c.Type), slices.Clone(c.Data))
+				if err != nil {
+					return nil, errors.Wrapf(err, "decode chunk from remote cluster %s", r.cfg.Name)
+				}
+				metas = append(metas, chunks.Meta{Chunk: chk, MinTime: c.MinTimeMs, MaxTime: c.MaxTimeMs})
+			}
+		}
+	}
+	flush()
+
+	level.Debug(spanLog).Log("msg", "received series from remote cluster", "series", len(result))
+	return storage.NewSeriesSetFromChunkSeriesSet(&chunkSeriesSet{series: result, idx: -1}), nil
+}
+
+func (r *remoteCluster) labelNames(ctx context.Context, mint, maxt int64, matchers []*labels.Matcher) ([]string, annotations.Annotations, error) {
+	return r.labelsRequest(ctx, "label_names", labelNamesEndpointPath, mint, maxt, matchers)
+}
+
+func (r *remoteCluster) labelValues(ctx context.Context, mint, maxt int64, name string, matchers []*labels.Matcher) ([]string, annotations.Annotations, error) {
+	return r.labelsRequest(ctx, "label_values", fmt.Sprintf(labelValuesEndpointPath, url.PathEscape(name)), mint, maxt, matchers)
+}
+
+// labelsRequest runs a label names or values request against the remote cluster, returning the errors as warnings
+// if partial responses are enabled for the remote cluster.
+func (r *remoteCluster) labelsRequest(ctx context.Context, op, path string, mint, maxt int64, matchers []*labels.Matcher) ([]string, annotations.Annotations, error) {
+	values, warnings, err := r.doLabelsRequest(ctx, op, path, mint, maxt, matchers)
+	if err != nil && r.cfg.PartialResponse && !errors.Is(err, context.Canceled) {
+		warnings.Add(err)
+		return nil, warnings, nil
+	}
+	return values, warnings, err
+}
+
+func (r *remoteCluster) doLabelsRequest(ctx context.Context, op, path string, mint, maxt int64, matchers []*labels.Matcher) (_ []string, _ annotations.Annotations, returnErr error) {
+	spanLog, ctx := spanlogger.NewWithLogger(ctx, r.logger, "remoteCluster.labelsRequest")
+	defer spanLog.Finish()
+
+	ctx, cancel := r.withTimeout(ctx)
+	defer cancel()
+	defer r.observe(op, time.Now(), &returnErr)
+
+	params := url.Values{}
+	params.Set("start", formatTime(mint))
+	params.Set("end", formatTime(maxt))
+	if len(matchers) > 0 {
+		params.Set("match[]", (&parser.VectorSelector{LabelMatchers: matchers}).String())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.cfg.Address+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := r.do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var body struct {
+		Status   string   `json:"status"`
+		Data     []string `json:"data"`
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, nil, errors.Wrapf(err, "decode response from remote cluster %s", r.cfg.Name)
+	}
+	if body.Status != statusSuccess {
+		return nil, nil, fmt.Errorf("remote cluster %s returned status %q", r.cfg.Name, body.Status)
+	}
+
+	var warnings annotations.Annotations
+	for _, w := range body.Warnings {
+		warnings.Add(errors.New(w))
+	}
+	return body.Data, warnings, nil
+}
+
+// do sends the request to the remote cluster, with the tenant ID of the request context and marked as federated, and
+// returns an error if the response status code is not 2xx.
+func (r *remoteCluster) do(req *http.Request) (*http.Response, error) {
+	if err := user.InjectOrgIDIntoHTTPRequest(req.Context(), req); err != nil {
+		return nil, err
+	}
+	req.Header.Set(FederatedRequestHeader, "true")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "request to remote cluster %s", r.cfg.Name)
+	}
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("remote cluster %s returned status code %d: %s", r.cfg.Name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp, nil
+}
+
+func (r *remoteCluster) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.cfg.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, r.cfg.Timeout)
+}
+
+func (r *remoteCluster) observe(op string, start time.Time, err *error) {
+	status := statusSuccess
+	if *err != nil {
+		status = "error"
+	}
+	r.metrics.requestDuration.WithLabelValues(r.cfg.Name, op, status).Observe(time.Since(start).Seconds())
+}
+
+func formatTime(ms int64) string {
+	return strconv.FormatFloat(float64(ms)/1000, 'f', -1, 64)
+}
+
+// chunkSeriesSet is a storage.ChunkSeriesSet over a slice of sorted series.
+type chunkSeriesSet struct {
+	series []storage.ChunkSeries
+	idx    int
+}
+
+func (s *chunkSeriesSet) Next() bool {
+	s.idx++
+	return s.idx < len(s.series)
+}
+
+func (s *chunkSeriesSet) At() storage.ChunkSeries {
+	return s.series[s.idx]
+}
+
+func (s *chunkSeriesSet) Err() error {
+	return nil
+}
+
+func (s *chunkSeriesSet) Warnings() annotations.Annotations {
+	return nil
+}
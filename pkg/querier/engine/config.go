@@ -57,6 +57,8 @@ func NewPromQLEngineOptions(cfg Config, activityTracker *activitytracker.Activit
 		LookbackDelta:        cfg.LookbackDelta,
 		EnableAtModifier:     true,
 		EnableNegativeOffset: true,
+		// The per-step stats are only computed for the queries requesting them.
+		EnablePerStepStats: true,
 		NoStepSubqueryIntervalFn: func(int64) int64 {
 			return cfg.DefaultEvaluationInterval.Milliseconds()
 		},
@@ -58,6 +58,8 @@ type Config struct {
 	StreamingChunksPerStoreGatewaySeriesBufferSize uint64        `yaml:"streaming_chunks_per_store_gateway_series_buffer_size" category:"experimental"`
 	MinimizeIngesterRequests                       bool          `yaml:"minimize_ingester_requests" category:"experimental"`
 	MinimiseIngesterRequestsHedgingDelay           time.Duration `yaml:"minimize_ingester_requests_hedging_delay" category:"experimental"`
+	AggregationPushdownEnabled                     bool          `yaml:"aggregation_pushdown_enabled" category:"experimental"`
+	StoreGatewayHedgingMinDelay                    time.Duration `yaml:"store_gateway_hedging_min_delay" category:"experimental"`
 
 	// PromQL engine config.
 	EngineConfig engine.Config `yaml:",inline"`
@@ -93,6 +95,10 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.MinimizeIngesterRequests, minimiseIngesterRequestsFlagName, false, "If true, when querying ingesters, only the minimum required ingesters required to reach quorum will be queried initially, with other ingesters queried only if needed due to failures from the initial set of ingesters. Enabling this option reduces resource consumption for the happy path at the cost of increased latency for the unhappy path.")
 	f.DurationVar(&cfg.MinimiseIngesterRequestsHedgingDelay, minimiseIngesterRequestsFlagName+"-hedging-delay", 3*time.Second, "Delay before initiating requests to further ingesters when request minimization is enabled and the initially selected set of ingesters have not all responded. Ignored if -"+minimiseIngesterRequestsFlagName+" is not enabled.")
 
+	f.DurationVar(&cfg.StoreGatewayHedgingMinDelay, "querier.store-gateway-hedging-min-delay", 100*time.Millisecond, "Minimum delay before sending a hedged series request to another store-gateway replica, when hedging is enabled for the tenant via -"+validation.StoreGatewayHedgingLatencyPercentileFlag+".")
+
+	f.BoolVar(&cfg.AggregationPushdownEnabled, "querier.aggregation-pushdown-enabled", false, "If true, range queries in the form '<sum|min|max|count> [by|without (...)] (<range function>(<metric>[<range>]))' that only query the long-term storage are evaluated by pushing down the aggregation to the store-gateways, which return partial aggregates instead of the raw chunks.")
+
 	// Why 256 series / ingester/store-gateway?
 	// Based on our testing, 256 series / ingester was a good balance between memory consumption and the CPU overhead of managing a batch of series.
 	f.Uint64Var(&cfg.StreamingChunksPerIngesterSeriesBufferSize, "querier.streaming-chunks-per-ingester-buffer-size", 256, "Number of series to buffer per ingester when streaming chunks from ingesters.")
@@ -349,8 +355,10 @@ func (mq multiQuerier) Select(ctx context.Context, _ bool, sp *storage.SelectHin
 		return storage.ErrSeriesSet(validation.NewMaxQueryLengthError(endTime.Sub(startTime), maxQueryLength))
 	}
 
+	retentionRules := mq.limits.CompactorRetentionRules(userID)
+
 	if len(queriers) == 1 {
-		return queriers[0].Select(ctx, true, sp, matchers...)
+		return newRetentionRulesSeriesSet(queriers[0].Select(ctx, true, sp, matchers...), retentionRules, now, startMs)
 	}
 
 	sets := make(chan storage.SeriesSet, len(queriers))
@@ -373,7 +381,7 @@ func (mq multiQuerier) Select(ctx context.Context, _ bool, sp *storage.SelectHin
 	// we have all the sets from different sources (chunk from store, chunks from ingesters,
 	// time series from store and time series from ingesters).
 	// mergeSeriesSets will return sorted set.
-	return mq.mergeSeriesSets(result)
+	return newRetentionRulesSeriesSet(mq.mergeSeriesSets(result), retentionRules, now, startMs)
 }
 
 // LabelValues implements storage.Querier.
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"time"
+
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+// retentionRulesApply returns whether any of the retention rules masks samples with timestamp minT or later.
+func retentionRulesApply(rules []*validation.RetentionRule, now time.Time, minT int64) bool {
+	for _, r := range rules {
+		if r.Period > 0 && now.Add(-time.Duration(r.Period)).UnixMilli() > minT {
+			return true
+		}
+	}
+	return false
+}
+
+// newRetentionRulesSeriesSet returns a storage.SeriesSet which doesn't return the samples of the series
+// older than the retention period of the first retention rule matching them. The set is returned as is
+// if no retention rule masks samples with timestamp minT or later.
+func newRetentionRulesSeriesSet(set storage.SeriesSet, rules []*validation.RetentionRule, now time.Time, minT int64) storage.SeriesSet {
+	if !retentionRulesApply(rules, now, minT) {
+		return set
+	}
+	return &retentionRulesSeriesSet{SeriesSet: set, rules: rules, now: now}
+}
+
+type retentionRulesSeriesSet struct {
+	storage.SeriesSet
+
+	rules []*validation.RetentionRule
+	now   time.Time
+}
+
+func (s *retentionRulesSeriesSet) At() storage.Series {
+	series := s.SeriesSet.At()
+
+	rule := validation.MatchingRetentionRule(s.rules, series.Labels())
+	if rule == nil || rule.Period == 0 {
+		return series
+	}
+	return &retentionRuleSeries{Series: series, minT: s.now.Add(-time.Duration(rule.Period)).UnixMilli()}
+}
+
+// retentionRuleSeries is a storage.Series which doesn't return the samples older than minT.
+type retentionRuleSeries struct {
+	storage.Series
+
+	minT int64
+}
+
+func (s *retentionRuleSeries) Iterator(it chunkenc.Iterator) chunkenc.Iterator {
+	if rit, ok := it.(*retentionRuleIterator); ok {
+		rit.Iterator = s.Series.Iterator(rit.Iterator)
+		rit.minT = s.minT
+		rit.started = false
+		return rit
+	}
+	return &retentionRuleIterator{Iterator: s.Series.Iterator(it), minT: s.minT}
+}
+
+// retentionRuleIterator is a chunkenc.Iterator which doesn't return the samples older than minT.
+type retentionRuleIterator struct {
+	chunkenc.Iterator
+
+	minT    int64
+	started bool
+}
+
+func (it *retentionRuleIterator) Next() chunkenc.ValueType {
+	if !it.started {
+		it.started = true
+		return it.Iterator.Seek(it.minT)
+	}
+	return it.Iterator.Next()
+}
+
+func (it *retentionRuleIterator) Seek(t int64) chunkenc.ValueType {
+	it.started = true
+	return it.Iterator.Seek(max(t, it.minT))
+}
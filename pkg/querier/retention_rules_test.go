@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package querier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/series"
+	"github.com/grafana/mimir/pkg/util/validation"
+)
+
+func TestRetentionRulesSeriesSet(t *testing.T) {
+	now := time.UnixMilli(10 * time.Hour.Milliseconds())
+
+	rules := []*validation.RetentionRule{
+		mustNewRetentionRule(t, `{__name__="debug"}`, 2*time.Hour),
+		mustNewRetentionRule(t, `{__name__="slo"}`, 0),
+		mustNewRetentionRule(t, `{__name__=~"debug|other"}`, time.Hour),
+	}
+
+	// One sample per hour.
+	samples := func() []model.SamplePair {
+		var s []model.SamplePair
+		for ts := int64(0); ts <= 10; ts++ {
+			s = append(s, model.SamplePair{Timestamp: model.Time(ts * time.Hour.Milliseconds()), Value: model.SampleValue(ts)})
+		}
+		return s
+	}
+	newSet := func() storage.SeriesSet {
+		return series.NewConcreteSeriesSetFromUnsortedSeries([]storage.Series{
+			series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "debug"), samples(), nil),
+			series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "slo"), samples(), nil),
+			series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "other"), samples(), nil),
+			series.NewConcreteSeries(labels.FromStrings(labels.MetricName, "unmatched"), samples(), nil),
+		})
+	}
+
+	t.Run("should mask the samples past the first matching rule", func(t *testing.T) {
+		set := newRetentionRulesSeriesSet(newSet(), rules, now, 0)
+
+		actual := map[string][]int64{}
+		for set.Next() {
+			s := set.At()
+			for it := s.Iterator(nil); it.Next() != chunkenc.ValNone; {
+				ts, _ := it.At()
+				actual[s.Labels().Get(labels.MetricName)] = append(actual[s.Labels().Get(labels.MetricName)], ts/time.Hour.Milliseconds())
+			}
+		}
+		require.NoError(t, set.Err())
+
+		assert.Equal(t, map[string][]int64{
+			"debug":     {8, 9, 10},
+			"other":     {9, 10},
+			"slo":       {0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			"unmatched": {0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+		}, actual)
+	})
+
+	t.Run("should not seek before the rule period", func(t *testing.T) {
+		set := newRetentionRulesSeriesSet(newSet(), rules, now, 0)
+
+		require.True(t, set.Next())
+		require.Equal(t, "debug", set.At().Labels().Get(labels.MetricName))
+
+		it := set.At().Iterator(nil)
+		require.Equal(t, chunkenc.ValFloat, it.Seek(time.Hour.Milliseconds()))
+		ts, _ := it.At()
+		assert.Equal(t, 8*time.Hour.Milliseconds(), ts)
+	})
+
+	t.Run("should return the set as is if no rule masks samples in the queried time range", func(t *testing.T) {
+		set := newSet()
+		assert.Same(t, set, newRetentionRulesSeriesSet(set, rules, now, 9*time.Hour.Milliseconds()))
+		assert.Same(t, set, newRetentionRulesSeriesSet(set, nil, now, 0))
+	})
+}
+
+func mustNewRetentionRule(t *testing.T, selector string, period time.Duration) *validation.RetentionRule {
+	r, err := validation.NewRetentionRule(selector, period)
+	require.NoError(t, err)
+	return r
+}
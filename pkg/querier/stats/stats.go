@@ -7,10 +7,12 @@ package stats
 
 import (
 	"context"
+	"sync"
 	"sync/atomic" //lint:ignore faillint we can't use go.uber.org/atomic with a protobuf struct without wrapping it.
 	"time"
 
 	"github.com/grafana/dskit/httpgrpc"
+	"golang.org/x/exp/slices"
 )
 
 type contextKey int
@@ -171,6 +173,134 @@ func (s *Stats) LoadEstimatedSeriesCount() uint64 {
 	return atomic.LoadUint64(&s.EstimatedSeriesCount)
 }
 
+func (s *Stats) AddSamplesProcessed(c uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.SamplesProcessed, c)
+}
+
+func (s *Stats) LoadSamplesProcessed() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.SamplesProcessed)
+}
+
+// AddQueueTime adds some time to the queue time counter.
+func (s *Stats) AddQueueTime(t time.Duration) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddInt64((*int64)(&s.QueueTime), int64(t))
+}
+
+// LoadQueueTime returns current queue time.
+func (s *Stats) LoadQueueTime() time.Duration {
+	if s == nil {
+		return 0
+	}
+
+	return time.Duration(atomic.LoadInt64((*int64)(&s.QueueTime)))
+}
+
+func (s *Stats) AddResultsCacheLookups(num uint32) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint32(&s.ResultsCacheLookups, num)
+}
+
+func (s *Stats) LoadResultsCacheLookups() uint32 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint32(&s.ResultsCacheLookups)
+}
+
+func (s *Stats) AddResultsCacheHits(num uint32) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint32(&s.ResultsCacheHits, num)
+}
+
+func (s *Stats) LoadResultsCacheHits() uint32 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint32(&s.ResultsCacheHits)
+}
+
+// LoadResultsCacheHitRatio returns the ratio of split queries served from the results cache
+// over the ones looked up in the results cache. Returns 0 if no lookup has been done.
+func (s *Stats) LoadResultsCacheHitRatio() float64 {
+	lookups := s.LoadResultsCacheLookups()
+	if lookups == 0 {
+		return 0
+	}
+
+	return float64(s.LoadResultsCacheHits()) / float64(lookups)
+}
+
+// samplesProcessedPerStepMx protects the samples processed per step of all Stats, which can't be updated atomically.
+// They're only tracked for the queries explicitly requesting them, so a single lock is enough.
+var samplesProcessedPerStepMx sync.Mutex
+
+// AddSamplesProcessedPerStep adds the number of samples processed at each step. The samples of the steps with the same
+// timestamp, like the ones of the sharded queries, are summed up.
+func (s *Stats) AddSamplesProcessedPerStep(steps []StepStat) {
+	if s == nil || len(steps) == 0 {
+		return
+	}
+
+	samplesProcessedPerStepMx.Lock()
+	defer samplesProcessedPerStepMx.Unlock()
+
+	merged := append(slices.Clone(s.SamplesProcessedPerStep), steps...)
+	slices.SortFunc(merged, compareStepStats)
+
+	// Sum up the samples of the steps with the same timestamp.
+	s.SamplesProcessedPerStep = merged[:0]
+	for _, step := range merged {
+		if n := len(s.SamplesProcessedPerStep); n > 0 && s.SamplesProcessedPerStep[n-1].TimestampMs == step.TimestampMs {
+			s.SamplesProcessedPerStep[n-1].Value += step.Value
+			continue
+		}
+		s.SamplesProcessedPerStep = append(s.SamplesProcessedPerStep, step)
+	}
+}
+
+// LoadSamplesProcessedPerStep returns the number of samples processed at each step, sorted by timestamp.
+func (s *Stats) LoadSamplesProcessedPerStep() []StepStat {
+	if s == nil {
+		return nil
+	}
+
+	samplesProcessedPerStepMx.Lock()
+	defer samplesProcessedPerStepMx.Unlock()
+
+	return slices.Clone(s.SamplesProcessedPerStep)
+}
+
+func compareStepStats(a, b StepStat) int {
+	switch {
+	case a.TimestampMs < b.TimestampMs:
+		return -1
+	case a.TimestampMs > b.TimestampMs:
+		return 1
+	default:
+		return 0
+	}
+}
+
 // Merge the provided Stats into this one.
 func (s *Stats) Merge(other *Stats) {
 	if s == nil || other == nil {
@@ -185,6 +315,11 @@ func (s *Stats) Merge(other *Stats) {
 	s.AddSplitQueries(other.LoadSplitQueries())
 	s.AddFetchedIndexBytes(other.LoadFetchedIndexBytes())
 	s.AddEstimatedSeriesCount(other.LoadEstimatedSeriesCount())
+	s.AddSamplesProcessed(other.LoadSamplesProcessed())
+	s.AddQueueTime(other.LoadQueueTime())
+	s.AddResultsCacheLookups(other.LoadResultsCacheLookups())
+	s.AddResultsCacheHits(other.LoadResultsCacheHits())
+	s.AddSamplesProcessedPerStep(other.LoadSamplesProcessedPerStep())
 }
 
 func ShouldTrackHTTPGRPCResponse(r *httpgrpc.HTTPResponse) bool {
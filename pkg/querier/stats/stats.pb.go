@@ -46,6 +46,17 @@ type Stats struct {
 	FetchedIndexBytes uint64 `protobuf:"varint,7,opt,name=fetched_index_bytes,json=fetchedIndexBytes,proto3" json:"fetched_index_bytes,omitempty"`
 	// The estimated number of series to be fetched for the query
 	EstimatedSeriesCount uint64 `protobuf:"varint,8,opt,name=estimated_series_count,json=estimatedSeriesCount,proto3" json:"estimated_series_count,omitempty"`
+	// The number of samples processed by the PromQL engine to execute the query
+	SamplesProcessed uint64 `protobuf:"varint,9,opt,name=samples_processed,json=samplesProcessed,proto3" json:"samples_processed,omitempty"`
+	// The sum of all time spent by the query requests in the queue before being picked up by a querier.
+	QueueTime time.Duration `protobuf:"bytes,10,opt,name=queue_time,json=queueTime,proto3,stdduration" json:"queue_time"`
+	// The number of split queries looked up in the results cache.
+	ResultsCacheLookups uint32 `protobuf:"varint,11,opt,name=results_cache_lookups,json=resultsCacheLookups,proto3" json:"results_cache_lookups,omitempty"`
+	// The number of split queries fully or partially served from the results cache.
+	ResultsCacheHits uint32 `protobuf:"varint,12,opt,name=results_cache_hits,json=resultsCacheHits,proto3" json:"results_cache_hits,omitempty"`
+	// The number of samples processed by the PromQL engine at each step, only tracked when per-step stats
+	// have been requested for the query.
+	SamplesProcessedPerStep []StepStat `protobuf:"bytes,13,rep,name=samples_processed_per_step,json=samplesProcessedPerStep,proto3" json:"samples_processed_per_step"`
 }
 
 func (m *Stats) Reset()      { *m = Stats{} }
@@ -136,37 +147,136 @@ func (m *Stats) GetEstimatedSeriesCount() uint64 {
 	return 0
 }
 
+func (m *Stats) GetSamplesProcessed() uint64 {
+	if m != nil {
+		return m.SamplesProcessed
+	}
+	return 0
+}
+
+func (m *Stats) GetQueueTime() time.Duration {
+	if m != nil {
+		return m.QueueTime
+	}
+	return 0
+}
+
+func (m *Stats) GetResultsCacheLookups() uint32 {
+	if m != nil {
+		return m.ResultsCacheLookups
+	}
+	return 0
+}
+
+func (m *Stats) GetResultsCacheHits() uint32 {
+	if m != nil {
+		return m.ResultsCacheHits
+	}
+	return 0
+}
+
+func (m *Stats) GetSamplesProcessedPerStep() []StepStat {
+	if m != nil {
+		return m.SamplesProcessedPerStep
+	}
+	return nil
+}
+
+type StepStat struct {
+	// The timestamp of the step, in milliseconds.
+	TimestampMs int64 `protobuf:"varint,1,opt,name=timestamp_ms,json=timestampMs,proto3" json:"timestamp_ms,omitempty"`
+	Value       int64 `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *StepStat) Reset()      { *m = StepStat{} }
+func (*StepStat) ProtoMessage() {}
+func (*StepStat) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b4756a0aec8b9d44, []int{1}
+}
+func (m *StepStat) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *StepStat) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_StepStat.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *StepStat) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StepStat.Merge(m, src)
+}
+func (m *StepStat) XXX_Size() int {
+	return m.Size()
+}
+func (m *StepStat) XXX_DiscardUnknown() {
+	xxx_messageInfo_StepStat.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StepStat proto.InternalMessageInfo
+
+func (m *StepStat) GetTimestampMs() int64 {
+	if m != nil {
+		return m.TimestampMs
+	}
+	return 0
+}
+
+func (m *StepStat) GetValue() int64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Stats)(nil), "stats.Stats")
+	proto.RegisterType((*StepStat)(nil), "stats.StepStat")
 }
 
 func init() { proto.RegisterFile("stats.proto", fileDescriptor_b4756a0aec8b9d44) }
 
 var fileDescriptor_b4756a0aec8b9d44 = []byte{
-	// 362 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x92, 0x3f, 0x4f, 0xc2, 0x40,
-	0x18, 0xc6, 0x7b, 0xf2, 0x47, 0x3c, 0x44, 0x63, 0x25, 0xa6, 0x32, 0xbc, 0x10, 0x1d, 0x64, 0x2a,
-	0x46, 0xdd, 0x5c, 0x0c, 0xb8, 0x38, 0x0a, 0x4e, 0x2e, 0x4d, 0xa1, 0x47, 0x69, 0x2c, 0x3d, 0xec,
-	0x5d, 0xa3, 0x6e, 0x7e, 0x04, 0xdd, 0xfc, 0x08, 0x7e, 0x14, 0x46, 0x46, 0x26, 0x95, 0x63, 0x71,
-	0xe4, 0x23, 0x98, 0xbb, 0xb6, 0x04, 0xdc, 0x7a, 0xef, 0xef, 0xfd, 0xe5, 0x79, 0x72, 0x3d, 0x5c,
-	0x64, 0xdc, 0xe6, 0xcc, 0x1c, 0x85, 0x94, 0x53, 0x3d, 0xa7, 0x0e, 0x95, 0xb2, 0x4b, 0x5d, 0xaa,
-	0x26, 0x0d, 0xf9, 0x15, 0xc3, 0x0a, 0xb8, 0x94, 0xba, 0x3e, 0x69, 0xa8, 0x53, 0x37, 0xea, 0x37,
-	0x9c, 0x28, 0xb4, 0xb9, 0x47, 0x83, 0x98, 0x1f, 0xbd, 0x67, 0x70, 0xae, 0x23, 0x7d, 0xfd, 0x0a,
-	0x6f, 0x3d, 0xd9, 0xbe, 0x6f, 0x71, 0x6f, 0x48, 0x0c, 0x54, 0x43, 0xf5, 0xe2, 0xd9, 0xa1, 0x19,
-	0xdb, 0x66, 0x6a, 0x9b, 0xd7, 0x89, 0xdd, 0x2c, 0x8c, 0xbf, 0xaa, 0xda, 0xc7, 0x77, 0x15, 0xb5,
-	0x0b, 0xd2, 0xba, 0xf3, 0x86, 0x44, 0x3f, 0xc5, 0xe5, 0x3e, 0xe1, 0xbd, 0x01, 0x71, 0x2c, 0x46,
-	0x42, 0x8f, 0x30, 0xab, 0x47, 0xa3, 0x80, 0x1b, 0x1b, 0x35, 0x54, 0xcf, 0xb6, 0xf5, 0x84, 0x75,
-	0x14, 0x6a, 0x49, 0xa2, 0x9b, 0x78, 0x3f, 0x35, 0x7a, 0x83, 0x28, 0x78, 0xb0, 0xba, 0x2f, 0x9c,
-	0x30, 0x23, 0xa3, 0x84, 0xbd, 0x04, 0xb5, 0x24, 0x69, 0x4a, 0xb0, 0x9a, 0xa0, 0xf6, 0xd3, 0x84,
-	0xec, 0x5a, 0x82, 0x12, 0x92, 0x84, 0x13, 0xbc, 0xcb, 0x06, 0x76, 0xe8, 0x10, 0xc7, 0x7a, 0x8c,
-	0x54, 0xb2, 0x91, 0xab, 0xa1, 0x7a, 0xa9, 0xbd, 0x93, 0x8c, 0x6f, 0xe3, 0xa9, 0x7e, 0x8c, 0x4b,
-	0x6c, 0xe4, 0x7b, 0x7c, 0xb9, 0x96, 0x57, 0x6b, 0xdb, 0x6a, 0x98, 0x2e, 0xad, 0xf4, 0xf5, 0x02,
-	0x87, 0x3c, 0x27, 0x7d, 0x37, 0xd7, 0xfa, 0xde, 0x48, 0x12, 0xf7, 0xbd, 0xc0, 0x07, 0x84, 0x71,
-	0x6f, 0x68, 0xf3, 0xff, 0x77, 0x52, 0x50, 0x4a, 0x79, 0x49, 0x57, 0x6e, 0xa5, 0x79, 0x39, 0x99,
-	0x81, 0x36, 0x9d, 0x81, 0xb6, 0x98, 0x01, 0x7a, 0x15, 0x80, 0x3e, 0x05, 0xa0, 0xb1, 0x00, 0x34,
-	0x11, 0x80, 0x7e, 0x04, 0xa0, 0x5f, 0x01, 0xda, 0x42, 0x00, 0x7a, 0x9b, 0x83, 0x36, 0x99, 0x83,
-	0x36, 0x9d, 0x83, 0x76, 0x1f, 0x3f, 0x83, 0x6e, 0x5e, 0xfd, 0xab, 0xf3, 0xbf, 0x00, 0x00, 0x00,
-	0xff, 0xff, 0xba, 0xe5, 0x86, 0x2e, 0x23, 0x02, 0x00, 0x00,
+	// 531 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x93, 0xbd, 0x72, 0xd3, 0x40,
+	0x10, 0x80, 0x75, 0xd8, 0x0e, 0xf6, 0xd9, 0x26, 0xc9, 0xc5, 0x80, 0x70, 0x71, 0x31, 0xa1, 0xc0,
+	0x33, 0x30, 0x32, 0x63, 0xe8, 0x68, 0x18, 0x9b, 0x02, 0x66, 0x60, 0x26, 0xc8, 0x54, 0x34, 0x37,
+	0xb2, 0xb4, 0xb1, 0x34, 0x91, 0x7c, 0x8a, 0xee, 0xc4, 0x4f, 0xc7, 0x23, 0x50, 0xf2, 0x08, 0x3c,
+	0x02, 0x8f, 0x90, 0xd2, 0x65, 0x2a, 0xc0, 0x72, 0x43, 0x99, 0x47, 0x60, 0xee, 0x24, 0x79, 0xec,
+	0xd0, 0xa4, 0xd3, 0xed, 0xb7, 0xdf, 0xec, 0xde, 0xde, 0x0a, 0x37, 0x85, 0x74, 0xa4, 0xb0, 0xe2,
+	0x84, 0x4b, 0x4e, 0x6a, 0xfa, 0xd0, 0xed, 0xcc, 0xf8, 0x8c, 0xeb, 0xc8, 0x40, 0x7d, 0xe5, 0xb0,
+	0x4b, 0x67, 0x9c, 0xcf, 0x42, 0x18, 0xe8, 0xd3, 0x34, 0x3d, 0x19, 0x78, 0x69, 0xe2, 0xc8, 0x80,
+	0xcf, 0x73, 0x7e, 0xf4, 0xb3, 0x86, 0x6b, 0x13, 0xe5, 0x93, 0x17, 0xb8, 0xf1, 0xc9, 0x09, 0x43,
+	0x26, 0x83, 0x08, 0x4c, 0xd4, 0x43, 0xfd, 0xe6, 0xf0, 0x9e, 0x95, 0xdb, 0x56, 0x69, 0x5b, 0x2f,
+	0x0b, 0x7b, 0x54, 0x3f, 0xff, 0x75, 0x68, 0x7c, 0xff, 0x7d, 0x88, 0xec, 0xba, 0xb2, 0xde, 0x07,
+	0x11, 0x90, 0x27, 0xb8, 0x73, 0x02, 0xd2, 0xf5, 0xc1, 0x63, 0x02, 0x92, 0x00, 0x04, 0x73, 0x79,
+	0x3a, 0x97, 0xe6, 0x8d, 0x1e, 0xea, 0x57, 0x6d, 0x52, 0xb0, 0x89, 0x46, 0x63, 0x45, 0x88, 0x85,
+	0x0f, 0x4a, 0xc3, 0xf5, 0xd3, 0xf9, 0x29, 0x9b, 0x7e, 0x91, 0x20, 0xcc, 0x8a, 0x16, 0xf6, 0x0b,
+	0x34, 0x56, 0x64, 0xa4, 0xc0, 0x66, 0x05, 0x9d, 0x5f, 0x56, 0xa8, 0x6e, 0x55, 0xd0, 0x42, 0x51,
+	0xe1, 0x21, 0xde, 0x15, 0xbe, 0x93, 0x78, 0xe0, 0xb1, 0xb3, 0x54, 0x57, 0x36, 0x6b, 0x3d, 0xd4,
+	0x6f, 0xdb, 0xb7, 0x8a, 0xf0, 0xbb, 0x3c, 0x4a, 0x1e, 0xe0, 0xb6, 0x88, 0xc3, 0x40, 0xae, 0xd3,
+	0x76, 0x74, 0x5a, 0x4b, 0x07, 0xcb, 0xa4, 0x8d, 0x7e, 0x83, 0xb9, 0x07, 0x9f, 0x8b, 0x7e, 0x6f,
+	0x6e, 0xf5, 0xfb, 0x5a, 0x91, 0xbc, 0xdf, 0x67, 0xf8, 0x0e, 0x08, 0x19, 0x44, 0x8e, 0xbc, 0x3a,
+	0x93, 0xba, 0x56, 0x3a, 0x6b, 0xba, 0x39, 0x95, 0x47, 0x78, 0x5f, 0x38, 0x51, 0x1c, 0x82, 0x60,
+	0x71, 0xc2, 0x5d, 0x10, 0x02, 0x3c, 0xb3, 0xa1, 0x85, 0xbd, 0x02, 0x1c, 0x97, 0x71, 0x32, 0xc2,
+	0xf8, 0x2c, 0x85, 0x14, 0xf2, 0x77, 0xc3, 0xd7, 0x7f, 0xb7, 0x86, 0xd6, 0xf4, 0xc3, 0x0d, 0xf1,
+	0xed, 0x04, 0x44, 0x1a, 0x4a, 0xc1, 0x5c, 0xc7, 0xf5, 0x81, 0x85, 0x9c, 0x9f, 0xa6, 0xb1, 0x30,
+	0x9b, 0x7a, 0x06, 0x07, 0x05, 0x1c, 0x2b, 0xf6, 0x26, 0x47, 0xe4, 0x31, 0x26, 0xdb, 0x8e, 0x1f,
+	0x48, 0x61, 0xb6, 0xb4, 0xb0, 0xb7, 0x29, 0xbc, 0x0a, 0xa4, 0x20, 0x36, 0xee, 0xfe, 0x77, 0x25,
+	0x16, 0x43, 0xc2, 0x84, 0x84, 0xd8, 0x6c, 0xf7, 0x2a, 0xfd, 0xe6, 0x70, 0xd7, 0xca, 0xb7, 0x7a,
+	0x22, 0x21, 0x56, 0x2b, 0x39, 0xaa, 0xaa, 0x5e, 0xed, 0xbb, 0x57, 0xaf, 0x7c, 0x0c, 0x89, 0x4a,
+	0x39, 0x1a, 0xe3, 0x7a, 0x99, 0x4a, 0xee, 0xe3, 0x96, 0xba, 0xbf, 0x90, 0x4e, 0x14, 0xb3, 0x48,
+	0xe8, 0xfd, 0xad, 0xd8, 0xcd, 0x75, 0xec, 0xad, 0x20, 0x1d, 0x5c, 0xfb, 0xe8, 0x84, 0x29, 0xe8,
+	0x75, 0xac, 0xd8, 0xf9, 0x61, 0xf4, 0x7c, 0xb1, 0xa4, 0xc6, 0xc5, 0x92, 0x1a, 0x97, 0x4b, 0x8a,
+	0xbe, 0x66, 0x14, 0xfd, 0xc8, 0x28, 0x3a, 0xcf, 0x28, 0x5a, 0x64, 0x14, 0xfd, 0xc9, 0x28, 0xfa,
+	0x9b, 0x51, 0xe3, 0x32, 0xa3, 0xe8, 0xdb, 0x8a, 0x1a, 0x8b, 0x15, 0x35, 0x2e, 0x56, 0xd4, 0xf8,
+	0x90, 0xff, 0x72, 0xd3, 0x1d, 0x3d, 0xdf, 0xa7, 0xff, 0x06, 0x00, 0xaa, 0x0f, 0x7c, 0x27, 0x8f,
+	0x03, 0x00, 0x00,
 }
 
 func (this *Stats) Equal(that interface{}) bool {
@@ -212,13 +322,60 @@ func (this *Stats) Equal(that interface{}) bool {
 	if this.EstimatedSeriesCount != that1.EstimatedSeriesCount {
 		return false
 	}
+	if this.SamplesProcessed != that1.SamplesProcessed {
+		return false
+	}
+	if this.QueueTime != that1.QueueTime {
+		return false
+	}
+	if this.ResultsCacheLookups != that1.ResultsCacheLookups {
+		return false
+	}
+	if this.ResultsCacheHits != that1.ResultsCacheHits {
+		return false
+	}
+	if len(this.SamplesProcessedPerStep) != len(that1.SamplesProcessedPerStep) {
+		return false
+	}
+	for i := range this.SamplesProcessedPerStep {
+		if !this.SamplesProcessedPerStep[i].Equal(&that1.SamplesProcessedPerStep[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *StepStat) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*StepStat)
+	if !ok {
+		that2, ok := that.(StepStat)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.TimestampMs != that1.TimestampMs {
+		return false
+	}
+	if this.Value != that1.Value {
+		return false
+	}
 	return true
 }
 func (this *Stats) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 12)
+	s := make([]string, 0, 17)
 	s = append(s, "&stats.Stats{")
 	s = append(s, "WallTime: "+fmt.Sprintf("%#v", this.WallTime)+",\n")
 	s = append(s, "FetchedSeriesCount: "+fmt.Sprintf("%#v", this.FetchedSeriesCount)+",\n")
@@ -228,6 +385,28 @@ func (this *Stats) GoString() string {
 	s = append(s, "SplitQueries: "+fmt.Sprintf("%#v", this.SplitQueries)+",\n")
 	s = append(s, "FetchedIndexBytes: "+fmt.Sprintf("%#v", this.FetchedIndexBytes)+",\n")
 	s = append(s, "EstimatedSeriesCount: "+fmt.Sprintf("%#v", this.EstimatedSeriesCount)+",\n")
+	s = append(s, "SamplesProcessed: "+fmt.Sprintf("%#v", this.SamplesProcessed)+",\n")
+	s = append(s, "QueueTime: "+fmt.Sprintf("%#v", this.QueueTime)+",\n")
+	s = append(s, "ResultsCacheLookups: "+fmt.Sprintf("%#v", this.ResultsCacheLookups)+",\n")
+	s = append(s, "ResultsCacheHits: "+fmt.Sprintf("%#v", this.ResultsCacheHits)+",\n")
+	if this.SamplesProcessedPerStep != nil {
+		vs := make([]StepStat, len(this.SamplesProcessedPerStep))
+		for i := range vs {
+			vs[i] = this.SamplesProcessedPerStep[i]
+		}
+		s = append(s, "SamplesProcessedPerStep: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *StepStat) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&stats.StepStat{")
+	s = append(s, "TimestampMs: "+fmt.Sprintf("%#v", this.TimestampMs)+",\n")
+	s = append(s, "Value: "+fmt.Sprintf("%#v", this.Value)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -259,6 +438,43 @@ func (m *Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.SamplesProcessedPerStep) > 0 {
+		for iNdEx := len(m.SamplesProcessedPerStep) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.SamplesProcessedPerStep[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintStats(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x6a
+		}
+	}
+	if m.ResultsCacheHits != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.ResultsCacheHits))
+		i--
+		dAtA[i] = 0x60
+	}
+	if m.ResultsCacheLookups != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.ResultsCacheLookups))
+		i--
+		dAtA[i] = 0x58
+	}
+	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.QueueTime, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.QueueTime):])
+	if err1 != nil {
+		return 0, err1
+	}
+	i -= n1
+	i = encodeVarintStats(dAtA, i, uint64(n1))
+	i--
+	dAtA[i] = 0x52
+	if m.SamplesProcessed != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.SamplesProcessed))
+		i--
+		dAtA[i] = 0x48
+	}
 	if m.EstimatedSeriesCount != 0 {
 		i = encodeVarintStats(dAtA, i, uint64(m.EstimatedSeriesCount))
 		i--
@@ -294,17 +510,50 @@ func (m *Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0x10
 	}
-	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.WallTime, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.WallTime):])
-	if err1 != nil {
-		return 0, err1
+	n2, err2 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.WallTime, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.WallTime):])
+	if err2 != nil {
+		return 0, err2
 	}
-	i -= n1
-	i = encodeVarintStats(dAtA, i, uint64(n1))
+	i -= n2
+	i = encodeVarintStats(dAtA, i, uint64(n2))
 	i--
 	dAtA[i] = 0xa
 	return len(dAtA) - i, nil
 }
 
+func (m *StepStat) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *StepStat) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *StepStat) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Value != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.Value))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.TimestampMs != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.TimestampMs))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintStats(dAtA []byte, offset int, v uint64) int {
 	offset -= sovStats(v)
 	base := offset
@@ -345,6 +594,38 @@ func (m *Stats) Size() (n int) {
 	if m.EstimatedSeriesCount != 0 {
 		n += 1 + sovStats(uint64(m.EstimatedSeriesCount))
 	}
+	if m.SamplesProcessed != 0 {
+		n += 1 + sovStats(uint64(m.SamplesProcessed))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.QueueTime)
+	n += 1 + l + sovStats(uint64(l))
+	if m.ResultsCacheLookups != 0 {
+		n += 1 + sovStats(uint64(m.ResultsCacheLookups))
+	}
+	if m.ResultsCacheHits != 0 {
+		n += 1 + sovStats(uint64(m.ResultsCacheHits))
+	}
+	if len(m.SamplesProcessedPerStep) > 0 {
+		for _, e := range m.SamplesProcessedPerStep {
+			l = e.Size()
+			n += 1 + l + sovStats(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *StepStat) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.TimestampMs != 0 {
+		n += 1 + sovStats(uint64(m.TimestampMs))
+	}
+	if m.Value != 0 {
+		n += 1 + sovStats(uint64(m.Value))
+	}
 	return n
 }
 
@@ -358,6 +639,11 @@ func (this *Stats) String() string {
 	if this == nil {
 		return "nil"
 	}
+	repeatedStringForSamplesProcessedPerStep := "[]StepStat{"
+	for _, f := range this.SamplesProcessedPerStep {
+		repeatedStringForSamplesProcessedPerStep += strings.Replace(strings.Replace(f.String(), "StepStat", "StepStat", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForSamplesProcessedPerStep += "}"
 	s := strings.Join([]string{`&Stats{`,
 		`WallTime:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.WallTime), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
 		`FetchedSeriesCount:` + fmt.Sprintf("%v", this.FetchedSeriesCount) + `,`,
@@ -367,6 +653,22 @@ func (this *Stats) String() string {
 		`SplitQueries:` + fmt.Sprintf("%v", this.SplitQueries) + `,`,
 		`FetchedIndexBytes:` + fmt.Sprintf("%v", this.FetchedIndexBytes) + `,`,
 		`EstimatedSeriesCount:` + fmt.Sprintf("%v", this.EstimatedSeriesCount) + `,`,
+		`SamplesProcessed:` + fmt.Sprintf("%v", this.SamplesProcessed) + `,`,
+		`QueueTime:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.QueueTime), "Duration", "durationpb.Duration", 1), `&`, ``, 1) + `,`,
+		`ResultsCacheLookups:` + fmt.Sprintf("%v", this.ResultsCacheLookups) + `,`,
+		`ResultsCacheHits:` + fmt.Sprintf("%v", this.ResultsCacheHits) + `,`,
+		`SamplesProcessedPerStep:` + repeatedStringForSamplesProcessedPerStep + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *StepStat) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&StepStat{`,
+		`TimestampMs:` + fmt.Sprintf("%v", this.TimestampMs) + `,`,
+		`Value:` + fmt.Sprintf("%v", this.Value) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -574,6 +876,221 @@ func (m *Stats) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SamplesProcessed", wireType)
+			}
+			m.SamplesProcessed = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SamplesProcessed |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueueTime", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.QueueTime, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ResultsCacheLookups", wireType)
+			}
+			m.ResultsCacheLookups = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ResultsCacheLookups |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ResultsCacheHits", wireType)
+			}
+			m.ResultsCacheHits = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ResultsCacheHits |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SamplesProcessedPerStep", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStats
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStats
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SamplesProcessedPerStep = append(m.SamplesProcessedPerStep, StepStat{})
+			if err := m.SamplesProcessedPerStep[len(m.SamplesProcessedPerStep)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStats(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStats
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *StepStat) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStats
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: StepStat: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: StepStat: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TimestampMs", wireType)
+			}
+			m.TimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			m.Value = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Value |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -115,6 +115,85 @@ func TestStats_AddSplitQueries(t *testing.T) {
 	})
 }
 
+func TestStats_AddSamplesProcessed(t *testing.T) {
+	t.Run("add and load samples processed", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		stats.AddSamplesProcessed(100)
+		stats.AddSamplesProcessed(50)
+
+		assert.Equal(t, uint64(150), stats.LoadSamplesProcessed())
+	})
+
+	t.Run("add and load samples processed nil receiver", func(t *testing.T) {
+		var stats *Stats
+		stats.AddSamplesProcessed(50)
+
+		assert.Equal(t, uint64(0), stats.LoadSamplesProcessed())
+	})
+}
+
+func TestStats_QueueTime(t *testing.T) {
+	t.Run("add and load queue time", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		stats.AddQueueTime(time.Second)
+		stats.AddQueueTime(time.Second)
+
+		assert.Equal(t, 2*time.Second, stats.LoadQueueTime())
+	})
+
+	t.Run("add and load queue time nil receiver", func(t *testing.T) {
+		var stats *Stats
+		stats.AddQueueTime(time.Second)
+
+		assert.Equal(t, time.Duration(0), stats.LoadQueueTime())
+	})
+}
+
+func TestStats_ResultsCacheHitRatio(t *testing.T) {
+	t.Run("add and load results cache lookups and hits", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		assert.Equal(t, float64(0), stats.LoadResultsCacheHitRatio())
+
+		stats.AddResultsCacheLookups(3)
+		stats.AddResultsCacheLookups(1)
+		stats.AddResultsCacheHits(1)
+
+		assert.Equal(t, uint32(4), stats.LoadResultsCacheLookups())
+		assert.Equal(t, uint32(1), stats.LoadResultsCacheHits())
+		assert.Equal(t, 0.25, stats.LoadResultsCacheHitRatio())
+	})
+
+	t.Run("add and load results cache lookups and hits nil receiver", func(t *testing.T) {
+		var stats *Stats
+		stats.AddResultsCacheLookups(2)
+		stats.AddResultsCacheHits(1)
+
+		assert.Equal(t, uint32(0), stats.LoadResultsCacheLookups())
+		assert.Equal(t, uint32(0), stats.LoadResultsCacheHits())
+		assert.Equal(t, float64(0), stats.LoadResultsCacheHitRatio())
+	})
+}
+
+func TestStats_AddSamplesProcessedPerStep(t *testing.T) {
+	t.Run("add and load samples processed per step", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+
+		stats.AddSamplesProcessedPerStep([]StepStat{{TimestampMs: 2000, Value: 2}, {TimestampMs: 1000, Value: 1}})
+		stats.AddSamplesProcessedPerStep([]StepStat{{TimestampMs: 3000, Value: 3}, {TimestampMs: 1000, Value: 10}, {TimestampMs: 3000, Value: 30}})
+		stats.AddSamplesProcessedPerStep(nil)
+
+		assert.Equal(t, []StepStat{{TimestampMs: 1000, Value: 11}, {TimestampMs: 2000, Value: 2}, {TimestampMs: 3000, Value: 33}}, stats.LoadSamplesProcessedPerStep())
+	})
+
+	t.Run("add and load samples processed per step with nil stats", func(t *testing.T) {
+		var stats *Stats
+
+		stats.AddSamplesProcessedPerStep([]StepStat{{TimestampMs: 1000, Value: 1}})
+
+		assert.Nil(t, stats.LoadSamplesProcessedPerStep())
+	})
+}
+
 func TestStats_Merge(t *testing.T) {
 	t.Run("merge two stats objects", func(t *testing.T) {
 		stats1 := &Stats{}
@@ -124,6 +203,11 @@ func TestStats_Merge(t *testing.T) {
 		stats1.AddFetchedChunks(10)
 		stats1.AddShardedQueries(20)
 		stats1.AddSplitQueries(10)
+		stats1.AddSamplesProcessed(100)
+		stats1.AddQueueTime(time.Millisecond)
+		stats1.AddResultsCacheLookups(2)
+		stats1.AddResultsCacheHits(1)
+		stats1.AddSamplesProcessedPerStep([]StepStat{{TimestampMs: 1000, Value: 10}, {TimestampMs: 2000, Value: 20}})
 
 		stats2 := &Stats{}
 		stats2.AddWallTime(time.Second)
@@ -132,6 +216,11 @@ func TestStats_Merge(t *testing.T) {
 		stats2.AddFetchedChunks(11)
 		stats2.AddShardedQueries(21)
 		stats2.AddSplitQueries(11)
+		stats2.AddSamplesProcessed(200)
+		stats2.AddQueueTime(time.Second)
+		stats2.AddResultsCacheLookups(2)
+		stats2.AddResultsCacheHits(2)
+		stats2.AddSamplesProcessedPerStep([]StepStat{{TimestampMs: 2000, Value: 5}, {TimestampMs: 3000, Value: 30}})
 
 		stats1.Merge(stats2)
 
@@ -141,6 +230,11 @@ func TestStats_Merge(t *testing.T) {
 		assert.Equal(t, uint64(21), stats1.LoadFetchedChunks())
 		assert.Equal(t, uint32(41), stats1.LoadShardedQueries())
 		assert.Equal(t, uint32(21), stats1.LoadSplitQueries())
+		assert.Equal(t, uint64(300), stats1.LoadSamplesProcessed())
+		assert.Equal(t, 1001*time.Millisecond, stats1.LoadQueueTime())
+		assert.Equal(t, uint32(4), stats1.LoadResultsCacheLookups())
+		assert.Equal(t, uint32(3), stats1.LoadResultsCacheHits())
+		assert.Equal(t, []StepStat{{TimestampMs: 1000, Value: 10}, {TimestampMs: 2000, Value: 25}, {TimestampMs: 3000, Value: 30}}, stats1.LoadSamplesProcessedPerStep())
 	})
 
 	t.Run("merge two nil stats objects", func(t *testing.T) {
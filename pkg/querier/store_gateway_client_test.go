@@ -87,3 +87,11 @@ func (m *mockStoreGatewayServer) LabelNames(context.Context, *storepb.LabelNames
 func (m *mockStoreGatewayServer) LabelValues(context.Context, *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
 	return nil, nil
 }
+
+func (m *mockStoreGatewayServer) LabelNamesCardinality(context.Context, *storepb.LabelNamesCardinalityRequest) (*storepb.LabelNamesCardinalityResponse, error) {
+	return nil, nil
+}
+
+func (m *mockStoreGatewayServer) LabelValuesCardinality(context.Context, *storepb.LabelValuesCardinalityRequest) (*storepb.LabelValuesCardinalityResponse, error) {
+	return nil, nil
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/prometheus/prometheus/util/annotations"
+	"go.uber.org/atomic"
 	"golang.org/x/exp/slices"
 
 	"github.com/grafana/mimir/pkg/util/spanlogger"
@@ -35,7 +36,9 @@ import (
 // If the label "__tenant_id__" already exists, its value is overwritten
 // by the tenant ID and the previous value is exposed through a new label
 // prefixed with "original_". This behaviour is not implemented recursively.
-func NewQueryable(upstream storage.Queryable, bypassWithSingleID bool, maxConcurrency int, logger log.Logger) storage.Queryable {
+// By setting allowPartialResults to true, errors querying a tenant are returned
+// as warnings, unless all the queried tenants fail.
+func NewQueryable(upstream storage.Queryable, bypassWithSingleID, allowPartialResults bool, maxConcurrency int, logger log.Logger) storage.Queryable {
 	callbacks := MergeQueryableCallbacks{
 		Querier: func(mint, maxt int64) (MergeQuerierUpstream, error) {
 			q, err := upstream.Querier(mint, maxt)
@@ -52,7 +55,7 @@ func NewQueryable(upstream storage.Queryable, bypassWithSingleID bool, maxConcur
 			return tenantIDs, err
 		},
 	}
-	return NewMergeQueryable(defaultTenantLabel, callbacks, bypassWithSingleID, maxConcurrency, logger)
+	return NewMergeQueryable(defaultTenantLabel, callbacks, bypassWithSingleID, allowPartialResults, maxConcurrency, logger)
 }
 
 // MergeQueryableCallbacks contains callbacks to NewMergeQueryable, for customizing its behaviour.
@@ -103,22 +106,26 @@ func (q *tenantQuerier) Close() error {
 // If the label `idLabelName` already exists, its value is overwritten and
 // the previous value is exposed through a new label prefixed with "original_".
 // This behaviour is not implemented recursively.
-func NewMergeQueryable(idLabelName string, callbacks MergeQueryableCallbacks, bypassWithSingleID bool, maxConcurrency int, logger log.Logger) storage.Queryable {
+// By setting allowPartialResults to true, errors querying a federation ID are
+// returned as warnings, unless all the queried IDs fail.
+func NewMergeQueryable(idLabelName string, callbacks MergeQueryableCallbacks, bypassWithSingleID, allowPartialResults bool, maxConcurrency int, logger log.Logger) storage.Queryable {
 	return &mergeQueryable{
-		logger:             logger,
-		idLabelName:        idLabelName,
-		callbacks:          callbacks,
-		bypassWithSingleID: bypassWithSingleID,
-		maxConcurrency:     maxConcurrency,
+		logger:              logger,
+		idLabelName:         idLabelName,
+		callbacks:           callbacks,
+		bypassWithSingleID:  bypassWithSingleID,
+		allowPartialResults: allowPartialResults,
+		maxConcurrency:      maxConcurrency,
 	}
 }
 
 type mergeQueryable struct {
-	logger             log.Logger
-	idLabelName        string
-	bypassWithSingleID bool
-	callbacks          MergeQueryableCallbacks
-	maxConcurrency     int
+	logger              log.Logger
+	idLabelName         string
+	bypassWithSingleID  bool
+	allowPartialResults bool
+	callbacks           MergeQueryableCallbacks
+	maxConcurrency      int
 }
 
 // Querier returns a new mergeQuerier, which aggregates results for multiple federation IDs
@@ -129,12 +136,13 @@ func (m *mergeQueryable) Querier(mint int64, maxt int64) (storage.Querier, error
 		return nil, err
 	}
 	return &mergeQuerier{
-		logger:             m.logger,
-		idLabelName:        m.idLabelName,
-		callbacks:          m.callbacks,
-		upstream:           upstream,
-		maxConcurrency:     m.maxConcurrency,
-		bypassWithSingleID: m.bypassWithSingleID,
+		logger:              m.logger,
+		idLabelName:         m.idLabelName,
+		callbacks:           m.callbacks,
+		upstream:            upstream,
+		maxConcurrency:      m.maxConcurrency,
+		bypassWithSingleID:  m.bypassWithSingleID,
+		allowPartialResults: m.allowPartialResults,
 	}, nil
 }
 
@@ -144,12 +152,13 @@ func (m *mergeQueryable) Querier(mint int64, maxt int64) (storage.Querier, error
 // the previous value is exposed through a new label prefixed with "original_".
 // This behaviour is not implemented recursively
 type mergeQuerier struct {
-	logger             log.Logger
-	callbacks          MergeQueryableCallbacks
-	upstream           MergeQuerierUpstream
-	idLabelName        string
-	maxConcurrency     int
-	bypassWithSingleID bool
+	logger              log.Logger
+	callbacks           MergeQueryableCallbacks
+	upstream            MergeQuerierUpstream
+	idLabelName         string
+	maxConcurrency      int
+	bypassWithSingleID  bool
+	allowPartialResults bool
 }
 
 // LabelValues returns all potential values for a label name given involved federation IDs.
@@ -247,12 +256,14 @@ type stringSliceFuncJob struct {
 	id       string
 	result   []string
 	warnings annotations.Annotations
+	err      error
 }
 
 // mergeDistinctStringSliceWithTenants aggregates stringSliceFunc call
 // results for provided tenants. It removes duplicates and sorts the result.
 // It doesn't require the output of the stringSliceFunc to be sorted, as results
-// of LabelValues are not sorted.
+// of LabelValues are not sorted. If partial results are allowed, errors are
+// returned as warnings unless all the IDs fail.
 func (m *mergeQuerier) mergeDistinctStringSliceWithTenants(ctx context.Context, ids map[string]struct{}, f stringSliceFunc) ([]string, annotations.Annotations, error) {
 	jobs := make([]*stringSliceFuncJob, 0, len(ids))
 	for id := range ids {
@@ -265,7 +276,11 @@ func (m *mergeQuerier) mergeDistinctStringSliceWithTenants(ctx context.Context,
 		job := jobs[idx]
 		job.result, job.warnings, err = f(ctx, job.id)
 		if err != nil {
-			return errors.Wrapf(err, "error querying %s %s", rewriteLabelName(m.idLabelName), job.id)
+			err = errors.Wrapf(err, "error querying %s %s", rewriteLabelName(m.idLabelName), job.id)
+			if !m.allowPartialResults || isCancellationError(err) {
+				return err
+			}
+			job.err = err
 		}
 
 		return nil
@@ -278,8 +293,15 @@ func (m *mergeQuerier) mergeDistinctStringSliceWithTenants(ctx context.Context,
 
 	// aggregate warnings and deduplicate string results
 	var warnings annotations.Annotations
+	var failed int
 	resultMap := make(map[string]struct{})
 	for _, job := range jobs {
+		if job.err != nil {
+			failed++
+			warnings.Add(job.err)
+			continue
+		}
+
 		for _, e := range job.result {
 			resultMap[e] = struct{}{}
 		}
@@ -289,6 +311,11 @@ func (m *mergeQuerier) mergeDistinctStringSliceWithTenants(ctx context.Context,
 		}
 	}
 
+	// partial results are only returned if at least one ID could be queried
+	if failed > 0 && failed == len(jobs) {
+		return nil, nil, jobs[0].err
+	}
+
 	result := make([]string, 0, len(resultMap))
 	for e := range resultMap {
 		result = append(result, e)
@@ -326,11 +353,16 @@ func (m *mergeQuerier) Select(ctx context.Context, sortSeries bool, hints *stora
 		jobs = append(jobs, id)
 	}
 
+	var failures *partialResultsFailures
+	if m.allowPartialResults {
+		failures = &partialResultsFailures{total: len(jobs)}
+	}
+
 	// We don't use the context passed to this function, since the context has to live longer
 	// than the call to ForEachJob (i.e. as long as seriesSets)
 	run := func(_ context.Context, idx int) error {
 		id := jobs[idx]
-		seriesSets[idx] = &addLabelsSeriesSet{
+		var seriesSet storage.SeriesSet = &addLabelsSeriesSet{
 			upstream: m.upstream.Select(ctx, id, sortSeries, hints, filteredMatchers...),
 			labels: []labels.Label{
 				{
@@ -339,6 +371,10 @@ func (m *mergeQuerier) Select(ctx context.Context, sortSeries bool, hints *stora
 				},
 			},
 		}
+		if failures != nil {
+			seriesSet = &partialResultsSeriesSet{SeriesSet: seriesSet, failures: failures}
+		}
+		seriesSets[idx] = seriesSet
 		return nil
 	}
 
@@ -389,6 +425,66 @@ func (m *addLabelsSeriesSet) Warnings() annotations.Annotations {
 	return warnings
 }
 
+// partialResultsFailures counts the series sets of a Select which failed, to tell
+// whether partial results can be returned.
+type partialResultsFailures struct {
+	total  int
+	failed atomic.Int64
+}
+
+// partialResultsSeriesSet returns the error of the upstream series set as a warning,
+// unless all the series sets of the Select failed or the query has been cancelled.
+type partialResultsSeriesSet struct {
+	storage.SeriesSet
+	failures *partialResultsFailures
+	err      error
+}
+
+func (p *partialResultsSeriesSet) Next() bool {
+	if p.err != nil {
+		return false
+	}
+	if p.SeriesSet.Next() {
+		return true
+	}
+	p.checkErr()
+	return false
+}
+
+func (p *partialResultsSeriesSet) checkErr() {
+	if p.err != nil {
+		return
+	}
+	if p.err = p.SeriesSet.Err(); p.err != nil {
+		p.failures.failed.Inc()
+	}
+}
+
+func (p *partialResultsSeriesSet) Err() error {
+	p.checkErr()
+	if p.err == nil {
+		return nil
+	}
+	if isCancellationError(p.err) || p.failures.failed.Load() >= int64(p.failures.total) {
+		return p.err
+	}
+	return nil
+}
+
+func (p *partialResultsSeriesSet) Warnings() annotations.Annotations {
+	warnings := p.SeriesSet.Warnings()
+	if p.err != nil {
+		warnings.Add(p.err)
+	}
+	return warnings
+}
+
+// isCancellationError returns whether err is caused by the query being cancelled,
+// in which case it must not be turned into a warning.
+func isCancellationError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
 // rewrite label name to be more readable in error output
 func rewriteLabelName(s string) string {
 	return strings.TrimRight(strings.TrimLeft(s, "_"), "_")
@@ -127,6 +127,9 @@ type mockSeriesSet struct {
 }
 
 func (m *mockSeriesSet) Next() bool {
+	if m.queryErr != nil {
+		return false
+	}
 	return m.upstream.Next()
 }
 
@@ -259,11 +262,13 @@ type mergeQueryableScenario struct {
 	queryable mockTenantQueryableWithFilter
 	// doNotByPassSingleQuerier determines whether the MergeQueryable is by-passed in favor of a single querier.
 	doNotByPassSingleQuerier bool
+	// allowPartialResults determines whether errors querying some of the tenants are returned as warnings.
+	allowPartialResults bool
 }
 
 func (s *mergeQueryableScenario) init(t *testing.T) (context.Context, storage.Querier) {
 	// initialize with default tenant label
-	q := NewQueryable(&s.queryable, !s.doNotByPassSingleQuerier, defaultConcurrency, log.NewNopLogger())
+	q := NewQueryable(&s.queryable, !s.doNotByPassSingleQuerier, s.allowPartialResults, defaultConcurrency, log.NewNopLogger())
 
 	// inject tenants into context
 	ctx := context.Background()
@@ -346,7 +351,7 @@ func TestMergeQueryable_Querier(t *testing.T) {
 		queryable := &mockTenantQueryableWithFilter{
 			logger: log.NewNopLogger(),
 		}
-		qable := NewQueryable(queryable, false /* bypassWithSingleID */, defaultConcurrency, log.NewNopLogger())
+		qable := NewQueryable(queryable, false /* bypassWithSingleID */, false /* allowPartialResults */, defaultConcurrency, log.NewNopLogger())
 		q, err := qable.Querier(mint, maxt)
 		require.NoError(t, err)
 
@@ -404,6 +409,29 @@ var (
 			},
 		},
 	}
+
+	threeTenantsWithErrorAndPartialResultsScenario = mergeQueryableScenario{
+		name:    "three tenants, one erroring, with partial results allowed",
+		tenants: []string{"team-a", "team-b", "team-c"},
+		queryable: mockTenantQueryableWithFilter{
+			queryErrByTenant: map[string]error{
+				"team-b": errors.New("failure xyz"),
+			},
+		},
+		allowPartialResults: true,
+	}
+
+	singleTenantErroringWithPartialResultsScenario = mergeQueryableScenario{
+		name:    "single tenant without bypass, erroring, with partial results allowed",
+		tenants: []string{"team-b"},
+		queryable: mockTenantQueryableWithFilter{
+			queryErrByTenant: map[string]error{
+				"team-b": errors.New("failure xyz"),
+			},
+		},
+		doNotByPassSingleQuerier: true,
+		allowPartialResults:      true,
+	}
 )
 
 func TestMergeQueryable_Select(t *testing.T) {
@@ -501,6 +529,33 @@ func TestMergeQueryable_Select(t *testing.T) {
 				expectedQueryErr: errors.New("error querying tenant_id team-b: failure xyz"),
 			}},
 		},
+		{
+			mergeQueryableScenario: threeTenantsWithErrorAndPartialResultsScenario,
+			selectTestCases: []selectTestCase{
+				{
+					name:                "should return the series of the other tenants and the error as a warning",
+					expectedSeriesCount: 4,
+					expectedWarnings:    []string{"error querying tenant_id team-b: failure xyz"},
+				},
+				{
+					name:                "should not return a warning when the erroring tenant is not queried",
+					matchers:            []*labels.Matcher{{Name: defaultTenantLabel, Value: "team-b", Type: labels.MatchNotEqual}},
+					expectedSeriesCount: 4,
+				},
+				{
+					name:             "should return the error when the erroring tenant is the only one queried",
+					matchers:         []*labels.Matcher{{Name: defaultTenantLabel, Value: "team-b", Type: labels.MatchEqual}},
+					expectedQueryErr: errors.New("error querying tenant_id team-b: failure xyz"),
+				},
+			},
+		},
+		{
+			mergeQueryableScenario: singleTenantErroringWithPartialResultsScenario,
+			selectTestCases: []selectTestCase{{
+				name:             "should return the error when all tenants fail",
+				expectedQueryErr: errors.New("error querying tenant_id team-b: failure xyz"),
+			}},
+		},
 	} {
 		t.Run(scenario.name, func(t *testing.T) {
 			for _, tc := range scenario.selectTestCases {
@@ -592,6 +647,21 @@ func TestMergeQueryable_LabelNames(t *testing.T) {
 				expectedQueryErr: errors.New("error querying tenant_id team-b: failure xyz"),
 			},
 		},
+		{
+			mergeQueryableScenario: threeTenantsWithErrorAndPartialResultsScenario,
+			labelNamesTestCase: labelNamesTestCase{
+				name:               "should return the label names of the other tenants and the error as a warning",
+				expectedLabelNames: []string{defaultTenantLabel, "instance", "tenant-team-a", "tenant-team-c"},
+				expectedWarnings:   []string{"error querying tenant_id team-b: failure xyz"},
+			},
+		},
+		{
+			mergeQueryableScenario: singleTenantErroringWithPartialResultsScenario,
+			labelNamesTestCase: labelNamesTestCase{
+				name:             "should return the error when all tenants fail",
+				expectedQueryErr: errors.New("error querying tenant_id team-b: failure xyz"),
+			},
+		},
 		{
 			mergeQueryableScenario: threeTenantsWithWarningsScenario,
 			labelNamesTestCase: labelNamesTestCase{
@@ -813,6 +883,15 @@ func TestMergeQueryable_LabelValues(t *testing.T) {
 				expectedQueryErr: errors.New("error querying tenant_id team-b: failure xyz"),
 			}},
 		},
+		{
+			mergeQueryableScenario: threeTenantsWithErrorAndPartialResultsScenario,
+			labelValuesTestCases: []labelValuesTestCase{{
+				name:                "should return the label values of the other tenants and the error as a warning",
+				labelName:           "instance",
+				expectedLabelValues: []string{"host1", "host2.team-a", "host2.team-c"},
+				expectedWarnings:    []string{"error querying tenant_id team-b: failure xyz"},
+			}},
+		},
 		{
 			mergeQueryableScenario: threeTenantsWithErrorScenario,
 			labelValuesTestCases: []labelValuesTestCase{{
@@ -898,7 +977,7 @@ func TestTracingMergeQueryable(t *testing.T) {
 	// set a multi tenant resolver
 	tenant.WithDefaultResolver(tenant.NewMultiResolver())
 	filter := mockTenantQueryableWithFilter{}
-	q := NewQueryable(&filter, false, defaultConcurrency, log.NewNopLogger())
+	q := NewQueryable(&filter, false, false, defaultConcurrency, log.NewNopLogger())
 	// retrieve querier if set
 	querier, err := q.Querier(mint, maxt)
 	require.NoError(t, err)
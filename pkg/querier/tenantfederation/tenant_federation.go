@@ -19,13 +19,15 @@ const (
 
 type Config struct {
 	// Enabled switches on support for multi tenant query federation
-	Enabled       bool `yaml:"enabled"`
-	MaxConcurrent int  `yaml:"max_concurrent" category:"experimental"`
+	Enabled             bool `yaml:"enabled"`
+	MaxConcurrent       int  `yaml:"max_concurrent" category:"experimental"`
+	AllowPartialResults bool `yaml:"allow_partial_results" category:"experimental"`
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.Enabled, "tenant-federation.enabled", false, "If enabled on all services, queries can be federated across multiple tenants. The tenant IDs involved need to be specified separated by a '|' character in the 'X-Scope-OrgID' header.")
 	f.IntVar(&cfg.MaxConcurrent, "tenant-federation.max-concurrent", defaultConcurrency, "The number of workers used for each tenant federated query. This setting limits the maximum number of per-tenant queries executed at a time for a tenant federated query.")
+	f.BoolVar(&cfg.AllowPartialResults, "tenant-federation.allow-partial-results", false, "If enabled, series and label queries federated across multiple tenants return the results of the tenants that could be queried, and the errors of the failing tenants as warnings. The query fails if all tenants fail.")
 }
 
 // filterValuesByMatchers applies matchers to inputed `idLabelName` and
@@ -30,6 +30,7 @@ import (
 	"golang.org/x/exp/slices"
 	"google.golang.org/grpc"
 
+	"github.com/grafana/mimir/pkg/scheduler/queue"
 	"github.com/grafana/mimir/pkg/util/spanlogger"
 	"github.com/grafana/mimir/pkg/util/version"
 )
@@ -62,6 +63,8 @@ type QueryFrontendConfig struct {
 	GRPCClientConfig grpcclient.Config `yaml:"grpc_client_config" doc:"description=Configures the gRPC client used to communicate between the rulers and query-frontends."`
 
 	QueryResultResponseFormat string `yaml:"query_result_response_format"`
+
+	QueryPriorityClass string `yaml:"query_priority_class" category:"experimental"`
 }
 
 func (c *QueryFrontendConfig) RegisterFlags(f *flag.FlagSet) {
@@ -74,6 +77,7 @@ func (c *QueryFrontendConfig) RegisterFlags(f *flag.FlagSet) {
 	c.GRPCClientConfig.RegisterFlagsWithPrefix("ruler.query-frontend.grpc-client-config", f)
 
 	f.StringVar(&c.QueryResultResponseFormat, "ruler.query-frontend.query-result-response-format", formatProtobuf, fmt.Sprintf("Format to use when retrieving query results from query-frontends. Supported values: %s", strings.Join(allFormats, ", ")))
+	f.StringVar(&c.QueryPriorityClass, "ruler.query-frontend.query-priority-class", "ruler", "Query priority class requested for the queries sent to query-frontends. The priority is applied by the query-scheduler only if the class is listed in -query-frontend.query-priority-classes and allowed for the tenant with -query-frontend.allowed-query-priority-classes. Empty to not request any priority class.")
 }
 
 func (c *QueryFrontendConfig) Validate() error {
@@ -325,6 +329,17 @@ func WithOrgIDMiddleware(ctx context.Context, req *httpgrpc.HTTPRequest) error {
 	return nil
 }
 
+// WithQueryPriorityClassMiddleware returns a Middleware requesting the input query priority class for the outgoing request.
+func WithQueryPriorityClassMiddleware(class string) Middleware {
+	return func(_ context.Context, req *httpgrpc.HTTPRequest) error {
+		req.Headers = append(req.Headers, &httpgrpc.Header{
+			Key:    textproto.CanonicalMIMEHeaderKey(queue.PriorityClassHeaderName),
+			Values: []string{class},
+		})
+		return nil
+	}
+}
+
 func getHeader(headers []*httpgrpc.Header, name string) string {
 	for _, h := range headers {
 		if h.Key == name && len(h.Values) > 0 {
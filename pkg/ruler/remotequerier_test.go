@@ -25,6 +25,7 @@ import (
 	"google.golang.org/grpc/codes"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/scheduler/queue"
 )
 
 type mockHTTPGRPCClient func(ctx context.Context, req *httpgrpc.HTTPRequest, _ ...grpc.CallOption) (*httpgrpc.HTTPResponse, error)
@@ -117,6 +118,28 @@ func TestRemoteQuerier_QueryReq(t *testing.T) {
 
 }
 
+func TestRemoteQuerier_QueryReqWithQueryPriorityClass(t *testing.T) {
+	var inReq *httpgrpc.HTTPRequest
+
+	mockClientFn := func(ctx context.Context, req *httpgrpc.HTTPRequest, _ ...grpc.CallOption) (*httpgrpc.HTTPResponse, error) {
+		inReq = req
+		return &httpgrpc.HTTPResponse{
+			Code: http.StatusOK,
+			Headers: []*httpgrpc.Header{
+				{Key: "Content-Type", Values: []string{"application/json"}},
+			},
+			Body: []byte(`{"status": "success","data": {"resultType":"vector","result":[]}}`),
+		}, nil
+	}
+	q := NewRemoteQuerier(mockHTTPGRPCClient(mockClientFn), time.Minute, formatJSON, "/prometheus", log.NewNopLogger(), WithQueryPriorityClassMiddleware("ruler"))
+
+	_, err := q.Query(context.Background(), "qs", time.Now())
+	require.NoError(t, err)
+
+	require.NotNil(t, inReq)
+	require.Equal(t, "ruler", getHeader(inReq.Headers, queue.PriorityClassHeaderName))
+}
+
 func TestRemoteQuerier_QueryJSONDecoding(t *testing.T) {
 	scenarios := map[string]struct {
 		body          string
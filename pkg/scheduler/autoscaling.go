@@ -0,0 +1,263 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/grafana/mimir/pkg/scheduler/queue"
+	"github.com/grafana/mimir/pkg/util"
+	util_math "github.com/grafana/mimir/pkg/util/math"
+)
+
+const (
+	// autoscalingUpdateInterval is how frequently the moving averages used to compute the autoscaling signals are
+	// updated.
+	autoscalingUpdateInterval = 5 * time.Second
+
+	// autoscalingEWMAAlpha is the weight of the last interval in the moving averages.
+	autoscalingEWMAAlpha = 0.2
+)
+
+// autoscalingSignals are the signals exposed to scale the queriers based on the load of the query-scheduler.
+type autoscalingSignals struct {
+	// Number of querier workers currently connected to the query-scheduler.
+	ConnectedQuerierWorkers int `json:"connectedQuerierWorkers"`
+
+	// Number of requests currently being processed by querier workers.
+	InflightRequests int `json:"inflightRequests"`
+
+	// Ratio between InflightRequests and ConnectedQuerierWorkers.
+	InflightToCapacityRatio float64 `json:"inflightToCapacityRatio"`
+
+	// Number of querier workers recommended to handle the current load, summing up the recommendations of all tenants.
+	RecommendedQuerierWorkers int `json:"recommendedQuerierWorkers"`
+
+	Tenants []tenantAutoscalingSignals `json:"tenants"`
+}
+
+type tenantAutoscalingSignals struct {
+	TenantID string `json:"tenantID"`
+
+	// Number of requests of the tenant waiting in the queue.
+	QueueLength int `json:"queueLength"`
+
+	// Moving average of the number of requests of the tenant dequeued per second.
+	DequeueRate float64 `json:"dequeueRate"`
+
+	// Moving average of the time spent by querier workers processing the requests of the tenant.
+	AverageLatencySeconds float64 `json:"averageLatencySeconds"`
+
+	// Number of querier workers recommended to handle the load of the tenant.
+	RecommendedQuerierWorkers float64 `json:"recommendedQuerierWorkers"`
+}
+
+// autoscalingTracker tracks the dequeue rate and the processing latency of the requests of each tenant, which are
+// used to compute the autoscaling signals.
+type autoscalingTracker struct {
+	// queueDrainPeriod is the time within which the recommended querier workers should drain the queued requests.
+	queueDrainPeriod time.Duration
+
+	// inflight is the number of requests currently being processed by querier workers.
+	inflight atomic.Int64
+
+	mtx     sync.Mutex
+	tenants map[string]*tenantAutoscalingStats
+}
+
+type tenantAutoscalingStats struct {
+	dequeueRate *util_math.EwmaRate
+
+	// Latency of the requests completed since the last update, and its moving average.
+	latencySum   time.Duration
+	latencyCount int
+	avgLatency   float64
+	latencyInit  bool
+}
+
+func newAutoscalingTracker(queueDrainPeriod time.Duration) *autoscalingTracker {
+	return &autoscalingTracker{
+		queueDrainPeriod: queueDrainPeriod,
+		tenants:          map[string]*tenantAutoscalingStats{},
+	}
+}
+
+// getOrCreateTenant returns the stats of the tenant. Must be called with mtx held.
+func (t *autoscalingTracker) getOrCreateTenant(tenantID string) *tenantAutoscalingStats {
+	stats, ok := t.tenants[tenantID]
+	if !ok {
+		stats = &tenantAutoscalingStats{dequeueRate: util_math.NewEWMARate(autoscalingEWMAAlpha, autoscalingUpdateInterval)}
+		t.tenants[tenantID] = stats
+	}
+	return stats
+}
+
+// requestStarted records that a request of the tenant has been dequeued and sent to a querier worker.
+func (t *autoscalingTracker) requestStarted(tenantID string) {
+	t.inflight.Inc()
+
+	t.mtx.Lock()
+	t.getOrCreateTenant(tenantID).dequeueRate.Inc()
+	t.mtx.Unlock()
+}
+
+// requestCompleted records that a querier worker completed a request of the tenant, if successful is true, or gave up
+// on it otherwise. Only the latency of successful requests is tracked.
+func (t *autoscalingTracker) requestCompleted(tenantID string, latency time.Duration, successful bool) {
+	t.inflight.Dec()
+	if !successful {
+		return
+	}
+
+	t.mtx.Lock()
+	stats := t.getOrCreateTenant(tenantID)
+	stats.latencySum += latency
+	stats.latencyCount++
+	t.mtx.Unlock()
+}
+
+// tick updates the moving averages. It's expected to be called every autoscalingUpdateInterval.
+func (t *autoscalingTracker) tick() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for _, stats := range t.tenants {
+		stats.dequeueRate.Tick()
+
+		if stats.latencyCount == 0 {
+			continue
+		}
+
+		latency := (stats.latencySum / time.Duration(stats.latencyCount)).Seconds()
+		if stats.latencyInit {
+			stats.avgLatency += autoscalingEWMAAlpha * (latency - stats.avgLatency)
+		} else {
+			stats.avgLatency = latency
+			stats.latencyInit = true
+		}
+		stats.latencySum, stats.latencyCount = 0, 0
+	}
+}
+
+func (t *autoscalingTracker) cleanupTenant(tenantID string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	delete(t.tenants, tenantID)
+}
+
+// inflightToCapacityRatio returns the ratio between the requests being processed by querier workers and the
+// connected querier workers.
+func (t *autoscalingTracker) inflightToCapacityRatio(connectedWorkers int) float64 {
+	if connectedWorkers == 0 {
+		return 0
+	}
+	return float64(t.inflight.Load()) / float64(connectedWorkers)
+}
+
+// signals computes the autoscaling signals, given the current state of the queue and the number of connected
+// querier workers.
+//
+// The querier workers recommended for each tenant are the ones kept busy by the current dequeue rate (dequeue rate
+// multiplied by the average latency, following the Little's law), plus the ones required to drain the queued
+// requests within the queue drain period. The latency of a tenant without completed requests yet is estimated with
+// the average latency of the other tenants, or with the queue drain period if no request has completed yet.
+func (t *autoscalingTracker) signals(state []queue.TenantQueueState, connectedWorkers int) autoscalingSignals {
+	queueLengths := make(map[string]int, len(state))
+	for _, tenantState := range state {
+		queueLengths[tenantState.TenantID] = len(tenantState.Requests)
+	}
+
+	t.mtx.Lock()
+	tenants := make([]tenantAutoscalingSignals, 0, len(t.tenants))
+	for tenantID, stats := range t.tenants {
+		tenant := tenantAutoscalingSignals{
+			TenantID:    tenantID,
+			QueueLength: queueLengths[tenantID],
+			DequeueRate: stats.dequeueRate.Rate(),
+		}
+		if stats.latencyInit {
+			tenant.AverageLatencySeconds = stats.avgLatency
+		}
+		tenants = append(tenants, tenant)
+	}
+
+	// Tenants which have queued requests, but have never been dequeued.
+	for tenantID, length := range queueLengths {
+		if _, ok := t.tenants[tenantID]; !ok {
+			tenants = append(tenants, tenantAutoscalingSignals{TenantID: tenantID, QueueLength: length})
+		}
+	}
+	t.mtx.Unlock()
+
+	// Estimate the latency of the tenants without completed requests.
+	fallbackLatency := t.queueDrainPeriod.Seconds()
+	if sum, count := latencySum(tenants); count > 0 {
+		fallbackLatency = sum / float64(count)
+	}
+
+	var recommended float64
+	for i := range tenants {
+		latency := tenants[i].AverageLatencySeconds
+		if latency == 0 {
+			latency = fallbackLatency
+		}
+
+		busy := tenants[i].DequeueRate * latency
+		draining := float64(tenants[i].QueueLength) * latency / t.queueDrainPeriod.Seconds()
+		tenants[i].RecommendedQuerierWorkers = busy + draining
+		recommended += tenants[i].RecommendedQuerierWorkers
+	}
+
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].TenantID < tenants[j].TenantID
+	})
+
+	// The querier workers currently busy are needed anyway.
+	inflight := int(t.inflight.Load())
+	return autoscalingSignals{
+		ConnectedQuerierWorkers:   connectedWorkers,
+		InflightRequests:          inflight,
+		InflightToCapacityRatio:   t.inflightToCapacityRatio(connectedWorkers),
+		RecommendedQuerierWorkers: max(int(math.Ceil(recommended)), inflight),
+		Tenants:                   tenants,
+	}
+}
+
+func latencySum(tenants []tenantAutoscalingSignals) (sum float64, count int) {
+	for _, tenant := range tenants {
+		if tenant.AverageLatencySeconds > 0 {
+			sum += tenant.AverageLatencySeconds
+			count++
+		}
+	}
+	return sum, count
+}
+
+// AutoscalingHandler returns, in JSON format, the signals to scale the queriers based on the load of this
+// query-scheduler: the querier workers recommended given the queue length, dequeue rate and average latency of each
+// tenant, and the ratio between the in-flight requests and the connected querier workers.
+func (s *Scheduler) AutoscalingHandler(w http.ResponseWriter, req *http.Request) {
+	signals, err := s.autoscalingSignals(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, signals)
+}
+
+func (s *Scheduler) autoscalingSignals(ctx context.Context) (autoscalingSignals, error) {
+	state, err := s.requestQueue.GetQueueState(ctx)
+	if err != nil {
+		return autoscalingSignals{}, err
+	}
+	return s.autoscaling.signals(state, int(s.requestQueue.GetConnectedQuerierWorkersMetric())), nil
+}
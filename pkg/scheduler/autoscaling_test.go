@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/scheduler/queue"
+	"github.com/grafana/mimir/pkg/scheduler/schedulerpb"
+)
+
+func TestAutoscalingTracker_Signals(t *testing.T) {
+	tracker := newAutoscalingTracker(10 * time.Second)
+
+	// user-1 dequeued 10 requests in the last interval (2 per second), each taking 500ms.
+	for i := 0; i < 10; i++ {
+		tracker.requestStarted("user-1")
+		tracker.requestCompleted("user-1", 500*time.Millisecond, true)
+	}
+	// user-2 has a request in progress, and a failed one whose latency is not tracked.
+	tracker.requestStarted("user-2")
+	tracker.requestStarted("user-2")
+	tracker.requestCompleted("user-2", time.Minute, false)
+	tracker.tick()
+
+	state := []queue.TenantQueueState{
+		{TenantID: "user-1", Requests: make([]queue.QueuedRequestState, 20)},
+		{TenantID: "user-3", Requests: make([]queue.QueuedRequestState, 4)},
+	}
+	signals := tracker.signals(state, 4)
+
+	assert.Equal(t, 4, signals.ConnectedQuerierWorkers)
+	assert.Equal(t, 1, signals.InflightRequests)
+	assert.Equal(t, 0.25, signals.InflightToCapacityRatio)
+
+	require.Len(t, signals.Tenants, 3)
+	assert.Equal(t, tenantAutoscalingSignals{
+		TenantID:              "user-1",
+		QueueLength:           20,
+		DequeueRate:           2,
+		AverageLatencySeconds: 0.5,
+		// 2 req/s * 0.5s busy workers, plus 20 requests * 0.5s to drain in 10s.
+		RecommendedQuerierWorkers: 2,
+	}, signals.Tenants[0])
+
+	// user-2 has no completed requests, so its latency is estimated with the average latency of the other tenants.
+	assert.Equal(t, "user-2", signals.Tenants[1].TenantID)
+	assert.Equal(t, 0.4, signals.Tenants[1].DequeueRate)
+	assert.Zero(t, signals.Tenants[1].AverageLatencySeconds)
+	assert.InDelta(t, 0.2, signals.Tenants[1].RecommendedQuerierWorkers, 1e-9)
+
+	// user-3 has only queued requests.
+	assert.Equal(t, "user-3", signals.Tenants[2].TenantID)
+	assert.Equal(t, 4, signals.Tenants[2].QueueLength)
+	assert.InDelta(t, 0.2, signals.Tenants[2].RecommendedQuerierWorkers, 1e-9)
+
+	assert.Equal(t, 3, signals.RecommendedQuerierWorkers)
+
+	// Once a tenant is inactive, it's not tracked anymore.
+	tracker.cleanupTenant("user-2")
+	signals = tracker.signals(nil, 4)
+	require.Len(t, signals.Tenants, 1)
+	assert.Equal(t, "user-1", signals.Tenants[0].TenantID)
+}
+
+func TestAutoscalingTracker_SignalsWithoutCompletedRequests(t *testing.T) {
+	tracker := newAutoscalingTracker(10 * time.Second)
+
+	// Without any latency tracked, each queued request is expected to take the whole queue drain period.
+	signals := tracker.signals([]queue.TenantQueueState{{TenantID: "user-1", Requests: make([]queue.QueuedRequestState, 3)}}, 0)
+	assert.Equal(t, 3, signals.RecommendedQuerierWorkers)
+	assert.Zero(t, signals.InflightToCapacityRatio)
+}
+
+func TestScheduler_AutoscalingHandler(t *testing.T) {
+	scheduler, frontendClient, querierClient := setupScheduler(t, nil)
+
+	frontendLoop := initFrontendLoop(t, frontendClient, "frontend-12345")
+	for queryID := uint64(1); queryID <= 3; queryID++ {
+		frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+			Type:        schedulerpb.ENQUEUE,
+			QueryID:     queryID,
+			UserID:      "user-1",
+			HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+		})
+	}
+
+	// Dequeue a request, which is then in progress.
+	querierLoop := initQuerierLoop(t, querierClient, "querier-1")
+	_, err := querierLoop.Recv()
+	require.NoError(t, err)
+
+	var signals autoscalingSignals
+	require.Eventually(t, func() bool {
+		req := httptest.NewRequest(http.MethodGet, "/scheduler/autoscaling", nil)
+		rec := httptest.NewRecorder()
+		scheduler.AutoscalingHandler(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &signals))
+
+		return signals.InflightRequests == 1 && signals.ConnectedQuerierWorkers == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, 1.0, signals.InflightToCapacityRatio)
+	require.Len(t, signals.Tenants, 1)
+	assert.Equal(t, "user-1", signals.Tenants[0].TenantID)
+	assert.Equal(t, 2, signals.Tenants[0].QueueLength)
+	assert.Equal(t, 2, signals.RecommendedQuerierWorkers)
+
+	require.NoError(t, querierLoop.CloseSend())
+}
@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package queue
+
+import (
+	"net/http"
+
+	"github.com/grafana/dskit/httpgrpc"
+	"golang.org/x/exp/slices"
+)
+
+// PriorityClassHeaderName is the name of the header used by clients to request a query priority class.
+const PriorityClassHeaderName = "X-Query-Priority-Class"
+
+// PriorityForRequest returns the priority of the input request, based on the priority class requested via the
+// PriorityClassHeaderName header and the configured priority classes, from the highest to the lowest priority.
+// Returns 0 (unprioritized) if the request has no priority class, if the class isn't configured, or if any of the
+// request tenants isn't allowed to request it.
+func PriorityForRequest(req *httpgrpc.HTTPRequest, priorityClasses []string, tenantIDs []string, allowedPriorityClasses func(tenantID string) []string) int {
+	class := ""
+	for _, h := range req.Headers {
+		if http.CanonicalHeaderKey(h.Key) == PriorityClassHeaderName && len(h.Values) > 0 {
+			class = h.Values[0]
+			break
+		}
+	}
+	if class == "" || len(tenantIDs) == 0 {
+		return 0
+	}
+
+	ix := slices.Index(priorityClasses, class)
+	if ix < 0 {
+		return 0
+	}
+
+	for _, tenantID := range tenantIDs {
+		if !slices.Contains(allowedPriorityClasses(tenantID), class) {
+			return 0
+		}
+	}
+	return len(priorityClasses) - ix
+}
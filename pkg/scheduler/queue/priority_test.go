@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package queue
+
+import (
+	"testing"
+
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityForRequest(t *testing.T) {
+	priorityClasses := []string{"ruler", "dashboards"}
+	allowedPriorityClasses := map[string][]string{
+		"user-1": {"ruler", "dashboards", "unknown"},
+		"user-2": {"dashboards"},
+	}
+	getAllowedPriorityClasses := func(tenantID string) []string {
+		return allowedPriorityClasses[tenantID]
+	}
+
+	withClass := func(class string) *httpgrpc.HTTPRequest {
+		return &httpgrpc.HTTPRequest{Headers: []*httpgrpc.Header{{Key: PriorityClassHeaderName, Values: []string{class}}}}
+	}
+
+	tests := map[string]struct {
+		req       *httpgrpc.HTTPRequest
+		tenantIDs []string
+		expected  int
+	}{
+		"request without priority class": {
+			req:       &httpgrpc.HTTPRequest{},
+			tenantIDs: []string{"user-1"},
+			expected:  0,
+		},
+		"request with the highest priority class": {
+			req:       withClass("ruler"),
+			tenantIDs: []string{"user-1"},
+			expected:  2,
+		},
+		"request with the lowest priority class": {
+			req:       withClass("dashboards"),
+			tenantIDs: []string{"user-1"},
+			expected:  1,
+		},
+		"request with a priority class not configured": {
+			req:       withClass("unknown"),
+			tenantIDs: []string{"user-1"},
+			expected:  0,
+		},
+		"request with a priority class not allowed for the tenant": {
+			req:       withClass("ruler"),
+			tenantIDs: []string{"user-2"},
+			expected:  0,
+		},
+		"tenant without allowed priority classes": {
+			req:       withClass("ruler"),
+			tenantIDs: []string{"user-3"},
+			expected:  0,
+		},
+		"multi-tenant request with a priority class allowed for all tenants": {
+			req:       withClass("dashboards"),
+			tenantIDs: []string{"user-1", "user-2"},
+			expected:  1,
+		},
+		"multi-tenant request with a priority class not allowed for all tenants": {
+			req:       withClass("ruler"),
+			tenantIDs: []string{"user-1", "user-2"},
+			expected:  0,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, PriorityForRequest(tc.req, priorityClasses, tc.tenantIDs, getAllowedPriorityClasses))
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package queue
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/grafana/dskit/httpgrpc"
+
+	"github.com/grafana/mimir/pkg/util"
+)
+
+// Query components a request is expected to query. Requests for which the query component can't be
+// inferred are enqueued with an empty query component.
+const (
+	QueryComponentIngester                = "ingester"
+	QueryComponentStoreGateway            = "store-gateway"
+	QueryComponentIngesterAndStoreGateway = "ingester-and-store-gateway"
+)
+
+// QueryComponentForRequest infers which component the input request is expected to query, based on
+// its time range, -querier.query-store-after and the tenant's query_ingesters_within limit. The logic
+// mirrors the one used by queriers. Returns an empty string if the request time range can't be inferred.
+func QueryComponentForRequest(req *httpgrpc.HTTPRequest, now time.Time, queryStoreAfter, queryIngestersWithin time.Duration) string {
+	minT, maxT, ok := RequestTimeRange(req)
+	if !ok {
+		return ""
+	}
+
+	queryIngesters := queryIngestersWithin == 0 || maxT > util.TimeToMillis(now.Add(-queryIngestersWithin))
+	queryStoreGateways := queryStoreAfter == 0 || minT < util.TimeToMillis(now.Add(-queryStoreAfter))
+
+	switch {
+	case queryIngesters && queryStoreGateways:
+		return QueryComponentIngesterAndStoreGateway
+	case queryIngesters:
+		return QueryComponentIngester
+	case queryStoreGateways:
+		return QueryComponentStoreGateway
+	default:
+		return ""
+	}
+}
+
+// RequestTimeRange returns the time range of a range query (start and end parameters) or of an
+// instant query (time parameter), read from both the URL and the form-encoded body. Timestamps are in milliseconds.
+func RequestTimeRange(req *httpgrpc.HTTPRequest) (minT, maxT int64, ok bool) {
+	params, ok := RequestParams(req)
+	if !ok {
+		return 0, 0, false
+	}
+
+	if t := params.Get("time"); t != "" && params.Get("start") == "" && params.Get("end") == "" {
+		ts, err := util.ParseTime(t)
+		if err != nil {
+			return 0, 0, false
+		}
+		return ts, ts, true
+	}
+
+	start, err := util.ParseTime(params.Get("start"))
+	if err != nil {
+		return 0, 0, false
+	}
+	end, err := util.ParseTime(params.Get("end"))
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// RequestParams returns the parameters of the request, read from both the URL and the form-encoded body.
+func RequestParams(req *httpgrpc.HTTPRequest) (url.Values, bool) {
+	u, err := url.Parse(req.Url)
+	if err != nil {
+		return nil, false
+	}
+	params := u.Query()
+
+	if req.Method != http.MethodPost || len(req.Body) == 0 {
+		return params, true
+	}
+
+	for _, h := range req.Headers {
+		if http.CanonicalHeaderKey(h.Key) != "Content-Type" || len(h.Values) == 0 {
+			continue
+		}
+		if mediaType, _, err := mime.ParseMediaType(h.Values[0]); err != nil || mediaType != "application/x-www-form-urlencoded" {
+			return params, true
+		}
+
+		body, err := url.ParseQuery(string(req.Body))
+		if err != nil {
+			return nil, false
+		}
+		for name, values := range body {
+			params[name] = append(values, params[name]...)
+		}
+	}
+	return params, true
+}
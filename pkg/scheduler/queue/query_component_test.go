@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package queue
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryComponentForRequest(t *testing.T) {
+	const (
+		queryStoreAfter      = 12 * time.Hour
+		queryIngestersWithin = 13 * time.Hour
+	)
+
+	now := time.Now()
+
+	rangeQuery := func(start, end time.Time) *httpgrpc.HTTPRequest {
+		return &httpgrpc.HTTPRequest{
+			Method: http.MethodGet,
+			Url:    fmt.Sprintf("/prometheus/api/v1/query_range?query=up&start=%d&end=%d&step=60", start.Unix(), end.Unix()),
+		}
+	}
+
+	tests := map[string]struct {
+		req                  *httpgrpc.HTTPRequest
+		queryStoreAfter      time.Duration
+		queryIngestersWithin time.Duration
+		expected             string
+	}{
+		"range query within query store after": {
+			req:                  rangeQuery(now.Add(-time.Hour), now),
+			queryStoreAfter:      queryStoreAfter,
+			queryIngestersWithin: queryIngestersWithin,
+			expected:             QueryComponentIngester,
+		},
+		"range query older than query ingesters within": {
+			req:                  rangeQuery(now.Add(-48*time.Hour), now.Add(-24*time.Hour)),
+			queryStoreAfter:      queryStoreAfter,
+			queryIngestersWithin: queryIngestersWithin,
+			expected:             QueryComponentStoreGateway,
+		},
+		"range query spanning over both ingesters and store-gateways": {
+			req:                  rangeQuery(now.Add(-24*time.Hour), now),
+			queryStoreAfter:      queryStoreAfter,
+			queryIngestersWithin: queryIngestersWithin,
+			expected:             QueryComponentIngesterAndStoreGateway,
+		},
+		"range query when query store after and query ingesters within are disabled": {
+			req:      rangeQuery(now.Add(-time.Hour), now),
+			expected: QueryComponentIngesterAndStoreGateway,
+		},
+		"instant query": {
+			req: &httpgrpc.HTTPRequest{
+				Method: http.MethodGet,
+				Url:    fmt.Sprintf("/prometheus/api/v1/query?query=up&time=%d", now.Add(-24*time.Hour).Unix()),
+			},
+			queryStoreAfter:      queryStoreAfter,
+			queryIngestersWithin: queryIngestersWithin,
+			expected:             QueryComponentStoreGateway,
+		},
+		"range query with form-encoded body": {
+			req: &httpgrpc.HTTPRequest{
+				Method:  http.MethodPost,
+				Url:     "/prometheus/api/v1/query_range",
+				Headers: []*httpgrpc.Header{{Key: "Content-Type", Values: []string{"application/x-www-form-urlencoded"}}},
+				Body: []byte(url.Values{
+					"query": []string{"up"},
+					"start": []string{now.Add(-time.Hour).Format(time.RFC3339)},
+					"end":   []string{now.Format(time.RFC3339)},
+					"step":  []string{"60"},
+				}.Encode()),
+			},
+			queryStoreAfter:      queryStoreAfter,
+			queryIngestersWithin: queryIngestersWithin,
+			expected:             QueryComponentIngester,
+		},
+		"request without time range": {
+			req: &httpgrpc.HTTPRequest{
+				Method: http.MethodGet,
+				Url:    "/prometheus/api/v1/labels",
+			},
+			queryStoreAfter:      queryStoreAfter,
+			queryIngestersWithin: queryIngestersWithin,
+			expected:             "",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, QueryComponentForRequest(tc.req, now, tc.queryStoreAfter, tc.queryIngestersWithin))
+		})
+	}
+}
@@ -32,12 +32,16 @@ var (
 // of RequestQueue.GetNextRequestForQuerier method.
 type UserIndex struct {
 	last int
+
+	// Whether the last returned request is unprioritized. The querier worker capacity it holds is released
+	// on the next call to GetNextRequestForQuerier, or when the querier connection is unregistered.
+	unprioritizedRequest bool
 }
 
 // Modify index to start iteration on the same user, for which last queue was returned.
 func (ui UserIndex) ReuseLastUser() UserIndex {
 	if ui.last >= 0 {
-		return UserIndex{last: ui.last - 1}
+		return UserIndex{last: ui.last - 1, unprioritizedRequest: ui.unprioritizedRequest}
 	}
 	return ui
 }
@@ -56,26 +60,35 @@ type Request interface{}
 type RequestQueue struct {
 	services.Service
 
-	maxOutstandingPerTenant int
-	forgetDelay             time.Duration
+	maxOutstandingPerTenant          int
+	forgetDelay                      time.Duration
+	reservedQuerierWorkersPercentage float64
+	priorityStarvationTimeout        time.Duration
 
 	connectedQuerierWorkers *atomic.Int32
 
+	// Number of querier workers processing an unprioritized request. Only accessed by dispatcherLoop().
+	unprioritizedRequestsInFlight int
+
 	stopRequested              chan struct{} // Written to by stop() to wake up dispatcherLoop() in response to a stop request.
 	stopCompleted              chan struct{} // Closed by dispatcherLoop() after a stop is requested and the dispatcher has stopped.
 	querierOperations          chan querierOperation
 	requestsToEnqueue          chan requestToEnqueue
 	nextRequestForQuerierCalls chan *nextRequestForQuerierCall
+	queueStateCalls            chan chan []TenantQueueState
+	removeRequestsCalls        chan removeRequestsCall
 
-	queueLength       *prometheus.GaugeVec   // Per user and reason.
-	discardedRequests *prometheus.CounterVec // Per user.
+	queueLength                 *prometheus.GaugeVec   // Per user.
+	queueLengthByQueryComponent *prometheus.GaugeVec   // Per user and query component.
+	discardedRequests           *prometheus.CounterVec // Per user.
 
 	enqueueDuration prometheus.Histogram
 }
 
 type querierOperation struct {
-	querierID QuerierID
-	operation querierOperationType
+	querierID     QuerierID
+	operation     querierOperationType
+	lastUserIndex UserIndex
 }
 
 type querierOperationType int
@@ -88,21 +101,32 @@ const (
 )
 
 type requestToEnqueue struct {
-	tenantID    TenantID
-	req         Request
-	maxQueriers int
-	successFn   func()
-	processed   chan error
+	tenantID       TenantID
+	req            Request
+	queryComponent string
+	priority       int
+	maxQueriers    int
+	successFn      func()
+	processed      chan error
 }
 
-func NewRequestQueue(maxOutstandingPerTenant int, forgetDelay time.Duration, queueLength *prometheus.GaugeVec, discardedRequests *prometheus.CounterVec, enqueueDuration prometheus.Histogram) *RequestQueue {
+type removeRequestsCall struct {
+	tenantID  TenantID
+	match     func(Request) bool
+	processed chan []Request
+}
+
+func NewRequestQueue(maxOutstandingPerTenant int, forgetDelay time.Duration, reservedQuerierWorkersPercentage float64, priorityStarvationTimeout time.Duration, queueLength, queueLengthByQueryComponent *prometheus.GaugeVec, discardedRequests *prometheus.CounterVec, enqueueDuration prometheus.Histogram) *RequestQueue {
 	q := &RequestQueue{
-		maxOutstandingPerTenant: maxOutstandingPerTenant,
-		forgetDelay:             forgetDelay,
-		connectedQuerierWorkers: atomic.NewInt32(0),
-		queueLength:             queueLength,
-		discardedRequests:       discardedRequests,
-		enqueueDuration:         enqueueDuration,
+		maxOutstandingPerTenant:          maxOutstandingPerTenant,
+		forgetDelay:                      forgetDelay,
+		reservedQuerierWorkersPercentage: reservedQuerierWorkersPercentage,
+		priorityStarvationTimeout:        priorityStarvationTimeout,
+		connectedQuerierWorkers:          atomic.NewInt32(0),
+		queueLength:                      queueLength,
+		queueLengthByQueryComponent:      queueLengthByQueryComponent,
+		discardedRequests:                discardedRequests,
+		enqueueDuration:                  enqueueDuration,
 
 		stopRequested: make(chan struct{}),
 		stopCompleted: make(chan struct{}),
@@ -111,6 +135,8 @@ func NewRequestQueue(maxOutstandingPerTenant int, forgetDelay time.Duration, que
 		querierOperations:          make(chan querierOperation),
 		requestsToEnqueue:          make(chan requestToEnqueue),
 		nextRequestForQuerierCalls: make(chan *nextRequestForQuerierCall),
+		queueStateCalls:            make(chan chan []TenantQueueState),
+		removeRequestsCalls:        make(chan removeRequestsCall),
 	}
 
 	q.Service = services.NewTimerService(forgetCheckPeriod, q.starting, q.forgetDisconnectedQueriers, q.stop).WithName("request queue")
@@ -147,6 +173,7 @@ func (q *RequestQueue) dispatcherLoop() {
 				needToDispatchQueries = true
 			case unregisterConnection:
 				q.connectedQuerierWorkers.Dec()
+				q.releaseQuerierWorker(&qe.lastUserIndex)
 				queueBroker.removeQuerierConnection(qe.querierID, time.Now())
 				needToDispatchQueries = true
 			case notifyShutdown:
@@ -161,6 +188,11 @@ func (q *RequestQueue) dispatcherLoop() {
 					// Removing some queriers may have caused a resharding.
 					needToDispatchQueries = true
 				}
+
+				if q.priorityStarvationTimeout > 0 {
+					// Some requests held back by the capacity reserved to prioritized requests may be starving by now.
+					needToDispatchQueries = true
+				}
 			default:
 				panic(fmt.Sprintf("received unknown querier event %v for querier ID %v", qe.operation, qe.querierID))
 			}
@@ -172,10 +204,20 @@ func (q *RequestQueue) dispatcherLoop() {
 				needToDispatchQueries = true
 			}
 		case call := <-q.nextRequestForQuerierCalls:
+			// The querier worker has done with the previous request, if any. Releasing its capacity may allow
+			// other waiting querier workers to get an unprioritized request.
+			if q.releaseQuerierWorker(&call.lastUserIndex) {
+				needToDispatchQueries = true
+			}
+
 			if !q.tryDispatchRequest(queueBroker, call) {
 				// No requests available for this querier connection right now. Add it to the list to try later.
 				waitingGetNextRequestForQuerierCalls.PushBack(call)
 			}
+		case processed := <-q.queueStateCalls:
+			processed <- queueBroker.getState()
+		case call := <-q.removeRequestsCalls:
+			call.processed <- q.handleRemoveRequests(queueBroker, call)
 		}
 
 		if needToDispatchQueries {
@@ -217,13 +259,14 @@ func (q *RequestQueue) handleEnqueueRequest(broker *queueBroker, r requestToEnqu
 		return errors.New("no queue found")
 	}
 
-	if queue.Len()+1 > broker.maxUserQueueSize {
+	if queue.len()+1 > broker.maxUserQueueSize {
 		q.discardedRequests.WithLabelValues(string(r.tenantID)).Inc()
 		return ErrTooManyRequests
 	}
 
-	queue.PushBack(r.req)
+	queue.enqueue(r.req, r.queryComponent, r.priority, time.Now())
 	q.queueLength.WithLabelValues(string(r.tenantID)).Inc()
+	q.queueLengthByQueryComponent.WithLabelValues(string(r.tenantID), r.queryComponent).Inc()
 
 	// Call the successFn here to ensure we call it before sending this request to a waiting querier.
 	if r.successFn != nil {
@@ -233,64 +276,159 @@ func (q *RequestQueue) handleEnqueueRequest(broker *queueBroker, r requestToEnqu
 	return nil
 }
 
+func (q *RequestQueue) handleRemoveRequests(broker *queueBroker, call removeRequestsCall) []Request {
+	removed := broker.removeRequests(call.tenantID, call.match)
+
+	reqs := make([]Request, 0, len(removed))
+	for _, queued := range removed {
+		q.queueLength.WithLabelValues(string(call.tenantID)).Dec()
+		q.queueLengthByQueryComponent.WithLabelValues(string(call.tenantID), queued.queryComponent).Dec()
+		reqs = append(reqs, queued.req)
+	}
+	return reqs
+}
+
 // tryDispatchRequest finds and forwards a request to a waiting GetNextRequestForQuerier call, if a suitable request is available.
 // Returns true if call should be removed from the list of waiting calls (eg. because a request has been forwarded to it), false otherwise.
+//
+// The request is dispatched from the tenant with a starving request or, if none, with the highest pending priority
+// among the tenants handled by the querier. Tenants are looked at in a round-robin fashion, starting after the
+// tenant of the last request dispatched to the querier, so that tenants with the same priority are served fairly.
 func (q *RequestQueue) tryDispatchRequest(broker *queueBroker, call *nextRequestForQuerierCall) bool {
-	queue, tenantID, idx, err := broker.getNextQueueForQuerier(call.lastUserIndex.last, call.querierID)
-	if err != nil {
-		// If this querier has told us it's shutting down, terminate GetNextRequestForQuerier with an error now...
-		call.sendError(err)
-		// ...and remove the waiting GetNextRequestForQuerier call from our list.
-		return true
+	var starvedBefore time.Time
+	if q.priorityStarvationTimeout > 0 {
+		starvedBefore = time.Now().Add(-q.priorityStarvationTimeout)
 	}
+	allowUnprioritized := q.canDispatchUnprioritizedRequest()
+
+	var (
+		next         *userQueue
+		nextTenantID TenantID
+		nextIdx      int
+		nextPriority int
+	)
+
+	lastUserIndex := call.lastUserIndex.last
+	firstIdx := -1
+	for attempt := 0; ; attempt++ {
+		queue, tenantID, idx, err := broker.getNextQueueForQuerier(lastUserIndex, call.querierID)
+		if err != nil {
+			// If this querier has told us it's shutting down, terminate GetNextRequestForQuerier with an error now...
+			call.sendError(err)
+			// ...and remove the waiting GetNextRequestForQuerier call from our list.
+			return true
+		}
 
-	call.lastUserIndex.last = idx
-	if queue == nil {
-		// Nothing available for this querier, try again next time.
+		if queue == nil || idx == firstIdx {
+			// No more tenants available for this querier.
+			break
+		}
+		if firstIdx < 0 {
+			firstIdx = idx
+		}
+		lastUserIndex = idx
+
+		if priority, starving, ok := queue.nextPriority(starvedBefore, allowUnprioritized); ok {
+			if starving {
+				// Starving requests are dispatched first, whatever the pending priorities of the other tenants.
+				next, nextTenantID, nextIdx = queue, tenantID, idx
+				break
+			}
+			if next == nil || priority > nextPriority {
+				next, nextTenantID, nextIdx, nextPriority = queue, tenantID, idx, priority
+			}
+		}
+
+		if attempt+1 >= broker.len() {
+			break
+		}
+	}
+
+	if next == nil {
+		// No request can be dispatched right now, try again next time.
 		return false
 	}
 
-	// Pick next request from the queue. The queue is guaranteed not to be empty because we remove empty broker.
-	queueElement := queue.Front()
+	call.lastUserIndex.last = nextIdx
+	q.dispatchRequest(broker, call, next, nextTenantID, next.front(starvedBefore, allowUnprioritized))
+	return true
+}
+
+// dispatchRequest forwards the request in queueElement to the waiting GetNextRequestForQuerier call, and removes
+// it from the queue if the call received it.
+func (q *RequestQueue) dispatchRequest(broker *queueBroker, call *nextRequestForQuerierCall, queue *userQueue, tenantID TenantID, queueElement *list.Element) {
+	queued := queueElement.Value.(*queuedRequest)
+	unprioritized := queued.priority <= 0
 
 	requestSent := call.send(nextRequestForQuerier{
-		req:           queueElement.Value,
-		lastUserIndex: call.lastUserIndex,
+		req:           queued.req,
+		lastUserIndex: UserIndex{last: call.lastUserIndex.last, unprioritizedRequest: unprioritized},
 		err:           nil,
 	})
 
 	if requestSent {
 		// If GetNextRequestForQuerier received the request, remove it from the queue.
 		// (GetNextRequestForQuerier might have already returned if its context was cancelled.)
-		queue.Remove(queueElement)
+		queue.remove(queueElement)
 
-		if queue.Len() == 0 {
+		if queue.len() == 0 {
 			broker.deleteQueue(tenantID)
 		}
 
+		if unprioritized {
+			q.unprioritizedRequestsInFlight++
+		}
+
 		q.queueLength.WithLabelValues(string(tenantID)).Dec()
+		q.queueLengthByQueryComponent.WithLabelValues(string(tenantID), queued.queryComponent).Dec()
+	}
+}
+
+// canDispatchUnprioritizedRequest returns whether an unprioritized request can be dispatched without using the
+// querier workers reserved to prioritized requests.
+func (q *RequestQueue) canDispatchUnprioritizedRequest() bool {
+	if q.reservedQuerierWorkersPercentage <= 0 {
+		return true
 	}
 
+	connected := int(q.connectedQuerierWorkers.Load())
+	reserved := int(float64(connected) * q.reservedQuerierWorkersPercentage / 100)
+	return q.unprioritizedRequestsInFlight < connected-reserved
+}
+
+// releaseQuerierWorker releases the querier worker capacity held by the last unprioritized request returned to
+// the querier worker, if any. Returns true if some capacity has been released.
+func (q *RequestQueue) releaseQuerierWorker(last *UserIndex) bool {
+	if !last.unprioritizedRequest {
+		return false
+	}
+
+	last.unprioritizedRequest = false
+	q.unprioritizedRequestsInFlight--
 	return true
 }
 
 // EnqueueRequest puts the request into the queue. maxQueries is user-specific value that specifies how many queriers can
 // this user use (zero or negative = all queriers). It is passed to each EnqueueRequest, because it can change
-// between calls.
+// between calls. queryComponent is the component the request is expected to query (eg. ingester or store-gateway):
+// requests of the same tenant are dequeued fairly across query components. It can be empty if unknown. Requests of the
+// same tenant with a higher priority are dequeued first, while priority 0 is used for unprioritized requests.
 //
 // If request is successfully enqueued, successFn is called before any querier can receive the request.
-func (q *RequestQueue) EnqueueRequest(tenantID string, req Request, maxQueriers int, successFn func()) error {
+func (q *RequestQueue) EnqueueRequest(tenantID string, req Request, queryComponent string, priority int, maxQueriers int, successFn func()) error {
 	start := time.Now()
 	defer func() {
 		q.enqueueDuration.Observe(time.Since(start).Seconds())
 	}()
 
 	r := requestToEnqueue{
-		tenantID:    TenantID(tenantID),
-		req:         req,
-		maxQueriers: maxQueriers,
-		successFn:   successFn,
-		processed:   make(chan error),
+		tenantID:       TenantID(tenantID),
+		req:            req,
+		queryComponent: queryComponent,
+		priority:       priority,
+		maxQueriers:    maxQueriers,
+		successFn:      successFn,
+		processed:      make(chan error),
 	}
 
 	select {
@@ -304,6 +442,7 @@ func (q *RequestQueue) EnqueueRequest(tenantID string, req Request, maxQueriers
 // GetNextRequestForQuerier find next user queue and takes the next request off of it. Will block if there are no requests.
 // By passing user index from previous call of this method, querier guarantees that it iterates over all users fairly.
 // If querier finds that request from the user is already expired, it can get a request for the same user by using UserIndex.ReuseLastUser.
+// The returned UserIndex must be passed to UnregisterQuerierConnection when the querier worker disconnects, even if an error is returned.
 func (q *RequestQueue) GetNextRequestForQuerier(ctx context.Context, last UserIndex, querierID string) (Request, UserIndex, error) {
 	call := &nextRequestForQuerierCall{
 		ctx:           ctx,
@@ -314,7 +453,10 @@ func (q *RequestQueue) GetNextRequestForQuerier(ctx context.Context, last UserIn
 
 	select {
 	case q.nextRequestForQuerierCalls <- call:
-		// The dispatcher now knows we're waiting. Either we'll get a request to send to a querier, or we'll cancel.
+		// The dispatcher now knows we're waiting, and has released the capacity held by the previous request.
+		// Either we'll get a request to send to a querier, or we'll cancel.
+		last.unprioritizedRequest = false
+
 		select {
 		case result := <-call.processed:
 			return result.req, result.lastUserIndex, result.err
@@ -328,6 +470,39 @@ func (q *RequestQueue) GetNextRequestForQuerier(ctx context.Context, last UserIn
 	}
 }
 
+// GetQueueState returns a snapshot of the pending requests of each tenant, sorted by tenant ID.
+func (q *RequestQueue) GetQueueState(ctx context.Context) ([]TenantQueueState, error) {
+	processed := make(chan []TenantQueueState, 1)
+
+	select {
+	case q.queueStateCalls <- processed:
+		return <-processed, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-q.stopCompleted:
+		return nil, ErrStopped
+	}
+}
+
+// RemoveRequests removes the pending requests of the tenant for which match returns true, and returns them.
+// Removed requests will not be dispatched to queriers, so it's the caller's responsibility to notify their senders.
+func (q *RequestQueue) RemoveRequests(ctx context.Context, tenantID string, match func(Request) bool) ([]Request, error) {
+	call := removeRequestsCall{
+		tenantID:  TenantID(tenantID),
+		match:     match,
+		processed: make(chan []Request, 1),
+	}
+
+	select {
+	case q.removeRequestsCalls <- call:
+		return <-call.processed, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-q.stopCompleted:
+		return nil, ErrStopped
+	}
+}
+
 func (q *RequestQueue) stop(_ error) error {
 	q.stopRequested <- struct{}{} // Why not close the channel? We only want to trigger dispatcherLoop() once.
 	<-q.stopCompleted
@@ -336,27 +511,30 @@ func (q *RequestQueue) stop(_ error) error {
 }
 
 func (q *RequestQueue) forgetDisconnectedQueriers(_ context.Context) error {
-	q.runQuerierOperation("", forgetDisconnected)
+	q.runQuerierOperation("", forgetDisconnected, UserIndex{})
 
 	return nil
 }
 
 func (q *RequestQueue) RegisterQuerierConnection(querierID string) {
-	q.runQuerierOperation(querierID, registerConnection)
+	q.runQuerierOperation(querierID, registerConnection, UserIndex{})
 }
 
-func (q *RequestQueue) UnregisterQuerierConnection(querierID string) {
-	q.runQuerierOperation(querierID, unregisterConnection)
+// UnregisterQuerierConnection unregisters a querier worker connection. last is the UserIndex returned by the
+// last GetNextRequestForQuerier call of the connection, used to release the capacity held by its last request.
+func (q *RequestQueue) UnregisterQuerierConnection(querierID string, last UserIndex) {
+	q.runQuerierOperation(querierID, unregisterConnection, last)
 }
 
 func (q *RequestQueue) NotifyQuerierShutdown(querierID string) {
-	q.runQuerierOperation(querierID, notifyShutdown)
+	q.runQuerierOperation(querierID, notifyShutdown, UserIndex{})
 }
 
-func (q *RequestQueue) runQuerierOperation(querierID string, operation querierOperationType) {
+func (q *RequestQueue) runQuerierOperation(querierID string, operation querierOperationType, lastUserIndex UserIndex) {
 	op := querierOperation{
-		querierID: QuerierID(querierID),
-		operation: operation,
+		querierID:     QuerierID(querierID),
+		operation:     operation,
+		lastUserIndex: lastUserIndex,
 	}
 
 	select {
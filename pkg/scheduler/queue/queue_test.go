@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/dskit/services"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
@@ -39,9 +40,10 @@ func BenchmarkConcurrentQueueOperations(b *testing.B) {
 					for _, numConsumers := range []int{16, 160, 1600} { // Queriers run with parallelism of 16 when query sharding is enabled.
 						b.Run(fmt.Sprintf("%v concurrent consumers", numConsumers), func(b *testing.B) {
 							queueLength := promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"})
+							queueLengthByQueryComponent := promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user", "query_component"})
 							discardedRequests := promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"})
 							enqueueDuration := promauto.With(nil).NewHistogram(prometheus.HistogramOpts{})
-							queue := NewRequestQueue(100, 0, queueLength, discardedRequests, enqueueDuration)
+							queue := NewRequestQueue(100, 0, 0, 0, queueLength, queueLengthByQueryComponent, discardedRequests, enqueueDuration)
 
 							start := make(chan struct{})
 							producersAndConsumers, ctx := errgroup.WithContext(context.Background())
@@ -75,7 +77,7 @@ func BenchmarkConcurrentQueueOperations(b *testing.B) {
 
 								for i := 0; i < requestCount; i++ {
 									for {
-										err := queue.EnqueueRequest(strconv.Itoa(tenantID), req, maxQueriers, func() {})
+										err := queue.EnqueueRequest(strconv.Itoa(tenantID), req, "", 0, maxQueriers, func() {})
 										if err == nil {
 											break
 										}
@@ -104,7 +106,9 @@ func BenchmarkConcurrentQueueOperations(b *testing.B) {
 								lastTenantIndex := FirstUser()
 								querierID := fmt.Sprintf("consumer-%v", consumerIdx)
 								queue.RegisterQuerierConnection(querierID)
-								defer queue.UnregisterQuerierConnection(querierID)
+								defer func() {
+									queue.UnregisterQuerierConnection(querierID, lastTenantIndex)
+								}()
 
 								<-start
 
@@ -144,8 +148,9 @@ func BenchmarkConcurrentQueueOperations(b *testing.B) {
 func TestRequestQueue_GetNextRequestForQuerier_ShouldGetRequestAfterReshardingBecauseQuerierHasBeenForgotten(t *testing.T) {
 	const forgetDelay = 3 * time.Second
 
-	queue := NewRequestQueue(1, forgetDelay,
+	queue := NewRequestQueue(1, forgetDelay, 0, 0,
 		promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"}),
+		promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user", "query_component"}),
 		promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}),
 		promauto.With(nil).NewHistogram(prometheus.HistogramOpts{}))
 
@@ -170,11 +175,11 @@ func TestRequestQueue_GetNextRequestForQuerier_ShouldGetRequestAfterReshardingBe
 	}()
 
 	// Querier-1 crashes (no graceful shutdown notification).
-	queue.UnregisterQuerierConnection("querier-1")
+	queue.UnregisterQuerierConnection("querier-1", FirstUser())
 
 	// Enqueue a request from an user which would be assigned to querier-1.
 	// NOTE: "user-1" hash falls in the querier-1 shard.
-	require.NoError(t, queue.EnqueueRequest("user-1", "request", 1, nil))
+	require.NoError(t, queue.EnqueueRequest("user-1", "request", "", 0, 1, nil))
 
 	startTime := time.Now()
 	querier2wg.Wait()
@@ -188,8 +193,9 @@ func TestRequestQueue_GetNextRequestForQuerier_ShouldReturnAfterContextCancelled
 	const forgetDelay = 3 * time.Second
 	const querierID = "querier-1"
 
-	queue := NewRequestQueue(1, forgetDelay,
+	queue := NewRequestQueue(1, forgetDelay, 0, 0,
 		promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"}),
+		promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user", "query_component"}),
 		promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}),
 		promauto.With(nil).NewHistogram(prometheus.HistogramOpts{}))
 
@@ -222,8 +228,9 @@ func TestRequestQueue_GetNextRequestForQuerier_ShouldReturnImmediatelyIfQuerierI
 	const forgetDelay = 3 * time.Second
 	const querierID = "querier-1"
 
-	queue := NewRequestQueue(1, forgetDelay,
+	queue := NewRequestQueue(1, forgetDelay, 0, 0,
 		promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"}),
+		promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user", "query_component"}),
 		promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}),
 		promauto.With(nil).NewHistogram(prometheus.HistogramOpts{}))
 
@@ -239,3 +246,197 @@ func TestRequestQueue_GetNextRequestForQuerier_ShouldReturnImmediatelyIfQuerierI
 	_, _, err := queue.GetNextRequestForQuerier(context.Background(), FirstUser(), querierID)
 	require.EqualError(t, err, "querier has informed the scheduler it is shutting down")
 }
+
+func TestRequestQueue_GetNextRequestForQuerier_ShouldReserveQuerierWorkersToPrioritizedRequests(t *testing.T) {
+	const querierID = "querier-1"
+
+	queue := NewRequestQueue(100, 0, 50, 0,
+		promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"}),
+		promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user", "query_component"}),
+		promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}),
+		promauto.With(nil).NewHistogram(prometheus.HistogramOpts{}))
+
+	ctx := context.Background()
+	require.NoError(t, services.StartAndAwaitRunning(ctx, queue))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, queue))
+	})
+
+	// Two querier workers, one of them reserved to prioritized requests.
+	queue.RegisterQuerierConnection(querierID)
+	queue.RegisterQuerierConnection(querierID)
+
+	require.NoError(t, queue.EnqueueRequest("user-1", "unprioritized-0", "", 0, 0, nil))
+	require.NoError(t, queue.EnqueueRequest("user-2", "unprioritized-1", "", 0, 0, nil))
+
+	req, firstWorkerIdx, err := queue.GetNextRequestForQuerier(ctx, FirstUser(), querierID)
+	require.NoError(t, err)
+	assert.Equal(t, "unprioritized-0", req)
+
+	// The second querier worker is reserved to prioritized requests.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, _, err = queue.GetNextRequestForQuerier(timeoutCtx, FirstUser(), querierID)
+	require.Equal(t, context.DeadlineExceeded, err)
+
+	require.NoError(t, queue.EnqueueRequest("user-2", "prioritized-0", "", 1, 0, nil))
+	req, secondWorkerIdx, err := queue.GetNextRequestForQuerier(ctx, FirstUser(), querierID)
+	require.NoError(t, err)
+	assert.Equal(t, "prioritized-0", req)
+
+	// Once the first querier worker is done with the unprioritized request, it can get the next one.
+	req, firstWorkerIdx, err = queue.GetNextRequestForQuerier(ctx, firstWorkerIdx, querierID)
+	require.NoError(t, err)
+	assert.Equal(t, "unprioritized-1", req)
+
+	queue.UnregisterQuerierConnection(querierID, firstWorkerIdx)
+	queue.UnregisterQuerierConnection(querierID, secondWorkerIdx)
+}
+
+func TestRequestQueue_GetNextRequestForQuerier_ShouldPickTheTenantWithTheHighestPendingPriority(t *testing.T) {
+	const querierID = "querier-1"
+
+	startQueue := func(t *testing.T, priorityStarvationTimeout time.Duration) *RequestQueue {
+		queue := NewRequestQueue(100, 0, 0, priorityStarvationTimeout,
+			promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"}),
+			promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user", "query_component"}),
+			promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}),
+			promauto.With(nil).NewHistogram(prometheus.HistogramOpts{}))
+
+		require.NoError(t, services.StartAndAwaitRunning(context.Background(), queue))
+		t.Cleanup(func() {
+			require.NoError(t, services.StopAndAwaitTerminated(context.Background(), queue))
+		})
+
+		queue.RegisterQuerierConnection(querierID)
+		t.Cleanup(func() {
+			queue.UnregisterQuerierConnection(querierID, FirstUser())
+		})
+		return queue
+	}
+
+	t.Run("tenants with the same priority are picked in a round-robin fashion", func(t *testing.T) {
+		queue := startQueue(t, 0)
+
+		require.NoError(t, queue.EnqueueRequest("user-1", "user-1-low-0", "", 1, 0, nil))
+		require.NoError(t, queue.EnqueueRequest("user-1", "user-1-low-1", "", 1, 0, nil))
+		require.NoError(t, queue.EnqueueRequest("user-2", "user-2-high-0", "", 2, 0, nil))
+		require.NoError(t, queue.EnqueueRequest("user-2", "user-2-high-1", "", 2, 0, nil))
+		require.NoError(t, queue.EnqueueRequest("user-3", "user-3-high-0", "", 2, 0, nil))
+
+		var actual []Request
+		lastUserIndex := FirstUser()
+		for i := 0; i < 5; i++ {
+			req, idx, err := queue.GetNextRequestForQuerier(context.Background(), lastUserIndex, querierID)
+			require.NoError(t, err)
+			actual = append(actual, req)
+			lastUserIndex = idx
+		}
+
+		assert.Equal(t, []Request{"user-2-high-0", "user-3-high-0", "user-2-high-1", "user-1-low-0", "user-1-low-1"}, actual)
+	})
+
+	t.Run("starving requests are picked before the higher priorities of other tenants", func(t *testing.T) {
+		queue := startQueue(t, 50*time.Millisecond)
+
+		require.NoError(t, queue.EnqueueRequest("user-1", "user-1-low-0", "", 1, 0, nil))
+		time.Sleep(100 * time.Millisecond)
+		require.NoError(t, queue.EnqueueRequest("user-2", "user-2-high-0", "", 2, 0, nil))
+
+		req, lastUserIndex, err := queue.GetNextRequestForQuerier(context.Background(), FirstUser(), querierID)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1-low-0", req)
+
+		req, _, err = queue.GetNextRequestForQuerier(context.Background(), lastUserIndex, querierID)
+		require.NoError(t, err)
+		assert.Equal(t, "user-2-high-0", req)
+	})
+}
+
+func TestRequestQueue_GetQueueStateAndRemoveRequests(t *testing.T) {
+	queueLength := promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user"})
+	queueLengthByQueryComponent := promauto.With(nil).NewGaugeVec(prometheus.GaugeOpts{}, []string{"user", "query_component"})
+
+	queue := NewRequestQueue(100, 0, 0, 0,
+		queueLength,
+		queueLengthByQueryComponent,
+		promauto.With(nil).NewCounterVec(prometheus.CounterOpts{}, []string{"user"}),
+		promauto.With(nil).NewHistogram(prometheus.HistogramOpts{}))
+
+	ctx := context.Background()
+	require.NoError(t, services.StartAndAwaitRunning(ctx, queue))
+	t.Cleanup(func() {
+		require.NoError(t, services.StopAndAwaitTerminated(ctx, queue))
+	})
+
+	queue.RegisterQuerierConnection("querier-1")
+	queue.RegisterQuerierConnection("querier-2")
+
+	require.NoError(t, queue.EnqueueRequest("user-2", "request-0", QueryComponentIngester, 0, 0, nil))
+	require.NoError(t, queue.EnqueueRequest("user-1", "request-1", QueryComponentStoreGateway, 1, 1, nil))
+	require.NoError(t, queue.EnqueueRequest("user-1", "request-2", QueryComponentIngester, 0, 1, nil))
+	require.NoError(t, queue.EnqueueRequest("user-1", "request-3", QueryComponentStoreGateway, 0, 1, nil))
+
+	state, err := queue.GetQueueState(ctx)
+	require.NoError(t, err)
+	require.Len(t, state, 2)
+
+	// Tenants are sorted by ID, and requests by enqueue time.
+	assert.Equal(t, "user-1", state[0].TenantID)
+	assert.Len(t, state[0].Queriers, 1)
+	require.Len(t, state[0].Requests, 3)
+	for i, expected := range []QueuedRequestState{
+		{Request: "request-1", QueryComponent: QueryComponentStoreGateway, Priority: 1},
+		{Request: "request-2", QueryComponent: QueryComponentIngester, Priority: 0},
+		{Request: "request-3", QueryComponent: QueryComponentStoreGateway, Priority: 0},
+	} {
+		actual := state[0].Requests[i]
+		assert.Equal(t, expected.Request, actual.Request)
+		assert.Equal(t, expected.QueryComponent, actual.QueryComponent)
+		assert.Equal(t, expected.Priority, actual.Priority)
+		assert.False(t, actual.EnqueuedAt.IsZero())
+	}
+
+	// The tenant with shuffle sharding disabled can use all queriers.
+	assert.Equal(t, "user-2", state[1].TenantID)
+	assert.Nil(t, state[1].Queriers)
+	require.Len(t, state[1].Requests, 1)
+
+	// Remove a single request.
+	removed, err := queue.RemoveRequests(ctx, "user-1", func(req Request) bool { return req == "request-3" })
+	require.NoError(t, err)
+	assert.Equal(t, []Request{"request-3"}, removed)
+	assert.Equal(t, 2.0, testutil.ToFloat64(queueLength.WithLabelValues("user-1")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(queueLengthByQueryComponent.WithLabelValues("user-1", QueryComponentStoreGateway)))
+
+	// Removing requests of an unknown tenant is a no-op.
+	removed, err = queue.RemoveRequests(ctx, "user-3", func(Request) bool { return true })
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+
+	// Flush the tenant queue.
+	removed, err = queue.RemoveRequests(ctx, "user-2", func(Request) bool { return true })
+	require.NoError(t, err)
+	assert.Equal(t, []Request{"request-0"}, removed)
+	assert.Equal(t, 0.0, testutil.ToFloat64(queueLength.WithLabelValues("user-2")))
+
+	state, err = queue.GetQueueState(ctx)
+	require.NoError(t, err)
+	require.Len(t, state, 1)
+	assert.Equal(t, "user-1", state[0].TenantID)
+
+	// Remaining requests are still dispatched to queriers.
+	assignedQuerierID := state[0].Queriers[0]
+	req, lastUserIndex, err := queue.GetNextRequestForQuerier(ctx, FirstUser(), assignedQuerierID)
+	require.NoError(t, err)
+	assert.Equal(t, "request-1", req)
+
+	// Disconnect queriers, so that the queue can be stopped with pending requests.
+	for _, querierID := range []string{"querier-1", "querier-2"} {
+		if querierID == assignedQuerierID {
+			queue.UnregisterQuerierConnection(querierID, lastUserIndex)
+		} else {
+			queue.UnregisterQuerierConnection(querierID, FirstUser())
+		}
+	}
+}
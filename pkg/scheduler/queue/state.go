@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package queue
+
+import (
+	"container/list"
+	"sort"
+	"time"
+)
+
+// TenantQueueState is a snapshot of the pending requests of a tenant.
+type TenantQueueState struct {
+	TenantID string
+
+	// Queriers the tenant has been assigned to by shuffle sharding, sorted by ID.
+	// Nil if the tenant can be handled by all queriers.
+	Queriers []string
+
+	// Pending requests, sorted by enqueue time.
+	Requests []QueuedRequestState
+}
+
+// QueuedRequestState is a snapshot of a pending request.
+type QueuedRequestState struct {
+	Request        Request
+	QueryComponent string
+	Priority       int
+	EnqueuedAt     time.Time
+}
+
+// elements returns all the requests in the queue, in no particular order.
+func (q *userQueue) elements() []*list.Element {
+	elems := make([]*list.Element, 0, q.length)
+	for _, priorityQueue := range q.priorityQueues {
+		for _, componentQueue := range priorityQueue.queues {
+			for elem := componentQueue.Front(); elem != nil; elem = elem.Next() {
+				elems = append(elems, elem)
+			}
+		}
+	}
+	return elems
+}
+
+// getState returns a snapshot of the pending requests of each tenant, sorted by tenant ID.
+func (qb *queueBroker) getState() []TenantQueueState {
+	state := make([]TenantQueueState, 0, len(qb.tenantQueues))
+
+	for tenantID, queue := range qb.tenantQueues {
+		tenantState := TenantQueueState{
+			TenantID: string(tenantID),
+			Requests: make([]QueuedRequestState, 0, queue.len()),
+		}
+
+		if querierIDs := qb.tenantQuerierAssignments.tenantQuerierIDs[tenantID]; querierIDs != nil {
+			tenantState.Queriers = make([]string, 0, len(querierIDs))
+			for querierID := range querierIDs {
+				tenantState.Queriers = append(tenantState.Queriers, string(querierID))
+			}
+			sort.Strings(tenantState.Queriers)
+		}
+
+		for _, elem := range queue.elements() {
+			queued := elem.Value.(*queuedRequest)
+			tenantState.Requests = append(tenantState.Requests, QueuedRequestState{
+				Request:        queued.req,
+				QueryComponent: queued.queryComponent,
+				Priority:       queued.priority,
+				EnqueuedAt:     queued.enqueuedAt,
+			})
+		}
+		sort.SliceStable(tenantState.Requests, func(i, j int) bool {
+			return tenantState.Requests[i].EnqueuedAt.Before(tenantState.Requests[j].EnqueuedAt)
+		})
+
+		state = append(state, tenantState)
+	}
+
+	sort.Slice(state, func(i, j int) bool { return state[i].TenantID < state[j].TenantID })
+	return state
+}
+
+// removeRequests removes the pending requests of the tenant for which match returns true, and returns them.
+// The tenant queue is deleted if it's left empty.
+func (qb *queueBroker) removeRequests(tenantID TenantID, match func(Request) bool) []*queuedRequest {
+	queue := qb.tenantQueues[tenantID]
+	if queue == nil {
+		return nil
+	}
+
+	var removed []*queuedRequest
+	for _, elem := range queue.elements() {
+		queued := elem.Value.(*queuedRequest)
+		if !match(queued.req) {
+			continue
+		}
+
+		queue.remove(elem)
+		removed = append(removed, queued)
+	}
+
+	if queue.len() == 0 {
+		qb.deleteQueue(tenantID)
+	}
+
+	return removed
+}
@@ -102,8 +102,196 @@ type queueBroker struct {
 	maxUserQueueSize int
 }
 
+// userQueue holds the pending requests of a tenant. Requests are split by priority, and requests with a higher
+// priority are dequeued first, unless a lower priority request has been waiting for too long. Within the same
+// priority, requests are dequeued fairly across query components.
 type userQueue struct {
-	requests *list.List
+	priorityQueues map[int]*componentQueues
+
+	// Priorities with pending requests, sorted from the highest to the lowest.
+	priorities []int
+
+	// Total number of pending requests, across all priorities.
+	length int
+}
+
+// queuedRequest is the value of the elements stored in a userQueue.
+type queuedRequest struct {
+	req            Request
+	queryComponent string
+	priority       int
+	enqueuedAt     time.Time
+}
+
+func newUserQueue() *userQueue {
+	return &userQueue{
+		priorityQueues: map[int]*componentQueues{},
+	}
+}
+
+func (q *userQueue) len() int {
+	return q.length
+}
+
+// enqueue adds the request to the sub-queue of the input priority and query component.
+func (q *userQueue) enqueue(req Request, queryComponent string, priority int, now time.Time) {
+	priorityQueue := q.priorityQueues[priority]
+	if priorityQueue == nil {
+		priorityQueue = newComponentQueues()
+		q.priorityQueues[priority] = priorityQueue
+
+		ix := sort.Search(len(q.priorities), func(i int) bool { return q.priorities[i] < priority })
+		q.priorities = append(q.priorities, 0)
+		copy(q.priorities[ix+1:], q.priorities[ix:])
+		q.priorities[ix] = priority
+	}
+
+	priorityQueue.enqueue(&queuedRequest{req: req, queryComponent: queryComponent, priority: priority, enqueuedAt: now})
+	q.length++
+}
+
+// front returns the next request to dequeue, without removing it from the queue. Requests are dequeued from
+// the priority returned by nextPriority. Within the chosen priority, requests are dequeued in a round-robin fashion
+// across query components. Returns nil if there's no request to dequeue.
+func (q *userQueue) front(starvedBefore time.Time, allowUnprioritized bool) *list.Element {
+	priority, _, ok := q.nextPriority(starvedBefore, allowUnprioritized)
+	if !ok {
+		return nil
+	}
+
+	return q.priorityQueues[priority].front()
+}
+
+// nextPriority returns the priority to dequeue the next request from, and whether its requests are starving.
+// Requests are dequeued from the highest priority, unless a lower priority has a request enqueued before
+// starvedBefore, in which case the lowest starving priority is dequeued first, to protect lower priorities from
+// starvation. Unprioritized requests (priority 0) are returned only if allowUnprioritized is true or they're
+// starving. Returns false if there's no request to dequeue.
+func (q *userQueue) nextPriority(starvedBefore time.Time, allowUnprioritized bool) (priority int, starving bool, ok bool) {
+	if q.length == 0 {
+		return 0, false, false
+	}
+
+	priority = q.priorities[0]
+	if !starvedBefore.IsZero() {
+		// Look for starving requests from the lowest priority, which is the most likely to starve.
+		for i := len(q.priorities) - 1; i >= 0; i-- {
+			if q.priorityQueues[q.priorities[i]].oldest().Value.(*queuedRequest).enqueuedAt.Before(starvedBefore) {
+				priority = q.priorities[i]
+				starving = true
+				break
+			}
+		}
+	}
+
+	if priority <= 0 && !starving && !allowUnprioritized {
+		return 0, false, false
+	}
+
+	return priority, starving, true
+}
+
+// remove removes the request, previously returned by front, from the queue.
+func (q *userQueue) remove(elem *list.Element) {
+	priority := elem.Value.(*queuedRequest).priority
+	priorityQueue := q.priorityQueues[priority]
+	priorityQueue.remove(elem)
+	q.length--
+
+	if priorityQueue.length == 0 {
+		delete(q.priorityQueues, priority)
+		for i, p := range q.priorities {
+			if p == priority {
+				q.priorities = append(q.priorities[:i], q.priorities[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// componentQueues holds the pending requests of a tenant with the same priority. Requests are split into a
+// sub-queue per query component, and sub-queues are dequeued in a round-robin fashion, so that requests expected
+// to query a slow component (eg. long time range queries hitting the store-gateways) don't starve requests of the
+// same tenant hitting a faster one.
+type componentQueues struct {
+	queues map[string]*list.List
+
+	// Query components with pending requests, in the order they're dequeued.
+	order []string
+
+	// Index in order of the query component to dequeue the next request from. It may be out of range,
+	// in which case the dequeueing wraps around to the first query component.
+	nextIndex int
+
+	// Total number of pending requests, across all query components.
+	length int
+}
+
+func newComponentQueues() *componentQueues {
+	return &componentQueues{
+		queues: map[string]*list.List{},
+	}
+}
+
+func (q *componentQueues) enqueue(req *queuedRequest) {
+	componentQueue := q.queues[req.queryComponent]
+	if componentQueue == nil {
+		componentQueue = list.New()
+		q.queues[req.queryComponent] = componentQueue
+		q.order = append(q.order, req.queryComponent)
+	}
+
+	componentQueue.PushBack(req)
+	q.length++
+}
+
+// front returns the next request to dequeue. Must not be called on empty queues.
+func (q *componentQueues) front() *list.Element {
+	// Wrap around lazily, so that a query component added after the last one has been dequeued isn't skipped.
+	if q.nextIndex >= len(q.order) {
+		q.nextIndex = 0
+	}
+
+	return q.queues[q.order[q.nextIndex]].Front()
+}
+
+// oldest returns the request enqueued first, across all query components. Must not be called on empty queues.
+func (q *componentQueues) oldest() *list.Element {
+	var oldest *list.Element
+	for _, component := range q.order {
+		elem := q.queues[component].Front()
+		if oldest == nil || elem.Value.(*queuedRequest).enqueuedAt.Before(oldest.Value.(*queuedRequest).enqueuedAt) {
+			oldest = elem
+		}
+	}
+	return oldest
+}
+
+// remove removes the request, previously returned by front, and moves to the next query component.
+func (q *componentQueues) remove(elem *list.Element) {
+	queryComponent := elem.Value.(*queuedRequest).queryComponent
+	componentQueue := q.queues[queryComponent]
+	componentQueue.Remove(elem)
+	q.length--
+
+	componentIndex := 0
+	for i, c := range q.order {
+		if c == queryComponent {
+			componentIndex = i
+			break
+		}
+	}
+
+	if componentQueue.Len() == 0 {
+		// Remove the empty sub-queue. The next query component, if any, is shifted back by one position.
+		delete(q.queues, queryComponent)
+		q.order = append(q.order[:componentIndex], q.order[componentIndex+1:]...)
+		if componentIndex < q.nextIndex {
+			q.nextIndex--
+		}
+	} else if componentIndex == q.nextIndex {
+		q.nextIndex++
+	}
 }
 
 func newQueueBroker(maxUserQueueSize int, forgetDelay time.Duration) *queueBroker {
@@ -129,7 +317,7 @@ func (qb *queueBroker) len() int {
 // MaxQueriers is used to compute which queriers should handle requests for this user.
 // If maxQueriers is <= 0, all queriers can handle this user's requests.
 // If maxQueriers has changed since the last call, queriers for this are recomputed.
-func (qb *queueBroker) getOrAddTenantQueue(tenantID TenantID, maxQueriers int) *list.List {
+func (qb *queueBroker) getOrAddTenantQueue(tenantID TenantID, maxQueriers int) *userQueue {
 	tenant := qb.tenantQuerierAssignments.getOrAddTenant(tenantID, maxQueriers)
 	if tenant == nil {
 		return nil
@@ -137,13 +325,11 @@ func (qb *queueBroker) getOrAddTenantQueue(tenantID TenantID, maxQueriers int) *
 	queue := qb.tenantQueues[tenantID]
 
 	if queue == nil {
-		queue = &userQueue{
-			requests: list.New(),
-		}
+		queue = newUserQueue()
 		qb.tenantQueues[tenantID] = queue
 	}
 
-	return queue.requests
+	return queue
 }
 
 // Finds next queue for the querier. To support fair scheduling between users, client is expected
@@ -151,14 +337,14 @@ func (qb *queueBroker) getOrAddTenantQueue(tenantID TenantID, maxQueriers int) *
 // last user index, use -1.
 //
 // getNextQueueForQuerier returns an error if the querier has already notified this scheduler that it is shutting down.
-func (qb *queueBroker) getNextQueueForQuerier(lastUserIndex int, querierID QuerierID) (*list.List, TenantID, int, error) {
+func (qb *queueBroker) getNextQueueForQuerier(lastUserIndex int, querierID QuerierID) (*userQueue, TenantID, int, error) {
 	nextTenantID, nextTenantIndex, err := qb.tenantQuerierAssignments.getNextTenantIDForQuerier(lastUserIndex, querierID)
 	if err != nil || nextTenantID == emptyTenantID {
 		return nil, nextTenantID, nextTenantIndex, err
 	}
 
 	tenantQueue := qb.tenantQueues[nextTenantID]
-	return tenantQueue.requests, nextTenantID, nextTenantIndex, nil
+	return tenantQueue, nextTenantID, nextTenantIndex, nil
 }
 
 func (qb *queueBroker) addQuerierConnection(querierID QuerierID) {
@@ -6,7 +6,6 @@
 package queue
 
 import (
-	"container/list"
 	"fmt"
 	"math"
 	"math/rand"
@@ -404,20 +403,20 @@ func generateQuerier(r *rand.Rand) QuerierID {
 	return QuerierID(fmt.Sprint("querier-", r.Int()%5))
 }
 
-func getOrAdd(t *testing.T, qb *queueBroker, tenantID TenantID, maxQueriers int) *list.List {
+func getOrAdd(t *testing.T, qb *queueBroker, tenantID TenantID, maxQueriers int) *userQueue {
 	q := qb.getOrAddTenantQueue(tenantID, maxQueriers)
 	assert.NotNil(t, q)
 	assert.NoError(t, isConsistent(qb))
-	assert.Equal(t, q, qb.getOrAddTenantQueue(tenantID, maxQueriers))
+	assert.Same(t, q, qb.getOrAddTenantQueue(tenantID, maxQueriers))
 	return q
 }
 
-func confirmOrderForQuerier(t *testing.T, qb *queueBroker, querier QuerierID, lastUserIndex int, qs ...*list.List) int {
-	var n *list.List
+func confirmOrderForQuerier(t *testing.T, qb *queueBroker, querier QuerierID, lastUserIndex int, qs ...*userQueue) int {
+	var n *userQueue
 	for _, q := range qs {
 		var err error
 		n, _, lastUserIndex, err = qb.getNextQueueForQuerier(lastUserIndex, querier)
-		assert.Equal(t, q, n)
+		assert.Same(t, q, n)
 		assert.NoError(t, isConsistent(qb))
 		assert.NoError(t, err)
 	}
@@ -572,3 +571,139 @@ func TestShuffleQueriersCorrectness(t *testing.T) {
 		}
 	}
 }
+
+func TestUserQueue_ShouldDequeueFairlyAcrossQueryComponents(t *testing.T) {
+	q := newUserQueue()
+	now := time.Now()
+
+	// Enqueue a burst of store-gateway requests, followed by a few ingester ones.
+	for i := 0; i < 4; i++ {
+		q.enqueue(fmt.Sprintf("store-gateway-%d", i), "store-gateway", 0, now)
+	}
+	q.enqueue("ingester-0", "ingester", 0, now)
+	q.enqueue("ingester-1", "ingester", 0, now)
+	q.enqueue("unknown-0", "", 0, now)
+	require.Equal(t, 7, q.len())
+
+	assert.Equal(t, []string{
+		"store-gateway-0", "ingester-0", "unknown-0",
+		"store-gateway-1", "ingester-1",
+		"store-gateway-2",
+		"store-gateway-3",
+	}, dequeueAll(t, q, time.Time{}))
+
+	assert.Nil(t, q.front(time.Time{}, true))
+	assert.Empty(t, q.priorityQueues)
+	assert.Empty(t, q.priorities)
+}
+
+func TestUserQueue_ShouldNotSkipQueryComponentsWhenAddedWhileDequeueing(t *testing.T) {
+	q := newUserQueue()
+	now := time.Now()
+	q.enqueue("store-gateway-0", "store-gateway", 0, now)
+	q.enqueue("store-gateway-1", "store-gateway", 0, now)
+
+	assert.Equal(t, "store-gateway-0", dequeueOne(t, q, time.Time{}))
+
+	// An ingester request arriving after a store-gateway one has been dequeued should be picked up next.
+	q.enqueue("ingester-0", "ingester", 0, now)
+
+	assert.Equal(t, "ingester-0", dequeueOne(t, q, time.Time{}))
+	assert.Equal(t, "store-gateway-1", dequeueOne(t, q, time.Time{}))
+	assert.Equal(t, 0, q.len())
+}
+
+func TestUserQueue_ShouldDequeueHigherPrioritiesFirst(t *testing.T) {
+	q := newUserQueue()
+	now := time.Now()
+
+	q.enqueue("unprioritized-0", "ingester", 0, now)
+	q.enqueue("low-0", "store-gateway", 1, now)
+	q.enqueue("high-0", "store-gateway", 2, now)
+	q.enqueue("unprioritized-1", "store-gateway", 0, now)
+	q.enqueue("high-1", "ingester", 2, now)
+	q.enqueue("low-1", "store-gateway", 1, now)
+	assert.Equal(t, []int{2, 1, 0}, q.priorities)
+
+	assert.Equal(t, []string{
+		"high-0", "high-1",
+		"low-0", "low-1",
+		"unprioritized-0", "unprioritized-1",
+	}, dequeueAll(t, q, time.Time{}))
+}
+
+func TestUserQueue_ShouldDequeueStarvingRequestsFirst(t *testing.T) {
+	q := newUserQueue()
+	now := time.Now()
+
+	q.enqueue("unprioritized-0", "ingester", 0, now.Add(-2*time.Minute))
+	q.enqueue("unprioritized-1", "ingester", 0, now)
+	q.enqueue("low-0", "ingester", 1, now.Add(-time.Minute))
+	q.enqueue("high-0", "ingester", 2, now)
+
+	// Requests enqueued before the starvation threshold are dequeued first, starting from the lowest priority.
+	assert.Equal(t, "unprioritized-0", dequeueOne(t, q, now.Add(-30*time.Second)))
+	assert.Equal(t, "low-0", dequeueOne(t, q, now.Add(-30*time.Second)))
+	assert.Equal(t, "high-0", dequeueOne(t, q, now.Add(-30*time.Second)))
+	assert.Equal(t, "unprioritized-1", dequeueOne(t, q, now.Add(-30*time.Second)))
+}
+
+func TestUserQueue_ShouldKeepDequeueingStarvingPrioritiesFairlyAcrossQueryComponents(t *testing.T) {
+	q := newUserQueue()
+	now := time.Now()
+	starvedBefore := now.Add(-30 * time.Second)
+
+	q.enqueue("high-store-gateway-0", "store-gateway", 2, now.Add(-2*time.Minute))
+	q.enqueue("high-store-gateway-1", "store-gateway", 2, now.Add(-2*time.Minute))
+	q.enqueue("high-ingester-0", "ingester", 2, now)
+	q.enqueue("low-store-gateway-0", "store-gateway", 1, now.Add(-time.Minute))
+	q.enqueue("low-store-gateway-1", "store-gateway", 1, now.Add(-time.Minute))
+	q.enqueue("low-ingester-0", "ingester", 1, now)
+
+	// The starving lower priority is dequeued first, but still in a round-robin fashion across query components.
+	assert.Equal(t, "low-store-gateway-0", dequeueOne(t, q, starvedBefore))
+	assert.Equal(t, "low-ingester-0", dequeueOne(t, q, starvedBefore))
+	assert.Equal(t, "low-store-gateway-1", dequeueOne(t, q, starvedBefore))
+
+	// Starving requests of the highest priority don't skip the requests of the other query components.
+	assert.Equal(t, "high-store-gateway-0", dequeueOne(t, q, starvedBefore))
+	assert.Equal(t, "high-ingester-0", dequeueOne(t, q, starvedBefore))
+	assert.Equal(t, "high-store-gateway-1", dequeueOne(t, q, starvedBefore))
+}
+
+func TestUserQueue_ShouldNotReturnUnprioritizedRequestsUnlessAllowedOrStarving(t *testing.T) {
+	q := newUserQueue()
+	now := time.Now()
+
+	q.enqueue("unprioritized-0", "ingester", 0, now.Add(-time.Minute))
+	q.enqueue("unprioritized-1", "ingester", 0, now)
+
+	assert.Nil(t, q.front(time.Time{}, false))
+	assert.Nil(t, q.front(now.Add(-2*time.Minute), false))
+
+	// A starving request is returned even if unprioritized requests are not allowed.
+	elem := q.front(now.Add(-30*time.Second), false)
+	require.NotNil(t, elem)
+	assert.Equal(t, "unprioritized-0", elem.Value.(*queuedRequest).req)
+	q.remove(elem)
+
+	assert.Nil(t, q.front(now.Add(-30*time.Second), false))
+	elem = q.front(now.Add(-30*time.Second), true)
+	require.NotNil(t, elem)
+	assert.Equal(t, "unprioritized-1", elem.Value.(*queuedRequest).req)
+}
+
+func dequeueOne(t *testing.T, q *userQueue, starvedBefore time.Time) string {
+	elem := q.front(starvedBefore, true)
+	require.NotNil(t, elem)
+	q.remove(elem)
+	return elem.Value.(*queuedRequest).req.(string)
+}
+
+func dequeueAll(t *testing.T, q *userQueue, starvedBefore time.Time) []string {
+	var dequeued []string
+	for q.len() > 0 {
+		dequeued = append(dequeued, dequeueOne(t, q, starvedBefore))
+	}
+	return dequeued
+}
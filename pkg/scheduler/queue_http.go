@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"context"
+	_ "embed" // Used to embed html template
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/pkg/errors"
+
+	"github.com/grafana/mimir/pkg/scheduler/queue"
+	"github.com/grafana/mimir/pkg/util"
+)
+
+//go:embed queue_status.gohtml
+var queueStatusPageHTML string
+var queueStatusPageTemplate = template.Must(template.New("queue").Parse(queueStatusPageHTML))
+
+// maxQueueStatusQueryLength is the max length of the query displayed for each queued request.
+const maxQueueStatusQueryLength = 200
+
+var errRequestCancelledByOperator = errors.New("the request has been cancelled by an operator while waiting in the query-scheduler queue")
+
+type queueStatusPageContents struct {
+	Now     time.Time           `json:"now"`
+	Tenants []queueTenantStatus `json:"tenants"`
+}
+
+type queueTenantStatus struct {
+	TenantID          string    `json:"tenantID"`
+	Length            int       `json:"length"`
+	OldestEnqueueTime time.Time `json:"oldestEnqueueTime"`
+
+	// Queriers the tenant has been assigned to by shuffle sharding. Empty if the tenant can use all queriers.
+	Queriers []string `json:"queriers,omitempty"`
+
+	Requests []queuedRequestStatus `json:"requests"`
+}
+
+type queuedRequestStatus struct {
+	QueryID         uint64     `json:"queryID"`
+	FrontendAddress string     `json:"frontendAddress"`
+	Path            string     `json:"path"`
+	Query           string     `json:"query,omitempty"`
+	Start           *time.Time `json:"start,omitempty"`
+	End             *time.Time `json:"end,omitempty"`
+	QueryComponent  string     `json:"queryComponent,omitempty"`
+	Priority        int        `json:"priority"`
+	EnqueueTime     time.Time  `json:"enqueueTime"`
+}
+
+// QueueHandler lists the requests waiting in the queue of each tenant. It also allows to cancel a queued request,
+// identified by the "frontend" and "query_id" form values, or to flush the queue of the tenant in the "tenant" form
+// value, depending on the "action" form value of POST requests.
+func (s *Scheduler) QueueHandler(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodPost {
+		var err error
+
+		switch action := req.FormValue("action"); action {
+		case "cancel":
+			err = s.cancelQueuedRequest(req.Context(), req.FormValue("frontend"), req.FormValue("query_id"))
+		case "flush":
+			err = s.flushTenantQueue(req.Context(), req.FormValue("tenant"))
+		default:
+			err = httpgrpc.Errorf(http.StatusBadRequest, "unknown action %q", action)
+		}
+
+		if err != nil {
+			if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+				http.Error(w, string(resp.Body), int(resp.Code))
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	state, err := s.requestQueue.GetQueueState(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tenants := make([]queueTenantStatus, 0, len(state))
+	for _, tenantState := range state {
+		tenant := queueTenantStatus{
+			TenantID: tenantState.TenantID,
+			Length:   len(tenantState.Requests),
+			Queriers: tenantState.Queriers,
+			Requests: make([]queuedRequestStatus, 0, len(tenantState.Requests)),
+		}
+		if len(tenantState.Requests) > 0 {
+			tenant.OldestEnqueueTime = tenantState.Requests[0].EnqueuedAt
+		}
+
+		for _, queued := range tenantState.Requests {
+			tenant.Requests = append(tenant.Requests, newQueuedRequestStatus(queued))
+		}
+
+		tenants = append(tenants, tenant)
+	}
+
+	util.RenderHTTPResponse(w, queueStatusPageContents{
+		Now:     time.Now(),
+		Tenants: tenants,
+	}, queueStatusPageTemplate, req)
+}
+
+func newQueuedRequestStatus(queued queue.QueuedRequestState) queuedRequestStatus {
+	r := queued.Request.(*schedulerRequest)
+
+	status := queuedRequestStatus{
+		QueryID:         r.queryID,
+		FrontendAddress: r.frontendAddress,
+		QueryComponent:  queued.QueryComponent,
+		Priority:        queued.Priority,
+		EnqueueTime:     queued.EnqueuedAt,
+	}
+
+	if r.request == nil {
+		return status
+	}
+
+	if params, ok := queue.RequestParams(r.request); ok {
+		status.Query = truncateQuery(params.Get("query"))
+	}
+	status.Path, _, _ = strings.Cut(r.request.Url, "?")
+	if minT, maxT, ok := queue.RequestTimeRange(r.request); ok {
+		start, end := util.TimeFromMillis(minT), util.TimeFromMillis(maxT)
+		status.Start, status.End = &start, &end
+	}
+
+	return status
+}
+
+func truncateQuery(query string) string {
+	runes := []rune(query)
+	if len(runes) <= maxQueueStatusQueryLength {
+		return query
+	}
+	return string(runes[:maxQueueStatusQueryLength]) + "..."
+}
+
+// cancelQueuedRequest removes the request from the queue, and notifies the frontend which enqueued it.
+func (s *Scheduler) cancelQueuedRequest(ctx context.Context, frontendAddr, queryIDValue string) error {
+	queryID, err := strconv.ParseUint(queryIDValue, 10, 64)
+	if err != nil {
+		return httpgrpc.Errorf(http.StatusBadRequest, "invalid query ID %q: %s", queryIDValue, err)
+	}
+
+	s.pendingRequestsMu.Lock()
+	pending := s.pendingRequests[requestKey{frontendAddr: frontendAddr, queryID: queryID}]
+	s.pendingRequestsMu.Unlock()
+
+	if pending == nil {
+		return httpgrpc.Errorf(http.StatusNotFound, "request %d from frontend %s not found", queryID, frontendAddr)
+	}
+
+	removed, err := s.requestQueue.RemoveRequests(ctx, pending.userID, func(req queue.Request) bool {
+		return req == pending
+	})
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		return httpgrpc.Errorf(http.StatusNotFound, "request %d from frontend %s is not queued anymore", queryID, frontendAddr)
+	}
+
+	s.cancelRemovedRequests(ctx, removed)
+	return nil
+}
+
+// flushTenantQueue removes all the requests from the queue of the tenant, and notifies the frontends which enqueued them.
+func (s *Scheduler) flushTenantQueue(ctx context.Context, tenantID string) error {
+	if tenantID == "" {
+		return httpgrpc.Errorf(http.StatusBadRequest, "no tenant specified")
+	}
+
+	removed, err := s.requestQueue.RemoveRequests(ctx, tenantID, func(queue.Request) bool { return true })
+	if err != nil {
+		return err
+	}
+
+	s.cancelRemovedRequests(ctx, removed)
+	return nil
+}
+
+func (s *Scheduler) cancelRemovedRequests(ctx context.Context, removed []queue.Request) {
+	for _, req := range removed {
+		r := req.(*schedulerRequest)
+		level.Info(s.log).Log("msg", "cancelling queued request on operator request", "user", r.userID, "frontend", r.frontendAddress, "query_id", r.queryID)
+
+		r.queueSpan.Finish()
+		s.cancelledRequests.WithLabelValues(r.userID).Inc()
+		s.forwardErrorToFrontend(ctx, r, errRequestCancelledByOperator)
+		s.cancelRequestAndRemoveFromPending(r.frontendAddress, r.queryID)
+	}
+}
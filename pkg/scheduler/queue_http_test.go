@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/grafana/mimir/pkg/frontend/v2/frontendv2pb"
+	"github.com/grafana/mimir/pkg/scheduler/schedulerpb"
+)
+
+func TestScheduler_QueueHandler(t *testing.T) {
+	scheduler, frontendClient, _ := setupScheduler(t, nil)
+
+	fm := &frontendMock{resp: map[uint64]*httpgrpc.HTTPResponse{}}
+	frontendAddress := ""
+
+	// Setup frontend grpc server, which receives the errors of cancelled requests.
+	{
+		frontendGrpcServer := grpc.NewServer()
+		frontendv2pb.RegisterFrontendForQuerierServer(frontendGrpcServer, fm)
+
+		l, err := net.Listen("tcp", "localhost:0")
+		require.NoError(t, err)
+
+		frontendAddress = l.Addr().String()
+
+		go func() {
+			_ = frontendGrpcServer.Serve(l)
+		}()
+
+		t.Cleanup(func() {
+			_ = l.Close()
+		})
+	}
+
+	start := time.Unix(1700000000, 0).UTC()
+	end := start.Add(time.Hour)
+	longQuery := "sum(rate(metric[5m]))" + strings.Repeat(" + vector(1)", 50)
+
+	frontendLoop := initFrontendLoop(t, frontendClient, frontendAddress)
+	for queryID, userID := range map[uint64]string{1: "user-1", 2: "user-1", 3: "user-2"} {
+		frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+			Type:    schedulerpb.ENQUEUE,
+			QueryID: queryID,
+			UserID:  userID,
+			HttpRequest: &httpgrpc.HTTPRequest{
+				Method: "GET",
+				Url:    fmt.Sprintf("/prometheus/api/v1/query_range?query=%s&start=%d&end=%d&step=60", url.QueryEscape(longQuery), start.Unix(), end.Unix()),
+			},
+		})
+	}
+
+	getState := func(t *testing.T) queueStatusPageContents {
+		req := httptest.NewRequest(http.MethodGet, "/scheduler/queue", nil)
+		req.Header.Set("Accept", "application/json")
+		rec := httptest.NewRecorder()
+		scheduler.QueueHandler(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var contents queueStatusPageContents
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &contents))
+		return contents
+	}
+
+	post := func(t *testing.T, values url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/scheduler/queue", strings.NewReader(values.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		scheduler.QueueHandler(rec, req)
+		return rec
+	}
+
+	t.Run("should list the queued requests of each tenant", func(t *testing.T) {
+		contents := getState(t)
+		require.Len(t, contents.Tenants, 2)
+
+		tenant := contents.Tenants[0]
+		assert.Equal(t, "user-1", tenant.TenantID)
+		assert.Equal(t, 2, tenant.Length)
+		assert.Empty(t, tenant.Queriers)
+		require.Len(t, tenant.Requests, 2)
+		assert.Equal(t, tenant.Requests[0].EnqueueTime, tenant.OldestEnqueueTime)
+
+		for _, r := range tenant.Requests {
+			assert.Equal(t, frontendAddress, r.FrontendAddress)
+			assert.Equal(t, "/prometheus/api/v1/query_range", r.Path)
+			assert.Equal(t, longQuery[:maxQueueStatusQueryLength]+"...", r.Query)
+			require.NotNil(t, r.Start)
+			require.NotNil(t, r.End)
+			assert.True(t, start.Equal(*r.Start))
+			assert.True(t, end.Equal(*r.End))
+		}
+
+		assert.Equal(t, "user-2", contents.Tenants[1].TenantID)
+		assert.Equal(t, 1, contents.Tenants[1].Length)
+	})
+
+	t.Run("should render the HTML page", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		scheduler.QueueHandler(rec, httptest.NewRequest(http.MethodGet, "/scheduler/queue", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Tenant user-1")
+		assert.Contains(t, rec.Body.String(), "Tenant user-2")
+	})
+
+	t.Run("should cancel a queued request and notify the frontend", func(t *testing.T) {
+		rec := post(t, url.Values{"action": {"cancel"}, "frontend": {frontendAddress}, "query_id": {"1"}})
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		resp := fm.getRequest(1)
+		require.NotNil(t, resp)
+		assert.Equal(t, int32(http.StatusInternalServerError), resp.Code)
+		assert.Equal(t, errRequestCancelledByOperator.Error(), string(resp.Body))
+
+		contents := getState(t)
+		require.Len(t, contents.Tenants, 2)
+		require.Len(t, contents.Tenants[0].Requests, 1)
+		assert.Equal(t, uint64(2), contents.Tenants[0].Requests[0].QueryID)
+	})
+
+	t.Run("should return an error when cancelling a request which is not queued", func(t *testing.T) {
+		rec := post(t, url.Values{"action": {"cancel"}, "frontend": {frontendAddress}, "query_id": {"1"}})
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = post(t, url.Values{"action": {"cancel"}, "frontend": {frontendAddress}, "query_id": {"invalid"}})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("should flush the queue of a tenant", func(t *testing.T) {
+		rec := post(t, url.Values{"action": {"flush"}, "tenant": {"user-1"}})
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NotNil(t, fm.getRequest(2))
+		assert.Nil(t, fm.getRequest(3))
+
+		contents := getState(t)
+		require.Len(t, contents.Tenants, 1)
+		assert.Equal(t, "user-2", contents.Tenants[0].TenantID)
+
+		rec = post(t, url.Values{"action": {"flush"}, "tenant": {"user-2"}})
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NotNil(t, fm.getRequest(3))
+		assert.Empty(t, getState(t).Tenants)
+
+		verifyNoPendingRequestsLeft(t, scheduler)
+	})
+
+	t.Run("should return an error on unknown action", func(t *testing.T) {
+		rec := post(t, url.Values{"action": {"unknown"}})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -66,12 +66,18 @@ type Scheduler struct {
 
 	// Metrics.
 	queueLength              *prometheus.GaugeVec
+	queueLengthByComponent   *prometheus.GaugeVec
 	discardedRequests        *prometheus.CounterVec
 	cancelledRequests        *prometheus.CounterVec
 	connectedQuerierClients  prometheus.GaugeFunc
 	connectedFrontendClients prometheus.GaugeFunc
 	queueDuration            prometheus.Histogram
 	inflightRequests         prometheus.Summary
+
+	// Autoscaling signals.
+	autoscaling               *autoscalingTracker
+	recommendedQuerierWorkers prometheus.Gauge
+	inflightToCapacityRatio   prometheus.GaugeFunc
 }
 
 type requestKey struct {
@@ -88,21 +94,38 @@ type connectedFrontend struct {
 	cancel context.CancelFunc
 }
 
+var (
+	errInvalidReservedQuerierWorkers      = errors.New("the percentage of querier workers reserved to prioritized queries must be between 0 and 100")
+	errInvalidAutoscalingQueueDrainPeriod = errors.New("the autoscaling queue drain period must be greater than 0")
+)
+
 type Config struct {
-	MaxOutstandingPerTenant int                       `yaml:"max_outstanding_requests_per_tenant"`
-	QuerierForgetDelay      time.Duration             `yaml:"querier_forget_delay" category:"experimental"`
-	GRPCClientConfig        grpcclient.Config         `yaml:"grpc_client_config" doc:"description=This configures the gRPC client used to report errors back to the query-frontend."`
-	ServiceDiscovery        schedulerdiscovery.Config `yaml:",inline"`
+	MaxOutstandingPerTenant          int                       `yaml:"max_outstanding_requests_per_tenant"`
+	QuerierForgetDelay               time.Duration             `yaml:"querier_forget_delay" category:"experimental"`
+	ReservedQuerierWorkersPercentage float64                   `yaml:"prioritized_queries_reserved_querier_workers_percentage" category:"experimental"`
+	PriorityStarvationTimeout        time.Duration             `yaml:"priority_starvation_timeout" category:"experimental"`
+	AutoscalingQueueDrainPeriod      time.Duration             `yaml:"autoscaling_queue_drain_period" category:"experimental"`
+	GRPCClientConfig                 grpcclient.Config         `yaml:"grpc_client_config" doc:"description=This configures the gRPC client used to report errors back to the query-frontend."`
+	ServiceDiscovery                 schedulerdiscovery.Config `yaml:",inline"`
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet, logger log.Logger) {
 	f.IntVar(&cfg.MaxOutstandingPerTenant, "query-scheduler.max-outstanding-requests-per-tenant", 100, "Maximum number of outstanding requests per tenant per query-scheduler. In-flight requests above this limit will fail with HTTP response status code 429.")
 	f.DurationVar(&cfg.QuerierForgetDelay, "query-scheduler.querier-forget-delay", 0, "If a querier disconnects without sending notification about graceful shutdown, the query-scheduler will keep the querier in the tenant's shard until the forget delay has passed. This feature is useful to reduce the blast radius when shuffle-sharding is enabled.")
+	f.Float64Var(&cfg.ReservedQuerierWorkersPercentage, "query-scheduler.prioritized-queries-reserved-querier-workers-percentage", 0, "Percentage of the connected querier workers reserved to queries with a priority class. Queries without a priority class are not dispatched to the reserved querier workers, unless they're starving. 0 to disable.")
+	f.DurationVar(&cfg.PriorityStarvationTimeout, "query-scheduler.priority-starvation-timeout", time.Minute, "Queries waiting in the queue for longer than this timeout are dequeued ahead of higher priority queries, and ignore the querier workers reserved to prioritized queries. 0 to disable.")
+	f.DurationVar(&cfg.AutoscalingQueueDrainPeriod, "query-scheduler.autoscaling-queue-drain-period", 30*time.Second, "Time within which the queued requests should be drained by the querier workers recommended by the autoscaling signals of the query-scheduler. A lower value results in more querier workers recommended when requests are queued.")
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("query-scheduler.grpc-client-config", f)
 	cfg.ServiceDiscovery.RegisterFlags(f, logger)
 }
 
 func (cfg *Config) Validate() error {
+	if cfg.ReservedQuerierWorkersPercentage < 0 || cfg.ReservedQuerierWorkersPercentage > 100 {
+		return errInvalidReservedQuerierWorkers
+	}
+	if cfg.AutoscalingQueueDrainPeriod <= 0 {
+		return errInvalidAutoscalingQueueDrainPeriod
+	}
 	return cfg.ServiceDiscovery.Validate()
 }
 
@@ -124,6 +147,10 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 		Name: "cortex_query_scheduler_queue_length",
 		Help: "Number of queries in the queue.",
 	}, []string{"user"})
+	s.queueLengthByComponent = promauto.With(registerer).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cortex_query_scheduler_queue_length_by_query_component",
+		Help: "Number of queries in the queue, by the component they're expected to query.",
+	}, []string{"user", "query_component"})
 
 	s.cancelledRequests = promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
 		Name: "cortex_query_scheduler_cancelled_requests_total",
@@ -137,7 +164,7 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 		Name: "cortex_query_scheduler_enqueue_duration_seconds",
 		Help: "Time spent by requests waiting to join the queue or be rejected.",
 	})
-	s.requestQueue = queue.NewRequestQueue(cfg.MaxOutstandingPerTenant, cfg.QuerierForgetDelay, s.queueLength, s.discardedRequests, enqueueDuration)
+	s.requestQueue = queue.NewRequestQueue(cfg.MaxOutstandingPerTenant, cfg.QuerierForgetDelay, cfg.ReservedQuerierWorkersPercentage, cfg.PriorityStarvationTimeout, s.queueLength, s.queueLengthByComponent, s.discardedRequests, enqueueDuration)
 
 	s.queueDuration = promauto.With(registerer).NewHistogram(prometheus.HistogramOpts{
 		Name:    "cortex_query_scheduler_queue_duration_seconds",
@@ -161,6 +188,18 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 		AgeBuckets: 6,
 	})
 
+	s.autoscaling = newAutoscalingTracker(cfg.AutoscalingQueueDrainPeriod)
+	s.recommendedQuerierWorkers = promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
+		Name: "cortex_query_scheduler_recommended_querier_workers",
+		Help: "Number of querier workers recommended to handle the load of the query-scheduler, based on the queue length, dequeue rate and average latency of the requests of each tenant.",
+	})
+	s.inflightToCapacityRatio = promauto.With(registerer).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "cortex_query_scheduler_querier_inflight_requests_to_capacity_ratio",
+		Help: "Ratio between the number of requests being processed by querier workers and the number of querier workers connected to the query-scheduler.",
+	}, func() float64 {
+		return s.autoscaling.inflightToCapacityRatio(int(s.requestQueue.GetConnectedQuerierWorkersMetric()))
+	})
+
 	s.activeUsers = util.NewActiveUsersCleanupWithDefaultValues(s.cleanupMetricsForInactiveUser)
 	subservices := []services.Service{s.requestQueue, s.activeUsers}
 
@@ -356,7 +395,7 @@ func (s *Scheduler) enqueueRequest(requestContext context.Context, frontendAddr
 	maxQueriers := validation.SmallestPositiveNonZeroIntPerTenant(tenantIDs, s.limits.MaxQueriersPerUser)
 
 	s.activeUsers.UpdateUserTimestamp(userID, now)
-	return s.requestQueue.EnqueueRequest(userID, req, maxQueriers, func() {
+	return s.requestQueue.EnqueueRequest(userID, req, msg.QueryComponent, int(msg.Priority), maxQueriers, func() {
 		shouldCancel = false
 
 		s.pendingRequestsMu.Lock()
@@ -389,13 +428,15 @@ func (s *Scheduler) QuerierLoop(querier schedulerpb.SchedulerForQuerier_QuerierL
 	querierID := resp.GetQuerierID()
 
 	s.requestQueue.RegisterQuerierConnection(querierID)
-	defer s.requestQueue.UnregisterQuerierConnection(querierID)
-
 	lastUserIndex := queue.FirstUser()
+	defer func() {
+		s.requestQueue.UnregisterQuerierConnection(querierID, lastUserIndex)
+	}()
 
 	// In stopping state scheduler is not accepting new queries, but still dispatching queries in the queues.
 	for s.isRunningOrStopping() {
 		req, idx, err := s.requestQueue.GetNextRequestForQuerier(querier.Context(), lastUserIndex, querierID)
+		lastUserIndex = idx
 		if err != nil {
 			// Return a more clear error if the queue is stopped because the query-scheduler is not running.
 			if errors.Is(err, queue.ErrStopped) && !s.isRunning() {
@@ -404,7 +445,6 @@ func (s *Scheduler) QuerierLoop(querier schedulerpb.SchedulerForQuerier_QuerierL
 
 			return err
 		}
-		lastUserIndex = idx
 
 		r := req.(*schedulerRequest)
 
@@ -450,6 +490,12 @@ func (s *Scheduler) forwardRequestToQuerier(querier schedulerpb.SchedulerForQuer
 	// Make sure to cancel request at the end to clean up resources.
 	defer s.cancelRequestAndRemoveFromPending(req.frontendAddress, req.queryID)
 
+	// Track the request for the autoscaling signals.
+	start := time.Now()
+	s.autoscaling.requestStarted(req.userID)
+	successful := false
+	defer func() { s.autoscaling.requestCompleted(req.userID, time.Since(start), successful) }()
+
 	// Handle the stream sending & receiving on a goroutine so we can
 	// monitor the contexts in a select and cancel things appropriately.
 	errCh := make(chan error, 1)
@@ -460,6 +506,7 @@ func (s *Scheduler) forwardRequestToQuerier(querier schedulerpb.SchedulerForQuer
 			FrontendAddress: req.frontendAddress,
 			HttpRequest:     req.request,
 			StatsEnabled:    req.statsEnabled,
+			QueueTimeNanos:  time.Since(req.enqueueTime).Nanoseconds(),
 		})
 		if err != nil {
 			errCh <- err
@@ -485,6 +532,7 @@ func (s *Scheduler) forwardRequestToQuerier(querier schedulerpb.SchedulerForQuer
 		if err != nil {
 			s.forwardErrorToFrontend(req.ctx, req, err)
 		}
+		successful = err == nil
 		return err
 	}
 }
@@ -554,6 +602,9 @@ func (s *Scheduler) running(ctx context.Context) error {
 	inflightRequestsTicker := time.NewTicker(250 * time.Millisecond)
 	defer inflightRequestsTicker.Stop()
 
+	autoscalingTicker := time.NewTicker(autoscalingUpdateInterval)
+	defer autoscalingTicker.Stop()
+
 	for {
 		select {
 		case <-inflightRequestsTicker.C:
@@ -562,6 +613,15 @@ func (s *Scheduler) running(ctx context.Context) error {
 			s.pendingRequestsMu.Unlock()
 
 			s.inflightRequests.Observe(float64(inflight))
+		case <-autoscalingTicker.C:
+			s.autoscaling.tick()
+
+			signals, err := s.autoscalingSignals(ctx)
+			if err != nil {
+				level.Warn(s.log).Log("msg", "failed to compute the autoscaling signals", "err", err)
+				continue
+			}
+			s.recommendedQuerierWorkers.Set(float64(signals.RecommendedQuerierWorkers))
 		case <-ctx.Done():
 			return nil
 		case err := <-s.subservicesWatcher.Chan():
@@ -578,8 +638,10 @@ func (s *Scheduler) stopping(_ error) error {
 
 func (s *Scheduler) cleanupMetricsForInactiveUser(user string) {
 	s.queueLength.DeleteLabelValues(user)
+	s.queueLengthByComponent.DeletePartialMatch(prometheus.Labels{"user": user})
 	s.discardedRequests.DeleteLabelValues(user)
 	s.cancelledRequests.DeleteLabelValues(user)
+	s.autoscaling.cleanupTenant(user)
 }
 
 func (s *Scheduler) getConnectedFrontendClientsMetric() float64 {
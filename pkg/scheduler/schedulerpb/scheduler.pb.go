@@ -139,6 +139,8 @@ type SchedulerToQuerier struct {
 	// Whether query statistics tracking should be enabled. The response will include
 	// statistics only when this option is enabled.
 	StatsEnabled bool `protobuf:"varint,5,opt,name=statsEnabled,proto3" json:"statsEnabled,omitempty"`
+	// The time the query spent in the query-scheduler queue, in nanoseconds.
+	QueueTimeNanos int64 `protobuf:"varint,6,opt,name=queueTimeNanos,proto3" json:"queueTimeNanos,omitempty"`
 }
 
 func (m *SchedulerToQuerier) Reset()      { *m = SchedulerToQuerier{} }
@@ -208,6 +210,13 @@ func (m *SchedulerToQuerier) GetStatsEnabled() bool {
 	return false
 }
 
+func (m *SchedulerToQuerier) GetQueueTimeNanos() int64 {
+	if m != nil {
+		return m.QueueTimeNanos
+	}
+	return 0
+}
+
 type FrontendToScheduler struct {
 	Type FrontendToSchedulerType `protobuf:"varint,1,opt,name=type,proto3,enum=schedulerpb.FrontendToSchedulerType" json:"type,omitempty"`
 	// Used by INIT message. Will be put into all requests passed to querier.
@@ -219,6 +228,12 @@ type FrontendToScheduler struct {
 	UserID       string                `protobuf:"bytes,4,opt,name=userID,proto3" json:"userID,omitempty"`
 	HttpRequest  *httpgrpc.HTTPRequest `protobuf:"bytes,5,opt,name=httpRequest,proto3" json:"httpRequest,omitempty"`
 	StatsEnabled bool                  `protobuf:"varint,6,opt,name=statsEnabled,proto3" json:"statsEnabled,omitempty"`
+	// The component the query is expected to hit (eg. ingester or store-gateway), used to fairly dequeue
+	// the requests of the same tenant. Empty if unknown.
+	QueryComponent string `protobuf:"bytes,7,opt,name=queryComponent,proto3" json:"queryComponent,omitempty"`
+	// Priority of the query, from the priority class requested by the client. Requests of the same tenant with
+	// a higher priority are dequeued first. 0 if unprioritized.
+	Priority int32 `protobuf:"varint,8,opt,name=priority,proto3" json:"priority,omitempty"`
 }
 
 func (m *FrontendToScheduler) Reset()      { *m = FrontendToScheduler{} }
@@ -295,6 +310,20 @@ func (m *FrontendToScheduler) GetStatsEnabled() bool {
 	return false
 }
 
+func (m *FrontendToScheduler) GetQueryComponent() string {
+	if m != nil {
+		return m.QueryComponent
+	}
+	return ""
+}
+
+func (m *FrontendToScheduler) GetPriority() int32 {
+	if m != nil {
+		return m.Priority
+	}
+	return 0
+}
+
 type SchedulerToFrontend struct {
 	Status SchedulerToFrontendStatus `protobuf:"varint,1,opt,name=status,proto3,enum=schedulerpb.SchedulerToFrontendStatus" json:"status,omitempty"`
 	Error  string                    `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
@@ -438,47 +467,51 @@ func init() {
 func init() { proto.RegisterFile("scheduler.proto", fileDescriptor_2b3fc28395a6d9c5) }
 
 var fileDescriptor_2b3fc28395a6d9c5 = []byte{
-	// 640 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x94, 0xcf, 0x4f, 0x1a, 0x41,
-	0x14, 0xc7, 0x77, 0x10, 0x50, 0x1f, 0xb6, 0x6e, 0x47, 0x6d, 0x29, 0xb1, 0x23, 0x21, 0x4d, 0x43,
-	0x3d, 0x80, 0xa1, 0x87, 0xf6, 0x60, 0x9a, 0x50, 0x5d, 0x2b, 0xa9, 0x5d, 0x74, 0x19, 0xd2, 0x1f,
-	0x17, 0x02, 0xec, 0x08, 0x44, 0xbb, 0xb3, 0xce, 0xce, 0xa6, 0xe1, 0xd6, 0x63, 0x8f, 0xfd, 0x33,
-	0xfa, 0xa7, 0xf4, 0xd2, 0xc4, 0xa3, 0x87, 0x1e, 0xea, 0x7a, 0xe9, 0xd1, 0x3f, 0xa1, 0x61, 0x59,
-	0xe8, 0x62, 0x17, 0xf5, 0xf6, 0xe6, 0xf1, 0xfd, 0x2e, 0xef, 0x7d, 0xde, 0x9b, 0x81, 0x45, 0xa7,
-	0xdd, 0x65, 0xa6, 0x7b, 0xcc, 0x44, 0xc1, 0x16, 0x5c, 0x72, 0x9c, 0x1a, 0x27, 0xec, 0x56, 0x66,
-	0xb9, 0xc3, 0x3b, 0xdc, 0xcf, 0x17, 0x07, 0xd1, 0x50, 0x92, 0xd9, 0xe8, 0xf4, 0x64, 0xd7, 0x6d,
-	0x15, 0xda, 0xfc, 0x53, 0xb1, 0x23, 0x9a, 0x87, 0x4d, 0xab, 0x59, 0x34, 0x9d, 0xa3, 0x9e, 0x2c,
-	0x76, 0xa5, 0xb4, 0x3b, 0xc2, 0x6e, 0x8f, 0x83, 0xa1, 0x23, 0x57, 0x02, 0x7c, 0xe0, 0x32, 0xd1,
-	0x63, 0x82, 0xf2, 0xda, 0xe8, 0xfb, 0x78, 0x15, 0xe6, 0x4f, 0x86, 0xd9, 0xca, 0x76, 0x1a, 0x65,
-	0x51, 0x7e, 0xde, 0xf8, 0x97, 0xc8, 0xfd, 0x44, 0x80, 0xc7, 0x5a, 0xca, 0x03, 0x3f, 0x4e, 0xc3,
-	0xec, 0x40, 0xd3, 0x0f, 0x2c, 0x71, 0x63, 0x74, 0xc4, 0xcf, 0x21, 0x35, 0xf8, 0x5b, 0x83, 0x9d,
-	0xb8, 0xcc, 0x91, 0xe9, 0x58, 0x16, 0xe5, 0x53, 0xa5, 0x95, 0xc2, 0xb8, 0x94, 0x5d, 0x4a, 0xf7,
-	0x83, 0x1f, 0x8d, 0xb0, 0x12, 0xe7, 0x61, 0xf1, 0x50, 0x70, 0x4b, 0x32, 0xcb, 0x2c, 0x9b, 0xa6,
-	0x60, 0x8e, 0x93, 0x9e, 0xf1, 0xab, 0xb9, 0x9a, 0xc6, 0xf7, 0x21, 0xe9, 0x3a, 0x7e, 0xb9, 0x71,
-	0x5f, 0x10, 0x9c, 0x70, 0x0e, 0x16, 0x1c, 0xd9, 0x94, 0x8e, 0x66, 0x35, 0x5b, 0xc7, 0xcc, 0x4c,
-	0x27, 0xb2, 0x28, 0x3f, 0x67, 0x4c, 0xe4, 0x72, 0x5f, 0x63, 0xb0, 0xb4, 0x13, 0x7c, 0x2f, 0x4c,
-	0xe1, 0x05, 0xc4, 0x65, 0xdf, 0x66, 0x7e, 0x37, 0x77, 0x4b, 0x8f, 0x0b, 0x21, 0xfe, 0x85, 0x08,
-	0x3d, 0xed, 0xdb, 0xcc, 0xf0, 0x1d, 0x51, 0x75, 0xc7, 0xa2, 0xeb, 0x0e, 0x41, 0x9b, 0x99, 0x84,
-	0x36, 0xad, 0xa3, 0x2b, 0x30, 0x13, 0xb7, 0x86, 0x79, 0x15, 0x45, 0x32, 0x02, 0xc5, 0x11, 0x2c,
-	0x85, 0x26, 0x3b, 0x6a, 0x12, 0xbf, 0x84, 0xe4, 0x40, 0xe6, 0x3a, 0x01, 0x8b, 0x27, 0x13, 0x2c,
-	0x22, 0x1c, 0x35, 0x5f, 0x6d, 0x04, 0x2e, 0xbc, 0x0c, 0x09, 0x26, 0x04, 0x17, 0x01, 0x85, 0xe1,
-	0x21, 0xb7, 0x09, 0xab, 0x3a, 0x97, 0xbd, 0xc3, 0x7e, 0xb0, 0x41, 0xb5, 0xae, 0x2b, 0x4d, 0xfe,
-	0xd9, 0x1a, 0x15, 0x7c, 0xfd, 0x16, 0xae, 0xc1, 0xa3, 0x29, 0x6e, 0xc7, 0xe6, 0x96, 0xc3, 0xd6,
-	0x37, 0xe1, 0xc1, 0x94, 0x29, 0xe1, 0x39, 0x88, 0x57, 0xf4, 0x0a, 0x55, 0x15, 0x9c, 0x82, 0x59,
-	0x4d, 0x3f, 0xa8, 0x6b, 0x75, 0x4d, 0x45, 0x18, 0x20, 0xb9, 0x55, 0xd6, 0xb7, 0xb4, 0x3d, 0x35,
-	0xb6, 0xde, 0x86, 0x87, 0x53, 0xfb, 0xc2, 0x49, 0x88, 0x55, 0xdf, 0xa8, 0x0a, 0xce, 0xc2, 0x2a,
-	0xad, 0x56, 0x1b, 0x6f, 0xcb, 0xfa, 0x87, 0x86, 0xa1, 0x1d, 0xd4, 0xb5, 0x1a, 0xad, 0x35, 0xf6,
-	0x35, 0xa3, 0x41, 0x35, 0xbd, 0xac, 0x53, 0x15, 0xe1, 0x79, 0x48, 0x68, 0x86, 0x51, 0x35, 0xd4,
-	0x18, 0xbe, 0x07, 0x77, 0x6a, 0xbb, 0x75, 0x4a, 0x2b, 0xfa, 0xeb, 0xc6, 0x76, 0xf5, 0x9d, 0xae,
-	0xce, 0x94, 0x7e, 0xa1, 0x10, 0xef, 0x1d, 0x2e, 0x46, 0x57, 0xa9, 0x0e, 0xa9, 0x20, 0xdc, 0xe3,
-	0xdc, 0xc6, 0x6b, 0x13, 0xb8, 0xff, 0xbf, 0xaf, 0x99, 0xb5, 0x69, 0xf3, 0x08, 0xb4, 0x39, 0x25,
-	0x8f, 0x36, 0x10, 0xb6, 0x60, 0x25, 0x12, 0x19, 0x7e, 0x3a, 0xe1, 0xbf, 0x6e, 0x28, 0x99, 0xf5,
-	0xdb, 0x48, 0x87, 0x13, 0x28, 0xd9, 0xb0, 0x1c, 0xee, 0x6e, 0xbc, 0x4e, 0xef, 0x61, 0x61, 0x14,
-	0xfb, 0xfd, 0x65, 0x6f, 0xba, 0x5a, 0x99, 0xec, 0x4d, 0x0b, 0x37, 0xec, 0xf0, 0x55, 0xf9, 0xf4,
-	0x9c, 0x28, 0x67, 0xe7, 0x44, 0xb9, 0x3c, 0x27, 0xe8, 0x8b, 0x47, 0xd0, 0x77, 0x8f, 0xa0, 0x1f,
-	0x1e, 0x41, 0xa7, 0x1e, 0x41, 0xbf, 0x3d, 0x82, 0xfe, 0x78, 0x44, 0xb9, 0xf4, 0x08, 0xfa, 0x76,
-	0x41, 0x94, 0xd3, 0x0b, 0xa2, 0x9c, 0x5d, 0x10, 0xe5, 0x63, 0xf8, 0x65, 0x6d, 0x25, 0xfd, 0x87,
-	0xf1, 0xd9, 0xdf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x67, 0xdc, 0x4e, 0xdc, 0x80, 0x05, 0x00, 0x00,
+	// 689 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0xcd, 0x4e, 0xdb, 0x4c,
+	0x14, 0xf5, 0xe4, 0x8f, 0x70, 0xc3, 0x07, 0xf9, 0x06, 0x68, 0xdd, 0x88, 0x1a, 0x2b, 0xaa, 0x50,
+	0xca, 0x22, 0x41, 0xe9, 0xa2, 0x5d, 0xa0, 0x4a, 0x29, 0x98, 0x12, 0x95, 0x3a, 0x30, 0x71, 0xd4,
+	0x9f, 0x4d, 0x94, 0x9f, 0x21, 0xb1, 0x00, 0x8f, 0x19, 0x8f, 0x55, 0x65, 0xd7, 0x47, 0xe8, 0x63,
+	0xf4, 0x51, 0xba, 0xe8, 0x82, 0x25, 0x8b, 0x2e, 0x8a, 0xd9, 0x74, 0xc9, 0xa6, 0xfb, 0x2a, 0x8e,
+	0x93, 0x3a, 0x69, 0x02, 0xec, 0xe6, 0x9e, 0x9c, 0x93, 0x3b, 0xe7, 0xdc, 0xeb, 0x81, 0x25, 0xa7,
+	0xd5, 0xa5, 0x6d, 0xf7, 0x94, 0xf2, 0xbc, 0xcd, 0x99, 0x60, 0x38, 0x35, 0x02, 0xec, 0x66, 0x66,
+	0xa5, 0xc3, 0x3a, 0xcc, 0xc7, 0x0b, 0xfd, 0xd3, 0x80, 0x92, 0xd9, 0xea, 0x98, 0xa2, 0xeb, 0x36,
+	0xf3, 0x2d, 0x76, 0x56, 0xe8, 0xf0, 0xc6, 0x71, 0xc3, 0x6a, 0x14, 0xda, 0xce, 0x89, 0x29, 0x0a,
+	0x5d, 0x21, 0xec, 0x0e, 0xb7, 0x5b, 0xa3, 0xc3, 0x40, 0x91, 0x2d, 0x02, 0x3e, 0x72, 0x29, 0x37,
+	0x29, 0x37, 0x58, 0x75, 0xf8, 0xff, 0x78, 0x0d, 0xe6, 0xcf, 0x07, 0x68, 0x79, 0x57, 0x46, 0x2a,
+	0xca, 0xcd, 0x93, 0xbf, 0x40, 0xf6, 0x37, 0x02, 0x3c, 0xe2, 0x1a, 0x2c, 0xd0, 0x63, 0x19, 0xe6,
+	0xfa, 0x9c, 0x5e, 0x20, 0x89, 0x91, 0x61, 0x89, 0x9f, 0x43, 0xaa, 0xdf, 0x96, 0xd0, 0x73, 0x97,
+	0x3a, 0x42, 0x8e, 0xa8, 0x28, 0x97, 0x2a, 0xae, 0xe6, 0x47, 0x57, 0xd9, 0x37, 0x8c, 0xc3, 0xe0,
+	0x47, 0x12, 0x66, 0xe2, 0x1c, 0x2c, 0x1d, 0x73, 0x66, 0x09, 0x6a, 0xb5, 0x4b, 0xed, 0x36, 0xa7,
+	0x8e, 0x23, 0x47, 0xfd, 0xdb, 0x4c, 0xc2, 0xf8, 0x01, 0x24, 0x5c, 0xc7, 0xbf, 0x6e, 0xcc, 0x27,
+	0x04, 0x15, 0xce, 0xc2, 0x82, 0x23, 0x1a, 0xc2, 0xd1, 0xac, 0x46, 0xf3, 0x94, 0xb6, 0xe5, 0xb8,
+	0x8a, 0x72, 0x49, 0x32, 0x86, 0xe1, 0x0d, 0x58, 0x3c, 0x77, 0xa9, 0x4b, 0x0d, 0xf3, 0x8c, 0xea,
+	0x0d, 0x8b, 0x39, 0x72, 0x42, 0x45, 0xb9, 0x28, 0x99, 0x40, 0xb3, 0xdf, 0x23, 0xb0, 0xbc, 0x17,
+	0xf4, 0x0d, 0xa7, 0xf5, 0x02, 0x62, 0xa2, 0x67, 0x53, 0xdf, 0xf5, 0x62, 0xf1, 0x49, 0x3e, 0x34,
+	0xa7, 0xfc, 0x14, 0xbe, 0xd1, 0xb3, 0x29, 0xf1, 0x15, 0xd3, 0xfc, 0x45, 0xa6, 0xfb, 0x0b, 0x85,
+	0x1b, 0x1d, 0x0f, 0x77, 0x96, 0xf3, 0x89, 0xd0, 0xe3, 0xf7, 0x0e, 0x7d, 0x32, 0xb2, 0xc4, 0xcc,
+	0xc8, 0x78, 0x6f, 0x87, 0x9d, 0xd9, 0xcc, 0xa2, 0x96, 0x90, 0xe7, 0xfc, 0xe6, 0x13, 0x28, 0xce,
+	0x40, 0xd2, 0xe6, 0x26, 0xe3, 0xa6, 0xe8, 0xc9, 0x49, 0x15, 0xe5, 0xe2, 0x64, 0x54, 0x67, 0x4f,
+	0x60, 0x39, 0xb4, 0x45, 0xc3, 0xa0, 0xf0, 0x4b, 0x48, 0xf4, 0x5b, 0xb9, 0x4e, 0x90, 0xe7, 0xc6,
+	0x58, 0x9e, 0x53, 0x14, 0x55, 0x9f, 0x4d, 0x02, 0x15, 0x5e, 0x81, 0x38, 0xe5, 0x9c, 0xf1, 0x20,
+	0xc9, 0x41, 0x91, 0xdd, 0x86, 0x35, 0x9d, 0x09, 0xf3, 0xb8, 0x17, 0x6c, 0x6b, 0xb5, 0xeb, 0x8a,
+	0x36, 0xfb, 0x64, 0x0d, 0x4d, 0xdf, 0xbe, 0xf1, 0xeb, 0xf0, 0x78, 0x86, 0xda, 0xb1, 0x99, 0xe5,
+	0xd0, 0xcd, 0x6d, 0x78, 0x38, 0x63, 0xd2, 0x38, 0x09, 0xb1, 0xb2, 0x5e, 0x36, 0xd2, 0x12, 0x4e,
+	0xc1, 0x9c, 0xa6, 0x1f, 0xd5, 0xb4, 0x9a, 0x96, 0x46, 0x18, 0x20, 0xb1, 0x53, 0xd2, 0x77, 0xb4,
+	0x83, 0x74, 0x64, 0xb3, 0x05, 0x8f, 0x66, 0xfa, 0xc2, 0x09, 0x88, 0x54, 0xde, 0xa4, 0x25, 0xac,
+	0xc2, 0x9a, 0x51, 0xa9, 0xd4, 0xdf, 0x96, 0xf4, 0x0f, 0x75, 0xa2, 0x1d, 0xd5, 0xb4, 0xaa, 0x51,
+	0xad, 0x1f, 0x6a, 0xa4, 0x6e, 0x68, 0x7a, 0x49, 0x37, 0xd2, 0x08, 0xcf, 0x43, 0x5c, 0x23, 0xa4,
+	0x42, 0xd2, 0x11, 0xfc, 0x3f, 0xfc, 0x57, 0xdd, 0xaf, 0x19, 0x46, 0x59, 0x7f, 0x5d, 0xdf, 0xad,
+	0xbc, 0xd3, 0xd3, 0xd1, 0xe2, 0x0f, 0x14, 0xca, 0x7b, 0x8f, 0xf1, 0xe1, 0x67, 0x5b, 0x83, 0x54,
+	0x70, 0x3c, 0x60, 0xcc, 0xc6, 0xeb, 0x63, 0x71, 0xff, 0xfb, 0x36, 0x64, 0xd6, 0x67, 0xcd, 0x23,
+	0xe0, 0x66, 0xa5, 0x1c, 0xda, 0x42, 0xd8, 0x82, 0xd5, 0xa9, 0x91, 0xe1, 0xa7, 0x63, 0xfa, 0xdb,
+	0x86, 0x92, 0xd9, 0xbc, 0x0f, 0x75, 0x30, 0x81, 0xa2, 0x0d, 0x2b, 0x61, 0x77, 0xa3, 0x75, 0x7a,
+	0x0f, 0x0b, 0xc3, 0xb3, 0xef, 0x4f, 0xbd, 0xeb, 0xf3, 0xcc, 0xa8, 0x77, 0x2d, 0xdc, 0xc0, 0xe1,
+	0xab, 0xd2, 0xc5, 0x95, 0x22, 0x5d, 0x5e, 0x29, 0xd2, 0xcd, 0x95, 0x82, 0x3e, 0x7b, 0x0a, 0xfa,
+	0xea, 0x29, 0xe8, 0x9b, 0xa7, 0xa0, 0x0b, 0x4f, 0x41, 0x3f, 0x3d, 0x05, 0xfd, 0xf2, 0x14, 0xe9,
+	0xc6, 0x53, 0xd0, 0x97, 0x6b, 0x45, 0xba, 0xb8, 0x56, 0xa4, 0xcb, 0x6b, 0x45, 0xfa, 0x18, 0x7e,
+	0xc5, 0x9b, 0x09, 0xff, 0x11, 0x7e, 0xf6, 0x67, 0x00, 0x0a, 0x80, 0x8a, 0x0d, 0xec, 0x05, 0x00,
+	0x00,
 }
 
 func (x FrontendToSchedulerType) String() string {
@@ -553,6 +586,9 @@ func (this *SchedulerToQuerier) Equal(that interface{}) bool {
 	if this.StatsEnabled != that1.StatsEnabled {
 		return false
 	}
+	if this.QueueTimeNanos != that1.QueueTimeNanos {
+		return false
+	}
 	return true
 }
 func (this *FrontendToScheduler) Equal(that interface{}) bool {
@@ -592,6 +628,12 @@ func (this *FrontendToScheduler) Equal(that interface{}) bool {
 	if this.StatsEnabled != that1.StatsEnabled {
 		return false
 	}
+	if this.QueryComponent != that1.QueryComponent {
+		return false
+	}
+	if this.Priority != that1.Priority {
+		return false
+	}
 	return true
 }
 func (this *SchedulerToFrontend) Equal(that interface{}) bool {
@@ -680,7 +722,7 @@ func (this *SchedulerToQuerier) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 10)
 	s = append(s, "&schedulerpb.SchedulerToQuerier{")
 	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
 	if this.HttpRequest != nil {
@@ -689,6 +731,7 @@ func (this *SchedulerToQuerier) GoString() string {
 	s = append(s, "FrontendAddress: "+fmt.Sprintf("%#v", this.FrontendAddress)+",\n")
 	s = append(s, "UserID: "+fmt.Sprintf("%#v", this.UserID)+",\n")
 	s = append(s, "StatsEnabled: "+fmt.Sprintf("%#v", this.StatsEnabled)+",\n")
+	s = append(s, "QueueTimeNanos: "+fmt.Sprintf("%#v", this.QueueTimeNanos)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -696,7 +739,7 @@ func (this *FrontendToScheduler) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 10)
+	s := make([]string, 0, 12)
 	s = append(s, "&schedulerpb.FrontendToScheduler{")
 	s = append(s, "Type: "+fmt.Sprintf("%#v", this.Type)+",\n")
 	s = append(s, "FrontendAddress: "+fmt.Sprintf("%#v", this.FrontendAddress)+",\n")
@@ -706,6 +749,8 @@ func (this *FrontendToScheduler) GoString() string {
 		s = append(s, "HttpRequest: "+fmt.Sprintf("%#v", this.HttpRequest)+",\n")
 	}
 	s = append(s, "StatsEnabled: "+fmt.Sprintf("%#v", this.StatsEnabled)+",\n")
+	s = append(s, "QueryComponent: "+fmt.Sprintf("%#v", this.QueryComponent)+",\n")
+	s = append(s, "Priority: "+fmt.Sprintf("%#v", this.Priority)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1077,6 +1122,11 @@ func (m *SchedulerToQuerier) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.QueueTimeNanos != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.QueueTimeNanos))
+		i--
+		dAtA[i] = 0x30
+	}
 	if m.StatsEnabled {
 		i--
 		if m.StatsEnabled {
@@ -1141,6 +1191,18 @@ func (m *FrontendToScheduler) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Priority != 0 {
+		i = encodeVarintScheduler(dAtA, i, uint64(m.Priority))
+		i--
+		dAtA[i] = 0x40
+	}
+	if len(m.QueryComponent) > 0 {
+		i -= len(m.QueryComponent)
+		copy(dAtA[i:], m.QueryComponent)
+		i = encodeVarintScheduler(dAtA, i, uint64(len(m.QueryComponent)))
+		i--
+		dAtA[i] = 0x3a
+	}
 	if m.StatsEnabled {
 		i--
 		if m.StatsEnabled {
@@ -1326,6 +1388,9 @@ func (m *SchedulerToQuerier) Size() (n int) {
 	if m.StatsEnabled {
 		n += 2
 	}
+	if m.QueueTimeNanos != 0 {
+		n += 1 + sovScheduler(uint64(m.QueueTimeNanos))
+	}
 	return n
 }
 
@@ -1356,6 +1421,13 @@ func (m *FrontendToScheduler) Size() (n int) {
 	if m.StatsEnabled {
 		n += 2
 	}
+	l = len(m.QueryComponent)
+	if l > 0 {
+		n += 1 + l + sovScheduler(uint64(l))
+	}
+	if m.Priority != 0 {
+		n += 1 + sovScheduler(uint64(m.Priority))
+	}
 	return n
 }
 
@@ -1423,6 +1495,7 @@ func (this *SchedulerToQuerier) String() string {
 		`FrontendAddress:` + fmt.Sprintf("%v", this.FrontendAddress) + `,`,
 		`UserID:` + fmt.Sprintf("%v", this.UserID) + `,`,
 		`StatsEnabled:` + fmt.Sprintf("%v", this.StatsEnabled) + `,`,
+		`QueueTimeNanos:` + fmt.Sprintf("%v", this.QueueTimeNanos) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1438,6 +1511,8 @@ func (this *FrontendToScheduler) String() string {
 		`UserID:` + fmt.Sprintf("%v", this.UserID) + `,`,
 		`HttpRequest:` + strings.Replace(fmt.Sprintf("%v", this.HttpRequest), "HTTPRequest", "httpgrpc.HTTPRequest", 1) + `,`,
 		`StatsEnabled:` + fmt.Sprintf("%v", this.StatsEnabled) + `,`,
+		`QueryComponent:` + fmt.Sprintf("%v", this.QueryComponent) + `,`,
+		`Priority:` + fmt.Sprintf("%v", this.Priority) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1733,6 +1808,25 @@ func (m *SchedulerToQuerier) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.StatsEnabled = bool(v != 0)
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueueTimeNanos", wireType)
+			}
+			m.QueueTimeNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.QueueTimeNanos |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipScheduler(dAtA[iNdEx:])
@@ -1944,6 +2038,57 @@ func (m *FrontendToScheduler) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.StatsEnabled = bool(v != 0)
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryComponent", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthScheduler
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.QueryComponent = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Priority", wireType)
+			}
+			m.Priority = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowScheduler
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Priority |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipScheduler(dAtA[iNdEx:])
@@ -121,6 +121,14 @@ func Upload(ctx context.Context, logger log.Logger, bkt objstore.Bucket, blockDi
 		return cleanUp(logger, bkt, id, errors.Wrap(err, "upload index"))
 	}
 
+	if _, err := os.Stat(filepath.Join(blockDir, BloomFiltersFilename)); err == nil {
+		if err := objstore.UploadFile(ctx, logger, bkt, filepath.Join(blockDir, BloomFiltersFilename), path.Join(id.String(), BloomFiltersFilename)); err != nil {
+			return cleanUp(logger, bkt, id, errors.Wrap(err, "upload bloom filters"))
+		}
+	} else if !os.IsNotExist(err) {
+		return cleanUp(logger, bkt, id, errors.Wrap(err, "stat bloom filters"))
+	}
+
 	// Meta.json always need to be uploaded as a last item. This will allow to assume block directories without meta file to be pending uploads.
 	if err := bkt.Upload(ctx, path.Join(id.String(), MetaFilename), strings.NewReader(metaEncoded.String())); err != nil {
 		// Don't call cleanUp here. Despite getting error, meta.json may have been uploaded in certain cases,
@@ -284,7 +292,7 @@ func GetSegmentFiles(blockDir string) []string {
 	return result
 }
 
-// GatherFileStats returns File entry for files inside TSDB block (index, chunks, meta.json).
+// GatherFileStats returns File entry for files inside TSDB block (index, chunks, meta.json and the optional bloom filters).
 func GatherFileStats(blockDir string) (res []File, _ error) {
 	files, err := os.ReadDir(filepath.Join(blockDir, ChunksDirname))
 	if err != nil {
@@ -319,10 +327,17 @@ func GatherFileStats(blockDir string) (res []File, _ error) {
 	}
 	res = append(res, File{RelPath: metaFile.Name()})
 
+	bloomFiltersFile, err := os.Stat(filepath.Join(blockDir, BloomFiltersFilename))
+	if err == nil {
+		res = append(res, File{RelPath: bloomFiltersFile.Name(), SizeBytes: bloomFiltersFile.Size()})
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "stat %v", filepath.Join(blockDir, BloomFiltersFilename))
+	}
+
 	sort.Slice(res, func(i, j int) bool {
 		return strings.Compare(res[i].RelPath, res[j].RelPath) < 0
 	})
-	return res, err
+	return res, nil
 }
 
 // MarkForNoCompact creates a file which marks block to be not compacted.
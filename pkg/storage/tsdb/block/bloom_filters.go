@@ -0,0 +1,271 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/grafana/dskit/runutil"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/encoding"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/objstore"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// BloomFiltersFilename is the known filename of the optional sidecar file storing the label values
+	// bloom filters of a block.
+	BloomFiltersFilename = "bloom-filters"
+
+	bloomFiltersMagic    = 0xB10F11E5
+	bloomFiltersVersion1 = 1
+
+	// bloomFilterMinBits is the minimum size of a bloom filter, so that the filter of a label with
+	// few values doesn't degenerate.
+	bloomFilterMinBits = 64
+)
+
+// BloomFilter is a bloom filter of label values.
+type BloomFilter struct {
+	hashes uint32
+	bits   []uint64
+}
+
+// NewBloomFilter returns an empty bloom filter sized to store the given number of values with
+// the given false positive rate.
+func NewBloomFilter(values int, falsePositiveRate float64) *BloomFilter {
+	bits := bloomFilterMinBits
+	hashes := 1
+	if values > 0 {
+		bits = int(math.Ceil(-float64(values) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+		bits = max(bits, bloomFilterMinBits)
+		hashes = max(int(math.Round(float64(bits)/float64(values)*math.Ln2)), 1)
+	}
+
+	return &BloomFilter{
+		hashes: uint32(hashes),
+		bits:   make([]uint64, (bits+63)/64),
+	}
+}
+
+// Add adds the value to the filter.
+func (f *BloomFilter) Add(value string) {
+	h1, h2 := bloomFilterHashes(value)
+	m := uint64(len(f.bits)) * 64
+	for i := uint64(0); i < uint64(f.hashes); i++ {
+		bit := (h1 + i*h2) % m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// MayContain returns false if the value has certainly not been added to the filter, true otherwise.
+func (f *BloomFilter) MayContain(value string) bool {
+	h1, h2 := bloomFilterHashes(value)
+	m := uint64(len(f.bits)) * 64
+	for i := uint64(0); i < uint64(f.hashes); i++ {
+		bit := (h1 + i*h2) % m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomFilterHashes returns the two hashes used to compute the bits of the value with the
+// Kirsch-Mitzenmacher double hashing.
+func bloomFilterHashes(value string) (uint64, uint64) {
+	h := xxhash.Sum64String(value)
+	return h & math.MaxUint32, h>>32 | 1
+}
+
+// BloomFilters holds the label values bloom filters of a block, by label name.
+type BloomFilters map[string]*BloomFilter
+
+// MayMatch returns false if the bloom filters rule out that any series of the block matches all the matchers.
+// It also returns the matchers which have been checked against the filters.
+func (f BloomFilters) MayMatch(matchers []*labels.Matcher) (mayMatch bool, checked []*labels.Matcher) {
+	for _, m := range matchers {
+		filter, ok := f[m.Name]
+		if !ok {
+			continue
+		}
+		values, ok := BloomFilterMatcherValues(m)
+		if !ok {
+			continue
+		}
+
+		checked = append(checked, m)
+
+		found := false
+		for _, v := range values {
+			if filter.MayContain(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, checked
+		}
+	}
+	return true, checked
+}
+
+// BloomFilterMatcherValues returns the label values a series must have to match the matcher,
+// if the matcher can be checked against a bloom filter. A matcher can be checked if it's an equal
+// matcher or a regexp matcher of a set of values, and it doesn't match series without the label.
+func BloomFilterMatcherValues(m *labels.Matcher) ([]string, bool) {
+	if m.Matches("") {
+		return nil, false
+	}
+
+	switch m.Type {
+	case labels.MatchEqual:
+		return []string{m.Value}, true
+	case labels.MatchRegexp:
+		values := m.SetMatches()
+		return values, len(values) > 0
+	default:
+		return nil, false
+	}
+}
+
+// Encode returns the binary representation of the bloom filters.
+func (f BloomFilters) Encode() []byte {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	buf := encoding.Encbuf{}
+	buf.PutBE32(bloomFiltersMagic)
+	buf.PutByte(bloomFiltersVersion1)
+	buf.PutUvarint(len(names))
+	for _, name := range names {
+		filter := f[name]
+		buf.PutUvarintStr(name)
+		buf.PutUvarint32(filter.hashes)
+		buf.PutUvarint(len(filter.bits))
+		for _, w := range filter.bits {
+			buf.PutBE64(w)
+		}
+	}
+	buf.PutBE32(crc32.Checksum(buf.Get(), castagnoli))
+
+	return buf.Get()
+}
+
+// DecodeBloomFilters decodes the bloom filters encoded with BloomFilters.Encode.
+func DecodeBloomFilters(data []byte) (BloomFilters, error) {
+	if len(data) < 4 {
+		return nil, errors.New("bloom filters: data too short")
+	}
+	content, checksum := data[:len(data)-4], binary.BigEndian.Uint32(data[len(data)-4:])
+	if crc32.Checksum(content, castagnoli) != checksum {
+		return nil, errors.New("bloom filters: checksum mismatch")
+	}
+
+	d := encoding.Decbuf{B: content}
+	if magic := d.Be32(); d.Err() == nil && magic != bloomFiltersMagic {
+		return nil, errors.Errorf("bloom filters: invalid magic number %x", magic)
+	}
+	if version := d.Byte(); d.Err() == nil && version != bloomFiltersVersion1 {
+		return nil, errors.Errorf("bloom filters: unsupported version %d", version)
+	}
+
+	num := d.Uvarint()
+	if d.Err() == nil && num > d.Len() {
+		return nil, errors.Errorf("bloom filters: invalid number of filters %d", num)
+	}
+	filters := make(BloomFilters, num)
+	for i := 0; i < num && d.Err() == nil; i++ {
+		name := d.UvarintStr()
+		hashes := d.Uvarint32()
+		words := d.Uvarint()
+		if d.Err() != nil {
+			break
+		}
+		if hashes == 0 || words == 0 || words > d.Len()/8 {
+			return nil, errors.Errorf("bloom filters: invalid filter for label %q", name)
+		}
+
+		filter := &BloomFilter{hashes: hashes, bits: make([]uint64, words)}
+		for j := range filter.bits {
+			filter.bits[j] = d.Be64()
+		}
+		filters[name] = filter
+	}
+	if d.Err() != nil {
+		return nil, errors.Wrap(d.Err(), "bloom filters: decode")
+	}
+	if d.Len() > 0 {
+		return nil, errors.Errorf("bloom filters: %d unexpected trailing bytes", d.Len())
+	}
+
+	return filters, nil
+}
+
+// WriteBloomFilters builds the bloom filters of the values of the given label names from the index
+// of the block in blockDir, and writes them to the block's BloomFiltersFilename file. A filter is
+// written for each label name, even if the block has no series with the label, so that equal
+// matchers on the label rule out the block.
+func WriteBloomFilters(ctx context.Context, blockDir string, labelNames []string, falsePositiveRate float64) (returnErr error) {
+	indexr, err := index.NewFileReader(filepath.Join(blockDir, IndexFilename))
+	if err != nil {
+		return errors.Wrap(err, "open index")
+	}
+	defer runutil.CloseWithErrCapture(&returnErr, indexr, "close index")
+
+	filters := make(BloomFilters, len(labelNames))
+	for _, name := range labelNames {
+		values, err := indexr.SortedLabelValues(ctx, name)
+		if err != nil {
+			return errors.Wrapf(err, "read values of label %s", name)
+		}
+
+		filter := NewBloomFilter(len(values), falsePositiveRate)
+		for _, v := range values {
+			filter.Add(v)
+		}
+		filters[name] = filter
+	}
+
+	return errors.Wrap(os.WriteFile(filepath.Join(blockDir, BloomFiltersFilename), filters.Encode(), 0o666), "write bloom filters")
+}
+
+// HasBloomFilters returns whether the block has a BloomFiltersFilename file, according to its meta.
+func HasBloomFilters(meta *Meta) bool {
+	for _, f := range meta.Thanos.Files {
+		if f.RelPath == BloomFiltersFilename {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadBloomFilters downloads and decodes the bloom filters of the block from the bucket.
+func ReadBloomFilters(ctx context.Context, bkt objstore.BucketReader, id ulid.ULID) (_ BloomFilters, returnErr error) {
+	r, err := bkt.Get(ctx, path.Join(id.String(), BloomFiltersFilename))
+	if err != nil {
+		return nil, errors.Wrapf(err, "get bloom filters of block %s", id)
+	}
+	defer runutil.CloseWithErrCapture(&returnErr, r, "close bloom filters reader")
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read bloom filters of block %s", id)
+	}
+
+	return DecodeBloomFilters(data)
+}
@@ -0,0 +1,188 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package block
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestBloomFilter(t *testing.T) {
+	const (
+		values            = 10000
+		falsePositiveRate = 0.01
+	)
+
+	filter := NewBloomFilter(values, falsePositiveRate)
+	for i := 0; i < values; i++ {
+		filter.Add(fmt.Sprintf("pod-%d", i))
+	}
+
+	// There must be no false negatives.
+	for i := 0; i < values; i++ {
+		require.True(t, filter.MayContain(fmt.Sprintf("pod-%d", i)))
+	}
+
+	// The false positive rate must be close to the configured one.
+	falsePositives := 0
+	for i := values; i < 10*values; i++ {
+		if filter.MayContain(fmt.Sprintf("pod-%d", i)) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, float64(falsePositives)/float64(9*values), 2*falsePositiveRate)
+}
+
+func TestBloomFilter_Empty(t *testing.T) {
+	filter := NewBloomFilter(0, 0.01)
+	assert.False(t, filter.MayContain(""))
+	assert.False(t, filter.MayContain("value"))
+}
+
+func TestBloomFilters_MayMatch(t *testing.T) {
+	pods := NewBloomFilter(2, 0.001)
+	pods.Add("pod-1")
+	pods.Add("pod-2")
+	filters := BloomFilters{"pod": pods}
+
+	tests := map[string]struct {
+		matchers        []*labels.Matcher
+		expectedMatch   bool
+		expectedChecked int
+	}{
+		"no matchers": {
+			expectedMatch: true,
+		},
+		"equal matcher on a label without filter": {
+			matchers:      []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "job", "unknown")},
+			expectedMatch: true,
+		},
+		"equal matcher on an existing value": {
+			matchers:        []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "pod", "pod-1")},
+			expectedMatch:   true,
+			expectedChecked: 1,
+		},
+		"equal matcher on a missing value": {
+			matchers:        []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "pod", "pod-3")},
+			expectedMatch:   false,
+			expectedChecked: 1,
+		},
+		"equal matcher on the empty value": {
+			matchers:      []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "pod", "")},
+			expectedMatch: true,
+		},
+		"regexp matcher on a set of values including an existing one": {
+			matchers:        []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "pod", "pod-3|pod-2")},
+			expectedMatch:   true,
+			expectedChecked: 1,
+		},
+		"regexp matcher on a set of missing values": {
+			matchers:        []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "pod", "pod-3|pod-4")},
+			expectedMatch:   false,
+			expectedChecked: 1,
+		},
+		"regexp matcher on a set of values including the empty one": {
+			matchers:      []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "pod", "pod-3|")},
+			expectedMatch: true,
+		},
+		"regexp matcher which is not a set of values": {
+			matchers:      []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "pod", "pod-3.+")},
+			expectedMatch: true,
+		},
+		"not equal matcher": {
+			matchers:      []*labels.Matcher{labels.MustNewMatcher(labels.MatchNotEqual, "pod", "pod-1")},
+			expectedMatch: true,
+		},
+		"multiple matchers, one ruling out the block": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, "job", "test"),
+				labels.MustNewMatcher(labels.MatchEqual, "pod", "pod-2"),
+				labels.MustNewMatcher(labels.MatchEqual, "pod", "pod-3"),
+			},
+			expectedMatch:   false,
+			expectedChecked: 2,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			mayMatch, checked := filters.MayMatch(testData.matchers)
+			assert.Equal(t, testData.expectedMatch, mayMatch)
+			assert.Len(t, checked, testData.expectedChecked)
+		})
+	}
+}
+
+func TestBloomFilters_EncodeDecode(t *testing.T) {
+	pods := NewBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		pods.Add(fmt.Sprintf("pod-%d", i))
+	}
+	filters := BloomFilters{
+		"pod":       pods,
+		"container": NewBloomFilter(0, 0.01),
+	}
+
+	data := filters.Encode()
+	decoded, err := DecodeBloomFilters(data)
+	require.NoError(t, err)
+	assert.Equal(t, filters, decoded)
+
+	t.Run("should fail on truncated data", func(t *testing.T) {
+		for _, l := range []int{0, 3, len(data) / 2, len(data) - 1} {
+			_, err := DecodeBloomFilters(data[:l])
+			assert.Error(t, err, "length %d", l)
+		}
+	})
+
+	t.Run("should fail on corrupted data", func(t *testing.T) {
+		corrupted := append([]byte(nil), data...)
+		corrupted[len(corrupted)/2]++
+		_, err := DecodeBloomFilters(corrupted)
+		assert.Error(t, err)
+	})
+}
+
+func TestWriteBloomFilters(t *testing.T) {
+	ctx := context.Background()
+	tmpDir := t.TempDir()
+
+	id, err := CreateBlock(ctx, tmpDir, []labels.Labels{
+		labels.FromStrings("job", "a", "pod", "pod-1"),
+		labels.FromStrings("job", "a", "pod", "pod-2"),
+		labels.FromStrings("job", "b", "pod", "pod-3"),
+	}, 100, 0, 1000, labels.EmptyLabels())
+	require.NoError(t, err)
+
+	blockDir := filepath.Join(tmpDir, id.String())
+	require.NoError(t, WriteBloomFilters(ctx, blockDir, []string{"pod", "container"}, 0.01))
+
+	// The bloom filters file must be uploaded with the block.
+	bkt := objstore.NewInMemBucket()
+	require.NoError(t, Upload(ctx, log.NewNopLogger(), bkt, blockDir, nil))
+
+	meta, err := DownloadMeta(ctx, log.NewNopLogger(), bkt, id)
+	require.NoError(t, err)
+	require.True(t, HasBloomFilters(&meta))
+
+	filters, err := ReadBloomFilters(ctx, bkt, id)
+	require.NoError(t, err)
+	require.Len(t, filters, 2)
+
+	for _, pod := range []string{"pod-1", "pod-2", "pod-3"} {
+		mayMatch, _ := filters.MayMatch([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "pod", pod)})
+		assert.True(t, mayMatch, pod)
+	}
+
+	// The block has no series with the container label.
+	mayMatch, _ := filters.MayMatch([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "container", "c")})
+	assert.False(t, mayMatch)
+}
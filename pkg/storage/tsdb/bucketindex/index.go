@@ -90,6 +90,14 @@ type Block struct {
 
 	// Block's compactor shard ID, copied from tsdb.CompactorShardIDExternalLabel label.
 	CompactorShardID string `json:"compactor_shard_id,omitempty"`
+
+	// BloomFilters is true if the block has the block.BloomFiltersFilename sidecar file
+	// storing the bloom filters of some label values.
+	BloomFilters bool `json:"bloom_filters,omitempty"`
+
+	// Resolution is the downsampling resolution of the block, in milliseconds, copied from
+	// the block's meta. It's 0 for the raw blocks.
+	Resolution int64 `json:"resolution,omitempty"`
 }
 
 // Within returns whether the block contains samples within the provided range.
@@ -117,10 +125,20 @@ func (m *Block) ThanosMeta() *block.Meta {
 		Thanos: block.ThanosMeta{
 			Version:      block.ThanosVersion1,
 			SegmentFiles: m.thanosMetaSegmentFiles(),
+			Files:        m.thanosMetaFiles(),
+			Downsample:   block.ThanosDownsample{Resolution: m.Resolution},
 		},
 	}
 }
 
+// thanosMetaFiles returns the optional files of the block known by the index.
+func (m *Block) thanosMetaFiles() []block.File {
+	if m.BloomFilters {
+		return []block.File{{RelPath: block.BloomFiltersFilename}}
+	}
+	return nil
+}
+
 func (m *Block) thanosMetaSegmentFiles() (files []string) {
 	if m.SegmentsFormat == SegmentsFormat1Based6Digits {
 		for i := 1; i <= m.SegmentsNum; i++ {
@@ -153,6 +171,8 @@ func BlockFromThanosMeta(meta block.Meta) *Block {
 		SegmentsFormat:   segmentsFormat,
 		SegmentsNum:      segmentsNum,
 		CompactorShardID: meta.Thanos.Labels[mimir_tsdb.CompactorShardIDExternalLabel],
+		BloomFilters:     block.HasBloomFilters(&meta),
+		Resolution:       meta.Thanos.Downsample.Resolution,
 	}
 }
 
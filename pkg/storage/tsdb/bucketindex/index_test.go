@@ -272,6 +272,48 @@ func TestBlockFromThanosMeta(t *testing.T) {
 				CompactorShardID: "some weird value",
 			},
 		},
+		"meta.json with bloom filters": {
+			meta: block.Meta{
+				BlockMeta: tsdb.BlockMeta{
+					ULID:    blockID,
+					MinTime: 10,
+					MaxTime: 20,
+				},
+				Thanos: block.ThanosMeta{
+					Files: []block.File{
+						{RelPath: block.BloomFiltersFilename},
+						{RelPath: "chunks/000001"},
+						{RelPath: "index"},
+					},
+				},
+			},
+			expected: Block{
+				ID:             blockID,
+				MinTime:        10,
+				MaxTime:        20,
+				SegmentsFormat: SegmentsFormat1Based6Digits,
+				SegmentsNum:    1,
+				BloomFilters:   true,
+			},
+		},
+		"downsampled meta.json": {
+			meta: block.Meta{
+				BlockMeta: tsdb.BlockMeta{
+					ULID:    blockID,
+					MinTime: 10,
+					MaxTime: 20,
+				},
+				Thanos: block.ThanosMeta{
+					Downsample: block.ThanosDownsample{Resolution: 300000},
+				},
+			},
+			expected: Block{
+				ID:         blockID,
+				MinTime:    10,
+				MaxTime:    20,
+				Resolution: 300000,
+			},
+		},
 	}
 
 	for testName, testData := range tests {
@@ -378,6 +420,46 @@ func TestBlock_ThanosMeta(t *testing.T) {
 				},
 			},
 		},
+		"block with bloom filters": {
+			block: Block{
+				ID:           blockID,
+				MinTime:      10,
+				MaxTime:      20,
+				BloomFilters: true,
+			},
+			expected: &block.Meta{
+				BlockMeta: tsdb.BlockMeta{
+					ULID:    blockID,
+					MinTime: 10,
+					MaxTime: 20,
+					Version: block.TSDBVersion1,
+				},
+				Thanos: block.ThanosMeta{
+					Version: block.ThanosVersion1,
+					Files:   []block.File{{RelPath: block.BloomFiltersFilename}},
+				},
+			},
+		},
+		"downsampled block": {
+			block: Block{
+				ID:         blockID,
+				MinTime:    10,
+				MaxTime:    20,
+				Resolution: 300000,
+			},
+			expected: &block.Meta{
+				BlockMeta: tsdb.BlockMeta{
+					ULID:    blockID,
+					MinTime: 10,
+					MaxTime: 20,
+					Version: block.TSDBVersion1,
+				},
+				Thanos: block.ThanosMeta{
+					Version:    block.ThanosVersion1,
+					Downsample: block.ThanosDownsample{Resolution: 300000},
+				},
+			},
+		},
 	}
 
 	for testName, testData := range tests {
@@ -23,12 +23,17 @@ import (
 
 	"github.com/grafana/mimir/pkg/storage/tsdb/block"
 	"github.com/grafana/mimir/pkg/storage/tsdb/bucketcache"
+	"github.com/grafana/mimir/pkg/storage/tsdb/diskcache"
 )
 
 // subrangeSize is the size of each subrange that bucket objects are split into for better caching
 const subrangeSize int64 = 16000
 
-var supportedCacheBackends = []string{cache.BackendMemcached, cache.BackendRedis}
+var (
+	supportedCacheBackends = []string{cache.BackendMemcached, cache.BackendRedis}
+
+	errDiskCacheRequiresRemoteBackend = errors.New("the disk cache requires the memcached or redis cache backend")
+)
 
 type ChunksCacheConfig struct {
 	cache.BackendConfig `yaml:",inline"`
@@ -37,6 +42,8 @@ type ChunksCacheConfig struct {
 	AttributesTTL              time.Duration `yaml:"attributes_ttl" category:"advanced"`
 	AttributesInMemoryMaxItems int           `yaml:"attributes_in_memory_max_items" category:"advanced"`
 	SubrangeTTL                time.Duration `yaml:"subrange_ttl" category:"advanced"`
+
+	Disk diskcache.Config `yaml:"disk"`
 }
 
 func (cfg *ChunksCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
@@ -49,10 +56,60 @@ func (cfg *ChunksCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix st
 	f.DurationVar(&cfg.AttributesTTL, prefix+"attributes-ttl", 168*time.Hour, "TTL for caching object attributes for chunks. If the metadata cache is configured, attributes will be stored under this cache backend, otherwise attributes are stored in the chunks cache backend.")
 	f.IntVar(&cfg.AttributesInMemoryMaxItems, prefix+"attributes-in-memory-max-items", 50000, "Maximum number of object attribute items to keep in a first level in-memory LRU cache. Metadata will be stored and fetched in-memory before hitting the cache backend. 0 to disable the in-memory cache.")
 	f.DurationVar(&cfg.SubrangeTTL, prefix+"subrange-ttl", 24*time.Hour, "TTL for caching individual chunks subranges.")
+
+	cfg.Disk.RegisterFlagsWithPrefix(f, prefix+"disk.", "./disk-cache/chunks/")
 }
 
 func (cfg *ChunksCacheConfig) Validate() error {
-	return cfg.BackendConfig.Validate()
+	if err := cfg.BackendConfig.Validate(); err != nil {
+		return err
+	}
+	if cfg.Disk.Enabled && cfg.Backend == "" {
+		return errDiskCacheRequiresRemoteBackend
+	}
+	return cfg.Disk.Validate()
+}
+
+// CreateChunksCacheClient creates the chunks cache client, or returns nil if the chunks cache is
+// not configured. If the disk cache is enabled, it's used as a tier in front of the remote cache.
+func CreateChunksCacheClient(cfg ChunksCacheConfig, logger log.Logger, reg prometheus.Registerer) (cache.Cache, error) {
+	const cacheName = "chunks-cache"
+
+	if !cfg.Disk.Enabled {
+		return cache.CreateClient(cacheName, cfg.BackendConfig, logger, prometheus.WrapRegistererWithPrefix("thanos_", reg))
+	}
+
+	client, err := createRemoteCacheClient(cacheName, cfg.BackendConfig, logger, prometheus.WrapRegistererWithPrefix("thanos_", reg))
+	if err != nil {
+		return nil, err
+	}
+
+	// Chunks subranges are immutable, so items fetched from the remote cache are kept on disk
+	// as long as they would have been kept in the remote cache.
+	diskClient, err := diskcache.NewClient(cfg.Disk, cacheName, client, cfg.SubrangeTTL, logger, prometheus.WrapRegistererWithPrefix("cortex_", reg))
+	if err != nil {
+		client.Stop()
+		return nil, errors.Wrap(err, "create chunks disk cache")
+	}
+
+	if cfg.Backend == cache.BackendRedis {
+		return cache.NewRedisCache(cacheName, logger, diskClient, prometheus.WrapRegistererWithPrefix("thanos_", reg)), nil
+	}
+	return cache.NewMemcachedCache(cacheName, logger, diskClient, prometheus.WrapRegistererWithPrefix("thanos_", reg)), nil
+}
+
+// createRemoteCacheClient creates the memcached or redis client for the input backend config.
+func createRemoteCacheClient(cacheName string, cfg cache.BackendConfig, logger log.Logger, reg prometheus.Registerer) (cache.RemoteCacheClient, error) {
+	switch cfg.Backend {
+	case cache.BackendMemcached:
+		client, err := cache.NewMemcachedClientWithConfig(logger, cacheName, cfg.Memcached, reg)
+		return client, errors.Wrapf(err, "failed to create memcached client")
+	case cache.BackendRedis:
+		client, err := cache.NewRedisClient(logger, cacheName, cfg.Redis, reg)
+		return client, errors.Wrapf(err, "failed to create redis client")
+	default:
+		return nil, errDiskCacheRequiresRemoteBackend
+	}
 }
 
 type MetadataCacheConfig struct {
@@ -0,0 +1,620 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package diskcache
+
+import (
+	"container/list"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/cache"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	// tmpDirName is the name of the directory where the items are written before being moved
+	// to their final location, so that a partially written item is never visible.
+	tmpDirName = "tmp"
+
+	// fileNameLen is the length of the hex-encoded hash of the key used as item file name.
+	fileNameLen = blake2b.Size256 * 2
+
+	// headerLen is the length of the item file header: magic, expiration time, key length and value length.
+	headerLen = 4 + 8 + 4 + 4
+
+	// checksumLen is the length of the checksum at the end of the item file.
+	checksumLen = crc32.Size
+)
+
+var (
+	itemMagic = [4]byte{'M', 'D', 'C', '1'}
+
+	castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+	errCorruptedItem = errors.New("corrupted item")
+	errExpiredItem   = errors.New("expired item")
+	errKeyMismatch   = errors.New("key mismatch")
+	errQueueFull     = errors.New("the disk cache write queue is full")
+
+	errInvalidPath                = errors.New("the disk cache path must not be empty")
+	errInvalidMaxSizeBytes        = errors.New("the disk cache max size must be greater than 0")
+	errInvalidMaxAsyncConcurrency = errors.New("the disk cache max async concurrency must be greater than 0")
+)
+
+// Config configures the local disk cache tier.
+type Config struct {
+	Enabled             bool   `yaml:"enabled" category:"experimental"`
+	Path                string `yaml:"path" category:"experimental"`
+	MaxSizeBytes        uint64 `yaml:"max_size_bytes" category:"experimental"`
+	MaxItemSizeBytes    uint64 `yaml:"max_item_size_bytes" category:"experimental"`
+	MaxAsyncConcurrency int    `yaml:"max_async_concurrency" category:"experimental"`
+	MaxAsyncBufferSize  int    `yaml:"max_async_buffer_size" category:"experimental"`
+}
+
+func (cfg *Config) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix, defaultPath string) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "If enabled, a cache on the local disk is used as an additional tier in front of the remote cache. Items are looked up on the local disk first, and items fetched from the remote cache are written to the local disk. The items on the local disk survive restarts.")
+	f.StringVar(&cfg.Path, prefix+"path", defaultPath, "Directory where the local disk cache stores the items. The directory must not be shared with other caches.")
+	f.Uint64Var(&cfg.MaxSizeBytes, prefix+"max-size-bytes", 100*1024*1024*1024, "Maximum size in bytes of the items stored in the local disk cache. The least recently used items are evicted once the limit is reached.")
+	f.Uint64Var(&cfg.MaxItemSizeBytes, prefix+"max-item-size-bytes", 16*1024*1024, "Maximum size in bytes of a single item stored in the local disk cache. Larger items are only stored in the remote cache. 0 to disable the limit.")
+	f.IntVar(&cfg.MaxAsyncConcurrency, prefix+"max-async-concurrency", 4, "The maximum number of concurrent writes to the local disk cache.")
+	f.IntVar(&cfg.MaxAsyncBufferSize, prefix+"max-async-buffer-size", 10000, "The maximum number of enqueued writes to the local disk cache. Writes are dropped when the queue is full.")
+}
+
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Path == "" {
+		return errInvalidPath
+	}
+	if cfg.MaxSizeBytes == 0 {
+		return errInvalidMaxSizeBytes
+	}
+	if cfg.MaxAsyncConcurrency <= 0 {
+		return errInvalidMaxAsyncConcurrency
+	}
+	return nil
+}
+
+var _ cache.RemoteCacheClient = (*Client)(nil)
+
+// Client is a cache.RemoteCacheClient storing the items on the local disk, in front of another
+// cache.RemoteCacheClient. Items are looked up on the local disk first, and the missing ones are
+// fetched from the next client and written to the local disk. Items stored via the client are written
+// to both the local disk and the next client.
+//
+// Each item is stored in its own file, named after the hash of its key. The file contains the key,
+// the expiration time and a checksum of the item, so that the index of the items can be rebuilt from
+// the files when the client is created, and corrupted files are detected when read. The least recently
+// used items are evicted once the total size of the item files exceeds the configured max size.
+type Client struct {
+	cfg         Config
+	name        string
+	next        cache.RemoteCacheClient
+	backfillTTL time.Duration
+	logger      log.Logger
+
+	mtx     sync.Mutex
+	lru     *list.List               // Items ordered from the most to the least recently used.
+	entries map[string]*list.Element // Items by file name.
+	size    uint64                   // Total size of the item files.
+
+	queue   chan func()
+	stopCh  chan struct{}
+	workers sync.WaitGroup
+
+	requests      prometheus.Counter
+	hits          prometheus.Counter
+	evictions     prometheus.Counter
+	corrupted     prometheus.Counter
+	writeFailures prometheus.Counter
+	writesDropped prometheus.Counter
+	loadedItems   prometheus.Counter
+}
+
+type entry struct {
+	fileName  string
+	size      uint64
+	expiresAt time.Time
+}
+
+// NewClient makes a new Client, loading the items stored in the configured directory. The items
+// fetched from the next client are written to the local disk with the backfillTTL, because their
+// actual TTL is unknown.
+func NewClient(cfg Config, name string, next cache.RemoteCacheClient, backfillTTL time.Duration, logger log.Logger, reg prometheus.Registerer) (*Client, error) {
+	c := &Client{
+		cfg:         cfg,
+		name:        name,
+		next:        next,
+		backfillTTL: backfillTTL,
+		logger:      log.With(logger, "name", "disk-"+name),
+		lru:         list.New(),
+		entries:     map[string]*list.Element{},
+		queue:       make(chan func(), cfg.MaxAsyncBufferSize),
+		stopCh:      make(chan struct{}),
+	}
+
+	constLabels := prometheus.Labels{"name": name}
+	c.requests = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name:        "cache_disk_requests_total",
+		Help:        "Total number of requests to the disk cache.",
+		ConstLabels: constLabels,
+	})
+	c.hits = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name:        "cache_disk_hits_total",
+		Help:        "Total number of requests to the disk cache that were a hit.",
+		ConstLabels: constLabels,
+	})
+	c.evictions = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name:        "cache_disk_evicted_items_total",
+		Help:        "Total number of items evicted from the disk cache because the max size was reached.",
+		ConstLabels: constLabels,
+	})
+	c.corrupted = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name:        "cache_disk_corrupted_items_total",
+		Help:        "Total number of corrupted items found and removed from the disk cache.",
+		ConstLabels: constLabels,
+	})
+	c.writeFailures = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name:        "cache_disk_write_failures_total",
+		Help:        "Total number of items that failed to be written to the disk cache.",
+		ConstLabels: constLabels,
+	})
+	c.writesDropped = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name:        "cache_disk_writes_dropped_total",
+		Help:        "Total number of items not written to the disk cache because the write queue was full.",
+		ConstLabels: constLabels,
+	})
+	c.loadedItems = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name:        "cache_disk_loaded_items_total",
+		Help:        "Total number of items loaded from the disk cache directory at startup.",
+		ConstLabels: constLabels,
+	})
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "cache_disk_items_count",
+		Help:        "Total number of items currently in the disk cache.",
+		ConstLabels: constLabels,
+	}, func() float64 {
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+
+		return float64(c.lru.Len())
+	})
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "cache_disk_size_bytes",
+		Help:        "Total size in bytes of the items currently in the disk cache.",
+		ConstLabels: constLabels,
+	}, func() float64 {
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+
+		return float64(c.size)
+	})
+
+	if err := c.load(); err != nil {
+		return nil, errors.Wrapf(err, "load disk cache from %s", cfg.Path)
+	}
+
+	c.workers.Add(cfg.MaxAsyncConcurrency)
+	for i := 0; i < cfg.MaxAsyncConcurrency; i++ {
+		go c.processQueue()
+	}
+
+	return c, nil
+}
+
+// GetMulti implements cache.RemoteCacheClient.
+func (c *Client) GetMulti(ctx context.Context, keys []string, opts ...cache.Option) map[string][]byte {
+	found := make(map[string][]byte, len(keys))
+	missed := make([]string, 0, len(keys))
+
+	c.requests.Add(float64(len(keys)))
+	for _, key := range keys {
+		value, ok := c.get(key)
+		if !ok {
+			missed = append(missed, key)
+			continue
+		}
+		found[key] = value
+	}
+	c.hits.Add(float64(len(found)))
+
+	if len(missed) == 0 {
+		return found
+	}
+
+	for key, value := range c.next.GetMulti(ctx, missed, opts...) {
+		found[key] = value
+		c.enqueueWrite(key, value, c.backfillTTL)
+	}
+
+	return found
+}
+
+// SetAsync implements cache.RemoteCacheClient.
+func (c *Client) SetAsync(key string, value []byte, ttl time.Duration) error {
+	c.enqueueWrite(key, value, ttl)
+
+	return c.next.SetAsync(key, value, ttl)
+}
+
+// Delete implements cache.RemoteCacheClient.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	c.mtx.Lock()
+	if elem, ok := c.entries[fileNameForKey(key)]; ok {
+		c.removeLocked(elem)
+	}
+	c.mtx.Unlock()
+
+	return c.next.Delete(ctx, key)
+}
+
+// Stop implements cache.RemoteCacheClient. It waits for the in-flight writes to the local disk.
+func (c *Client) Stop() {
+	close(c.stopCh)
+	c.workers.Wait()
+
+	c.next.Stop()
+}
+
+// get returns the value of the item with the given key from the local disk.
+func (c *Client) get(key string) ([]byte, bool) {
+	fileName := fileNameForKey(key)
+
+	c.mtx.Lock()
+	elem, ok := c.entries[fileName]
+	if ok {
+		if e := elem.Value.(*entry); time.Now().After(e.expiresAt) {
+			c.removeLocked(elem)
+			ok = false
+		} else {
+			c.lru.MoveToFront(elem)
+		}
+	}
+	c.mtx.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	filePath := c.filePath(fileName)
+	value, err := readItem(filePath, key)
+	if err != nil {
+		if errors.Is(err, errCorruptedItem) {
+			c.corrupted.Inc()
+			level.Warn(c.logger).Log("msg", "removing corrupted item from disk cache", "file", filePath, "err", err)
+		}
+		if !errors.Is(err, errKeyMismatch) {
+			c.removeIfSame(elem)
+		}
+		return nil, false
+	}
+
+	// Keep track of the access time on the file too, so that the least recently used order is preserved
+	// across restarts.
+	now := time.Now()
+	_ = os.Chtimes(filePath, now, now)
+
+	return value, true
+}
+
+// enqueueWrite encodes the item and enqueues its write to the local disk. The item is encoded before
+// being enqueued, because the value may be reused by the caller once this function returns.
+func (c *Client) enqueueWrite(key string, value []byte, ttl time.Duration) {
+	if c.cfg.MaxItemSizeBytes > 0 && uint64(len(value)) > c.cfg.MaxItemSizeBytes {
+		return
+	}
+
+	fileName := fileNameForKey(key)
+	data := encodeItem(key, value, time.Now().Add(ttl))
+
+	select {
+	case c.queue <- func() { c.write(fileName, data) }:
+	default:
+		c.writesDropped.Inc()
+		level.Debug(c.logger).Log("msg", "failed to write item to disk cache", "err", errQueueFull)
+	}
+}
+
+func (c *Client) processQueue() {
+	defer c.workers.Done()
+
+	for {
+		select {
+		case op := <-c.queue:
+			op()
+		case <-c.stopCh:
+			return
+		}
+	}
+}
+
+// write writes the encoded item to a temporary file, and atomically moves it to its final location.
+func (c *Client) write(fileName string, data []byte) {
+	if err := c.writeFile(fileName, data); err != nil {
+		c.writeFailures.Inc()
+		level.Warn(c.logger).Log("msg", "failed to write item to disk cache", "file", fileName, "err", err)
+	}
+}
+
+func (c *Client) writeFile(fileName string, data []byte) (returnErr error) {
+	tmp, err := os.CreateTemp(filepath.Join(c.cfg.Path, tmpDirName), fileName+"-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if returnErr != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	filePath := c.filePath(fileName)
+	if err := os.MkdirAll(filepath.Dir(filePath), os.ModePerm); err != nil {
+		return err
+	}
+
+	// The rename and the index update are done while holding the lock, so that the index
+	// is consistent with the files, even if the same item is concurrently written or evicted.
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return err
+	}
+
+	e := &entry{fileName: fileName, size: uint64(len(data)), expiresAt: decodeExpiresAt(data)}
+	if elem, ok := c.entries[fileName]; ok {
+		c.size -= elem.Value.(*entry).size
+		elem.Value = e
+		c.lru.MoveToFront(elem)
+	} else {
+		c.entries[fileName] = c.lru.PushFront(e)
+	}
+	c.size += e.size
+
+	c.evictLocked()
+	return nil
+}
+
+// evictLocked removes the least recently used items until the total size is within the max size.
+// Must be called while holding the lock.
+func (c *Client) evictLocked() {
+	for c.size > c.cfg.MaxSizeBytes && c.lru.Len() > 0 {
+		c.removeLocked(c.lru.Back())
+		c.evictions.Inc()
+	}
+}
+
+// removeIfSame removes the item if it's still the one referenced by elem, which
+// may have been replaced by a concurrent write in the meanwhile.
+func (c *Client) removeIfSame(elem *list.Element) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	e := elem.Value.(*entry)
+	if current, ok := c.entries[e.fileName]; ok && current == elem {
+		c.removeLocked(elem)
+	}
+}
+
+// removeLocked removes the item from the index and the local disk. Must be called while holding the lock.
+func (c *Client) removeLocked(elem *list.Element) {
+	e := elem.Value.(*entry)
+
+	c.lru.Remove(elem)
+	delete(c.entries, e.fileName)
+	c.size -= e.size
+
+	if err := os.Remove(c.filePath(e.fileName)); err != nil && !os.IsNotExist(err) {
+		level.Warn(c.logger).Log("msg", "failed to remove item from disk cache", "file", e.fileName, "err", err)
+	}
+}
+
+// load rebuilds the index of the items from the files in the cache directory. Files which are
+// expired, partially written or not recognized as items are removed.
+func (c *Client) load() error {
+	start := time.Now()
+
+	tmpDir := filepath.Join(c.cfg.Path, tmpDirName)
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmpDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	type loadedEntry struct {
+		entry
+		modTime time.Time
+	}
+
+	var (
+		now    = time.Now()
+		loaded []loadedEntry
+	)
+
+	err := filepath.WalkDir(c.cfg.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == tmpDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		fileName := d.Name()
+		if !isItemFileName(fileName) || filepath.Base(filepath.Dir(path)) != fileName[:2] {
+			level.Warn(c.logger).Log("msg", "ignoring unexpected file in disk cache directory", "file", path)
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		expiresAt, err := readItemExpiresAt(path, info.Size())
+		if err != nil || now.After(expiresAt) {
+			if errors.Is(err, errCorruptedItem) {
+				c.corrupted.Inc()
+			}
+			if err := os.Remove(path); err != nil {
+				level.Warn(c.logger).Log("msg", "failed to remove item from disk cache", "file", path, "err", err)
+			}
+			return nil
+		}
+
+		loaded = append(loaded, loadedEntry{
+			entry:   entry{fileName: fileName, size: uint64(info.Size()), expiresAt: expiresAt},
+			modTime: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Add the items from the least to the most recently used one.
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].modTime.Before(loaded[j].modTime) })
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for i := range loaded {
+		e := loaded[i].entry
+		c.entries[e.fileName] = c.lru.PushFront(&e)
+		c.size += e.size
+	}
+	c.loadedItems.Add(float64(len(loaded)))
+
+	// The max size may have been reduced since the items were written.
+	c.evictLocked()
+
+	level.Info(c.logger).Log("msg", "loaded disk cache", "items", c.lru.Len(), "size_bytes", c.size, "duration", time.Since(start))
+	return nil
+}
+
+func (c *Client) filePath(fileName string) string {
+	// Spread the files across sub-directories to keep the size of each directory small.
+	return filepath.Join(c.cfg.Path, fileName[:2], fileName)
+}
+
+func fileNameForKey(key string) string {
+	sum := blake2b.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func isItemFileName(name string) bool {
+	if len(name) != fileNameLen {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
+
+// encodeItem encodes the item as: magic, expiration time, key length, value length, key, value and
+// the CRC32 checksum of all the previous fields.
+func encodeItem(key string, value []byte, expiresAt time.Time) []byte {
+	data := make([]byte, headerLen+len(key)+len(value)+checksumLen)
+
+	copy(data, itemMagic[:])
+	binary.BigEndian.PutUint64(data[4:], uint64(expiresAt.UnixMilli()))
+	binary.BigEndian.PutUint32(data[12:], uint32(len(key)))
+	binary.BigEndian.PutUint32(data[16:], uint32(len(value)))
+	copy(data[headerLen:], key)
+	copy(data[headerLen+len(key):], value)
+
+	checksumOffset := len(data) - checksumLen
+	binary.BigEndian.PutUint32(data[checksumOffset:], crc32.Checksum(data[:checksumOffset], castagnoliTable))
+
+	return data
+}
+
+func decodeExpiresAt(data []byte) time.Time {
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(data[4:])))
+}
+
+// decodeHeader validates the item header against the total size of the item, and returns the
+// length of the key and value.
+func decodeHeader(header []byte, size int64) (keyLen, valueLen int, err error) {
+	if len(header) < headerLen || [4]byte(header[:4]) != itemMagic {
+		return 0, 0, errors.Wrap(errCorruptedItem, "invalid header")
+	}
+
+	keyLen = int(binary.BigEndian.Uint32(header[12:]))
+	valueLen = int(binary.BigEndian.Uint32(header[16:]))
+	if int64(headerLen)+int64(keyLen)+int64(valueLen)+checksumLen != size {
+		return 0, 0, errors.Wrap(errCorruptedItem, "unexpected size")
+	}
+	return keyLen, valueLen, nil
+}
+
+// readItemExpiresAt reads the expiration time of the item from its header, without reading the whole item.
+func readItemExpiresAt(path string, size int64) (time.Time, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return time.Time{}, errors.Wrap(errCorruptedItem, err.Error())
+	}
+	if _, _, err := decodeHeader(header, size); err != nil {
+		return time.Time{}, err
+	}
+	return decodeExpiresAt(header), nil
+}
+
+// readItem reads and validates the item, returning its value.
+func readItem(path, key string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keyLen, valueLen, err := decodeHeader(data, int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	checksumOffset := len(data) - checksumLen
+	if crc32.Checksum(data[:checksumOffset], castagnoliTable) != binary.BigEndian.Uint32(data[checksumOffset:]) {
+		return nil, errors.Wrap(errCorruptedItem, "checksum mismatch")
+	}
+	if string(data[headerLen:headerLen+keyLen]) != key {
+		return nil, errKeyMismatch
+	}
+	if time.Now().After(decodeExpiresAt(data)) {
+		return nil, errExpiredItem
+	}
+
+	valueOffset := headerLen + keyLen
+	return data[valueOffset : valueOffset+valueLen : valueOffset+valueLen], nil
+}
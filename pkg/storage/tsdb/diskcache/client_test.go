@@ -0,0 +1,328 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package diskcache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_FetchFromNextAndBackfill(t *testing.T) {
+	next := newMockRemoteClient()
+	next.items["a"] = []byte("value-a")
+	next.items["b"] = []byte("value-b")
+
+	c, reg := newTestClient(t, testConfig(t), next)
+
+	assert.Equal(t, map[string][]byte{"a": []byte("value-a"), "b": []byte("value-b")}, c.GetMulti(context.Background(), []string{"a", "b", "c"}))
+	waitItems(t, c, 2)
+
+	// The items must now be fetched from the disk.
+	next.reset()
+	assert.Equal(t, map[string][]byte{"a": []byte("value-a"), "b": []byte("value-b")}, c.GetMulti(context.Background(), []string{"a", "b", "c"}))
+	assert.Equal(t, []string{"c"}, next.getMultiKeys())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_cache_disk_hits_total Total number of requests to the disk cache that were a hit.
+		# TYPE cortex_cache_disk_hits_total counter
+		cortex_cache_disk_hits_total{name="test"} 2
+		# HELP cortex_cache_disk_requests_total Total number of requests to the disk cache.
+		# TYPE cortex_cache_disk_requests_total counter
+		cortex_cache_disk_requests_total{name="test"} 6
+	`), "cortex_cache_disk_hits_total", "cortex_cache_disk_requests_total"))
+}
+
+func TestClient_SetAsyncAndDelete(t *testing.T) {
+	next := newMockRemoteClient()
+	c, _ := newTestClient(t, testConfig(t), next)
+
+	require.NoError(t, c.SetAsync("a", []byte("value-a"), time.Hour))
+	waitItems(t, c, 1)
+	assert.Equal(t, []byte("value-a"), next.items["a"])
+
+	next.reset()
+	assert.Equal(t, map[string][]byte{"a": []byte("value-a")}, c.GetMulti(context.Background(), []string{"a"}))
+
+	require.NoError(t, c.Delete(context.Background(), "a"))
+	assert.Equal(t, 0, c.lru.Len())
+	assert.Empty(t, c.GetMulti(context.Background(), []string{"a"}))
+	assert.NoFileExists(t, c.filePath(fileNameForKey("a")))
+}
+
+func TestClient_SurvivesRestart(t *testing.T) {
+	cfg := testConfig(t)
+
+	c, _ := newTestClient(t, cfg, newMockRemoteClient())
+	require.NoError(t, c.SetAsync("a", []byte("value-a"), time.Hour))
+	require.NoError(t, c.SetAsync("b", []byte("value-b"), time.Hour))
+	waitItems(t, c, 2)
+	c.Stop()
+
+	// Leave a partially written item behind, which must be ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.Path, tmpDirName, "partial"), []byte("MDC1"), os.ModePerm))
+
+	next := newMockRemoteClient()
+	c, reg := newTestClient(t, cfg, next)
+	assert.Equal(t, map[string][]byte{"a": []byte("value-a"), "b": []byte("value-b")}, c.GetMulti(context.Background(), []string{"a", "b"}))
+	assert.Empty(t, next.getMultiKeys())
+	assert.NoFileExists(t, filepath.Join(cfg.Path, tmpDirName, "partial"))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_cache_disk_loaded_items_total Total number of items loaded from the disk cache directory at startup.
+		# TYPE cortex_cache_disk_loaded_items_total counter
+		cortex_cache_disk_loaded_items_total{name="test"} 2
+	`), "cortex_cache_disk_loaded_items_total"))
+}
+
+func TestClient_ExpiredItems(t *testing.T) {
+	cfg := testConfig(t)
+	next := newMockRemoteClient()
+
+	c, _ := newTestClient(t, cfg, next)
+	require.NoError(t, c.SetAsync("expired", []byte("value"), -time.Second))
+	require.NoError(t, c.SetAsync("valid", []byte("value"), time.Hour))
+	waitItems(t, c, 2)
+
+	next.reset()
+	assert.Equal(t, map[string][]byte{"valid": []byte("value")}, c.GetMulti(context.Background(), []string{"expired", "valid"}))
+	assert.Equal(t, 1, c.lru.Len())
+
+	require.NoError(t, c.SetAsync("expired", []byte("value"), -time.Second))
+	waitItems(t, c, 2)
+	c.Stop()
+
+	// Expired items are removed when loaded.
+	c, _ = newTestClient(t, cfg, newMockRemoteClient())
+	assert.Equal(t, 1, c.lru.Len())
+	assert.NoFileExists(t, c.filePath(fileNameForKey("expired")))
+}
+
+func TestClient_Eviction(t *testing.T) {
+	cfg := testConfig(t)
+	itemSize := uint64(len(encodeItem("key-0", []byte("value-0"), time.Now())))
+	cfg.MaxSizeBytes = 3 * itemSize
+	cfg.MaxAsyncConcurrency = 1
+
+	next := newMockRemoteClient()
+	c, reg := newTestClient(t, cfg, next)
+	for _, key := range []string{"key-0", "key-1", "key-2"} {
+		require.NoError(t, c.SetAsync(key, []byte("value-"+key[4:]), time.Hour))
+		waitItems(t, c, 1+int(key[4]-'0'))
+	}
+
+	// Touch the first item, so that the second one is the least recently used.
+	assert.Len(t, c.GetMulti(context.Background(), []string{"key-0"}), 1)
+
+	require.NoError(t, c.SetAsync("key-3", []byte("value-3"), time.Hour))
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(c.evictions) == 1
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, 3, c.lru.Len())
+	assert.Equal(t, 3*itemSize, c.size)
+	assert.NoFileExists(t, c.filePath(fileNameForKey("key-1")))
+
+	next.reset()
+	assert.Len(t, c.GetMulti(context.Background(), []string{"key-0", "key-1", "key-2", "key-3"}), 3)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_cache_disk_size_bytes Total size in bytes of the items currently in the disk cache.
+		# TYPE cortex_cache_disk_size_bytes gauge
+		cortex_cache_disk_size_bytes{name="test"} 108
+		# HELP cortex_cache_disk_items_count Total number of items currently in the disk cache.
+		# TYPE cortex_cache_disk_items_count gauge
+		cortex_cache_disk_items_count{name="test"} 3
+	`), "cortex_cache_disk_size_bytes", "cortex_cache_disk_items_count"))
+
+	// The max size is honored when the items are loaded too.
+	c.Stop()
+	cfg.MaxSizeBytes = itemSize
+	c, _ = newTestClient(t, cfg, newMockRemoteClient())
+	assert.Equal(t, 1, c.lru.Len())
+}
+
+func TestClient_CorruptedItems(t *testing.T) {
+	cfg := testConfig(t)
+	next := newMockRemoteClient()
+
+	c, reg := newTestClient(t, cfg, next)
+	require.NoError(t, c.SetAsync("a", []byte("value-a"), time.Hour))
+	require.NoError(t, c.SetAsync("b", []byte("value-b"), time.Hour))
+	waitItems(t, c, 2)
+
+	// Flip a byte of the value of the first item, and truncate the second one.
+	pathA := c.filePath(fileNameForKey("a"))
+	data, err := os.ReadFile(pathA)
+	require.NoError(t, err)
+	data[headerLen+1+2] ^= 0xff
+	require.NoError(t, os.WriteFile(pathA, data, os.ModePerm))
+
+	pathB := c.filePath(fileNameForKey("b"))
+	require.NoError(t, os.Truncate(pathB, headerLen))
+
+	// The corrupted item is fetched from the next client instead.
+	next.items["a"] = []byte("value-a")
+	assert.Equal(t, map[string][]byte{"a": []byte("value-a")}, c.GetMulti(context.Background(), []string{"a"}))
+	assert.Equal(t, []string{"a"}, next.getMultiKeys())
+	assert.Equal(t, float64(1), testutil.ToFloat64(c.corrupted))
+	c.Stop()
+
+	// The truncated item is detected when loaded.
+	c, reg = newTestClient(t, cfg, newMockRemoteClient())
+	assert.NoFileExists(t, pathB)
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_cache_disk_corrupted_items_total Total number of corrupted items found and removed from the disk cache.
+		# TYPE cortex_cache_disk_corrupted_items_total counter
+		cortex_cache_disk_corrupted_items_total{name="test"} 1
+	`), "cortex_cache_disk_corrupted_items_total"))
+}
+
+func TestClient_MaxItemSize(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.MaxItemSizeBytes = 4
+
+	next := newMockRemoteClient()
+	c, _ := newTestClient(t, cfg, next)
+	require.NoError(t, c.SetAsync("small", []byte("1234"), time.Hour))
+	require.NoError(t, c.SetAsync("large", []byte("12345"), time.Hour))
+	waitItems(t, c, 1)
+
+	// Larger items are stored in the next client only.
+	assert.Equal(t, []byte("12345"), next.items["large"])
+	_, ok := c.entries[fileNameForKey("large")]
+	assert.False(t, ok)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cfg         Config
+		expectedErr error
+	}{
+		"disabled": {
+			cfg: Config{},
+		},
+		"valid": {
+			cfg: Config{Enabled: true, Path: "cache", MaxSizeBytes: 1, MaxAsyncConcurrency: 1},
+		},
+		"missing path": {
+			cfg:         Config{Enabled: true, MaxSizeBytes: 1, MaxAsyncConcurrency: 1},
+			expectedErr: errInvalidPath,
+		},
+		"zero max size": {
+			cfg:         Config{Enabled: true, Path: "cache", MaxAsyncConcurrency: 1},
+			expectedErr: errInvalidMaxSizeBytes,
+		},
+		"zero max async concurrency": {
+			cfg:         Config{Enabled: true, Path: "cache", MaxSizeBytes: 1},
+			expectedErr: errInvalidMaxAsyncConcurrency,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedErr, tc.cfg.Validate())
+		})
+	}
+}
+
+func testConfig(t *testing.T) Config {
+	return Config{
+		Enabled:             true,
+		Path:                t.TempDir(),
+		MaxSizeBytes:        1024 * 1024,
+		MaxAsyncConcurrency: 2,
+		MaxAsyncBufferSize:  100,
+	}
+}
+
+func newTestClient(t *testing.T, cfg Config, next cache.RemoteCacheClient) (*Client, *prometheus.Registry) {
+	reg := prometheus.NewPedanticRegistry()
+	c, err := NewClient(cfg, "test", next, time.Hour, log.NewNopLogger(), prometheus.WrapRegistererWithPrefix("cortex_", reg))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		// Some tests stop the client to reopen the same directory.
+		select {
+		case <-c.stopCh:
+		default:
+			c.Stop()
+		}
+	})
+	return c, reg
+}
+
+// waitItems waits until the disk cache contains the expected number of items.
+func waitItems(t *testing.T, c *Client, expected int) {
+	require.Eventually(t, func() bool {
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+		return c.lru.Len() == expected
+	}, time.Second, time.Millisecond)
+}
+
+type mockRemoteClient struct {
+	mtx   sync.Mutex
+	items map[string][]byte
+	keys  []string
+}
+
+func newMockRemoteClient() *mockRemoteClient {
+	return &mockRemoteClient{items: map[string][]byte{}}
+}
+
+func (m *mockRemoteClient) GetMulti(_ context.Context, keys []string, _ ...cache.Option) map[string][]byte {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.keys = append(m.keys, keys...)
+	found := map[string][]byte{}
+	for _, key := range keys {
+		if value, ok := m.items[key]; ok {
+			found[key] = value
+		}
+	}
+	return found
+}
+
+func (m *mockRemoteClient) SetAsync(key string, value []byte, _ time.Duration) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.items[key] = value
+	return nil
+}
+
+func (m *mockRemoteClient) Delete(_ context.Context, key string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	delete(m.items, key)
+	return nil
+}
+
+func (m *mockRemoteClient) Stop() {}
+
+func (m *mockRemoteClient) reset() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.items = map[string][]byte{}
+	m.keys = nil
+}
+
+func (m *mockRemoteClient) getMultiKeys() []string {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	return m.keys
+}
@@ -0,0 +1,266 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package downsample
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+)
+
+// ChunkEncAggr is the encoding of the chunks of the downsampled blocks. It's not a Prometheus
+// encoding, so downsampled blocks can only be read with the pool returned by NewPool.
+const ChunkEncAggr = chunkenc.Encoding(0xff)
+
+// AggrType is an aggregate of the samples of a downsampling window.
+type AggrType uint8
+
+const (
+	// AggrCount is the number of samples of the window.
+	AggrCount AggrType = iota
+	// AggrSum is the sum of the values of the window.
+	AggrSum
+	// AggrMin is the min value of the window.
+	AggrMin
+	// AggrMax is the max value of the window.
+	AggrMax
+	// AggrCounter is a subset of the raw samples of the window, which preserves the increase of the series
+	// when it's a counter: it includes the last sample of the window, and the samples before and after each
+	// counter reset.
+	AggrCounter
+
+	// AggrAvg is the average value of the window. It's not stored in the chunks, but computed
+	// from AggrSum and AggrCount when reading them.
+	AggrAvg
+)
+
+// numStoredAggrs is the number of aggregates stored in an AggrChunk.
+const numStoredAggrs = int(AggrCounter) + 1
+
+func (a AggrType) String() string {
+	switch a {
+	case AggrCount:
+		return "count"
+	case AggrSum:
+		return "sum"
+	case AggrMin:
+		return "min"
+	case AggrMax:
+		return "max"
+	case AggrCounter:
+		return "counter"
+	case AggrAvg:
+		return "avg"
+	default:
+		return fmt.Sprintf("<unknown aggregate %d>", a)
+	}
+}
+
+// AggrChunk is a chunk of a downsampled series. It holds an XOR chunk for each stored aggregate,
+// encoded as its length followed by its bytes. An aggregate with length zero is not set.
+type AggrChunk []byte
+
+// EncodeAggrChunk returns the AggrChunk holding the given XOR chunks, indexed by AggrType.
+func EncodeAggrChunk(chks [numStoredAggrs]chunkenc.Chunk) AggrChunk {
+	var b []byte
+	for _, c := range chks {
+		if c == nil {
+			b = binary.AppendUvarint(b, 0)
+			continue
+		}
+		b = binary.AppendUvarint(b, uint64(len(c.Bytes())))
+		b = append(b, c.Bytes()...)
+	}
+	return b
+}
+
+// Get returns the chunk of the given aggregate. It returns a nil chunk if the aggregate is not set.
+func (c AggrChunk) Get(t AggrType) (chunkenc.Chunk, error) {
+	if t == AggrAvg {
+		sum, err := c.Get(AggrSum)
+		if err != nil || sum == nil {
+			return nil, err
+		}
+		count, err := c.Get(AggrCount)
+		if err != nil || count == nil {
+			return nil, err
+		}
+		return &avgChunk{sum: sum, count: count}, nil
+	}
+	if int(t) >= numStoredAggrs {
+		return nil, errors.Errorf("unknown aggregate %d", t)
+	}
+
+	b := []byte(c)
+	for i := AggrType(0); i <= t; i++ {
+		l, n := binary.Uvarint(b)
+		if n <= 0 || uint64(len(b)-n) < l {
+			return nil, errors.Errorf("invalid aggregated chunk: aggregate %s is truncated", i)
+		}
+		b = b[n:]
+		if i == t {
+			if l == 0 {
+				return nil, nil
+			}
+			return chunkenc.FromData(chunkenc.EncXOR, b[:l])
+		}
+		b = b[l:]
+	}
+	return nil, nil
+}
+
+// Bytes implements chunkenc.Chunk.
+func (c AggrChunk) Bytes() []byte {
+	return c
+}
+
+// Encoding implements chunkenc.Chunk.
+func (c AggrChunk) Encoding() chunkenc.Encoding {
+	return ChunkEncAggr
+}
+
+// Appender implements chunkenc.Chunk. Aggregated chunks can't be appended to.
+func (c AggrChunk) Appender() (chunkenc.Appender, error) {
+	return nil, errors.New("aggregated chunks can't be appended to")
+}
+
+// Iterator implements chunkenc.Chunk. It iterates the average of each window, because it's the
+// aggregate which best approximates the raw samples.
+func (c AggrChunk) Iterator(it chunkenc.Iterator) chunkenc.Iterator {
+	chk, err := c.Get(AggrAvg)
+	if err != nil {
+		return errIterator{err: err}
+	}
+	if chk == nil {
+		return chunkenc.NewNopIterator()
+	}
+	return chk.Iterator(it)
+}
+
+// NumSamples implements chunkenc.Chunk. It returns the number of windows of the chunk.
+func (c AggrChunk) NumSamples() int {
+	chk, err := c.Get(AggrCount)
+	if err != nil || chk == nil {
+		return 0
+	}
+	return chk.NumSamples()
+}
+
+// Compact implements chunkenc.Chunk.
+func (c AggrChunk) Compact() {}
+
+// avgChunk is a read-only chunk whose values are the sum chunk values divided by the count chunk values.
+type avgChunk struct {
+	sum, count chunkenc.Chunk
+}
+
+func (c *avgChunk) Bytes() []byte               { return nil }
+func (c *avgChunk) Encoding() chunkenc.Encoding { return chunkenc.EncNone }
+func (c *avgChunk) NumSamples() int             { return c.count.NumSamples() }
+func (c *avgChunk) Compact()                    {}
+func (c *avgChunk) Appender() (chunkenc.Appender, error) {
+	return nil, errors.New("avg chunks can't be appended to")
+}
+func (c *avgChunk) Iterator(chunkenc.Iterator) chunkenc.Iterator {
+	return &avgIterator{sum: c.sum.Iterator(nil), count: c.count.Iterator(nil)}
+}
+
+// avgIterator iterates the sum and count iterators together, which have samples at the same timestamps.
+type avgIterator struct {
+	sum, count chunkenc.Iterator
+	err        error
+}
+
+func (it *avgIterator) Next() chunkenc.ValueType {
+	sumType, countType := it.sum.Next(), it.count.Next()
+	return it.check(sumType, countType)
+}
+
+func (it *avgIterator) Seek(t int64) chunkenc.ValueType {
+	sumType, countType := it.sum.Seek(t), it.count.Seek(t)
+	return it.check(sumType, countType)
+}
+
+func (it *avgIterator) check(sumType, countType chunkenc.ValueType) chunkenc.ValueType {
+	if sumType == chunkenc.ValNone || countType == chunkenc.ValNone {
+		return chunkenc.ValNone
+	}
+	if sumT, countT := it.sum.AtT(), it.count.AtT(); sumT != countT {
+		it.err = errors.Errorf("invalid aggregated chunk: sum sample at %d doesn't match count sample at %d", sumT, countT)
+		return chunkenc.ValNone
+	}
+	return chunkenc.ValFloat
+}
+
+func (it *avgIterator) At() (int64, float64) {
+	t, sum := it.sum.At()
+	_, count := it.count.At()
+	return t, sum / count
+}
+
+func (it *avgIterator) AtHistogram() (int64, *histogram.Histogram) {
+	panic("avgIterator: AtHistogram not implemented")
+}
+
+func (it *avgIterator) AtFloatHistogram() (int64, *histogram.FloatHistogram) {
+	panic("avgIterator: AtFloatHistogram not implemented")
+}
+
+func (it *avgIterator) AtT() int64 {
+	return it.sum.AtT()
+}
+
+func (it *avgIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	if err := it.sum.Err(); err != nil {
+		return err
+	}
+	return it.count.Err()
+}
+
+// errIterator is an iterator which only returns an error.
+type errIterator struct {
+	err error
+}
+
+func (it errIterator) Next() chunkenc.ValueType      { return chunkenc.ValNone }
+func (it errIterator) Seek(int64) chunkenc.ValueType { return chunkenc.ValNone }
+func (it errIterator) At() (int64, float64)          { return 0, math.NaN() }
+func (it errIterator) AtHistogram() (int64, *histogram.Histogram) {
+	return 0, nil
+}
+func (it errIterator) AtFloatHistogram() (int64, *histogram.FloatHistogram) {
+	return 0, nil
+}
+func (it errIterator) AtT() int64 { return 0 }
+func (it errIterator) Err() error { return it.err }
+
+// pool is a chunkenc.Pool which also supports the chunks of the downsampled blocks.
+type pool struct {
+	chunkenc.Pool
+}
+
+// NewPool returns a chunkenc.Pool which supports the ChunkEncAggr encoding, besides the Prometheus ones.
+func NewPool() chunkenc.Pool {
+	return &pool{Pool: chunkenc.NewPool()}
+}
+
+func (p *pool) Get(e chunkenc.Encoding, b []byte) (chunkenc.Chunk, error) {
+	if e == ChunkEncAggr {
+		return AggrChunk(b), nil
+	}
+	return p.Pool.Get(e, b)
+}
+
+func (p *pool) Put(c chunkenc.Chunk) error {
+	if c.Encoding() == ChunkEncAggr {
+		return nil
+	}
+	return p.Pool.Put(c)
+}
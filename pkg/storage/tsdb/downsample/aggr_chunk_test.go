@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package downsample
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggrChunk(t *testing.T) {
+	var chks [numStoredAggrs]chunkenc.Chunk
+	for i := range chks {
+		if AggrType(i) == AggrCounter {
+			// Leave the counter aggregate unset.
+			continue
+		}
+		chks[i] = chunkenc.NewXORChunk()
+		app, err := chks[i].Appender()
+		require.NoError(t, err)
+		for ts := int64(1); ts <= 3; ts++ {
+			app.Append(ts*100, float64(ts)*float64(i+1))
+		}
+	}
+
+	c := EncodeAggrChunk(chks)
+	assert.Equal(t, ChunkEncAggr, c.Encoding())
+	assert.Equal(t, 3, c.NumSamples())
+
+	for _, aggr := range []AggrType{AggrCount, AggrSum, AggrMin, AggrMax} {
+		chk, err := c.Get(aggr)
+		require.NoError(t, err)
+		require.NotNil(t, chk)
+		assert.Equal(t, []sample{{100, float64(aggr + 1)}, {200, 2 * float64(aggr+1)}, {300, 3 * float64(aggr+1)}}, readSamples(t, chk.Iterator(nil)), aggr.String())
+	}
+
+	counter, err := c.Get(AggrCounter)
+	require.NoError(t, err)
+	assert.Nil(t, counter)
+
+	// The average is the sum divided by the count, and it's what the chunk iterates.
+	assert.Equal(t, []sample{{100, 2}, {200, 2}, {300, 2}}, readSamples(t, c.Iterator(nil)))
+
+	_, err = c.Get(AggrAvg + 1)
+	assert.Error(t, err)
+
+	_, err = c[:len(c)-1].Get(AggrCounter)
+	assert.Error(t, err)
+}
+
+func TestPool(t *testing.T) {
+	p := NewPool()
+
+	aggr, err := p.Get(ChunkEncAggr, []byte{0, 0, 0, 0, 0})
+	require.NoError(t, err)
+	assert.IsType(t, AggrChunk{}, aggr)
+	assert.NoError(t, p.Put(aggr))
+
+	xor, err := p.Get(chunkenc.EncXOR, chunkenc.NewXORChunk().Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, chunkenc.EncXOR, xor.Encoding())
+	assert.NoError(t, p.Put(xor))
+}
+
+func readSamples(t *testing.T, it chunkenc.Iterator) []sample {
+	var res []sample
+	for it.Next() != chunkenc.ValNone {
+		ts, v := it.At()
+		res = append(res, sample{t: ts, v: v})
+	}
+	require.NoError(t, it.Err())
+	return res
+}
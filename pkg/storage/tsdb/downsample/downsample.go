@@ -0,0 +1,449 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package downsample
+
+import (
+	"context"
+	"crypto/rand"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+const (
+	// ResLevel0 is the resolution of the raw blocks.
+	ResLevel0 = int64(0)
+	// ResLevel1 is the resolution of the blocks downsampled from the raw blocks.
+	ResLevel1 = int64(5 * time.Minute / time.Millisecond)
+	// ResLevel2 is the resolution of the blocks downsampled from the ResLevel1 blocks.
+	ResLevel2 = int64(time.Hour / time.Millisecond)
+)
+
+// Resolutions is the list of the supported resolutions, from the finest to the coarsest.
+var Resolutions = []int64{ResLevel0, ResLevel1, ResLevel2}
+
+// maxWindowsPerChunk is the max number of downsampling windows of each chunk of a downsampled series.
+const maxWindowsPerChunk = 120
+
+// Downsample writes in dir a new block with the data of the block b aggregated at the given resolution,
+// and returns its ID. The series of the new block have one AggrChunk per maxWindowsPerChunk windows,
+// except the native histogram series, whose chunks are copied as they are.
+func Downsample(ctx context.Context, logger log.Logger, origMeta *block.Meta, b tsdb.BlockReader, dir string, resolution int64) (id ulid.ULID, returnErr error) {
+	origResolution := origMeta.Thanos.Downsample.Resolution
+	if origResolution >= resolution {
+		return id, errors.Errorf("block %s has resolution %d, which is not lower than the target resolution %d", origMeta.ULID, origResolution, resolution)
+	}
+
+	indexr, err := b.Index()
+	if err != nil {
+		return id, errors.Wrap(err, "open index reader")
+	}
+	defer func() {
+		if err := indexr.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "close index reader")
+		}
+	}()
+
+	chunkr, err := b.Chunks()
+	if err != nil {
+		return id, errors.Wrap(err, "open chunk reader")
+	}
+	defer func() {
+		if err := chunkr.Close(); err != nil && returnErr == nil {
+			returnErr = errors.Wrap(err, "close chunk reader")
+		}
+	}()
+
+	id = ulid.MustNew(ulid.Now(), rand.Reader)
+	blockDir := filepath.Join(dir, id.String())
+	if err := os.MkdirAll(blockDir, 0o750); err != nil {
+		return id, errors.Wrap(err, "create block dir")
+	}
+
+	var (
+		chunkw *chunks.Writer
+		indexw *index.Writer
+	)
+	defer func() {
+		if returnErr == nil {
+			return
+		}
+		// Release the writers, if they haven't been closed yet, and remove the partially written block.
+		if chunkw != nil {
+			_ = chunkw.Close()
+		}
+		if indexw != nil {
+			_ = indexw.Close()
+		}
+		_ = os.RemoveAll(blockDir)
+	}()
+
+	chunkw, err = chunks.NewWriter(filepath.Join(blockDir, block.ChunksDirname))
+	if err != nil {
+		return id, errors.Wrap(err, "open chunk writer")
+	}
+	indexw, err = index.NewWriter(ctx, filepath.Join(blockDir, block.IndexFilename))
+	if err != nil {
+		return id, errors.Wrap(err, "open index writer")
+	}
+
+	// The downsampled block has the same series, so the symbols of the original block are copied as they are.
+	symbols := indexr.Symbols()
+	for symbols.Next() {
+		if err := indexw.AddSymbol(symbols.At()); err != nil {
+			return id, errors.Wrap(err, "add symbol")
+		}
+	}
+	if err := symbols.Err(); err != nil {
+		return id, errors.Wrap(err, "read symbols")
+	}
+
+	k, v := index.AllPostingsKey()
+	postings, err := indexr.Postings(ctx, k, v)
+	if err != nil {
+		return id, errors.Wrap(err, "read postings")
+	}
+	postings = indexr.SortedPostings(postings)
+
+	var (
+		builder labels.ScratchBuilder
+		chks    []chunks.Meta
+		ref     storage.SeriesRef
+		stats   tsdb.BlockStats
+	)
+	for postings.Next() {
+		if err := ctx.Err(); err != nil {
+			return id, err
+		}
+
+		if err := indexr.Series(postings.At(), &builder, &chks); err != nil {
+			return id, errors.Wrap(err, "read series")
+		}
+		for i := range chks {
+			if chks[i].Chunk, err = chunkr.Chunk(chks[i]); err != nil {
+				return id, errors.Wrapf(err, "read chunk of series %s", builder.Labels())
+			}
+		}
+
+		out, err := downsampleSeries(chks, origResolution, resolution)
+		if err != nil {
+			return id, errors.Wrapf(err, "downsample series %s", builder.Labels())
+		}
+		if len(out) == 0 {
+			continue
+		}
+
+		if err := chunkw.WriteChunks(out...); err != nil {
+			return id, errors.Wrap(err, "write chunks")
+		}
+		if err := indexw.AddSeries(ref, builder.Labels(), out...); err != nil {
+			return id, errors.Wrap(err, "add series")
+		}
+		ref++
+
+		stats.NumSeries++
+		stats.NumChunks += uint64(len(out))
+		for _, c := range out {
+			stats.NumSamples += uint64(c.Chunk.NumSamples())
+		}
+	}
+	if err := postings.Err(); err != nil {
+		return id, errors.Wrap(err, "iterate postings")
+	}
+
+	err = chunkw.Close()
+	chunkw = nil
+	if err != nil {
+		return id, errors.Wrap(err, "close chunk writer")
+	}
+	err = indexw.Close()
+	indexw = nil
+	if err != nil {
+		return id, errors.Wrap(err, "close index writer")
+	}
+
+	meta := block.Meta{
+		BlockMeta: tsdb.BlockMeta{
+			ULID:       id,
+			MinTime:    origMeta.MinTime,
+			MaxTime:    origMeta.MaxTime,
+			Stats:      stats,
+			Compaction: origMeta.Compaction,
+			Version:    origMeta.Version,
+		},
+		Thanos: block.ThanosMeta{
+			Version:    block.ThanosVersion1,
+			Labels:     origMeta.Thanos.Labels,
+			Downsample: block.ThanosDownsample{Resolution: resolution},
+			Source:     block.CompactorSource,
+		},
+	}
+	if err := meta.WriteToDir(logger, blockDir); err != nil {
+		return id, errors.Wrap(err, "write meta")
+	}
+
+	return id, nil
+}
+
+// downsampleSeries returns the chunks of the series with the given chunks at the given resolution.
+func downsampleSeries(chks []chunks.Meta, origResolution, resolution int64) ([]chunks.Meta, error) {
+	for _, c := range chks {
+		if enc := c.Chunk.Encoding(); enc == chunkenc.EncHistogram || enc == chunkenc.EncFloatHistogram {
+			// Native histograms are not downsampled.
+			out := make([]chunks.Meta, 0, len(chks))
+			for _, c := range chks {
+				out = append(out, chunks.Meta{MinTime: c.MinTime, MaxTime: c.MaxTime, Chunk: c.Chunk})
+			}
+			return out, nil
+		}
+	}
+
+	var (
+		in  aggrSeries
+		err error
+	)
+	if origResolution == ResLevel0 {
+		in, err = newRawAggrSeries(chks)
+	} else {
+		in, err = newDownsampledAggrSeries(chks)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return in.downsample(resolution).encode(), nil
+}
+
+type sample struct {
+	t int64
+	v float64
+}
+
+// window holds the aggregates of the samples of a downsampling window, whose end is t.
+type window struct {
+	t                    int64
+	count, sum, min, max float64
+}
+
+// aggrSeries holds the aggregates of a series.
+type aggrSeries struct {
+	windows []window
+	counter []sample
+}
+
+// newRawAggrSeries returns the aggrSeries of the raw series with the given chunks, where each raw sample
+// is a window on its own. Stale markers are dropped, because the downsampled series are not meant
+// to be queried with the lookback delta of the raw series.
+func newRawAggrSeries(chks []chunks.Meta) (aggrSeries, error) {
+	var (
+		s     aggrSeries
+		lastT = int64(math.MinInt64)
+		it    chunkenc.Iterator
+	)
+	for _, c := range chks {
+		if c.Chunk.Encoding() != chunkenc.EncXOR {
+			return s, errors.Errorf("unexpected chunk encoding %s in raw series", c.Chunk.Encoding())
+		}
+		it = c.Chunk.Iterator(it)
+		for it.Next() != chunkenc.ValNone {
+			t, v := it.At()
+			if t <= lastT || value.IsStaleNaN(v) {
+				continue
+			}
+			lastT = t
+
+			s.windows = append(s.windows, window{t: t, count: 1, sum: v, min: v, max: v})
+			if !math.IsNaN(v) {
+				s.counter = append(s.counter, sample{t: t, v: v})
+			}
+		}
+		if err := it.Err(); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// newDownsampledAggrSeries returns the aggrSeries of the downsampled series with the given chunks.
+func newDownsampledAggrSeries(chks []chunks.Meta) (aggrSeries, error) {
+	var s aggrSeries
+	for _, c := range chks {
+		aggrChk, ok := c.Chunk.(AggrChunk)
+		if !ok {
+			return s, errors.Errorf("unexpected chunk encoding %s in downsampled series", c.Chunk.Encoding())
+		}
+
+		var its [numStoredAggrs]chunkenc.Iterator
+		for i := range its {
+			chk, err := aggrChk.Get(AggrType(i))
+			if err != nil {
+				return s, err
+			}
+			if chk == nil {
+				its[i] = chunkenc.NewNopIterator()
+				continue
+			}
+			its[i] = chk.Iterator(nil)
+		}
+
+		// The count, sum, min and max chunks have a sample for each window.
+		for its[AggrCount].Next() != chunkenc.ValNone {
+			t, count := its[AggrCount].At()
+			w := window{t: t, count: count}
+			for _, aggr := range []AggrType{AggrSum, AggrMin, AggrMax} {
+				if its[aggr].Next() == chunkenc.ValNone {
+					return s, errors.Errorf("invalid aggregated chunk: missing %s sample at %d", aggr, t)
+				}
+				at, v := its[aggr].At()
+				if at != t {
+					return s, errors.Errorf("invalid aggregated chunk: %s sample at %d doesn't match count sample at %d", aggr, at, t)
+				}
+				switch aggr {
+				case AggrSum:
+					w.sum = v
+				case AggrMin:
+					w.min = v
+				case AggrMax:
+					w.max = v
+				}
+			}
+			s.windows = append(s.windows, w)
+		}
+
+		for its[AggrCounter].Next() != chunkenc.ValNone {
+			t, v := its[AggrCounter].At()
+			s.counter = append(s.counter, sample{t: t, v: v})
+		}
+
+		for _, it := range its {
+			if err := it.Err(); err != nil {
+				return s, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// downsample returns the aggregates of the series at the given resolution.
+func (s aggrSeries) downsample(resolution int64) aggrSeries {
+	var out aggrSeries
+	for _, w := range s.windows {
+		t := windowEnd(w.t, resolution)
+		if n := len(out.windows); n > 0 && out.windows[n-1].t == t {
+			last := &out.windows[n-1]
+			last.count += w.count
+			last.sum += w.sum
+			last.min = math.Min(last.min, w.min)
+			last.max = math.Max(last.max, w.max)
+			continue
+		}
+		out.windows = append(out.windows, window{t: t, count: w.count, sum: w.sum, min: w.min, max: w.max})
+	}
+
+	out.counter = downsampleCounter(s.counter, resolution)
+	return out
+}
+
+// downsampleCounter returns the subset of the counter samples which preserves the increase of the counter:
+// the first sample, the last sample of each window, and the samples before and after each counter reset.
+// Since the values are not modified, the increase between two consecutive returned samples is the same as
+// the one of the input samples in between, and the counter resets are detected the same way, including
+// across downsampled blocks or between downsampled and raw blocks.
+func downsampleCounter(in []sample, resolution int64) []sample {
+	var (
+		out      []sample
+		lastKept = -1
+	)
+	keep := func(i int) {
+		if i > lastKept {
+			out = append(out, in[i])
+			lastKept = i
+		}
+	}
+
+	for i := range in {
+		switch {
+		case i == 0:
+			keep(i)
+		case in[i].v < in[i-1].v:
+			keep(i - 1)
+			keep(i)
+		case i == len(in)-1 || windowEnd(in[i+1].t, resolution) != windowEnd(in[i].t, resolution):
+			keep(i)
+		}
+	}
+	return out
+}
+
+// encode returns the chunks of the series.
+func (s aggrSeries) encode() []chunks.Meta {
+	var (
+		res     []chunks.Meta
+		windows = s.windows
+		counter = s.counter
+	)
+	for len(windows) > 0 {
+		batch := windows[:min(maxWindowsPerChunk, len(windows))]
+		windows = windows[len(batch):]
+
+		// The counter samples of the chunk are the ones of its windows, which end at the end of its last window.
+		minT, maxT := batch[0].t, batch[len(batch)-1].t
+		n := len(counter)
+		if len(windows) > 0 {
+			n = sort.Search(len(counter), func(i int) bool { return counter[i].t > maxT })
+		}
+		counterBatch := counter[:n]
+		counter = counter[n:]
+
+		var chks [numStoredAggrs]chunkenc.Chunk
+		var apps [numStoredAggrs]chunkenc.Appender
+		for i := range chks {
+			if AggrType(i) == AggrCounter && len(counterBatch) == 0 {
+				continue
+			}
+			chks[i] = chunkenc.NewXORChunk()
+			apps[i], _ = chks[i].Appender()
+		}
+
+		for _, w := range batch {
+			apps[AggrCount].Append(w.t, w.count)
+			apps[AggrSum].Append(w.t, w.sum)
+			apps[AggrMin].Append(w.t, w.min)
+			apps[AggrMax].Append(w.t, w.max)
+		}
+		for _, c := range counterBatch {
+			apps[AggrCounter].Append(c.t, c.v)
+		}
+		if len(counterBatch) > 0 {
+			minT = min(minT, counterBatch[0].t)
+			maxT = max(maxT, counterBatch[len(counterBatch)-1].t)
+		}
+
+		res = append(res, chunks.Meta{MinTime: minT, MaxTime: maxT, Chunk: EncodeAggrChunk(chks)})
+	}
+	return res
+}
+
+// windowEnd returns the timestamp of the last millisecond of the window of the given resolution which includes t.
+func windowEnd(t, resolution int64) int64 {
+	start := t - t%resolution
+	if t < 0 && t%resolution != 0 {
+		start -= resolution
+	}
+	return start + resolution - 1
+}
@@ -0,0 +1,248 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package downsample
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+)
+
+func TestWindowEnd(t *testing.T) {
+	assert.Equal(t, int64(ResLevel1-1), windowEnd(0, ResLevel1))
+	assert.Equal(t, int64(ResLevel1-1), windowEnd(ResLevel1-1, ResLevel1))
+	assert.Equal(t, int64(2*ResLevel1-1), windowEnd(ResLevel1, ResLevel1))
+	assert.Equal(t, int64(-1), windowEnd(-1, ResLevel1))
+	assert.Equal(t, int64(-1), windowEnd(-ResLevel1, ResLevel1))
+}
+
+func TestDownsampleCounter(t *testing.T) {
+	const res = 100
+
+	in := []sample{
+		{10, 1}, {20, 2}, {30, 3}, // Window 1.
+		{110, 4}, {120, 1}, {130, 2}, {140, 3}, // Window 2, with a reset.
+		{210, 5},           // Window 3.
+		{310, 6}, {320, 7}, // Window 4.
+	}
+	out := downsampleCounter(in, res)
+	assert.Equal(t, []sample{{10, 1}, {30, 3}, {110, 4}, {120, 1}, {140, 3}, {210, 5}, {320, 7}}, out)
+	assert.Equal(t, counterIncrease(in), counterIncrease(out))
+
+	// Downsampling the downsampled counter at a coarser resolution keeps the same increase.
+	out2 := downsampleCounter(out, 2*res)
+	assert.Equal(t, []sample{{10, 1}, {110, 4}, {120, 1}, {140, 3}, {320, 7}}, out2)
+	assert.Equal(t, counterIncrease(in), counterIncrease(out2))
+
+	assert.Empty(t, downsampleCounter(nil, res))
+}
+
+func TestDownsample(t *testing.T) {
+	const (
+		scrapeInterval = int64(15000)
+		numSamples     = 2 * 24 * 60 * 4 // 2 days of samples.
+	)
+
+	var (
+		ctx     = context.Background()
+		logger  = log.NewNopLogger()
+		dir     = t.TempDir()
+		gauge   = labels.FromStrings("__name__", "gauge")
+		counter = labels.FromStrings("__name__", "counter")
+	)
+
+	// The gauge alternates 0, 1, 2, 3 and has a stale marker in the middle, which is dropped.
+	// The counter increases by 1 at each sample and is reset every 1000 samples.
+	var gaugeSamples, counterSamples []sample
+	for i := int64(0); i < numSamples; i++ {
+		ts := i * scrapeInterval
+		if i == numSamples/2 {
+			gaugeSamples = append(gaugeSamples, sample{ts, math.Float64frombits(value.StaleNaN)})
+		} else {
+			gaugeSamples = append(gaugeSamples, sample{ts, float64(i % 4)})
+		}
+		counterSamples = append(counterSamples, sample{ts, float64(i % 1000)})
+	}
+
+	origMeta, err := block.GenerateBlockFromSpec("", dir, block.SeriesSpecs{
+		{Labels: gauge, Chunks: xorChunks(t, gaugeSamples)},
+		{Labels: counter, Chunks: xorChunks(t, counterSamples)},
+	})
+	require.NoError(t, err)
+	origMeta.Thanos.Labels = map[string]string{"ext": "1"}
+
+	// Downsample the raw block to 5m.
+	meta5m, series5m := downsampleBlock(t, ctx, logger, origMeta, filepath.Join(dir, origMeta.ULID.String()), dir, ResLevel1)
+	assert.Equal(t, ResLevel1, meta5m.Thanos.Downsample.Resolution)
+	assert.Equal(t, block.CompactorSource, meta5m.Thanos.Source)
+	assert.Equal(t, origMeta.Thanos.Labels, meta5m.Thanos.Labels)
+	assert.Equal(t, origMeta.MinTime, meta5m.MinTime)
+	assert.Equal(t, origMeta.MaxTime, meta5m.MaxTime)
+	assert.Equal(t, origMeta.Compaction.Sources, meta5m.Compaction.Sources)
+	assert.Equal(t, uint64(2), meta5m.Stats.NumSeries)
+
+	const windows5m = int(numSamples * scrapeInterval / ResLevel1)
+	assertAggregates(t, series5m[gauge.String()], ResLevel1, windows5m, 20)
+	assert.Equal(t, counterIncrease(counterSamples), counterIncrease(series5m[counter.String()][AggrCounter]))
+
+	// Raw samples of the gauge are 0, 1, 2, 3 repeated, so each window with all samples has the same aggregates.
+	for _, s := range series5m[gauge.String()][AggrMin] {
+		assert.Equal(t, float64(0), s.v)
+	}
+	for _, s := range series5m[gauge.String()][AggrMax] {
+		assert.Equal(t, float64(3), s.v)
+	}
+
+	// Downsample the 5m block to 1h.
+	meta1h, series1h := downsampleBlock(t, ctx, logger, meta5m, filepath.Join(dir, meta5m.ULID.String()), dir, ResLevel2)
+	assert.Equal(t, ResLevel2, meta1h.Thanos.Downsample.Resolution)
+
+	const windows1h = int(numSamples * scrapeInterval / ResLevel2)
+	assertAggregates(t, series1h[gauge.String()], ResLevel2, windows1h, 20*12)
+	assert.Equal(t, counterIncrease(counterSamples), counterIncrease(series1h[counter.String()][AggrCounter]))
+
+	// The sum and count of all windows is the same at all resolutions.
+	for _, aggr := range []AggrType{AggrCount, AggrSum} {
+		assert.Equal(t, sumValues(series5m[gauge.String()][aggr]), sumValues(series1h[gauge.String()][aggr]), aggr.String())
+	}
+
+	// Downsampling to the same resolution is not allowed.
+	_, err = Downsample(ctx, logger, meta1h, nil, dir, ResLevel2)
+	assert.Error(t, err)
+}
+
+// assertAggregates checks that the series has a sample per window at the end of the window, and that each window has
+// the expected number of samples, excluding the window with the stale marker.
+func assertAggregates(t *testing.T, series map[AggrType][]sample, resolution int64, expectedWindows, expectedCount int) {
+	for _, aggr := range []AggrType{AggrCount, AggrSum, AggrMin, AggrMax} {
+		require.Len(t, series[aggr], expectedWindows, aggr.String())
+		for i, s := range series[aggr] {
+			assert.Equal(t, int64(i+1)*resolution-1, s.t, aggr.String())
+		}
+	}
+
+	staleWindows := 0
+	for _, s := range series[AggrCount] {
+		if s.v != float64(expectedCount) {
+			assert.Equal(t, float64(expectedCount-1), s.v)
+			staleWindows++
+		}
+	}
+	assert.Equal(t, 1, staleWindows)
+}
+
+// downsampleBlock downsamples the block in blockDir to the given resolution, and returns the meta of the new block
+// with the samples of each aggregate of each series.
+func downsampleBlock(t *testing.T, ctx context.Context, logger log.Logger, meta *block.Meta, blockDir, dir string, resolution int64) (*block.Meta, map[string]map[AggrType][]sample) {
+	b, err := tsdb.OpenBlock(logger, blockDir, NewPool())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, b.Close()) }()
+
+	id, err := Downsample(ctx, logger, meta, b, dir, resolution)
+	require.NoError(t, err)
+
+	newBlockDir := filepath.Join(dir, id.String())
+	newMeta, err := block.ReadMetaFromDir(newBlockDir)
+	require.NoError(t, err)
+
+	nb, err := tsdb.OpenBlock(logger, newBlockDir, NewPool())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, nb.Close()) }()
+
+	indexr, err := nb.Index()
+	require.NoError(t, err)
+	defer func() { require.NoError(t, indexr.Close()) }()
+	chunkr, err := nb.Chunks()
+	require.NoError(t, err)
+	defer func() { require.NoError(t, chunkr.Close()) }()
+
+	k, v := index.AllPostingsKey()
+	postings, err := indexr.Postings(ctx, k, v)
+	require.NoError(t, err)
+
+	var (
+		builder labels.ScratchBuilder
+		chks    []chunks.Meta
+		res     = map[string]map[AggrType][]sample{}
+	)
+	for postings.Next() {
+		require.NoError(t, indexr.Series(postings.At(), &builder, &chks))
+		series := map[AggrType][]sample{}
+		lastMaxT := int64(math.MinInt64)
+		for _, meta := range chks {
+			require.Greater(t, meta.MinTime, lastMaxT, "chunks must not overlap")
+			lastMaxT = meta.MaxTime
+
+			chk, err := chunkr.Chunk(meta)
+			require.NoError(t, err)
+			require.Equal(t, ChunkEncAggr, chk.Encoding())
+
+			for aggr := AggrType(0); int(aggr) < numStoredAggrs; aggr++ {
+				aggrChk, err := chk.(AggrChunk).Get(aggr)
+				require.NoError(t, err)
+				if aggrChk == nil {
+					continue
+				}
+				for _, s := range readSamples(t, aggrChk.Iterator(nil)) {
+					require.True(t, s.t >= meta.MinTime && s.t <= meta.MaxTime)
+					series[aggr] = append(series[aggr], s)
+				}
+			}
+		}
+		res[builder.Labels().String()] = series
+	}
+	require.NoError(t, postings.Err())
+
+	return newMeta, res
+}
+
+func xorChunks(t *testing.T, samples []sample) []chunks.Meta {
+	var res []chunks.Meta
+	for len(samples) > 0 {
+		batch := samples[:min(120, len(samples))]
+		samples = samples[len(batch):]
+
+		chk := chunkenc.NewXORChunk()
+		app, err := chk.Appender()
+		require.NoError(t, err)
+		for _, s := range batch {
+			app.Append(s.t, s.v)
+		}
+		res = append(res, chunks.Meta{MinTime: batch[0].t, MaxTime: batch[len(batch)-1].t, Chunk: chk})
+	}
+	return res
+}
+
+// counterIncrease returns the increase of the counter with the given samples, accounting for counter resets.
+func counterIncrease(samples []sample) float64 {
+	var res float64
+	for i := 1; i < len(samples); i++ {
+		if samples[i].v < samples[i-1].v {
+			res += samples[i].v
+		} else {
+			res += samples[i].v - samples[i-1].v
+		}
+	}
+	return res
+}
+
+func sumValues(samples []sample) float64 {
+	var res float64
+	for _, s := range samples {
+		res += s.v
+	}
+	return res
+}
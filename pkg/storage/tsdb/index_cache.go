@@ -9,6 +9,7 @@ import (
 	"flag"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/units"
 	"github.com/go-kit/log"
@@ -17,6 +18,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/grafana/mimir/pkg/storage/tsdb/diskcache"
 	"github.com/grafana/mimir/pkg/storegateway/indexcache"
 	"github.com/grafana/mimir/pkg/util"
 )
@@ -35,17 +37,35 @@ const (
 	IndexCacheBackendDefault = IndexCacheBackendInMemory
 
 	defaultMaxItemSize = flagext.Bytes(128 * units.MiB)
+
+	// PostingsCodecDiffVarintSnappy is the value for the diff+varint+snappy postings codec.
+	PostingsCodecDiffVarintSnappy = "dvs"
+
+	// PostingsCodecRoaring is the value for the roaring bitmap postings codec.
+	PostingsCodecRoaring = "roaring"
+
+	// indexDiskCacheBackfillTTL is the TTL of the items fetched from the remote index cache and written to
+	// the disk cache. It matches the TTL of the items stored in the remote index cache.
+	indexDiskCacheBackfillTTL = 7 * 24 * time.Hour
 )
 
 var (
 	supportedIndexCacheBackends = []string{IndexCacheBackendInMemory, IndexCacheBackendMemcached, IndexCacheBackendRedis}
+	supportedPostingsCodecs     = []string{PostingsCodecDiffVarintSnappy, PostingsCodecRoaring}
 
-	errUnsupportedIndexCacheBackend = errors.New("unsupported index cache backend")
+	errUnsupportedIndexCacheBackend   = errors.New("unsupported index cache backend")
+	errUnsupportedPostingsCodec       = errors.New("unsupported postings codec")
+	errInvalidRoaringCodecMinPostings = errors.New("the roaring codec minimum number of postings must be greater than or equal to 0")
 )
 
 type IndexCacheConfig struct {
 	cache.BackendConfig `yaml:",inline"`
 	InMemory            InMemoryIndexCacheConfig `yaml:"inmemory"`
+	Disk                diskcache.Config         `yaml:"disk"`
+
+	PostingsCodec           string `yaml:"postings_codec" category:"experimental"`
+	ExpandedPostingsCodec   string `yaml:"expanded_postings_codec" category:"experimental"`
+	RoaringCodecMinPostings int    `yaml:"roaring_codec_min_postings" category:"experimental"`
 }
 
 func (cfg *IndexCacheConfig) RegisterFlags(f *flag.FlagSet) {
@@ -58,6 +78,11 @@ func (cfg *IndexCacheConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix str
 	cfg.InMemory.RegisterFlagsWithPrefix(prefix+"inmemory.", f)
 	cfg.Memcached.RegisterFlagsWithPrefix(prefix+"memcached.", f)
 	cfg.Redis.RegisterFlagsWithPrefix(prefix+"redis.", f)
+	cfg.Disk.RegisterFlagsWithPrefix(f, prefix+"disk.", "./disk-cache/index/")
+
+	f.StringVar(&cfg.PostingsCodec, prefix+"postings-codec", PostingsCodecDiffVarintSnappy, fmt.Sprintf("The codec used to encode postings stored in the index cache. Supported values: %s.", strings.Join(supportedPostingsCodecs, ", ")))
+	f.StringVar(&cfg.ExpandedPostingsCodec, prefix+"expanded-postings-codec", PostingsCodecDiffVarintSnappy, fmt.Sprintf("The codec used to encode expanded postings stored in the index cache. Supported values: %s.", strings.Join(supportedPostingsCodecs, ", ")))
+	f.IntVar(&cfg.RoaringCodecMinPostings, prefix+"roaring-codec-min-postings", 10000, "When the "+PostingsCodecRoaring+" codec is used, postings lists with fewer postings than this value are encoded with the "+PostingsCodecDiffVarintSnappy+" codec. Roaring bitmaps are larger than "+PostingsCodecDiffVarintSnappy+" encoded postings for short lists, but can be intersected without decoding them.")
 }
 
 // Validate the config.
@@ -65,15 +90,23 @@ func (cfg *IndexCacheConfig) Validate() error {
 	if !util.StringsContain(supportedIndexCacheBackends, cfg.Backend) {
 		return errUnsupportedIndexCacheBackend
 	}
+	if !util.StringsContain(supportedPostingsCodecs, cfg.PostingsCodec) || !util.StringsContain(supportedPostingsCodecs, cfg.ExpandedPostingsCodec) {
+		return errUnsupportedPostingsCodec
+	}
+	if cfg.RoaringCodecMinPostings < 0 {
+		return errInvalidRoaringCodecMinPostings
+	}
 
 	// Validate backend config only when not using the in-memory cache.
 	if cfg.Backend == IndexCacheBackendMemcached || cfg.Backend == IndexCacheBackendRedis {
 		if err := cfg.BackendConfig.Validate(); err != nil {
 			return err
 		}
+	} else if cfg.Disk.Enabled {
+		return errDiskCacheRequiresRemoteBackend
 	}
 
-	return nil
+	return cfg.Disk.Validate()
 }
 
 type InMemoryIndexCacheConfig struct {
@@ -90,9 +123,9 @@ func NewIndexCache(cfg IndexCacheConfig, logger log.Logger, registerer prometheu
 	case IndexCacheBackendInMemory:
 		return newInMemoryIndexCache(cfg.InMemory, logger, registerer)
 	case IndexCacheBackendMemcached:
-		return newMemcachedIndexCache(cfg.Memcached, logger, registerer)
+		return newMemcachedIndexCache(cfg.Memcached, cfg.Disk, logger, registerer)
 	case IndexCacheBackendRedis:
-		return newRedisIndexCache(cfg.Redis, logger, registerer)
+		return newRedisIndexCache(cfg.Redis, cfg.Disk, logger, registerer)
 	default:
 		return nil, errUnsupportedIndexCacheBackend
 	}
@@ -113,12 +146,17 @@ func newInMemoryIndexCache(cfg InMemoryIndexCacheConfig, logger log.Logger, regi
 	})
 }
 
-func newMemcachedIndexCache(cfg cache.MemcachedClientConfig, logger log.Logger, registerer prometheus.Registerer) (indexcache.IndexCache, error) {
-	client, err := cache.NewMemcachedClientWithConfig(logger, "index-cache", cfg, prometheus.WrapRegistererWithPrefix("thanos_", registerer))
+func newMemcachedIndexCache(cfg cache.MemcachedClientConfig, diskCfg diskcache.Config, logger log.Logger, registerer prometheus.Registerer) (indexcache.IndexCache, error) {
+	memcachedClient, err := cache.NewMemcachedClientWithConfig(logger, "index-cache", cfg, prometheus.WrapRegistererWithPrefix("thanos_", registerer))
 	if err != nil {
 		return nil, errors.Wrap(err, "create index cache memcached client")
 	}
 
+	client, err := wrapIndexCacheClientWithDiskCache(memcachedClient, diskCfg, logger, registerer)
+	if err != nil {
+		return nil, err
+	}
+
 	c, err := indexcache.NewRemoteIndexCache(logger, client, registerer)
 	if err != nil {
 		return nil, errors.Wrap(err, "create memcached-based index cache")
@@ -127,12 +165,17 @@ func newMemcachedIndexCache(cfg cache.MemcachedClientConfig, logger log.Logger,
 	return indexcache.NewTracingIndexCache(c, logger), nil
 }
 
-func newRedisIndexCache(cfg cache.RedisClientConfig, logger log.Logger, registerer prometheus.Registerer) (indexcache.IndexCache, error) {
-	client, err := cache.NewRedisClient(logger, "index-cache", cfg, prometheus.WrapRegistererWithPrefix("thanos_", registerer))
+func newRedisIndexCache(cfg cache.RedisClientConfig, diskCfg diskcache.Config, logger log.Logger, registerer prometheus.Registerer) (indexcache.IndexCache, error) {
+	redisClient, err := cache.NewRedisClient(logger, "index-cache", cfg, prometheus.WrapRegistererWithPrefix("thanos_", registerer))
 	if err != nil {
 		return nil, errors.Wrap(err, "create index cache redis client")
 	}
 
+	client, err := wrapIndexCacheClientWithDiskCache(redisClient, diskCfg, logger, registerer)
+	if err != nil {
+		return nil, err
+	}
+
 	c, err := indexcache.NewRemoteIndexCache(logger, client, registerer)
 	if err != nil {
 		return nil, errors.Wrap(err, "create redis-based index cache")
@@ -140,3 +183,17 @@ func newRedisIndexCache(cfg cache.RedisClientConfig, logger log.Logger, register
 
 	return indexcache.NewTracingIndexCache(c, logger), nil
 }
+
+// wrapIndexCacheClientWithDiskCache puts the disk cache in front of the remote index cache client, if enabled.
+func wrapIndexCacheClientWithDiskCache(client cache.RemoteCacheClient, cfg diskcache.Config, logger log.Logger, registerer prometheus.Registerer) (cache.RemoteCacheClient, error) {
+	if !cfg.Enabled {
+		return client, nil
+	}
+
+	diskClient, err := diskcache.NewClient(cfg, "index-cache", client, indexDiskCacheBackfillTTL, logger, prometheus.WrapRegistererWithPrefix("cortex_", registerer))
+	if err != nil {
+		client.Stop()
+		return nil, errors.Wrap(err, "create index disk cache")
+	}
+	return diskClient, nil
+}
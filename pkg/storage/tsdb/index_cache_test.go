@@ -11,6 +11,8 @@ import (
 	"github.com/grafana/dskit/cache"
 	"github.com/grafana/dskit/flagext"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/diskcache"
 )
 
 func TestIndexCacheConfig_Validate(t *testing.T) {
@@ -71,6 +73,27 @@ func TestIndexCacheConfig_Validate(t *testing.T) {
 				},
 			},
 		},
+		"disk cache with memcached should pass": {
+			cfg: IndexCacheConfig{
+				BackendConfig: cache.BackendConfig{
+					Backend: IndexCacheBackendMemcached,
+					Memcached: cache.MemcachedClientConfig{
+						Addresses:           []string{"dns+localhost:11211"},
+						MaxAsyncConcurrency: 1,
+					},
+				},
+				Disk: diskcache.Config{Enabled: true, Path: "index-cache", MaxSizeBytes: 1024, MaxAsyncConcurrency: 1},
+			},
+		},
+		"disk cache with inmemory should fail": {
+			cfg: IndexCacheConfig{
+				BackendConfig: cache.BackendConfig{
+					Backend: IndexCacheBackendInMemory,
+				},
+				Disk: diskcache.Config{Enabled: true, Path: "index-cache", MaxSizeBytes: 1024, MaxAsyncConcurrency: 1},
+			},
+			expected: errDiskCacheRequiresRemoteBackend,
+		},
 		"inmemory should pass": {
 			cfg: IndexCacheConfig{
 				BackendConfig: cache.BackendConfig{
@@ -78,10 +101,55 @@ func TestIndexCacheConfig_Validate(t *testing.T) {
 				},
 			},
 		},
+		"roaring postings codecs should pass": {
+			cfg: IndexCacheConfig{
+				BackendConfig: cache.BackendConfig{
+					Backend: IndexCacheBackendInMemory,
+				},
+				PostingsCodec:           PostingsCodecRoaring,
+				ExpandedPostingsCodec:   PostingsCodecRoaring,
+				RoaringCodecMinPostings: 0,
+			},
+		},
+		"unsupported postings codec should fail": {
+			cfg: IndexCacheConfig{
+				BackendConfig: cache.BackendConfig{
+					Backend: IndexCacheBackendInMemory,
+				},
+				PostingsCodec: "xxx",
+			},
+			expected: errUnsupportedPostingsCodec,
+		},
+		"unsupported expanded postings codec should fail": {
+			cfg: IndexCacheConfig{
+				BackendConfig: cache.BackendConfig{
+					Backend: IndexCacheBackendInMemory,
+				},
+				ExpandedPostingsCodec: "xxx",
+			},
+			expected: errUnsupportedPostingsCodec,
+		},
+		"negative roaring codec min postings should fail": {
+			cfg: IndexCacheConfig{
+				BackendConfig: cache.BackendConfig{
+					Backend: IndexCacheBackendInMemory,
+				},
+				RoaringCodecMinPostings: -1,
+			},
+			expected: errInvalidRoaringCodecMinPostings,
+		},
 	}
 
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
+			// Default the postings codecs, unless the test case is about them.
+			if testData.cfg.PostingsCodec == "" {
+				testData.cfg.PostingsCodec = PostingsCodecDiffVarintSnappy
+			}
+			if testData.cfg.ExpandedPostingsCodec == "" {
+				testData.cfg.ExpandedPostingsCodec = PostingsCodecDiffVarintSnappy
+			}
+
 			assert.Equal(t, testData.expected, testData.cfg.Validate())
 		})
 	}
@@ -28,6 +28,7 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb/chunks"
 	"github.com/prometheus/prometheus/tsdb/encoding"
@@ -111,7 +112,15 @@ type BucketStore struct {
 	// seriesLimiterFactory creates a new limiter used to limit the number of touched series by each Series() call,
 	// or LabelName and LabelValues calls when used with matchers.
 	seriesLimiterFactory SeriesLimiterFactory
-	partitioners         blockPartitioners
+	// chunkBytesLimiterFactory creates a new limiter used to limit the size of the chunks fetched by each Series() call.
+	chunkBytesLimiterFactory ChunkBytesLimiterFactory
+	partitioners             blockPartitioners
+
+	// Codecs used to encode the postings stored in the index cache.
+	postingsCodecs postingsCacheCodecs
+
+	// Whether the label values bloom filters of the blocks are used to skip blocks.
+	bloomFiltersEnabled bool
 
 	// Every how many posting offset entry we pool in heap memory. Default in Prometheus is 32.
 	postingOffsetsInMemSampling int
@@ -121,6 +130,9 @@ type BucketStore struct {
 
 	// postingsStrategy is a strategy shared among all tenants.
 	postingsStrategy postingsSelectionStrategy
+
+	// aggregationPushdownEngine evaluates the aggregation pushdown requested by the queriers on each block.
+	aggregationPushdownEngine *promql.Engine
 }
 
 type noopCache struct{}
@@ -160,6 +172,12 @@ func (noopCache) FetchLabelValues(_ context.Context, _ string, _ ulid.ULID, _ st
 	return nil, false
 }
 
+func (noopCache) StoreLabelValuesCardinality(_ string, _ ulid.ULID, _ string, _ indexcache.LabelMatchersKey, _ []byte) {
+}
+func (noopCache) FetchLabelValuesCardinality(_ context.Context, _ string, _ ulid.ULID, _ string, _ indexcache.LabelMatchersKey) ([]byte, bool) {
+	return nil, false
+}
+
 // BucketStoreOption are functions that configure BucketStore.
 type BucketStoreOption func(s *BucketStore)
 
@@ -191,6 +209,14 @@ func WithLazyLoadingGate(lazyLoadingGate gate.Gate) BucketStoreOption {
 	}
 }
 
+// WithChunkBytesLimiterFactory sets the factory of the limiters of the size of the chunks fetched by each
+// Series() call, instead of not limiting it.
+func WithChunkBytesLimiterFactory(factory ChunkBytesLimiterFactory) BucketStoreOption {
+	return func(s *BucketStore) {
+		s.chunkBytesLimiterFactory = factory
+	}
+}
+
 // NewBucketStore creates a new bucket backed store that implements the store API against
 // an object store bucket. It is optimized to work against high latency backends.
 func NewBucketStore(
@@ -220,7 +246,10 @@ func NewBucketStore(
 		lazyLoadingGate:             gate.NewNoop(),
 		chunksLimiterFactory:        chunksLimiterFactory,
 		seriesLimiterFactory:        seriesLimiterFactory,
+		chunkBytesLimiterFactory:    NewChunkBytesLimiterFactory(func() uint64 { return 0 }),
 		partitioners:                partitioners,
+		postingsCodecs:              newPostingsCacheCodecs(bucketStoreConfig.IndexCache),
+		bloomFiltersEnabled:         bucketStoreConfig.BloomFiltersEnabled,
 		postingOffsetsInMemSampling: bucketStoreConfig.PostingOffsetsInMemSampling,
 		indexHeaderCfg:              bucketStoreConfig.IndexHeader,
 		seriesHashCache:             seriesHashCache,
@@ -234,6 +263,8 @@ func NewBucketStore(
 		option(s)
 	}
 
+	s.aggregationPushdownEngine = newAggregationPushdownEngine(s.logger)
+
 	lazyLoadedSnapshotConfig := indexheader.LazyLoadedHeadersSnapshotConfig{
 		Path:   dir,
 		UserID: userID,
@@ -357,6 +388,10 @@ func (s *BucketStore) InitialSync(ctx context.Context) error {
 		return errors.Wrap(err, "sync block")
 	}
 
+	// Eagerly load the index-headers which were loaded before the previous shutdown, so that
+	// the store-gateway doesn't pay their loading cost on the first queries after being ready.
+	s.indexReaderPool.EagerLoadPreShutdownLoadedReaders(ctx, s.blockSyncConcurrency)
+
 	fis, err := os.ReadDir(s.dir)
 	if err != nil {
 		return errors.Wrap(err, "read dir")
@@ -438,6 +473,7 @@ func (s *BucketStore) addBlock(ctx context.Context, meta *block.Meta, initialSyn
 		s.indexCache,
 		indexHeaderReader,
 		s.partitioners,
+		s.postingsCodecs,
 	)
 	if err != nil {
 		return errors.Wrap(err, "new bucket block")
@@ -448,6 +484,8 @@ func (s *BucketStore) addBlock(ctx context.Context, meta *block.Meta, initialSyn
 		}
 	}()
 
+	b.bloomFilters = s.loadBloomFilters(ctx, meta)
+
 	s.blocksMx.Lock()
 	defer s.blocksMx.Unlock()
 
@@ -572,6 +610,7 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		ctx              = srv.Context()
 		stats            = newSafeQueryStats()
 		reqBlockMatchers []*labels.Matcher
+		reqPushdown      *hintspb.AggregationPushdown
 	)
 	defer s.recordSeriesCallResult(stats)
 
@@ -585,11 +624,17 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 		if err != nil {
 			return status.Error(codes.InvalidArgument, errors.Wrap(err, "translate request hints labels matchers").Error())
 		}
+
+		reqPushdown = reqHints.AggregationPushdown
 	}
 
 	logSeriesRequestToSpan(srv.Context(), s.logger, req.MinTime, req.MaxTime, matchers, reqBlockMatchers, shardSelector, req.StreamingChunksBatchSize)
 
-	blocks, indexReaders, chunkReaders := s.openBlocksForReading(ctx, req.SkipChunks, req.MinTime, req.MaxTime, reqBlockMatchers, stats)
+	if reqPushdown != nil {
+		return s.seriesAggregationPushdown(ctx, req, srv, reqPushdown, matchers, reqBlockMatchers, shardSelector, stats)
+	}
+
+	blocks, skippedBlocks, bloomFilterChecks, indexReaders, chunkReaders := s.openBlocksForReading(ctx, req.SkipChunks, req.MinTime, req.MaxTime, reqBlockMatchers, matchers, stats)
 	// We must keep the readers open until all their data has been sent.
 	for _, r := range indexReaders {
 		defer runutil.CloseWithLogOnErr(s.logger, r, "close block index reader")
@@ -622,6 +667,10 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 	for _, b := range blocks {
 		resHints.AddQueriedBlock(b.meta.ULID)
 	}
+	// The blocks skipped by their bloom filters have been queried, as they can't contain any matching series.
+	for _, b := range skippedBlocks {
+		resHints.AddQueriedBlock(b.meta.ULID)
+	}
 	if err := s.sendHints(srv, resHints); err != nil {
 		return err
 	}
@@ -635,7 +684,7 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 			seriesLimiter   = s.seriesLimiterFactory(s.metrics.queriesDropped.WithLabelValues("series"))
 		)
 
-		seriesSet, reuse, err = s.streamingSeriesForBlocks(ctx, req, blocks, indexReaders, shardSelector, matchers, chunksLimiter, seriesLimiter, stats)
+		seriesSet, reuse, err = s.streamingSeriesForBlocks(ctx, req, blocks, bloomFilterChecks, indexReaders, shardSelector, matchers, chunksLimiter, seriesLimiter, stats)
 		if err != nil {
 			return err
 		}
@@ -660,18 +709,19 @@ func (s *BucketStore) Series(req *storepb.SeriesRequest, srv storepb.Store_Serie
 	// and hit the limit prematurely.
 	chunksLimiter := s.chunksLimiterFactory(s.metrics.queriesDropped.WithLabelValues("chunks"))
 	seriesLimiter := s.seriesLimiterFactory(s.metrics.queriesDropped.WithLabelValues("series"))
+	chunkBytesLimiter := s.chunkBytesLimiterFactory(s.metrics.queriesDropped.WithLabelValues("chunk_bytes"))
 
 	start := time.Now()
 	if req.StreamingChunksBatchSize > 0 {
 		var seriesChunkIt seriesChunksSetIterator
-		seriesChunkIt, err = s.streamingChunksSetForBlocks(ctx, req, blocks, indexReaders, readers, shardSelector, matchers, chunksLimiter, seriesLimiter, stats, reuse)
+		seriesChunkIt, err = s.streamingChunksSetForBlocks(ctx, req, blocks, indexReaders, readers, shardSelector, matchers, chunksLimiter, seriesLimiter, chunkBytesLimiter, stats, reuse)
 		if err != nil {
 			return err
 		}
 		err = s.sendStreamingChunks(req, srv, seriesChunkIt, stats, streamingSeriesCount)
 	} else {
 		var seriesSet storepb.SeriesSet
-		seriesSet, err = s.nonStreamingSeriesSetForBlocks(ctx, req, blocks, indexReaders, readers, shardSelector, matchers, chunksLimiter, seriesLimiter, stats)
+		seriesSet, err = s.nonStreamingSeriesSetForBlocks(ctx, req, blocks, bloomFilterChecks, indexReaders, readers, shardSelector, matchers, chunksLimiter, seriesLimiter, chunkBytesLimiter, stats)
 		if err != nil {
 			return err
 		}
@@ -1003,26 +1053,28 @@ func (s *BucketStore) nonStreamingSeriesSetForBlocks(
 	ctx context.Context,
 	req *storepb.SeriesRequest,
 	blocks []*bucketBlock,
+	bloomFilterChecks map[ulid.ULID][]*labels.Matcher, // The matchers checked against the bloom filters of each block.
 	indexReaders map[ulid.ULID]*bucketIndexReader,
 	chunkReaders *bucketChunkReaders,
 	shardSelector *sharding.ShardSelector,
 	matchers []*labels.Matcher,
 	chunksLimiter ChunksLimiter, // Rate limiter for loading chunks.
 	seriesLimiter SeriesLimiter, // Rate limiter for loading series.
+	chunkBytesLimiter ChunkBytesLimiter, // Rate limiter for the size of the loaded chunks.
 	stats *safeQueryStats,
 ) (storepb.SeriesSet, error) {
 	strategy := defaultStrategy
 	if req.SkipChunks {
 		strategy = noChunkRefs
 	}
-	it, err := s.getSeriesIteratorFromBlocks(ctx, req, blocks, indexReaders, shardSelector, matchers, chunksLimiter, seriesLimiter, stats, nil, strategy)
+	it, err := s.getSeriesIteratorFromBlocks(ctx, req, blocks, bloomFilterChecks, indexReaders, shardSelector, matchers, chunksLimiter, seriesLimiter, stats, nil, strategy)
 	if err != nil {
 		return nil, err
 	}
 
 	var set storepb.SeriesSet
 	if !req.SkipChunks {
-		ss := newChunksPreloadingIterator(ctx, s.logger, s.userID, *chunkReaders, it, s.maxSeriesPerBatch, stats)
+		ss := newChunksPreloadingIterator(ctx, s.logger, s.userID, *chunkReaders, it, s.maxSeriesPerBatch, chunkBytesLimiter, stats)
 		set = newSeriesChunksSeriesSet(ss)
 	} else {
 		set = newSeriesSetWithoutChunks(ctx, it, stats)
@@ -1037,6 +1089,7 @@ func (s *BucketStore) streamingSeriesForBlocks(
 	ctx context.Context,
 	req *storepb.SeriesRequest,
 	blocks []*bucketBlock,
+	bloomFilterChecks map[ulid.ULID][]*labels.Matcher, // The matchers checked against the bloom filters of each block.
 	indexReaders map[ulid.ULID]*bucketIndexReader,
 	shardSelector *sharding.ShardSelector,
 	matchers []*labels.Matcher,
@@ -1051,7 +1104,7 @@ func (s *BucketStore) streamingSeriesForBlocks(
 	for i := range reuse {
 		reuse[i] = &reusedPostingsAndMatchers{}
 	}
-	it, err := s.getSeriesIteratorFromBlocks(ctx, req, blocks, indexReaders, shardSelector, matchers, chunksLimiter, seriesLimiter, stats, reuse, strategy)
+	it, err := s.getSeriesIteratorFromBlocks(ctx, req, blocks, bloomFilterChecks, indexReaders, shardSelector, matchers, chunksLimiter, seriesLimiter, stats, reuse, strategy)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -1071,14 +1124,16 @@ func (s *BucketStore) streamingChunksSetForBlocks(
 	matchers []*labels.Matcher,
 	chunksLimiter ChunksLimiter, // Rate limiter for loading chunks.
 	seriesLimiter SeriesLimiter, // Rate limiter for loading series.
+	chunkBytesLimiter ChunkBytesLimiter, // Rate limiter for the size of the loaded chunks.
 	stats *safeQueryStats,
 	reuse []*reusedPostingsAndMatchers, // Should come from streamingSeriesForBlocks.
 ) (seriesChunksSetIterator, error) {
-	it, err := s.getSeriesIteratorFromBlocks(ctx, req, blocks, indexReaders, shardSelector, matchers, chunksLimiter, seriesLimiter, stats, reuse, defaultStrategy)
+	// The bloom filters false positives have already been checked while fetching the series.
+	it, err := s.getSeriesIteratorFromBlocks(ctx, req, blocks, nil, indexReaders, shardSelector, matchers, chunksLimiter, seriesLimiter, stats, reuse, defaultStrategy)
 	if err != nil {
 		return nil, err
 	}
-	scsi := newChunksPreloadingIterator(ctx, s.logger, s.userID, *chunkReaders, it, s.maxSeriesPerBatch, stats)
+	scsi := newChunksPreloadingIterator(ctx, s.logger, s.userID, *chunkReaders, it, s.maxSeriesPerBatch, chunkBytesLimiter, stats)
 	return scsi, nil
 }
 
@@ -1086,6 +1141,7 @@ func (s *BucketStore) getSeriesIteratorFromBlocks(
 	ctx context.Context,
 	req *storepb.SeriesRequest,
 	blocks []*bucketBlock,
+	bloomFilterChecks map[ulid.ULID][]*labels.Matcher, // The blocks index-headers are looked up for bloom filters false positives. Can be nil.
 	indexReaders map[ulid.ULID]*bucketIndexReader,
 	shardSelector *sharding.ShardSelector,
 	matchers []*labels.Matcher,
@@ -1118,7 +1174,14 @@ func (s *BucketStore) getSeriesIteratorFromBlocks(
 		if len(reuse) > 0 {
 			r = reuse[i]
 		}
+		checked := bloomFilterChecks[b.meta.ULID]
 		g.Go(func() error {
+			// The false positives are looked up in the per-block goroutine, which uses the index-header anyway,
+			// so that a lazy loaded index-header is not loaded sequentially for each block before fetching the series.
+			if len(checked) > 0 {
+				s.recordBloomFilterFalsePositive(b, checked)
+			}
+
 			part, err := openBlockSeriesChunkRefsSetsIterator(
 				ctx,
 				s.maxSeriesPerBatch,
@@ -1261,31 +1324,49 @@ func (s *BucketStore) recordSeriesHashCacheStats(stats *queryStats) {
 	s.metrics.seriesHashCacheHits.Add(float64(stats.seriesHashCacheHits))
 }
 
-func (s *BucketStore) openBlocksForReading(ctx context.Context, skipChunks bool, minT, maxT int64, blockMatchers []*labels.Matcher, stats *safeQueryStats) ([]*bucketBlock, map[ulid.ULID]*bucketIndexReader, map[ulid.ULID]chunkReader) {
+// openBlocksForReading returns the blocks to query for the request, with their index and chunk readers, the blocks
+// skipped because their bloom filters rule out the matchers, and the matchers checked against the bloom filters of
+// each queried block, to look up false positives while querying the block.
+func (s *BucketStore) openBlocksForReading(ctx context.Context, skipChunks bool, minT, maxT int64, blockMatchers, matchers []*labels.Matcher, stats *safeQueryStats) ([]*bucketBlock, []*bucketBlock, map[ulid.ULID][]*labels.Matcher, map[ulid.ULID]*bucketIndexReader, map[ulid.ULID]chunkReader) {
 	// ignore the span context so that we can use the context for cancellation
 	span, _ := opentracing.StartSpanFromContext(ctx, "bucket_store_open_blocks_for_reading")
 	defer span.Finish()
 
 	s.blocksMx.RLock()
-	defer s.blocksMx.RUnlock()
 
 	// Find all blocks owned by this store-gateway instance and matching the request.
-	blocks := s.blockSet.getFor(minT, maxT, blockMatchers)
+	var (
+		blocks, skippedBlocks []*bucketBlock
+		bloomFilterChecks     = map[ulid.ULID][]*labels.Matcher{}
+	)
+	for _, b := range s.blockSet.getFor(minT, maxT, blockMatchers) {
+		mayMatch, checked := s.bloomFiltersMayMatch(b, matchers)
+		if !mayMatch {
+			skippedBlocks = append(skippedBlocks, b)
+			continue
+		}
+		blocks = append(blocks, b)
+		if len(checked) > 0 {
+			bloomFilterChecks[b.meta.ULID] = checked
+		}
+	}
 
 	indexReaders := make(map[ulid.ULID]*bucketIndexReader, len(blocks))
 	for _, b := range blocks {
 		indexReaders[b.meta.ULID] = b.loadedIndexReader(s.postingsStrategy, stats)
 	}
-	if skipChunks {
-		return blocks, indexReaders, nil
-	}
 
-	chunkReaders := make(map[ulid.ULID]chunkReader, len(blocks))
-	for _, b := range blocks {
-		chunkReaders[b.meta.ULID] = b.chunkReader(ctx)
+	var chunkReaders map[ulid.ULID]chunkReader
+	if !skipChunks {
+		chunkReaders = make(map[ulid.ULID]chunkReader, len(blocks))
+		for _, b := range blocks {
+			chunkReaders[b.meta.ULID] = b.chunkReader(ctx)
+		}
 	}
 
-	return blocks, indexReaders, chunkReaders
+	s.blocksMx.RUnlock()
+
+	return blocks, skippedBlocks, bloomFilterChecks, indexReaders, chunkReaders
 }
 
 // LabelNames implements the storepb.StoreServer interface.
@@ -1335,11 +1416,20 @@ func (s *BucketStore) LabelNames(ctx context.Context, req *storepb.LabelNamesReq
 
 		resHints.AddQueriedBlock(b.meta.ULID)
 
+		mayMatch, checked := s.bloomFiltersMayMatch(b, reqSeriesMatchers)
+		if !mayMatch {
+			continue
+		}
+
 		indexr := b.loadedIndexReader(s.postingsStrategy, stats)
 
 		g.Go(func() error {
 			defer runutil.CloseWithLogOnErr(s.logger, indexr, "label names")
 
+			if len(checked) > 0 {
+				s.recordBloomFilterFalsePositive(b, checked)
+			}
+
 			result, err := blockLabelNames(gctx, indexr, reqSeriesMatchers, seriesLimiter, s.maxSeriesPerBatch, s.logger, stats)
 			if err != nil {
 				return errors.Wrapf(err, "block %s", b.meta.ULID)
@@ -1523,7 +1613,16 @@ func (s *BucketStore) LabelValues(ctx context.Context, req *storepb.LabelValuesR
 
 		resHints.AddQueriedBlock(b.meta.ULID)
 
+		mayMatch, checked := s.bloomFiltersMayMatch(b, reqSeriesMatchers)
+		if !mayMatch {
+			continue
+		}
+
 		g.Go(func() error {
+			if len(checked) > 0 {
+				s.recordBloomFilterFalsePositive(b, checked)
+			}
+
 			result, err := blockLabelValues(gctx, b, s.postingsStrategy, s.maxSeriesPerBatch, req.Label, reqSeriesMatchers, s.logger, stats)
 			if err != nil {
 				return errors.Wrapf(err, "block %s", b.meta.ULID)
@@ -1826,6 +1925,11 @@ type bucketBlock struct {
 
 	partitioners blockPartitioners
 
+	postingsCodecs postingsCacheCodecs
+
+	// Label values bloom filters of the block, nil if the block has none or they're disabled.
+	bloomFilters block.BloomFilters
+
 	// Block's labels used by block-level matchers to filter blocks to query. These are used to select blocks using
 	// request hints' BlockMatchers.
 	blockLabels labels.Labels
@@ -1844,6 +1948,7 @@ func newBucketBlock(
 	indexCache indexcache.IndexCache,
 	indexHeadReader indexheader.Reader,
 	p blockPartitioners,
+	postingsCodecs postingsCacheCodecs,
 ) (b *bucketBlock, err error) {
 	b = &bucketBlock{
 		userID:            userID,
@@ -1853,6 +1958,7 @@ func newBucketBlock(
 		indexCache:        indexCache,
 		dir:               dir,
 		partitioners:      p,
+		postingsCodecs:    postingsCodecs,
 		meta:              meta,
 		indexHeaderReader: indexHeadReader,
 		// Inject the block ID as a label to allow to match blocks by ID.
@@ -0,0 +1,339 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/runutil"
+	"github.com/oklog/ulid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/util/annotations"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/pkg/mimirpb"
+	"github.com/grafana/mimir/pkg/storage/series"
+	"github.com/grafana/mimir/pkg/storage/sharding"
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
+	"github.com/grafana/mimir/pkg/storegateway/pushdown"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+)
+
+const (
+	// aggregationPushdownMaxSamples is the max number of samples the aggregation pushdown engine can
+	// load into memory while evaluating a single block. It's the same as the querier default.
+	aggregationPushdownMaxSamples = 50_000_000
+
+	// aggregationPushdownTimeout is the max time the aggregation pushdown engine can spend evaluating a single block.
+	aggregationPushdownTimeout = 2 * time.Minute
+
+	// aggregationPushdownSamplesPerChunk is the max number of samples encoded in each chunk of the partial aggregates.
+	aggregationPushdownSamplesPerChunk = 120
+)
+
+var errAggregationPushdownNativeHistograms = status.Error(codes.FailedPrecondition, "native histograms are not supported by the aggregation pushdown")
+
+func newAggregationPushdownEngine(logger log.Logger) *promql.Engine {
+	return promql.NewEngine(promql.EngineOpts{
+		Logger:     logger,
+		MaxSamples: aggregationPushdownMaxSamples,
+		Timeout:    aggregationPushdownTimeout,
+	})
+}
+
+// partialAggregate is the partial result of the aggregation for a group of series.
+type partialAggregate struct {
+	lset   labels.Labels
+	values map[int64]float64
+}
+
+// seriesAggregationPushdown evaluates the aggregation pushdown on each block matching the request, and sends
+// the merged partial aggregates as series. The partial aggregates of a block are only computed at the steps
+// whose range function window is fully contained in the block time range.
+func (s *BucketStore) seriesAggregationPushdown(
+	ctx context.Context,
+	req *storepb.SeriesRequest,
+	srv storepb.Store_SeriesServer,
+	reqPushdown *hintspb.AggregationPushdown,
+	matchers []*labels.Matcher,
+	blockMatchers []*labels.Matcher,
+	shardSelector *sharding.ShardSelector,
+	stats *safeQueryStats,
+) error {
+	if err := pushdown.Validate(reqPushdown); err != nil {
+		return status.Error(codes.InvalidArgument, errors.Wrap(err, "invalid aggregation pushdown").Error())
+	}
+
+	spanLogger := spanlogger.FromContext(ctx, s.logger)
+
+	blocks, skippedBlocks, bloomFilterChecks, indexReaders, chunkReaders := s.openBlocksForReading(ctx, false, req.MinTime, req.MaxTime, blockMatchers, matchers, stats)
+	// We must keep the readers open until all blocks have been evaluated.
+	for _, r := range indexReaders {
+		defer runutil.CloseWithLogOnErr(s.logger, r, "close block index reader")
+	}
+	for _, r := range chunkReaders {
+		defer runutil.CloseWithLogOnErr(s.logger, r, "close block chunk reader")
+	}
+
+	span, spanCtx := opentracing.StartSpanFromContext(ctx, "store_query_gate_ismyturn")
+	err := s.queryGate.Start(spanCtx)
+	span.Finish()
+	if err != nil {
+		return errors.Wrapf(err, "failed to wait for turn")
+	}
+	defer s.queryGate.Done()
+
+	resHints := &hintspb.SeriesResponseHints{}
+	for _, b := range blocks {
+		resHints.AddQueriedBlock(b.meta.ULID)
+	}
+	for _, b := range skippedBlocks {
+		resHints.AddQueriedBlock(b.meta.ULID)
+	}
+	if err := s.sendHints(srv, resHints); err != nil {
+		return err
+	}
+
+	var (
+		start             = time.Now()
+		merge             = pushdown.MergeFunc(reqPushdown.Aggregation)
+		groups            = map[string]*partialAggregate{}
+		chunksLimiter     = s.chunksLimiterFactory(s.metrics.queriesDropped.WithLabelValues("chunks"))
+		seriesLimiter     = s.seriesLimiterFactory(s.metrics.queriesDropped.WithLabelValues("series"))
+		chunkBytesLimiter = s.chunkBytesLimiterFactory(s.metrics.queriesDropped.WithLabelValues("chunk_bytes"))
+	)
+
+	for _, b := range blocks {
+		first, last, ok := pushdown.CoveredSteps(reqPushdown, b.meta.MinTime, b.meta.MaxTime)
+		if !ok {
+			continue
+		}
+
+		matrix, err := s.evaluateAggregationPushdown(ctx, req, reqPushdown, b, first, last, bloomFilterChecks, indexReaders, chunkReaders[b.meta.ULID], shardSelector, matchers, chunksLimiter, seriesLimiter, chunkBytesLimiter, stats)
+		if err != nil {
+			return err
+		}
+
+		for _, partial := range matrix {
+			key := partial.Metric.String()
+			group, ok := groups[key]
+			if !ok {
+				group = &partialAggregate{lset: partial.Metric, values: make(map[int64]float64, len(partial.Floats))}
+				groups[key] = group
+			}
+
+			for _, p := range partial.Floats {
+				if prev, ok := group.values[p.T]; ok {
+					group.values[p.T] = merge(prev, p.F)
+				} else {
+					group.values[p.T] = p.F
+				}
+			}
+		}
+	}
+
+	if err := s.sendAggregationPushdownSeries(srv, groups); err != nil {
+		return err
+	}
+
+	level.Debug(spanLogger).Log(
+		"msg", "sent aggregation pushdown series",
+		"num_blocks", len(blocks),
+		"num_series", len(groups),
+		"duration", time.Since(start),
+	)
+
+	return s.sendStats(srv, stats)
+}
+
+// evaluateAggregationPushdown evaluates the aggregation pushdown on the series of a single block, at the steps between first and last.
+func (s *BucketStore) evaluateAggregationPushdown(
+	ctx context.Context,
+	req *storepb.SeriesRequest,
+	reqPushdown *hintspb.AggregationPushdown,
+	b *bucketBlock,
+	first, last int64,
+	bloomFilterChecks map[ulid.ULID][]*labels.Matcher,
+	indexReaders map[ulid.ULID]*bucketIndexReader,
+	blockChunkReader chunkReader,
+	shardSelector *sharding.ShardSelector,
+	matchers []*labels.Matcher,
+	chunksLimiter ChunksLimiter,
+	seriesLimiter SeriesLimiter,
+	chunkBytesLimiter ChunkBytesLimiter,
+	stats *safeQueryStats,
+) (promql.Matrix, error) {
+	// Only fetch the chunks overlapping the windows of the steps to evaluate.
+	blockReq := *req
+	blockReq.MinTime = first - reqPushdown.Range
+	blockReq.MaxTime = last
+	blockReq.SkipChunks = false
+	blockReq.StreamingChunksBatchSize = 0
+
+	readers := newChunkReaders(map[ulid.ULID]chunkReader{b.meta.ULID: blockChunkReader})
+	seriesSet, err := s.nonStreamingSeriesSetForBlocks(ctx, &blockReq, []*bucketBlock{b}, bloomFilterChecks, indexReaders, readers, shardSelector, matchers, chunksLimiter, seriesLimiter, chunkBytesLimiter, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	var blockSeries []storage.Series
+	for seriesSet.Next() {
+		// The memory returned by At() is released on the next call to Next(), so we copy it.
+		lset, chks := seriesSet.At()
+		chunks := make([]chunkenc.Chunk, 0, len(chks))
+		for _, chk := range chks {
+			if chk.Raw.Type != storepb.Chunk_XOR {
+				return nil, errAggregationPushdownNativeHistograms
+			}
+			c, err := chunkenc.FromData(chunkenc.EncXOR, append([]byte(nil), chk.Raw.Data...))
+			if err != nil {
+				return nil, errors.Wrapf(err, "decode chunk of block %s", b.meta.ULID)
+			}
+			chunks = append(chunks, c)
+		}
+		blockSeries = append(blockSeries, newChunksSeries(lset.Copy(), chunks))
+	}
+	if seriesSet.Err() != nil {
+		return nil, errors.Wrap(seriesSet.Err(), "expand series set")
+	}
+	if len(blockSeries) == 0 {
+		return nil, nil
+	}
+
+	query, err := s.aggregationPushdownEngine.NewRangeQuery(ctx, seriesQueryable(blockSeries), nil, pushdown.Query(reqPushdown),
+		time.UnixMilli(first), time.UnixMilli(last), time.Duration(reqPushdown.Step)*time.Millisecond)
+	if err != nil {
+		return nil, errors.Wrap(err, "create aggregation pushdown query")
+	}
+	defer query.Close()
+
+	res := query.Exec(ctx)
+	if res.Err != nil {
+		return nil, errors.Wrapf(res.Err, "evaluate aggregation pushdown on block %s", b.meta.ULID)
+	}
+	matrix, err := res.Matrix()
+	if err != nil {
+		return nil, err
+	}
+
+	// The matrix is released when the query is closed, so we copy it.
+	partials := make(promql.Matrix, 0, len(matrix))
+	for _, partial := range matrix {
+		if len(partial.Histograms) > 0 {
+			return nil, errAggregationPushdownNativeHistograms
+		}
+		partials = append(partials, promql.Series{Metric: partial.Metric, Floats: append([]promql.FPoint(nil), partial.Floats...)})
+	}
+	return partials, nil
+}
+
+// sendAggregationPushdownSeries sends the partial aggregates as series, sorted by labels.
+func (s *BucketStore) sendAggregationPushdownSeries(srv storepb.Store_SeriesServer, groups map[string]*partialAggregate) error {
+	sorted := make([]*partialAggregate, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return labels.Compare(sorted[i].lset, sorted[j].lset) < 0
+	})
+
+	var encodeDuration, sendDuration time.Duration
+	for _, group := range sorted {
+		chks, err := encodePartialAggregate(group.values)
+		if err != nil {
+			return status.Error(codes.Internal, errors.Wrap(err, "encode partial aggregate").Error())
+		}
+
+		series := storepb.Series{
+			Labels: mimirpb.FromLabelsToLabelAdapters(group.lset),
+			Chunks: chks,
+		}
+		if err := s.sendMessage("series", srv, storepb.NewSeriesResponse(&series), &encodeDuration, &sendDuration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodePartialAggregate encodes the values of a partial aggregate, sorted by timestamp, in XOR chunks.
+func encodePartialAggregate(values map[int64]float64) ([]storepb.AggrChunk, error) {
+	timestamps := make([]int64, 0, len(values))
+	for t := range values {
+		timestamps = append(timestamps, t)
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+	var chks []storepb.AggrChunk
+	for len(timestamps) > 0 {
+		n := len(timestamps)
+		if n > aggregationPushdownSamplesPerChunk {
+			n = aggregationPushdownSamplesPerChunk
+		}
+
+		chk := chunkenc.NewXORChunk()
+		app, err := chk.Appender()
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range timestamps[:n] {
+			app.Append(t, values[t])
+		}
+
+		chks = append(chks, storepb.AggrChunk{
+			MinTime: timestamps[0],
+			MaxTime: timestamps[n-1],
+			Raw:     &storepb.Chunk{Type: storepb.Chunk_XOR, Data: chk.Bytes()},
+		})
+		timestamps = timestamps[n:]
+	}
+	return chks, nil
+}
+
+// newChunksSeries returns a storage.Series iterating the samples of the input chunks.
+func newChunksSeries(lset labels.Labels, chks []chunkenc.Chunk) storage.Series {
+	return &storage.SeriesEntry{
+		Lset: lset,
+		SampleIteratorFn: func(it chunkenc.Iterator) chunkenc.Iterator {
+			iterators := make([]chunkenc.Iterator, 0, len(chks))
+			for _, chk := range chks {
+				iterators = append(iterators, chk.Iterator(nil))
+			}
+			// Chunks of the same series may overlap, so we merge them.
+			return storage.ChainSampleIteratorFromIterators(it, iterators)
+		},
+	}
+}
+
+// seriesQueryable is a storage.Queryable returning the same series, which are expected to be sorted, for any selector.
+type seriesQueryable []storage.Series
+
+func (q seriesQueryable) Querier(_, _ int64) (storage.Querier, error) {
+	return q, nil
+}
+
+func (q seriesQueryable) Select(context.Context, bool, *storage.SelectHints, ...*labels.Matcher) storage.SeriesSet {
+	return series.NewConcreteSeriesSetFromSortedSeries(q)
+}
+
+func (q seriesQueryable) LabelValues(context.Context, string, ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	return nil, nil, nil
+}
+
+func (q seriesQueryable) LabelNames(context.Context, ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	return nil, nil, nil
+}
+
+func (q seriesQueryable) Close() error {
+	return nil
+}
@@ -0,0 +1,347 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/hashcache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/objstore/providers/filesystem"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/pkg/storage/sharding"
+	mimir_tsdb "github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
+	"github.com/grafana/mimir/pkg/storegateway/indexheader"
+	"github.com/grafana/mimir/pkg/storegateway/pushdown"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+)
+
+func TestBucketStore_Series_AggregationPushdown(t *testing.T) {
+	const (
+		scrapeInterval = 15 * time.Second
+		numPods        = 6
+	)
+
+	var (
+		ctx    = context.Background()
+		random = rand.New(rand.NewSource(1))
+		tmpDir = t.TempDir()
+		bktDir = filepath.Join(tmpDir, "bkt")
+
+		// The reference head holds all the samples, and it's used to evaluate the queries with the PromQL engine.
+		referenceHead = newAggregationPushdownTestHead(t, filepath.Join(tmpDir, "reference"))
+
+		// The first block covers the first 2h, the following 2h are split in two blocks holding different series,
+		// like the split-and-merge compactor shards.
+		firstBlockHead  = newAggregationPushdownTestHead(t, filepath.Join(tmpDir, "first"))
+		secondBlockHead = []*tsdb.Head{
+			newAggregationPushdownTestHead(t, filepath.Join(tmpDir, "second-1")),
+			newAggregationPushdownTestHead(t, filepath.Join(tmpDir, "second-2")),
+		}
+	)
+
+	appendSample := func(head *tsdb.Head, lset labels.Labels, ts int64, value float64) {
+		app := head.Appender(ctx)
+		_, err := app.Append(0, lset, ts, value)
+		require.NoError(t, err)
+		require.NoError(t, app.Commit())
+	}
+
+	for pod := 0; pod < numPods; pod++ {
+		for _, name := range []string{"metric", "other"} {
+			lset := labels.FromStrings(labels.MetricName, name, "job", fmt.Sprintf("job-%d", pod%2), "pod", fmt.Sprintf("pod-%d", pod))
+
+			value := 0.0
+			for ts := int64(0); ts < (4 * time.Hour).Milliseconds(); ts += scrapeInterval.Milliseconds() {
+				// Simulate a counter, with some resets.
+				if random.Intn(100) == 0 {
+					value = 0
+				}
+				value += float64(random.Intn(10))
+
+				appendSample(referenceHead, lset, ts, value)
+				if ts < (2 * time.Hour).Milliseconds() {
+					appendSample(firstBlockHead, lset, ts, value)
+				} else {
+					appendSample(secondBlockHead[pod%2], lset, ts, value)
+				}
+			}
+		}
+	}
+
+	var queriedBlocks []hintspb.Block
+	for _, head := range append([]*tsdb.Head{firstBlockHead}, secondBlockHead...) {
+		blockID := createBlockFromHead(t, bktDir, head)
+		_, err := block.InjectThanosMeta(log.NewNopLogger(), filepath.Join(bktDir, blockID.String()), block.ThanosMeta{Source: block.TestSource}, nil)
+		require.NoError(t, err)
+		queriedBlocks = append(queriedBlocks, hintspb.Block{Id: blockID.String()})
+	}
+
+	store := prepareStoreForAggregationPushdownTest(t, tmpDir, bktDir)
+	srv := newBucketStoreTestServer(t, store)
+
+	engine := promql.NewEngine(promql.EngineOpts{MaxSamples: math.MaxInt, Timeout: time.Minute})
+	referenceQueryable := storage.QueryableFunc(func(mint, maxt int64) (storage.Querier, error) {
+		return tsdb.NewBlockQuerier(referenceHead, mint, maxt)
+	})
+
+	var (
+		start = 10 * time.Minute
+		end   = 4 * time.Hour
+		step  = time.Minute
+	)
+
+	newAggregationPushdown := func(t *testing.T, query string) (*hintspb.AggregationPushdown, []*labels.Matcher) {
+		expr, err := parser.ParseExpr(query)
+		require.NoError(t, err)
+
+		p, matchers, ok := pushdown.FromExpr(expr)
+		require.True(t, ok)
+		p.Start = start.Milliseconds()
+		p.End = end.Milliseconds()
+		p.Step = step.Milliseconds()
+		return p, matchers
+	}
+
+	// seriesAggregationPushdown pushes down the aggregation to the store-gateway, and returns the partial aggregates by labels.
+	seriesAggregationPushdown := func(t *testing.T, p *hintspb.AggregationPushdown, matchers []*labels.Matcher) map[string]map[int64]float64 {
+		storeMatchers, err := storepb.PromMatchersToMatchers(matchers...)
+		require.NoError(t, err)
+
+		seriesSet, _, hints, _, err := srv.Series(ctx, &storepb.SeriesRequest{
+			MinTime:  p.Start - p.Range,
+			MaxTime:  p.End,
+			Matchers: storeMatchers,
+			Hints:    mustMarshalAny(&hintspb.SeriesRequestHints{AggregationPushdown: p}),
+		})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, queriedBlocks, hints.QueriedBlocks)
+
+		res := map[string]map[int64]float64{}
+		for _, series := range seriesSet {
+			lset := series.PromLabels()
+			require.NotContains(t, res, lset.String())
+			res[lset.String()] = decodeAggregationPushdownTestSeries(t, series)
+		}
+		return res
+	}
+
+	for _, query := range []string{
+		`sum(rate(metric[5m]))`,
+		`sum by (job) (increase(metric[5m]))`,
+		`sum without (pod) (sum_over_time(metric[5m]))`,
+		`sum(avg_over_time(metric{job="job-1"}[5m]))`,
+		`min by (pod) (min_over_time(metric[5m]))`,
+		`min(idelta(metric[5m]))`,
+		`max without (pod) (irate(metric[5m]))`,
+		`max(delta(metric[10m]))`,
+		`max by (job) (last_over_time(metric[1m]))`,
+		`count(count_over_time(metric[5m]))`,
+		`count by (job) (max_over_time(metric{pod=~"pod-[0-3]"}[5m]))`,
+	} {
+		t.Run(query, func(t *testing.T) {
+			p, matchers := newAggregationPushdown(t, query)
+
+			// Evaluate the query on the raw data.
+			refQuery, err := engine.NewRangeQuery(ctx, referenceQueryable, nil, query, time.UnixMilli(p.Start), time.UnixMilli(p.End), step)
+			require.NoError(t, err)
+			t.Cleanup(refQuery.Close)
+
+			refMatrix, err := refQuery.Exec(ctx).Matrix()
+			require.NoError(t, err)
+
+			expected := map[string]map[int64]float64{}
+			for _, series := range refMatrix {
+				expected[series.Metric.String()] = map[int64]float64{}
+				for _, point := range series.Floats {
+					expected[series.Metric.String()][point.T] = point.F
+				}
+			}
+
+			actual := seriesAggregationPushdown(t, p, matchers)
+			require.Len(t, actual, len(expected))
+
+			for lset, values := range actual {
+				require.Contains(t, expected, lset)
+
+				// The store-gateway must return all and only the steps whose range function window is fully
+				// contained in a block: [start, 2h) for the first block and [2h+range, 4h) for the second one.
+				for ts := p.Start; ts <= p.End; ts += p.Step {
+					covered := pushdown.IsStepCovered(p.Range, ts, 0, (2*time.Hour).Milliseconds()) ||
+						pushdown.IsStepCovered(p.Range, ts, (2*time.Hour).Milliseconds(), (4*time.Hour-scrapeInterval).Milliseconds()+1)
+
+					value, ok := values[ts]
+					require.Equal(t, covered, ok, "series: %s step: %d", lset, ts)
+					if !ok {
+						continue
+					}
+
+					// The partial sums are added up in a different order, so the result may differ by rounding errors.
+					expectedValue := expected[lset][ts]
+					if p.Aggregation == "sum" {
+						assert.InDelta(t, expectedValue, value, 1e-9*math.Max(1, math.Abs(expectedValue)), "series: %s step: %d", lset, ts)
+					} else {
+						assert.Equal(t, expectedValue, value, "series: %s step: %d", lset, ts)
+					}
+				}
+			}
+		})
+	}
+
+	// With query sharding, each sharded query is pushed down separately, and the query-frontend
+	// merges the results with the same aggregation.
+	for _, query := range []string{
+		`sum by (job) (rate(metric[5m]))`,
+		`min(min_over_time(metric[5m]))`,
+		`max without (pod) (max_over_time(metric[5m]))`,
+		`count by (job) (count_over_time(metric[5m]))`,
+	} {
+		t.Run(fmt.Sprintf("sharded %s", query), func(t *testing.T) {
+			const shardCount = 3
+
+			p, matchers := newAggregationPushdown(t, query)
+			expected := seriesAggregationPushdown(t, p, matchers)
+
+			var (
+				merge  = pushdown.MergeFunc(p.Aggregation)
+				actual = map[string]map[int64]float64{}
+			)
+			for shardIndex := uint64(0); shardIndex < shardCount; shardIndex++ {
+				shardMatcher := labels.MustNewMatcher(labels.MatchEqual, sharding.ShardLabel, sharding.FormatShardIDLabelValue(shardIndex, shardCount))
+
+				for lset, values := range seriesAggregationPushdown(t, p, append([]*labels.Matcher{shardMatcher}, matchers...)) {
+					if _, ok := actual[lset]; !ok {
+						actual[lset] = map[int64]float64{}
+					}
+					for ts, value := range values {
+						if prev, ok := actual[lset][ts]; ok {
+							actual[lset][ts] = merge(prev, value)
+						} else {
+							actual[lset][ts] = value
+						}
+					}
+				}
+			}
+
+			require.Len(t, actual, len(expected))
+			for lset, values := range expected {
+				require.Contains(t, actual, lset)
+				require.Len(t, actual[lset], len(values))
+
+				for ts, value := range values {
+					require.Contains(t, actual[lset], ts)
+					assert.InDelta(t, value, actual[lset][ts], 1e-9*math.Max(1, math.Abs(value)), "series: %s step: %d", lset, ts)
+				}
+			}
+		})
+	}
+
+	t.Run("invalid aggregation pushdown", func(t *testing.T) {
+		_, _, _, _, err := srv.Series(ctx, &storepb.SeriesRequest{
+			MinTime:  0,
+			MaxTime:  end.Milliseconds(),
+			Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: labels.MetricName, Value: "metric"}},
+			Hints: mustMarshalAny(&hintspb.SeriesRequestHints{AggregationPushdown: &hintspb.AggregationPushdown{
+				Aggregation: "avg", Function: "rate", Range: time.Minute.Milliseconds(), Start: 0, End: end.Milliseconds(), Step: step.Milliseconds(),
+			}}),
+		})
+		require.Error(t, err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func newAggregationPushdownTestHead(t *testing.T, dir string) *tsdb.Head {
+	headOpts := tsdb.DefaultHeadOptions()
+	headOpts.ChunkDirRoot = dir
+	headOpts.ChunkRange = (24 * time.Hour).Milliseconds()
+
+	head, err := tsdb.NewHead(nil, nil, nil, nil, headOpts, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, head.Close())
+	})
+	return head
+}
+
+func prepareStoreForAggregationPushdownTest(t *testing.T, tmpDir, bktDir string) *BucketStore {
+	bkt, err := filesystem.NewBucket(bktDir)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, bkt.Close()) })
+
+	var (
+		logger   = log.NewNopLogger()
+		instrBkt = objstore.WithNoopInstr(bkt)
+	)
+
+	fetcher, err := block.NewMetaFetcher(logger, 10, instrBkt, filepath.Join(tmpDir, "cache"), nil, nil)
+	require.NoError(t, err)
+
+	store, err := NewBucketStore(
+		"tenant",
+		instrBkt,
+		fetcher,
+		filepath.Join(tmpDir, "store"),
+		mimir_tsdb.BucketStoreConfig{
+			StreamingBatchSize:          1000,
+			BlockSyncConcurrency:        10,
+			PostingOffsetsInMemSampling: mimir_tsdb.DefaultPostingOffsetInMemorySampling,
+			IndexHeader: indexheader.Config{
+				EagerLoadingStartupEnabled: false,
+				LazyLoadingEnabled:         false,
+				LazyLoadingIdleTimeout:     0,
+				SparsePersistenceEnabled:   true,
+			},
+		},
+		selectAllStrategy{},
+		newStaticChunksLimiterFactory(0),
+		newStaticSeriesLimiterFactory(0),
+		newGapBasedPartitioners(mimir_tsdb.DefaultPartitionerMaxGapSize, nil),
+		hashcache.NewSeriesHashCache(1024*1024),
+		NewBucketStoreMetrics(nil),
+		WithLogger(logger),
+	)
+	require.NoError(t, err)
+	require.NoError(t, store.SyncBlocks(context.Background()))
+	t.Cleanup(func() { assert.NoError(t, store.RemoveBlocksAndClose()) })
+
+	return store
+}
+
+func decodeAggregationPushdownTestSeries(t *testing.T, series *storepb.Series) map[int64]float64 {
+	values := map[int64]float64{}
+	for _, chk := range series.Chunks {
+		require.Equal(t, storepb.Chunk_XOR, chk.Raw.Type)
+		c, err := chunkenc.FromData(chunkenc.EncXOR, chk.Raw.Data)
+		require.NoError(t, err)
+
+		it := c.Iterator(nil)
+		for it.Next() == chunkenc.ValFloat {
+			ts, value := it.At()
+			require.GreaterOrEqual(t, ts, chk.MinTime)
+			require.LessOrEqual(t, ts, chk.MaxTime)
+			require.NotContains(t, values, ts)
+			values[ts] = value
+		}
+		require.NoError(t, it.Err())
+	}
+	return values
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb/block"
+	"github.com/grafana/mimir/pkg/storegateway/indexheader"
+)
+
+// loadBloomFilters returns the label values bloom filters of the block, or nil if the bloom filters
+// are disabled, the block has none or they can't be loaded. The bloom filters are an optimization,
+// so a block whose bloom filters fail to load is queried as if it had none.
+func (s *BucketStore) loadBloomFilters(ctx context.Context, meta *block.Meta) block.BloomFilters {
+	if !s.bloomFiltersEnabled || !block.HasBloomFilters(meta) {
+		return nil
+	}
+
+	filters, err := block.ReadBloomFilters(ctx, s.bkt, meta.ULID)
+	if err != nil {
+		s.metrics.bloomFilterLoadFailures.Inc()
+		level.Warn(s.logger).Log("msg", "failed to load block bloom filters, the block will be queried without them", "id", meta.ULID, "err", err)
+		return nil
+	}
+	return filters
+}
+
+// bloomFiltersMayMatch returns false if the bloom filters of the block rule out that any of its series
+// matches the matchers, and the matchers which have been checked against the bloom filters.
+func (s *BucketStore) bloomFiltersMayMatch(b *bucketBlock, matchers []*labels.Matcher) (bool, []*labels.Matcher) {
+	if b.bloomFilters == nil {
+		return true, nil
+	}
+
+	mayMatch, checked := b.bloomFilters.MayMatch(matchers)
+	if len(checked) == 0 {
+		return true, nil
+	}
+
+	s.metrics.bloomFilterChecks.Inc()
+	if !mayMatch {
+		s.metrics.bloomFilterSkippedBlocks.Inc()
+	}
+	return mayMatch, checked
+}
+
+// recordBloomFilterFalsePositive looks up in the index-header whether the block contains the label values
+// required by the matchers which have been checked against its bloom filters, and tracks a false positive
+// if it doesn't. The index-header is loaded if it's not already, so this must only be called for blocks
+// which are queried anyway, from the goroutine querying the block.
+func (s *BucketStore) recordBloomFilterFalsePositive(b *bucketBlock, checked []*labels.Matcher) {
+	for _, m := range checked {
+		values, _ := block.BloomFilterMatcherValues(m)
+
+		found := false
+		for _, v := range values {
+			_, err := b.indexHeaderReader.PostingsOffset(m.Name, v)
+			if err == nil {
+				found = true
+				break
+			}
+			if !errors.Is(err, indexheader.NotFoundRangeErr) {
+				level.Warn(b.logger).Log("msg", "failed to look up label value in index-header to check bloom filters false positives", "label", m.Name, "err", err)
+				return
+			}
+		}
+
+		if !found {
+			s.metrics.bloomFilterFalsePositives.Inc()
+			return
+		}
+	}
+}
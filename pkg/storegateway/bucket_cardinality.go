@@ -0,0 +1,405 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/types"
+	"github.com/grafana/dskit/runutil"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
+	"github.com/grafana/mimir/pkg/storegateway/indexcache"
+	streamindex "github.com/grafana/mimir/pkg/storegateway/indexheader/index"
+	"github.com/grafana/mimir/pkg/storegateway/storepb"
+	"github.com/grafana/mimir/pkg/util"
+	"github.com/grafana/mimir/pkg/util/spanlogger"
+)
+
+// LabelNamesCardinality returns all label names, with their values, of the series matching the request matchers
+// in the blocks overlapping the request time range. The label names and values of each block are cached, because
+// blocks are immutable.
+func (s *BucketStore) LabelNamesCardinality(ctx context.Context, req *storepb.LabelNamesCardinalityRequest) (*storepb.LabelNamesCardinalityResponse, error) {
+	reqSeriesMatchers, err := storepb.MatchersToPromMatchers(req.Matchers...)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "translate request labels matchers").Error())
+	}
+
+	var reqBlockMatchers []*labels.Matcher
+	if req.Hints != nil {
+		reqHints := &hintspb.LabelNamesRequestHints{}
+		if err := types.UnmarshalAny(req.Hints, reqHints); err != nil {
+			return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "unmarshal label names cardinality request hints").Error())
+		}
+
+		reqBlockMatchers, err = storepb.MatchersToPromMatchers(reqHints.BlockMatchers...)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "translate request hints labels matchers").Error())
+		}
+	}
+
+	var (
+		stats    = newSafeQueryStats()
+		resHints = &hintspb.LabelNamesResponseHints{}
+	)
+
+	defer s.recordLabelNamesCallResult(stats)
+
+	g, gctx := errgroup.WithContext(ctx)
+
+	var mtx sync.Mutex
+	valuesByName := map[string][][]string{}
+	seriesLimiter := s.seriesLimiterFactory(s.metrics.queriesDropped.WithLabelValues("series"))
+
+	s.blocksMx.RLock()
+
+	for _, b := range s.blocks {
+		b := b
+		if !b.overlapsClosedInterval(req.Start, req.End) {
+			continue
+		}
+		if len(reqBlockMatchers) > 0 && !b.matchLabels(reqBlockMatchers) {
+			continue
+		}
+
+		resHints.AddQueriedBlock(b.meta.ULID)
+
+		indexr := b.loadedIndexReader(s.postingsStrategy, stats)
+
+		g.Go(func() error {
+			defer runutil.CloseWithLogOnErr(s.logger, indexr, "label names cardinality")
+
+			names, err := blockLabelNames(gctx, indexr, reqSeriesMatchers, seriesLimiter, s.maxSeriesPerBatch, s.logger, stats)
+			if err != nil {
+				return errors.Wrapf(err, "block %s", b.meta.ULID)
+			}
+
+			blockValues := make([][]string, len(names))
+			for i, name := range names {
+				blockValues[i], err = blockLabelValues(gctx, b, s.postingsStrategy, s.maxSeriesPerBatch, name, reqSeriesMatchers, s.logger, stats)
+				if err != nil {
+					return errors.Wrapf(err, "block %s", b.meta.ULID)
+				}
+			}
+
+			mtx.Lock()
+			for i, name := range names {
+				if len(blockValues[i]) > 0 {
+					valuesByName[name] = append(valuesByName[name], blockValues[i])
+				}
+			}
+			mtx.Unlock()
+
+			return nil
+		})
+	}
+
+	s.blocksMx.RUnlock()
+
+	if err := g.Wait(); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return nil, status.Error(codes.Canceled, err.Error())
+		}
+
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	stats.update(func(stats *queryStats) {
+		stats.blocksQueried = len(resHints.QueriedBlocks)
+	})
+
+	anyHints, err := types.MarshalAny(resHints)
+	if err != nil {
+		return nil, status.Error(codes.Unknown, errors.Wrap(err, "marshal label names cardinality response hints").Error())
+	}
+
+	items := make([]*storepb.LabelNameValues, 0, len(valuesByName))
+	for name, sets := range valuesByName {
+		items = append(items, &storepb.LabelNameValues{LabelName: name, Values: util.MergeSlices(sets...)})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].LabelName < items[j].LabelName })
+
+	return &storepb.LabelNamesCardinalityResponse{
+		Items: items,
+		Hints: anyHints,
+	}, nil
+}
+
+// LabelValuesCardinality returns the series count of each value of the requested label names, for the series
+// matching the request matchers in the blocks overlapping the request time range. The series counts are grouped
+// by the time range of the blocks, see storepb.MergeLabelValuesCardinalityGroups(). The series counts of each
+// block are cached, because blocks are immutable.
+func (s *BucketStore) LabelValuesCardinality(ctx context.Context, req *storepb.LabelValuesCardinalityRequest) (*storepb.LabelValuesCardinalityResponse, error) {
+	reqSeriesMatchers, err := storepb.MatchersToPromMatchers(req.Matchers...)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "translate request labels matchers").Error())
+	}
+
+	var reqBlockMatchers []*labels.Matcher
+	if req.Hints != nil {
+		reqHints := &hintspb.LabelValuesRequestHints{}
+		if err := types.UnmarshalAny(req.Hints, reqHints); err != nil {
+			return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "unmarshal label values cardinality request hints").Error())
+		}
+
+		reqBlockMatchers, err = storepb.MatchersToPromMatchers(reqHints.BlockMatchers...)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, errors.Wrap(err, "translate request hints labels matchers").Error())
+		}
+	}
+
+	stats := newSafeQueryStats()
+	defer s.recordLabelValuesCallResult(stats)
+
+	resHints := &hintspb.LabelValuesResponseHints{}
+
+	g, gctx := errgroup.WithContext(ctx)
+
+	var mtx sync.Mutex
+	var blockGroups []storepb.LabelValuesCardinalityGroup
+
+	s.blocksMx.RLock()
+
+	for _, b := range s.blocks {
+		b := b
+		if !b.overlapsClosedInterval(req.Start, req.End) {
+			continue
+		}
+		if len(reqBlockMatchers) > 0 && !b.matchLabels(reqBlockMatchers) {
+			continue
+		}
+
+		resHints.AddQueriedBlock(b.meta.ULID)
+
+		g.Go(func() error {
+			group := storepb.LabelValuesCardinalityGroup{
+				MinTime: b.meta.MinTime,
+				MaxTime: b.meta.MaxTime,
+				// Blocks which have not been compacted yet have been uploaded by ingesters,
+				// so they contain the same series replicated across ingesters.
+				Replicated:  b.meta.Compaction.Level <= 1,
+				SeriesCount: b.meta.Stats.NumSeries,
+				Items:       make([]storepb.LabelValueSeriesCount, 0, len(req.LabelNames)),
+			}
+
+			for _, name := range req.LabelNames {
+				seriesCounts, err := blockLabelValuesCardinality(gctx, b, s.postingsStrategy, s.maxSeriesPerBatch, name, reqSeriesMatchers, s.logger, stats)
+				if err != nil {
+					return errors.Wrapf(err, "block %s", b.meta.ULID)
+				}
+				group.Items = append(group.Items, storepb.LabelValueSeriesCount{LabelName: name, LabelValueSeries: seriesCounts})
+			}
+
+			mtx.Lock()
+			blockGroups = append(blockGroups, group)
+			mtx.Unlock()
+
+			return nil
+		})
+	}
+
+	s.blocksMx.RUnlock()
+
+	if err := g.Wait(); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return nil, status.Error(codes.Canceled, err.Error())
+		}
+
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	anyHints, err := types.MarshalAny(resHints)
+	if err != nil {
+		return nil, status.Error(codes.Unknown, errors.Wrap(err, "marshal label values cardinality response hints").Error())
+	}
+
+	return &storepb.LabelValuesCardinalityResponse{
+		Groups: storepb.MergeLabelValuesCardinalityGroups(blockGroups),
+		Hints:  anyHints,
+	}, nil
+}
+
+// blockLabelValuesCardinality returns the number of series of each value of the label with requested name,
+// optionally restricting the count to the series that match the matchers provided. Label values without
+// any matching series are not returned.
+func blockLabelValuesCardinality(ctx context.Context, b *bucketBlock, postingsStrategy postingsSelectionStrategy, maxSeriesPerBatch int, labelName string, matchers []*labels.Matcher, logger log.Logger, stats *safeQueryStats) (map[string]uint64, error) {
+	seriesCounts, ok := fetchCachedLabelValuesCardinality(ctx, b.indexCache, b.userID, b.meta.ULID, labelName, matchers, logger)
+	if ok {
+		return seriesCounts, nil
+	}
+
+	allValuesPostingOffsets, err := b.indexHeaderReader.LabelValuesOffsets(labelName, "", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "index header label values")
+	}
+
+	if len(matchers) == 0 {
+		// This index reader shouldn't be used for ExpandedPostings, since it doesn't have the correct strategy.
+		indexr := b.loadedIndexReader(selectAllStrategy{}, stats)
+		defer runutil.CloseWithLogOnErr(b.logger, indexr, "close block index reader")
+
+		seriesCounts, err = labelValuesCardinalityFromPostings(ctx, labelName, indexr, allValuesPostingOffsets, nil, stats)
+	} else {
+		strategy := &labelValuesPostingsStrategy{
+			matchersStrategy: postingsStrategy,
+			allLabelValues:   allValuesPostingOffsets,
+		}
+		indexr := b.indexReader(strategy)
+		defer runutil.CloseWithLogOnErr(b.logger, indexr, "close block index reader")
+
+		var (
+			matchersPostings []storage.SeriesRef
+			pendingMatchers  []*labels.Matcher
+		)
+		matchersPostings, pendingMatchers, err = indexr.ExpandedPostings(ctx, matchers, stats)
+		if err != nil {
+			return nil, errors.Wrap(err, "expanded postings")
+		}
+		if len(pendingMatchers) > 0 || strategy.preferSeriesToPostings(matchersPostings) {
+			seriesCounts, err = labelValuesCardinalityFromSeries(ctx, labelName, maxSeriesPerBatch, pendingMatchers, indexr, b, matchersPostings, stats)
+		} else {
+			seriesCounts, err = labelValuesCardinalityFromPostings(ctx, labelName, indexr, allValuesPostingOffsets, matchersPostings, stats)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	storeCachedLabelValuesCardinality(ctx, b.indexCache, b.userID, b.meta.ULID, labelName, matchers, seriesCounts, logger)
+	return seriesCounts, nil
+}
+
+func labelValuesCardinalityFromSeries(ctx context.Context, labelName string, seriesPerBatch int, pendingMatchers []*labels.Matcher, indexr *bucketIndexReader, b *bucketBlock, matchersPostings []storage.SeriesRef, stats *safeQueryStats) (map[string]uint64, error) {
+	var iterator seriesChunkRefsSetIterator
+	iterator = newLoadingSeriesChunkRefsSetIterator(
+		ctx,
+		newPostingsSetsIterator(matchersPostings, seriesPerBatch),
+		indexr,
+		b.indexCache,
+		stats,
+		b.meta,
+		nil,
+		nil,
+		noChunkRefs,
+		b.meta.MinTime,
+		b.meta.MaxTime,
+		b.userID,
+		b.logger,
+	)
+	if len(pendingMatchers) > 0 {
+		iterator = newFilteringSeriesChunkRefsSetIterator(pendingMatchers, iterator, stats)
+	}
+	iterator = seriesStreamingFetchRefsDurationIterator(iterator, stats)
+	seriesSet := newSeriesSetWithoutChunks(ctx, iterator, stats)
+
+	seriesCounts := make(map[string]uint64)
+	for seriesSet.Next() {
+		series, _ := seriesSet.At()
+		if lVal := series.Get(labelName); lVal != "" {
+			seriesCounts[lVal]++
+		}
+	}
+	if seriesSet.Err() != nil {
+		return nil, errors.Wrap(seriesSet.Err(), "iterating series for label values cardinality")
+	}
+	return seriesCounts, nil
+}
+
+// labelValuesCardinalityFromPostings counts the series of each label value intersecting the matchers postings p,
+// or all the series of each label value if p is nil.
+func labelValuesCardinalityFromPostings(ctx context.Context, labelName string, indexr *bucketIndexReader, allValues []streamindex.PostingListOffset, p []storage.SeriesRef, stats *safeQueryStats) (map[string]uint64, error) {
+	keys := make([]labels.Label, len(allValues))
+	for i, value := range allValues {
+		keys[i] = labels.Label{Name: labelName, Value: value.LabelValue}
+	}
+
+	fetchedPostings, err := indexr.FetchPostings(ctx, keys, stats)
+	if err != nil {
+		return nil, errors.Wrap(err, "get postings")
+	}
+
+	seriesCounts := make(map[string]uint64, len(allValues))
+	for i, value := range allValues {
+		postings := fetchedPostings[i]
+		if p != nil {
+			postings = index.Intersect(index.NewListPostings(p), postings)
+		}
+
+		count := uint64(0)
+		for postings.Next() {
+			count++
+		}
+		if err = postings.Err(); err != nil {
+			return nil, errors.Wrapf(err, "counting value %q postings", value.LabelValue)
+		}
+		if count > 0 {
+			seriesCounts[value.LabelValue] = count
+		}
+	}
+	return seriesCounts, nil
+}
+
+type labelValuesCardinalityCacheEntry struct {
+	SeriesCounts map[string]uint64
+	LabelName    string
+	MatchersKey  indexcache.LabelMatchersKey
+}
+
+func fetchCachedLabelValuesCardinality(ctx context.Context, indexCache indexcache.IndexCache, userID string, blockID ulid.ULID, labelName string, matchers []*labels.Matcher, logger log.Logger) (map[string]uint64, bool) {
+	matchersKey := indexcache.CanonicalLabelMatchersKey(matchers)
+	data, ok := indexCache.FetchLabelValuesCardinality(ctx, userID, blockID, labelName, matchersKey)
+	if !ok {
+		return nil, false
+	}
+	var entry labelValuesCardinalityCacheEntry
+	if err := decodeSnappyGob(data, &entry); err != nil {
+		level.Warn(spanlogger.FromContext(ctx, logger)).Log("msg", "can't decode label values cardinality cache", "err", err)
+		return nil, false
+	}
+	if entry.LabelName != labelName {
+		level.Debug(spanlogger.FromContext(ctx, logger)).Log("msg", "cached label values cardinality entry label name doesn't match, possible collision", "cached_label_name", entry.LabelName, "requested_label_name", labelName)
+		return nil, false
+	}
+	if entry.MatchersKey != matchersKey {
+		level.Debug(spanlogger.FromContext(ctx, logger)).Log("msg", "cached label values cardinality entry key doesn't match, possible collision", "cached_key", entry.MatchersKey, "requested_key", matchersKey)
+		return nil, false
+	}
+
+	// Gob decodes empty maps as nil.
+	if entry.SeriesCounts == nil {
+		entry.SeriesCounts = map[string]uint64{}
+	}
+	return entry.SeriesCounts, true
+}
+
+func storeCachedLabelValuesCardinality(ctx context.Context, indexCache indexcache.IndexCache, userID string, blockID ulid.ULID, labelName string, matchers []*labels.Matcher, seriesCounts map[string]uint64, logger log.Logger) {
+	// This limit is a workaround for panics in decoding large responses. See https://github.com/golang/go/issues/59172
+	const valuesLimit = 655360
+	if len(seriesCounts) > valuesLimit {
+		level.Debug(spanlogger.FromContext(ctx, logger)).Log("msg", "skipping storing label values cardinality to cache because it exceeds number of values limit", "limit", valuesLimit, "values_count", len(seriesCounts))
+		return
+	}
+	entry := labelValuesCardinalityCacheEntry{
+		SeriesCounts: seriesCounts,
+		LabelName:    labelName,
+		MatchersKey:  indexcache.CanonicalLabelMatchersKey(matchers),
+	}
+	data, err := encodeSnappyGob(entry)
+	if err != nil {
+		level.Error(spanlogger.FromContext(ctx, logger)).Log("msg", "can't encode label values cardinality for caching", "err", err)
+		return
+	}
+	indexCache.StoreLabelValuesCardinality(userID, blockID, labelName, entry.MatchersKey, data)
+}
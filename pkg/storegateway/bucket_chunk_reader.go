@@ -24,6 +24,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storage/tsdb/downsample"
 	"github.com/grafana/mimir/pkg/storegateway/storepb"
 	util_math "github.com/grafana/mimir/pkg/util/math"
 	"github.com/grafana/mimir/pkg/util/pool"
@@ -207,6 +208,8 @@ func populateChunk(out *storepb.AggrChunk, in rawChunk) error {
 		enc = storepb.Chunk_Histogram
 	case chunkenc.EncFloatHistogram:
 		enc = storepb.Chunk_FloatHistogram
+	case downsample.ChunkEncAggr:
+		enc = storepb.Chunk_Downsampled
 	default:
 		return errors.Errorf("unsupported chunk encoding %d", in.Encoding())
 	}
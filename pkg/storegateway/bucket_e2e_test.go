@@ -19,6 +19,7 @@ import (
 	"github.com/gogo/status"
 	dskit_metrics "github.com/grafana/dskit/metrics"
 	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/timestamp"
@@ -63,7 +64,7 @@ type storeSuite struct {
 // When nonOverlappingBlocks is true, it shifts the 2nd block ahead by 2hrs for every block range.
 // This way the first and the last blocks created have no overlapping blocks.
 func prepareTestBlocks(t testing.TB, now time.Time, count int, dir string, bkt objstore.Bucket,
-	series []labels.Labels, extLset labels.Labels, nonOverlappingBlocks bool) (minTime, maxTime int64) {
+	series []labels.Labels, extLset labels.Labels, nonOverlappingBlocks bool, bloomFilterLabelNames []string) (minTime, maxTime int64) {
 	ctx := context.Background()
 	logger := log.NewNopLogger()
 
@@ -97,6 +98,11 @@ func prepareTestBlocks(t testing.TB, now time.Time, count int, dir string, bkt o
 		meta.Thanos.Labels = map[string]string{"ext2": "value2"}
 		assert.NoError(t, meta.WriteToDir(logger, dir2))
 
+		if len(bloomFilterLabelNames) > 0 {
+			assert.NoError(t, block.WriteBloomFilters(ctx, dir1, bloomFilterLabelNames, 0.01))
+			assert.NoError(t, block.WriteBloomFilters(ctx, dir2, bloomFilterLabelNames, 0.01))
+		}
+
 		assert.NoError(t, block.Upload(ctx, logger, bkt, dir1, nil))
 		assert.NoError(t, block.Upload(ctx, logger, bkt, dir2, nil))
 
@@ -117,6 +123,10 @@ type prepareStoreConfig struct {
 	indexCache           indexcache.IndexCache
 	metricsRegistry      *prometheus.Registry
 	postingsStrategy     postingsSelectionStrategy
+	postingsCodec        string
+	// When bloomFilterLabelNames is not empty, the bloom filters of these labels are written for each block,
+	// and used by the store.
+	bloomFilterLabelNames []string
 	// When nonOverlappingBlocks is false, prepare store creates 2 blocks per block range.
 	// When nonOverlappingBlocks is true, it shifts the 2nd block ahead by 2hrs for every block range.
 	// This way the first and the last blocks created have no overlapping blocks.
@@ -143,6 +153,7 @@ func defaultPrepareStoreConfig(t testing.TB) *prepareStoreConfig {
 		chunksLimiterFactory: newStaticChunksLimiterFactory(0),
 		indexCache:           noopCache{},
 		postingsStrategy:     selectAllStrategy{},
+		postingsCodec:        mimir_tsdb.PostingsCodecDiffVarintSnappy,
 		series: []labels.Labels{
 			labels.FromStrings("a", "1", "b", "1"),
 			labels.FromStrings("a", "1", "b", "2"),
@@ -158,6 +169,18 @@ func defaultPrepareStoreConfig(t testing.TB) *prepareStoreConfig {
 
 type prepareStoreConfigOption func(config *prepareStoreConfig)
 
+func withPostingsCodec(codec string) prepareStoreConfigOption {
+	return func(config *prepareStoreConfig) {
+		config.postingsCodec = codec
+	}
+}
+
+func withBloomFilters(labelNames ...string) prepareStoreConfigOption {
+	return func(config *prepareStoreConfig) {
+		config.bloomFilterLabelNames = labelNames
+	}
+}
+
 func withManyParts() prepareStoreConfigOption {
 	return func(config *prepareStoreConfig) {
 		config.manyParts = true
@@ -167,7 +190,7 @@ func withManyParts() prepareStoreConfigOption {
 func prepareStoreWithTestBlocks(t testing.TB, bkt objstore.Bucket, cfg *prepareStoreConfig) *storeSuite {
 	extLset := labels.FromStrings("ext1", "value1")
 
-	minTime, maxTime := prepareTestBlocks(t, time.Now(), 3, cfg.tempDir, bkt, cfg.series, extLset, cfg.nonOverlappingBlocks)
+	minTime, maxTime := prepareTestBlocks(t, time.Now(), 3, cfg.tempDir, bkt, cfg.series, extLset, cfg.nonOverlappingBlocks, cfg.bloomFilterLabelNames)
 
 	s := &storeSuite{
 		logger:          log.NewNopLogger(),
@@ -192,12 +215,17 @@ func prepareStoreWithTestBlocks(t testing.TB, bkt objstore.Bucket, cfg *prepareS
 			StreamingBatchSize:          cfg.maxSeriesPerBatch,
 			BlockSyncConcurrency:        20,
 			PostingOffsetsInMemSampling: mimir_tsdb.DefaultPostingOffsetInMemorySampling,
+			IndexCache: mimir_tsdb.IndexCacheConfig{
+				PostingsCodec:         cfg.postingsCodec,
+				ExpandedPostingsCodec: cfg.postingsCodec,
+			},
 			IndexHeader: indexheader.Config{
 				EagerLoadingStartupEnabled: true,
 				LazyLoadingEnabled:         true,
 				LazyLoadingIdleTimeout:     time.Minute,
 				SparsePersistenceEnabled:   true,
 			},
+			BloomFiltersEnabled: len(cfg.bloomFilterLabelNames) > 0,
 		},
 		cfg.postingsStrategy,
 		cfg.chunksLimiterFactory,
@@ -507,6 +535,64 @@ func TestBucketStore_e2e(t *testing.T) {
 	})
 }
 
+func TestBucketStore_e2e_RoaringPostingsCodec(t *testing.T) {
+	foreachStore(t, func(t *testing.T, newSuite suiteFactory) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		s := newSuite(withPostingsCodec(mimir_tsdb.PostingsCodecRoaring))
+
+		indexCache, err := indexcache.NewInMemoryIndexCacheWithConfig(s.logger, nil, indexcache.InMemoryIndexCacheConfig{
+			MaxItemSize: 1e5,
+			MaxSize:     2e5,
+		})
+		assert.NoError(t, err)
+		s.cache.SwapIndexCacheWith(indexCache)
+
+		// Run the test twice: the first run populates the cache, the second one reads from it.
+		if ok := t.Run("cold cache", func(t *testing.T) {
+			testBucketStore_e2e(t, ctx, s)
+		}); !ok {
+			return
+		}
+		t.Run("warm cache", func(t *testing.T) {
+			testBucketStore_e2e(t, ctx, s)
+		})
+	})
+}
+
+func TestBucketStore_e2e_BloomFilters(t *testing.T) {
+	foreachStore(t, func(t *testing.T, newSuite suiteFactory) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		s := newSuite(withBloomFilters("b", "c"))
+
+		// Results must be the same as without bloom filters.
+		testBucketStore_e2e(t, ctx, s)
+
+		// Each time range has a block with the series with the "b" label, and another one with the series
+		// with the "c" label, so equal matchers on these labels must skip one block out of two.
+		srv := newBucketStoreTestServer(t, s.store)
+		seriesSet, _, hints, _, err := srv.Series(ctx, &storepb.SeriesRequest{
+			Matchers: []storepb.LabelMatcher{
+				{Type: storepb.LabelMatcher_EQ, Name: "b", Value: "1"},
+			},
+			MinTime: s.minTime,
+			MaxTime: s.maxTime,
+		})
+		require.NoError(t, err)
+		assert.Len(t, seriesSet, 2)
+		// The skipped blocks must be returned as queried.
+		assert.Len(t, hints.QueriedBlocks, 6)
+
+		assert.Greater(t, promtest.ToFloat64(s.store.metrics.bloomFilterChecks), 0.0)
+		assert.Greater(t, promtest.ToFloat64(s.store.metrics.bloomFilterSkippedBlocks), 0.0)
+		assert.Equal(t, 0.0, promtest.ToFloat64(s.store.metrics.bloomFilterFalsePositives))
+		assert.Equal(t, 0.0, promtest.ToFloat64(s.store.metrics.bloomFilterLoadFailures))
+	})
+}
+
 func TestBucketStore_e2e_StreamingEdgeCases(t *testing.T) {
 	foreachStore(t, func(t *testing.T, newSuite suiteFactory) {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -891,6 +977,136 @@ func TestBucketStore_LabelValues_e2e(t *testing.T) {
 	})
 }
 
+func TestBucketStore_LabelNamesCardinality_e2e(t *testing.T) {
+	foreachStore(t, func(t *testing.T, newSuite suiteFactory) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		s := newSuite()
+
+		for name, tc := range map[string]struct {
+			req      *storepb.LabelNamesCardinalityRequest
+			expected []*storepb.LabelNameValues
+		}{
+			"all series": {
+				req: &storepb.LabelNamesCardinalityRequest{
+					Start: timestamp.FromTime(minTime),
+					End:   timestamp.FromTime(maxTime),
+				},
+				expected: []*storepb.LabelNameValues{
+					{LabelName: "a", Values: []string{"1", "2"}},
+					{LabelName: "b", Values: []string{"1", "2"}},
+					{LabelName: "c", Values: []string{"1", "2"}},
+				},
+			},
+			"outside the time range": {
+				req: &storepb.LabelNamesCardinalityRequest{
+					Start: timestamp.FromTime(time.Now().Add(-24 * time.Hour)),
+					End:   timestamp.FromTime(time.Now().Add(-23 * time.Hour)),
+				},
+				expected: nil,
+			},
+			"matcher b=1": {
+				req: &storepb.LabelNamesCardinalityRequest{
+					Start:    timestamp.FromTime(minTime),
+					End:      timestamp.FromTime(maxTime),
+					Matchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "b", Value: "1"}},
+				},
+				expected: []*storepb.LabelNameValues{
+					{LabelName: "a", Values: []string{"1", "2"}},
+					{LabelName: "b", Values: []string{"1"}},
+				},
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				res, err := s.store.LabelNamesCardinality(ctx, tc.req)
+				require.NoError(t, err)
+
+				var items []*storepb.LabelNameValues
+				if len(res.Items) > 0 {
+					items = res.Items
+				}
+				assert.Equal(t, tc.expected, items)
+			})
+		}
+	})
+}
+
+func TestBucketStore_LabelValuesCardinality_e2e(t *testing.T) {
+	foreachStore(t, func(t *testing.T, newSuite suiteFactory) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		s := newSuite()
+
+		for name, tc := range map[string]struct {
+			req      *storepb.LabelValuesCardinalityRequest
+			expected []storepb.LabelValueSeriesCount
+		}{
+			"label a": {
+				req: &storepb.LabelValuesCardinalityRequest{
+					Start:      timestamp.FromTime(minTime),
+					End:        timestamp.FromTime(maxTime),
+					LabelNames: []string{"a"},
+				},
+				expected: []storepb.LabelValueSeriesCount{
+					{LabelName: "a", LabelValueSeries: map[string]uint64{"1": 2, "2": 2}},
+				},
+			},
+			"labels b and c, a=1": {
+				req: &storepb.LabelValuesCardinalityRequest{
+					Start:      timestamp.FromTime(minTime),
+					End:        timestamp.FromTime(maxTime),
+					LabelNames: []string{"b", "c"},
+					Matchers:   []storepb.LabelMatcher{{Type: storepb.LabelMatcher_EQ, Name: "a", Value: "1"}},
+				},
+				expected: []storepb.LabelValueSeriesCount{
+					{LabelName: "b", LabelValueSeries: map[string]uint64{"1": 1, "2": 1}},
+					{LabelName: "c", LabelValueSeries: map[string]uint64{"1": 1, "2": 1}},
+				},
+			},
+			"label a, a=~1|2, b=2": {
+				req: &storepb.LabelValuesCardinalityRequest{
+					Start:      timestamp.FromTime(minTime),
+					End:        timestamp.FromTime(maxTime),
+					LabelNames: []string{"a"},
+					Matchers: []storepb.LabelMatcher{
+						{Type: storepb.LabelMatcher_RE, Name: "a", Value: "1|2"},
+						{Type: storepb.LabelMatcher_EQ, Name: "b", Value: "2"},
+					},
+				},
+				expected: []storepb.LabelValueSeriesCount{
+					{LabelName: "a", LabelValueSeries: map[string]uint64{"1": 1, "2": 1}},
+				},
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				res, err := s.store.LabelValuesCardinality(ctx, tc.req)
+				require.NoError(t, err)
+
+				// The test blocks are made of 2 level-1 blocks per time range, each one with 4 series,
+				// so their series counts are merged taking the max.
+				require.Len(t, res.Groups, 3)
+				for _, group := range res.Groups {
+					assert.True(t, group.Replicated)
+					assert.Equal(t, uint64(4), group.SeriesCount)
+					assert.Equal(t, tc.expected, group.Items)
+				}
+			})
+		}
+
+		t.Run("outside the time range", func(t *testing.T) {
+			res, err := s.store.LabelValuesCardinality(ctx, &storepb.LabelValuesCardinalityRequest{
+				Start:      timestamp.FromTime(time.Now().Add(-24 * time.Hour)),
+				End:        timestamp.FromTime(time.Now().Add(-23 * time.Hour)),
+				LabelNames: []string{"a"},
+			})
+			require.NoError(t, err)
+			assert.Empty(t, res.Groups)
+		})
+	})
+}
+
 func TestBucketStore_ValueTypes_e2e(t *testing.T) {
 	for _, streamingBatchSize := range []int{0, 1, 5} {
 		t.Run(fmt.Sprintf("streamingBatchSize=%d", streamingBatchSize), func(t *testing.T) {
@@ -283,6 +283,29 @@ func filterPostingsByCachedShardHash(ps []storage.SeriesRef, shard *sharding.Sha
 	return ps
 }
 
+// intersectPostings returns the intersection of the input postings, like index.Intersect. Postings
+// fetched from the cache encoded as roaring bitmaps are intersected without decoding them.
+func intersectPostings(its ...index.Postings) index.Postings {
+	var (
+		bitmaps []roaringBitmap
+		others  = make([]index.Postings, 0, len(its))
+	)
+	for _, it := range its {
+		if rp, ok := it.(*roaringPostings); ok && !rp.started {
+			bitmaps = append(bitmaps, rp.bm)
+			continue
+		}
+		others = append(others, it)
+	}
+
+	// There's no benefit in intersecting a single bitmap on its own.
+	if len(bitmaps) < 2 {
+		return index.Intersect(its...)
+	}
+
+	return index.Intersect(append(others, index.NewListPostings(intersectRoaringBitmaps(bitmaps)))...)
+}
+
 // paddedPostings adds the v2 index padding to postings without expanding them
 type paddedPostings struct {
 	index.Postings
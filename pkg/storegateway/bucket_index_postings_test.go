@@ -14,6 +14,7 @@ import (
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/tsdb/index"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	streamindex "github.com/grafana/mimir/pkg/storegateway/indexheader/index"
 )
@@ -36,6 +37,42 @@ func TestBigEndianPostingsCount(t *testing.T) {
 	assert.Equal(t, count, c)
 }
 
+func TestIntersectPostings(t *testing.T) {
+	a := []storage.SeriesRef{1, 3, 5, 1 << 16, 1<<16 + 1, 1 << 20}
+	b := []storage.SeriesRef{3, 4, 5, 1<<16 + 1, 1 << 20, 1<<20 + 1}
+	c := []storage.SeriesRef{0, 3, 1<<16 + 1, 1 << 20}
+	expected := []storage.SeriesRef{3, 1<<16 + 1, 1 << 20}
+
+	roaring := func(refs []storage.SeriesRef) index.Postings {
+		data, err := roaringWithMatchersEncode(index.NewListPostings(refs), "key", nil)
+		require.NoError(t, err)
+		p, _, _, err := roaringMatchersDecode(data)
+		require.NoError(t, err)
+		return p
+	}
+	startedRoaring := func(refs []storage.SeriesRef) index.Postings {
+		p := roaring(refs)
+		require.True(t, p.Seek(0))
+		return p
+	}
+
+	tests := map[string][]index.Postings{
+		"no roaring postings":               {index.NewListPostings(a), index.NewListPostings(b), index.NewListPostings(c)},
+		"single roaring postings":           {roaring(a), index.NewListPostings(b), index.NewListPostings(c)},
+		"some roaring postings":             {roaring(a), index.NewListPostings(b), roaring(c)},
+		"all roaring postings":              {roaring(a), roaring(b), roaring(c)},
+		"already iterated roaring postings": {startedRoaring(a), roaring(b), roaring(c)},
+	}
+
+	for name, postings := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := index.ExpandPostings(intersectPostings(postings...))
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
 func TestWorstCaseFetchedDataStrategy(t *testing.T) {
 	testCases := map[string]struct {
 		input            []postingGroup
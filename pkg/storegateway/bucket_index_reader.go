@@ -155,7 +155,7 @@ func (r *bucketIndexReader) expandedPostingsPromise(ctx context.Context, ms []*l
 }
 
 func (r *bucketIndexReader) cacheExpandedPostings(userID string, key indexcache.LabelMatchersKey, refs []storage.SeriesRef, pendingMatchers []*labels.Matcher) {
-	data, err := diffVarintSnappyWithMatchersEncode(index.NewListPostings(refs), len(refs), key, pendingMatchers)
+	data, err := r.block.postingsCodecs.expandedPostings.encode(index.NewListPostings(refs), len(refs), key, pendingMatchers)
 	if err != nil {
 		level.Warn(r.block.logger).Log("msg", "can't encode expanded postings cache", "err", err, "matchers_key", key, "block", r.block.meta.ULID)
 		return
@@ -227,7 +227,7 @@ func (r *bucketIndexReader) expandedPostings(ctx context.Context, ms []*labels.M
 		}
 	}
 
-	result := index.Without(index.Intersect(groupAdds...), index.Merge(ctx, groupRemovals...))
+	result := index.Without(intersectPostings(groupAdds...), index.Merge(ctx, groupRemovals...))
 
 	ps, err := index.ExpandPostings(result)
 	if err != nil {
@@ -530,7 +530,7 @@ func (r *bucketIndexReader) fetchPostings(ctx context.Context, keys []labels.Lab
 				compressions++
 				s := time.Now()
 				bep := newBigEndianPostings(pBytes[4:])
-				dataToCache, err := diffVarintSnappyWithMatchersEncode(bep, bep.length(), encodeLabelForPostingsCache(keys[p.keyID]), nil)
+				dataToCache, err := r.block.postingsCodecs.postings.encode(bep, bep.length(), encodeLabelForPostingsCache(keys[p.keyID]), nil)
 				compressionTime = time.Since(s)
 				if err == nil {
 					compressedSize = len(dataToCache)
@@ -587,13 +587,16 @@ func (r *bucketIndexReader) decodePostings(b []byte, stats *safeQueryStats) (ind
 		pendingMatchers []*labels.Matcher
 		err             error
 	)
-	if !isDiffVarintSnappyWithMatchersEncodedPostings(b) {
+	s := time.Now()
+	switch {
+	case isDiffVarintSnappyWithMatchersEncodedPostings(b):
+		l, key, pendingMatchers, err = diffVarintSnappyMatchersDecode(b)
+	case isRoaringWithMatchersEncodedPostings(b):
+		l, key, pendingMatchers, err = roaringMatchersDecode(b)
+	default:
 		return nil, "", nil, errors.New("didn't find expected prefix for postings key")
 	}
 
-	s := time.Now()
-	l, key, pendingMatchers, err = diffVarintSnappyMatchersDecode(b)
-
 	stats.update(func(stats *queryStats) {
 		stats.cachedPostingsDecompressions++
 		stats.cachedPostingsDecompressionTimeSum += time.Since(s)
@@ -49,6 +49,11 @@ type BucketStoreMetrics struct {
 	seriesHashCacheRequests prometheus.Counter
 	seriesHashCacheHits     prometheus.Counter
 
+	bloomFilterLoadFailures   prometheus.Counter
+	bloomFilterChecks         prometheus.Counter
+	bloomFilterSkippedBlocks  prometheus.Counter
+	bloomFilterFalsePositives prometheus.Counter
+
 	seriesFetchDuration   prometheus.Histogram
 	postingsFetchDuration prometheus.Histogram
 
@@ -160,6 +165,23 @@ func NewBucketStoreMetrics(reg prometheus.Registerer) *BucketStoreMetrics {
 		Help: "Total number of fetch hits to the in-memory series hash cache.",
 	})
 
+	m.bloomFilterLoadFailures = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_bloom_filter_load_failures_total",
+		Help: "Total number of failures to load the bloom filters of a block. Blocks are loaded without bloom filters on failure.",
+	})
+	m.bloomFilterChecks = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_bloom_filter_checks_total",
+		Help: "Total number of times the bloom filters of a block have been checked against the matchers of a request.",
+	})
+	m.bloomFilterSkippedBlocks = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_bloom_filter_skipped_blocks_total",
+		Help: "Total number of times a block has been skipped because its bloom filters ruled out the matchers of a request.",
+	})
+	m.bloomFilterFalsePositives = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_store_bloom_filter_false_positives_total",
+		Help: "Total number of times the bloom filters of a block didn't rule out the matchers of a request, while the block doesn't contain the label values required by the matchers. The false positive rate is false positives / (false positives + skipped blocks).",
+	})
+
 	m.chunkSizeBytes = promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
 		Name: "cortex_bucket_store_sent_chunk_size_bytes",
 		Help: "Size in bytes of the chunks for the single series, which is adequate to the gRPC message size sent to querier.",
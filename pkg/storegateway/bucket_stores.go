@@ -8,6 +8,7 @@ package storegateway
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sync"
@@ -16,8 +17,8 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/backoff"
-	"github.com/grafana/dskit/cache"
 	"github.com/grafana/dskit/gate"
+	"github.com/grafana/dskit/httpgrpc"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -41,6 +42,10 @@ import (
 // (This is now separate from DeprecatedTenantIDExternalLabel to signify different use case.)
 const GrpcContextMetadataTenantID = "__org_id__"
 
+// errTooManyInflightSeriesRequests is returned when the tenant reached the max number of concurrent Series
+// requests in the store-gateway. The querier retries the request on another store-gateway replica.
+var errTooManyInflightSeriesRequests = httpgrpc.Errorf(http.StatusTooManyRequests, "the tenant reached the max number of concurrent series requests in the store-gateway")
+
 // defaultBlockDurations is the expected duration of blocks the compactor generates. This is used for
 // metrics emitted by the store-gateway, so it's fine to hardcode it here instead of using the durations
 // that are actually configured to avoid coupling to compactor configuration.
@@ -66,9 +71,13 @@ type BucketStores struct {
 	// partitioners shared across all tenants.
 	partitioners blockPartitioners
 
-	// Gate used to limit query concurrency across all tenants.
+	// Gate used to limit query concurrency across all tenants, admitting the queries fairly across tenants.
 	queryGate gate.Gate
 
+	// Number of in-flight Series requests by tenant.
+	seriesRequestsMx sync.Mutex
+	seriesRequests   map[string]int
+
 	// Gate used to limit concurrency on loading index-headers across all tenants.
 	lazyLoadingGate gate.Gate
 
@@ -81,13 +90,14 @@ type BucketStores struct {
 	syncLastSuccess        prometheus.Gauge
 	tenantsDiscovered      prometheus.Gauge
 	tenantsSynced          prometheus.Gauge
+	seriesRequestsRejected prometheus.Counter
 	blocksLoaded           *prometheus.Desc
 	blocksLoadedByDuration *prometheus.Desc
 }
 
 // NewBucketStores makes a new BucketStores.
 func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStrategy, bucketClient objstore.Bucket, limits *validation.Overrides, logger log.Logger, reg prometheus.Registerer) (*BucketStores, error) {
-	chunksCacheClient, err := cache.CreateClient("chunks-cache", cfg.BucketStore.ChunksCache.BackendConfig, logger, prometheus.WrapRegistererWithPrefix("thanos_", reg))
+	chunksCacheClient, err := tsdb.CreateChunksCacheClient(cfg.BucketStore.ChunksCache, logger, reg)
 	if err != nil {
 		return nil, errors.Wrapf(err, "chunks-cache")
 	}
@@ -99,9 +109,10 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 
 	gateReg := prometheus.WrapRegistererWithPrefix("cortex_bucket_stores_", reg)
 
-	// The number of concurrent queries against the tenants BucketStores are limited.
+	// The number of concurrent queries against the tenants BucketStores are limited,
+	// and the waiting queries are admitted in a round-robin fashion across tenants.
 	queryGateReg := prometheus.WrapRegistererWith(prometheus.Labels{"gate": "query"}, gateReg)
-	queryGate := gate.NewBlocking(cfg.BucketStore.MaxConcurrent)
+	queryGate := gate.Gate(newFairGate(cfg.BucketStore.MaxConcurrent))
 	queryGate = gate.NewInstrumented(queryGateReg, cfg.BucketStore.MaxConcurrent, queryGate)
 
 	// The number of concurrent index header loads from storegateway are limited.
@@ -123,6 +134,7 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 		bucketStoreMetrics: NewBucketStoreMetrics(reg),
 		metaFetcherMetrics: NewMetadataFetcherMetrics(),
 		queryGate:          queryGate,
+		seriesRequests:     map[string]int{},
 		lazyLoadingGate:    lazyLoadingGate,
 		partitioners:       newGapBasedPartitioners(cfg.BucketStore.PartitionerMaxGapBytes, reg),
 		seriesHashCache:    hashcache.NewSeriesHashCache(cfg.BucketStore.SeriesHashCacheMaxBytes),
@@ -151,6 +163,10 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 		Name: "cortex_bucket_stores_tenants_synced",
 		Help: "Number of tenants synced.",
 	})
+	u.seriesRequestsRejected = promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_bucket_stores_series_requests_rejected_total",
+		Help: "Total number of series requests rejected because the tenant reached the max number of concurrent series requests.",
+	})
 	u.blocksLoaded = prometheus.NewDesc(
 		"cortex_bucket_store_blocks_loaded",
 		"Number of currently loaded blocks.",
@@ -324,12 +340,44 @@ func (u *BucketStores) Series(req *storepb.SeriesRequest, srv storepb.Store_Seri
 		return nil
 	}
 
+	if !u.startSeriesRequest(userID) {
+		u.seriesRequestsRejected.Inc()
+		return errTooManyInflightSeriesRequests
+	}
+	defer u.doneSeriesRequest(userID)
+
 	return store.Series(req, spanSeriesServer{
 		Store_SeriesServer: srv,
 		ctx:                spanCtx,
 	})
 }
 
+// startSeriesRequest tracks a new in-flight Series request of the tenant, and returns false
+// if the tenant has already reached its max number of concurrent Series requests.
+func (u *BucketStores) startSeriesRequest(userID string) bool {
+	limit := u.limits.StoreGatewayTenantMaxConcurrentSeriesRequests(userID)
+
+	u.seriesRequestsMx.Lock()
+	defer u.seriesRequestsMx.Unlock()
+
+	if limit > 0 && u.seriesRequests[userID] >= limit {
+		return false
+	}
+	u.seriesRequests[userID]++
+	return true
+}
+
+func (u *BucketStores) doneSeriesRequest(userID string) {
+	u.seriesRequestsMx.Lock()
+	defer u.seriesRequestsMx.Unlock()
+
+	if u.seriesRequests[userID] <= 1 {
+		delete(u.seriesRequests, userID)
+		return
+	}
+	u.seriesRequests[userID]--
+}
+
 // LabelNames implements the storepb.StoreServer interface.
 func (u *BucketStores) LabelNames(ctx context.Context, req *storepb.LabelNamesRequest) (*storepb.LabelNamesResponse, error) {
 	spanLog, spanCtx := spanlogger.NewWithLogger(ctx, u.logger, "BucketStores.LabelNames")
@@ -366,6 +414,42 @@ func (u *BucketStores) LabelValues(ctx context.Context, req *storepb.LabelValues
 	return store.LabelValues(ctx, req)
 }
 
+// LabelNamesCardinality returns the label names, with their values, of the series matching the request.
+func (u *BucketStores) LabelNamesCardinality(ctx context.Context, req *storepb.LabelNamesCardinalityRequest) (*storepb.LabelNamesCardinalityResponse, error) {
+	spanLog, spanCtx := spanlogger.NewWithLogger(ctx, u.logger, "BucketStores.LabelNamesCardinality")
+	defer spanLog.Span.Finish()
+
+	userID := getUserIDFromGRPCContext(spanCtx)
+	if userID == "" {
+		return nil, fmt.Errorf("no userID")
+	}
+
+	store := u.getStore(userID)
+	if store == nil {
+		return &storepb.LabelNamesCardinalityResponse{}, nil
+	}
+
+	return store.LabelNamesCardinality(ctx, req)
+}
+
+// LabelValuesCardinality returns the series count of each value of the requested label names.
+func (u *BucketStores) LabelValuesCardinality(ctx context.Context, req *storepb.LabelValuesCardinalityRequest) (*storepb.LabelValuesCardinalityResponse, error) {
+	spanLog, spanCtx := spanlogger.NewWithLogger(ctx, u.logger, "BucketStores.LabelValuesCardinality")
+	defer spanLog.Span.Finish()
+
+	userID := getUserIDFromGRPCContext(spanCtx)
+	if userID == "" {
+		return nil, fmt.Errorf("no userID")
+	}
+
+	store := u.getStore(userID)
+	if store == nil {
+		return &storepb.LabelValuesCardinalityResponse{}, nil
+	}
+
+	return store.LabelValuesCardinality(ctx, req)
+}
+
 // scanUsers in the bucket and return the list of found users. If an error occurs while
 // iterating the bucket, it may return both an error and a subset of the users in the bucket.
 func (u *BucketStores) scanUsers(ctx context.Context) ([]string, error) {
@@ -476,8 +560,11 @@ func (u *BucketStores) getOrCreateStore(userID string) (*BucketStore, error) {
 	bucketStoreOpts := []BucketStoreOption{
 		WithLogger(userLogger),
 		WithIndexCache(u.indexCache),
-		WithQueryGate(u.queryGate),
+		WithQueryGate(withFairGateTenant(u.queryGate, userID)),
 		WithLazyLoadingGate(u.lazyLoadingGate),
+		WithChunkBytesLimiterFactory(NewChunkBytesLimiterFactory(func() uint64 {
+			return uint64(u.limits.MaxFetchedChunkBytesPerQuery(userID))
+		})),
 	}
 
 	bs, err := NewBucketStore(
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -37,6 +38,7 @@ import (
 	"go.uber.org/atomic"
 	"golang.org/x/exp/slices"
 	grpc_metadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/grafana/mimir/pkg/mimirpb"
 	"github.com/grafana/mimir/pkg/storage/bucket"
@@ -327,31 +329,36 @@ func TestBucketStores_ChunksAndSeriesLimiterFactoriesInitializedByEnforcedLimits
 	test.VerifyNoLeak(t)
 
 	const (
-		userID                = "user-1"
-		overriddenChunksLimit = 1000000
-		overriddenSeriesLimit = 2000
+		userID                    = "user-1"
+		overriddenChunksLimit     = 1000000
+		overriddenSeriesLimit     = 2000
+		overriddenChunkBytesLimit = 3000000
 	)
 
 	defaultLimits := defaultLimitsConfig()
 
 	tests := map[string]struct {
-		tenantLimits        map[string]*validation.Limits
-		expectedChunkLimit  uint64
-		expectedSeriesLimit uint64
+		tenantLimits            map[string]*validation.Limits
+		expectedChunkLimit      uint64
+		expectedSeriesLimit     uint64
+		expectedChunkBytesLimit uint64
 	}{
-		"when max_fetched_chunks_per_query and max_fetched_series_per_query are not overridden, their default values are used as the limit of the Limiter": {
-			expectedChunkLimit:  uint64(defaultLimits.MaxChunksPerQuery),
-			expectedSeriesLimit: uint64(defaultLimits.MaxFetchedSeriesPerQuery),
+		"when max_fetched_chunks_per_query, max_fetched_series_per_query and max_fetched_chunk_bytes_per_query are not overridden, their default values are used as the limit of the Limiter": {
+			expectedChunkLimit:      uint64(defaultLimits.MaxChunksPerQuery),
+			expectedSeriesLimit:     uint64(defaultLimits.MaxFetchedSeriesPerQuery),
+			expectedChunkBytesLimit: uint64(defaultLimits.MaxFetchedChunkBytesPerQuery),
 		},
-		"when max_fetched_chunks_per_query and max_fetched_series_per_query are overridden, the overridden values are used as the limit of the Limiter": {
+		"when max_fetched_chunks_per_query, max_fetched_series_per_query and max_fetched_chunk_bytes_per_query are overridden, the overridden values are used as the limit of the Limiter": {
 			tenantLimits: map[string]*validation.Limits{
 				userID: {
-					MaxChunksPerQuery:        overriddenChunksLimit,
-					MaxFetchedSeriesPerQuery: overriddenSeriesLimit,
+					MaxChunksPerQuery:            overriddenChunksLimit,
+					MaxFetchedSeriesPerQuery:     overriddenSeriesLimit,
+					MaxFetchedChunkBytesPerQuery: overriddenChunkBytesLimit,
 				},
 			},
-			expectedChunkLimit:  uint64(overriddenChunksLimit),
-			expectedSeriesLimit: uint64(overriddenSeriesLimit),
+			expectedChunkLimit:      uint64(overriddenChunksLimit),
+			expectedSeriesLimit:     uint64(overriddenSeriesLimit),
+			expectedChunkBytesLimit: uint64(overriddenChunkBytesLimit),
 		},
 	}
 
@@ -394,10 +401,74 @@ func TestBucketStores_ChunksAndSeriesLimiterFactoriesInitializedByEnforcedLimits
 				require.Error(t, err)
 			}
 
+			chunkBytesLimit := overrides.MaxFetchedChunkBytesPerQuery(userID)
+			if chunkBytesLimit != 0 {
+				chunkBytesLimiter := store.chunkBytesLimiterFactory(promauto.With(nil).NewCounter(prometheus.CounterOpts{Name: "chunk_bytes"}))
+				err = chunkBytesLimiter.Reserve(testData.expectedChunkBytesLimit)
+				require.NoError(t, err)
+
+				err = chunkBytesLimiter.Reserve(1)
+				require.Error(t, err)
+			}
 		})
 	}
 }
 
+func TestBucketStores_Series_ShouldRejectRequestsOverTenantMaxConcurrentSeriesRequests(t *testing.T) {
+	test.VerifyNoLeak(t)
+
+	userToMetric := map[string]string{
+		"user-1": "series_1",
+		"user-2": "series_2",
+	}
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+
+	storageDir := t.TempDir()
+	for userID, metricName := range userToMetric {
+		generateStorageBlock(t, storageDir, userID, metricName, 10, 100, 15)
+	}
+
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	limits := defaultLimitsConfig()
+	limits.StoreGatewayTenantMaxConcurrentSeriesRequests = 1
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+
+	reg := prometheus.NewPedanticRegistry()
+	stores, err := NewBucketStores(cfg, newNoShardingStrategy(), bucket, overrides, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	require.NoError(t, stores.InitialSync(ctx))
+
+	// Simulate an in-flight request of user-1.
+	require.True(t, stores.startSeriesRequest("user-1"))
+
+	_, _, err = querySeries(t, stores, "user-1", "series_1", 20, 40)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, int(status.Code(err)))
+
+	// The requests of the other tenants aren't affected.
+	seriesSet, _, err := querySeries(t, stores, "user-2", "series_2", 20, 40)
+	require.NoError(t, err)
+	assert.Len(t, seriesSet, 1)
+
+	// Once the in-flight request is done, the requests of user-1 are accepted again.
+	stores.doneSeriesRequest("user-1")
+	seriesSet, _, err = querySeries(t, stores, "user-1", "series_1", 20, 40)
+	require.NoError(t, err)
+	assert.Len(t, seriesSet, 1)
+	assert.Empty(t, stores.seriesRequests)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_stores_series_requests_rejected_total Total number of series requests rejected because the tenant reached the max number of concurrent series requests.
+		# TYPE cortex_bucket_stores_series_requests_rejected_total counter
+		cortex_bucket_stores_series_requests_rejected_total 1
+	`), "cortex_bucket_stores_series_requests_rejected_total"))
+}
+
 func testBucketStoresSeriesShouldCorrectlyQuerySeriesSpanningMultipleChunks(t *testing.T, lazyLoadingEnabled bool) {
 	const (
 		userID     = "user-1"
@@ -86,7 +86,7 @@ func TestBucketBlock_matchLabels(t *testing.T) {
 		},
 	}
 
-	b, err := newBucketBlock(context.Background(), "test", log.NewNopLogger(), NewBucketStoreMetrics(nil), meta, bkt, path.Join(dir, blockID.String()), nil, nil, blockPartitioners{})
+	b, err := newBucketBlock(context.Background(), "test", log.NewNopLogger(), NewBucketStoreMetrics(nil), meta, bkt, path.Join(dir, blockID.String()), nil, nil, blockPartitioners{}, postingsCacheCodecs{})
 	assert.NoError(t, err)
 
 	cases := []struct {
@@ -1780,11 +1780,12 @@ func TestBucketStore_Series_OneBlock_InMemIndexCacheSegfault(t *testing.T) {
 			b1.meta.ULID: b1,
 			b2.meta.ULID: b2,
 		},
-		postingsStrategy:     selectAllStrategy{},
-		queryGate:            gate.NewNoop(),
-		chunksLimiterFactory: newStaticChunksLimiterFactory(0),
-		seriesLimiterFactory: newStaticSeriesLimiterFactory(0),
-		maxSeriesPerBatch:    65536,
+		postingsStrategy:         selectAllStrategy{},
+		queryGate:                gate.NewNoop(),
+		chunksLimiterFactory:     newStaticChunksLimiterFactory(0),
+		seriesLimiterFactory:     newStaticSeriesLimiterFactory(0),
+		chunkBytesLimiterFactory: NewChunkBytesLimiterFactory(func() uint64 { return 0 }),
+		maxSeriesPerBatch:        65536,
 	}
 
 	srv := newBucketStoreTestServer(t, store)
@@ -2313,11 +2314,12 @@ func TestBucketStore_Series_Limits(t *testing.T) {
 	assert.NoError(t, err)
 
 	tests := map[string]struct {
-		reqMatchers    []storepb.LabelMatcher
-		seriesLimit    uint64
-		chunksLimit    uint64
-		expectedErr    string
-		expectedSeries int
+		reqMatchers     []storepb.LabelMatcher
+		seriesLimit     uint64
+		chunksLimit     uint64
+		chunkBytesLimit uint64
+		expectedErr     string
+		expectedSeries  int
 	}{
 		"should fail if the number of unique series queried is greater than the configured series limit": {
 			reqMatchers: []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: labels.MetricName, Value: "series_[123]"}},
@@ -2339,6 +2341,16 @@ func TestBucketStore_Series_Limits(t *testing.T) {
 			chunksLimit:    6,
 			expectedSeries: 3,
 		},
+		"should fail if the size of the chunks queried is greater than the configured chunk bytes limit": {
+			reqMatchers:     []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: labels.MetricName, Value: "series_[123]"}},
+			chunkBytesLimit: 1,
+			expectedErr:     ErrChunkBytesLimitMessage,
+		},
+		"should pass if the size of the chunks queried is less than the configured chunk bytes limit": {
+			reqMatchers:     []storepb.LabelMatcher{{Type: storepb.LabelMatcher_RE, Name: labels.MetricName, Value: "series_[123]"}},
+			chunkBytesLimit: 1024 * 1024,
+			expectedSeries:  3,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -2367,6 +2379,7 @@ func TestBucketStore_Series_Limits(t *testing.T) {
 						newGapBasedPartitioners(mimir_tsdb.DefaultPartitionerMaxGapSize, nil),
 						hashcache.NewSeriesHashCache(1024*1024),
 						NewBucketStoreMetrics(nil),
+						WithChunkBytesLimiterFactory(NewChunkBytesLimiterFactory(func() uint64 { return testData.chunkBytesLimit })),
 					)
 					assert.NoError(t, err)
 					assert.NoError(t, store.SyncBlocks(ctx))
@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"sync"
+
+	"github.com/grafana/dskit/gate"
+)
+
+type fairGateTenantKey struct{}
+
+// fairGate is a gate.Gate limiting the number of concurrent queries across all tenants. Unlike
+// gate.NewBlocking, the queries waiting at the gate are admitted in a round-robin fashion across
+// tenants, so that a tenant running many queries doesn't starve the queries of the other tenants.
+// The tenant of a query is read from the context, see withFairGateTenant.
+type fairGate struct {
+	maxConcurrent int
+
+	mtx      sync.Mutex
+	inflight int
+	waiting  map[string][]chan struct{}
+	// tenants are the tenants with queries waiting at the gate, in the order they're admitted.
+	tenants []string
+}
+
+func newFairGate(maxConcurrent int) *fairGate {
+	return &fairGate{
+		maxConcurrent: maxConcurrent,
+		waiting:       map[string][]chan struct{}{},
+	}
+}
+
+// withFairGateTenant returns a gate.Gate admitting the queries through the input fairGate (possibly
+// wrapped, e.g. by gate.NewInstrumented) on behalf of the tenant.
+func withFairGateTenant(g gate.Gate, userID string) gate.Gate {
+	return tenantGate{Gate: g, userID: userID}
+}
+
+type tenantGate struct {
+	gate.Gate
+
+	userID string
+}
+
+func (g tenantGate) Start(ctx context.Context) error {
+	return g.Gate.Start(context.WithValue(ctx, fairGateTenantKey{}, g.userID))
+}
+
+// Start implements gate.Gate.
+func (g *fairGate) Start(ctx context.Context) error {
+	userID, _ := ctx.Value(fairGateTenantKey{}).(string)
+
+	g.mtx.Lock()
+	if g.inflight < g.maxConcurrent && len(g.tenants) == 0 {
+		g.inflight++
+		g.mtx.Unlock()
+		return nil
+	}
+
+	admitted := make(chan struct{})
+	if len(g.waiting[userID]) == 0 {
+		g.tenants = append(g.tenants, userID)
+	}
+	g.waiting[userID] = append(g.waiting[userID], admitted)
+	g.mtx.Unlock()
+
+	select {
+	case <-admitted:
+		return nil
+	case <-ctx.Done():
+	}
+
+	g.mtx.Lock()
+	removed := g.removeWaiting(userID, admitted)
+	g.mtx.Unlock()
+
+	// The query has been admitted in the meanwhile: hand its slot over to the next one.
+	if !removed {
+		g.Done()
+	}
+	return ctx.Err()
+}
+
+// Done implements gate.Gate.
+func (g *fairGate) Done() {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	if len(g.tenants) == 0 {
+		g.inflight--
+		return
+	}
+
+	// Admit the oldest waiting query of the next tenant, keeping the slot in use.
+	userID := g.tenants[0]
+	g.tenants = g.tenants[1:]
+
+	queue := g.waiting[userID]
+	close(queue[0])
+	if len(queue) == 1 {
+		delete(g.waiting, userID)
+	} else {
+		g.waiting[userID] = queue[1:]
+		g.tenants = append(g.tenants, userID)
+	}
+}
+
+// removeWaiting removes the admitted channel from the queries of the tenant waiting at the gate,
+// and returns whether it was found. Must be called with the lock held.
+func (g *fairGate) removeWaiting(userID string, admitted chan struct{}) bool {
+	queue := g.waiting[userID]
+	for i, c := range queue {
+		if c != admitted {
+			continue
+		}
+
+		if len(queue) > 1 {
+			g.waiting[userID] = append(queue[:i:i], queue[i+1:]...)
+			return true
+		}
+
+		delete(g.waiting, userID)
+		for j, t := range g.tenants {
+			if t == userID {
+				g.tenants = append(g.tenants[:j:j], g.tenants[j+1:]...)
+				break
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dstest "github.com/grafana/dskit/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFairGate_ShouldAdmitWaitingQueriesRoundRobinAcrossTenants(t *testing.T) {
+	ctx := context.Background()
+	g := newFairGate(1)
+
+	// Hold the only slot.
+	require.NoError(t, withFairGateTenant(g, "user-a").Start(ctx))
+
+	admitted := make(chan string, 4)
+	for i, waiter := range []struct{ userID, name string }{
+		{"user-a", "a1"},
+		{"user-a", "a2"},
+		{"user-a", "a3"},
+		{"user-b", "b1"},
+	} {
+		waiter := waiter
+		go func() {
+			if err := withFairGateTenant(g, waiter.userID).Start(ctx); err == nil {
+				admitted <- waiter.name
+			}
+		}()
+
+		// Wait until the query is waiting at the gate, to have a deterministic order.
+		dstest.Poll(t, time.Second, i+1, func() interface{} {
+			return g.numWaiting()
+		})
+	}
+
+	var order []string
+	for i := 0; i < 4; i++ {
+		g.Done()
+		order = append(order, <-admitted)
+	}
+	assert.Equal(t, []string{"a1", "b1", "a2", "a3"}, order)
+
+	g.Done()
+	assert.Equal(t, 0, g.inflight)
+}
+
+func TestFairGate_ShouldRemoveWaitingQueryOnContextCancellation(t *testing.T) {
+	g := newFairGate(1)
+	require.NoError(t, withFairGateTenant(g, "user-a").Start(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		errs <- withFairGateTenant(g, "user-b").Start(ctx)
+	}()
+
+	dstest.Poll(t, time.Second, 1, func() interface{} {
+		return g.numWaiting()
+	})
+	cancel()
+	assert.ErrorIs(t, <-errs, context.Canceled)
+	assert.Equal(t, 0, g.numWaiting())
+	assert.Empty(t, g.tenants)
+
+	// The slot is released to no one.
+	g.Done()
+	assert.Equal(t, 0, g.inflight)
+	require.NoError(t, withFairGateTenant(g, "user-b").Start(context.Background()))
+	g.Done()
+}
+
+func (g *fairGate) numWaiting() int {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	n := 0
+	for _, queue := range g.waiting {
+		n += len(queue)
+	}
+	return n
+}
@@ -340,6 +340,26 @@ func (g *StoreGateway) LabelValues(ctx context.Context, req *storepb.LabelValues
 	return g.stores.LabelValues(ctx, req)
 }
 
+// LabelNamesCardinality implements the storegatewaypb.StoreGatewayServer interface.
+func (g *StoreGateway) LabelNamesCardinality(ctx context.Context, req *storepb.LabelNamesCardinalityRequest) (*storepb.LabelNamesCardinalityResponse, error) {
+	ix := g.tracker.Insert(func() string {
+		return requestActivity(ctx, "StoreGateway/LabelNamesCardinality", req)
+	})
+	defer g.tracker.Delete(ix)
+
+	return g.stores.LabelNamesCardinality(ctx, req)
+}
+
+// LabelValuesCardinality implements the storegatewaypb.StoreGatewayServer interface.
+func (g *StoreGateway) LabelValuesCardinality(ctx context.Context, req *storepb.LabelValuesCardinalityRequest) (*storepb.LabelValuesCardinalityResponse, error) {
+	ix := g.tracker.Insert(func() string {
+		return requestActivity(ctx, "StoreGateway/LabelValuesCardinality", req)
+	})
+	defer g.tracker.Delete(ix)
+
+	return g.stores.LabelValuesCardinality(ctx, req)
+}
+
 func requestActivity(ctx context.Context, name string, req interface{}) string {
 	user := getUserIDFromGRPCContext(ctx)
 	traceID, _ := tracing.ExtractSampledTraceID(ctx)
@@ -31,6 +31,9 @@ type SeriesRequestHints struct {
 	/// labels to filter which blocks get queried. If the list is empty, no per-block filtering
 	/// is applied.
 	BlockMatchers []storepb.LabelMatcher `protobuf:"bytes,1,rep,name=block_matchers,json=blockMatchers,proto3" json:"block_matchers"`
+	/// aggregation_pushdown, if set, asks the store-gateway to evaluate the aggregation over each
+	/// queried block and to return the partial aggregates instead of the raw series.
+	AggregationPushdown *AggregationPushdown `protobuf:"bytes,2,opt,name=aggregation_pushdown,json=aggregationPushdown,proto3" json:"aggregation_pushdown,omitempty"`
 }
 
 func (m *SeriesRequestHints) Reset()      { *m = SeriesRequestHints{} }
@@ -65,6 +68,57 @@ func (m *SeriesRequestHints) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_SeriesRequestHints proto.InternalMessageInfo
 
+// / AggregationPushdown describes a range query in the form
+// / <aggregation> [by|without (<grouping>)] (<function>(<selector>[<range>])), where the
+// / selector is defined by the matchers of the series request.
+type AggregationPushdown struct {
+	/// aggregation is the name of the aggregation operator (e.g. "sum").
+	Aggregation string `protobuf:"bytes,1,opt,name=aggregation,proto3" json:"aggregation,omitempty"`
+	/// grouping is the list of label names of the "by" clause, or of the "without" clause if without is true.
+	Grouping []string `protobuf:"bytes,2,rep,name=grouping,proto3" json:"grouping,omitempty"`
+	Without  bool     `protobuf:"varint,3,opt,name=without,proto3" json:"without,omitempty"`
+	/// function is the name of the range function (e.g. "rate").
+	Function string `protobuf:"bytes,4,opt,name=function,proto3" json:"function,omitempty"`
+	/// range is the range of the range function, in milliseconds.
+	Range int64 `protobuf:"varint,5,opt,name=range,proto3" json:"range,omitempty"`
+	/// start, end and step are the evaluation time range and resolution of the query, in milliseconds.
+	Start int64 `protobuf:"varint,6,opt,name=start,proto3" json:"start,omitempty"`
+	End   int64 `protobuf:"varint,7,opt,name=end,proto3" json:"end,omitempty"`
+	Step  int64 `protobuf:"varint,8,opt,name=step,proto3" json:"step,omitempty"`
+}
+
+func (m *AggregationPushdown) Reset()      { *m = AggregationPushdown{} }
+func (*AggregationPushdown) ProtoMessage() {}
+func (*AggregationPushdown) Descriptor() ([]byte, []int) {
+	return fileDescriptor_522be8e0d2634375, []int{1}
+}
+func (m *AggregationPushdown) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *AggregationPushdown) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_AggregationPushdown.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *AggregationPushdown) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AggregationPushdown.Merge(m, src)
+}
+func (m *AggregationPushdown) XXX_Size() int {
+	return m.Size()
+}
+func (m *AggregationPushdown) XXX_DiscardUnknown() {
+	xxx_messageInfo_AggregationPushdown.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AggregationPushdown proto.InternalMessageInfo
+
 type SeriesResponseHints struct {
 	/// queried_blocks is the list of blocks that have been queried.
 	QueriedBlocks []Block `protobuf:"bytes,1,rep,name=queried_blocks,json=queriedBlocks,proto3" json:"queried_blocks"`
@@ -73,7 +127,7 @@ type SeriesResponseHints struct {
 func (m *SeriesResponseHints) Reset()      { *m = SeriesResponseHints{} }
 func (*SeriesResponseHints) ProtoMessage() {}
 func (*SeriesResponseHints) Descriptor() ([]byte, []int) {
-	return fileDescriptor_522be8e0d2634375, []int{1}
+	return fileDescriptor_522be8e0d2634375, []int{2}
 }
 func (m *SeriesResponseHints) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -109,7 +163,7 @@ type Block struct {
 func (m *Block) Reset()      { *m = Block{} }
 func (*Block) ProtoMessage() {}
 func (*Block) Descriptor() ([]byte, []int) {
-	return fileDescriptor_522be8e0d2634375, []int{2}
+	return fileDescriptor_522be8e0d2634375, []int{3}
 }
 func (m *Block) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -148,7 +202,7 @@ type LabelNamesRequestHints struct {
 func (m *LabelNamesRequestHints) Reset()      { *m = LabelNamesRequestHints{} }
 func (*LabelNamesRequestHints) ProtoMessage() {}
 func (*LabelNamesRequestHints) Descriptor() ([]byte, []int) {
-	return fileDescriptor_522be8e0d2634375, []int{3}
+	return fileDescriptor_522be8e0d2634375, []int{4}
 }
 func (m *LabelNamesRequestHints) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -185,7 +239,7 @@ type LabelNamesResponseHints struct {
 func (m *LabelNamesResponseHints) Reset()      { *m = LabelNamesResponseHints{} }
 func (*LabelNamesResponseHints) ProtoMessage() {}
 func (*LabelNamesResponseHints) Descriptor() ([]byte, []int) {
-	return fileDescriptor_522be8e0d2634375, []int{4}
+	return fileDescriptor_522be8e0d2634375, []int{5}
 }
 func (m *LabelNamesResponseHints) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -224,7 +278,7 @@ type LabelValuesRequestHints struct {
 func (m *LabelValuesRequestHints) Reset()      { *m = LabelValuesRequestHints{} }
 func (*LabelValuesRequestHints) ProtoMessage() {}
 func (*LabelValuesRequestHints) Descriptor() ([]byte, []int) {
-	return fileDescriptor_522be8e0d2634375, []int{5}
+	return fileDescriptor_522be8e0d2634375, []int{6}
 }
 func (m *LabelValuesRequestHints) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -261,7 +315,7 @@ type LabelValuesResponseHints struct {
 func (m *LabelValuesResponseHints) Reset()      { *m = LabelValuesResponseHints{} }
 func (*LabelValuesResponseHints) ProtoMessage() {}
 func (*LabelValuesResponseHints) Descriptor() ([]byte, []int) {
-	return fileDescriptor_522be8e0d2634375, []int{6}
+	return fileDescriptor_522be8e0d2634375, []int{7}
 }
 func (m *LabelValuesResponseHints) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -292,6 +346,7 @@ var xxx_messageInfo_LabelValuesResponseHints proto.InternalMessageInfo
 
 func init() {
 	proto.RegisterType((*SeriesRequestHints)(nil), "hintspb.SeriesRequestHints")
+	proto.RegisterType((*AggregationPushdown)(nil), "hintspb.AggregationPushdown")
 	proto.RegisterType((*SeriesResponseHints)(nil), "hintspb.SeriesResponseHints")
 	proto.RegisterType((*Block)(nil), "hintspb.Block")
 	proto.RegisterType((*LabelNamesRequestHints)(nil), "hintspb.LabelNamesRequestHints")
@@ -303,30 +358,39 @@ func init() {
 func init() { proto.RegisterFile("hints.proto", fileDescriptor_522be8e0d2634375) }
 
 var fileDescriptor_522be8e0d2634375 = []byte{
-	// 358 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x92, 0xb1, 0x4e, 0xfb, 0x30,
-	0x10, 0xc6, 0xed, 0xfe, 0xff, 0x80, 0x70, 0x45, 0x86, 0x80, 0x68, 0xd5, 0xe1, 0xa8, 0x32, 0x75,
-	0x21, 0x91, 0x60, 0x44, 0x0c, 0xed, 0xc4, 0x00, 0x0c, 0x41, 0x6a, 0x25, 0x40, 0xaa, 0x9c, 0xd6,
-	0x4d, 0xa2, 0x36, 0x71, 0x1a, 0x3b, 0x42, 0xdd, 0x78, 0x04, 0x1e, 0x83, 0x47, 0xe9, 0xd8, 0xb1,
-	0x13, 0x22, 0xe9, 0xc2, 0xd8, 0x47, 0x40, 0x75, 0x12, 0xa9, 0xec, 0xd9, 0xfc, 0x7d, 0x77, 0xf7,
-	0xf3, 0x77, 0xd2, 0x91, 0xba, 0xe7, 0x87, 0x52, 0x98, 0x51, 0xcc, 0x25, 0xd7, 0x8f, 0x94, 0x88,
-	0x9c, 0xd6, 0xa5, 0xeb, 0x4b, 0x2f, 0x71, 0xcc, 0x11, 0x0f, 0x2c, 0x97, 0xbb, 0xdc, 0x52, 0x75,
-	0x27, 0x99, 0x28, 0xa5, 0x84, 0x7a, 0xe5, 0x73, 0xad, 0xdb, 0xfd, 0xf6, 0x98, 0x4e, 0x68, 0x48,
-	0xad, 0xc0, 0x0f, 0xfc, 0xd8, 0x8a, 0xa6, 0xae, 0x25, 0x24, 0x8f, 0x99, 0x4b, 0x25, 0x7b, 0xa3,
-	0x8b, 0x5c, 0x44, 0x8e, 0x25, 0x17, 0x11, 0x2b, 0xbe, 0x35, 0x06, 0x44, 0x7f, 0x62, 0xb1, 0xcf,
-	0x84, 0xcd, 0xe6, 0x09, 0x13, 0xf2, 0x6e, 0x97, 0x42, 0xef, 0x12, 0xcd, 0x99, 0xf1, 0xd1, 0x74,
-	0x18, 0x50, 0x39, 0xf2, 0x58, 0x2c, 0x9a, 0xb8, 0xfd, 0xaf, 0x53, 0xbf, 0x3a, 0x33, 0xa5, 0x47,
-	0x43, 0x2e, 0xcc, 0x7b, 0xea, 0xb0, 0xd9, 0x43, 0x5e, 0xec, 0xfd, 0x5f, 0x7e, 0x5d, 0x20, 0xfb,
-	0x44, 0x4d, 0x14, 0x9e, 0x30, 0x6c, 0x72, 0x5a, 0x82, 0x45, 0xc4, 0x43, 0xc1, 0x72, 0xf2, 0x0d,
-	0xd1, 0xe6, 0xc9, 0xce, 0x1f, 0x0f, 0x55, 0x7f, 0x49, 0xd6, 0xcc, 0x62, 0x7f, 0xb3, 0xb7, 0xb3,
-	0x4b, 0x66, 0xd1, 0xab, 0x3c, 0x61, 0x34, 0xc8, 0x81, 0x7a, 0xe9, 0x1a, 0xa9, 0xf9, 0xe3, 0x26,
-	0x6e, 0xe3, 0xce, 0xb1, 0x5d, 0xf3, 0xc7, 0xc6, 0x0b, 0x39, 0x57, 0x89, 0x1e, 0x69, 0x50, 0xfd,
-	0x26, 0x7d, 0xd2, 0xd8, 0x87, 0x57, 0xb6, 0xcd, 0x6b, 0xc1, 0xed, 0xd3, 0x59, 0x52, 0x7d, 0xea,
-	0x01, 0x69, 0xfe, 0xa1, 0x57, 0x15, 0xbb, 0xd7, 0x5d, 0xa6, 0x80, 0x56, 0x29, 0xa0, 0x75, 0x0a,
-	0x68, 0x9b, 0x02, 0x7e, 0xcf, 0x00, 0x7f, 0x66, 0x80, 0x97, 0x19, 0xe0, 0x55, 0x06, 0xf8, 0x3b,
-	0x03, 0xfc, 0x93, 0x01, 0xda, 0x66, 0x80, 0x3f, 0x36, 0x80, 0x56, 0x1b, 0x40, 0xeb, 0x0d, 0xa0,
-	0xe7, 0xf2, 0xc4, 0x9d, 0x43, 0x75, 0x7b, 0xd7, 0xbf, 0x01, 0x00, 0x00, 0xff, 0xff, 0xe1, 0x13,
-	0x56, 0x30, 0x01, 0x03, 0x00, 0x00,
+	// 502 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x54, 0xcd, 0x8a, 0xdb, 0x3c,
+	0x14, 0xb5, 0xf2, 0x33, 0xc9, 0x28, 0x7c, 0xe1, 0x43, 0x09, 0x1d, 0x11, 0x8a, 0x6a, 0xbc, 0xf2,
+	0xa6, 0x36, 0x4c, 0x97, 0xa5, 0x8b, 0x64, 0xd5, 0x45, 0xff, 0x70, 0x61, 0x0a, 0x6d, 0x21, 0xc8,
+	0x89, 0x62, 0x8b, 0x49, 0x24, 0x8f, 0x25, 0x13, 0x66, 0xd7, 0x47, 0xe8, 0x63, 0xcc, 0xa3, 0x64,
+	0x99, 0xe5, 0x40, 0xa1, 0x34, 0xce, 0xa6, 0xcb, 0x79, 0x84, 0x62, 0xd9, 0x4e, 0x53, 0xda, 0x65,
+	0x76, 0xf7, 0x1c, 0x9d, 0x7b, 0x74, 0x75, 0x0f, 0x36, 0xec, 0xc5, 0x5c, 0x68, 0xe5, 0x25, 0xa9,
+	0xd4, 0x12, 0x75, 0x0c, 0x48, 0xc2, 0xd1, 0xd3, 0x88, 0xeb, 0x38, 0x0b, 0xbd, 0x99, 0x5c, 0xf9,
+	0x91, 0x8c, 0xa4, 0x6f, 0xce, 0xc3, 0x6c, 0x61, 0x90, 0x01, 0xa6, 0x2a, 0xfb, 0x46, 0x2f, 0x8e,
+	0xe5, 0x29, 0x5d, 0x50, 0x41, 0xfd, 0x15, 0x5f, 0xf1, 0xd4, 0x4f, 0xae, 0x23, 0x5f, 0x69, 0x99,
+	0xb2, 0x88, 0x6a, 0xb6, 0xa6, 0xb7, 0x25, 0x48, 0x42, 0x5f, 0xdf, 0x26, 0xac, 0xba, 0xd6, 0xb9,
+	0x03, 0x10, 0xbd, 0x67, 0x29, 0x67, 0x2a, 0x60, 0x37, 0x19, 0x53, 0xfa, 0x65, 0x31, 0x06, 0x1a,
+	0xc3, 0x7e, 0xb8, 0x94, 0xb3, 0xeb, 0xe9, 0x8a, 0xea, 0x59, 0xcc, 0x52, 0x85, 0x81, 0xdd, 0x74,
+	0x7b, 0x97, 0x43, 0x4f, 0xc7, 0x54, 0x48, 0xe5, 0xbd, 0xa2, 0x21, 0x5b, 0xbe, 0x2e, 0x0f, 0x27,
+	0xad, 0xcd, 0xf7, 0x27, 0x56, 0xf0, 0x9f, 0xe9, 0xa8, 0x38, 0x85, 0xde, 0xc2, 0x21, 0x8d, 0x22,
+	0x73, 0x3b, 0x97, 0x62, 0x9a, 0x64, 0x2a, 0x9e, 0xcb, 0xb5, 0xc0, 0x0d, 0x1b, 0xb8, 0xbd, 0xcb,
+	0xc7, 0x5e, 0xf5, 0x5e, 0x6f, 0xfc, 0x5b, 0xf4, 0xae, 0xd2, 0x04, 0x03, 0xfa, 0x37, 0xe9, 0x7c,
+	0x03, 0x70, 0xf0, 0x0f, 0x31, 0xb2, 0x61, 0xef, 0x48, 0x8e, 0x81, 0x0d, 0xdc, 0xf3, 0xe0, 0x98,
+	0x42, 0x23, 0xd8, 0x8d, 0x52, 0x99, 0x25, 0x5c, 0x44, 0xb8, 0x61, 0x37, 0xdd, 0xf3, 0xe0, 0x80,
+	0x11, 0x86, 0x9d, 0x35, 0xd7, 0xb1, 0xcc, 0x34, 0x6e, 0xda, 0xc0, 0xed, 0x06, 0x35, 0x2c, 0xba,
+	0x16, 0x99, 0x98, 0x19, 0xd3, 0x96, 0x31, 0x3d, 0x60, 0x34, 0x84, 0xed, 0x94, 0x8a, 0x88, 0xe1,
+	0xb6, 0x0d, 0xdc, 0x66, 0x50, 0x82, 0x82, 0x55, 0x9a, 0xa6, 0x1a, 0x9f, 0x95, 0xac, 0x01, 0xe8,
+	0x7f, 0xd8, 0x64, 0x62, 0x8e, 0x3b, 0x86, 0x2b, 0x4a, 0x84, 0x60, 0x4b, 0x69, 0x96, 0xe0, 0xae,
+	0xa1, 0x4c, 0xed, 0x04, 0x70, 0x50, 0xe7, 0xa0, 0x12, 0x29, 0x14, 0x2b, 0x83, 0x78, 0x0e, 0xfb,
+	0x37, 0x59, 0xc1, 0xcf, 0xa7, 0x66, 0xbd, 0x75, 0x10, 0xfd, 0xc3, 0xfe, 0x26, 0x05, 0x5d, 0x47,
+	0x50, 0x69, 0x0d, 0xa7, 0x9c, 0x0b, 0xd8, 0x36, 0x15, 0xea, 0xc3, 0x06, 0x9f, 0x57, 0x9b, 0x69,
+	0xf0, 0xb9, 0xf3, 0x09, 0x3e, 0x32, 0x01, 0xbe, 0xa1, 0xab, 0x93, 0x07, 0xef, 0x5c, 0xc1, 0x8b,
+	0x63, 0xf3, 0x93, 0xbd, 0xe6, 0x73, 0xe5, 0x7b, 0x45, 0x97, 0xd9, 0xe9, 0xa7, 0xfe, 0x00, 0xf1,
+	0x1f, 0xee, 0xa7, 0x1a, 0x7b, 0x32, 0xde, 0xec, 0x88, 0xb5, 0xdd, 0x11, 0xeb, 0x7e, 0x47, 0xac,
+	0x87, 0x1d, 0x01, 0x5f, 0x72, 0x02, 0xee, 0x72, 0x02, 0x36, 0x39, 0x01, 0xdb, 0x9c, 0x80, 0x1f,
+	0x39, 0x01, 0x3f, 0x73, 0x62, 0x3d, 0xe4, 0x04, 0x7c, 0xdd, 0x13, 0x6b, 0xbb, 0x27, 0xd6, 0xfd,
+	0x9e, 0x58, 0x1f, 0xeb, 0x5f, 0x42, 0x78, 0x66, 0xbe, 0xd5, 0x67, 0xbf, 0x06, 0x00, 0xc9, 0xfb,
+	0xb7, 0xf5, 0x31, 0x04, 0x00, 0x00,
 }
 
 func (this *SeriesRequestHints) Equal(that interface{}) bool {
@@ -356,6 +420,59 @@ func (this *SeriesRequestHints) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if !this.AggregationPushdown.Equal(that1.AggregationPushdown) {
+		return false
+	}
+	return true
+}
+func (this *AggregationPushdown) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*AggregationPushdown)
+	if !ok {
+		that2, ok := that.(AggregationPushdown)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Aggregation != that1.Aggregation {
+		return false
+	}
+	if len(this.Grouping) != len(that1.Grouping) {
+		return false
+	}
+	for i := range this.Grouping {
+		if this.Grouping[i] != that1.Grouping[i] {
+			return false
+		}
+	}
+	if this.Without != that1.Without {
+		return false
+	}
+	if this.Function != that1.Function {
+		return false
+	}
+	if this.Range != that1.Range {
+		return false
+	}
+	if this.Start != that1.Start {
+		return false
+	}
+	if this.End != that1.End {
+		return false
+	}
+	if this.Step != that1.Step {
+		return false
+	}
 	return true
 }
 func (this *SeriesResponseHints) Equal(that interface{}) bool {
@@ -531,7 +648,7 @@ func (this *SeriesRequestHints) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 5)
+	s := make([]string, 0, 6)
 	s = append(s, "&hintspb.SeriesRequestHints{")
 	if this.BlockMatchers != nil {
 		vs := make([]*storepb.LabelMatcher, len(this.BlockMatchers))
@@ -540,6 +657,26 @@ func (this *SeriesRequestHints) GoString() string {
 		}
 		s = append(s, "BlockMatchers: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
+	if this.AggregationPushdown != nil {
+		s = append(s, "AggregationPushdown: "+fmt.Sprintf("%#v", this.AggregationPushdown)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *AggregationPushdown) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 12)
+	s = append(s, "&hintspb.AggregationPushdown{")
+	s = append(s, "Aggregation: "+fmt.Sprintf("%#v", this.Aggregation)+",\n")
+	s = append(s, "Grouping: "+fmt.Sprintf("%#v", this.Grouping)+",\n")
+	s = append(s, "Without: "+fmt.Sprintf("%#v", this.Without)+",\n")
+	s = append(s, "Function: "+fmt.Sprintf("%#v", this.Function)+",\n")
+	s = append(s, "Range: "+fmt.Sprintf("%#v", this.Range)+",\n")
+	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
+	s = append(s, "End: "+fmt.Sprintf("%#v", this.End)+",\n")
+	s = append(s, "Step: "+fmt.Sprintf("%#v", this.Step)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -661,6 +798,18 @@ func (m *SeriesRequestHints) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.AggregationPushdown != nil {
+		{
+			size, err := m.AggregationPushdown.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintHints(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
 	if len(m.BlockMatchers) > 0 {
 		for iNdEx := len(m.BlockMatchers) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -678,6 +827,82 @@ func (m *SeriesRequestHints) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *AggregationPushdown) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *AggregationPushdown) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *AggregationPushdown) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Step != 0 {
+		i = encodeVarintHints(dAtA, i, uint64(m.Step))
+		i--
+		dAtA[i] = 0x40
+	}
+	if m.End != 0 {
+		i = encodeVarintHints(dAtA, i, uint64(m.End))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.Start != 0 {
+		i = encodeVarintHints(dAtA, i, uint64(m.Start))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.Range != 0 {
+		i = encodeVarintHints(dAtA, i, uint64(m.Range))
+		i--
+		dAtA[i] = 0x28
+	}
+	if len(m.Function) > 0 {
+		i -= len(m.Function)
+		copy(dAtA[i:], m.Function)
+		i = encodeVarintHints(dAtA, i, uint64(len(m.Function)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Without {
+		i--
+		if m.Without {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Grouping) > 0 {
+		for iNdEx := len(m.Grouping) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Grouping[iNdEx])
+			copy(dAtA[i:], m.Grouping[iNdEx])
+			i = encodeVarintHints(dAtA, i, uint64(len(m.Grouping[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Aggregation) > 0 {
+		i -= len(m.Aggregation)
+		copy(dAtA[i:], m.Aggregation)
+		i = encodeVarintHints(dAtA, i, uint64(len(m.Aggregation)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *SeriesResponseHints) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -916,6 +1141,48 @@ func (m *SeriesRequestHints) Size() (n int) {
 			n += 1 + l + sovHints(uint64(l))
 		}
 	}
+	if m.AggregationPushdown != nil {
+		l = m.AggregationPushdown.Size()
+		n += 1 + l + sovHints(uint64(l))
+	}
+	return n
+}
+
+func (m *AggregationPushdown) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Aggregation)
+	if l > 0 {
+		n += 1 + l + sovHints(uint64(l))
+	}
+	if len(m.Grouping) > 0 {
+		for _, s := range m.Grouping {
+			l = len(s)
+			n += 1 + l + sovHints(uint64(l))
+		}
+	}
+	if m.Without {
+		n += 2
+	}
+	l = len(m.Function)
+	if l > 0 {
+		n += 1 + l + sovHints(uint64(l))
+	}
+	if m.Range != 0 {
+		n += 1 + sovHints(uint64(m.Range))
+	}
+	if m.Start != 0 {
+		n += 1 + sovHints(uint64(m.Start))
+	}
+	if m.End != 0 {
+		n += 1 + sovHints(uint64(m.End))
+	}
+	if m.Step != 0 {
+		n += 1 + sovHints(uint64(m.Step))
+	}
 	return n
 }
 
@@ -1024,6 +1291,24 @@ func (this *SeriesRequestHints) String() string {
 	repeatedStringForBlockMatchers += "}"
 	s := strings.Join([]string{`&SeriesRequestHints{`,
 		`BlockMatchers:` + repeatedStringForBlockMatchers + `,`,
+		`AggregationPushdown:` + strings.Replace(this.AggregationPushdown.String(), "AggregationPushdown", "AggregationPushdown", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *AggregationPushdown) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&AggregationPushdown{`,
+		`Aggregation:` + fmt.Sprintf("%v", this.Aggregation) + `,`,
+		`Grouping:` + fmt.Sprintf("%v", this.Grouping) + `,`,
+		`Without:` + fmt.Sprintf("%v", this.Without) + `,`,
+		`Function:` + fmt.Sprintf("%v", this.Function) + `,`,
+		`Range:` + fmt.Sprintf("%v", this.Range) + `,`,
+		`Start:` + fmt.Sprintf("%v", this.Start) + `,`,
+		`End:` + fmt.Sprintf("%v", this.End) + `,`,
+		`Step:` + fmt.Sprintf("%v", this.Step) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1184,6 +1469,284 @@ func (m *SeriesRequestHints) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AggregationPushdown", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHints
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHints
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.AggregationPushdown == nil {
+				m.AggregationPushdown = &AggregationPushdown{}
+			}
+			if err := m.AggregationPushdown.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipHints(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthHints
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *AggregationPushdown) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowHints
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: AggregationPushdown: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: AggregationPushdown: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Aggregation", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthHints
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHints
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Aggregation = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Grouping", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthHints
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHints
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Grouping = append(m.Grouping, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Without", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Without = bool(v != 0)
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Function", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthHints
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHints
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Function = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Range", wireType)
+			}
+			m.Range = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Range |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+			}
+			m.Start = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Start |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+			}
+			m.End = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.End |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Step", wireType)
+			}
+			m.Step = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHints
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Step |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipHints(dAtA[iNdEx:])
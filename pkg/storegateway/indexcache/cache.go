@@ -29,6 +29,8 @@ const (
 	cacheTypeSeriesForPostings = "SeriesForPostings"
 	cacheTypeLabelNames        = "LabelNames"
 	cacheTypeLabelValues       = "LabelValues"
+
+	cacheTypeLabelValuesCardinality = "LabelValuesCardinality"
 )
 
 var (
@@ -39,6 +41,7 @@ var (
 		cacheTypeSeriesForPostings,
 		cacheTypeLabelNames,
 		cacheTypeLabelValues,
+		cacheTypeLabelValuesCardinality,
 	}
 )
 
@@ -114,6 +117,11 @@ type IndexCache interface {
 	StoreLabelValues(userID string, blockID ulid.ULID, labelName string, matchersKey LabelMatchersKey, v []byte)
 	// FetchLabelValues fetches the result of a LabelValues() call.
 	FetchLabelValues(ctx context.Context, userID string, blockID ulid.ULID, labelName string, matchersKey LabelMatchersKey) ([]byte, bool)
+
+	// StoreLabelValuesCardinality stores the series count of each label value, computed by a LabelValuesCardinality() call.
+	StoreLabelValuesCardinality(userID string, blockID ulid.ULID, labelName string, matchersKey LabelMatchersKey, v []byte)
+	// FetchLabelValuesCardinality fetches the series count of each label value, computed by a LabelValuesCardinality() call.
+	FetchLabelValuesCardinality(ctx context.Context, userID string, blockID ulid.ULID, labelName string, matchersKey LabelMatchersKey) ([]byte, bool)
 }
 
 // PostingsKey represents a canonical key for a []storage.SeriesRef slice
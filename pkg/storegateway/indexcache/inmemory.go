@@ -374,6 +374,16 @@ func (c *InMemoryIndexCache) FetchLabelValues(_ context.Context, userID string,
 	return c.get(cacheKeyLabelValues{userID, blockID, labelName, matchersKey})
 }
 
+// StoreLabelValuesCardinality stores the result of a LabelValuesCardinality() call.
+func (c *InMemoryIndexCache) StoreLabelValuesCardinality(userID string, blockID ulid.ULID, labelName string, matchersKey LabelMatchersKey, v []byte) {
+	c.set(cacheKeyLabelValuesCardinality{userID, blockID, labelName, matchersKey}, v)
+}
+
+// FetchLabelValuesCardinality fetches the result of a LabelValuesCardinality() call.
+func (c *InMemoryIndexCache) FetchLabelValuesCardinality(_ context.Context, userID string, blockID ulid.ULID, labelName string, matchersKey LabelMatchersKey) ([]byte, bool) {
+	return c.get(cacheKeyLabelValuesCardinality{userID, blockID, labelName, matchersKey})
+}
+
 // cacheKey is used by in-memory representation to store cached data.
 // The implementations of cacheKey should be hashable, as they will be used as keys for *lru.LRU cache
 type cacheKey interface {
@@ -467,6 +477,21 @@ func (c cacheKeyLabelValues) size() uint64 {
 	return stringSize(c.userID) + ulidSize + stringSize(c.labelName) + stringSize(string(c.matchersKey))
 }
 
+type cacheKeyLabelValuesCardinality struct {
+	userID      string
+	block       ulid.ULID
+	labelName   string
+	matchersKey LabelMatchersKey
+}
+
+func (c cacheKeyLabelValuesCardinality) typ() string {
+	return cacheTypeLabelValuesCardinality
+}
+
+func (c cacheKeyLabelValuesCardinality) size() uint64 {
+	return stringSize(c.userID) + ulidSize + stringSize(c.labelName) + stringSize(string(c.matchersKey))
+}
+
 func stringSize(s string) uint64 {
 	return stringHeaderSize + uint64(len(s))
 }
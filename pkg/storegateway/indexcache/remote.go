@@ -327,3 +327,18 @@ func labelValuesCacheKey(userID string, blockID ulid.ULID, labelName string, mat
 	hash := blake2b.Sum256([]byte(matchersKey))
 	return "LV2:" + userID + ":" + blockID.String() + ":" + labelName + ":" + base64.RawURLEncoding.EncodeToString(hash[0:])
 }
+
+// StoreLabelValuesCardinality stores the result of a LabelValuesCardinality() call.
+func (c *RemoteIndexCache) StoreLabelValuesCardinality(userID string, blockID ulid.ULID, labelName string, matchersKey LabelMatchersKey, v []byte) {
+	c.set(cacheTypeLabelValuesCardinality, labelValuesCardinalityCacheKey(userID, blockID, labelName, matchersKey), v)
+}
+
+// FetchLabelValuesCardinality fetches the result of a LabelValuesCardinality() call.
+func (c *RemoteIndexCache) FetchLabelValuesCardinality(ctx context.Context, userID string, blockID ulid.ULID, labelName string, matchersKey LabelMatchersKey) ([]byte, bool) {
+	return c.get(ctx, cacheTypeLabelValuesCardinality, labelValuesCardinalityCacheKey(userID, blockID, labelName, matchersKey))
+}
+
+func labelValuesCardinalityCacheKey(userID string, blockID ulid.ULID, labelName string, matchersKey LabelMatchersKey) string {
+	hash := blake2b.Sum256([]byte(matchersKey))
+	return "LVC:" + userID + ":" + blockID.String() + ":" + labelName + ":" + base64.RawURLEncoding.EncodeToString(hash[0:])
+}
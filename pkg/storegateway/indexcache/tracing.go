@@ -170,3 +170,26 @@ func sumBytes[T comparable](res map[T][]byte) int {
 	}
 	return sum
 }
+
+func (t *TracingIndexCache) StoreLabelValuesCardinality(userID string, blockID ulid.ULID, labelName string, matchersKey LabelMatchersKey, v []byte) {
+	t.c.StoreLabelValuesCardinality(userID, blockID, labelName, matchersKey, v)
+}
+
+func (t *TracingIndexCache) FetchLabelValuesCardinality(ctx context.Context, userID string, blockID ulid.ULID, labelName string, matchersKey LabelMatchersKey) ([]byte, bool) {
+	t0 := time.Now()
+	data, found := t.c.FetchLabelValuesCardinality(ctx, userID, blockID, labelName, matchersKey)
+
+	spanLogger := spanlogger.FromContext(ctx, t.logger)
+	level.Debug(spanLogger).Log(
+		"msg", "IndexCache.FetchLabelValuesCardinality",
+		"block", blockID,
+		"label name", labelName,
+		"requested key", matchersKey,
+		"found", found,
+		"time elapsed", time.Since(t0),
+		"returned bytes", len(data),
+		"user_id", userID,
+	)
+
+	return data, found
+}
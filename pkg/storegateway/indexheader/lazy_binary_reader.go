@@ -217,14 +217,16 @@ func (r *LazyBinaryReader) LabelNames() ([]string, error) {
 	return reader.LabelNames()
 }
 
-// EagerLoad attempts to eagerly load this index header.
-func (r *LazyBinaryReader) EagerLoad() {
+// EagerLoad attempts to eagerly load this index header. A failure is logged and returned,
+// but the reader remains usable and will try to load the index-header again upon next usage.
+func (r *LazyBinaryReader) EagerLoad() error {
 	_, wg, err := r.getOrLoadReader()
 	if err != nil {
 		level.Warn(r.logger).Log("msg", "eager loading of lazy loaded index-header failed; skipping", "err", err)
-		return
+		return err
 	}
 	wg.Done()
+	return nil
 }
 
 // getOrLoadReader ensures the underlying binary index-header reader has been successfully loaded.
@@ -223,9 +223,8 @@ func TestNewLazyBinaryReader_EagerLoadLazyLoadedIndexHeaders(t *testing.T) {
 	tmpDir, bkt, blockID := initBucketAndBlocksForTest(t)
 
 	testLazyBinaryReader(t, bkt, tmpDir, blockID, func(t *testing.T, r *LazyBinaryReader, err error) {
-		r.EagerLoad()
-
 		require.NoError(t, err)
+		require.NoError(t, r.EagerLoad())
 		require.NotNil(t, r.reader, "t.reader must already eagerly loaded")
 		t.Cleanup(func() {
 			require.NoError(t, r.Close())
@@ -16,11 +16,14 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
 	"github.com/grafana/dskit/gate"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/thanos-io/objstore"
+	"go.uber.org/atomic"
 
 	"github.com/grafana/mimir/pkg/util/atomicfs"
 )
@@ -31,6 +34,10 @@ const lazyLoadedHeadersListFileName = "lazy-loaded.json"
 type ReaderPoolMetrics struct {
 	lazyReader   *LazyBinaryReaderMetrics
 	streamReader *StreamBinaryReaderMetrics
+
+	eagerLoadCount       prometheus.Counter
+	eagerLoadFailedCount prometheus.Counter
+	eagerLoadDuration    prometheus.Histogram
 }
 
 // NewReaderPoolMetrics makes new ReaderPoolMetrics.
@@ -38,6 +45,19 @@ func NewReaderPoolMetrics(reg prometheus.Registerer) *ReaderPoolMetrics {
 	return &ReaderPoolMetrics{
 		lazyReader:   NewLazyBinaryReaderMetrics(reg),
 		streamReader: NewStreamBinaryReaderMetrics(reg),
+		eagerLoadCount: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "indexheader_eager_load_total",
+			Help: "Total number of index-header eager load operations performed at startup.",
+		}),
+		eagerLoadFailedCount: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "indexheader_eager_load_failed_total",
+			Help: "Total number of failed index-header eager load operations performed at startup.",
+		}),
+		eagerLoadDuration: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
+			Name:    "indexheader_eager_load_duration_seconds",
+			Help:    "Time it took to eagerly load all index-headers of a tenant loaded before the previous shutdown.",
+			Buckets: []float64{0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600, 1200},
+		}),
 	}
 }
 
@@ -48,6 +68,7 @@ func NewReaderPoolMetrics(reg prometheus.Registerer) *ReaderPoolMetrics {
 type ReaderPool struct {
 	lazyReaderEnabled        bool
 	lazyReaderIdleTimeout    time.Duration
+	eagerLoadingEnabled      bool
 	sparsePersistenceEnabled bool
 	logger                   log.Logger
 	metrics                  *ReaderPoolMetrics
@@ -62,6 +83,10 @@ type ReaderPool struct {
 	lazyReadersMx           sync.Mutex
 	lazyReaders             map[*LazyBinaryReader]struct{}
 	preShutdownLoadedBlocks *lazyLoadedHeadersSnapshot
+
+	// Lazy readers created during the initial sync which were loaded before the previous
+	// shutdown, and are waiting to be loaded by EagerLoadPreShutdownLoadedReaders().
+	eagerLoadReaders map[*LazyBinaryReader]struct{}
 }
 
 // LazyLoadedHeadersSnapshotConfig stores information needed to track lazy loaded index headers.
@@ -102,17 +127,19 @@ func NewReaderPool(logger log.Logger, indexHeaderConfig Config, lazyLoadingGate
 
 	p := newReaderPool(logger, indexHeaderConfig, lazyLoadingGate, metrics, snapshot)
 
-	// Start a goroutine to close idle readers (only if required).
-	if p.lazyReaderEnabled && p.lazyReaderIdleTimeout > 0 {
-		checkFreq := p.lazyReaderIdleTimeout / 10
-
+	// Start a goroutine to close idle readers and persist the loaded ones (only if required).
+	if p.isTrackingLazyReaders() {
 		go func() {
-			tickerIdleReader := time.NewTicker(checkFreq)
-			defer tickerIdleReader.Stop()
+			var idleReaderC, lazyLoadC <-chan time.Time
 
-			var lazyLoadC <-chan time.Time
+			if p.lazyReaderIdleTimeout > 0 {
+				tickerIdleReader := time.NewTicker(p.lazyReaderIdleTimeout / 10)
+				defer tickerIdleReader.Stop()
+
+				idleReaderC = tickerIdleReader.C
+			}
 
-			if indexHeaderConfig.EagerLoadingStartupEnabled {
+			if p.eagerLoadingEnabled {
 				tickerLazyLoadPersist := time.NewTicker(time.Minute)
 				defer tickerLazyLoadPersist.Stop()
 
@@ -123,7 +150,7 @@ func NewReaderPool(logger log.Logger, indexHeaderConfig Config, lazyLoadingGate
 				select {
 				case <-p.close:
 					return
-				case <-tickerIdleReader.C:
+				case <-idleReaderC:
 					p.closeIdleReaders()
 				case <-lazyLoadC:
 					snapshot := lazyLoadedHeadersSnapshot{
@@ -176,8 +203,10 @@ func newReaderPool(logger log.Logger, indexHeaderConfig Config, lazyLoadingGate
 		metrics:                  metrics,
 		lazyReaderEnabled:        indexHeaderConfig.LazyLoadingEnabled,
 		lazyReaderIdleTimeout:    indexHeaderConfig.LazyLoadingIdleTimeout,
+		eagerLoadingEnabled:      indexHeaderConfig.LazyLoadingEnabled && indexHeaderConfig.EagerLoadingStartupEnabled,
 		sparsePersistenceEnabled: indexHeaderConfig.SparsePersistenceEnabled,
 		lazyReaders:              make(map[*LazyBinaryReader]struct{}),
+		eagerLoadReaders:         make(map[*LazyBinaryReader]struct{}),
 		close:                    make(chan struct{}),
 		preShutdownLoadedBlocks:  lazyLoadedHeadersSnapshot,
 		lazyLoadingGate:          lazyLoadingGate,
@@ -215,12 +244,12 @@ func (p *ReaderPool) NewBinaryReader(ctx context.Context, logger log.Logger, bkt
 			return nil, lazyErr
 		}
 
-		// we only try to eager load only during initialSync
-		if initialSync && p.preShutdownLoadedBlocks != nil {
-			// we only eager load if we have preShutdownLoadedBlocks for the given block id
-			if p.preShutdownLoadedBlocks.IndexHeaderLastUsedTime[id] > 0 {
-				lazyBinaryReader.EagerLoad()
-			}
+		// We only eager load during the initial sync, and only blocks which were loaded before the previous shutdown.
+		// The actual loading is deferred to EagerLoadPreShutdownLoadedReaders(), so that it can run concurrently.
+		if initialSync && p.preShutdownLoadedBlocks != nil && p.preShutdownLoadedBlocks.IndexHeaderLastUsedTime[id] > 0 {
+			p.lazyReadersMx.Lock()
+			p.eagerLoadReaders[lazyBinaryReader] = struct{}{}
+			p.lazyReadersMx.Unlock()
 		}
 		reader, err = lazyBinaryReader, lazyErr
 	} else {
@@ -232,7 +261,7 @@ func (p *ReaderPool) NewBinaryReader(ctx context.Context, logger log.Logger, bkt
 	}
 
 	// Keep track of lazy readers only if required.
-	if p.lazyReaderEnabled && p.lazyReaderIdleTimeout > 0 {
+	if p.isTrackingLazyReaders() {
 		p.lazyReadersMx.Lock()
 		p.lazyReaders[reader.(*LazyBinaryReader)] = struct{}{}
 		p.lazyReadersMx.Unlock()
@@ -241,6 +270,42 @@ func (p *ReaderPool) NewBinaryReader(ctx context.Context, logger log.Logger, bkt
 	return reader, err
 }
 
+// EagerLoadPreShutdownLoadedReaders loads the index-headers of the readers created during the initial
+// sync whose blocks were loaded before the previous shutdown, running up to loadConcurrency loads at once.
+// The number of concurrent loads across all pools is further limited by the lazy loading gate.
+// Failures are logged and tracked in metrics, but don't fail the whole operation: an index-header which
+// can't be loaded eagerly will be lazy loaded upon the first query.
+func (p *ReaderPool) EagerLoadPreShutdownLoadedReaders(ctx context.Context, loadConcurrency int) {
+	p.lazyReadersMx.Lock()
+	readers := make([]*LazyBinaryReader, 0, len(p.eagerLoadReaders))
+	for r := range p.eagerLoadReaders {
+		readers = append(readers, r)
+	}
+	p.eagerLoadReaders = make(map[*LazyBinaryReader]struct{})
+	p.lazyReadersMx.Unlock()
+
+	if len(readers) == 0 {
+		return
+	}
+
+	start := time.Now()
+	level.Info(p.logger).Log("msg", "eagerly loading index-headers loaded before the previous shutdown", "count", len(readers))
+
+	failed := atomic.NewInt64(0)
+	_ = concurrency.ForEachJob(ctx, len(readers), loadConcurrency, func(_ context.Context, idx int) error {
+		p.metrics.eagerLoadCount.Inc()
+		if err := readers[idx].EagerLoad(); err != nil {
+			p.metrics.eagerLoadFailedCount.Inc()
+			failed.Inc()
+		}
+		return nil
+	})
+
+	elapsed := time.Since(start)
+	p.metrics.eagerLoadDuration.Observe(elapsed.Seconds())
+	level.Info(p.logger).Log("msg", "eagerly loaded index-headers loaded before the previous shutdown", "count", len(readers), "failed", failed.Load(), "elapsed", elapsed)
+}
+
 // Close the pool and stop checking for idle readers. No reader tracked by this pool
 // will be closed. It's the caller responsibility to close readers.
 func (p *ReaderPool) Close() {
@@ -271,6 +336,12 @@ func (p *ReaderPool) getIdleReadersSince(ts int64) []*LazyBinaryReader {
 	return idle
 }
 
+// isTrackingLazyReaders returns whether the pool needs to keep track of the lazy readers, either
+// to close them once idle or to persist the list of loaded ones for eager loading at startup.
+func (p *ReaderPool) isTrackingLazyReaders() bool {
+	return p.lazyReaderEnabled && (p.lazyReaderIdleTimeout > 0 || p.eagerLoadingEnabled)
+}
+
 func (p *ReaderPool) isTracking(r *LazyBinaryReader) bool {
 	p.lazyReadersMx.Lock()
 	defer p.lazyReadersMx.Unlock()
@@ -287,6 +358,7 @@ func (p *ReaderPool) onLazyReaderClosed(r *LazyBinaryReader) {
 	// but because the consumer closed it. By contract, a reader closed by the consumer can't
 	// be used anymore, so we can automatically remove it from the pool.
 	delete(p.lazyReaders, r)
+	delete(p.eagerLoadReaders, r)
 }
 
 // LoadedBlocks returns a new map of lazy-loaded block IDs and the last time they were used in milliseconds.
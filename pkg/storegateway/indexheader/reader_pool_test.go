@@ -34,6 +34,7 @@ func TestReaderPool_NewBinaryReader(t *testing.T) {
 		initialSync                                 bool
 		createLazyLoadedHeadersSnapshotFn           func(blockId ulid.ULID) lazyLoadedHeadersSnapshot
 		expectedLoadCountMetricBeforeLabelNamesCall int
+		expectedEagerLoadCountMetric                int
 		expectedLoadCountMetricAfterLabelNamesCall  int
 	}{
 		"lazy reader is disabled": {
@@ -56,6 +57,7 @@ func TestReaderPool_NewBinaryReader(t *testing.T) {
 			eagerLoadReaderEnabled: true,
 			initialSync:            true,
 			expectedLoadCountMetricBeforeLabelNamesCall: 1, // the index header will be eagerly loaded before the operation
+			expectedEagerLoadCountMetric:                1,
 			expectedLoadCountMetricAfterLabelNamesCall:  1,
 			createLazyLoadedHeadersSnapshotFn: func(blockId ulid.ULID) lazyLoadedHeadersSnapshot {
 				return lazyLoadedHeadersSnapshot{
@@ -135,6 +137,12 @@ func TestReaderPool_NewBinaryReader(t *testing.T) {
 			require.NoError(t, err)
 			defer func() { require.NoError(t, r.Close()) }()
 
+			// Creating the reader never loads the index-header, even if it should be eagerly loaded.
+			require.Equal(t, float64(0), promtestutil.ToFloat64(metrics.lazyReader.loadCount))
+
+			pool.EagerLoadPreShutdownLoadedReaders(ctx, 1)
+			require.Equal(t, float64(testData.expectedEagerLoadCountMetric), promtestutil.ToFloat64(metrics.eagerLoadCount))
+			require.Equal(t, float64(0), promtestutil.ToFloat64(metrics.eagerLoadFailedCount))
 			require.Equal(t, float64(testData.expectedLoadCountMetricBeforeLabelNamesCall), promtestutil.ToFloat64(metrics.lazyReader.loadCount))
 
 			// Ensure it can read data.
@@ -277,6 +285,58 @@ func TestReaderPool_PersistLazyLoadedBlock(t *testing.T) {
 	require.JSONEq(t, `{"index_header_last_used_time":{},"user_id":"anonymous"}`, string(persistedData), "index_header_last_used_time should be cleared")
 }
 
+func TestReaderPool_EagerLoadPreShutdownLoadedReaders(t *testing.T) {
+	ctx, tmpDir, bkt, blockID, metrics := prepareReaderPool(t)
+
+	otherBlockID, err := block.CreateBlock(ctx, tmpDir, []labels.Labels{
+		labels.FromStrings("b", "1"),
+		labels.FromStrings("b", "2"),
+		labels.FromStrings("b", "3"),
+	}, 100, 0, 1000, labels.FromStrings("ext1", "1"))
+	require.NoError(t, err)
+	require.NoError(t, block.Upload(ctx, log.NewNopLogger(), bkt, filepath.Join(tmpDir, otherBlockID.String()), nil))
+
+	snapshot := &lazyLoadedHeadersSnapshot{
+		IndexHeaderLastUsedTime: map[ulid.ULID]int64{
+			blockID:      time.Now().UnixMilli(),
+			otherBlockID: time.Now().UnixMilli(),
+		},
+		UserID: "anonymous",
+	}
+
+	// Idle timeout is disabled, but readers must be tracked anyway to persist the loaded ones.
+	cfg := Config{
+		LazyLoadingEnabled:         true,
+		LazyLoadingIdleTimeout:     0,
+		EagerLoadingStartupEnabled: true,
+	}
+	pool := newReaderPool(log.NewNopLogger(), cfg, gate.NewNoop(), metrics, snapshot)
+	defer pool.Close()
+
+	r, err := pool.NewBinaryReader(ctx, log.NewNopLogger(), bkt, tmpDir, blockID, 3, cfg, true)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, r.Close()) }()
+
+	// The reader for the other block is closed before eager loading runs, so it must not be loaded.
+	otherReader, err := pool.NewBinaryReader(ctx, log.NewNopLogger(), bkt, tmpDir, otherBlockID, 3, cfg, true)
+	require.NoError(t, err)
+	require.NoError(t, otherReader.Close())
+
+	pool.EagerLoadPreShutdownLoadedReaders(ctx, 2)
+	require.Equal(t, float64(1), promtestutil.ToFloat64(metrics.eagerLoadCount))
+	require.Equal(t, float64(0), promtestutil.ToFloat64(metrics.eagerLoadFailedCount))
+	require.Equal(t, float64(1), promtestutil.ToFloat64(metrics.lazyReader.loadCount))
+
+	require.True(t, pool.isTracking(r.(*LazyBinaryReader)))
+	loadedBlocks := pool.LoadedBlocks()
+	require.Len(t, loadedBlocks, 1)
+	require.Contains(t, loadedBlocks, blockID)
+
+	// Running it again is a no-op.
+	pool.EagerLoadPreShutdownLoadedReaders(ctx, 2)
+	require.Equal(t, float64(1), promtestutil.ToFloat64(metrics.eagerLoadCount))
+}
+
 func prepareReaderPool(t *testing.T) (context.Context, string, *filesystem.Bucket, ulid.ULID, *ReaderPoolMetrics) {
 	ctx := context.Background()
 
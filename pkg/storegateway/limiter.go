@@ -28,6 +28,13 @@ type SeriesLimiter interface {
 	Reserve(num uint64) error
 }
 
+type ChunkBytesLimiter interface {
+	// Reserve num bytes out of the total size of chunks enforced by the limiter.
+	// Returns an error if the limit has been exceeded. This function must be
+	// goroutine safe.
+	Reserve(num uint64) error
+}
+
 // ChunksLimiterFactory is used to create a new ChunksLimiter. The factory is useful for
 // projects depending on Thanos which have dynamic limits.
 type ChunksLimiterFactory func(failedCounter prometheus.Counter) ChunksLimiter
@@ -35,6 +42,9 @@ type ChunksLimiterFactory func(failedCounter prometheus.Counter) ChunksLimiter
 // SeriesLimiterFactory is used to create a new SeriesLimiter.
 type SeriesLimiterFactory func(failedCounter prometheus.Counter) SeriesLimiter
 
+// ChunkBytesLimiterFactory is used to create a new ChunkBytesLimiter.
+type ChunkBytesLimiterFactory func(failedCounter prometheus.Counter) ChunkBytesLimiter
+
 // Limiter is a simple mechanism for checking if something has passed a certain threshold.
 type Limiter struct {
 	limit    uint64
@@ -77,3 +87,10 @@ func NewSeriesLimiterFactory(limitsExtractor func() uint64) SeriesLimiterFactory
 		return NewLimiter(limitsExtractor(), failedCounter)
 	}
 }
+
+// NewChunkBytesLimiterFactory makes a new ChunkBytesLimiterFactory with a dynamic limit.
+func NewChunkBytesLimiterFactory(limitsExtractor func() uint64) ChunkBytesLimiterFactory {
+	return func(failedCounter prometheus.Counter) ChunkBytesLimiter {
+		return NewLimiter(limitsExtractor(), failedCounter)
+	}
+}
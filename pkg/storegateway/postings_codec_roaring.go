@@ -0,0 +1,544 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+
+	"github.com/dennwc/varint"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/index"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storegateway/indexcache"
+)
+
+// This file implements encoding and decoding of postings as a roaring bitmap.
+//
+// Postings are split in containers by their high bits (the container key), and the low 16 bits
+// of each posting are stored in the container. A container is stored as a sorted array of uint16 if it
+// holds up to roaringArrayMaxCardinality postings, otherwise as a bitmap of 2^16 bits. Compared to
+// diff+varint+snappy, the encoded postings are larger for sparse lists, but don't need to be
+// decompressed: they can be iterated, seeked and intersected directly on the encoded bytes,
+// which is significantly cheaper for very large postings lists.
+//
+// The encoded postings are laid out as follows:
+//
+//	header | request matchers key | pending matchers | uvarint number of containers | containers table | containers data
+//
+// Each entry of the containers table is roaringContainerEntrySize bytes long:
+//
+//	key (uint32) | type (uint8) | cardinality - 1 (uint16) | offset of the container in the containers data (uint32)
+//
+// All numbers in the containers table and data are little endian.
+
+const (
+	codecHeaderRoaringWithMatchers codec = "rbm" // As in "roaring bitmap+matchers".
+
+	roaringContainerArray  = byte(0)
+	roaringContainerBitmap = byte(1)
+
+	roaringContainerEntrySize  = 4 + 1 + 2 + 4
+	roaringArrayMaxCardinality = 4096
+	roaringBitmapWords         = (1 << 16) / 64
+	roaringBitmapSize          = roaringBitmapWords * 8
+
+	// roaringMaxSeriesRef is the highest series reference which can be encoded, given
+	// containers keys are 32 bits and containers hold the low 16 bits of each posting.
+	roaringMaxSeriesRef = storage.SeriesRef(1<<48 - 1)
+)
+
+// postingsCacheCodec encodes postings before storing them into the index cache.
+type postingsCacheCodec struct {
+	codec string
+
+	// roaringMinPostings is the minimum number of postings for a list to be encoded with
+	// the roaring bitmap codec. Shorter lists are encoded with diff+varint+snappy.
+	roaringMinPostings int
+}
+
+// postingsCacheCodecs holds the codecs used for each type of postings stored in the index cache.
+type postingsCacheCodecs struct {
+	postings, expandedPostings postingsCacheCodec
+}
+
+func newPostingsCacheCodecs(cfg tsdb.IndexCacheConfig) postingsCacheCodecs {
+	return postingsCacheCodecs{
+		postings:         postingsCacheCodec{codec: cfg.PostingsCodec, roaringMinPostings: cfg.RoaringCodecMinPostings},
+		expandedPostings: postingsCacheCodec{codec: cfg.ExpandedPostingsCodec, roaringMinPostings: cfg.RoaringCodecMinPostings},
+	}
+}
+
+// encode encodes the postings with the configured codec. Length argument is expected number of postings.
+func (c postingsCacheCodec) encode(p index.Postings, length int, requestMatchers indexcache.LabelMatchersKey, pendingMatchers []*labels.Matcher) ([]byte, error) {
+	if c.codec == tsdb.PostingsCodecRoaring && length >= c.roaringMinPostings {
+		return roaringWithMatchersEncode(p, requestMatchers, pendingMatchers)
+	}
+	return diffVarintSnappyWithMatchersEncode(p, length, requestMatchers, pendingMatchers)
+}
+
+// isRoaringWithMatchersEncodedPostings returns true, if input looks like it has been encoded by roaring bitmap+matchers codec.
+func isRoaringWithMatchersEncodedPostings(input []byte) bool {
+	return bytes.HasPrefix(input, []byte(codecHeaderRoaringWithMatchers))
+}
+
+// roaringWithMatchersEncode encodes postings into a roaring bitmap, prepended with the request matchers key
+// and any pending matchers. Returned byte slice starts with codecHeaderRoaringWithMatchers header.
+func roaringWithMatchersEncode(p index.Postings, requestMatchers indexcache.LabelMatchersKey, pendingMatchers []*labels.Matcher) ([]byte, error) {
+	containers, err := buildRoaringContainers(p)
+	if err != nil {
+		return nil, err
+	}
+
+	// Compute the size of the encoded containers.
+	dataLen := 0
+	for _, c := range containers {
+		if len(c.values) > roaringArrayMaxCardinality {
+			dataLen += roaringBitmapSize
+		} else {
+			dataLen += 2 * len(c.values)
+		}
+	}
+
+	reqMatchersLen := varint.UvarintSize(uint64(len(requestMatchers))) + len(requestMatchers)
+	estPendingMatchersLen := encodedMatchersLen(pendingMatchers)
+	codecLen := len(codecHeaderRoaringWithMatchers)
+	tableLen := varint.UvarintSize(uint64(len(containers))) + len(containers)*roaringContainerEntrySize
+
+	result := make([]byte, codecLen+reqMatchersLen+estPendingMatchersLen+tableLen+dataLen)
+
+	// Codec
+	copy(result, codecHeaderRoaringWithMatchers)
+	offset := codecLen
+
+	// Request matchers
+	offset += binary.PutUvarint(result[offset:], uint64(len(requestMatchers)))
+	offset += copy(result[offset:], requestMatchers)
+
+	// Pending matchers size + matchers
+	actualMatchersLen, err := encodeMatchers(estPendingMatchersLen, pendingMatchers, result[offset:])
+	if err != nil {
+		return nil, err
+	}
+	if actualMatchersLen != estPendingMatchersLen {
+		return nil, fmt.Errorf("encoding matchers wrote unexpected number of bytes: wrote %d, expected %d", actualMatchersLen, estPendingMatchersLen)
+	}
+	offset += actualMatchersLen
+
+	// Containers table and data
+	offset += binary.PutUvarint(result[offset:], uint64(len(containers)))
+	table := result[offset : offset+len(containers)*roaringContainerEntrySize]
+	data := result[offset+len(table):]
+
+	dataOffset := 0
+	for i, c := range containers {
+		entry := table[i*roaringContainerEntrySize:]
+		binary.LittleEndian.PutUint32(entry, c.key)
+		binary.LittleEndian.PutUint16(entry[5:], uint16(len(c.values)-1))
+		binary.LittleEndian.PutUint32(entry[7:], uint32(dataOffset))
+
+		if len(c.values) > roaringArrayMaxCardinality {
+			entry[4] = roaringContainerBitmap
+			bitmap := data[dataOffset : dataOffset+roaringBitmapSize]
+			for _, v := range c.values {
+				word := bitmap[(v/64)*8:]
+				binary.LittleEndian.PutUint64(word, binary.LittleEndian.Uint64(word)|1<<(v%64))
+			}
+			dataOffset += roaringBitmapSize
+		} else {
+			entry[4] = roaringContainerArray
+			for _, v := range c.values {
+				binary.LittleEndian.PutUint16(data[dataOffset:], v)
+				dataOffset += 2
+			}
+		}
+	}
+
+	return result, nil
+}
+
+type roaringContainerValues struct {
+	key    uint32
+	values []uint16
+}
+
+func buildRoaringContainers(p index.Postings) ([]roaringContainerValues, error) {
+	var (
+		containers []roaringContainerValues
+		prev       storage.SeriesRef
+		first      = true
+	)
+
+	for p.Next() {
+		v := p.At()
+		if !first && v <= prev {
+			return nil, errors.Errorf("postings entries must be in strictly increasing order, current: %d, previous: %d", v, prev)
+		}
+		if v > roaringMaxSeriesRef {
+			return nil, errors.Errorf("postings entry %d is too large to be encoded as roaring bitmap", v)
+		}
+		first = false
+		prev = v
+
+		key := uint32(v >> 16)
+		if len(containers) == 0 || containers[len(containers)-1].key != key {
+			containers = append(containers, roaringContainerValues{key: key})
+		}
+		last := &containers[len(containers)-1]
+		last.values = append(last.values, uint16(v))
+	}
+	if p.Err() != nil {
+		return nil, p.Err()
+	}
+
+	return containers, nil
+}
+
+func roaringMatchersDecode(input []byte) (*roaringPostings, indexcache.LabelMatchersKey, []*labels.Matcher, error) {
+	if !isRoaringWithMatchersEncodedPostings(input) {
+		return nil, "", nil, errors.New(string(codecHeaderRoaringWithMatchers) + " header not found")
+	}
+
+	offset := len(codecHeaderRoaringWithMatchers)
+
+	requestMatchersKeyLen, requestMatchersKeyLenSize := varint.Uvarint(input[offset:])
+	if requestMatchersKeyLenSize <= 0 || uint64(len(input)) < uint64(offset+requestMatchersKeyLenSize)+requestMatchersKeyLen {
+		return nil, "", nil, errors.New("invalid request matchers key")
+	}
+	offset += requestMatchersKeyLenSize
+	requestMatchersKey := indexcache.LabelMatchersKey(input[offset : uint64(offset)+requestMatchersKeyLen])
+	offset += int(requestMatchersKeyLen)
+
+	pendingMatchers, pendingMatchersLen, err := decodeMatchers(input[offset:])
+	if err != nil {
+		return nil, "", nil, errors.Wrap(err, "decoding request matchers")
+	}
+	offset += pendingMatchersLen
+
+	bm, err := newRoaringBitmap(input[offset:])
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	return newRoaringPostings(bm), requestMatchersKey, pendingMatchers, nil
+}
+
+// roaringBitmap is a read-only view over the containers of an encoded roaring bitmap.
+type roaringBitmap struct {
+	numContainers int
+	table         []byte
+	data          []byte
+}
+
+// newRoaringBitmap validates the encoded containers and returns a view over them. The input isn't copied.
+func newRoaringBitmap(input []byte) (roaringBitmap, error) {
+	numContainers, n := varint.Uvarint(input)
+	if n <= 0 {
+		return roaringBitmap{}, errors.New("invalid number of roaring bitmap containers")
+	}
+	input = input[n:]
+	if numContainers > uint64(len(input)/roaringContainerEntrySize) {
+		return roaringBitmap{}, errors.Errorf("roaring bitmap containers table is truncated: expected %d containers", numContainers)
+	}
+
+	bm := roaringBitmap{
+		numContainers: int(numContainers),
+		table:         input[:int(numContainers)*roaringContainerEntrySize],
+		data:          input[int(numContainers)*roaringContainerEntrySize:],
+	}
+
+	for i := 0; i < bm.numContainers; i++ {
+		entry := bm.table[i*roaringContainerEntrySize:]
+		if i > 0 && bm.key(i) <= bm.key(i-1) {
+			return roaringBitmap{}, errors.New("roaring bitmap containers are not sorted")
+		}
+
+		start := uint64(binary.LittleEndian.Uint32(entry[7:]))
+		card := uint64(binary.LittleEndian.Uint16(entry[5:])) + 1
+		var size uint64
+		switch entry[4] {
+		case roaringContainerArray:
+			size = 2 * card
+		case roaringContainerBitmap:
+			size = roaringBitmapSize
+		default:
+			return roaringBitmap{}, errors.Errorf("unknown roaring bitmap container type %d", entry[4])
+		}
+		if start+size > uint64(len(bm.data)) {
+			return roaringBitmap{}, errors.New("roaring bitmap container data is truncated")
+		}
+	}
+
+	return bm, nil
+}
+
+func (b roaringBitmap) key(i int) uint32 {
+	return binary.LittleEndian.Uint32(b.table[i*roaringContainerEntrySize:])
+}
+
+func (b roaringBitmap) container(i int) roaringContainer {
+	entry := b.table[i*roaringContainerEntrySize:]
+	c := roaringContainer{
+		bitmap: entry[4] == roaringContainerBitmap,
+		card:   int(binary.LittleEndian.Uint16(entry[5:])) + 1,
+	}
+	start := int(binary.LittleEndian.Uint32(entry[7:]))
+	if c.bitmap {
+		c.data = b.data[start : start+roaringBitmapSize]
+	} else {
+		c.data = b.data[start : start+2*c.card]
+	}
+	return c
+}
+
+// searchKey returns the index of the first container, starting from the i-th one, whose key is >= key.
+func (b roaringBitmap) searchKey(i int, key uint32) int {
+	return i + sort.Search(b.numContainers-i, func(j int) bool {
+		return b.key(i+j) >= key
+	})
+}
+
+// roaringContainer is a read-only view over a single container of an encoded roaring bitmap.
+type roaringContainer struct {
+	bitmap bool
+	card   int
+	data   []byte
+}
+
+func (c roaringContainer) arrayValue(i int) uint16 {
+	return binary.LittleEndian.Uint16(c.data[2*i:])
+}
+
+func (c roaringContainer) bitmapWord(i int) uint64 {
+	return binary.LittleEndian.Uint64(c.data[8*i:])
+}
+
+// contains returns whether the container holds v. For array containers, the search starts
+// from the from-th value, and the returned index is where the search can resume for values >= v.
+func (c roaringContainer) contains(v uint16, from int) (bool, int) {
+	if c.bitmap {
+		return c.bitmapWord(int(v/64))&(1<<(v%64)) != 0, from
+	}
+	i := from + sort.Search(c.card-from, func(j int) bool {
+		return c.arrayValue(from+j) >= v
+	})
+	return i < c.card && c.arrayValue(i) == v, i
+}
+
+// next returns the position of the first value >= v whose position is >= from, where positions
+// are indexes for array containers and the values themselves for bitmap containers.
+func (c roaringContainer) next(v uint16, from int) (pos int, value uint16, ok bool) {
+	if !c.bitmap {
+		i := from + sort.Search(c.card-from, func(j int) bool {
+			return c.arrayValue(from+j) >= v
+		})
+		if i >= c.card {
+			return 0, 0, false
+		}
+		return i, c.arrayValue(i), true
+	}
+
+	if int(v) > from {
+		from = int(v)
+	}
+	if from > math.MaxUint16 {
+		return 0, 0, false
+	}
+	w := from / 64
+	word := c.bitmapWord(w) & (math.MaxUint64 << (from % 64))
+	for {
+		if word != 0 {
+			pos = w*64 + bits.TrailingZeros64(word)
+			return pos, uint16(pos), true
+		}
+		w++
+		if w >= roaringBitmapWords {
+			return 0, 0, false
+		}
+		word = c.bitmapWord(w)
+	}
+}
+
+// roaringPostings is an implementation of index.Postings iterating over an encoded roaring bitmap.
+type roaringPostings struct {
+	bm roaringBitmap
+
+	containerIdx int
+	container    roaringContainer
+	pos          int // Position of the current value in the current container, see roaringContainer.next.
+	started      bool
+	cur          storage.SeriesRef
+}
+
+func newRoaringPostings(bm roaringBitmap) *roaringPostings {
+	return &roaringPostings{bm: bm, containerIdx: -1}
+}
+
+func (it *roaringPostings) At() storage.SeriesRef {
+	return it.cur
+}
+
+func (it *roaringPostings) Next() bool {
+	if !it.started {
+		it.started = true
+		return it.seekFrom(0, 0, 0)
+	}
+	if it.containerIdx >= it.bm.numContainers {
+		return false
+	}
+	return it.seekFrom(it.containerIdx, 0, it.pos+1)
+}
+
+func (it *roaringPostings) Seek(x storage.SeriesRef) bool {
+	if it.started && it.cur >= x {
+		return true
+	}
+	if it.started && it.containerIdx >= it.bm.numContainers {
+		return false
+	}
+	if x > roaringMaxSeriesRef {
+		it.started = true
+		it.containerIdx = it.bm.numContainers
+		return false
+	}
+
+	from := 0
+	if it.started {
+		from = it.containerIdx
+	}
+	it.started = true
+
+	key := uint32(x >> 16)
+	idx := it.bm.searchKey(from, key)
+	if idx < it.bm.numContainers && it.bm.key(idx) == key {
+		pos := 0
+		if idx == it.containerIdx {
+			pos = it.pos
+		}
+		return it.seekFrom(idx, uint16(x), pos)
+	}
+	return it.seekFrom(idx, 0, 0)
+}
+
+// seekFrom moves the iterator to the first value >= v whose position is >= pos in the idx-th container,
+// or to the first value of the following containers.
+func (it *roaringPostings) seekFrom(idx int, v uint16, pos int) bool {
+	for ; idx < it.bm.numContainers; idx, v, pos = idx+1, 0, 0 {
+		if idx != it.containerIdx {
+			it.containerIdx = idx
+			it.container = it.bm.container(idx)
+		}
+
+		p, value, ok := it.container.next(v, pos)
+		if !ok {
+			continue
+		}
+		it.pos = p
+		it.cur = storage.SeriesRef(it.bm.key(idx))<<16 | storage.SeriesRef(value)
+		return true
+	}
+
+	it.containerIdx = it.bm.numContainers
+	return false
+}
+
+func (it *roaringPostings) Err() error {
+	return nil
+}
+
+// intersectRoaringBitmaps returns the sorted series references present in all input bitmaps.
+// Containers whose key is not present in all bitmaps are skipped without being read, and
+// bitmap containers are intersected a word at a time.
+func intersectRoaringBitmaps(bms []roaringBitmap) []storage.SeriesRef {
+	if len(bms) == 0 {
+		return nil
+	}
+
+	// Drive the intersection from the bitmap with the fewest containers.
+	sort.Slice(bms, func(i, j int) bool {
+		return bms[i].numContainers < bms[j].numContainers
+	})
+
+	var (
+		result     []storage.SeriesRef
+		positions  = make([]int, len(bms))
+		containers = make([]roaringContainer, len(bms))
+	)
+
+outer:
+	for i := 0; i < bms[0].numContainers; i++ {
+		key := bms[0].key(i)
+		containers[0] = bms[0].container(i)
+
+		for j := 1; j < len(bms); j++ {
+			positions[j] = bms[j].searchKey(positions[j], key)
+			if positions[j] >= bms[j].numContainers {
+				break outer
+			}
+			if bms[j].key(positions[j]) != key {
+				continue outer
+			}
+			containers[j] = bms[j].container(positions[j])
+		}
+
+		result = intersectRoaringContainers(result, key, containers)
+	}
+
+	return result
+}
+
+// intersectRoaringContainers appends the values present in all containers to result.
+func intersectRoaringContainers(result []storage.SeriesRef, key uint32, containers []roaringContainer) []storage.SeriesRef {
+	high := storage.SeriesRef(key) << 16
+
+	// Look for the smallest array container, to drive the intersection from it.
+	driver := -1
+	for i, c := range containers {
+		if !c.bitmap && (driver < 0 || c.card < containers[driver].card) {
+			driver = i
+		}
+	}
+
+	if driver < 0 {
+		// All containers are bitmaps: intersect them a word at a time.
+		for w := 0; w < roaringBitmapWords; w++ {
+			word := containers[0].bitmapWord(w)
+			for _, c := range containers[1:] {
+				if word == 0 {
+					break
+				}
+				word &= c.bitmapWord(w)
+			}
+			for word != 0 {
+				result = append(result, high|storage.SeriesRef(w*64+bits.TrailingZeros64(word)))
+				word &= word - 1
+			}
+		}
+		return result
+	}
+
+	from := make([]int, len(containers))
+	d := containers[driver]
+values:
+	for i := 0; i < d.card; i++ {
+		v := d.arrayValue(i)
+		for j, c := range containers {
+			if j == driver {
+				continue
+			}
+			var ok bool
+			if ok, from[j] = c.contains(v, from[j]); !ok {
+				continue values
+			}
+		}
+		result = append(result, high|storage.SeriesRef(v))
+	}
+	return result
+}
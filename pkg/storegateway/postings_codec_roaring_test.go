@@ -0,0 +1,248 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storegateway
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storage/tsdb"
+	"github.com/grafana/mimir/pkg/storegateway/indexcache"
+)
+
+func TestRoaringMatchersCodec(t *testing.T) {
+	postingsMap := map[string][]storage.SeriesRef{
+		"empty":                     nil,
+		"single":                    {12345},
+		"sparse":                    randomSeriesRefs(rand.New(rand.NewSource(1)), 1000, 1<<32),
+		"dense":                     randomSeriesRefs(rand.New(rand.NewSource(2)), 50000, 1<<17),
+		"full container":            sequentialSeriesRefs(1<<16, 1<<16),
+		"array and bitmap":          append(sequentialSeriesRefs(0, 100), sequentialSeriesRefs(1<<16, 10000)...),
+		"large refs":                {1 << 40, 1<<40 + 1, 1<<47 + 3},
+		"containers boundaries":     {1<<16 - 1, 1 << 16, 1<<17 - 1, 1 << 17},
+		"max cardinality array":     sequentialSeriesRefs(0, roaringArrayMaxCardinality),
+		"min cardinality bitmap":    sequentialSeriesRefs(0, roaringArrayMaxCardinality+1),
+		"max series ref":            {roaringMaxSeriesRef},
+		"bitmap with last bit set":  append(sequentialSeriesRefs(0, roaringArrayMaxCardinality+1), 1<<16-1),
+		"bitmap with first bit set": append([]storage.SeriesRef{1 << 16}, sequentialSeriesRefs(1<<16+100, roaringArrayMaxCardinality+1)...),
+	}
+
+	matchers := []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "a", "b.*")}
+
+	for name, refs := range postingsMap {
+		t.Run(name, func(t *testing.T) {
+			data, err := roaringWithMatchersEncode(index.NewListPostings(refs), "key", matchers)
+			require.NoError(t, err)
+			require.True(t, isRoaringWithMatchersEncodedPostings(data))
+			require.False(t, isDiffVarintSnappyWithMatchersEncodedPostings(data))
+
+			p, key, pendingMatchers, err := roaringMatchersDecode(data)
+			require.NoError(t, err)
+			assert.Equal(t, indexcache.LabelMatchersKey("key"), key)
+			require.Len(t, pendingMatchers, len(matchers))
+			for i := range matchers {
+				assert.Equal(t, matchers[i].String(), pendingMatchers[i].String())
+			}
+
+			actual, err := index.ExpandPostings(p)
+			require.NoError(t, err)
+			if len(refs) == 0 {
+				assert.Empty(t, actual)
+			} else {
+				assert.Equal(t, refs, actual)
+			}
+		})
+	}
+}
+
+func TestRoaringMatchersCodec_ShouldRejectInvalidPostings(t *testing.T) {
+	_, err := roaringWithMatchersEncode(index.NewListPostings([]storage.SeriesRef{2, 1}), "key", nil)
+	assert.Error(t, err)
+
+	_, err = roaringWithMatchersEncode(index.NewListPostings([]storage.SeriesRef{1, 1}), "key", nil)
+	assert.Error(t, err)
+
+	_, err = roaringWithMatchersEncode(index.NewListPostings([]storage.SeriesRef{roaringMaxSeriesRef + 1}), "key", nil)
+	assert.Error(t, err)
+}
+
+func TestRoaringMatchersCodec_ShouldFailDecodingCorruptedData(t *testing.T) {
+	refs := append(sequentialSeriesRefs(0, 100), sequentialSeriesRefs(1<<16, 10000)...)
+	data, err := roaringWithMatchersEncode(index.NewListPostings(refs), "key", nil)
+	require.NoError(t, err)
+
+	// Truncating the data anywhere within the containers must be detected.
+	for _, l := range []int{len(data) - 1, len(data) - roaringBitmapSize, len(data) / 2} {
+		_, _, _, err := roaringMatchersDecode(data[:l])
+		assert.Error(t, err, "length %d", l)
+	}
+
+	_, _, _, err = roaringMatchersDecode([]byte(codecHeaderSnappyWithMatchers))
+	assert.Error(t, err)
+}
+
+func TestRoaringPostings_Seek(t *testing.T) {
+	rnd := rand.New(rand.NewSource(3))
+	refs := append(randomSeriesRefs(rnd, 2000, 1<<20), randomSeriesRefs(rnd, 20000, 1<<17)...)
+	refs = sortedUniqueSeriesRefs(refs)
+
+	data, err := roaringWithMatchersEncode(index.NewListPostings(refs), "key", nil)
+	require.NoError(t, err)
+
+	for i := 0; i < 1000; i++ {
+		expected := index.NewListPostings(refs)
+		actual, _, _, err := roaringMatchersDecode(data)
+		require.NoError(t, err)
+
+		// Interleave Next() and Seek() calls, comparing with the list postings.
+		for j := 0; j < 10; j++ {
+			var ok bool
+			if rnd.Intn(2) == 0 {
+				ok = expected.Next()
+				require.Equal(t, ok, actual.Next())
+			} else {
+				target := storage.SeriesRef(rnd.Int63n(1 << 20))
+				ok = expected.Seek(target)
+				require.Equal(t, ok, actual.Seek(target), "seek %d", target)
+			}
+			if !ok {
+				break
+			}
+			require.Equal(t, expected.At(), actual.At())
+		}
+	}
+}
+
+func TestIntersectRoaringBitmaps(t *testing.T) {
+	rnd := rand.New(rand.NewSource(4))
+
+	lists := map[string][]storage.SeriesRef{
+		"sparse":     randomSeriesRefs(rnd, 5000, 1<<20),
+		"dense":      randomSeriesRefs(rnd, 200000, 1<<20),
+		"very dense": randomSeriesRefs(rnd, 500000, 1<<20),
+		"few":        randomSeriesRefs(rnd, 100, 1<<20),
+		"high":       sequentialSeriesRefs(1<<19, 1<<19),
+		"empty":      nil,
+	}
+
+	for nameA, a := range lists {
+		for nameB, b := range lists {
+			for nameC, c := range lists {
+				t.Run(nameA+"/"+nameB+"/"+nameC, func(t *testing.T) {
+					var bitmaps []roaringBitmap
+					for _, refs := range [][]storage.SeriesRef{a, b, c} {
+						data, err := roaringWithMatchersEncode(index.NewListPostings(refs), "key", nil)
+						require.NoError(t, err)
+						p, _, _, err := roaringMatchersDecode(data)
+						require.NoError(t, err)
+						bitmaps = append(bitmaps, p.bm)
+					}
+
+					expected, err := index.ExpandPostings(index.Intersect(index.NewListPostings(a), index.NewListPostings(b), index.NewListPostings(c)))
+					require.NoError(t, err)
+
+					actual := intersectRoaringBitmaps(bitmaps)
+					if len(expected) == 0 {
+						assert.Empty(t, actual)
+					} else {
+						assert.Equal(t, expected, actual)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestPostingsCacheCodec_Encode(t *testing.T) {
+	refs := sequentialSeriesRefs(0, 100)
+
+	tests := map[string]struct {
+		codec         postingsCacheCodec
+		expectRoaring bool
+	}{
+		"default codec": {
+			codec: postingsCacheCodec{},
+		},
+		"diff+varint+snappy codec": {
+			codec: postingsCacheCodec{codec: tsdb.PostingsCodecDiffVarintSnappy},
+		},
+		"roaring codec with postings above the threshold": {
+			codec:         postingsCacheCodec{codec: tsdb.PostingsCodecRoaring, roaringMinPostings: 100},
+			expectRoaring: true,
+		},
+		"roaring codec with postings below the threshold": {
+			codec: postingsCacheCodec{codec: tsdb.PostingsCodecRoaring, roaringMinPostings: 101},
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := testData.codec.encode(index.NewListPostings(refs), len(refs), "key", nil)
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectRoaring, isRoaringWithMatchersEncodedPostings(data))
+			assert.Equal(t, !testData.expectRoaring, isDiffVarintSnappyWithMatchersEncodedPostings(data))
+		})
+	}
+}
+
+func BenchmarkIntersectPostings(b *testing.B) {
+	rnd := rand.New(rand.NewSource(5))
+	a := randomSeriesRefs(rnd, 1000000, 1<<22)
+	c := randomSeriesRefs(rnd, 500000, 1<<22)
+
+	for _, codec := range []string{tsdb.PostingsCodecDiffVarintSnappy, tsdb.PostingsCodecRoaring} {
+		encoder := postingsCacheCodec{codec: codec}
+		dataA, err := encoder.encode(index.NewListPostings(a), len(a), "a", nil)
+		require.NoError(b, err)
+		dataC, err := encoder.encode(index.NewListPostings(c), len(c), "c", nil)
+		require.NoError(b, err)
+
+		r := &bucketIndexReader{}
+		b.Run(codec, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				pA, _, _, err := r.decodePostings(dataA, newSafeQueryStats())
+				require.NoError(b, err)
+				pC, _, _, err := r.decodePostings(dataC, newSafeQueryStats())
+				require.NoError(b, err)
+
+				_, err = index.ExpandPostings(intersectPostings(pA, pC))
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+func sequentialSeriesRefs(from storage.SeriesRef, count int) []storage.SeriesRef {
+	refs := make([]storage.SeriesRef, count)
+	for i := range refs {
+		refs[i] = from + storage.SeriesRef(i)
+	}
+	return refs
+}
+
+func randomSeriesRefs(rnd *rand.Rand, count int, maxRef int64) []storage.SeriesRef {
+	refs := make([]storage.SeriesRef, count)
+	for i := range refs {
+		refs[i] = storage.SeriesRef(rnd.Int63n(maxRef))
+	}
+	return sortedUniqueSeriesRefs(refs)
+}
+
+func sortedUniqueSeriesRefs(refs []storage.SeriesRef) []storage.SeriesRef {
+	sort.Slice(refs, func(i, j int) bool { return refs[i] < refs[j] })
+
+	out := refs[:0]
+	for i, r := range refs {
+		if i == 0 || r != refs[i-1] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
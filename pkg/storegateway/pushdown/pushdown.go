@@ -0,0 +1,213 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package pushdown implements the logic shared by queriers and store-gateways to push down
+// the evaluation of simple aggregations over range functions to the store-gateways.
+//
+// A query can be pushed down if it's in the form <aggregation> [by|without (<grouping>)] (<function>(<selector>[<range>])).
+// The store-gateway evaluates the query on each block, only at the steps whose range function window is
+// fully contained in the block time range, and returns the partial aggregates. Since the window of those
+// steps doesn't span multiple blocks, the result of the range function for each series is the same as if
+// it was computed on the whole data, and the partial aggregates of non-overlapping blocks can be merged
+// by the querier.
+package pushdown
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
+	util_math "github.com/grafana/mimir/pkg/util/math"
+)
+
+// selectorName is the metric name of the placeholder selector in the query evaluated by the store-gateway.
+// The store-gateway evaluates the query on the series matching the series request, so the actual selector
+// doesn't matter.
+const selectorName = "__pushdown__"
+
+// supportedAggregations are the aggregations whose partial results can be merged.
+var supportedAggregations = map[string]parser.ItemType{
+	"sum":   parser.SUM,
+	"min":   parser.MIN,
+	"max":   parser.MAX,
+	"count": parser.COUNT,
+}
+
+// supportedFunctions are the range functions whose result only depends on the samples of a series within the window.
+var supportedFunctions = map[string]struct{}{
+	"rate":            {},
+	"irate":           {},
+	"increase":        {},
+	"delta":           {},
+	"idelta":          {},
+	"avg_over_time":   {},
+	"sum_over_time":   {},
+	"count_over_time": {},
+	"min_over_time":   {},
+	"max_over_time":   {},
+	"last_over_time":  {},
+}
+
+// FromExpr returns the AggregationPushdown of the input expression, and the label matchers of its selector,
+// if the expression can be pushed down. The returned AggregationPushdown has no time range set.
+func FromExpr(expr parser.Expr) (*hintspb.AggregationPushdown, []*labels.Matcher, bool) {
+	aggr, ok := unwrapParens(expr).(*parser.AggregateExpr)
+	if !ok || aggr.Param != nil {
+		return nil, nil, false
+	}
+	if _, ok := supportedAggregations[aggr.Op.String()]; !ok {
+		return nil, nil, false
+	}
+
+	call, ok := unwrapParens(aggr.Expr).(*parser.Call)
+	if !ok || len(call.Args) != 1 {
+		return nil, nil, false
+	}
+	if _, ok := supportedFunctions[call.Func.Name]; !ok {
+		return nil, nil, false
+	}
+
+	matrix, ok := call.Args[0].(*parser.MatrixSelector)
+	if !ok {
+		return nil, nil, false
+	}
+	selector, ok := matrix.VectorSelector.(*parser.VectorSelector)
+	if !ok || selector.OriginalOffset != 0 || selector.Timestamp != nil || selector.StartOrEnd != 0 {
+		return nil, nil, false
+	}
+
+	// Series with different metric names may end up having the same labels once the range function
+	// drops the metric name, which is an error in PromQL. Since the store-gateway evaluates each block
+	// separately, it wouldn't detect it if such series are in different blocks, so we only push down
+	// queries selecting a single metric name.
+	if !hasMetricNameEqualMatcher(selector.LabelMatchers) {
+		return nil, nil, false
+	}
+
+	return &hintspb.AggregationPushdown{
+		Aggregation: aggr.Op.String(),
+		Grouping:    aggr.Grouping,
+		Without:     aggr.Without,
+		Function:    call.Func.Name,
+		Range:       matrix.Range.Milliseconds(),
+	}, selector.LabelMatchers, true
+}
+
+// Validate returns an error if the AggregationPushdown is not supported.
+func Validate(p *hintspb.AggregationPushdown) error {
+	if _, ok := supportedAggregations[p.Aggregation]; !ok {
+		return fmt.Errorf("unsupported aggregation %q", p.Aggregation)
+	}
+	if _, ok := supportedFunctions[p.Function]; !ok {
+		return fmt.Errorf("unsupported function %q", p.Function)
+	}
+	if p.Range <= 0 {
+		return fmt.Errorf("invalid range %d", p.Range)
+	}
+	if p.Step <= 0 {
+		return fmt.Errorf("invalid step %d", p.Step)
+	}
+	if p.End < p.Start {
+		return fmt.Errorf("invalid time range %d-%d", p.Start, p.End)
+	}
+	return nil
+}
+
+// Query returns the PromQL query to evaluate on the series matching the series request.
+// The AggregationPushdown must be valid.
+func Query(p *hintspb.AggregationPushdown) string {
+	expr := &parser.AggregateExpr{
+		Op:       supportedAggregations[p.Aggregation],
+		Grouping: p.Grouping,
+		Without:  p.Without,
+		Expr: &parser.Call{
+			Func: parser.Functions[p.Function],
+			Args: parser.Expressions{&parser.MatrixSelector{
+				VectorSelector: &parser.VectorSelector{
+					Name:          selectorName,
+					LabelMatchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, model.MetricNameLabel, selectorName)},
+				},
+				Range: time.Duration(p.Range) * time.Millisecond,
+			}},
+		},
+	}
+	return expr.String()
+}
+
+// CoveredSteps returns the first and last step timestamps whose range function window is fully contained in
+// the block time range [minT, maxT). The returned bool is false if there's no such step.
+func CoveredSteps(p *hintspb.AggregationPushdown, minT, maxT int64) (first, last int64, ok bool) {
+	// The range function window of the step at time t is [t-range, t].
+	first = util_math.Max(p.Start, minT+p.Range)
+	last = util_math.Min(p.End, maxT-1)
+	if first > last {
+		return 0, 0, false
+	}
+
+	// Align to the steps.
+	if offset := (first - p.Start) % p.Step; offset != 0 {
+		first += p.Step - offset
+	}
+	last -= (last - p.Start) % p.Step
+
+	if first > last {
+		return 0, 0, false
+	}
+	return first, last, true
+}
+
+// IsStepCovered returns whether the range function window of the step at time t is fully contained in
+// the block time range [minT, maxT).
+func IsStepCovered(rangeMillis, t, minT, maxT int64) bool {
+	return t-rangeMillis >= minT && t < maxT
+}
+
+// MergeFunc returns the function to merge two partial aggregates of the aggregation.
+// The aggregation must be supported.
+func MergeFunc(aggregation string) func(a, b float64) float64 {
+	switch supportedAggregations[aggregation] {
+	case parser.MIN:
+		// Same as the PromQL engine, a NaN is replaced by any other value.
+		return func(a, b float64) float64 {
+			if a > b || math.IsNaN(a) {
+				return b
+			}
+			return a
+		}
+	case parser.MAX:
+		return func(a, b float64) float64 {
+			if a < b || math.IsNaN(a) {
+				return b
+			}
+			return a
+		}
+	default:
+		// The partial results of both sum and count are summed up.
+		return func(a, b float64) float64 {
+			return a + b
+		}
+	}
+}
+
+func unwrapParens(expr parser.Expr) parser.Expr {
+	for {
+		paren, ok := expr.(*parser.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = paren.Expr
+	}
+}
+
+func hasMetricNameEqualMatcher(matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if m.Name == model.MetricNameLabel && m.Type == labels.MatchEqual && m.Value != "" {
+			return true
+		}
+	}
+	return false
+}
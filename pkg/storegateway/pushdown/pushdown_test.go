@@ -0,0 +1,260 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package pushdown
+
+import (
+	"math"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/mimir/pkg/storegateway/hintspb"
+)
+
+func TestFromExpr(t *testing.T) {
+	tests := map[string]struct {
+		query            string
+		expectedOK       bool
+		expectedPushdown *hintspb.AggregationPushdown
+		expectedMatchers []*labels.Matcher
+	}{
+		"sum of rate": {
+			query:            `sum(rate(metric[5m]))`,
+			expectedOK:       true,
+			expectedPushdown: &hintspb.AggregationPushdown{Aggregation: "sum", Function: "rate", Range: 300000},
+			expectedMatchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "metric")},
+		},
+		"max by grouping of max_over_time with parens": {
+			query:            `(max by (pod) ((max_over_time(metric{job="a"}[1m]))))`,
+			expectedOK:       true,
+			expectedPushdown: &hintspb.AggregationPushdown{Aggregation: "max", Grouping: []string{"pod"}, Function: "max_over_time", Range: 60000},
+			expectedMatchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, "job", "a"),
+				labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "metric"),
+			},
+		},
+		"count without grouping of count_over_time": {
+			query:            `count without (pod) (count_over_time(metric[1m]))`,
+			expectedOK:       true,
+			expectedPushdown: &hintspb.AggregationPushdown{Aggregation: "count", Grouping: []string{"pod"}, Without: true, Function: "count_over_time", Range: 60000},
+			expectedMatchers: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "metric")},
+		},
+		"unsupported aggregation": {
+			query: `avg(rate(metric[5m]))`,
+		},
+		"aggregation with parameter": {
+			query: `topk(5, rate(metric[5m]))`,
+		},
+		"unsupported function": {
+			query: `sum(quantile_over_time(0.5, metric[5m]))`,
+		},
+		"no range function": {
+			query: `sum(metric)`,
+		},
+		"nested aggregation": {
+			query: `sum(sum(rate(metric[5m])))`,
+		},
+		"binary expression": {
+			query: `sum(rate(metric[5m]) * 2)`,
+		},
+		"subquery": {
+			query: `sum(rate(metric[5m:1m]))`,
+		},
+		"offset modifier": {
+			query: `sum(rate(metric[5m] offset 1h))`,
+		},
+		"@ modifier": {
+			query: `sum(rate(metric[5m] @ 100))`,
+		},
+		"no metric name": {
+			query: `sum(rate({job="a"}[5m]))`,
+		},
+		"metric name regexp": {
+			query: `sum(rate({__name__=~"metric.*"}[5m]))`,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			expr, err := parser.ParseExpr(testData.query)
+			require.NoError(t, err)
+
+			p, matchers, ok := FromExpr(expr)
+			require.Equal(t, testData.expectedOK, ok)
+			if !ok {
+				return
+			}
+
+			assert.Equal(t, testData.expectedPushdown, p)
+			assert.ElementsMatch(t, testData.expectedMatchers, matchers)
+			assert.NoError(t, Validate(&hintspb.AggregationPushdown{
+				Aggregation: p.Aggregation,
+				Grouping:    p.Grouping,
+				Without:     p.Without,
+				Function:    p.Function,
+				Range:       p.Range,
+				Step:        1,
+			}))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *hintspb.AggregationPushdown {
+		return &hintspb.AggregationPushdown{Aggregation: "sum", Function: "rate", Range: 60000, Start: 0, End: 60000, Step: 15000}
+	}
+
+	require.NoError(t, Validate(valid()))
+
+	for name, mutate := range map[string]func(p *hintspb.AggregationPushdown){
+		"unsupported aggregation": func(p *hintspb.AggregationPushdown) { p.Aggregation = "avg" },
+		"unsupported function":    func(p *hintspb.AggregationPushdown) { p.Function = "quantile_over_time" },
+		"invalid range":           func(p *hintspb.AggregationPushdown) { p.Range = 0 },
+		"invalid step":            func(p *hintspb.AggregationPushdown) { p.Step = 0 },
+		"invalid time range":      func(p *hintspb.AggregationPushdown) { p.End = p.Start - 1 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := valid()
+			mutate(p)
+			assert.Error(t, Validate(p))
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	for _, query := range []string{
+		`sum(rate(metric[5m]))`,
+		`min by (pod) (min_over_time(metric[1m]))`,
+		`count without (pod, job) (last_over_time(metric[30s]))`,
+	} {
+		t.Run(query, func(t *testing.T) {
+			expr, err := parser.ParseExpr(query)
+			require.NoError(t, err)
+
+			p, _, ok := FromExpr(expr)
+			require.True(t, ok)
+
+			// The query to evaluate on the store-gateway must be pushed down the same way, but selecting the placeholder.
+			pushedDownExpr, err := parser.ParseExpr(Query(p))
+			require.NoError(t, err)
+
+			pushedDown, matchers, ok := FromExpr(pushedDownExpr)
+			require.True(t, ok)
+			assert.Equal(t, p, pushedDown)
+			assert.Equal(t, []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, selectorName)}, matchers)
+		})
+	}
+}
+
+func TestCoveredSteps(t *testing.T) {
+	tests := map[string]struct {
+		start, end, step, rangeMillis int64
+		minT, maxT                    int64
+		expectedOK                    bool
+		expectedFirst, expectedLast   int64
+	}{
+		"block covering all the steps": {
+			start: 100, end: 200, step: 10, rangeMillis: 50,
+			minT: 0, maxT: 1000,
+			expectedOK: true, expectedFirst: 100, expectedLast: 200,
+		},
+		"first step window starting at the block min time": {
+			start: 100, end: 200, step: 10, rangeMillis: 50,
+			minT: 50, maxT: 1000,
+			expectedOK: true, expectedFirst: 100, expectedLast: 200,
+		},
+		"first step window starting before the block min time": {
+			start: 100, end: 200, step: 10, rangeMillis: 50,
+			minT: 51, maxT: 1000,
+			expectedOK: true, expectedFirst: 110, expectedLast: 200,
+		},
+		"last step at the block max time, which is exclusive": {
+			start: 100, end: 200, step: 10, rangeMillis: 50,
+			minT: 0, maxT: 200,
+			expectedOK: true, expectedFirst: 100, expectedLast: 190,
+		},
+		"steps aligned within the block": {
+			start: 100, end: 200, step: 10, rangeMillis: 50,
+			minT: 73, maxT: 168,
+			expectedOK: true, expectedFirst: 130, expectedLast: 160,
+		},
+		"single step": {
+			start: 100, end: 200, step: 10, rangeMillis: 50,
+			minT: 75, maxT: 131,
+			expectedOK: true, expectedFirst: 130, expectedLast: 130,
+		},
+		"block shorter than the range": {
+			start: 100, end: 200, step: 10, rangeMillis: 50,
+			minT: 120, maxT: 160,
+		},
+		"no step between the covered window": {
+			start: 100, end: 200, step: 10, rangeMillis: 50,
+			minT: 72, maxT: 129,
+		},
+		"block before the query": {
+			start: 100, end: 200, step: 10, rangeMillis: 50,
+			minT: 0, maxT: 100,
+		},
+		"block after the query": {
+			start: 100, end: 200, step: 10, rangeMillis: 50,
+			minT: 200, maxT: 1000,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := &hintspb.AggregationPushdown{Start: testData.start, End: testData.end, Step: testData.step, Range: testData.rangeMillis}
+
+			first, last, ok := CoveredSteps(p, testData.minT, testData.maxT)
+			require.Equal(t, testData.expectedOK, ok)
+
+			// Ensure the covered steps are consistent with IsStepCovered().
+			for ts := p.Start; ts <= p.End; ts += p.Step {
+				assert.Equal(t, ok && ts >= first && ts <= last, IsStepCovered(p.Range, ts, testData.minT, testData.maxT), "step: %d", ts)
+			}
+
+			if ok {
+				assert.Equal(t, testData.expectedFirst, first)
+				assert.Equal(t, testData.expectedLast, last)
+			}
+		})
+	}
+}
+
+func TestMergeFunc(t *testing.T) {
+	nan := math.NaN()
+
+	tests := map[string]struct {
+		a, b     float64
+		expected map[string]float64
+	}{
+		"two values": {
+			a: 1, b: 2,
+			expected: map[string]float64{"sum": 3, "count": 3, "min": 1, "max": 2},
+		},
+		"first value is NaN": {
+			a: nan, b: 2,
+			expected: map[string]float64{"sum": nan, "count": nan, "min": 2, "max": 2},
+		},
+		"second value is NaN": {
+			a: 1, b: nan,
+			expected: map[string]float64{"sum": nan, "count": nan, "min": 1, "max": 1},
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			for aggregation, expected := range testData.expected {
+				actual := MergeFunc(aggregation)(testData.a, testData.b)
+				if math.IsNaN(expected) {
+					assert.True(t, math.IsNaN(actual), aggregation)
+				} else {
+					assert.Equal(t, expected, actual, aggregation)
+				}
+			}
+		})
+	}
+}
@@ -187,10 +187,12 @@ func newChunksPreloadingIterator(
 	chunkReaders bucketChunkReaders,
 	refsIterator seriesChunkRefsSetIterator,
 	refsIteratorBatchSize int,
+	chunkBytesLimiter ChunkBytesLimiter,
 	stats *safeQueryStats,
 ) seriesChunksSetIterator {
 	var iterator seriesChunksSetIterator
 	iterator = newLoadingSeriesChunksSetIterator(ctx, logger, userID, chunkReaders, refsIterator, refsIteratorBatchSize, stats)
+	iterator = newLimitingSeriesChunksSetIterator(iterator, chunkBytesLimiter)
 	iterator = newPreloadingAndStatsTrackingSetIterator[seriesChunksSet](ctx, 1, iterator, stats)
 	return iterator
 }
@@ -431,6 +433,53 @@ func (c *loadingSeriesChunksSetIterator) Next() (retHasNext bool) {
 	return true
 }
 
+// limitingSeriesChunksSetIterator reserves the size of the loaded chunks of each set in the
+// ChunkBytesLimiter, and stops with an error once the limit has been exceeded.
+type limitingSeriesChunksSetIterator struct {
+	from              seriesChunksSetIterator
+	chunkBytesLimiter ChunkBytesLimiter
+
+	err     error
+	current seriesChunksSet
+}
+
+func newLimitingSeriesChunksSetIterator(from seriesChunksSetIterator, chunkBytesLimiter ChunkBytesLimiter) *limitingSeriesChunksSetIterator {
+	return &limitingSeriesChunksSetIterator{
+		from:              from,
+		chunkBytesLimiter: chunkBytesLimiter,
+	}
+}
+
+func (l *limitingSeriesChunksSetIterator) Next() bool {
+	if l.err != nil {
+		return false
+	}
+
+	if !l.from.Next() {
+		l.err = l.from.Err()
+		return false
+	}
+
+	next := l.from.At()
+	_, size := chunkStats(next.series)
+	if err := l.chunkBytesLimiter.Reserve(uint64(size)); err != nil {
+		next.release()
+		l.err = errors.Wrap(err, ErrChunkBytesLimitMessage)
+		return false
+	}
+
+	l.current = next
+	return true
+}
+
+func (l *limitingSeriesChunksSetIterator) At() seriesChunksSet {
+	return l.current
+}
+
+func (l *limitingSeriesChunksSetIterator) Err() error {
+	return l.err
+}
+
 func initializeChunks(refs []seriesChunkRef, chunks []storepb.AggrChunk) {
 	for cIdx := range chunks {
 		chunks[cIdx].MinTime = refs[cIdx].minTime
@@ -52,8 +52,9 @@ var (
 )
 
 const (
-	ErrSeriesLimitMessage = "exceeded series limit"
-	ErrChunksLimitMessage = "exceeded chunks limit"
+	ErrSeriesLimitMessage     = "exceeded series limit"
+	ErrChunksLimitMessage     = "exceeded chunks limit"
+	ErrChunkBytesLimitMessage = "exceeded chunks size limit"
 )
 
 // seriesChunkRefsSetIterator is the interface implemented by an iterator returning a sequence of seriesChunkRefsSet.
@@ -28,24 +28,27 @@ const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 func init() { proto.RegisterFile("gateway.proto", fileDescriptor_f1a937782ebbded5) }
 
 var fileDescriptor_f1a937782ebbded5 = []byte{
-	// 263 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x90, 0xbd, 0x4a, 0x04, 0x31,
-	0x14, 0x46, 0x93, 0x66, 0xc1, 0xf8, 0x53, 0x04, 0x14, 0x5c, 0xe1, 0x3e, 0xc2, 0x44, 0xb4, 0x12,
-	0x3b, 0x15, 0x6d, 0xc4, 0xc2, 0x05, 0x0b, 0xbb, 0x9b, 0xe5, 0x3a, 0x3b, 0xb8, 0x33, 0x89, 0x49,
-	0x06, 0xb1, 0xf3, 0x11, 0x7c, 0x0c, 0x1f, 0xc5, 0x72, 0x2a, 0xd9, 0xd2, 0xc9, 0x34, 0x96, 0xfb,
-	0x08, 0xe2, 0x66, 0x07, 0x7f, 0xd8, 0xf2, 0x3b, 0xf7, 0x70, 0x8a, 0x2b, 0x36, 0x73, 0x0c, 0xf4,
-	0x88, 0x4f, 0x99, 0x75, 0x26, 0x18, 0xb9, 0xb6, 0x9c, 0x56, 0x0f, 0x8f, 0xf3, 0x22, 0x4c, 0x6a,
-	0x9d, 0x8d, 0x4d, 0xa9, 0x72, 0x87, 0x77, 0x58, 0xa1, 0x2a, 0x8b, 0xb2, 0x70, 0xca, 0xde, 0xe7,
-	0xca, 0x07, 0xe3, 0x68, 0x29, 0xa7, 0x61, 0xb5, 0x72, 0x76, 0x9c, 0x3a, 0x07, 0xef, 0x5c, 0x6c,
-	0x8c, 0xbe, 0xe9, 0x45, 0x52, 0xe4, 0x91, 0x18, 0x8c, 0xc8, 0x15, 0xe4, 0xe5, 0x76, 0x16, 0x26,
-	0x58, 0x19, 0x9f, 0xa5, 0x7d, 0x4d, 0x0f, 0x35, 0xf9, 0x30, 0xdc, 0xf9, 0x8f, 0xbd, 0x35, 0x95,
-	0xa7, 0x7d, 0x2e, 0x4f, 0x85, 0xb8, 0x44, 0x4d, 0xd3, 0x2b, 0x2c, 0xc9, 0xcb, 0xdd, 0xde, 0xfb,
-	0x61, 0x7d, 0x62, 0xb8, 0xea, 0x94, 0x32, 0xf2, 0x5c, 0xac, 0x2f, 0xe8, 0x0d, 0x4e, 0x6b, 0xf2,
-	0xf2, 0xaf, 0x9a, 0x60, 0x9f, 0xd9, 0x5b, 0x79, 0x4b, 0x9d, 0x93, 0xb3, 0xa6, 0x05, 0x36, 0x6b,
-	0x81, 0xcd, 0x5b, 0xe0, 0xcf, 0x11, 0xf8, 0x6b, 0x04, 0xfe, 0x16, 0x81, 0x37, 0x11, 0xf8, 0x47,
-	0x04, 0xfe, 0x19, 0x81, 0xcd, 0x23, 0xf0, 0x97, 0x0e, 0x58, 0xd3, 0x01, 0x9b, 0x75, 0xc0, 0x6e,
-	0xb7, 0x7e, 0xbf, 0xcb, 0x6a, 0x3d, 0x58, 0x7c, 0xe9, 0xf0, 0x2b, 0x00, 0x00, 0xff, 0xff, 0xb9,
-	0xbe, 0x15, 0x37, 0x7e, 0x01, 0x00, 0x00,
+	// 307 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x91, 0x3f, 0x4b, 0x03, 0x31,
+	0x18, 0xc6, 0x13, 0x84, 0x82, 0xf1, 0xcf, 0x10, 0x68, 0xc1, 0x0a, 0xef, 0x64, 0x1d, 0xef, 0x44,
+	0x27, 0x71, 0xb3, 0xa2, 0x8b, 0x38, 0x58, 0x70, 0x70, 0x4b, 0xda, 0x78, 0x0d, 0xf6, 0x2e, 0x31,
+	0x49, 0x91, 0x6e, 0x7e, 0x04, 0xbf, 0x84, 0xe0, 0x47, 0x71, 0xec, 0xd8, 0xd1, 0xe6, 0x16, 0xc7,
+	0x7e, 0x04, 0xb1, 0xb9, 0xa3, 0xa7, 0x1e, 0x74, 0x7c, 0x9f, 0xe7, 0xe1, 0xf7, 0x0b, 0x84, 0xec,
+	0x24, 0xcc, 0x89, 0x67, 0x36, 0x89, 0xb4, 0x51, 0x4e, 0xd1, 0xcd, 0xe2, 0xd4, 0xbc, 0x7d, 0x96,
+	0x48, 0x37, 0x1c, 0xf3, 0xa8, 0xaf, 0xd2, 0x38, 0x31, 0xec, 0x81, 0x65, 0x2c, 0x4e, 0x65, 0x2a,
+	0x4d, 0xac, 0x1f, 0x93, 0xd8, 0x3a, 0x65, 0x44, 0x31, 0x0e, 0x87, 0xe6, 0xb1, 0xd1, 0xfd, 0xc0,
+	0x39, 0x7e, 0xdb, 0x20, 0xdb, 0xbd, 0x9f, 0xf4, 0x2a, 0x4c, 0xe8, 0x29, 0x69, 0xf4, 0x84, 0x91,
+	0xc2, 0xd2, 0x66, 0xe4, 0x86, 0x2c, 0x53, 0x36, 0x0a, 0xf7, 0xad, 0x78, 0x1a, 0x0b, 0xeb, 0xda,
+	0xad, 0xbf, 0xb1, 0xd5, 0x2a, 0xb3, 0xe2, 0x08, 0xd3, 0x2e, 0x21, 0xd7, 0x8c, 0x8b, 0xd1, 0x0d,
+	0x4b, 0x85, 0xa5, 0x7b, 0xe5, 0x6e, 0x95, 0x95, 0x88, 0x76, 0x5d, 0x15, 0x30, 0xf4, 0x92, 0x6c,
+	0x2d, 0xd3, 0x3b, 0x36, 0x1a, 0x0b, 0x4b, 0x7f, 0x4f, 0x43, 0x58, 0x62, 0xf6, 0x6b, 0xbb, 0x82,
+	0x33, 0x20, 0xcd, 0x15, 0xbd, 0xcb, 0xcc, 0x40, 0x66, 0x6c, 0x24, 0xdd, 0x84, 0x1e, 0xfc, 0x97,
+	0x57, 0xea, 0x92, 0xdd, 0x59, 0xb3, 0x2a, 0x2c, 0x09, 0x69, 0x55, 0xe4, 0x55, 0x4d, 0xa7, 0xe6,
+	0x71, 0x35, 0x9e, 0xc3, 0x75, 0xb3, 0x20, 0x3a, 0xbf, 0x98, 0xce, 0x01, 0xcd, 0xe6, 0x80, 0x16,
+	0x73, 0xc0, 0x2f, 0x1e, 0xf0, 0xbb, 0x07, 0xfc, 0xe1, 0x01, 0x4f, 0x3d, 0xe0, 0x4f, 0x0f, 0xf8,
+	0xcb, 0x03, 0x5a, 0x78, 0xc0, 0xaf, 0x39, 0xa0, 0x69, 0x0e, 0x68, 0x96, 0x03, 0xba, 0xdf, 0xad,
+	0xfe, 0xbe, 0xe6, 0xbc, 0xb1, 0xfc, 0xf4, 0x93, 0xef, 0x01, 0x00, 0x07, 0x08, 0xa2, 0x39, 0x4d,
+	0x02, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -72,6 +75,12 @@ type StoreGatewayClient interface {
 	LabelNames(ctx context.Context, in *storepb.LabelNamesRequest, opts ...grpc.CallOption) (*storepb.LabelNamesResponse, error)
 	// LabelValues returns all label values for given label name.
 	LabelValues(ctx context.Context, in *storepb.LabelValuesRequest, opts ...grpc.CallOption) (*storepb.LabelValuesResponse, error)
+	// LabelNamesCardinality returns all label names, with their values, of the series matching the matchers
+	// in the blocks of the given time range.
+	LabelNamesCardinality(ctx context.Context, in *storepb.LabelNamesCardinalityRequest, opts ...grpc.CallOption) (*storepb.LabelNamesCardinalityResponse, error)
+	// LabelValuesCardinality returns the series count of each value of the requested label names, for the series
+	// matching the matchers in the blocks of the given time range.
+	LabelValuesCardinality(ctx context.Context, in *storepb.LabelValuesCardinalityRequest, opts ...grpc.CallOption) (*storepb.LabelValuesCardinalityResponse, error)
 }
 
 type storeGatewayClient struct {
@@ -132,6 +141,24 @@ func (c *storeGatewayClient) LabelValues(ctx context.Context, in *storepb.LabelV
 	return out, nil
 }
 
+func (c *storeGatewayClient) LabelNamesCardinality(ctx context.Context, in *storepb.LabelNamesCardinalityRequest, opts ...grpc.CallOption) (*storepb.LabelNamesCardinalityResponse, error) {
+	out := new(storepb.LabelNamesCardinalityResponse)
+	err := c.cc.Invoke(ctx, "/gatewaypb.StoreGateway/LabelNamesCardinality", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storeGatewayClient) LabelValuesCardinality(ctx context.Context, in *storepb.LabelValuesCardinalityRequest, opts ...grpc.CallOption) (*storepb.LabelValuesCardinalityResponse, error) {
+	out := new(storepb.LabelValuesCardinalityResponse)
+	err := c.cc.Invoke(ctx, "/gatewaypb.StoreGateway/LabelValuesCardinality", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StoreGatewayServer is the server API for StoreGateway service.
 type StoreGatewayServer interface {
 	// Series streams each Series for given label matchers and time range.
@@ -146,6 +173,12 @@ type StoreGatewayServer interface {
 	LabelNames(context.Context, *storepb.LabelNamesRequest) (*storepb.LabelNamesResponse, error)
 	// LabelValues returns all label values for given label name.
 	LabelValues(context.Context, *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error)
+	// LabelNamesCardinality returns all label names, with their values, of the series matching the matchers
+	// in the blocks of the given time range.
+	LabelNamesCardinality(context.Context, *storepb.LabelNamesCardinalityRequest) (*storepb.LabelNamesCardinalityResponse, error)
+	// LabelValuesCardinality returns the series count of each value of the requested label names, for the series
+	// matching the matchers in the blocks of the given time range.
+	LabelValuesCardinality(context.Context, *storepb.LabelValuesCardinalityRequest) (*storepb.LabelValuesCardinalityResponse, error)
 }
 
 // UnimplementedStoreGatewayServer can be embedded to have forward compatible implementations.
@@ -161,6 +194,12 @@ func (*UnimplementedStoreGatewayServer) LabelNames(ctx context.Context, req *sto
 func (*UnimplementedStoreGatewayServer) LabelValues(ctx context.Context, req *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LabelValues not implemented")
 }
+func (*UnimplementedStoreGatewayServer) LabelNamesCardinality(ctx context.Context, req *storepb.LabelNamesCardinalityRequest) (*storepb.LabelNamesCardinalityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LabelNamesCardinality not implemented")
+}
+func (*UnimplementedStoreGatewayServer) LabelValuesCardinality(ctx context.Context, req *storepb.LabelValuesCardinalityRequest) (*storepb.LabelValuesCardinalityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LabelValuesCardinality not implemented")
+}
 
 func RegisterStoreGatewayServer(s *grpc.Server, srv StoreGatewayServer) {
 	s.RegisterService(&_StoreGateway_serviceDesc, srv)
@@ -223,6 +262,42 @@ func _StoreGateway_LabelValues_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StoreGateway_LabelNamesCardinality_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(storepb.LabelNamesCardinalityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreGatewayServer).LabelNamesCardinality(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gatewaypb.StoreGateway/LabelNamesCardinality",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreGatewayServer).LabelNamesCardinality(ctx, req.(*storepb.LabelNamesCardinalityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StoreGateway_LabelValuesCardinality_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(storepb.LabelValuesCardinalityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StoreGatewayServer).LabelValuesCardinality(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gatewaypb.StoreGateway/LabelValuesCardinality",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StoreGatewayServer).LabelValuesCardinality(ctx, req.(*storepb.LabelValuesCardinalityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _StoreGateway_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "gatewaypb.StoreGateway",
 	HandlerType: (*StoreGatewayServer)(nil),
@@ -235,6 +310,14 @@ var _StoreGateway_serviceDesc = grpc.ServiceDesc{
 			MethodName: "LabelValues",
 			Handler:    _StoreGateway_LabelValues_Handler,
 		},
+		{
+			MethodName: "LabelNamesCardinality",
+			Handler:    _StoreGateway_LabelNamesCardinality_Handler,
+		},
+		{
+			MethodName: "LabelValuesCardinality",
+			Handler:    _StoreGateway_LabelValuesCardinality_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package storepb
+
+import (
+	"sort"
+
+	"golang.org/x/exp/slices"
+
+	util_math "github.com/grafana/mimir/pkg/util/math"
+)
+
+type labelValuesCardinalityGroupKey struct {
+	minTime, maxTime int64
+	replicated       bool
+}
+
+// MergeLabelValuesCardinalityGroups merges the groups covering the same time range. The series counts of
+// non-replicated groups, made of blocks of different compactor shards, are summed up. The series counts of
+// replicated groups, made of blocks containing the same series, are merged taking the max. The input groups
+// are not modified, and the returned groups are sorted by time range.
+func MergeLabelValuesCardinalityGroups(groups ...[]LabelValuesCardinalityGroup) []LabelValuesCardinalityGroup {
+	merged := map[labelValuesCardinalityGroupKey]*LabelValuesCardinalityGroup{}
+	for _, gs := range groups {
+		for i := range gs {
+			key := labelValuesCardinalityGroupKey{minTime: gs[i].MinTime, maxTime: gs[i].MaxTime, replicated: gs[i].Replicated}
+			group, ok := merged[key]
+			if !ok {
+				group = &LabelValuesCardinalityGroup{MinTime: key.minTime, MaxTime: key.maxTime, Replicated: key.replicated}
+				merged[key] = group
+			}
+			group.merge(&gs[i])
+		}
+	}
+
+	result := make([]LabelValuesCardinalityGroup, 0, len(merged))
+	for _, group := range merged {
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.MinTime != b.MinTime {
+			return a.MinTime < b.MinTime
+		}
+		if a.MaxTime != b.MaxTime {
+			return a.MaxTime < b.MaxTime
+		}
+		return !a.Replicated && b.Replicated
+	})
+	return result
+}
+
+// merge merges o into g, which must cover the same time range.
+func (g *LabelValuesCardinalityGroup) merge(o *LabelValuesCardinalityGroup) {
+	combine := func(a, b uint64) uint64 { return a + b }
+	if g.Replicated {
+		combine = func(a, b uint64) uint64 { return util_math.Max(a, b) }
+	}
+
+	g.SeriesCount = combine(g.SeriesCount, o.SeriesCount)
+
+	for _, item := range o.Items {
+		idx := slices.IndexFunc(g.Items, func(i LabelValueSeriesCount) bool { return i.LabelName == item.LabelName })
+		if idx < 0 {
+			g.Items = append(g.Items, LabelValueSeriesCount{LabelName: item.LabelName, LabelValueSeries: make(map[string]uint64, len(item.LabelValueSeries))})
+			idx = len(g.Items) - 1
+		}
+		dst := g.Items[idx].LabelValueSeries
+		for value, count := range item.LabelValueSeries {
+			dst[value] = combine(dst[value], count)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
+	github_com_gogo_protobuf_sortkeys "github.com/gogo/protobuf/sortkeys"
 	types "github.com/gogo/protobuf/types"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
@@ -440,6 +441,293 @@ func (m *LabelValuesResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_LabelValuesResponse proto.InternalMessageInfo
 
+type LabelNamesCardinalityRequest struct {
+	Start int64 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End   int64 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	// hints is an opaque data structure that can be used to carry additional information.
+	// The content of this field and whether it's supported depends on the
+	// implementation of a specific store.
+	Hints    *types.Any     `protobuf:"bytes,3,opt,name=hints,proto3" json:"hints,omitempty"`
+	Matchers []LabelMatcher `protobuf:"bytes,4,rep,name=matchers,proto3" json:"matchers"`
+}
+
+func (m *LabelNamesCardinalityRequest) Reset()      { *m = LabelNamesCardinalityRequest{} }
+func (*LabelNamesCardinalityRequest) ProtoMessage() {}
+func (*LabelNamesCardinalityRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{7}
+}
+func (m *LabelNamesCardinalityRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LabelNamesCardinalityRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LabelNamesCardinalityRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *LabelNamesCardinalityRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelNamesCardinalityRequest.Merge(m, src)
+}
+func (m *LabelNamesCardinalityRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *LabelNamesCardinalityRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelNamesCardinalityRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LabelNamesCardinalityRequest proto.InternalMessageInfo
+
+type LabelNamesCardinalityResponse struct {
+	// items contains all the label names, with their values, of the series matching the matchers.
+	Items    []*LabelNameValues `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	Warnings []string           `protobuf:"bytes,2,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	/// hints is an opaque data structure that can be used to carry additional information from
+	/// the store. The content of this field and whether it's supported depends on the
+	/// implementation of a specific store.
+	Hints *types.Any `protobuf:"bytes,3,opt,name=hints,proto3" json:"hints,omitempty"`
+}
+
+func (m *LabelNamesCardinalityResponse) Reset()      { *m = LabelNamesCardinalityResponse{} }
+func (*LabelNamesCardinalityResponse) ProtoMessage() {}
+func (*LabelNamesCardinalityResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{8}
+}
+func (m *LabelNamesCardinalityResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LabelNamesCardinalityResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LabelNamesCardinalityResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *LabelNamesCardinalityResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelNamesCardinalityResponse.Merge(m, src)
+}
+func (m *LabelNamesCardinalityResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *LabelNamesCardinalityResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelNamesCardinalityResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LabelNamesCardinalityResponse proto.InternalMessageInfo
+
+type LabelNameValues struct {
+	LabelName string   `protobuf:"bytes,1,opt,name=label_name,json=labelName,proto3" json:"label_name,omitempty"`
+	Values    []string `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`
+}
+
+func (m *LabelNameValues) Reset()      { *m = LabelNameValues{} }
+func (*LabelNameValues) ProtoMessage() {}
+func (*LabelNameValues) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{9}
+}
+func (m *LabelNameValues) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LabelNameValues) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LabelNameValues.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *LabelNameValues) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelNameValues.Merge(m, src)
+}
+func (m *LabelNameValues) XXX_Size() int {
+	return m.Size()
+}
+func (m *LabelNameValues) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelNameValues.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LabelNameValues proto.InternalMessageInfo
+
+type LabelValuesCardinalityRequest struct {
+	Start int64 `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End   int64 `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	// hints is an opaque data structure that can be used to carry additional information.
+	// The content of this field and whether it's supported depends on the
+	// implementation of a specific store.
+	Hints      *types.Any     `protobuf:"bytes,3,opt,name=hints,proto3" json:"hints,omitempty"`
+	Matchers   []LabelMatcher `protobuf:"bytes,4,rep,name=matchers,proto3" json:"matchers"`
+	LabelNames []string       `protobuf:"bytes,5,rep,name=label_names,json=labelNames,proto3" json:"label_names,omitempty"`
+}
+
+func (m *LabelValuesCardinalityRequest) Reset()      { *m = LabelValuesCardinalityRequest{} }
+func (*LabelValuesCardinalityRequest) ProtoMessage() {}
+func (*LabelValuesCardinalityRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{10}
+}
+func (m *LabelValuesCardinalityRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LabelValuesCardinalityRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LabelValuesCardinalityRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *LabelValuesCardinalityRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelValuesCardinalityRequest.Merge(m, src)
+}
+func (m *LabelValuesCardinalityRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *LabelValuesCardinalityRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelValuesCardinalityRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LabelValuesCardinalityRequest proto.InternalMessageInfo
+
+type LabelValuesCardinalityResponse struct {
+	// groups contains the series count of each label value, grouped by the time range of the queried blocks.
+	Groups   []LabelValuesCardinalityGroup `protobuf:"bytes,1,rep,name=groups,proto3" json:"groups"`
+	Warnings []string                      `protobuf:"bytes,2,rep,name=warnings,proto3" json:"warnings,omitempty"`
+	/// hints is an opaque data structure that can be used to carry additional information from
+	/// the store. The content of this field and whether it's supported depends on the
+	/// implementation of a specific store.
+	Hints *types.Any `protobuf:"bytes,3,opt,name=hints,proto3" json:"hints,omitempty"`
+}
+
+func (m *LabelValuesCardinalityResponse) Reset()      { *m = LabelValuesCardinalityResponse{} }
+func (*LabelValuesCardinalityResponse) ProtoMessage() {}
+func (*LabelValuesCardinalityResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{11}
+}
+func (m *LabelValuesCardinalityResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LabelValuesCardinalityResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LabelValuesCardinalityResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *LabelValuesCardinalityResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelValuesCardinalityResponse.Merge(m, src)
+}
+func (m *LabelValuesCardinalityResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *LabelValuesCardinalityResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelValuesCardinalityResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LabelValuesCardinalityResponse proto.InternalMessageInfo
+
+// LabelValuesCardinalityGroup contains the series counts of the blocks covering the same time range.
+type LabelValuesCardinalityGroup struct {
+	MinTime int64 `protobuf:"varint,1,opt,name=min_time,json=minTime,proto3" json:"min_time,omitempty"`
+	MaxTime int64 `protobuf:"varint,2,opt,name=max_time,json=maxTime,proto3" json:"max_time,omitempty"`
+	// replicated is true if the group is made of blocks uploaded by ingesters, which contain the same series
+	// replicated across ingesters. The series counts of replicated groups are not additive.
+	Replicated bool `protobuf:"varint,3,opt,name=replicated,proto3" json:"replicated,omitempty"`
+	// series_count is the total number of series in the blocks, regardless of the matchers.
+	SeriesCount uint64                  `protobuf:"varint,4,opt,name=series_count,json=seriesCount,proto3" json:"series_count,omitempty"`
+	Items       []LabelValueSeriesCount `protobuf:"bytes,5,rep,name=items,proto3" json:"items"`
+}
+
+func (m *LabelValuesCardinalityGroup) Reset()      { *m = LabelValuesCardinalityGroup{} }
+func (*LabelValuesCardinalityGroup) ProtoMessage() {}
+func (*LabelValuesCardinalityGroup) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{12}
+}
+func (m *LabelValuesCardinalityGroup) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LabelValuesCardinalityGroup) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LabelValuesCardinalityGroup.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *LabelValuesCardinalityGroup) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelValuesCardinalityGroup.Merge(m, src)
+}
+func (m *LabelValuesCardinalityGroup) XXX_Size() int {
+	return m.Size()
+}
+func (m *LabelValuesCardinalityGroup) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelValuesCardinalityGroup.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LabelValuesCardinalityGroup proto.InternalMessageInfo
+
+type LabelValueSeriesCount struct {
+	LabelName        string            `protobuf:"bytes,1,opt,name=label_name,json=labelName,proto3" json:"label_name,omitempty"`
+	LabelValueSeries map[string]uint64 `protobuf:"bytes,2,rep,name=label_value_series,json=labelValueSeries,proto3" json:"label_value_series,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+}
+
+func (m *LabelValueSeriesCount) Reset()      { *m = LabelValueSeriesCount{} }
+func (*LabelValueSeriesCount) ProtoMessage() {}
+func (*LabelValueSeriesCount) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{13}
+}
+func (m *LabelValueSeriesCount) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *LabelValueSeriesCount) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_LabelValueSeriesCount.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *LabelValueSeriesCount) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LabelValueSeriesCount.Merge(m, src)
+}
+func (m *LabelValueSeriesCount) XXX_Size() int {
+	return m.Size()
+}
+func (m *LabelValueSeriesCount) XXX_DiscardUnknown() {
+	xxx_messageInfo_LabelValueSeriesCount.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LabelValueSeriesCount proto.InternalMessageInfo
+
 func init() {
 	proto.RegisterType((*SeriesRequest)(nil), "thanos.SeriesRequest")
 	proto.RegisterType((*Stats)(nil), "thanos.Stats")
@@ -448,63 +736,87 @@ func init() {
 	proto.RegisterType((*LabelNamesResponse)(nil), "thanos.LabelNamesResponse")
 	proto.RegisterType((*LabelValuesRequest)(nil), "thanos.LabelValuesRequest")
 	proto.RegisterType((*LabelValuesResponse)(nil), "thanos.LabelValuesResponse")
+	proto.RegisterType((*LabelNamesCardinalityRequest)(nil), "thanos.LabelNamesCardinalityRequest")
+	proto.RegisterType((*LabelNamesCardinalityResponse)(nil), "thanos.LabelNamesCardinalityResponse")
+	proto.RegisterType((*LabelNameValues)(nil), "thanos.LabelNameValues")
+	proto.RegisterType((*LabelValuesCardinalityRequest)(nil), "thanos.LabelValuesCardinalityRequest")
+	proto.RegisterType((*LabelValuesCardinalityResponse)(nil), "thanos.LabelValuesCardinalityResponse")
+	proto.RegisterType((*LabelValuesCardinalityGroup)(nil), "thanos.LabelValuesCardinalityGroup")
+	proto.RegisterType((*LabelValueSeriesCount)(nil), "thanos.LabelValueSeriesCount")
+	proto.RegisterMapType((map[string]uint64)(nil), "thanos.LabelValueSeriesCount.LabelValueSeriesEntry")
 }
 
 func init() { proto.RegisterFile("rpc.proto", fileDescriptor_77a6da22d6a3feb1) }
 
 var fileDescriptor_77a6da22d6a3feb1 = []byte{
-	// 803 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x94, 0x41, 0x6f, 0xe2, 0x46,
-	0x14, 0xc7, 0x3d, 0x78, 0x6c, 0x86, 0x21, 0xa4, 0xce, 0x24, 0x4d, 0x8d, 0x53, 0x39, 0x08, 0xa9,
-	0x12, 0xaa, 0x5a, 0x52, 0xa5, 0x52, 0xab, 0x1e, 0x7a, 0x08, 0x51, 0x2b, 0x62, 0xb5, 0x3d, 0x38,
-	0x55, 0x0f, 0x95, 0x2a, 0x64, 0x60, 0x02, 0x56, 0xb0, 0x4d, 0x3d, 0x43, 0x1b, 0x72, 0xea, 0x47,
-	0xe8, 0xc7, 0x58, 0xed, 0x7e, 0x82, 0xbd, 0xee, 0x29, 0xb7, 0xcd, 0x31, 0xa7, 0xd5, 0x42, 0x2e,
-	0x7b, 0xcc, 0x47, 0x58, 0x79, 0x3c, 0x60, 0xbc, 0x4b, 0x94, 0x8d, 0xb4, 0x37, 0xde, 0xff, 0xff,
-	0xfc, 0xe6, 0xcd, 0xef, 0xbd, 0x01, 0x97, 0xe2, 0x71, 0xaf, 0x39, 0x8e, 0x23, 0x1e, 0x11, 0x9d,
-	0x0f, 0xbd, 0x30, 0x62, 0x56, 0x99, 0x4f, 0xc7, 0x94, 0xa5, 0xa2, 0xf5, 0xf5, 0xc0, 0xe7, 0xc3,
-	0x49, 0xb7, 0xd9, 0x8b, 0x82, 0x83, 0x41, 0x34, 0x88, 0x0e, 0x84, 0xdc, 0x9d, 0x9c, 0x89, 0x48,
-	0x04, 0xe2, 0x97, 0x4c, 0xaf, 0x0e, 0xa2, 0x68, 0x30, 0xa2, 0x59, 0x96, 0x17, 0x4e, 0x53, 0xab,
-	0xfe, 0xbc, 0x80, 0x2b, 0xa7, 0x34, 0xf6, 0x29, 0x73, 0xe9, 0xdf, 0x13, 0xca, 0x38, 0xa9, 0x62,
-	0x14, 0xf8, 0x61, 0x87, 0xfb, 0x01, 0x35, 0x41, 0x0d, 0x34, 0x54, 0xb7, 0x18, 0xf8, 0xe1, 0xef,
-	0x7e, 0x40, 0x85, 0xe5, 0x5d, 0xa4, 0x56, 0x41, 0x5a, 0xde, 0x85, 0xb0, 0xbe, 0x4b, 0x2c, 0xde,
-	0x1b, 0xd2, 0x98, 0x99, 0x6a, 0x4d, 0x6d, 0x94, 0x0f, 0x77, 0x9a, 0x69, 0xe7, 0xcd, 0x5f, 0xbc,
-	0x2e, 0x1d, 0xfd, 0x9a, 0x9a, 0x2d, 0x78, 0xf5, 0x6a, 0x5f, 0x71, 0x97, 0xb9, 0x64, 0x1f, 0x97,
-	0xd9, 0xb9, 0x3f, 0xee, 0xf4, 0x86, 0x93, 0xf0, 0x9c, 0x99, 0xa8, 0x06, 0x1a, 0xc8, 0xc5, 0x89,
-	0x74, 0x2c, 0x14, 0xf2, 0x25, 0xd6, 0x86, 0x7e, 0xc8, 0x99, 0x59, 0xaa, 0x01, 0x51, 0x35, 0xbd,
-	0x4b, 0x73, 0x71, 0x97, 0xe6, 0x51, 0x38, 0x75, 0xd3, 0x14, 0xf2, 0x23, 0xde, 0x63, 0x3c, 0xa6,
-	0x5e, 0xe0, 0x87, 0x03, 0x59, 0xb1, 0xd3, 0x4d, 0x4e, 0xea, 0x30, 0xff, 0x92, 0x9a, 0xfd, 0x1a,
-	0x68, 0x40, 0xd7, 0x5c, 0xa6, 0xa4, 0x27, 0xb4, 0x92, 0x84, 0x53, 0xff, 0x92, 0x3a, 0x10, 0x41,
-	0x43, 0x73, 0x20, 0xd2, 0x0c, 0xdd, 0x81, 0x48, 0x37, 0x8a, 0x0e, 0x44, 0x45, 0x03, 0x39, 0x10,
-	0x61, 0xa3, 0xec, 0x40, 0x54, 0x36, 0x36, 0x1c, 0x88, 0x36, 0x8c, 0x8a, 0x03, 0x51, 0xc5, 0xd8,
-	0xac, 0x7f, 0x8f, 0xb5, 0x53, 0xee, 0x71, 0x46, 0x9a, 0x78, 0xfb, 0x8c, 0x26, 0x17, 0xea, 0x77,
-	0xfc, 0xb0, 0x4f, 0x2f, 0x3a, 0xdd, 0x29, 0xa7, 0x4c, 0xd0, 0x83, 0xee, 0x96, 0xb4, 0x4e, 0x12,
-	0xa7, 0x95, 0x18, 0xf5, 0xa7, 0x2a, 0xde, 0x5c, 0x40, 0x67, 0xe3, 0x28, 0x64, 0x94, 0x34, 0xb0,
-	0xce, 0x84, 0x22, 0xbe, 0x2a, 0x1f, 0x6e, 0x2e, 0xe8, 0xa5, 0x79, 0x6d, 0xc5, 0x95, 0x3e, 0xb1,
-	0x70, 0xf1, 0x5f, 0x2f, 0x0e, 0xfd, 0x70, 0x20, 0x66, 0x50, 0x6a, 0x2b, 0xee, 0x42, 0x20, 0x5f,
-	0x2d, 0x60, 0xa9, 0xf7, 0xc3, 0x6a, 0x2b, 0x0b, 0x5c, 0x5f, 0x60, 0x8d, 0x25, 0xfd, 0x9b, 0x50,
-	0x64, 0x57, 0x96, 0x47, 0x26, 0x62, 0x92, 0x26, 0x5c, 0x72, 0x82, 0x8d, 0x8c, 0xaa, 0x6c, 0x52,
-	0x13, 0x5f, 0x7c, 0x9e, 0x7d, 0x21, 0xfd, 0xb4, 0x5b, 0x81, 0xb4, 0xad, 0xb8, 0x9f, 0xb0, 0xbc,
-	0x9e, 0x2f, 0x25, 0x47, 0xae, 0xdf, 0x53, 0x6a, 0x65, 0x3a, 0xb9, 0x52, 0x72, 0x2f, 0xfe, 0xc2,
-	0xd5, 0xf7, 0x66, 0x4d, 0x19, 0xf7, 0x03, 0x8f, 0x53, 0xb3, 0x28, 0x6a, 0xee, 0xdf, 0x53, 0xf3,
-	0x27, 0x99, 0xd6, 0x56, 0xdc, 0xcf, 0xd8, 0x7a, 0xab, 0x85, 0xb0, 0x1e, 0x53, 0x36, 0x19, 0xf1,
-	0xfa, 0x33, 0x80, 0xb7, 0xc4, 0x0a, 0xff, 0xe6, 0x05, 0xd9, 0x2b, 0xd9, 0x11, 0xec, 0x62, 0x2e,
-	0x48, 0xab, 0x6e, 0x1a, 0x10, 0x03, 0xab, 0x34, 0xec, 0x0b, 0x9e, 0xaa, 0x9b, 0xfc, 0xcc, 0xd6,
-	0x57, 0x7b, 0x78, 0x7d, 0x57, 0xdf, 0x90, 0xfe, 0xe1, 0x6f, 0xc8, 0x81, 0x08, 0x18, 0x05, 0x07,
-	0xa2, 0x82, 0xa1, 0xd6, 0x63, 0x4c, 0x56, 0x9b, 0x95, 0xdb, 0xb5, 0x83, 0xb5, 0x30, 0x11, 0x4c,
-	0x50, 0x53, 0x1b, 0x25, 0x37, 0x0d, 0x88, 0x85, 0x91, 0x5c, 0x1c, 0x66, 0x16, 0x84, 0xb1, 0x8c,
-	0xb3, 0xbe, 0xd5, 0x07, 0xfb, 0xae, 0xbf, 0x00, 0xf2, 0xd0, 0x3f, 0xbc, 0xd1, 0x24, 0x87, 0x68,
-	0x94, 0xa8, 0x62, 0xa3, 0x4b, 0x6e, 0x1a, 0x64, 0xe0, 0xe0, 0x1a, 0x70, 0xda, 0x1a, 0x70, 0xfa,
-	0xe3, 0xc0, 0x15, 0x1f, 0x05, 0xae, 0x60, 0xa8, 0x0e, 0x44, 0xaa, 0x01, 0xeb, 0x13, 0xbc, 0x9d,
-	0xbb, 0x83, 0x24, 0xb7, 0x8b, 0xf5, 0x7f, 0x84, 0x22, 0xd1, 0xc9, 0xe8, 0x63, 0xb1, 0x3b, 0x7c,
-	0x09, 0x92, 0x3f, 0x91, 0x28, 0xa6, 0xe4, 0x07, 0xac, 0xcb, 0x57, 0xf2, 0x69, 0xfe, 0xed, 0x4b,
-	0x9e, 0xd6, 0xee, 0xbb, 0x72, 0xda, 0xe2, 0x37, 0x80, 0x1c, 0x63, 0x9c, 0x0d, 0x9d, 0x54, 0x73,
-	0x77, 0x5f, 0xdd, 0x5a, 0xcb, 0x5a, 0x67, 0xc9, 0x9b, 0xfe, 0x8c, 0xcb, 0x2b, 0x00, 0x48, 0x3e,
-	0x35, 0x37, 0x59, 0x6b, 0x6f, 0xad, 0x97, 0xd6, 0x69, 0x1d, 0x5d, 0xcd, 0x6c, 0xe5, 0x7a, 0x66,
-	0x2b, 0x37, 0x33, 0x5b, 0xb9, 0x9b, 0xd9, 0xe0, 0xbf, 0xb9, 0x0d, 0x9e, 0xcc, 0x6d, 0x70, 0x35,
-	0xb7, 0xc1, 0xf5, 0xdc, 0x06, 0xaf, 0xe7, 0x36, 0x78, 0x33, 0xb7, 0x95, 0xbb, 0xb9, 0x0d, 0xfe,
-	0xbf, 0xb5, 0x95, 0xeb, 0x5b, 0x5b, 0xb9, 0xb9, 0xb5, 0x95, 0x3f, 0x8b, 0x2c, 0x01, 0x31, 0xee,
-	0x76, 0x75, 0x41, 0xea, 0xdb, 0xb7, 0x01, 0x00, 0x00, 0xff, 0xff, 0x50, 0x01, 0x08, 0x1e, 0x07,
-	0x07, 0x00, 0x00,
+	// 1065 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x56, 0x4f, 0x6f, 0x1b, 0x45,
+	0x14, 0xdf, 0xf1, 0xce, 0xae, 0xd7, 0xcf, 0x4d, 0xba, 0x9d, 0x26, 0xad, 0xe3, 0x34, 0x1b, 0x63,
+	0x84, 0x64, 0x21, 0xea, 0xa2, 0x54, 0x02, 0x8a, 0xc4, 0x21, 0x89, 0x0a, 0xee, 0x0a, 0x38, 0x6c,
+	0x10, 0x07, 0x24, 0x64, 0xad, 0xed, 0xa9, 0xb3, 0x8a, 0xbd, 0x6b, 0x76, 0xd6, 0x10, 0xf7, 0xc4,
+	0x47, 0xe0, 0xc4, 0x67, 0x40, 0xe5, 0x13, 0x70, 0xe5, 0x94, 0x03, 0x12, 0xe1, 0xd6, 0x53, 0x45,
+	0x9c, 0x0b, 0xc7, 0x7e, 0x04, 0x34, 0x7f, 0xd6, 0x6b, 0xc7, 0x76, 0xd3, 0xa0, 0x1c, 0xb8, 0xed,
+	0xfc, 0x7e, 0x6f, 0xde, 0xbc, 0xf7, 0x7b, 0xbf, 0x9d, 0x5d, 0x28, 0xc4, 0x83, 0x76, 0x7d, 0x10,
+	0x47, 0x49, 0x44, 0xcc, 0xe4, 0xd0, 0x0f, 0x23, 0x56, 0x2e, 0x26, 0xa3, 0x01, 0x65, 0x12, 0x2c,
+	0xdf, 0xef, 0x06, 0xc9, 0xe1, 0xb0, 0x55, 0x6f, 0x47, 0xfd, 0x07, 0xdd, 0xa8, 0x1b, 0x3d, 0x10,
+	0x70, 0x6b, 0xf8, 0x54, 0xac, 0xc4, 0x42, 0x3c, 0xa9, 0xf0, 0x8d, 0x6e, 0x14, 0x75, 0x7b, 0x34,
+	0x8b, 0xf2, 0xc3, 0x91, 0xa4, 0xaa, 0xbf, 0xe5, 0x60, 0xe5, 0x80, 0xc6, 0x01, 0x65, 0x1e, 0xfd,
+	0x6e, 0x48, 0x59, 0x42, 0x36, 0xc0, 0xea, 0x07, 0x61, 0x33, 0x09, 0xfa, 0xb4, 0x84, 0x2a, 0xa8,
+	0xa6, 0x7b, 0xf9, 0x7e, 0x10, 0x7e, 0x15, 0xf4, 0xa9, 0xa0, 0xfc, 0x63, 0x49, 0xe5, 0x14, 0xe5,
+	0x1f, 0x0b, 0xea, 0x03, 0x4e, 0x25, 0xed, 0x43, 0x1a, 0xb3, 0x92, 0x5e, 0xd1, 0x6b, 0xc5, 0x9d,
+	0xb5, 0xba, 0xac, 0xbc, 0xfe, 0xb9, 0xdf, 0xa2, 0xbd, 0x2f, 0x24, 0xb9, 0x87, 0x4f, 0x5e, 0x6e,
+	0x6b, 0xde, 0x24, 0x96, 0x6c, 0x43, 0x91, 0x1d, 0x05, 0x83, 0x66, 0xfb, 0x70, 0x18, 0x1e, 0xb1,
+	0x92, 0x55, 0x41, 0x35, 0xcb, 0x03, 0x0e, 0xed, 0x0b, 0x84, 0xbc, 0x0b, 0xc6, 0x61, 0x10, 0x26,
+	0xac, 0x54, 0xa8, 0x20, 0x91, 0x55, 0xf6, 0x52, 0x4f, 0x7b, 0xa9, 0xef, 0x86, 0x23, 0x4f, 0x86,
+	0x90, 0x4f, 0x60, 0x93, 0x25, 0x31, 0xf5, 0xfb, 0x41, 0xd8, 0x55, 0x19, 0x9b, 0x2d, 0x7e, 0x52,
+	0x93, 0x05, 0xcf, 0x68, 0xa9, 0x53, 0x41, 0x35, 0xec, 0x95, 0x26, 0x21, 0xf2, 0x84, 0x3d, 0x1e,
+	0x70, 0x10, 0x3c, 0xa3, 0x2e, 0xb6, 0xb0, 0x6d, 0xb8, 0xd8, 0x32, 0x6c, 0xd3, 0xc5, 0x96, 0x69,
+	0xe7, 0x5d, 0x6c, 0xe5, 0x6d, 0xcb, 0xc5, 0x16, 0xd8, 0x45, 0x17, 0x5b, 0x45, 0xfb, 0x86, 0x8b,
+	0xad, 0x1b, 0xf6, 0x8a, 0x8b, 0xad, 0x15, 0x7b, 0xb5, 0xfa, 0x21, 0x18, 0x07, 0x89, 0x9f, 0x30,
+	0x52, 0x87, 0xdb, 0x4f, 0x29, 0x6f, 0xa8, 0xd3, 0x0c, 0xc2, 0x0e, 0x3d, 0x6e, 0xb6, 0x46, 0x09,
+	0x65, 0x42, 0x3d, 0xec, 0xdd, 0x52, 0xd4, 0x13, 0xce, 0xec, 0x71, 0xa2, 0xfa, 0x5c, 0x87, 0xd5,
+	0x54, 0x74, 0x36, 0x88, 0x42, 0x46, 0x49, 0x0d, 0x4c, 0x26, 0x10, 0xb1, 0xab, 0xb8, 0xb3, 0x9a,
+	0xaa, 0x27, 0xe3, 0x1a, 0x9a, 0xa7, 0x78, 0x52, 0x86, 0xfc, 0x0f, 0x7e, 0x1c, 0x06, 0x61, 0x57,
+	0xcc, 0xa0, 0xd0, 0xd0, 0xbc, 0x14, 0x20, 0xef, 0xa5, 0x62, 0xe9, 0xcb, 0xc5, 0x6a, 0x68, 0xa9,
+	0x5c, 0xef, 0x80, 0xc1, 0x78, 0xfd, 0x25, 0x2c, 0xa2, 0x57, 0x26, 0x47, 0x72, 0x90, 0x87, 0x09,
+	0x96, 0x3c, 0x01, 0x3b, 0x53, 0x55, 0x15, 0x69, 0x88, 0x1d, 0xf7, 0xb2, 0x1d, 0x8a, 0x97, 0xd5,
+	0x0a, 0x49, 0x1b, 0x9a, 0x77, 0x93, 0xcd, 0xe2, 0xb3, 0xa9, 0xd4, 0xc8, 0xcd, 0x25, 0xa9, 0xa6,
+	0xa6, 0x33, 0x93, 0x4a, 0xf9, 0xe2, 0x5b, 0xd8, 0x98, 0x9b, 0x35, 0x65, 0x49, 0xd0, 0xf7, 0x13,
+	0x5a, 0xca, 0x8b, 0x9c, 0xdb, 0x4b, 0x72, 0x3e, 0x56, 0x61, 0x0d, 0xcd, 0xbb, 0xcb, 0x16, 0x53,
+	0x7b, 0x16, 0x98, 0x31, 0x65, 0xc3, 0x5e, 0x52, 0xfd, 0x15, 0xc1, 0x2d, 0x61, 0xe1, 0x2f, 0xfd,
+	0x7e, 0xf6, 0x96, 0xac, 0x09, 0xed, 0xe2, 0x44, 0x28, 0xad, 0x7b, 0x72, 0x41, 0x6c, 0xd0, 0x69,
+	0xd8, 0x11, 0x7a, 0xea, 0x1e, 0x7f, 0xcc, 0xec, 0x6b, 0x5c, 0x6e, 0xdf, 0xe9, 0x77, 0xc8, 0x7c,
+	0xf3, 0x77, 0xc8, 0xc5, 0x16, 0xb2, 0x73, 0x2e, 0xb6, 0x72, 0xb6, 0x5e, 0x8d, 0x81, 0x4c, 0x17,
+	0xab, 0xdc, 0xb5, 0x06, 0x46, 0xc8, 0x81, 0x12, 0xaa, 0xe8, 0xb5, 0x82, 0x27, 0x17, 0xa4, 0x0c,
+	0x96, 0x32, 0x0e, 0x2b, 0xe5, 0x04, 0x31, 0x59, 0x67, 0x75, 0xeb, 0x97, 0xd6, 0x5d, 0xfd, 0x1d,
+	0xa9, 0x43, 0xbf, 0xf6, 0x7b, 0xc3, 0x19, 0x89, 0x7a, 0x1c, 0x15, 0x8e, 0x2e, 0x78, 0x72, 0x91,
+	0x09, 0x87, 0x17, 0x08, 0x67, 0x2c, 0x10, 0xce, 0xbc, 0x9a, 0x70, 0xf9, 0x2b, 0x09, 0x97, 0xb3,
+	0x75, 0x17, 0x5b, 0xba, 0x8d, 0xab, 0x43, 0xb8, 0x3d, 0xd3, 0x83, 0x52, 0xee, 0x0e, 0x98, 0xdf,
+	0x0b, 0x44, 0x49, 0xa7, 0x56, 0xd7, 0xa6, 0xdd, 0x73, 0x04, 0xf7, 0xb2, 0x81, 0xed, 0xfb, 0x71,
+	0x27, 0x08, 0xfd, 0x5e, 0x90, 0x8c, 0xe6, 0x8c, 0x86, 0x16, 0xe8, 0x95, 0x5b, 0xa0, 0x97, 0x7e,
+	0x35, 0xbd, 0xf0, 0x9b, 0xeb, 0x55, 0xfd, 0x19, 0xc1, 0xd6, 0x92, 0x62, 0x95, 0x5c, 0xf7, 0xc1,
+	0x08, 0x12, 0xda, 0x97, 0x6a, 0x15, 0x77, 0xee, 0xce, 0xa4, 0xe5, 0xbb, 0x94, 0xbc, 0x32, 0xea,
+	0xda, 0x54, 0x6c, 0xc0, 0xcd, 0x0b, 0x27, 0x90, 0x2d, 0x00, 0x61, 0xb8, 0x26, 0xf7, 0xba, 0xb2,
+	0x60, 0xa1, 0x97, 0x06, 0x4d, 0xcd, 0x35, 0x37, 0x3d, 0xd7, 0xea, 0x1f, 0x69, 0x8b, 0x32, 0xcd,
+	0xff, 0x7b, 0x20, 0xfc, 0xeb, 0x99, 0x35, 0xc9, 0xef, 0x18, 0xde, 0x0a, 0x4c, 0xba, 0x64, 0xfc,
+	0xf2, 0x72, 0x96, 0xb5, 0xa3, 0x46, 0xb6, 0x0b, 0x66, 0x37, 0x8e, 0x86, 0x83, 0x74, 0x66, 0x6f,
+	0xcf, 0x9c, 0x3c, 0xb7, 0xef, 0x33, 0x1e, 0xab, 0x0a, 0x51, 0x1b, 0xaf, 0x6d, 0x8c, 0x7f, 0x21,
+	0xd8, 0x7c, 0xcd, 0xa9, 0xff, 0xf1, 0xd7, 0xc4, 0x01, 0x88, 0xe9, 0xa0, 0x17, 0xb4, 0xfd, 0x84,
+	0x76, 0x44, 0x19, 0x96, 0x37, 0x85, 0x90, 0xb7, 0xe0, 0x86, 0xfc, 0xaa, 0x35, 0xdb, 0xd1, 0x30,
+	0x94, 0x17, 0x13, 0xf6, 0x8a, 0x12, 0xdb, 0xe7, 0x10, 0x79, 0x94, 0xda, 0xda, 0x10, 0x12, 0x6d,
+	0xcd, 0x4b, 0x74, 0x90, 0x45, 0x2b, 0x71, 0xe4, 0x8e, 0xea, 0x4b, 0x04, 0xeb, 0x0b, 0xc3, 0x2e,
+	0x73, 0xa8, 0x0f, 0x44, 0xd2, 0xc2, 0x99, 0xe9, 0x87, 0x37, 0x27, 0x0a, 0x78, 0xf8, 0xda, 0x02,
+	0xe6, 0xd0, 0xc7, 0x61, 0x12, 0x8f, 0x3c, 0xbb, 0x77, 0x01, 0x2e, 0xef, 0xc3, 0xfa, 0xc2, 0x50,
+	0xee, 0xe6, 0x23, 0x3a, 0x52, 0x35, 0xf1, 0x47, 0xee, 0x7a, 0x51, 0x87, 0x10, 0x17, 0x7b, 0x72,
+	0xf1, 0x71, 0xee, 0x23, 0xb4, 0xf3, 0x27, 0xe2, 0xbf, 0x41, 0x51, 0x4c, 0xc9, 0x23, 0x30, 0xd5,
+	0x77, 0x7e, 0x7d, 0xf6, 0xef, 0x45, 0xbd, 0x3a, 0xe5, 0x3b, 0x17, 0x61, 0x69, 0xc1, 0xf7, 0x11,
+	0xd9, 0x07, 0xc8, 0x2e, 0x16, 0xb2, 0x31, 0x77, 0x6d, 0x4c, 0x52, 0x94, 0x17, 0x51, 0xca, 0xc9,
+	0x9f, 0x42, 0x71, 0xca, 0x3d, 0xa4, 0xbc, 0xc0, 0xc8, 0x69, 0x9a, 0xcd, 0x85, 0x9c, 0xcc, 0xb3,
+	0xb7, 0x7b, 0x72, 0xe6, 0x68, 0xa7, 0x67, 0x8e, 0xf6, 0xe2, 0xcc, 0xd1, 0x5e, 0x9d, 0x39, 0xe8,
+	0xc7, 0xb1, 0x83, 0x7e, 0x19, 0x3b, 0xe8, 0x64, 0xec, 0xa0, 0xd3, 0xb1, 0x83, 0xfe, 0x1e, 0x3b,
+	0xe8, 0x9f, 0xb1, 0xa3, 0xbd, 0x1a, 0x3b, 0xe8, 0xa7, 0x73, 0x47, 0x3b, 0x3d, 0x77, 0xb4, 0x17,
+	0xe7, 0x8e, 0xf6, 0x4d, 0x9e, 0x71, 0x21, 0x06, 0xad, 0x96, 0x29, 0xec, 0xfd, 0xf0, 0xdf, 0x01,
+	0x00, 0x67, 0x00, 0x99, 0xd4, 0xc9, 0x0b, 0x00, 0x00,
 }
 
 func (this *SeriesRequest) Equal(that interface{}) bool {
@@ -932,78 +1244,347 @@ func (this *LabelValuesResponse) Equal(that interface{}) bool {
 	}
 	return true
 }
-func (this *SeriesRequest) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *LabelNamesCardinalityRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := make([]string, 0, 10)
-	s = append(s, "&storepb.SeriesRequest{")
-	s = append(s, "MinTime: "+fmt.Sprintf("%#v", this.MinTime)+",\n")
-	s = append(s, "MaxTime: "+fmt.Sprintf("%#v", this.MaxTime)+",\n")
-	if this.Matchers != nil {
-		vs := make([]*LabelMatcher, len(this.Matchers))
-		for i := range vs {
-			vs[i] = &this.Matchers[i]
+
+	that1, ok := that.(*LabelNamesCardinalityRequest)
+	if !ok {
+		that2, ok := that.(LabelNamesCardinalityRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
 		}
-		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
-	s = append(s, "SkipChunks: "+fmt.Sprintf("%#v", this.SkipChunks)+",\n")
-	if this.Hints != nil {
-		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
 	}
-	s = append(s, "StreamingChunksBatchSize: "+fmt.Sprintf("%#v", this.StreamingChunksBatchSize)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *Stats) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.Start != that1.Start {
+		return false
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&storepb.Stats{")
-	s = append(s, "FetchedIndexBytes: "+fmt.Sprintf("%#v", this.FetchedIndexBytes)+",\n")
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *SeriesResponse) GoString() string {
-	if this == nil {
-		return "nil"
+	if this.End != that1.End {
+		return false
 	}
-	s := make([]string, 0, 11)
-	s = append(s, "&storepb.SeriesResponse{")
-	if this.Result != nil {
-		s = append(s, "Result: "+fmt.Sprintf("%#v", this.Result)+",\n")
+	if !this.Hints.Equal(that1.Hints) {
+		return false
 	}
-	s = append(s, "}")
-	return strings.Join(s, "")
-}
-func (this *SeriesResponse_Series) GoString() string {
-	if this == nil {
-		return "nil"
+	if len(this.Matchers) != len(that1.Matchers) {
+		return false
 	}
-	s := strings.Join([]string{`&storepb.SeriesResponse_Series{` +
-		`Series:` + fmt.Sprintf("%#v", this.Series) + `}`}, ", ")
-	return s
-}
-func (this *SeriesResponse_Warning) GoString() string {
-	if this == nil {
-		return "nil"
+	for i := range this.Matchers {
+		if !this.Matchers[i].Equal(&that1.Matchers[i]) {
+			return false
+		}
 	}
-	s := strings.Join([]string{`&storepb.SeriesResponse_Warning{` +
-		`Warning:` + fmt.Sprintf("%#v", this.Warning) + `}`}, ", ")
-	return s
+	return true
 }
-func (this *SeriesResponse_Hints) GoString() string {
-	if this == nil {
-		return "nil"
+func (this *LabelNamesCardinalityResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
 	}
-	s := strings.Join([]string{`&storepb.SeriesResponse_Hints{` +
-		`Hints:` + fmt.Sprintf("%#v", this.Hints) + `}`}, ", ")
-	return s
-}
-func (this *SeriesResponse_Stats) GoString() string {
-	if this == nil {
-		return "nil"
+
+	that1, ok := that.(*LabelNamesCardinalityResponse)
+	if !ok {
+		that2, ok := that.(LabelNamesCardinalityResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Items) != len(that1.Items) {
+		return false
+	}
+	for i := range this.Items {
+		if !this.Items[i].Equal(that1.Items[i]) {
+			return false
+		}
+	}
+	if len(this.Warnings) != len(that1.Warnings) {
+		return false
+	}
+	for i := range this.Warnings {
+		if this.Warnings[i] != that1.Warnings[i] {
+			return false
+		}
+	}
+	if !this.Hints.Equal(that1.Hints) {
+		return false
+	}
+	return true
+}
+func (this *LabelNameValues) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*LabelNameValues)
+	if !ok {
+		that2, ok := that.(LabelNameValues)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.LabelName != that1.LabelName {
+		return false
+	}
+	if len(this.Values) != len(that1.Values) {
+		return false
+	}
+	for i := range this.Values {
+		if this.Values[i] != that1.Values[i] {
+			return false
+		}
+	}
+	return true
+}
+func (this *LabelValuesCardinalityRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*LabelValuesCardinalityRequest)
+	if !ok {
+		that2, ok := that.(LabelValuesCardinalityRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Start != that1.Start {
+		return false
+	}
+	if this.End != that1.End {
+		return false
+	}
+	if !this.Hints.Equal(that1.Hints) {
+		return false
+	}
+	if len(this.Matchers) != len(that1.Matchers) {
+		return false
+	}
+	for i := range this.Matchers {
+		if !this.Matchers[i].Equal(&that1.Matchers[i]) {
+			return false
+		}
+	}
+	if len(this.LabelNames) != len(that1.LabelNames) {
+		return false
+	}
+	for i := range this.LabelNames {
+		if this.LabelNames[i] != that1.LabelNames[i] {
+			return false
+		}
+	}
+	return true
+}
+func (this *LabelValuesCardinalityResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*LabelValuesCardinalityResponse)
+	if !ok {
+		that2, ok := that.(LabelValuesCardinalityResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Groups) != len(that1.Groups) {
+		return false
+	}
+	for i := range this.Groups {
+		if !this.Groups[i].Equal(&that1.Groups[i]) {
+			return false
+		}
+	}
+	if len(this.Warnings) != len(that1.Warnings) {
+		return false
+	}
+	for i := range this.Warnings {
+		if this.Warnings[i] != that1.Warnings[i] {
+			return false
+		}
+	}
+	if !this.Hints.Equal(that1.Hints) {
+		return false
+	}
+	return true
+}
+func (this *LabelValuesCardinalityGroup) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*LabelValuesCardinalityGroup)
+	if !ok {
+		that2, ok := that.(LabelValuesCardinalityGroup)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.MinTime != that1.MinTime {
+		return false
+	}
+	if this.MaxTime != that1.MaxTime {
+		return false
+	}
+	if this.Replicated != that1.Replicated {
+		return false
+	}
+	if this.SeriesCount != that1.SeriesCount {
+		return false
+	}
+	if len(this.Items) != len(that1.Items) {
+		return false
+	}
+	for i := range this.Items {
+		if !this.Items[i].Equal(&that1.Items[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *LabelValueSeriesCount) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*LabelValueSeriesCount)
+	if !ok {
+		that2, ok := that.(LabelValueSeriesCount)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.LabelName != that1.LabelName {
+		return false
+	}
+	if len(this.LabelValueSeries) != len(that1.LabelValueSeries) {
+		return false
+	}
+	for i := range this.LabelValueSeries {
+		if this.LabelValueSeries[i] != that1.LabelValueSeries[i] {
+			return false
+		}
+	}
+	return true
+}
+func (this *SeriesRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 10)
+	s = append(s, "&storepb.SeriesRequest{")
+	s = append(s, "MinTime: "+fmt.Sprintf("%#v", this.MinTime)+",\n")
+	s = append(s, "MaxTime: "+fmt.Sprintf("%#v", this.MaxTime)+",\n")
+	if this.Matchers != nil {
+		vs := make([]*LabelMatcher, len(this.Matchers))
+		for i := range vs {
+			vs[i] = &this.Matchers[i]
+		}
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "SkipChunks: "+fmt.Sprintf("%#v", this.SkipChunks)+",\n")
+	if this.Hints != nil {
+		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	}
+	s = append(s, "StreamingChunksBatchSize: "+fmt.Sprintf("%#v", this.StreamingChunksBatchSize)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *Stats) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&storepb.Stats{")
+	s = append(s, "FetchedIndexBytes: "+fmt.Sprintf("%#v", this.FetchedIndexBytes)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *SeriesResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 11)
+	s = append(s, "&storepb.SeriesResponse{")
+	if this.Result != nil {
+		s = append(s, "Result: "+fmt.Sprintf("%#v", this.Result)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *SeriesResponse_Series) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&storepb.SeriesResponse_Series{` +
+		`Series:` + fmt.Sprintf("%#v", this.Series) + `}`}, ", ")
+	return s
+}
+func (this *SeriesResponse_Warning) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&storepb.SeriesResponse_Warning{` +
+		`Warning:` + fmt.Sprintf("%#v", this.Warning) + `}`}, ", ")
+	return s
+}
+func (this *SeriesResponse_Hints) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&storepb.SeriesResponse_Hints{` +
+		`Hints:` + fmt.Sprintf("%#v", this.Hints) + `}`}, ", ")
+	return s
+}
+func (this *SeriesResponse_Stats) GoString() string {
+	if this == nil {
+		return "nil"
 	}
 	s := strings.Join([]string{`&storepb.SeriesResponse_Stats{` +
 		`Stats:` + fmt.Sprintf("%#v", this.Stats) + `}`}, ", ")
@@ -1104,6 +1685,139 @@ func (this *LabelValuesResponse) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *LabelNamesCardinalityRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&storepb.LabelNamesCardinalityRequest{")
+	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
+	s = append(s, "End: "+fmt.Sprintf("%#v", this.End)+",\n")
+	if this.Hints != nil {
+		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	}
+	if this.Matchers != nil {
+		vs := make([]LabelMatcher, len(this.Matchers))
+		for i := range vs {
+			vs[i] = this.Matchers[i]
+		}
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *LabelNamesCardinalityResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&storepb.LabelNamesCardinalityResponse{")
+	if this.Items != nil {
+		s = append(s, "Items: "+fmt.Sprintf("%#v", this.Items)+",\n")
+	}
+	s = append(s, "Warnings: "+fmt.Sprintf("%#v", this.Warnings)+",\n")
+	if this.Hints != nil {
+		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *LabelNameValues) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&storepb.LabelNameValues{")
+	s = append(s, "LabelName: "+fmt.Sprintf("%#v", this.LabelName)+",\n")
+	s = append(s, "Values: "+fmt.Sprintf("%#v", this.Values)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *LabelValuesCardinalityRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 9)
+	s = append(s, "&storepb.LabelValuesCardinalityRequest{")
+	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
+	s = append(s, "End: "+fmt.Sprintf("%#v", this.End)+",\n")
+	if this.Hints != nil {
+		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	}
+	if this.Matchers != nil {
+		vs := make([]LabelMatcher, len(this.Matchers))
+		for i := range vs {
+			vs[i] = this.Matchers[i]
+		}
+		s = append(s, "Matchers: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "LabelNames: "+fmt.Sprintf("%#v", this.LabelNames)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *LabelValuesCardinalityResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&storepb.LabelValuesCardinalityResponse{")
+	if this.Groups != nil {
+		vs := make([]LabelValuesCardinalityGroup, len(this.Groups))
+		for i := range vs {
+			vs[i] = this.Groups[i]
+		}
+		s = append(s, "Groups: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "Warnings: "+fmt.Sprintf("%#v", this.Warnings)+",\n")
+	if this.Hints != nil {
+		s = append(s, "Hints: "+fmt.Sprintf("%#v", this.Hints)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *LabelValuesCardinalityGroup) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 9)
+	s = append(s, "&storepb.LabelValuesCardinalityGroup{")
+	s = append(s, "MinTime: "+fmt.Sprintf("%#v", this.MinTime)+",\n")
+	s = append(s, "MaxTime: "+fmt.Sprintf("%#v", this.MaxTime)+",\n")
+	s = append(s, "Replicated: "+fmt.Sprintf("%#v", this.Replicated)+",\n")
+	s = append(s, "SeriesCount: "+fmt.Sprintf("%#v", this.SeriesCount)+",\n")
+	if this.Items != nil {
+		vs := make([]LabelValueSeriesCount, len(this.Items))
+		for i := range vs {
+			vs[i] = this.Items[i]
+		}
+		s = append(s, "Items: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *LabelValueSeriesCount) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&storepb.LabelValueSeriesCount{")
+	s = append(s, "LabelName: "+fmt.Sprintf("%#v", this.LabelName)+",\n")
+	keysForLabelValueSeries := make([]string, 0, len(this.LabelValueSeries))
+	for k, _ := range this.LabelValueSeries {
+		keysForLabelValueSeries = append(keysForLabelValueSeries, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForLabelValueSeries)
+	mapStringForLabelValueSeries := "map[string]uint64{"
+	for _, k := range keysForLabelValueSeries {
+		mapStringForLabelValueSeries += fmt.Sprintf("%#v: %#v,", k, this.LabelValueSeries[k])
+	}
+	mapStringForLabelValueSeries += "}"
+	if this.LabelValueSeries != nil {
+		s = append(s, "LabelValueSeries: "+mapStringForLabelValueSeries+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringRpc(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -1815,169 +2529,419 @@ func (m *LabelValuesResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintRpc(dAtA []byte, offset int, v uint64) int {
-	offset -= sovRpc(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *LabelNamesCardinalityRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return dAtA[:n], nil
 }
-func (m *SeriesRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+
+func (m *LabelNamesCardinalityRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelNamesCardinalityRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.MinTime != 0 {
-		n += 1 + sovRpc(uint64(m.MinTime))
-	}
-	if m.MaxTime != 0 {
-		n += 1 + sovRpc(uint64(m.MaxTime))
-	}
 	if len(m.Matchers) > 0 {
-		for _, e := range m.Matchers {
-			l = e.Size()
-			n += 1 + l + sovRpc(uint64(l))
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
 		}
 	}
-	if m.SkipChunks {
-		n += 2
-	}
 	if m.Hints != nil {
-		l = m.Hints.Size()
-		n += 1 + l + sovRpc(uint64(l))
+		{
+			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
 	}
-	if m.StreamingChunksBatchSize != 0 {
-		n += 2 + sovRpc(uint64(m.StreamingChunksBatchSize))
+	if m.End != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.End))
+		i--
+		dAtA[i] = 0x10
 	}
-	return n
+	if m.Start != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.Start))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
 }
 
-func (m *Stats) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.FetchedIndexBytes != 0 {
-		n += 1 + sovRpc(uint64(m.FetchedIndexBytes))
+func (m *LabelNamesCardinalityResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	return n
+	return dAtA[:n], nil
 }
 
-func (m *SeriesResponse) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.Result != nil {
-		n += m.Result.Size()
-	}
-	return n
+func (m *LabelNamesCardinalityResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *SeriesResponse_Series) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+func (m *LabelNamesCardinalityResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.Series != nil {
-		l = m.Series.Size()
-		n += 1 + l + sovRpc(uint64(l))
+	if m.Hints != nil {
+		{
+			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
 	}
-	return n
-}
-func (m *SeriesResponse_Warning) Size() (n int) {
-	if m == nil {
-		return 0
+	if len(m.Warnings) > 0 {
+		for iNdEx := len(m.Warnings) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Warnings[iNdEx])
+			copy(dAtA[i:], m.Warnings[iNdEx])
+			i = encodeVarintRpc(dAtA, i, uint64(len(m.Warnings[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
 	}
-	var l int
-	_ = l
-	l = len(m.Warning)
-	n += 1 + l + sovRpc(uint64(l))
-	return n
+	if len(m.Items) > 0 {
+		for iNdEx := len(m.Items) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Items[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
 }
-func (m *SeriesResponse_Hints) Size() (n int) {
-	if m == nil {
-		return 0
+
+func (m *LabelNameValues) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *LabelNameValues) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelNameValues) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.Hints != nil {
-		l = m.Hints.Size()
-		n += 1 + l + sovRpc(uint64(l))
+	if len(m.Values) > 0 {
+		for iNdEx := len(m.Values) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Values[iNdEx])
+			copy(dAtA[i:], m.Values[iNdEx])
+			i = encodeVarintRpc(dAtA, i, uint64(len(m.Values[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
 	}
-	return n
+	if len(m.LabelName) > 0 {
+		i -= len(m.LabelName)
+		copy(dAtA[i:], m.LabelName)
+		i = encodeVarintRpc(dAtA, i, uint64(len(m.LabelName)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
 }
-func (m *SeriesResponse_Stats) Size() (n int) {
-	if m == nil {
-		return 0
+
+func (m *LabelValuesCardinalityRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *LabelValuesCardinalityRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelValuesCardinalityRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.Stats != nil {
-		l = m.Stats.Size()
-		n += 1 + l + sovRpc(uint64(l))
+	if len(m.LabelNames) > 0 {
+		for iNdEx := len(m.LabelNames) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.LabelNames[iNdEx])
+			copy(dAtA[i:], m.LabelNames[iNdEx])
+			i = encodeVarintRpc(dAtA, i, uint64(len(m.LabelNames[iNdEx])))
+			i--
+			dAtA[i] = 0x2a
+		}
 	}
-	return n
+	if len(m.Matchers) > 0 {
+		for iNdEx := len(m.Matchers) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Matchers[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if m.Hints != nil {
+		{
+			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.End != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.End))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.Start != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.Start))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
 }
-func (m *SeriesResponse_StreamingSeries) Size() (n int) {
-	if m == nil {
-		return 0
+
+func (m *LabelValuesCardinalityResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *LabelValuesCardinalityResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelValuesCardinalityResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.StreamingSeries != nil {
-		l = m.StreamingSeries.Size()
-		n += 1 + l + sovRpc(uint64(l))
+	if m.Hints != nil {
+		{
+			size, err := m.Hints.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintRpc(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
 	}
-	return n
+	if len(m.Warnings) > 0 {
+		for iNdEx := len(m.Warnings) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Warnings[iNdEx])
+			copy(dAtA[i:], m.Warnings[iNdEx])
+			i = encodeVarintRpc(dAtA, i, uint64(len(m.Warnings[iNdEx])))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Groups) > 0 {
+		for iNdEx := len(m.Groups) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Groups[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
 }
-func (m *SeriesResponse_StreamingChunks) Size() (n int) {
-	if m == nil {
-		return 0
+
+func (m *LabelValuesCardinalityGroup) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *LabelValuesCardinalityGroup) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelValuesCardinalityGroup) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.StreamingChunks != nil {
-		l = m.StreamingChunks.Size()
-		n += 1 + l + sovRpc(uint64(l))
+	if len(m.Items) > 0 {
+		for iNdEx := len(m.Items) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Items[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintRpc(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x2a
+		}
 	}
-	return n
+	if m.SeriesCount != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.SeriesCount))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Replicated {
+		i--
+		if m.Replicated {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.MaxTime != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.MaxTime))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.MinTime != 0 {
+		i = encodeVarintRpc(dAtA, i, uint64(m.MinTime))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
 }
-func (m *SeriesResponse_StreamingChunksEstimate) Size() (n int) {
-	if m == nil {
-		return 0
+
+func (m *LabelValueSeriesCount) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *LabelValueSeriesCount) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelValueSeriesCount) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if m.StreamingChunksEstimate != nil {
-		l = m.StreamingChunksEstimate.Size()
-		n += 1 + l + sovRpc(uint64(l))
+	if len(m.LabelValueSeries) > 0 {
+		for k := range m.LabelValueSeries {
+			v := m.LabelValueSeries[k]
+			baseI := i
+			i = encodeVarintRpc(dAtA, i, uint64(v))
+			i--
+			dAtA[i] = 0x10
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintRpc(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintRpc(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x12
+		}
 	}
-	return n
+	if len(m.LabelName) > 0 {
+		i -= len(m.LabelName)
+		copy(dAtA[i:], m.LabelName)
+		i = encodeVarintRpc(dAtA, i, uint64(len(m.LabelName)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
 }
-func (m *LabelNamesRequest) Size() (n int) {
+
+func encodeVarintRpc(dAtA []byte, offset int, v uint64) int {
+	offset -= sovRpc(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *SeriesRequest) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	if m.Start != 0 {
-		n += 1 + sovRpc(uint64(m.Start))
-	}
-	if m.End != 0 {
-		n += 1 + sovRpc(uint64(m.End))
+	if m.MinTime != 0 {
+		n += 1 + sovRpc(uint64(m.MinTime))
 	}
-	if m.Hints != nil {
-		l = m.Hints.Size()
-		n += 1 + l + sovRpc(uint64(l))
+	if m.MaxTime != 0 {
+		n += 1 + sovRpc(uint64(m.MaxTime))
 	}
 	if len(m.Matchers) > 0 {
 		for _, e := range m.Matchers {
@@ -1985,18 +2949,159 @@ func (m *LabelNamesRequest) Size() (n int) {
 			n += 1 + l + sovRpc(uint64(l))
 		}
 	}
+	if m.SkipChunks {
+		n += 2
+	}
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	if m.StreamingChunksBatchSize != 0 {
+		n += 2 + sovRpc(uint64(m.StreamingChunksBatchSize))
+	}
 	return n
 }
 
-func (m *LabelNamesResponse) Size() (n int) {
+func (m *Stats) Size() (n int) {
 	if m == nil {
 		return 0
 	}
 	var l int
 	_ = l
-	if len(m.Names) > 0 {
-		for _, s := range m.Names {
-			l = len(s)
+	if m.FetchedIndexBytes != 0 {
+		n += 1 + sovRpc(uint64(m.FetchedIndexBytes))
+	}
+	return n
+}
+
+func (m *SeriesResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Result != nil {
+		n += m.Result.Size()
+	}
+	return n
+}
+
+func (m *SeriesResponse_Series) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Series != nil {
+		l = m.Series.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+func (m *SeriesResponse_Warning) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Warning)
+	n += 1 + l + sovRpc(uint64(l))
+	return n
+}
+func (m *SeriesResponse_Hints) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+func (m *SeriesResponse_Stats) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Stats != nil {
+		l = m.Stats.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+func (m *SeriesResponse_StreamingSeries) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StreamingSeries != nil {
+		l = m.StreamingSeries.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+func (m *SeriesResponse_StreamingChunks) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StreamingChunks != nil {
+		l = m.StreamingChunks.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+func (m *SeriesResponse_StreamingChunksEstimate) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.StreamingChunksEstimate != nil {
+		l = m.StreamingChunksEstimate.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+func (m *LabelNamesRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Start != 0 {
+		n += 1 + sovRpc(uint64(m.Start))
+	}
+	if m.End != 0 {
+		n += 1 + sovRpc(uint64(m.End))
+	}
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LabelNamesResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Names) > 0 {
+		for _, s := range m.Names {
+			l = len(s)
 			n += 1 + l + sovRpc(uint64(l))
 		}
 	}
@@ -2067,105 +3172,278 @@ func (m *LabelValuesResponse) Size() (n int) {
 	return n
 }
 
-func sovRpc(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozRpc(x uint64) (n int) {
-	return sovRpc(uint64((x << 1) ^ uint64((int64(x) >> 63))))
-}
-func (this *SeriesRequest) String() string {
-	if this == nil {
-		return "nil"
+func (m *LabelNamesCardinalityRequest) Size() (n int) {
+	if m == nil {
+		return 0
 	}
-	repeatedStringForMatchers := "[]LabelMatcher{"
-	for _, f := range this.Matchers {
-		repeatedStringForMatchers += fmt.Sprintf("%v", f) + ","
+	var l int
+	_ = l
+	if m.Start != 0 {
+		n += 1 + sovRpc(uint64(m.Start))
 	}
-	repeatedStringForMatchers += "}"
-	s := strings.Join([]string{`&SeriesRequest{`,
-		`MinTime:` + fmt.Sprintf("%v", this.MinTime) + `,`,
-		`MaxTime:` + fmt.Sprintf("%v", this.MaxTime) + `,`,
-		`Matchers:` + repeatedStringForMatchers + `,`,
-		`SkipChunks:` + fmt.Sprintf("%v", this.SkipChunks) + `,`,
-		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
-		`StreamingChunksBatchSize:` + fmt.Sprintf("%v", this.StreamingChunksBatchSize) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func (this *Stats) String() string {
-	if this == nil {
-		return "nil"
+	if m.End != 0 {
+		n += 1 + sovRpc(uint64(m.End))
 	}
-	s := strings.Join([]string{`&Stats{`,
-		`FetchedIndexBytes:` + fmt.Sprintf("%v", this.FetchedIndexBytes) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func (this *SeriesResponse) String() string {
-	if this == nil {
-		return "nil"
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
 	}
-	s := strings.Join([]string{`&SeriesResponse{`,
-		`Result:` + fmt.Sprintf("%v", this.Result) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func (this *SeriesResponse_Series) String() string {
-	if this == nil {
-		return "nil"
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
 	}
-	s := strings.Join([]string{`&SeriesResponse_Series{`,
-		`Series:` + strings.Replace(fmt.Sprintf("%v", this.Series), "Series", "Series", 1) + `,`,
-		`}`,
-	}, "")
-	return s
+	return n
 }
-func (this *SeriesResponse_Warning) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *LabelNamesCardinalityResponse) Size() (n int) {
+	if m == nil {
+		return 0
 	}
-	s := strings.Join([]string{`&SeriesResponse_Warning{`,
-		`Warning:` + fmt.Sprintf("%v", this.Warning) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func (this *SeriesResponse_Hints) String() string {
-	if this == nil {
-		return "nil"
+	var l int
+	_ = l
+	if len(m.Items) > 0 {
+		for _, e := range m.Items {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
 	}
-	s := strings.Join([]string{`&SeriesResponse_Hints{`,
-		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func (this *SeriesResponse_Stats) String() string {
-	if this == nil {
-		return "nil"
+	if len(m.Warnings) > 0 {
+		for _, s := range m.Warnings {
+			l = len(s)
+			n += 1 + l + sovRpc(uint64(l))
+		}
 	}
-	s := strings.Join([]string{`&SeriesResponse_Stats{`,
-		`Stats:` + strings.Replace(fmt.Sprintf("%v", this.Stats), "Stats", "Stats", 1) + `,`,
-		`}`,
-	}, "")
-	return s
-}
-func (this *SeriesResponse_StreamingSeries) String() string {
-	if this == nil {
-		return "nil"
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
 	}
-	s := strings.Join([]string{`&SeriesResponse_StreamingSeries{`,
-		`StreamingSeries:` + strings.Replace(fmt.Sprintf("%v", this.StreamingSeries), "StreamingSeriesBatch", "StreamingSeriesBatch", 1) + `,`,
-		`}`,
-	}, "")
-	return s
+	return n
 }
-func (this *SeriesResponse_StreamingChunks) String() string {
-	if this == nil {
-		return "nil"
+
+func (m *LabelNameValues) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.LabelName)
+	if l > 0 {
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	if len(m.Values) > 0 {
+		for _, s := range m.Values {
+			l = len(s)
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LabelValuesCardinalityRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Start != 0 {
+		n += 1 + sovRpc(uint64(m.Start))
+	}
+	if m.End != 0 {
+		n += 1 + sovRpc(uint64(m.End))
+	}
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if len(m.LabelNames) > 0 {
+		for _, s := range m.LabelNames {
+			l = len(s)
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LabelValuesCardinalityResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Groups) > 0 {
+		for _, e := range m.Groups {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if len(m.Warnings) > 0 {
+		for _, s := range m.Warnings {
+			l = len(s)
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	if m.Hints != nil {
+		l = m.Hints.Size()
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	return n
+}
+
+func (m *LabelValuesCardinalityGroup) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.MinTime != 0 {
+		n += 1 + sovRpc(uint64(m.MinTime))
+	}
+	if m.MaxTime != 0 {
+		n += 1 + sovRpc(uint64(m.MaxTime))
+	}
+	if m.Replicated {
+		n += 2
+	}
+	if m.SeriesCount != 0 {
+		n += 1 + sovRpc(uint64(m.SeriesCount))
+	}
+	if len(m.Items) > 0 {
+		for _, e := range m.Items {
+			l = e.Size()
+			n += 1 + l + sovRpc(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *LabelValueSeriesCount) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.LabelName)
+	if l > 0 {
+		n += 1 + l + sovRpc(uint64(l))
+	}
+	if len(m.LabelValueSeries) > 0 {
+		for k, v := range m.LabelValueSeries {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovRpc(uint64(len(k))) + 1 + sovRpc(uint64(v))
+			n += mapEntrySize + 1 + sovRpc(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func sovRpc(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozRpc(x uint64) (n int) {
+	return sovRpc(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *SeriesRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]LabelMatcher{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&SeriesRequest{`,
+		`MinTime:` + fmt.Sprintf("%v", this.MinTime) + `,`,
+		`MaxTime:` + fmt.Sprintf("%v", this.MaxTime) + `,`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`SkipChunks:` + fmt.Sprintf("%v", this.SkipChunks) + `,`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`StreamingChunksBatchSize:` + fmt.Sprintf("%v", this.StreamingChunksBatchSize) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *Stats) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&Stats{`,
+		`FetchedIndexBytes:` + fmt.Sprintf("%v", this.FetchedIndexBytes) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SeriesResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SeriesResponse{`,
+		`Result:` + fmt.Sprintf("%v", this.Result) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SeriesResponse_Series) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SeriesResponse_Series{`,
+		`Series:` + strings.Replace(fmt.Sprintf("%v", this.Series), "Series", "Series", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SeriesResponse_Warning) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SeriesResponse_Warning{`,
+		`Warning:` + fmt.Sprintf("%v", this.Warning) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SeriesResponse_Hints) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SeriesResponse_Hints{`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SeriesResponse_Stats) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SeriesResponse_Stats{`,
+		`Stats:` + strings.Replace(fmt.Sprintf("%v", this.Stats), "Stats", "Stats", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SeriesResponse_StreamingSeries) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&SeriesResponse_StreamingSeries{`,
+		`StreamingSeries:` + strings.Replace(fmt.Sprintf("%v", this.StreamingSeries), "StreamingSeriesBatch", "StreamingSeriesBatch", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *SeriesResponse_StreamingChunks) String() string {
+	if this == nil {
+		return "nil"
 	}
 	s := strings.Join([]string{`&SeriesResponse_StreamingChunks{`,
 		`StreamingChunks:` + strings.Replace(fmt.Sprintf("%v", this.StreamingChunks), "StreamingChunksBatch", "StreamingChunksBatch", 1) + `,`,
@@ -2244,15 +3522,1361 @@ func (this *LabelValuesResponse) String() string {
 	}, "")
 	return s
 }
-func valueToStringRpc(v interface{}) string {
-	rv := reflect.ValueOf(v)
-	if rv.IsNil() {
+func (this *LabelNamesCardinalityRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]LabelMatcher{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&LabelNamesCardinalityRequest{`,
+		`Start:` + fmt.Sprintf("%v", this.Start) + `,`,
+		`End:` + fmt.Sprintf("%v", this.End) + `,`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelNamesCardinalityResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForItems := "[]*LabelNameValues{"
+	for _, f := range this.Items {
+		repeatedStringForItems += strings.Replace(f.String(), "LabelNameValues", "LabelNameValues", 1) + ","
+	}
+	repeatedStringForItems += "}"
+	s := strings.Join([]string{`&LabelNamesCardinalityResponse{`,
+		`Items:` + repeatedStringForItems + `,`,
+		`Warnings:` + fmt.Sprintf("%v", this.Warnings) + `,`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelNameValues) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&LabelNameValues{`,
+		`LabelName:` + fmt.Sprintf("%v", this.LabelName) + `,`,
+		`Values:` + fmt.Sprintf("%v", this.Values) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelValuesCardinalityRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForMatchers := "[]LabelMatcher{"
+	for _, f := range this.Matchers {
+		repeatedStringForMatchers += fmt.Sprintf("%v", f) + ","
+	}
+	repeatedStringForMatchers += "}"
+	s := strings.Join([]string{`&LabelValuesCardinalityRequest{`,
+		`Start:` + fmt.Sprintf("%v", this.Start) + `,`,
+		`End:` + fmt.Sprintf("%v", this.End) + `,`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`Matchers:` + repeatedStringForMatchers + `,`,
+		`LabelNames:` + fmt.Sprintf("%v", this.LabelNames) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelValuesCardinalityResponse) String() string {
+	if this == nil {
 		return "nil"
 	}
-	pv := reflect.Indirect(rv).Interface()
-	return fmt.Sprintf("*%v", pv)
+	repeatedStringForGroups := "[]LabelValuesCardinalityGroup{"
+	for _, f := range this.Groups {
+		repeatedStringForGroups += strings.Replace(strings.Replace(f.String(), "LabelValuesCardinalityGroup", "LabelValuesCardinalityGroup", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForGroups += "}"
+	s := strings.Join([]string{`&LabelValuesCardinalityResponse{`,
+		`Groups:` + repeatedStringForGroups + `,`,
+		`Warnings:` + fmt.Sprintf("%v", this.Warnings) + `,`,
+		`Hints:` + strings.Replace(fmt.Sprintf("%v", this.Hints), "Any", "types.Any", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelValuesCardinalityGroup) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForItems := "[]LabelValueSeriesCount{"
+	for _, f := range this.Items {
+		repeatedStringForItems += strings.Replace(strings.Replace(f.String(), "LabelValueSeriesCount", "LabelValueSeriesCount", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForItems += "}"
+	s := strings.Join([]string{`&LabelValuesCardinalityGroup{`,
+		`MinTime:` + fmt.Sprintf("%v", this.MinTime) + `,`,
+		`MaxTime:` + fmt.Sprintf("%v", this.MaxTime) + `,`,
+		`Replicated:` + fmt.Sprintf("%v", this.Replicated) + `,`,
+		`SeriesCount:` + fmt.Sprintf("%v", this.SeriesCount) + `,`,
+		`Items:` + repeatedStringForItems + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *LabelValueSeriesCount) String() string {
+	if this == nil {
+		return "nil"
+	}
+	keysForLabelValueSeries := make([]string, 0, len(this.LabelValueSeries))
+	for k, _ := range this.LabelValueSeries {
+		keysForLabelValueSeries = append(keysForLabelValueSeries, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForLabelValueSeries)
+	mapStringForLabelValueSeries := "map[string]uint64{"
+	for _, k := range keysForLabelValueSeries {
+		mapStringForLabelValueSeries += fmt.Sprintf("%v: %v,", k, this.LabelValueSeries[k])
+	}
+	mapStringForLabelValueSeries += "}"
+	s := strings.Join([]string{`&LabelValueSeriesCount{`,
+		`LabelName:` + fmt.Sprintf("%v", this.LabelName) + `,`,
+		`LabelValueSeries:` + mapStringForLabelValueSeries + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringRpc(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SeriesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SeriesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MinTime", wireType)
+			}
+			m.MinTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MinTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxTime", wireType)
+			}
+			m.MaxTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SkipChunks", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.SkipChunks = bool(v != 0)
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Hints == nil {
+				m.Hints = &types.Any{}
+			}
+			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 100:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StreamingChunksBatchSize", wireType)
+			}
+			m.StreamingChunksBatchSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StreamingChunksBatchSize |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Stats) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Stats: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Stats: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FetchedIndexBytes", wireType)
+			}
+			m.FetchedIndexBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FetchedIndexBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SeriesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SeriesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Series", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &Series{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Result = &SeriesResponse_Series{v}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Warning", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Result = &SeriesResponse_Warning{string(dAtA[iNdEx:postIndex])}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &types.Any{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Result = &SeriesResponse_Hints{v}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Stats", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &Stats{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Result = &SeriesResponse_Stats{v}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StreamingSeries", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &StreamingSeriesBatch{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Result = &SeriesResponse_StreamingSeries{v}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StreamingChunks", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &StreamingChunksBatch{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Result = &SeriesResponse_StreamingChunks{v}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StreamingChunksEstimate", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &StreamingChunksEstimate{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Result = &SeriesResponse_StreamingChunksEstimate{v}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelNamesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelNamesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+			}
+			m.Start = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Start |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+			}
+			m.End = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.End |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Hints == nil {
+				m.Hints = &types.Any{}
+			}
+			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelNamesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelNamesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Names", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Names = append(m.Names, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Warnings", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Warnings = append(m.Warnings, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Hints == nil {
+				m.Hints = &types.Any{}
+			}
+			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelValuesRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelValuesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Label", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Label = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+			}
+			m.Start = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Start |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+			}
+			m.End = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.End |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Hints == nil {
+				m.Hints = &types.Any{}
+			}
+			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelValuesResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelValuesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Values = append(m.Values, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Warnings", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Warnings = append(m.Warnings, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Hints == nil {
+				m.Hints = &types.Any{}
+			}
+			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
 }
-func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
+func (m *LabelNamesCardinalityRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2275,17 +4899,17 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SeriesRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelNamesCardinalityRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SeriesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelNamesCardinalityRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field MinTime", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
 			}
-			m.MinTime = 0
+			m.Start = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2295,16 +4919,16 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.MinTime |= int64(b&0x7F) << shift
+				m.Start |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field MaxTime", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
 			}
-			m.MaxTime = 0
+			m.End = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2314,14 +4938,14 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.MaxTime |= int64(b&0x7F) << shift
+				m.End |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2348,34 +4972,16 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Matchers = append(m.Matchers, LabelMatcher{})
-			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.Hints == nil {
+				m.Hints = &types.Any{}
+			}
+			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 8:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SkipChunks", wireType)
-			}
-			var v int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowRpc
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				v |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			m.SkipChunks = bool(v != 0)
-		case 9:
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2402,114 +5008,18 @@ func (m *SeriesRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Hints == nil {
-				m.Hints = &types.Any{}
-			}
-			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Matchers = append(m.Matchers, LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 100:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StreamingChunksBatchSize", wireType)
-			}
-			m.StreamingChunksBatchSize = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowRpc
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.StreamingChunksBatchSize |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		default:
-			iNdEx = preIndex
-			skippy, err := skipRpc(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthRpc
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthRpc
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *Stats) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowRpc
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: Stats: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Stats: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FetchedIndexBytes", wireType)
-			}
-			m.FetchedIndexBytes = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowRpc
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.FetchedIndexBytes |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRpc(dAtA[iNdEx:])
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthRpc
-			}
-			if (iNdEx + skippy) < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthRpc
 			}
 			if (iNdEx + skippy) > l {
@@ -2524,7 +5034,7 @@ func (m *Stats) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
+func (m *LabelNamesCardinalityResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2547,15 +5057,15 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SeriesResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelNamesCardinalityResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SeriesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelNamesCardinalityResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Series", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Items", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -2582,15 +5092,14 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &Series{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Items = append(m.Items, &LabelNameValues{})
+			if err := m.Items[len(m.Items)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			m.Result = &SeriesResponse_Series{v}
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Warning", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Warnings", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -2618,7 +5127,7 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Result = &SeriesResponse_Warning{string(dAtA[iNdEx:postIndex])}
+			m.Warnings = append(m.Warnings, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
 		case 3:
 			if wireType != 2 {
@@ -2649,87 +5158,68 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &types.Any{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			m.Result = &SeriesResponse_Hints{v}
-			iNdEx = postIndex
-		case 4:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Stats", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowRpc
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if msglen < 0 {
-				return ErrInvalidLengthRpc
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthRpc
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+			if m.Hints == nil {
+				m.Hints = &types.Any{}
 			}
-			v := &Stats{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			m.Result = &SeriesResponse_Stats{v}
 			iNdEx = postIndex
-		case 5:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StreamingSeries", wireType)
-			}
-			var msglen int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowRpc
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				msglen |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			if msglen < 0 {
-				return ErrInvalidLengthRpc
+		default:
+			iNdEx = preIndex
+			skippy, err := skipRpc(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthRpc
 			}
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &StreamingSeriesBatch{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LabelNameValues) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowRpc
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
 			}
-			m.Result = &SeriesResponse_StreamingSeries{v}
-			iNdEx = postIndex
-		case 6:
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelNameValues: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelNameValues: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StreamingChunks", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelName", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2739,32 +5229,29 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthRpc
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthRpc
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &StreamingChunksBatch{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			m.Result = &SeriesResponse_StreamingChunks{v}
+			m.LabelName = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 7:
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StreamingChunksEstimate", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -2774,26 +5261,23 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthRpc
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthRpc
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &StreamingChunksEstimate{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			m.Result = &SeriesResponse_StreamingChunksEstimate{v}
+			m.Values = append(m.Values, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -2801,10 +5285,7 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthRpc
-			}
-			if (iNdEx + skippy) < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthRpc
 			}
 			if (iNdEx + skippy) > l {
@@ -2819,7 +5300,7 @@ func (m *SeriesResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
+func (m *LabelValuesCardinalityRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2842,13 +5323,13 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelNamesRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelValuesCardinalityRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelNamesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelValuesCardinalityRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 3:
+		case 1:
 			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
 			}
@@ -2867,7 +5348,7 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-		case 4:
+		case 2:
 			if wireType != 0 {
 				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
 			}
@@ -2886,7 +5367,7 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-		case 5:
+		case 3:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
 			}
@@ -2922,7 +5403,7 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
-		case 6:
+		case 4:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
 			}
@@ -2956,16 +5437,45 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelNames", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRpc
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthRpc
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthRpc
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.LabelNames = append(m.LabelNames, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRpc(dAtA[iNdEx:])
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthRpc
-			}
-			if (iNdEx + skippy) < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthRpc
 			}
 			if (iNdEx + skippy) > l {
@@ -2980,7 +5490,7 @@ func (m *LabelNamesRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
+func (m *LabelValuesCardinalityResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3003,17 +5513,17 @@ func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelNamesResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelValuesCardinalityResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelNamesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelValuesCardinalityResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Names", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Groups", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -3023,23 +5533,25 @@ func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthRpc
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthRpc
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Names = append(m.Names, string(dAtA[iNdEx:postIndex]))
+			m.Groups = append(m.Groups, LabelValuesCardinalityGroup{})
+			if err := m.Groups[len(m.Groups)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
@@ -3115,10 +5627,7 @@ func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthRpc
-			}
-			if (iNdEx + skippy) < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthRpc
 			}
 			if (iNdEx + skippy) > l {
@@ -3133,7 +5642,7 @@ func (m *LabelNamesResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
+func (m *LabelValuesCardinalityGroup) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3156,17 +5665,17 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelValuesRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelValuesCardinalityGroup: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelValuesRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelValuesCardinalityGroup: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Label", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MinTime", wireType)
 			}
-			var stringLen uint64
+			m.MinTime = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -3176,29 +5685,16 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.MinTime |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthRpc
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthRpc
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Label = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 4:
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxTime", wireType)
 			}
-			m.Start = 0
+			m.MaxTime = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -3208,16 +5704,16 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Start |= int64(b&0x7F) << shift
+				m.MaxTime |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 5:
+		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Replicated", wireType)
 			}
-			m.End = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -3227,16 +5723,17 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.End |= int64(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 6:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+			m.Replicated = bool(v != 0)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesCount", wireType)
 			}
-			var msglen int
+			m.SeriesCount = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowRpc
@@ -3246,31 +5743,14 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.SeriesCount |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthRpc
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthRpc
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.Hints == nil {
-				m.Hints = &types.Any{}
-			}
-			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		case 7:
+		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Items", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3297,8 +5777,8 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Matchers = append(m.Matchers, LabelMatcher{})
-			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Items = append(m.Items, LabelValueSeriesCount{})
+			if err := m.Items[len(m.Items)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -3308,10 +5788,7 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 			if err != nil {
 				return err
 			}
-			if skippy < 0 {
-				return ErrInvalidLengthRpc
-			}
-			if (iNdEx + skippy) < 0 {
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
 				return ErrInvalidLengthRpc
 			}
 			if (iNdEx + skippy) > l {
@@ -3326,7 +5803,7 @@ func (m *LabelValuesRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
+func (m *LabelValueSeriesCount) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -3349,15 +5826,15 @@ func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelValuesResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: LabelValueSeriesCount: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelValuesResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: LabelValueSeriesCount: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelName", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -3385,43 +5862,11 @@ func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Values = append(m.Values, string(dAtA[iNdEx:postIndex]))
+			m.LabelName = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Warnings", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowRpc
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthRpc
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthRpc
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Warnings = append(m.Warnings, string(dAtA[iNdEx:postIndex]))
-			iNdEx = postIndex
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Hints", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelValueSeries", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3448,12 +5893,89 @@ func (m *LabelValuesResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Hints == nil {
-				m.Hints = &types.Any{}
+			if m.LabelValueSeries == nil {
+				m.LabelValueSeries = make(map[string]uint64)
 			}
-			if err := m.Hints.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			var mapkey string
+			var mapvalue uint64
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowRpc
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowRpc
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthRpc
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthRpc
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowRpc
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipRpc(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthRpc
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
 			}
+			m.LabelValueSeries[mapkey] = mapvalue
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
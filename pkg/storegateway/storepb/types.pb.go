@@ -34,18 +34,22 @@ const (
 	Chunk_XOR            Chunk_Encoding = 0
 	Chunk_Histogram      Chunk_Encoding = 1
 	Chunk_FloatHistogram Chunk_Encoding = 2
+	// Chunk_Downsampled is a chunk of a downsampled block, holding the aggregates of the series at the block resolution.
+	Chunk_Downsampled Chunk_Encoding = 3
 )
 
 var Chunk_Encoding_name = map[int32]string{
 	0: "Chunk_XOR",
 	1: "Chunk_Histogram",
 	2: "Chunk_FloatHistogram",
+	3: "Chunk_Downsampled",
 }
 
 var Chunk_Encoding_value = map[string]int32{
 	"Chunk_XOR":            0,
 	"Chunk_Histogram":      1,
 	"Chunk_FloatHistogram": 2,
+	"Chunk_Downsampled":    3,
 }
 
 func (Chunk_Encoding) EnumDescriptor() ([]byte, []int) {
@@ -429,52 +433,53 @@ func init() {
 func init() { proto.RegisterFile("types.proto", fileDescriptor_d938547f84707355) }
 
 var fileDescriptor_d938547f84707355 = []byte{
-	// 711 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x54, 0xcd, 0x6e, 0xd3, 0x4c,
-	0x14, 0xf5, 0x24, 0x4e, 0xe2, 0x4c, 0xda, 0xaf, 0xfe, 0x26, 0x81, 0xa6, 0x5d, 0xb8, 0xc1, 0xab,
-	0x08, 0xa9, 0x0e, 0x84, 0x6e, 0x90, 0xd8, 0x34, 0x55, 0xa0, 0x44, 0x94, 0xb6, 0x6e, 0x91, 0x10,
-	0x42, 0xb2, 0x26, 0xc9, 0xc4, 0x19, 0x35, 0xfe, 0x91, 0x67, 0x02, 0xc9, 0x02, 0xa9, 0x8f, 0xc0,
-	0x2b, 0xb0, 0xe3, 0x45, 0x90, 0xba, 0xa3, 0xcb, 0x8a, 0x45, 0x45, 0xd2, 0x0d, 0xcb, 0x3e, 0x02,
-	0xf2, 0x8c, 0x53, 0xd2, 0x76, 0x53, 0x36, 0xac, 0x3c, 0xf7, 0x9e, 0x73, 0xef, 0x39, 0x77, 0x34,
-	0xd7, 0xb0, 0xc0, 0xc7, 0x21, 0x61, 0x56, 0x18, 0x05, 0x3c, 0x40, 0x59, 0xde, 0xc7, 0x7e, 0xc0,
-	0x56, 0xd7, 0x5d, 0xca, 0xfb, 0xc3, 0xb6, 0xd5, 0x09, 0xbc, 0x9a, 0x1b, 0xb8, 0x41, 0x4d, 0xc0,
-	0xed, 0x61, 0x4f, 0x44, 0x22, 0x10, 0x27, 0x59, 0xb6, 0xfa, 0x68, 0x9e, 0x1e, 0xe1, 0x1e, 0xf6,
-	0x71, 0xcd, 0xa3, 0x1e, 0x8d, 0x6a, 0xe1, 0x91, 0x2b, 0x4f, 0x61, 0x5b, 0x7e, 0x65, 0x85, 0xf9,
-	0x1d, 0xc0, 0xcc, 0x56, 0x7f, 0xe8, 0x1f, 0xa1, 0x87, 0x50, 0x8d, 0x1d, 0x94, 0x41, 0x05, 0x54,
-	0xff, 0xab, 0xdf, 0xb7, 0xa4, 0x03, 0x4b, 0x80, 0x56, 0xd3, 0xef, 0x04, 0x5d, 0xea, 0xbb, 0xb6,
-	0xe0, 0xa0, 0x3d, 0xa8, 0x76, 0x31, 0xc7, 0xe5, 0x54, 0x05, 0x54, 0x17, 0x1a, 0xcf, 0x4e, 0xce,
-	0xd7, 0x94, 0x1f, 0xe7, 0x6b, 0x1b, 0x77, 0x51, 0xb7, 0xde, 0xf8, 0x0c, 0xf7, 0x48, 0x63, 0xcc,
-	0xc9, 0xc1, 0x80, 0x76, 0x88, 0x2d, 0x3a, 0x99, 0xdb, 0x50, 0x9b, 0x69, 0xa0, 0x45, 0x98, 0x17,
-	0xaa, 0xce, 0xdb, 0x5d, 0x5b, 0x57, 0x50, 0x11, 0x2e, 0xc9, 0x70, 0x9b, 0x32, 0x1e, 0xb8, 0x11,
-	0xf6, 0x74, 0x80, 0xca, 0xb0, 0x24, 0x93, 0xcf, 0x07, 0x01, 0xe6, 0x7f, 0x90, 0x94, 0xf9, 0x05,
-	0xc0, 0xec, 0x01, 0x89, 0x28, 0x61, 0xa8, 0x07, 0xb3, 0x03, 0xdc, 0x26, 0x03, 0x56, 0x06, 0x95,
-	0x74, 0xb5, 0x50, 0x2f, 0x5a, 0x9d, 0x20, 0xe2, 0x64, 0x14, 0xb6, 0xad, 0x57, 0x71, 0x7e, 0x0f,
-	0xd3, 0xa8, 0xf1, 0x34, 0x71, 0xff, 0xf8, 0x4e, 0xee, 0x45, 0xdd, 0x66, 0x17, 0x87, 0x9c, 0x44,
-	0x76, 0xd2, 0x1d, 0xd5, 0x60, 0xb6, 0x13, 0x9b, 0x61, 0xe5, 0x94, 0xd0, 0xf9, 0x7f, 0x76, 0x79,
-	0x9b, 0xae, 0x1b, 0x09, 0x9b, 0x0d, 0x35, 0x56, 0xb1, 0x13, 0x9a, 0x39, 0x86, 0x4b, 0x07, 0x3c,
-	0x22, 0xd8, 0xa3, 0xbe, 0xfb, 0x6f, 0xbd, 0x9a, 0x9f, 0x60, 0xe9, 0x86, 0x74, 0x03, 0xf3, 0x4e,
-	0x3f, 0x9e, 0x81, 0x89, 0x30, 0xd1, 0x5f, 0x9e, 0xcd, 0x70, 0x83, 0x6d, 0x27, 0x34, 0xb4, 0x01,
-	0x97, 0x29, 0x73, 0x88, 0xdf, 0x75, 0x82, 0x9e, 0x23, 0x73, 0x0e, 0x13, 0x5c, 0xf1, 0x2c, 0x34,
-	0xbb, 0x48, 0x59, 0xd3, 0xef, 0xee, 0xf6, 0x64, 0x9d, 0x6c, 0x63, 0x92, 0xb9, 0xc9, 0xc5, 0xcd,
-	0x30, 0xf4, 0x00, 0x2e, 0x24, 0xe5, 0xd4, 0xef, 0x92, 0x91, 0x78, 0x80, 0xaa, 0x5d, 0x90, 0xb9,
-	0x97, 0x71, 0xea, 0xef, 0x2f, 0xf8, 0xc5, 0xdc, 0x94, 0x52, 0xe6, 0xae, 0x53, 0x4a, 0xf6, 0x6c,
-	0x4a, 0x73, 0x07, 0x2e, 0xdf, 0x80, 0x9a, 0x8c, 0x53, 0x0f, 0x73, 0x82, 0xea, 0xf0, 0x1e, 0x49,
-	0xce, 0x5d, 0x47, 0xe8, 0x3a, 0x9d, 0x60, 0xe8, 0xf3, 0x64, 0x80, 0xe2, 0x15, 0x28, 0xea, 0xb6,
-	0x62, 0xc8, 0x3c, 0x06, 0x30, 0x7f, 0xe5, 0x19, 0xad, 0x40, 0xcd, 0xa3, 0xbe, 0xc3, 0xa9, 0x27,
-	0xd7, 0x2e, 0x6d, 0xe7, 0x3c, 0xea, 0x1f, 0x52, 0x8f, 0x08, 0x08, 0x8f, 0x24, 0x94, 0x4a, 0x20,
-	0x3c, 0x12, 0xd0, 0x1a, 0x4c, 0x47, 0xf8, 0x63, 0x39, 0x5d, 0x01, 0xd5, 0x42, 0x7d, 0xf1, 0xda,
-	0x9e, 0xda, 0x31, 0xd2, 0x52, 0x35, 0x55, 0xcf, 0xb4, 0x54, 0x2d, 0xa3, 0x67, 0x5b, 0xaa, 0x96,
-	0xd5, 0x73, 0x2d, 0x55, 0xcb, 0xe9, 0x5a, 0x4b, 0xd5, 0x34, 0x3d, 0x6f, 0x7e, 0x03, 0x70, 0x41,
-	0xbc, 0x8c, 0x9d, 0xf8, 0x46, 0x48, 0x84, 0xd6, 0xaf, 0x2d, 0xfe, 0xca, 0xac, 0xe1, 0x3c, 0xc7,
-	0x3a, 0x1c, 0x87, 0x24, 0xd9, 0x7d, 0x04, 0x55, 0x1f, 0x27, 0xae, 0xf2, 0xb6, 0x38, 0xa3, 0x12,
-	0xcc, 0x7c, 0xc0, 0x83, 0x21, 0x11, 0xa6, 0xf2, 0xb6, 0x0c, 0xcc, 0xf7, 0x50, 0x8d, 0xeb, 0xe2,
-	0x05, 0x9e, 0x6f, 0xe6, 0x34, 0xf7, 0x75, 0x05, 0x95, 0xa0, 0x7e, 0x2d, 0xf9, 0xba, 0xb9, 0xaf,
-	0x83, 0x5b, 0x54, 0xbb, 0xa9, 0xa7, 0x6e, 0x53, 0xed, 0xa6, 0x9e, 0x6e, 0x6c, 0x9e, 0x4c, 0x0c,
-	0xe5, 0x74, 0x62, 0x28, 0x67, 0x13, 0x43, 0xb9, 0x9c, 0x18, 0xe0, 0x78, 0x6a, 0x80, 0xaf, 0x53,
-	0x03, 0x9c, 0x4c, 0x0d, 0x70, 0x3a, 0x35, 0xc0, 0xcf, 0xa9, 0x01, 0x7e, 0x4d, 0x0d, 0xe5, 0x72,
-	0x6a, 0x80, 0xcf, 0x17, 0x86, 0x72, 0x7a, 0x61, 0x28, 0x67, 0x17, 0x86, 0xf2, 0x2e, 0xc7, 0x78,
-	0x10, 0x91, 0xb0, 0xdd, 0xce, 0x8a, 0x7f, 0xe0, 0x93, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0xb0,
-	0x89, 0xf9, 0xfc, 0x7b, 0x05, 0x00, 0x00,
+	// 723 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x54, 0xcd, 0x4e, 0xdb, 0x40,
+	0x10, 0xf6, 0x26, 0x4e, 0xe2, 0x6c, 0xa0, 0x98, 0x4d, 0x28, 0x81, 0x83, 0x49, 0x7d, 0x8a, 0x2a,
+	0xe1, 0xb4, 0x29, 0x97, 0x4a, 0xbd, 0x10, 0x9a, 0xfe, 0x44, 0xa5, 0x80, 0xa1, 0x52, 0x55, 0x55,
+	0xb2, 0x36, 0xf1, 0xc6, 0x59, 0x11, 0xff, 0xc8, 0xde, 0x94, 0xe4, 0x50, 0x89, 0x47, 0xe8, 0x2b,
+	0xf4, 0xd6, 0x17, 0xa9, 0xc4, 0x91, 0x23, 0xea, 0x01, 0x35, 0x41, 0xaa, 0x7a, 0xe4, 0x11, 0x2a,
+	0xef, 0x3a, 0x34, 0xc0, 0x85, 0x5e, 0x7a, 0xf2, 0xce, 0x7c, 0xdf, 0xcc, 0x37, 0xdf, 0xc8, 0xbb,
+	0xb0, 0xc0, 0x46, 0x01, 0x89, 0x8c, 0x20, 0xf4, 0x99, 0x8f, 0xb2, 0xac, 0x87, 0x3d, 0x3f, 0x5a,
+	0x5d, 0x77, 0x28, 0xeb, 0x0d, 0xda, 0x46, 0xc7, 0x77, 0x6b, 0x8e, 0xef, 0xf8, 0x35, 0x0e, 0xb7,
+	0x07, 0x5d, 0x1e, 0xf1, 0x80, 0x9f, 0x44, 0xd9, 0xea, 0xa3, 0x59, 0x7a, 0x88, 0xbb, 0xd8, 0xc3,
+	0x35, 0x97, 0xba, 0x34, 0xac, 0x05, 0x87, 0x8e, 0x38, 0x05, 0x6d, 0xf1, 0x15, 0x15, 0xfa, 0x2f,
+	0x00, 0x33, 0x5b, 0xbd, 0x81, 0x77, 0x88, 0x1e, 0x42, 0x39, 0x9e, 0xa0, 0x0c, 0x2a, 0xa0, 0x7a,
+	0xaf, 0x7e, 0xdf, 0x10, 0x13, 0x18, 0x1c, 0x34, 0x9a, 0x5e, 0xc7, 0xb7, 0xa9, 0xe7, 0x98, 0x9c,
+	0x83, 0x76, 0xa1, 0x6c, 0x63, 0x86, 0xcb, 0xa9, 0x0a, 0xa8, 0xce, 0x35, 0x9e, 0x9d, 0x9c, 0xaf,
+	0x49, 0x3f, 0xce, 0xd7, 0x36, 0xee, 0xa2, 0x6e, 0xbc, 0xf3, 0x22, 0xdc, 0x25, 0x8d, 0x11, 0x23,
+	0xfb, 0x7d, 0xda, 0x21, 0x26, 0xef, 0xa4, 0x5b, 0x50, 0x99, 0x6a, 0xa0, 0x79, 0x98, 0xe7, 0xaa,
+	0xd6, 0xfb, 0x1d, 0x53, 0x95, 0x50, 0x11, 0x2e, 0x88, 0xf0, 0x15, 0x8d, 0x98, 0xef, 0x84, 0xd8,
+	0x55, 0x01, 0x2a, 0xc3, 0x92, 0x48, 0xbe, 0xe8, 0xfb, 0x98, 0xfd, 0x45, 0x52, 0x68, 0x09, 0x2e,
+	0x0a, 0xe4, 0xb9, 0x7f, 0xe4, 0x45, 0xd8, 0x0d, 0xfa, 0xc4, 0x56, 0xd3, 0xfa, 0x57, 0x00, 0xb3,
+	0xfb, 0x24, 0xa4, 0x24, 0x42, 0x5d, 0x98, 0xed, 0xe3, 0x36, 0xe9, 0x47, 0x65, 0x50, 0x49, 0x57,
+	0x0b, 0xf5, 0xa2, 0xd1, 0xf1, 0x43, 0x46, 0x86, 0x41, 0xdb, 0x78, 0x13, 0xe7, 0x77, 0x31, 0x0d,
+	0x1b, 0x4f, 0x13, 0x53, 0x8f, 0xef, 0x64, 0x8a, 0xd7, 0x6d, 0xda, 0x38, 0x60, 0x24, 0x34, 0x93,
+	0xee, 0xa8, 0x06, 0xb3, 0x9d, 0x78, 0x92, 0xa8, 0x9c, 0xe2, 0x3a, 0x8b, 0xd3, 0x9d, 0x6e, 0x3a,
+	0x4e, 0xc8, 0x67, 0x6c, 0xc8, 0xb1, 0x8a, 0x99, 0xd0, 0xf4, 0x11, 0x5c, 0xd8, 0x67, 0x21, 0xc1,
+	0x2e, 0xf5, 0x9c, 0xff, 0x3b, 0xab, 0xfe, 0x19, 0x96, 0x6e, 0x48, 0x37, 0x30, 0xeb, 0xf4, 0x62,
+	0x0f, 0x11, 0x0f, 0x13, 0xfd, 0xe5, 0xa9, 0x87, 0x1b, 0x6c, 0x33, 0xa1, 0xa1, 0x0d, 0xb8, 0x4c,
+	0x23, 0x8b, 0x78, 0xb6, 0xe5, 0x77, 0x2d, 0x91, 0xb3, 0x22, 0xce, 0xe5, 0x7f, 0x8b, 0x62, 0x16,
+	0x69, 0xd4, 0xf4, 0xec, 0x9d, 0xae, 0xa8, 0x13, 0x6d, 0x74, 0x32, 0xe3, 0x9c, 0x6f, 0x26, 0x42,
+	0x0f, 0xe0, 0x5c, 0x52, 0x4e, 0x3d, 0x9b, 0x0c, 0xf9, 0x7f, 0x29, 0x9b, 0x05, 0x91, 0x7b, 0x1d,
+	0xa7, 0xfe, 0x7d, 0xc1, 0x2f, 0x67, 0x5c, 0x0a, 0x99, 0xbb, 0xba, 0x14, 0xec, 0xa9, 0x4b, 0x7d,
+	0x1b, 0x2e, 0xdf, 0x80, 0x9a, 0x11, 0xa3, 0x2e, 0x66, 0x04, 0xd5, 0xe1, 0x12, 0x49, 0xce, 0xb6,
+	0xc5, 0x75, 0xad, 0x8e, 0x3f, 0xf0, 0x58, 0x62, 0xa0, 0x78, 0x05, 0xf2, 0xba, 0xad, 0x18, 0xd2,
+	0x8f, 0x01, 0xcc, 0x5f, 0xcd, 0x8c, 0x56, 0xa0, 0xe2, 0x52, 0xcf, 0x62, 0xd4, 0x15, 0xb7, 0x31,
+	0x6d, 0xe6, 0x5c, 0xea, 0x1d, 0x50, 0x97, 0x70, 0x08, 0x0f, 0x05, 0x94, 0x4a, 0x20, 0x3c, 0xe4,
+	0xd0, 0x1a, 0x4c, 0x87, 0xf8, 0xa8, 0x9c, 0xae, 0x80, 0x6a, 0xa1, 0x3e, 0x7f, 0xed, 0xfa, 0x9a,
+	0x31, 0xd2, 0x92, 0x15, 0x59, 0xcd, 0xb4, 0x64, 0x25, 0xa3, 0x66, 0x5b, 0xb2, 0x92, 0x55, 0x73,
+	0x2d, 0x59, 0xc9, 0xa9, 0x4a, 0x4b, 0x56, 0x14, 0x35, 0xaf, 0x7f, 0x07, 0x70, 0x8e, 0xff, 0x19,
+	0xdb, 0xf1, 0x46, 0x48, 0x88, 0xd6, 0xaf, 0xbd, 0x07, 0x2b, 0xd3, 0x86, 0xb3, 0x1c, 0xe3, 0x60,
+	0x14, 0x90, 0xe4, 0x49, 0x40, 0x50, 0xf6, 0x70, 0x32, 0x55, 0xde, 0xe4, 0x67, 0x54, 0x82, 0x99,
+	0x4f, 0xb8, 0x3f, 0x20, 0x7c, 0xa8, 0xbc, 0x29, 0x02, 0xfd, 0x23, 0x94, 0xe3, 0xba, 0xf8, 0x5e,
+	0xcf, 0x36, 0xb3, 0x9a, 0x7b, 0xaa, 0x84, 0x4a, 0x50, 0xbd, 0x96, 0x7c, 0xdb, 0xdc, 0x53, 0xc1,
+	0x2d, 0xaa, 0xd9, 0x54, 0x53, 0xb7, 0xa9, 0x66, 0x53, 0x4d, 0x37, 0x36, 0x4f, 0xc6, 0x9a, 0x74,
+	0x3a, 0xd6, 0xa4, 0xb3, 0xb1, 0x26, 0x5d, 0x8e, 0x35, 0x70, 0x3c, 0xd1, 0xc0, 0xb7, 0x89, 0x06,
+	0x4e, 0x26, 0x1a, 0x38, 0x9d, 0x68, 0xe0, 0xe7, 0x44, 0x03, 0xbf, 0x27, 0x9a, 0x74, 0x39, 0xd1,
+	0xc0, 0x97, 0x0b, 0x4d, 0x3a, 0xbd, 0xd0, 0xa4, 0xb3, 0x0b, 0x4d, 0xfa, 0x90, 0x8b, 0x98, 0x1f,
+	0x92, 0xa0, 0xdd, 0xce, 0xf2, 0xa7, 0xf1, 0xc9, 0x9f, 0x01, 0x00, 0x4c, 0xb4, 0x03, 0xd0, 0x92,
+	0x05, 0x00, 0x00,
 }
 
 func (x Chunk_Encoding) String() string {
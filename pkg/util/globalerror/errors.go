@@ -54,6 +54,8 @@ const (
 	MaxQueryLength              ID = "max-query-length"
 	MaxTotalQueryLength         ID = "max-total-query-length"
 	MaxQueryExpressionSizeBytes ID = "max-query-expression-size-bytes"
+	MaxQueryResponseSizeBytes   ID = "max-query-response-size-bytes"
+	MaxQueryResponseSeries      ID = "max-query-response-series"
 	RequestRateLimited          ID = "tenant-max-request-rate"
 	IngestionRateLimited        ID = "tenant-max-ingestion-rate"
 	TooManyHAClusters           ID = "tenant-too-many-ha-clusters"
@@ -80,6 +80,12 @@ func NewQueryLimiter(maxSeriesPerQuery, maxChunkBytesPerQuery, maxChunksPerQuery
 	}
 }
 
+// WithSameLimits returns a new QueryLimiter enforcing the same limits, with no series or chunks accounted yet.
+// It allows to enforce the limits while receiving a response which may be discarded, without accounting it here.
+func (ql *QueryLimiter) WithSameLimits() *QueryLimiter {
+	return NewQueryLimiter(ql.maxSeriesPerQuery, ql.maxChunkBytesPerQuery, ql.maxChunksPerQuery, ql.maxEstimatedChunksPerQuery, ql.queryMetrics)
+}
+
 func AddQueryLimiterToContext(ctx context.Context, limiter *QueryLimiter) context.Context {
 	return context.WithValue(ctx, ctxKey, limiter)
 }
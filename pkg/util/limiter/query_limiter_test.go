@@ -171,6 +171,27 @@ func TestQueryLimiter_AddEstimatedChunks_IgnoresDisabledLimit(t *testing.T) {
 	assertRejectedQueriesMetricValue(t, reg, 0, 0, 0, 0)
 }
 
+func TestQueryLimiter_WithSameLimits(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	limiter := NewQueryLimiter(1, 0, 10, 0, stats.NewQueryMetrics(reg))
+	require.NoError(t, limiter.AddSeries(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "test_metric_1"))))
+	require.NoError(t, limiter.AddChunks(10))
+
+	// The new limiter enforces the same limits, without the series and chunks accounted in the original one.
+	other := limiter.WithSameLimits()
+	require.NoError(t, other.AddSeries(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "test_metric_2"))))
+	require.NoError(t, other.AddChunks(10))
+	assert.Equal(t, 1, other.uniqueSeriesCount())
+	assertRejectedQueriesMetricValue(t, reg, 0, 0, 0, 0)
+
+	require.Error(t, other.AddSeries(mimirpb.FromLabelsToLabelAdapters(labels.FromStrings(labels.MetricName, "test_metric_3"))))
+	require.Error(t, other.AddChunks(1))
+	assertRejectedQueriesMetricValue(t, reg, 1, 0, 1, 0)
+
+	// The original limiter is left untouched.
+	assert.Equal(t, 1, limiter.uniqueSeriesCount())
+}
+
 func BenchmarkQueryLimiter_AddSeries(b *testing.B) {
 	const (
 		metricName = "test_metric"
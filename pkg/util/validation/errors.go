@@ -30,6 +30,18 @@ func NewMaxQueryExpressionSizeBytesError(actualSizeBytes, maxQuerySizeBytes int)
 		maxQueryExpressionSizeBytesFlag))
 }
 
+func NewMaxQueryResponseSizeBytesError(maxResponseSizeBytes int) LimitError {
+	return LimitError(globalerror.MaxQueryResponseSizeBytes.MessageWithPerTenantLimitConfig(
+		fmt.Sprintf("the query response size in bytes exceeds the limit (limit: %d)", maxResponseSizeBytes),
+		maxQueryResponseSizeBytesFlag))
+}
+
+func NewMaxQueryResponseSeriesError(actualSeries, maxSeries int) LimitError {
+	return LimitError(globalerror.MaxQueryResponseSeries.MessageWithPerTenantLimitConfig(
+		fmt.Sprintf("the number of series in the query response exceeds the limit (series: %d, limit: %d)", actualSeries, maxSeries),
+		maxQueryResponseSeriesFlag))
+}
+
 func NewQueryBlockedError() LimitError {
 	return LimitError(globalerror.QueryBlocked.Message("the request has been blocked by the cluster administrator"))
 }
@@ -44,6 +44,10 @@ const (
 	maxPartialQueryLengthFlag                = "querier.max-partial-query-length"
 	maxTotalQueryLengthFlag                  = "query-frontend.max-total-query-length"
 	maxQueryExpressionSizeBytesFlag          = "query-frontend.max-query-expression-size-bytes"
+	queryLogSampleRateFlag                   = "query-frontend.query-log-sample-rate"
+	StoreGatewayHedgingLatencyPercentileFlag = "querier.store-gateway-hedging-latency-percentile"
+	maxQueryResponseSizeBytesFlag            = "query-frontend.max-query-response-size-bytes"
+	maxQueryResponseSeriesFlag               = "query-frontend.max-query-response-series"
 	RequestRateFlag                          = "distributor.request-rate-limit"
 	RequestBurstSizeFlag                     = "distributor.request-burst-size"
 	IngestionRateFlag                        = "distributor.ingestion-rate-limit"
@@ -123,16 +127,23 @@ type Limits struct {
 	QueryShardingMaxRegexpSizeBytes      int            `yaml:"query_sharding_max_regexp_size_bytes" json:"query_sharding_max_regexp_size_bytes"`
 	SplitInstantQueriesByInterval        model.Duration `yaml:"split_instant_queries_by_interval" json:"split_instant_queries_by_interval" category:"experimental"`
 	QueryIngestersWithin                 model.Duration `yaml:"query_ingesters_within" json:"query_ingesters_within" category:"advanced"`
+	StoreGatewayHedgingLatencyPercentile float64        `yaml:"store_gateway_hedging_latency_percentile" json:"store_gateway_hedging_latency_percentile" category:"experimental"`
 
 	// Query-frontend limits.
-	MaxTotalQueryLength                    model.Duration  `yaml:"max_total_query_length" json:"max_total_query_length"`
-	ResultsCacheTTL                        model.Duration  `yaml:"results_cache_ttl" json:"results_cache_ttl"`
-	ResultsCacheTTLForOutOfOrderTimeWindow model.Duration  `yaml:"results_cache_ttl_for_out_of_order_time_window" json:"results_cache_ttl_for_out_of_order_time_window"`
-	ResultsCacheTTLForCardinalityQuery     model.Duration  `yaml:"results_cache_ttl_for_cardinality_query" json:"results_cache_ttl_for_cardinality_query"`
-	ResultsCacheTTLForLabelsQuery          model.Duration  `yaml:"results_cache_ttl_for_labels_query" json:"results_cache_ttl_for_labels_query"`
-	ResultsCacheForUnalignedQueryEnabled   bool            `yaml:"cache_unaligned_requests" json:"cache_unaligned_requests" category:"advanced"`
-	MaxQueryExpressionSizeBytes            int             `yaml:"max_query_expression_size_bytes" json:"max_query_expression_size_bytes"`
-	BlockedQueries                         []*BlockedQuery `yaml:"blocked_queries,omitempty" json:"blocked_queries,omitempty" doc:"nocli|description=List of queries to block." category:"experimental"`
+	MaxTotalQueryLength                    model.Duration         `yaml:"max_total_query_length" json:"max_total_query_length"`
+	ResultsCacheTTL                        model.Duration         `yaml:"results_cache_ttl" json:"results_cache_ttl"`
+	ResultsCacheTTLForOutOfOrderTimeWindow model.Duration         `yaml:"results_cache_ttl_for_out_of_order_time_window" json:"results_cache_ttl_for_out_of_order_time_window"`
+	ResultsCacheTTLForCardinalityQuery     model.Duration         `yaml:"results_cache_ttl_for_cardinality_query" json:"results_cache_ttl_for_cardinality_query"`
+	ResultsCacheTTLForLabelsQuery          model.Duration         `yaml:"results_cache_ttl_for_labels_query" json:"results_cache_ttl_for_labels_query"`
+	ResultsCacheForUnalignedQueryEnabled   bool                   `yaml:"cache_unaligned_requests" json:"cache_unaligned_requests" category:"advanced"`
+	MaxQueryExpressionSizeBytes            int                    `yaml:"max_query_expression_size_bytes" json:"max_query_expression_size_bytes"`
+	BlockedQueries                         []*BlockedQuery        `yaml:"blocked_queries,omitempty" json:"blocked_queries,omitempty" doc:"nocli|description=List of queries to block." category:"experimental"`
+	QueryHintsEnabled                      bool                   `yaml:"query_hints_enabled" json:"query_hints_enabled" category:"experimental"`
+	AllowedQueryPriorityClasses            flagext.StringSliceCSV `yaml:"allowed_query_priority_classes" json:"allowed_query_priority_classes" category:"experimental"`
+	MaxHedgedRequestsPerQuery              int                    `yaml:"max_hedged_requests_per_query" json:"max_hedged_requests_per_query" category:"experimental"`
+	QueryLogSampleRate                     float64                `yaml:"query_log_sample_rate" json:"query_log_sample_rate" category:"experimental"`
+	MaxQueryResponseSizeBytes              int                    `yaml:"max_query_response_size_bytes" json:"max_query_response_size_bytes" category:"experimental"`
+	MaxQueryResponseSeries                 int                    `yaml:"max_query_response_series" json:"max_query_response_series" category:"experimental"`
 
 	// Cardinality
 	CardinalityAnalysisEnabled                    bool `yaml:"cardinality_analysis_enabled" json:"cardinality_analysis_enabled"`
@@ -149,18 +160,24 @@ type Limits struct {
 	RulerSyncRulesOnChangesEnabled       bool           `yaml:"ruler_sync_rules_on_changes_enabled" json:"ruler_sync_rules_on_changes_enabled" category:"advanced"`
 
 	// Store-gateway.
-	StoreGatewayTenantShardSize int `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
+	StoreGatewayTenantShardSize                   int `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
+	StoreGatewayTenantMaxConcurrentSeriesRequests int `yaml:"store_gateway_tenant_max_concurrent_series_requests" json:"store_gateway_tenant_max_concurrent_series_requests" category:"experimental"`
 
 	// Compactor.
-	CompactorBlocksRetentionPeriod        model.Duration `yaml:"compactor_blocks_retention_period" json:"compactor_blocks_retention_period"`
-	CompactorSplitAndMergeShards          int            `yaml:"compactor_split_and_merge_shards" json:"compactor_split_and_merge_shards"`
-	CompactorSplitGroups                  int            `yaml:"compactor_split_groups" json:"compactor_split_groups"`
-	CompactorTenantShardSize              int            `yaml:"compactor_tenant_shard_size" json:"compactor_tenant_shard_size"`
-	CompactorPartialBlockDeletionDelay    model.Duration `yaml:"compactor_partial_block_deletion_delay" json:"compactor_partial_block_deletion_delay"`
-	CompactorBlockUploadEnabled           bool           `yaml:"compactor_block_upload_enabled" json:"compactor_block_upload_enabled"`
-	CompactorBlockUploadValidationEnabled bool           `yaml:"compactor_block_upload_validation_enabled" json:"compactor_block_upload_validation_enabled"`
-	CompactorBlockUploadVerifyChunks      bool           `yaml:"compactor_block_upload_verify_chunks" json:"compactor_block_upload_verify_chunks"`
-	CompactorBlockUploadMaxBlockSizeBytes int64          `yaml:"compactor_block_upload_max_block_size_bytes" json:"compactor_block_upload_max_block_size_bytes" category:"advanced"`
+	CompactorBlocksRetentionPeriod        model.Duration         `yaml:"compactor_blocks_retention_period" json:"compactor_blocks_retention_period"`
+	CompactorSplitAndMergeShards          int                    `yaml:"compactor_split_and_merge_shards" json:"compactor_split_and_merge_shards"`
+	CompactorSplitGroups                  int                    `yaml:"compactor_split_groups" json:"compactor_split_groups"`
+	CompactorTenantShardSize              int                    `yaml:"compactor_tenant_shard_size" json:"compactor_tenant_shard_size"`
+	CompactorPartialBlockDeletionDelay    model.Duration         `yaml:"compactor_partial_block_deletion_delay" json:"compactor_partial_block_deletion_delay"`
+	CompactorBlockUploadEnabled           bool                   `yaml:"compactor_block_upload_enabled" json:"compactor_block_upload_enabled"`
+	CompactorBlockUploadValidationEnabled bool                   `yaml:"compactor_block_upload_validation_enabled" json:"compactor_block_upload_validation_enabled"`
+	CompactorBlockUploadVerifyChunks      bool                   `yaml:"compactor_block_upload_verify_chunks" json:"compactor_block_upload_verify_chunks"`
+	CompactorBlockUploadMaxBlockSizeBytes int64                  `yaml:"compactor_block_upload_max_block_size_bytes" json:"compactor_block_upload_max_block_size_bytes" category:"advanced"`
+	CompactorBloomFilterLabelNames        flagext.StringSliceCSV `yaml:"compactor_bloom_filter_label_names" json:"compactor_bloom_filter_label_names" category:"experimental"`
+	CompactorDownsamplingEnabled          bool                   `yaml:"compactor_downsampling_enabled" json:"compactor_downsampling_enabled" category:"experimental"`
+	CompactorBlocksRetentionPeriod5m      model.Duration         `yaml:"compactor_blocks_retention_period_5m" json:"compactor_blocks_retention_period_5m" category:"experimental"`
+	CompactorBlocksRetentionPeriod1h      model.Duration         `yaml:"compactor_blocks_retention_period_1h" json:"compactor_blocks_retention_period_1h" category:"experimental"`
+	CompactorRetentionRules               []*RetentionRule       `yaml:"compactor_retention_rules,omitempty" json:"compactor_retention_rules,omitempty" doc:"nocli|description=List of retention rules applying to the series matching a selector, overriding the retention period of the blocks for such series. The first rule matching a series applies. Each rule has a 'selector', such as '{__name__=~\"debug_.*\"}', and a 'period', where 0 means the matching series are retained forever. Queriers don't return the samples of the series older than the retention period of their rule, and the compactor rewrites the blocks whose time range is past the retention period of a rule to remove the matching series. Blocks are deleted once past the retention period of all the rules and of the blocks." category:"experimental"`
 
 	// This config doesn't have a CLI flag registered here because they're registered in
 	// their own original config struct.
@@ -223,7 +240,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.MaxChunksPerQuery, MaxChunksPerQueryFlag, 2e6, "Maximum number of chunks that can be fetched in a single query from ingesters and long-term storage. This limit is enforced in the querier, ruler and store-gateway. 0 to disable.")
 	f.Float64Var(&l.MaxEstimatedChunksPerQueryMultiplier, MaxEstimatedChunksPerQueryMultiplierFlag, 0, "Maximum number of chunks estimated to be fetched in a single query from ingesters and long-term storage, as a multiple of -"+MaxChunksPerQueryFlag+". This limit is enforced in the querier. Must be greater than or equal to 1, or 0 to disable.")
 	f.IntVar(&l.MaxFetchedSeriesPerQuery, MaxSeriesPerQueryFlag, 0, "The maximum number of unique series for which a query can fetch samples from each ingesters and storage. This limit is enforced in the querier, ruler and store-gateway. 0 to disable")
-	f.IntVar(&l.MaxFetchedChunkBytesPerQuery, MaxChunkBytesPerQueryFlag, 0, "The maximum size of all chunks in bytes that a query can fetch from each ingester and storage. This limit is enforced in the querier and ruler. 0 to disable.")
+	f.IntVar(&l.MaxFetchedChunkBytesPerQuery, MaxChunkBytesPerQueryFlag, 0, "The maximum size of all chunks in bytes that a query can fetch from each ingester and storage. This limit is enforced in the querier, ruler and store-gateway. 0 to disable.")
 	f.Var(&l.MaxPartialQueryLength, maxPartialQueryLengthFlag, "Limit the time range for partial queries at the querier level.")
 	f.Var(&l.MaxQueryLookback, "querier.max-query-lookback", "Limit how long back data (series and metadata) can be queried, up until <lookback> duration ago. This limit is enforced in the query-frontend, querier and ruler. If the requested time range is outside the allowed range, the request will not fail but will be manipulated to only query data within the allowed time range. 0 to disable.")
 	f.IntVar(&l.MaxQueryParallelism, "querier.max-query-parallelism", 14, "Maximum number of split (by time) or partial (by shard) queries that will be scheduled in parallel by the query-frontend for a single input query. This limit is introduced to have a fairer query scheduling and avoid a single query over a large time range saturating all available queriers.")
@@ -241,6 +258,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&l.SplitInstantQueriesByInterval, "query-frontend.split-instant-queries-by-interval", "Split instant queries by an interval and execute in parallel. 0 to disable it.")
 	_ = l.QueryIngestersWithin.Set("13h")
 	f.Var(&l.QueryIngestersWithin, QueryIngestersWithinFlag, "Maximum lookback beyond which queries are not sent to ingester. 0 means all queries are sent to ingester.")
+	f.Float64Var(&l.StoreGatewayHedgingLatencyPercentile, StoreGatewayHedgingLatencyPercentileFlag, 0, "Percentile of the latest store-gateway series requests latencies of the tenant, after which the querier sends the same request to another store-gateway replica and uses the first response received. Must be between 0 and 100, or 0 to disable hedging.")
 
 	_ = l.RulerEvaluationDelay.Set("1m")
 	f.Var(&l.RulerEvaluationDelay, "ruler.evaluation-delay-duration", "Duration to delay the evaluation of rules to ensure the underlying metrics have been pushed.")
@@ -261,6 +279,10 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&l.CompactorBlockUploadValidationEnabled, "compactor.block-upload-validation-enabled", true, "Enable block upload validation for the tenant.")
 	f.BoolVar(&l.CompactorBlockUploadVerifyChunks, "compactor.block-upload-verify-chunks", true, "Verify chunks when uploading blocks via the upload API for the tenant.")
 	f.Int64Var(&l.CompactorBlockUploadMaxBlockSizeBytes, "compactor.block-upload-max-block-size-bytes", 0, "Maximum size in bytes of a block that is allowed to be uploaded or validated. 0 = no limit.")
+	f.Var(&l.CompactorBloomFilterLabelNames, "compactor.bloom-filter-label-names", "Comma-separated list of label names for which the compactor writes a bloom filter of the label values alongside each compacted block. Store-gateways use the bloom filters to skip the blocks which can't contain series matching the equal matchers of a query on these labels. Best suited for high-cardinality labels, such as pod names.")
+	f.BoolVar(&l.CompactorDownsamplingEnabled, "compactor.downsampling-enabled", false, "Enable downsampling of the tenant's blocks. The compactor downsamples the blocks compacted to the largest block range to 5m resolution blocks, and these to 1h resolution blocks. Queriers read the coarsest resolution compatible with the step of range queries.")
+	f.Var(&l.CompactorBlocksRetentionPeriod5m, "compactor.blocks-retention-period-5m", "Delete 5m resolution downsampled blocks containing samples older than the specified retention period. Also used by query-frontend to avoid querying beyond the retention period, when downsampling is enabled. 0 to disable.")
+	f.Var(&l.CompactorBlocksRetentionPeriod1h, "compactor.blocks-retention-period-1h", "Delete 1h resolution downsampled blocks containing samples older than the specified retention period. Also used by query-frontend to avoid querying beyond the retention period, when downsampling is enabled. 0 to disable.")
 
 	// Query-frontend.
 	f.Var(&l.MaxTotalQueryLength, maxTotalQueryLengthFlag, "Limit the total query time range (end - start time). This limit is enforced in the query-frontend on the received query.")
@@ -272,9 +294,16 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&l.ResultsCacheTTLForLabelsQuery, "query-frontend.results-cache-ttl-for-labels-query", "Time to live duration for cached label names and label values query results. The value 0 disables the cache.")
 	f.BoolVar(&l.ResultsCacheForUnalignedQueryEnabled, "query-frontend.cache-unaligned-requests", false, "Cache requests that are not step-aligned.")
 	f.IntVar(&l.MaxQueryExpressionSizeBytes, maxQueryExpressionSizeBytesFlag, 0, "Max size of the raw query, in bytes. 0 to not apply a limit to the size of the query.")
+	f.BoolVar(&l.QueryHintsEnabled, "query-frontend.query-hints-enabled", false, "Allow the tenant to override the query-frontend behaviour on a per-request basis via the Query-Hints header or the query_hints request parameter, for example to disable the results cache, force the number of shards, disable splitting or enable the per-step stats. The Split-Control and Stats-Control request headers are ignored unless enabled.")
+	f.Var(&l.AllowedQueryPriorityClasses, "query-frontend.allowed-query-priority-classes", "Comma-separated list of query priority classes, among the ones configured with -query-frontend.query-priority-classes, the tenant's clients are allowed to request via the X-Query-Priority-Class header. Queries requesting a class not allowed for the tenant get the lowest priority.")
+	f.Float64Var(&l.QueryLogSampleRate, queryLogSampleRateFlag, 1, "Fraction of the tenant's queries written to the query log, between 0 and 1, when the query log is enabled with -query-frontend.query-log.file-path. A query federated across multiple tenants is written with the highest sample rate of its tenants.")
+	f.IntVar(&l.MaxQueryResponseSizeBytes, maxQueryResponseSizeBytesFlag, 0, "Max size of the response returned by the query-frontend to range queries, instant queries, series, label names and label values requests, and cardinality requests, in bytes. Range and instant query responses are encoded incrementally and the encoding is aborted as soon as the limit is exceeded. Requests exceeding the limit fail with the HTTP status code 422. 0 to not apply a limit to the size of the response.")
+	f.IntVar(&l.MaxQueryResponseSeries, maxQueryResponseSeriesFlag, 0, "Max number of series returned by the query-frontend to range queries, instant queries and series requests. Requests exceeding the limit fail with the HTTP status code 422. Without a limit on the size of the response, series responses larger than 256MiB fail too. 0 to not apply a limit to the number of series in the response.")
+	f.IntVar(&l.MaxHedgedRequestsPerQuery, "query-frontend.max-hedged-requests-per-query", 0, "Maximum number of sharded queries of a single query which can be hedged, when they're straggling compared to the other sharded queries of the same query. Hedging is only supported when the query-scheduler is in use. 0 to disable hedging.")
 
 	// Store-gateway.
 	f.IntVar(&l.StoreGatewayTenantShardSize, "store-gateway.tenant-shard-size", 0, "The tenant's shard size, used when store-gateway sharding is enabled. Value of 0 disables shuffle sharding for the tenant, that is all tenant blocks are sharded across all store-gateway replicas.")
+	f.IntVar(&l.StoreGatewayTenantMaxConcurrentSeriesRequests, "store-gateway.tenant-max-concurrent-series-requests", 0, "Maximum number of series requests a tenant can run concurrently in each store-gateway. The requests over the limit are rejected with a retryable error, and the querier retries them on another store-gateway replica. 0 to disable.")
 
 	// Alertmanager.
 	f.Var(&l.AlertmanagerReceiversBlockCIDRNetworks, "alertmanager.receivers-firewall-block-cidr-networks", "Comma-separated list of network CIDRs to block in Alertmanager receiver integrations.")
@@ -359,6 +388,14 @@ func (l *Limits) validate() error {
 		return errors.New("invalid value for -" + MaxEstimatedChunksPerQueryMultiplierFlag + ": must be 0 or greater than or equal to 1")
 	}
 
+	if l.QueryLogSampleRate < 0 || l.QueryLogSampleRate > 1 {
+		return errors.New("invalid value for -" + queryLogSampleRateFlag + ": must be between 0 and 1")
+	}
+
+	if l.StoreGatewayHedgingLatencyPercentile < 0 || l.StoreGatewayHedgingLatencyPercentile > 100 {
+		return errors.New("invalid value for -" + StoreGatewayHedgingLatencyPercentileFlag + ": must be between 0 and 100")
+	}
+
 	return nil
 }
 
@@ -667,6 +704,57 @@ func (o *Overrides) CompactorBlocksRetentionPeriod(userID string) time.Duration
 	return time.Duration(o.getOverridesForUser(userID).CompactorBlocksRetentionPeriod)
 }
 
+// CompactorDownsamplingEnabled returns whether downsampling is enabled for a given user.
+func (o *Overrides) CompactorDownsamplingEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).CompactorDownsamplingEnabled
+}
+
+// CompactorBlocksRetentionPeriod5m returns the retention period of the 5m resolution blocks for a given user.
+func (o *Overrides) CompactorBlocksRetentionPeriod5m(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).CompactorBlocksRetentionPeriod5m)
+}
+
+// CompactorBlocksRetentionPeriod1h returns the retention period of the 1h resolution blocks for a given user.
+func (o *Overrides) CompactorBlocksRetentionPeriod1h(userID string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).CompactorBlocksRetentionPeriod1h)
+}
+
+// CompactorRetentionRules returns the retention rules applying to the series matching a selector for a given user.
+func (o *Overrides) CompactorRetentionRules(userID string) []*RetentionRule {
+	return o.getOverridesForUser(userID).CompactorRetentionRules
+}
+
+// CompactorBlocksMaxRetentionPeriod returns the retention period of the blocks of any resolution for a given user,
+// that is the retention period of the raw blocks, unless downsampling is enabled and the downsampled blocks are
+// retained longer, or a retention rule retains the matching series longer. 0 means unlimited.
+func (o *Overrides) CompactorBlocksMaxRetentionPeriod(userID string) time.Duration {
+	limits := o.getOverridesForUser(userID)
+
+	periods := []model.Duration{limits.CompactorBlocksRetentionPeriod}
+	if limits.CompactorDownsamplingEnabled {
+		periods = append(periods, limits.CompactorBlocksRetentionPeriod5m, limits.CompactorBlocksRetentionPeriod1h)
+	}
+	for _, r := range limits.CompactorRetentionRules {
+		periods = append(periods, r.Period)
+	}
+
+	return MaxRetentionPeriod(periods...)
+}
+
+// MaxRetentionPeriod returns the longest of the given retention periods, where 0 means unlimited.
+func MaxRetentionPeriod(periods ...model.Duration) time.Duration {
+	var retention time.Duration
+	for _, r := range periods {
+		if r == 0 {
+			return 0
+		}
+		if time.Duration(r) > retention {
+			retention = time.Duration(r)
+		}
+	}
+	return retention
+}
+
 // CompactorSplitAndMergeShards returns the number of shards to use when splitting blocks.
 func (o *Overrides) CompactorSplitAndMergeShards(userID string) int {
 	return o.getOverridesForUser(userID).CompactorSplitAndMergeShards
@@ -711,6 +799,11 @@ func (o *Overrides) CompactorBlockUploadMaxBlockSizeBytes(userID string) int64 {
 	return o.getOverridesForUser(userID).CompactorBlockUploadMaxBlockSizeBytes
 }
 
+// CompactorBloomFilterLabelNames returns the label names for which the compactor writes a bloom filter of the label values for a given user.
+func (o *Overrides) CompactorBloomFilterLabelNames(userID string) []string {
+	return o.getOverridesForUser(userID).CompactorBloomFilterLabelNames
+}
+
 // MetricRelabelConfigs returns the metric relabel configs for a given user.
 func (o *Overrides) MetricRelabelConfigs(userID string) []*relabel.Config {
 	return o.getOverridesForUser(userID).MetricRelabelConfigs
@@ -751,11 +844,22 @@ func (o *Overrides) RulerSyncRulesOnChangesEnabled(userID string) bool {
 	return o.getOverridesForUser(userID).RulerSyncRulesOnChangesEnabled
 }
 
+// StoreGatewayHedgingLatencyPercentile returns the percentile of the latest store-gateway series requests latencies
+// of the tenant, after which the series requests are hedged.
+func (o *Overrides) StoreGatewayHedgingLatencyPercentile(userID string) float64 {
+	return o.getOverridesForUser(userID).StoreGatewayHedgingLatencyPercentile
+}
+
 // StoreGatewayTenantShardSize returns the store-gateway shard size for a given user.
 func (o *Overrides) StoreGatewayTenantShardSize(userID string) int {
 	return o.getOverridesForUser(userID).StoreGatewayTenantShardSize
 }
 
+// StoreGatewayTenantMaxConcurrentSeriesRequests returns the max number of series requests a tenant can run concurrently in each store-gateway.
+func (o *Overrides) StoreGatewayTenantMaxConcurrentSeriesRequests(userID string) int {
+	return o.getOverridesForUser(userID).StoreGatewayTenantMaxConcurrentSeriesRequests
+}
+
 // MaxHAClusters returns maximum number of clusters that HA tracker will track for a user.
 func (o *Overrides) MaxHAClusters(user string) int {
 	return o.getOverridesForUser(user).HAMaxClusters
@@ -880,6 +984,36 @@ func (o *Overrides) ResultsCacheForUnalignedQueryEnabled(userID string) bool {
 	return o.getOverridesForUser(userID).ResultsCacheForUnalignedQueryEnabled
 }
 
+// QueryHintsEnabled returns whether the tenant is allowed to override the query-frontend behaviour via query hints.
+func (o *Overrides) QueryHintsEnabled(userID string) bool {
+	return o.getOverridesForUser(userID).QueryHintsEnabled
+}
+
+// AllowedQueryPriorityClasses returns the query priority classes the tenant's clients are allowed to request.
+func (o *Overrides) AllowedQueryPriorityClasses(userID string) []string {
+	return o.getOverridesForUser(userID).AllowedQueryPriorityClasses
+}
+
+// MaxHedgedRequestsPerQuery returns the maximum number of sharded queries of a single query which can be hedged.
+func (o *Overrides) MaxHedgedRequestsPerQuery(userID string) int {
+	return o.getOverridesForUser(userID).MaxHedgedRequestsPerQuery
+}
+
+// MaxQueryResponseSizeBytes returns the limit of the size of the query-frontend responses, in bytes.
+func (o *Overrides) MaxQueryResponseSizeBytes(userID string) int {
+	return o.getOverridesForUser(userID).MaxQueryResponseSizeBytes
+}
+
+// MaxQueryResponseSeries returns the limit of the number of series in the query-frontend responses.
+func (o *Overrides) MaxQueryResponseSeries(userID string) int {
+	return o.getOverridesForUser(userID).MaxQueryResponseSeries
+}
+
+// QueryLogSampleRate returns the fraction of the tenant's queries written to the query-frontend query log.
+func (o *Overrides) QueryLogSampleRate(userID string) float64 {
+	return o.getOverridesForUser(userID).QueryLogSampleRate
+}
+
 func (o *Overrides) getOverridesForUser(userID string) *Limits {
 	if o.tenantLimits != nil {
 		l := o.tenantLimits.ByUserID(userID)
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -408,6 +409,102 @@ func TestMaxPartialQueryLengthWithoutDefault(t *testing.T) {
 	assert.Equal(t, time.Duration(0), ov.MaxPartialQueryLength("tenant-b"))
 }
 
+func TestCompactorBlocksMaxRetentionPeriod(t *testing.T) {
+	tenantLimits := map[string]*Limits{
+		"downsampling-disabled": {
+			CompactorBlocksRetentionPeriod:   model.Duration(24 * time.Hour),
+			CompactorBlocksRetentionPeriod5m: model.Duration(48 * time.Hour),
+			CompactorBlocksRetentionPeriod1h: model.Duration(72 * time.Hour),
+		},
+		"downsampling-enabled": {
+			CompactorBlocksRetentionPeriod:   model.Duration(24 * time.Hour),
+			CompactorDownsamplingEnabled:     true,
+			CompactorBlocksRetentionPeriod5m: model.Duration(48 * time.Hour),
+			CompactorBlocksRetentionPeriod1h: model.Duration(72 * time.Hour),
+		},
+		"downsampled-blocks-retention-unlimited": {
+			CompactorBlocksRetentionPeriod:   model.Duration(24 * time.Hour),
+			CompactorDownsamplingEnabled:     true,
+			CompactorBlocksRetentionPeriod5m: model.Duration(48 * time.Hour),
+		},
+		"raw-blocks-retention-unlimited": {
+			CompactorDownsamplingEnabled:     true,
+			CompactorBlocksRetentionPeriod5m: model.Duration(48 * time.Hour),
+			CompactorBlocksRetentionPeriod1h: model.Duration(72 * time.Hour),
+		},
+		"retention-rules": {
+			CompactorBlocksRetentionPeriod: model.Duration(24 * time.Hour),
+			CompactorRetentionRules: []*RetentionRule{
+				mustNewRetentionRule(t, `{__name__=~"debug_.*"}`, 12*time.Hour),
+				mustNewRetentionRule(t, `{__name__=~"slo_.*"}`, 96*time.Hour),
+			},
+		},
+		"retention-rules-unlimited": {
+			CompactorBlocksRetentionPeriod: model.Duration(24 * time.Hour),
+			CompactorRetentionRules: []*RetentionRule{
+				mustNewRetentionRule(t, `{__name__=~"slo_.*"}`, 0),
+			},
+		},
+	}
+
+	ov, err := NewOverrides(Limits{}, NewMockTenantLimits(tenantLimits))
+	require.NoError(t, err)
+
+	assert.Equal(t, 24*time.Hour, ov.CompactorBlocksMaxRetentionPeriod("downsampling-disabled"))
+	assert.Equal(t, 72*time.Hour, ov.CompactorBlocksMaxRetentionPeriod("downsampling-enabled"))
+	assert.Equal(t, time.Duration(0), ov.CompactorBlocksMaxRetentionPeriod("downsampled-blocks-retention-unlimited"))
+	assert.Equal(t, time.Duration(0), ov.CompactorBlocksMaxRetentionPeriod("raw-blocks-retention-unlimited"))
+	assert.Equal(t, 96*time.Hour, ov.CompactorBlocksMaxRetentionPeriod("retention-rules"))
+	assert.Equal(t, time.Duration(0), ov.CompactorBlocksMaxRetentionPeriod("retention-rules-unlimited"))
+	assert.Equal(t, time.Duration(0), ov.CompactorBlocksMaxRetentionPeriod("other"))
+}
+
+func TestCompactorRetentionRules(t *testing.T) {
+	t.Run("valid rules", func(t *testing.T) {
+		inputYAML := `
+compactor_retention_rules:
+  - selector: '{__name__=~"debug_.*"}'
+    period: 14d
+  - selector: '{__name__=~"slo_.*", env="prod"}'
+    period: 0
+`
+		var limitsYAML Limits
+		require.NoError(t, yaml.Unmarshal([]byte(inputYAML), &limitsYAML))
+
+		data, err := json.Marshal(limitsYAML)
+		require.NoError(t, err)
+		var limitsJSON Limits
+		require.NoError(t, json.Unmarshal(data, &limitsJSON))
+
+		for _, limits := range []Limits{limitsYAML, limitsJSON} {
+			rules := limits.CompactorRetentionRules
+			require.Len(t, rules, 2)
+			assert.Equal(t, model.Duration(14*24*time.Hour), rules[0].Period)
+			assert.Equal(t, model.Duration(0), rules[1].Period)
+
+			assert.Equal(t, rules[0], MatchingRetentionRule(rules, labels.FromStrings("__name__", "debug_requests")))
+			assert.Equal(t, rules[1], MatchingRetentionRule(rules, labels.FromStrings("__name__", "slo_requests", "env", "prod")))
+			assert.Nil(t, MatchingRetentionRule(rules, labels.FromStrings("__name__", "slo_requests", "env", "dev")))
+		}
+	})
+
+	t.Run("invalid selector", func(t *testing.T) {
+		inputYAML := `
+compactor_retention_rules:
+  - selector: '{__name__=~"debug_.*"'
+    period: 14d
+`
+		var limits Limits
+		require.ErrorContains(t, yaml.Unmarshal([]byte(inputYAML), &limits), "invalid retention rule selector")
+	})
+}
+
+func mustNewRetentionRule(t *testing.T, selector string, period time.Duration) *RetentionRule {
+	r, err := NewRetentionRule(selector, period)
+	require.NoError(t, err)
+	return r
+}
+
 func TestAlertmanagerNotificationLimits(t *testing.T) {
 	for name, tc := range map[string]struct {
 		inputYAML         string
@@ -675,6 +772,31 @@ func TestUnmarshalMaxEstimatedChunksPerQuery(t *testing.T) {
 	}
 }
 
+func TestUnmarshalStoreGatewayHedgingLatencyPercentile(t *testing.T) {
+	testCases := map[string]bool{
+		"-1":    false,
+		"0":     true,
+		"95":    true,
+		"99.9":  true,
+		"100":   true,
+		"100.1": false,
+	}
+
+	for value, shouldBeValid := range testCases {
+		t.Run(value, func(t *testing.T) {
+			limits := Limits{}
+			cfg := "store_gateway_hedging_latency_percentile: " + value
+			err := yaml.Unmarshal([]byte(cfg), &limits)
+
+			if shouldBeValid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, "invalid value for -querier.store-gateway-hedging-latency-percentile: must be between 0 and 100")
+			}
+		})
+	}
+}
+
 type structExtension struct {
 	Foo int `yaml:"foo" json:"foo"`
 }
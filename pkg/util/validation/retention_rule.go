@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"gopkg.in/yaml.v3"
+)
+
+// RetentionRule is a retention period applying to the series matching a selector.
+type RetentionRule struct {
+	Selector string         `yaml:"selector" json:"selector"`
+	Period   model.Duration `yaml:"period" json:"period"`
+
+	matchers []*labels.Matcher
+}
+
+// NewRetentionRule returns a RetentionRule with the given selector and period.
+func NewRetentionRule(selector string, period time.Duration) (*RetentionRule, error) {
+	r := &RetentionRule{Selector: selector, Period: model.Duration(period)}
+	return r, r.parse()
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *RetentionRule) UnmarshalYAML(value *yaml.Node) error {
+	type plain RetentionRule
+	if err := value.Decode((*plain)(r)); err != nil {
+		return err
+	}
+	return r.parse()
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (r *RetentionRule) UnmarshalJSON(data []byte) error {
+	type plain RetentionRule
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+	return r.parse()
+}
+
+func (r *RetentionRule) parse() error {
+	matchers, err := parser.ParseMetricSelector(r.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid retention rule selector %q: %w", r.Selector, err)
+	}
+	if r.Period < 0 {
+		return fmt.Errorf("invalid retention rule period for selector %q: must be greater than or equal to 0", r.Selector)
+	}
+	r.matchers = matchers
+	return nil
+}
+
+// Matchers returns the matchers of the rule selector.
+func (r *RetentionRule) Matchers() []*labels.Matcher {
+	return r.matchers
+}
+
+// Matches returns whether the series with the given labels matches the rule selector.
+func (r *RetentionRule) Matches(series labels.Labels) bool {
+	for _, m := range r.matchers {
+		if !m.Matches(series.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchingRetentionRule returns the first of the rules matching the series with the given labels, or nil if none does.
+func MatchingRetentionRule(rules []*RetentionRule, series labels.Labels) *RetentionRule {
+	for _, r := range rules {
+		if r.Matches(series) {
+			return r
+		}
+	}
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/prometheus/prometheus/model/relabel"
 
 	"github.com/grafana/mimir/pkg/ingester/activeseries"
+	"github.com/grafana/mimir/pkg/querier/clusterfederation"
 	"github.com/grafana/mimir/pkg/storage/tsdb"
 	"github.com/grafana/mimir/pkg/util/fieldcategory"
 	"github.com/grafana/mimir/pkg/util/validation"
@@ -348,6 +349,10 @@ func getFieldCustomType(t reflect.Type) (string, bool) {
 		return "relabel_config...", true
 	case reflect.TypeOf([]*validation.BlockedQuery{}).String():
 		return "blocked_queries_config...", true
+	case reflect.TypeOf([]*validation.RetentionRule{}).String():
+		return "retention_rules_config...", true
+	case reflect.TypeOf([]clusterfederation.RemoteClusterConfig{}).String():
+		return "remote_cluster_config...", true
 	case reflect.TypeOf(activeseries.CustomTrackersConfig{}).String():
 		return "map of tracker name (string) to matcher (string)", true
 	default:
@@ -430,6 +435,10 @@ func getCustomFieldType(t reflect.Type) (string, bool) {
 		return "relabel_config...", true
 	case reflect.TypeOf([]*validation.BlockedQuery{}).String():
 		return "blocked_queries_config...", true
+	case reflect.TypeOf([]*validation.RetentionRule{}).String():
+		return "retention_rules_config...", true
+	case reflect.TypeOf([]clusterfederation.RemoteClusterConfig{}).String():
+		return "remote_cluster_config...", true
 	case reflect.TypeOf(activeseries.CustomTrackersConfig{}).String():
 		return "map of tracker name (string) to matcher (string)", true
 	default:
@@ -463,6 +472,10 @@ func ReflectType(typ string) reflect.Type {
 		return reflect.TypeOf([]*relabel.Config{})
 	case "blocked_queries_config...":
 		return reflect.TypeOf([]*validation.BlockedQuery{})
+	case "retention_rules_config...":
+		return reflect.TypeOf([]*validation.RetentionRule{})
+	case "remote_cluster_config...":
+		return reflect.TypeOf([]clusterfederation.RemoteClusterConfig{})
 	case "map of string to float64":
 		return reflect.TypeOf(map[string]float64{})
 	case "list of durations":